@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/flags"
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
 	"github.com/universal-tool-calling-protocol/go-utcp"
@@ -25,25 +28,101 @@ type Agent struct {
 	systemPrompt string
 	contextLimit int
 
+	promptFragments  []PromptFragment
+	contextProviders []namedContextProvider
+
 	toolCatalog       ToolCatalog
 	subAgentDirectory SubAgentDirectory
 	UTCPClient        utcp.UtcpClientInterface
 
 	mu sync.Mutex
 
-	toolMu           sync.RWMutex
-	toolSpecsCache   []tools.Tool
-	toolSpecsExpiry  time.Time
-	toolPromptCache  string
-	toolPromptKey    string
-	toolPromptExpiry time.Time
+	toolMu          sync.RWMutex
+	toolSpecsCache  []tools.Tool
+	toolSpecsExpiry time.Time
+	warmUTCPSpecs   []tools.Tool                  // see Options.UTCPToolSpecs
+	toolPrompts     *cache.LRUCache               // provider -> toolPromptSegment
+	toolTemplates   map[string]*template.Template // see Options.ToolTemplates
+
+	turns         *cache.LRUCache // turn ID -> Turn, for Feedback
+	subAgentCache *cache.LRUCache // see subAgentCacheKey
+
+	sessionsMu      sync.Mutex
+	sessionOpenings map[string][]Turn   // untitled session -> its first turns
+	toolProviders   map[string][]string // session -> preferred tool providers
+	sessionTitling  sync.WaitGroup
 
 	Shared   *memory.SharedSession
 	CodeMode *codemode.CodeModeUTCP
+	// CodeRuns, when set, persists CodeMode runs so a failed one can be
+	// continued with ResumeCodeRun.
+	CodeRuns CodeRunStore
+	// Budget caps each turn's tool calls, tokens and cost; see TurnBudget.
+	Budget TurnBudget
+	// Quotas, when set, limits usage across turns per session and tenant;
+	// see Quotas.
+	Quotas *Quotas
+	// ReadOnly blocks memory writes and every tool not marked
+	// ToolPolicy.ReadOnly, while retrieval and generation work as usual.
+	ReadOnly bool
 
 	AllowUnsafeTools bool
-	Guardrails       *OutputGuardrails
-	InputGuardrails  *InputGuardrails
+	// ToolPolicies sets timeouts and retries for tools by name, typically
+	// UTCP tools; a local tool's ToolSpec.Policy takes precedence.
+	ToolPolicies map[string]ToolPolicy
+	// ToolNamespaces resolves unqualified names shared by several tool
+	// providers; see ToolNamespacePolicy.
+	ToolNamespaces  ToolNamespacePolicy
+	Guardrails      *OutputGuardrails
+	InputGuardrails *InputGuardrails
+	FeedbackSink    FeedbackSink
+	// Prompts, when set, picks the system prompt per session, for example
+	// to canary an evolved prompt on a share of sessions.
+	Prompts PromptSelector
+	// PromptTemplate renders the final completion prompt;
+	// DefaultPromptTemplate when nil.
+	PromptTemplate PromptTemplate
+	// PromptAdapter lays out the turn prompt and the tool-planning prompts
+	// for the model; models.PromptAdapterFor(Model) when nil.
+	PromptAdapter models.PromptAdapter
+	// RetrievalPlanner routes each query to short-term memory, long-term
+	// memory or both; see HeuristicRetrievalPlanner.
+	RetrievalPlanner RetrievalPlanner
+	// Sessions, when set, tracks each session's turns and, after
+	// SessionTitleAfter turns, a title and topic tags from SessionTitler
+	// (a ModelSessionTitler on the agent's model when nil).
+	Sessions          SessionStore
+	SessionTitleAfter int
+	SessionTitler     SessionTitler
+	// SystemPromptBudget, when positive, caps the assembled system prompt at
+	// that many characters by leaving out the last prompt fragments.
+	SystemPromptBudget int
+	// SubAgentTimeout bounds each delegated sub-agent run; output produced
+	// before the deadline is returned marked as partial.
+	SubAgentTimeout time.Duration
+	// SubAgentCacheTTL, when positive, caches complete sub-agent results per
+	// session, sub-agent and normalized instruction for that long, keeping
+	// at most SubAgentCacheSize entries.
+	SubAgentCacheTTL  time.Duration
+	SubAgentCacheSize int
+	// AutoDelegate lets the model delegate to sub-agents on its own, based on
+	// their descriptions, instead of requiring "subagent:<name>" commands.
+	// MaxDelegations bounds the delegations made for one turn.
+	AutoDelegate   bool
+	MaxDelegations int
+	// StopSequences end streamed completions before the first of them
+	// unless the caller set its own with models.WithStopSequences.
+	StopSequences []string
+	// Attachments, when set, routes the files of GenerateWithFiles; see
+	// AttachmentPolicy. Without it every file becomes an attachment memory.
+	Attachments *AttachmentPolicy
+	// Flags, when set, is consulted on every turn for toggles that
+	// override the fields above; see the flags package for the keys.
+	Flags flags.Provider
+
+	// Name and Description identify the agent in its Manifest.
+	Name        string
+	Description string
 }
 
 // Options configure a new Agent.
@@ -58,10 +137,50 @@ type Options struct {
 	SubAgentDirectory SubAgentDirectory
 	UTCPClient        utcp.UtcpClientInterface
 	CodeMode          *codemode.CodeModeUTCP
+	CodeRuns          CodeRunStore
+	Budget            TurnBudget
+	Quotas            *Quotas
+	ReadOnly          bool
 	Shared            *memory.SharedSession
 	AllowUnsafeTools  bool
+	ToolPolicies      map[string]ToolPolicy
+	// ToolTemplates are text/template sources, by tool name, that turn a
+	// tool's structured output into prose before the model sees it, e.g.
+	// "{{.Data.city}} is {{.Data.temp_c}}°C and {{.Data.summary}}." The
+	// decoded output is .Data; .Raw, .Tool and .Arguments are also set. An
+	// output the template cannot render is passed on unchanged.
+	ToolTemplates     map[string]string
+	ToolNamespaces    ToolNamespacePolicy
 	Guardrails        *OutputGuardrails
 	InputGuardrails   *InputGuardrails
+	FeedbackSink      FeedbackSink
+	Prompts           PromptSelector
+	PromptTemplate    PromptTemplate
+	PromptAdapter     models.PromptAdapter
+	RetrievalPlanner  RetrievalPlanner
+	Sessions          SessionStore
+	SessionTitleAfter int
+	SessionTitler     SessionTitler
+	// PromptFragments are layered under SystemPrompt; see PromptFragment.
+	PromptFragments []PromptFragment
+	// ContextProviders add dynamic sections to every prompt, in name order;
+	// see ContextProvider.
+	ContextProviders   map[string]ContextProvider
+	SystemPromptBudget int
+	SubAgentTimeout    time.Duration
+	SubAgentCacheTTL   time.Duration
+	SubAgentCacheSize  int
+	AutoDelegate       bool
+	MaxDelegations     int
+	Name               string
+	Description        string
+	Flags              flags.Provider
+	StopSequences      []string
+	Attachments        *AttachmentPolicy
+	// UTCPToolSpecs are UTCP tools discovered ahead of time, e.g. by a kit
+	// warmup. The first ToolSpecs call uses them instead of searching
+	// UTCPClient; later refreshes search again.
+	UTCPToolSpecs []tools.Tool
 }
 
 // New creates an Agent with the provided options.
@@ -119,25 +238,66 @@ func New(opts Options) (*Agent, error) {
 		}
 	}
 
+	toolTemplates, err := compileToolTemplates(opts.ToolTemplates)
+	if err != nil {
+		return nil, err
+	}
+
 	a := &Agent{
-		model:             opts.Model,
-		memory:            opts.Memory,
-		systemPrompt:      systemPrompt,
-		contextLimit:      ctxLimit,
-		toolCatalog:       toolCatalog,
-		subAgentDirectory: subAgentDirectory,
-		UTCPClient:        opts.UTCPClient,
-		Shared:            opts.Shared,
-		CodeMode:          opts.CodeMode,
-		AllowUnsafeTools:  opts.AllowUnsafeTools,
-		Guardrails:        opts.Guardrails,
-		InputGuardrails:   opts.InputGuardrails,
+		model:              opts.Model,
+		memory:             opts.Memory,
+		systemPrompt:       systemPrompt,
+		promptFragments:    mergePromptFragments(nil, opts.PromptFragments...),
+		contextProviders:   sortedContextProviders(opts.ContextProviders),
+		SystemPromptBudget: opts.SystemPromptBudget,
+		contextLimit:       ctxLimit,
+		toolCatalog:        toolCatalog,
+		subAgentDirectory:  subAgentDirectory,
+		UTCPClient:         opts.UTCPClient,
+		Shared:             opts.Shared,
+		CodeMode:           opts.CodeMode,
+		CodeRuns:           opts.CodeRuns,
+		Budget:             opts.Budget,
+		Quotas:             opts.Quotas,
+		ReadOnly:           opts.ReadOnly,
+		AllowUnsafeTools:   opts.AllowUnsafeTools,
+		ToolPolicies:       opts.ToolPolicies,
+		ToolNamespaces:     opts.ToolNamespaces,
+		Guardrails:         opts.Guardrails,
+		InputGuardrails:    opts.InputGuardrails,
+		FeedbackSink:       opts.FeedbackSink,
+		Prompts:            opts.Prompts,
+		PromptTemplate:     opts.PromptTemplate,
+		PromptAdapter:      opts.PromptAdapter,
+		RetrievalPlanner:   opts.RetrievalPlanner,
+		Sessions:           opts.Sessions,
+		SessionTitleAfter:  opts.SessionTitleAfter,
+		SessionTitler:      opts.SessionTitler,
+		SubAgentTimeout:    opts.SubAgentTimeout,
+		SubAgentCacheTTL:   opts.SubAgentCacheTTL,
+		SubAgentCacheSize:  opts.SubAgentCacheSize,
+		AutoDelegate:       opts.AutoDelegate,
+		MaxDelegations:     opts.MaxDelegations,
+		Flags:              opts.Flags,
+		StopSequences:      opts.StopSequences,
+		Attachments:        opts.Attachments,
+		Name:               opts.Name,
+		Description:        opts.Description,
+		turns:              cache.NewLRUCache(turnHistorySize, turnHistoryTTL),
+		toolTemplates:      toolTemplates,
+		warmUTCPSpecs:      append([]tools.Tool(nil), opts.UTCPToolSpecs...),
 	}
 
 	return a, nil
 }
 
 func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any, error) {
+	started := time.Now()
+	ctx = a.withTurnBudget(ctx)
+	ctx, err := a.beginQuotaTurn(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
 	if a.InputGuardrails != nil {
 		transformed, err := a.InputGuardrails.ValidateAndTransform(ctx, userInput)
 		if err != nil {
@@ -154,7 +314,11 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 	// ---------------------------------------------
 	// 0. DIRECT TOOL INVOCATION (bypass everything)
 	// ---------------------------------------------
-	if toolName, args, ok := a.detectDirectToolCall(trimmed); ok {
+	toolName, args, ok, ambiguous := a.detectDirectToolCall(sessionID, trimmed)
+	if ambiguous != nil {
+		return ambiguous.Prompt(), nil
+	}
+	if ok {
 		// It's a direct tool call, execute it.
 		result, err := a.executeTool(ctx, sessionID, toolName, args)
 		if err != nil {
@@ -178,7 +342,7 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 		if err != nil {
 			return "", err
 		}
-		a.storeMemory(sessionID, "subagent", out, meta)
+		a.storeMemory(ctx, sessionID, "subagent", out, meta)
 		return out, nil
 	}
 
@@ -210,7 +374,7 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 	// 2. CODEMODE (Go-like DSL)
 	// ---------------------------------------------
 	if a.CodeMode != nil {
-		handled, output, err := a.CodeMode.CallTool(ctx, userInput)
+		handled, output, err := a.callCodeMode(ctx, sessionID, userInput)
 		if err != nil {
 			return "", err
 		}
@@ -223,6 +387,7 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 	// 3. TOOL ORCHESTRATOR (normal UTCP tools)
 	// ---------------------------------------------
 	prefetchWG.Wait() // Ensure memory is ready for orchestrator
+	ctx = models.WithContextFingerprint(ctx, memoryFingerprint(records))
 	if handled, output, err := a.toolOrchestrator(ctx, sessionID, userInput, records); handled {
 		if err != nil {
 			return "", err
@@ -234,7 +399,7 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 	// ---------------------------------------------
 	// 5. STORE USER MEMORY (ONLY after toolOrchestrator failed)
 	// ---------------------------------------------
-	userMemory := a.startMemoryStore(sessionID, "user", userInput, nil)
+	userMemory := a.startMemoryStore(ctx, sessionID, "user", userInput, scopeMetadata(ctx))
 	defer userMemory.Wait()
 
 	// If the user input looks like a tool call, but wasn't handled above,
@@ -248,28 +413,21 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 	// 6. LLM COMPLETION
 	// ---------------------------------------------
 	// Build LLM prompt without tools/subagents:
-	var sb strings.Builder
-	sb.Grow(4096)
-
-	sb.WriteString(a.systemPrompt)
-	sb.WriteString("\n\nConversation memory (TOON):\n")
-	sb.WriteString(a.renderMemory(records))
-
-	sb.WriteString("\n\nUser: ")
-	sb.WriteString(sanitizeInput(userInput))
-	sb.WriteString("\n\n")
-
-	prompt := sb.String()
+	sections, err := a.renderContextSections(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	data := PromptData{
+		SessionID:    sessionID,
+		SystemPrompt: a.systemPromptFor(sessionID),
+		Context:      sections,
+		Memory:       records,
+		Input:        sanitizeInput(userInput),
+	}
 
 	files := <-attachmentReady
 
-	var completion any
-	var err error
-	if len(files) > 0 {
-		completion, err = a.model.GenerateWithFiles(ctx, prompt, files)
-	} else {
-		completion, err = a.model.Generate(ctx, prompt)
-	}
+	completion, err := a.complete(ctx, sessionID, a.renderMessages(ctx, data, files))
 	if err != nil {
 		return "", err
 	}
@@ -287,7 +445,8 @@ func (a *Agent) Generate(ctx context.Context, sessionID, userInput string) (any,
 	// Preserve user-before-assistant memory order while hiding the user's
 	// embedding latency behind attachment retrieval and model generation.
 	userMemory.Wait()
-	a.storeMemory(sessionID, "assistant", finalText, nil)
+	turnID := a.recordTurn(ctx, sessionID, userInput, finalText, records, started)
+	a.storeMemory(ctx, sessionID, "assistant", finalText, map[string]string{"turn_id": turnID})
 	return completion, nil
 }
 
@@ -305,6 +464,12 @@ func (a *Agent) GenerateWithFiles(
 	userInput string,
 	files []models.File,
 ) (string, error) {
+	started := time.Now()
+	ctx = a.withTurnBudget(ctx)
+	ctx, err := a.beginQuotaTurn(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
 	if a.InputGuardrails != nil {
 		transformed, err := a.InputGuardrails.ValidateAndTransform(ctx, userInput)
 		if err != nil {
@@ -331,7 +496,11 @@ func (a *Agent) GenerateWithFiles(
 	// Direct tool calls are only safe for text-only requests.
 	// File-backed requests must go through the file-aware orchestration path.
 	if trimmed != "" && !fileBacked {
-		if toolName, args, ok := a.detectDirectToolCall(trimmed); ok {
+		toolName, args, ok, ambiguous := a.detectDirectToolCall(sessionID, trimmed)
+		if ambiguous != nil {
+			return ambiguous.Prompt(), nil
+		}
+		if ok {
 			result, err := a.executeTool(ctx, sessionID, toolName, args)
 			if err != nil {
 				return "", err
@@ -344,7 +513,7 @@ func (a *Agent) GenerateWithFiles(
 		if err != nil {
 			return "", err
 		}
-		a.storeMemory(sessionID, "subagent", out, meta)
+		a.storeMemory(ctx, sessionID, "subagent", out, meta)
 		return out, nil
 	}
 
@@ -376,7 +545,7 @@ func (a *Agent) GenerateWithFiles(
 	// Direct CodeMode does not receive files.
 	// If files are present, CodeMode must be disabled unless it receives full attachment context.
 	if trimmed != "" && !fileBacked && a.CodeMode != nil {
-		handled, output, err := a.CodeMode.CallTool(ctx, userInput)
+		handled, output, err := a.callCodeMode(ctx, sessionID, userInput)
 		if err != nil {
 			return "", err
 		}
@@ -386,6 +555,7 @@ func (a *Agent) GenerateWithFiles(
 	}
 
 	prefetchWG.Wait()
+	ctx = models.WithContextFingerprint(ctx, memoryFingerprint(records))
 	if existingFilesReady != nil {
 		existingFiles = <-existingFilesReady
 	}
@@ -397,7 +567,7 @@ func (a *Agent) GenerateWithFiles(
 	// Attachment embeddings are independent of planning/model generation.
 	// Prepare them while prompts and model results are built, then commit in
 	// file order before the user/assistant records become visible.
-	attachmentMemories := a.startAttachmentMemoryStores(sessionID, files)
+	attachmentMemories := a.startAttachmentMemoryStores(ctx, sessionID, files)
 	var userMemory *memoryStoreTask
 	defer func() {
 		waitMemoryStoreTasks(attachmentMemories)
@@ -449,60 +619,39 @@ func (a *Agent) GenerateWithFiles(
 	}
 
 	if trimmed != "" {
-		userMemory = a.startMemoryStore(sessionID, "user", userInput, nil)
+		userMemory = a.startMemoryStore(ctx, sessionID, "user", userInput, scopeMetadata(ctx))
 	}
 
 	if trimmed != "" && !fileBacked && a.userLooksLikeToolCall(trimmed) {
 		return "", nil
 	}
 
-	var sb strings.Builder
-	sb.Grow(4096)
-
-	if strings.TrimSpace(a.systemPrompt) != "" {
-		sb.WriteString(strings.TrimSpace(a.systemPrompt))
-		sb.WriteString("\n\n")
+	sections, err := a.renderContextSections(ctx, sessionID)
+	if err != nil {
+		return "", err
 	}
-
-	sb.WriteString("Conversation memory (TOON):\n")
-	sb.WriteString(a.renderMemory(records))
-	sb.WriteString("\n\n")
-
-	if fileBacked {
-		sb.WriteString(workspaceRules)
-		sb.WriteString("\n")
+	data := PromptData{
+		SessionID:    sessionID,
+		SystemPrompt: a.systemPromptFor(sessionID),
+		Context:      sections,
+		Memory:       records,
+		Attachments: []PromptAttachments{
+			{Title: "Session attachments rehydrated", Files: existingFiles},
+			{Title: "Files provided for this turn", Files: files},
+		},
+		Input: "Analyze the provided files.",
 	}
-
-	if existingFilesPrompt != "" {
-		sb.WriteString(existingFilesPrompt)
-		sb.WriteString("\n")
-	}
-
-	if turnFilesPrompt != "" {
-		sb.WriteString(turnFilesPrompt)
-		sb.WriteString("\n")
+	if fileBacked {
+		data.Rules = workspaceRules
 	}
-
 	if trimmed != "" {
-		sb.WriteString("User: ")
-		sb.WriteString(sanitizeInput(userInput))
-		sb.WriteString("\n")
-	} else {
-		sb.WriteString("User: Analyze the provided files.\n")
+		data.Input = sanitizeInput(userInput)
 	}
-
-	prompt := sb.String()
-
-	var (
-		completion any
-		err        error
-	)
-
+	var turnFiles []models.File
 	if fileBacked {
-		completion, err = a.model.GenerateWithFiles(ctx, prompt, allFiles)
-	} else {
-		completion, err = a.model.Generate(ctx, prompt)
+		turnFiles = allFiles
 	}
+	completion, err := a.complete(ctx, sessionID, a.renderMessages(ctx, data, turnFiles))
 	if err != nil {
 		return "", err
 	}
@@ -518,6 +667,7 @@ func (a *Agent) GenerateWithFiles(
 
 	waitMemoryStoreTasks(attachmentMemories)
 	userMemory.Wait()
-	a.storeMemory(sessionID, "assistant", response, nil)
+	turnID := a.recordTurn(ctx, sessionID, userInput, response, records, started)
+	a.storeMemory(ctx, sessionID, "assistant", response, map[string]string{"turn_id": turnID})
 	return response, nil
 }
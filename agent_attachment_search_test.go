@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// pictureEmbedder puts text and images on shared topic axes, the way a
+// CLIP-style model would: image bytes carry their topic as a marker.
+type pictureEmbedder struct{}
+
+var pictureTopics = []string{"auth", "billing"}
+
+func (pictureEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	return pictureVector([]byte(strings.ToLower(text))), nil
+}
+
+func (pictureEmbedder) EmbedImage(_ context.Context, data []byte, _ string) ([]float32, error) {
+	return pictureVector(data), nil
+}
+
+func pictureVector(data []byte) []float32 {
+	vec := make([]float32, len(pictureTopics)+1)
+	for i, topic := range pictureTopics {
+		if bytes.Contains(data, []byte(topic)) {
+			vec[i] = 1
+		}
+	}
+	vec[len(pictureTopics)] = 0.1
+	return vec
+}
+
+func TestSearchAttachmentFilesMatchesImageContent(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 8).WithEmbedder(pictureEmbedder{})
+	a, err := New(Options{Model: &fileEchoModel{response: "ok"}, Memory: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []models.File{
+		{Name: "diagram-1.png", MIME: "image/png", Data: []byte("\x89PNG billing pipeline")},
+		{Name: "diagram-2.png", MIME: "image/png", Data: []byte("\x89PNG auth sequence")},
+	}
+	if _, err := a.GenerateWithFiles(ctx, "s", "keep these", files); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Flush(ctx, "s"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := a.SearchAttachmentFiles(ctx, "s", "the diagram about the auth flow", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "diagram-2.png" || !bytes.Equal(got[0].Data, files[1].Data) {
+		t.Fatalf("SearchAttachmentFiles = %+v, want diagram-2.png", got)
+	}
+
+	got, err = a.SearchAttachmentFiles(ctx, "s", "billing", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "diagram-1.png" {
+		t.Fatalf("SearchAttachmentFiles(billing) = %+v, want diagram-1.png", got)
+	}
+}
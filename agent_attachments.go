@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// AttachmentRoute is where GenerateWithFiles keeps an attachment after the
+// turn it was sent with. Every route still shows the file to the model on
+// that turn.
+type AttachmentRoute string
+
+const (
+	// AttachmentMemory stores the whole file as an attachment memory, which
+	// later turns of the session rehydrate. It is the route without an
+	// AttachmentPolicy.
+	AttachmentMemory AttachmentRoute = "memory"
+	// AttachmentEphemeral keeps nothing beyond the turn.
+	AttachmentEphemeral AttachmentRoute = "ephemeral"
+	// AttachmentIngest chunks the file into long-term memory through the
+	// policy's Ingestor.
+	AttachmentIngest AttachmentRoute = "ingest"
+	// AttachmentBlob saves the file in the policy's Blobs store.
+	AttachmentBlob AttachmentRoute = "blob"
+)
+
+const defaultInlineAttachmentBytes = 32 << 10
+
+// AttachmentIngestor chunks a document into long-term memory under space.
+// uploads.Pipeline implements it.
+type AttachmentIngestor interface {
+	IngestAttachment(ctx context.Context, space string, file models.File) error
+}
+
+// AttachmentBlobStore saves a file and returns a URI it can be fetched by.
+// artifacts.Store implements it.
+type AttachmentBlobStore interface {
+	StoreAttachment(ctx context.Context, sessionID string, file models.File) (string, error)
+}
+
+// AttachmentPolicy routes the files of GenerateWithFiles by size and MIME
+// type instead of storing each one as an attachment memory:
+//
+//   - images go to Blobs;
+//   - text up to InlineMaxBytes is ephemeral;
+//   - anything larger goes through Ingestor into IngestSpace.
+//
+// A route whose store is unset, or that fails, falls back to
+// AttachmentMemory, so no file is lost. Ingested and blob-stored files
+// leave a short "attachment_ref" memory saying where they went. Route
+// overrides the choice; WithAttachmentRoute overrides it for one call.
+type AttachmentPolicy struct {
+	Ingestor AttachmentIngestor
+	Blobs    AttachmentBlobStore
+	// InlineMaxBytes is the largest text file kept ephemeral; 32 KiB when
+	// zero.
+	InlineMaxBytes int
+	// IngestSpace is the space documents are ingested into; the session
+	// when empty.
+	IngestSpace string
+	// Route, when set, picks the route of each file; returning "" leaves
+	// the choice to the policy.
+	Route func(file models.File) AttachmentRoute
+}
+
+type attachmentRouteKey struct{}
+
+// WithAttachmentRoute sends every file of the GenerateWithFiles calls made
+// with ctx down route, whatever the agent's AttachmentPolicy says.
+func WithAttachmentRoute(ctx context.Context, route AttachmentRoute) context.Context {
+	return context.WithValue(ctx, attachmentRouteKey{}, route)
+}
+
+// routeAttachment picks the route of file for a call made with ctx.
+func (p *AttachmentPolicy) routeAttachment(ctx context.Context, file models.File) AttachmentRoute {
+	route, _ := ctx.Value(attachmentRouteKey{}).(AttachmentRoute)
+	if route == "" && p != nil && p.Route != nil {
+		route = p.Route(file)
+	}
+	if route == "" && p != nil {
+		route = p.defaultRoute(file)
+	}
+	switch route {
+	case AttachmentEphemeral:
+		return route
+	case AttachmentIngest:
+		if p != nil && p.Ingestor != nil {
+			return route
+		}
+	case AttachmentBlob:
+		if p != nil && p.Blobs != nil {
+			return route
+		}
+	}
+	return AttachmentMemory
+}
+
+func (p *AttachmentPolicy) defaultRoute(file models.File) AttachmentRoute {
+	mime := strings.ToLower(strings.TrimSpace(file.MIME))
+	if strings.HasPrefix(mime, "image/") {
+		return AttachmentBlob
+	}
+	limit := p.InlineMaxBytes
+	if limit <= 0 {
+		limit = defaultInlineAttachmentBytes
+	}
+	if len(file.Data) <= limit && isTextAttachment(mime, file.Data) {
+		return AttachmentEphemeral
+	}
+	return AttachmentIngest
+}
+
+// startRoutedAttachment ingests or saves file in the background and then
+// prepares the memory that points at it. If the store fails the file is
+// kept as an attachment memory instead.
+func (a *Agent) startRoutedAttachment(ctx context.Context, sessionID string, route AttachmentRoute, name string, file models.File, fallback func() (preparedMemoryStore, bool)) *memoryStoreTask {
+	ctx = context.WithoutCancel(ctx)
+	policy := a.Attachments
+	ready := make(chan preparedMemoryStore, 1)
+	go func() {
+		extra := map[string]string{
+			"source":     "file_upload",
+			"filename":   name,
+			"route":      string(route),
+			"mime":       file.MIME,
+			"size_bytes": strconv.Itoa(len(file.Data)),
+		}
+		var err error
+		var content string
+		switch route {
+		case AttachmentIngest:
+			space := policy.IngestSpace
+			if space == "" {
+				space = sessionID
+			}
+			if err = policy.Ingestor.IngestAttachment(ctx, space, file); err == nil {
+				extra["space"] = space
+				content = fmt.Sprintf("Attachment %s was ingested into space %s.", name, space)
+			}
+		case AttachmentBlob:
+			var uri string
+			if uri, err = policy.Blobs.StoreAttachment(ctx, sessionID, file); err == nil {
+				extra["uri"] = uri
+				content = fmt.Sprintf("Attachment %s is stored at %s.", name, uri)
+			}
+		}
+		prepared, ok := preparedMemoryStore{}, false
+		if err != nil {
+			prepared, ok = fallback()
+		} else {
+			prepared, ok = a.prepareMemoryStore(ctx, sessionID, "attachment_ref", content, extra)
+		}
+		if ok {
+			prepared.embed()
+		}
+		ready <- prepared
+	}()
+	return &memoryStoreTask{ready: ready}
+}
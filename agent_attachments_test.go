@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+type recordingAttachmentStores struct {
+	mu       sync.Mutex
+	ingested []string
+	blobs    []string
+	fail     bool
+}
+
+func (r *recordingAttachmentStores) IngestAttachment(_ context.Context, space string, file models.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ingested = append(r.ingested, space+"/"+file.Name)
+	return nil
+}
+
+func (r *recordingAttachmentStores) StoreAttachment(_ context.Context, sessionID string, file models.File) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return "", errors.New("blob store offline")
+	}
+	r.blobs = append(r.blobs, file.Name)
+	return "artifact://" + sessionID + "/" + file.Name + "?v=1", nil
+}
+
+func TestAttachmentPolicyRoutesFiles(t *testing.T) {
+	stores := &recordingAttachmentStores{}
+	mem := memory.NewSessionMemory(nil, 16).WithEmbedder(memory.DummyEmbedder{})
+	a, err := New(Options{
+		Model:       &stubModel{response: "ok"},
+		Memory:      mem,
+		Attachments: &AttachmentPolicy{Ingestor: stores, Blobs: stores, InlineMaxBytes: 64, IngestSpace: "docs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []models.File{
+		{Name: "note.txt", MIME: "text/plain", Data: []byte("short note")},
+		{Name: "manual.md", MIME: "text/markdown", Data: []byte(strings.Repeat("long manual text ", 10))},
+		{Name: "diagram.png", MIME: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}},
+	}
+	if _, err := a.GenerateWithFiles(context.Background(), "s", "look at these", files); err != nil {
+		t.Fatal(err)
+	}
+	if len(stores.ingested) != 1 || stores.ingested[0] != "docs/manual.md" {
+		t.Fatalf("ingested %v", stores.ingested)
+	}
+	if len(stores.blobs) != 1 || stores.blobs[0] != "diagram.png" {
+		t.Fatalf("blobs %v", stores.blobs)
+	}
+	routes := map[string]string{}
+	for _, rec := range mem.ShortTerm("s") {
+		var meta struct {
+			Role     string `json:"role"`
+			Filename string `json:"filename"`
+		}
+		if json.Unmarshal([]byte(rec.Metadata), &meta) == nil && meta.Filename != "" {
+			routes[meta.Filename] = meta.Role
+		}
+	}
+	want := map[string]string{"manual.md": "attachment_ref", "diagram.png": "attachment_ref"}
+	if len(routes) != len(want) || routes["manual.md"] != want["manual.md"] || routes["diagram.png"] != want["diagram.png"] {
+		t.Fatalf("attachment memories %v, want %v (note.txt ephemeral)", routes, want)
+	}
+
+	// A per-call route wins, and a failing store falls back to a memory.
+	stores.fail = true
+	ctx := WithAttachmentRoute(context.Background(), AttachmentBlob)
+	if _, err := a.GenerateWithFiles(ctx, "t", "again", files[:1]); err != nil {
+		t.Fatal(err)
+	}
+	buf := mem.ShortTerm("t")
+	if len(buf) == 0 || metadataRole(buf[0].Metadata) != "attachment" {
+		t.Fatalf("failed blob route should keep the file as an attachment memory: %+v", buf)
+	}
+}
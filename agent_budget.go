@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Protocol-Lattice/go-agent/src/models/middleware"
+)
+
+// ErrBudgetExceeded is wrapped by every BudgetExceededError.
+var ErrBudgetExceeded = errors.New("turn budget exceeded")
+
+// TurnBudget caps what one turn may spend across the tool loop, CodeMode
+// scripts and the final completion. Zero fields are unlimited. Tokens are
+// estimated from prompt and response text, not taken from provider billing.
+type TurnBudget struct {
+	MaxToolCalls int
+	MaxTokens    int64
+	MaxCost      float64
+	// CostPerToken prices estimated model tokens toward MaxCost.
+	CostPerToken float64
+	// ToolCosts prices one call of a tool, by name, toward MaxCost.
+	ToolCosts map[string]float64
+}
+
+func (b TurnBudget) isZero() bool {
+	return b.MaxToolCalls <= 0 && b.MaxTokens <= 0 && b.MaxCost <= 0
+}
+
+// TurnUsage is what a turn has spent so far.
+type TurnUsage struct {
+	ToolCalls int     `json:"tool_calls"`
+	Tokens    int64   `json:"tokens"`
+	Cost      float64 `json:"cost"`
+}
+
+// BudgetStep is a tool call that finished before the budget ran out.
+type BudgetStep struct {
+	Tool   string `json:"tool"`
+	Result string `json:"result"`
+}
+
+// BudgetExceededError stops a turn that hit its budget. It carries the work
+// done so far, so callers can show a partial result instead of nothing.
+type BudgetExceededError struct {
+	// Limit is "tool_calls", "tokens" or "cost".
+	Limit     string       `json:"limit"`
+	Budget    TurnBudget   `json:"-"`
+	Usage     TurnUsage    `json:"usage"`
+	Completed []BudgetStep `json:"completed,omitempty"`
+}
+
+func (e *BudgetExceededError) Error() string {
+	var limit string
+	switch e.Limit {
+	case "tool_calls":
+		limit = fmt.Sprintf("%d tool calls", e.Budget.MaxToolCalls)
+	case "tokens":
+		limit = fmt.Sprintf("%d tokens", e.Budget.MaxTokens)
+	default:
+		limit = fmt.Sprintf("cost %.4g", e.Budget.MaxCost)
+	}
+	return fmt.Sprintf("%v: limit %s (used %d tool calls, ~%d tokens, cost %.4g)",
+		ErrBudgetExceeded, limit, e.Usage.ToolCalls, e.Usage.Tokens, e.Usage.Cost)
+}
+
+func (e *BudgetExceededError) Unwrap() error { return ErrBudgetExceeded }
+
+// Partial renders the completed steps for display.
+func (e *BudgetExceededError) Partial() string {
+	if len(e.Completed) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Stopped early: turn budget exceeded. Completed so far:\n")
+	for i, step := range e.Completed {
+		fmt.Fprintf(&sb, "%d. %s: %s\n", i+1, step.Tool, step.Result)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// budgetMeter tracks one turn's spend. Once the budget is exceeded every
+// further charge fails with the same error, so nested loops stop promptly.
+type budgetMeter struct {
+	mu        sync.Mutex
+	budget    TurnBudget
+	usage     TurnUsage
+	completed []BudgetStep
+	err       *BudgetExceededError
+	quota     *quotaTurn // set by beginQuotaTurn
+}
+
+type budgetContextKey struct{}
+
+// ContextWithTurnBudget applies budget to the turn run under ctx, in place
+// of Agent.Budget. Agents called as tools under ctx share it.
+func ContextWithTurnBudget(ctx context.Context, budget TurnBudget) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, budgetContextKey{}, &budgetMeter{budget: budget})
+}
+
+// TurnUsageFromContext reports what the turn under ctx has spent.
+func TurnUsageFromContext(ctx context.Context) (TurnUsage, bool) {
+	m := budgetFromContext(ctx)
+	if m == nil {
+		return TurnUsage{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage, true
+}
+
+func budgetFromContext(ctx context.Context) *budgetMeter {
+	if ctx == nil {
+		return nil
+	}
+	m, _ := ctx.Value(budgetContextKey{}).(*budgetMeter)
+	return m
+}
+
+// withTurnBudget starts metering a turn against a.Budget unless ctx already
+// carries a budget. Agents with Quotas meter every turn, since quotas are
+// charged through the meter.
+func (a *Agent) withTurnBudget(ctx context.Context) context.Context {
+	if (a.Budget.isZero() && a.Quotas == nil) || budgetFromContext(ctx) != nil {
+		return ctx
+	}
+	return ContextWithTurnBudget(ctx, a.Budget)
+}
+
+// chargeToolCall reserves one call of tool, failing before the call is made
+// if it would exceed the budget.
+func chargeToolCall(ctx context.Context, tool string) error {
+	m := budgetFromContext(ctx)
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	cost := m.budget.ToolCosts[tool]
+	switch {
+	case m.budget.MaxToolCalls > 0 && m.usage.ToolCalls+1 > m.budget.MaxToolCalls:
+		return m.exceed("tool_calls")
+	case m.budget.MaxCost > 0 && m.usage.Cost+cost > m.budget.MaxCost:
+		return m.exceed("cost")
+	}
+	if m.quota != nil {
+		if err := m.quota.chargeToolCall(); err != nil {
+			return err
+		}
+	}
+	m.usage.ToolCalls++
+	m.usage.Cost += cost
+	return nil
+}
+
+// chargeTokens records the estimated tokens of text sent to or received from
+// the model. Prompts are charged before the call; the response that tips the
+// budget over is kept, and the next charge stops the turn.
+func chargeTokens(ctx context.Context, text string) error {
+	m := budgetFromContext(ctx)
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	if m.budget.MaxTokens > 0 && m.usage.Tokens >= m.budget.MaxTokens {
+		return m.exceed("tokens")
+	}
+	if m.budget.MaxCost > 0 && m.usage.Cost >= m.budget.MaxCost {
+		return m.exceed("cost")
+	}
+	tokens := middleware.ApproximateTokenCount(text)
+	m.usage.Tokens += tokens
+	m.usage.Cost += float64(tokens) * m.budget.CostPerToken
+	if m.quota != nil {
+		m.quota.chargeTokens(tokens)
+	}
+	return nil
+}
+
+// recordBudgetStep keeps a finished tool call for the partial result.
+func recordBudgetStep(ctx context.Context, tool string, result any) {
+	m := budgetFromContext(ctx)
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.completed = append(m.completed, BudgetStep{Tool: tool, Result: truncate(fmt.Sprint(result), 500)})
+	m.mu.Unlock()
+}
+
+// budgetExceeded returns the error that stopped the turn, if any. CodeMode
+// scripts flatten tool errors into text, so their callers check here to
+// surface the structured error instead.
+func budgetExceeded(ctx context.Context) error {
+	m := budgetFromContext(ctx)
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err == nil {
+		return nil
+	}
+	return m.err
+}
+
+func (m *budgetMeter) exceed(limit string) error {
+	m.err = &BudgetExceededError{
+		Limit:     limit,
+		Budget:    m.budget,
+		Usage:     m.usage,
+		Completed: append([]BudgetStep(nil), m.completed...),
+	}
+	return m.err
+}
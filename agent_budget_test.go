@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestToolLoopStopsAtToolCallBudget(t *testing.T) {
+	model := &coordinatorModel{replies: []string{
+		`{"use_tool": true, "tool_name": "echo", "arguments": {"input": "one"}}`,
+		`{"use_tool": true, "tool_name": "echo", "arguments": {"input": "two"}}`,
+		`{"use_tool": false, "final_answer": "done"}`,
+	}}
+	tool := &stubTool{spec: ToolSpec{Name: "echo", Description: "Echoes input"}}
+	a, err := New(Options{
+		Model:  model,
+		Memory: memory.NewSessionMemory(&memory.MemoryBank{}, 0),
+		Tools:  []Tool{tool},
+		Budget: TurnBudget{MaxToolCalls: 1},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	_, err = a.Generate(context.Background(), "s1", "run echo twice")
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if budgetErr.Limit != "tool_calls" || budgetErr.Usage.ToolCalls != 1 {
+		t.Fatalf("unexpected budget error %+v", budgetErr)
+	}
+	if len(budgetErr.Completed) != 1 || budgetErr.Completed[0].Result != "one" {
+		t.Fatalf("expected the first call as partial result, got %+v", budgetErr.Completed)
+	}
+	if !strings.Contains(budgetErr.Partial(), "echo: one") {
+		t.Fatalf("unexpected partial %q", budgetErr.Partial())
+	}
+	if tool.lastInput.Arguments["input"] != "one" {
+		t.Fatalf("second call should not have run")
+	}
+}
+
+func TestTokenBudgetStopsBeforeNextModelCall(t *testing.T) {
+	ctx := ContextWithTurnBudget(context.Background(), TurnBudget{MaxTokens: 10})
+	if err := chargeTokens(ctx, strings.Repeat("x", 80)); err != nil {
+		t.Fatalf("first charge should be accepted: %v", err)
+	}
+	if err := chargeTokens(ctx, "next prompt"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if err := chargeToolCall(ctx, "echo"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("an exceeded budget should stop tool calls too, got %v", err)
+	}
+	usage, ok := TurnUsageFromContext(ctx)
+	if !ok || usage.Tokens != 20 || usage.ToolCalls != 0 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestToolCostBudget(t *testing.T) {
+	ctx := ContextWithTurnBudget(context.Background(), TurnBudget{MaxCost: 1, ToolCosts: map[string]float64{"search": 0.6}})
+	if err := chargeToolCall(ctx, "search"); err != nil {
+		t.Fatalf("first search fits: %v", err)
+	}
+	var budgetErr *BudgetExceededError
+	if err := chargeToolCall(ctx, "search"); !errors.As(err, &budgetErr) || budgetErr.Limit != "cost" {
+		t.Fatalf("expected cost limit, got %v", err)
+	}
+}
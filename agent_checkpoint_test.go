@@ -27,8 +27,8 @@ func TestAgentCheckpointAndRestore(t *testing.T) {
 	// 2. Add some state (memory)
 	sessionID := "test-session"
 	// storeMemory is unexported but accessible in the same package
-	agent.storeMemory(sessionID, "user", "Hello world", nil)
-	agent.storeMemory(sessionID, "assistant", "Hi there", nil)
+	agent.storeMemory(context.Background(), sessionID, "user", "Hello world", nil)
+	agent.storeMemory(context.Background(), sessionID, "assistant", "Hi there", nil)
 
 	// 3. Checkpoint
 	data, err := agent.Checkpoint()
@@ -0,0 +1,376 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/universal-tool-calling-protocol/go-utcp"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/plugins/codemode"
+)
+
+// ErrCodeRunNotFound is returned when a CodeMode run is not in a CodeRunStore.
+var ErrCodeRunNotFound = errors.New("codemode run not found")
+
+// CodeRunStatus describes where a persisted CodeMode run stands.
+type CodeRunStatus string
+
+const (
+	CodeRunRunning   CodeRunStatus = "running"
+	CodeRunCompleted CodeRunStatus = "completed"
+	CodeRunFailed    CodeRunStatus = "failed"
+)
+
+// CodeRun is a persisted CodeMode orchestration: the script (or, for runs
+// CodeMode planned itself, the request it planned from), every tool call the
+// script made, and where it failed.
+type CodeRun struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	// Code is the script run through codemode.run_code. It is empty for runs
+	// CodeMode generated from Prompt; those are re-planned on resume.
+	Code    string        `json:"code,omitempty"`
+	Prompt  string        `json:"prompt,omitempty"`
+	Timeout int           `json:"timeout,omitempty"`
+	Status  CodeRunStatus `json:"status"`
+	Steps   []CodeRunStep `json:"steps,omitempty"`
+	// FailedStep indexes the step that failed. It equals len(Steps) when the
+	// script itself failed after its last tool call, and is -1 otherwise.
+	FailedStep int       `json:"failed_step"`
+	Error      string    `json:"error,omitempty"`
+	Result     any       `json:"result,omitempty"`
+	Attempts   int       `json:"attempts"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CodeRunStep is one tool call made by a CodeMode script.
+type CodeRunStep struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Result    any            `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	// Replayed is set when the result was restored from an earlier attempt
+	// instead of calling the tool again.
+	Replayed bool      `json:"replayed,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// CodeRunStore persists CodeMode runs. Save creates or replaces a run.
+type CodeRunStore interface {
+	Save(ctx context.Context, run CodeRun) error
+	Load(ctx context.Context, id string) (CodeRun, error)
+}
+
+// InMemoryCodeRunStore keeps runs in process. Runs are round-tripped through
+// JSON, so step results must be JSON marshalable as they would be for a
+// durable store.
+type InMemoryCodeRunStore struct {
+	mu   sync.RWMutex
+	runs map[string][]byte
+}
+
+// NewInMemoryCodeRunStore returns an empty in-memory store.
+func NewInMemoryCodeRunStore() *InMemoryCodeRunStore {
+	return &InMemoryCodeRunStore{runs: map[string][]byte{}}
+}
+
+func (s *InMemoryCodeRunStore) Save(ctx context.Context, run CodeRun) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("encode codemode run %s: %w", run.ID, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runs == nil {
+		s.runs = map[string][]byte{}
+	}
+	s.runs[run.ID] = data
+	return nil
+}
+
+func (s *InMemoryCodeRunStore) Load(ctx context.Context, id string) (CodeRun, error) {
+	if err := ctx.Err(); err != nil {
+		return CodeRun{}, err
+	}
+	s.mu.RLock()
+	data, ok := s.runs[id]
+	s.mu.RUnlock()
+	if !ok {
+		return CodeRun{}, fmt.Errorf("%w: %s", ErrCodeRunNotFound, id)
+	}
+	var run CodeRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return CodeRun{}, fmt.Errorf("decode codemode run %s: %w", id, err)
+	}
+	return run, nil
+}
+
+// CodeRunError reports a failed CodeMode run that can be resumed with
+// Agent.ResumeCodeRun.
+type CodeRunError struct {
+	RunID string
+	Step  int
+	Err   error
+}
+
+func (e *CodeRunError) Error() string {
+	return fmt.Sprintf("codemode run %s failed at step %d: %v", e.RunID, e.Step, e.Err)
+}
+
+func (e *CodeRunError) Unwrap() error { return e.Err }
+
+// RecordCodeRunSteps wraps the UTCP client handed to codemode.NewCodeModeUTCP
+// so the agent can record the tool calls of persisted runs and, on resume,
+// answer already-completed calls from the earlier attempt. Calls made
+// outside a persisted run pass straight through.
+func RecordCodeRunSteps(client utcp.UtcpClientInterface) utcp.UtcpClientInterface {
+	if client == nil {
+		return nil
+	}
+	if _, ok := client.(*codeRunClient); ok {
+		return client
+	}
+	return &codeRunClient{UtcpClientInterface: client}
+}
+
+type codeRunClient struct {
+	utcp.UtcpClientInterface
+}
+
+func (c *codeRunClient) CallTool(ctx context.Context, toolName string, args map[string]any) (any, error) {
+	rec, ok := ctx.Value(codeRunContextKey{}).(*codeRunRecorder)
+	if !ok {
+		if err := chargeToolCall(ctx, toolName); err != nil {
+			return nil, err
+		}
+		result, err := c.UtcpClientInterface.CallTool(ctx, toolName, args)
+		if err == nil {
+			recordBudgetStep(ctx, toolName, result)
+		}
+		return result, err
+	}
+	key := stepKey(toolName, args)
+	if result, ok := rec.replay(key); ok {
+		rec.record(CodeRunStep{Tool: toolName, Arguments: args, Result: result, Replayed: true, At: time.Now().UTC()})
+		return result, nil
+	}
+	if err := chargeToolCall(ctx, toolName); err != nil {
+		rec.record(CodeRunStep{Tool: toolName, Arguments: args, Error: err.Error(), At: time.Now().UTC()})
+		return nil, err
+	}
+	result, err := c.UtcpClientInterface.CallTool(ctx, toolName, args)
+	if err == nil {
+		recordBudgetStep(ctx, toolName, result)
+	}
+	step := CodeRunStep{Tool: toolName, Arguments: args, Result: result, At: time.Now().UTC()}
+	if err != nil {
+		step.Result, step.Error = nil, err.Error()
+	}
+	rec.record(step)
+	return result, err
+}
+
+type codeRunContextKey struct{}
+
+// codeRunRecorder collects the steps of one attempt. Prior results are
+// matched by tool and arguments rather than position, so a re-planned
+// script that makes the same calls in another order still reuses them.
+type codeRunRecorder struct {
+	mu    sync.Mutex
+	prior map[string][]any
+	steps []CodeRunStep
+}
+
+func newCodeRunRecorder(prior []CodeRunStep) *codeRunRecorder {
+	rec := &codeRunRecorder{prior: map[string][]any{}}
+	for _, step := range prior {
+		if step.Error != "" {
+			continue
+		}
+		key := stepKey(step.Tool, step.Arguments)
+		rec.prior[key] = append(rec.prior[key], step.Result)
+	}
+	return rec
+}
+
+func (r *codeRunRecorder) replay(key string) (any, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := r.prior[key]
+	if len(results) == 0 {
+		return nil, false
+	}
+	r.prior[key] = results[1:]
+	return results[0], true
+}
+
+func (r *codeRunRecorder) record(step CodeRunStep) {
+	r.mu.Lock()
+	r.steps = append(r.steps, step)
+	r.mu.Unlock()
+}
+
+func (r *codeRunRecorder) snapshot() []CodeRunStep {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CodeRunStep(nil), r.steps...)
+}
+
+func stepKey(tool string, args map[string]any) string {
+	// encoding/json sorts map keys, which makes this canonical.
+	raw, err := json.Marshal(args)
+	if err != nil {
+		raw = []byte(fmt.Sprint(args))
+	}
+	return tool + "\x00" + string(raw)
+}
+
+// runCode executes a codemode.run_code script, persisting it as a run when
+// CodeRuns is set.
+func (a *Agent) runCode(ctx context.Context, sessionID, code string, timeout int) (any, error) {
+	if a.CodeRuns == nil {
+		out, err := a.executeCode(ctx, code, timeout)
+		if budgetErr := budgetExceeded(ctx); err != nil && budgetErr != nil {
+			return nil, budgetErr
+		}
+		return out, err
+	}
+	run := a.newCodeRun(sessionID)
+	run.Code, run.Timeout = code, timeout
+	return a.attemptCodeRun(ctx, run)
+}
+
+// callCodeMode lets CodeMode plan and run a script for prompt. Runs it
+// declines are not persisted.
+func (a *Agent) callCodeMode(ctx context.Context, sessionID, prompt string) (bool, any, error) {
+	// Scripts reach UTCP tools directly, past the read-only check.
+	if a.ReadOnly {
+		return false, nil, nil
+	}
+	if a.CodeRuns == nil {
+		handled, out, err := a.CodeMode.CallTool(ctx, prompt)
+		if budgetErr := budgetExceeded(ctx); err != nil && budgetErr != nil {
+			return true, nil, budgetErr
+		}
+		return handled, out, err
+	}
+	rec := newCodeRunRecorder(nil)
+	handled, output, err := a.CodeMode.CallTool(context.WithValue(ctx, codeRunContextKey{}, rec), prompt)
+	if !handled && err == nil {
+		return false, output, nil
+	}
+	run := a.newCodeRun(sessionID)
+	run.Prompt = prompt
+	out, err := a.finishCodeRun(ctx, run, rec, output, err)
+	return true, out, err
+}
+
+func (a *Agent) newCodeRun(sessionID string) CodeRun {
+	now := time.Now().UTC()
+	return CodeRun{ID: "cr-" + newTraceID(), SessionID: sessionID, Status: CodeRunRunning, FailedStep: -1, CreatedAt: now, UpdatedAt: now}
+}
+
+// ResumeCodeRun re-executes a failed CodeMode run. Tool calls that
+// succeeded in an earlier attempt return their stored results without
+// running again, so execution effectively resumes at the failed step.
+// Resuming a completed run returns its result.
+func (a *Agent) ResumeCodeRun(ctx context.Context, runID string) (any, error) {
+	if a.CodeRuns == nil {
+		return nil, errors.New("codemode runs are not persisted: set CodeRuns")
+	}
+	if a.CodeMode == nil {
+		return nil, fmt.Errorf("codemode is not configured")
+	}
+	run, err := a.CodeRuns.Load(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status == CodeRunCompleted {
+		return run.Result, nil
+	}
+	return a.attemptCodeRun(ctx, run)
+}
+
+func (a *Agent) attemptCodeRun(ctx context.Context, run CodeRun) (any, error) {
+	rec := newCodeRunRecorder(run.Steps)
+	run.Status = CodeRunRunning
+	run.Attempts++
+	if err := a.CodeRuns.Save(ctx, run); err != nil {
+		return nil, fmt.Errorf("persist codemode run %s: %w", run.ID, err)
+	}
+	runCtx := context.WithValue(ctx, codeRunContextKey{}, rec)
+	var (
+		out any
+		err error
+	)
+	if run.Code != "" {
+		out, err = a.executeCode(runCtx, run.Code, run.Timeout)
+	} else {
+		var handled bool
+		handled, out, err = a.CodeMode.CallTool(runCtx, run.Prompt)
+		if !handled && err == nil {
+			err = errors.New("codemode declined the request on resume")
+		}
+	}
+	return a.finishCodeRun(ctx, run, rec, out, err)
+}
+
+// finishCodeRun stores the outcome of an attempt.
+func (a *Agent) finishCodeRun(ctx context.Context, run CodeRun, rec *codeRunRecorder, out any, runErr error) (any, error) {
+	run.Steps = rec.snapshot()
+	run.UpdatedAt = time.Now().UTC()
+	if runErr != nil {
+		run.Status, run.Error, run.Result = CodeRunFailed, runErr.Error(), nil
+		run.FailedStep = len(run.Steps)
+		for i, step := range run.Steps {
+			if step.Error != "" {
+				run.FailedStep = i
+				break
+			}
+		}
+	} else {
+		run.Status, run.Error, run.Result, run.FailedStep = CodeRunCompleted, "", out, -1
+	}
+	if err := a.CodeRuns.Save(ctx, run); err != nil {
+		return out, errors.Join(runErr, fmt.Errorf("persist codemode run %s: %w", run.ID, err))
+	}
+	if runErr != nil {
+		if budgetErr := budgetExceeded(ctx); budgetErr != nil {
+			runErr = budgetErr
+		}
+		return nil, &CodeRunError{RunID: run.ID, Step: run.FailedStep, Err: runErr}
+	}
+	return out, nil
+}
+
+// executeCode runs a script and shapes its result as codemode.run_code
+// returns it.
+func (a *Agent) executeCode(ctx context.Context, code string, timeout int) (any, error) {
+	result, err := a.CodeMode.Execute(ctx, codemode.CodeModeArgs{
+		Code:    code,
+		Timeout: timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(result.Stderr) != "" {
+		return nil, fmt.Errorf("codemode script produced stderr: %s", result.Stderr)
+	}
+
+	if result.Stdout != "" {
+		return map[string]any{
+			"value":  result.Value,
+			"stdout": result.Stdout,
+		}, nil
+	}
+
+	return result.Value, nil
+}
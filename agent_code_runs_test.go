@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/plugins/codemode"
+)
+
+// flakyUTCPClient fails the first call to failOnce and answers the rest.
+type flakyUTCPClient struct {
+	*stubUTCPClient
+	failOnce string
+	calls    map[string]int
+}
+
+func (c *flakyUTCPClient) CallTool(ctx context.Context, name string, args map[string]any) (any, error) {
+	c.calls[name]++
+	if name == c.failOnce && c.calls[name] == 1 {
+		return nil, errors.New("upstream unavailable")
+	}
+	return fmt.Sprintf("%s(%v)", name, args["input"]), nil
+}
+
+func TestResumeCodeRunRestoresCompletedSteps(t *testing.T) {
+	ctx := context.Background()
+	client := &flakyUTCPClient{stubUTCPClient: &stubUTCPClient{}, failOnce: "store", calls: map[string]int{}}
+	model := &stubModel{}
+	a, err := New(Options{
+		Model:            model,
+		Memory:           memory.NewSessionMemory(&memory.MemoryBank{}, 0),
+		CodeMode:         codemode.NewCodeModeUTCP(RecordCodeRunSteps(client), model),
+		CodeRuns:         NewInMemoryCodeRunStore(),
+		AllowUnsafeTools: true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	code := `
+		var a, b any
+		var err error
+		a, err = codemode.CallTool("fetch", map[string]any{"input": "x"})
+		if err != nil {
+			return err
+		}
+		b, err = codemode.CallTool("store", map[string]any{"input": a})
+		if err != nil {
+			return err
+		}
+		__out = b
+	`
+	_, err = a.invokeTool(ctx, "s1", "codemode.run_code", map[string]any{"code": code})
+	var runErr *CodeRunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("expected CodeRunError, got %v", err)
+	}
+	if runErr.Step != 1 {
+		t.Fatalf("expected failure at step 1, got %d", runErr.Step)
+	}
+	run, err := a.CodeRuns.Load(ctx, runErr.RunID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if run.Status != CodeRunFailed || run.Code == "" || len(run.Steps) != 2 || run.Steps[1].Error == "" {
+		t.Fatalf("unexpected persisted run: %+v", run)
+	}
+
+	out, err := a.ResumeCodeRun(ctx, runErr.RunID)
+	if err != nil {
+		t.Fatalf("ResumeCodeRun: %v", err)
+	}
+	if out != "store(fetch(x))" {
+		t.Fatalf("unexpected result %v", out)
+	}
+	if client.calls["fetch"] != 1 || client.calls["store"] != 2 {
+		t.Fatalf("expected fetch to be restored and store retried, got %v", client.calls)
+	}
+
+	run, _ = a.CodeRuns.Load(ctx, runErr.RunID)
+	if run.Status != CodeRunCompleted || run.Attempts != 2 || !run.Steps[0].Replayed || run.FailedStep != -1 {
+		t.Fatalf("unexpected run after resume: %+v", run)
+	}
+	if again, err := a.ResumeCodeRun(ctx, run.ID); err != nil || again != out {
+		t.Fatalf("resuming a completed run should return its result, got %v, %v", again, err)
+	}
+	if client.calls["store"] != 2 {
+		t.Fatalf("completed run must not execute again")
+	}
+}
+
+func TestResumeCodeRunUnknown(t *testing.T) {
+	a, _ := New(Options{Model: &stubModel{}, Memory: memory.NewSessionMemory(&memory.MemoryBank{}, 0), CodeMode: codemode.NewCodeModeUTCP(&stubUTCPClient{}, &stubModel{}), CodeRuns: NewInMemoryCodeRunStore()})
+	if _, err := a.ResumeCodeRun(context.Background(), "missing"); !errors.Is(err, ErrCodeRunNotFound) {
+		t.Fatalf("expected ErrCodeRunNotFound, got %v", err)
+	}
+}
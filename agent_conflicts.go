@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ModelConflictJudge asks a model whether a new memory contradicts an
+// existing one. Install it with engine.WithConflictJudge.
+type ModelConflictJudge struct {
+	Model models.Agent
+}
+
+var _ memory.ConflictJudge = ModelConflictJudge{}
+
+const conflictJudgePrompt = `Do these two notes contradict each other, so that they cannot both be true?
+Notes that add detail, change topic or describe different times without claiming otherwise do not contradict.
+
+Earlier note: %s
+New note: %s
+
+Answer with exactly YES or NO.`
+
+func (j ModelConflictJudge) Contradicts(ctx context.Context, existing, incoming string) (bool, error) {
+	if j.Model == nil {
+		return false, errors.New("conflict judge has no model")
+	}
+	raw, err := j.Model.Generate(ctx, fmt.Sprintf(conflictJudgePrompt, truncate(sanitizeInput(existing), 1000), truncate(sanitizeInput(incoming), 1000)))
+	if err != nil {
+		return false, err
+	}
+	answer := strings.ToUpper(strings.TrimSpace(fmt.Sprint(raw)))
+	switch {
+	case strings.HasPrefix(answer, "YES"):
+		return true, nil
+	case strings.HasPrefix(answer, "NO"):
+		return false, nil
+	}
+	return false, fmt.Errorf("conflict judge: unexpected reply %q", truncate(answer, 40))
+}
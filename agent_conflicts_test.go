@@ -0,0 +1,19 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestModelConflictJudge(t *testing.T) {
+	ctx := context.Background()
+	for reply, want := range map[string]bool{"YES": true, "No.": false} {
+		conflict, err := ModelConflictJudge{Model: &stubModel{response: reply}}.Contradicts(ctx, "a", "b")
+		if err != nil || conflict != want {
+			t.Fatalf("reply %q: Contradicts = %v, %v; want %v", reply, conflict, err, want)
+		}
+	}
+	if _, err := (ModelConflictJudge{Model: &stubModel{response: "unsure"}}).Contradicts(ctx, "a", "b"); err == nil {
+		t.Fatal("expected an error for an unclear reply")
+	}
+}
@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// ContextProvider produces a dynamic prompt section for a session, such as
+// the current time, open tasks or environment status. Providers run on every
+// turn; returning an empty string leaves the section out.
+type ContextProvider func(ctx context.Context, sessionID string) (string, error)
+
+type namedContextProvider struct {
+	name    string
+	provide ContextProvider
+}
+
+// AddContextProvider registers p under name. Sections render in registration
+// order, after the system prompt; registering a name again replaces its
+// provider.
+func (a *Agent) AddContextProvider(name string, p ContextProvider) {
+	if p == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	providers := append([]namedContextProvider(nil), a.contextProviders...)
+	for i := range providers {
+		if providers[i].name == name {
+			providers[i].provide = p
+			a.contextProviders = providers
+			return
+		}
+	}
+	a.contextProviders = append(providers, namedContextProvider{name: name, provide: p})
+}
+
+// RemoveContextProvider unregisters the provider called name.
+func (a *Agent) RemoveContextProvider(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var kept []namedContextProvider
+	for _, p := range a.contextProviders {
+		if p.name != name {
+			kept = append(kept, p)
+		}
+	}
+	a.contextProviders = kept
+}
+
+// sortedContextProviders turns Options.ContextProviders into a deterministic
+// registration order.
+func sortedContextProviders(providers map[string]ContextProvider) []namedContextProvider {
+	names := make([]string, 0, len(providers))
+	for name, p := range providers {
+		if p != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := make([]namedContextProvider, 0, len(names))
+	for _, name := range names {
+		out = append(out, namedContextProvider{name: name, provide: providers[name]})
+	}
+	return out
+}
+
+// renderContextSections runs every provider concurrently and renders their
+// sections as "<name>:\n<text>" blocks, each followed by a blank line. The
+// first provider error fails the turn.
+func (a *Agent) renderContextSections(ctx context.Context, sessionID string) (string, error) {
+	a.mu.Lock()
+	providers := a.contextProviders
+	a.mu.Unlock()
+	if len(providers) == 0 {
+		return "", nil
+	}
+
+	texts := make([]string, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			texts[i], errs[i] = p.provide(ctx, sessionID)
+		}()
+	}
+	wg.Wait()
+
+	var sb strings.Builder
+	for i, p := range providers {
+		if errs[i] != nil {
+			return "", fmt.Errorf("context provider %s: %w", p.name, errs[i])
+		}
+		text := strings.TrimSpace(texts[i])
+		if text == "" {
+			continue
+		}
+		sb.WriteString(p.name)
+		sb.WriteString(":\n")
+		sb.WriteString(text)
+		sb.WriteString("\n\n")
+	}
+	return sb.String(), nil
+}
+
+// CurrentTimeProvider reports the current time in loc (UTC when nil), so
+// the model can resolve relative dates.
+func CurrentTimeProvider(loc *time.Location) ContextProvider {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return func(context.Context, string) (string, error) {
+		return time.Now().In(loc).Format("Monday, 2006-01-02 15:04 MST"), nil
+	}
+}
+
+// SimilarSessionsProvider lists the summaries of up to k past sessions that
+// resemble the current one, found with Engine.FindSimilarSessions. Sessions
+// without stored memories yet get no section.
+func SimilarSessionsProvider(e *memory.Engine, k int) ContextProvider {
+	return func(ctx context.Context, sessionID string) (string, error) {
+		if e == nil {
+			return "", nil
+		}
+		similar, err := e.FindSimilarSessions(ctx, sessionID, k)
+		if errors.Is(err, memory.ErrUnknownSession) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		for _, s := range similar {
+			if strings.TrimSpace(s.Summary) == "" {
+				continue
+			}
+			fmt.Fprintf(&sb, "- %s (similarity %.2f): %s\n", s.SessionID, s.Similarity, escapePromptContent(s.Summary))
+		}
+		return sb.String(), nil
+	}
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestContextProvidersAreEvaluatedPerTurn(t *testing.T) {
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	model := &coordinatorModel{replies: []string{"one", "two"}}
+	calls := 0
+	a, err := New(Options{
+		Model:  model,
+		Memory: mem,
+		ContextProviders: map[string]ContextProvider{
+			"Open tasks": func(_ context.Context, session string) (string, error) {
+				calls++
+				return fmt.Sprintf("%d open for %s", calls, session), nil
+			},
+			"Feature flags": func(context.Context, string) (string, error) { return "", nil },
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a.AddContextProvider("Environment", func(context.Context, string) (string, error) { return "staging", nil })
+
+	ctx := context.Background()
+	for _, input := range []string{"What should I work on next today", "And after that one is finished"} {
+		if _, err := a.Generate(ctx, "alice", input); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+	if !strings.Contains(model.prompts[0], "Open tasks:\n1 open for alice\n\nEnvironment:\nstaging\n\n") {
+		t.Fatalf("first prompt missing sections:\n%s", model.prompts[0])
+	}
+	if !strings.Contains(model.prompts[1], "Open tasks:\n2 open for alice") {
+		t.Fatalf("second prompt not refreshed:\n%s", model.prompts[1])
+	}
+	if strings.Contains(model.prompts[0], "Feature flags") {
+		t.Fatalf("empty section rendered:\n%s", model.prompts[0])
+	}
+
+	a.RemoveContextProvider("Environment")
+	a.AddContextProvider("Open tasks", func(context.Context, string) (string, error) { return "", errors.New("tracker down") })
+	if _, err := a.Generate(ctx, "alice", "Anything else pending for me"); err == nil || !strings.Contains(err.Error(), "context provider Open tasks: tracker down") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+}
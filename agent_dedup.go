@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ModelDuplicateJudge asks a model whether two memories state the same
+// fact. Install it with engine.WithDuplicateJudge; a small, cheap model is
+// enough.
+type ModelDuplicateJudge struct {
+	Model models.Agent
+}
+
+var _ memory.DuplicateJudge = ModelDuplicateJudge{}
+
+const duplicateJudgePrompt = `Do these two notes state the same fact, so that keeping both would be redundant?
+Notes that share a topic but differ in any detail (names, numbers, dates, decisions) are different.
+
+Note A: %s
+Note B: %s
+
+Answer with exactly YES or NO.`
+
+func (j ModelDuplicateJudge) SameFact(ctx context.Context, existing, incoming string) (bool, error) {
+	if j.Model == nil {
+		return false, errors.New("duplicate judge has no model")
+	}
+	raw, err := j.Model.Generate(ctx, fmt.Sprintf(duplicateJudgePrompt, truncate(sanitizeInput(existing), 1000), truncate(sanitizeInput(incoming), 1000)))
+	if err != nil {
+		return false, err
+	}
+	answer := strings.ToUpper(strings.TrimSpace(fmt.Sprint(raw)))
+	switch {
+	case strings.HasPrefix(answer, "YES"):
+		return true, nil
+	case strings.HasPrefix(answer, "NO"):
+		return false, nil
+	}
+	return false, fmt.Errorf("duplicate judge: unexpected reply %q", truncate(answer, 40))
+}
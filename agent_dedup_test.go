@@ -0,0 +1,19 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestModelDuplicateJudge(t *testing.T) {
+	ctx := context.Background()
+	for reply, want := range map[string]bool{"YES": true, "no": false} {
+		same, err := ModelDuplicateJudge{Model: &stubModel{response: reply}}.SameFact(ctx, "a", "b")
+		if err != nil || same != want {
+			t.Fatalf("reply %q: SameFact = %v, %v; want %v", reply, same, err, want)
+		}
+	}
+	if _, err := (ModelDuplicateJudge{Model: &stubModel{response: "maybe"}}).SameFact(ctx, "a", "b"); err == nil {
+		t.Fatal("expected an error for an unclear reply")
+	}
+}
@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/flags"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// defaultMaxDelegations bounds the auto-delegation loop when
+// MaxDelegations is unset.
+const defaultMaxDelegations = 4
+
+// delegateAction is the structured reply a coordinator gives to hand part of
+// a request to a sub-agent:
+//
+//	{"delegate": {"subagent": "researcher", "instruction": "..."}}
+type delegateAction struct {
+	Delegate *struct {
+		SubAgent    string `json:"subagent"`
+		Instruction string `json:"instruction"`
+	} `json:"delegate"`
+}
+
+// parseDelegateAction returns the requested delegation in a coordinator
+// reply, if the reply is one.
+func parseDelegateAction(reply string) (name, instruction string, ok bool) {
+	jsonStr := extractJSON(reply)
+	if jsonStr == "" {
+		return "", "", false
+	}
+	var action delegateAction
+	if err := json.Unmarshal([]byte(jsonStr), &action); err != nil || action.Delegate == nil {
+		return "", "", false
+	}
+	name = strings.TrimSpace(action.Delegate.SubAgent)
+	instruction = strings.TrimSpace(action.Delegate.Instruction)
+	if name == "" || instruction == "" {
+		return "", "", false
+	}
+	return name, instruction, true
+}
+
+func renderSubAgentsForPrompt(subs []SubAgent) string {
+	var sb strings.Builder
+	for _, sa := range subs {
+		fmt.Fprintf(&sb, "- %s: %s\n", sa.Name(), strings.TrimSpace(sa.Description()))
+	}
+	return sb.String()
+}
+
+// complete runs the final model call for a turn on the messages from
+// renderMessages. With AutoDelegate set and sub-agents registered, the model
+// acts as a coordinator: it sees each sub-agent's description, may answer
+// with a delegate action instead of a reply, and gets each delegation's
+// result folded into the user message until it answers or MaxDelegations is
+// reached.
+func (a *Agent) complete(ctx context.Context, sessionID string, messages []models.Message) (any, error) {
+	generate := func(suffix string) (any, error) {
+		turn := append([]models.Message(nil), messages...)
+		if last := len(turn) - 1; last >= 0 {
+			turn[last].Content += suffix
+		}
+		prompt, _ := models.FlattenMessages(turn)
+		if err := chargeTokens(ctx, prompt); err != nil {
+			return nil, err
+		}
+		reply, err := models.Chat(ctx, a.modelFor(ctx), turn)
+		if err != nil {
+			return nil, err
+		}
+		_ = chargeTokens(ctx, reply.Content)
+		return reply.Content, nil
+	}
+	subs := a.SubAgents()
+	if !flags.Bool(ctx, a.Flags, flags.AutoDelegate, a.AutoDelegate) || len(subs) == 0 {
+		return generate("")
+	}
+
+	maxDelegations := a.MaxDelegations
+	if maxDelegations <= 0 {
+		maxDelegations = defaultMaxDelegations
+	}
+	roster := renderSubAgentsForPrompt(subs)
+
+	var (
+		observations []string
+		lastCall     string
+	)
+	for step := 1; ; step++ {
+		var sb strings.Builder
+		sb.Grow(1024)
+		sb.WriteString("\nSPECIALIST SUB-AGENTS:\n")
+		sb.WriteString(roster)
+		if len(observations) > 0 {
+			sb.WriteString("\nDELEGATION RESULTS:\n")
+			sb.WriteString(strings.Join(observations, "\n\n"))
+			sb.WriteString("\n")
+		}
+		if step > maxDelegations {
+			sb.WriteString("\nAnswer the user now using the delegation results. Do not delegate again.\n")
+			return generate(sb.String())
+		}
+		sb.WriteString(`
+If a sub-agent above is better placed to handle the request, or part of it,
+reply ONLY with JSON:
+{"delegate": {"subagent": "<name>", "instruction": "<self-contained task>"}}
+Otherwise reply to the user directly, using any delegation results above.
+`)
+
+		reply, err := generate(sb.String())
+		if err != nil {
+			return nil, err
+		}
+		name, instruction, ok := parseDelegateAction(fmt.Sprint(reply))
+		if !ok {
+			return reply, nil
+		}
+		sa, found := a.lookupSubAgent(name)
+		if !found {
+			return nil, fmt.Errorf("unknown subagent: %s", name)
+		}
+
+		// Asking the same sub-agent the same thing again will not move the
+		// coordinator forward; make it answer with what it has.
+		call := strings.ToLower(sa.Name()) + "\x00" + instruction
+		if call == lastCall {
+			step = maxDelegations
+			continue
+		}
+		lastCall = call
+
+		res, err := a.runSubAgent(ctx, sessionID, sa, instruction, nil)
+		output := res.Output
+		meta := map[string]string{"subagent": sa.Name(), "source": "delegation"}
+		switch {
+		case err != nil:
+			output = "error: " + err.Error()
+		case res.Partial:
+			output += partialNotice(sa.Name(), a.SubAgentTimeout)
+			meta["partial"] = "true"
+		}
+		if err == nil {
+			a.storeMemory(ctx, sessionID, "subagent", output, meta)
+		}
+		observations = append(observations, fmt.Sprintf(
+			"[delegation %d] subagent=%s instruction=%q\nresult=%s",
+			step, sa.Name(), instruction, truncate(output, defaultToolObservationMaxBytes),
+		))
+	}
+}
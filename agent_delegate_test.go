@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/flags"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// coordinatorModel replays scripted replies and records the prompts it saw.
+type coordinatorModel struct {
+	mu      sync.Mutex
+	replies []string
+	prompts []string
+}
+
+func (m *coordinatorModel) Generate(_ context.Context, prompt string) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	if len(m.replies) == 0 {
+		return "no more replies", nil
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return reply, nil
+}
+
+func (m *coordinatorModel) GenerateWithFiles(ctx context.Context, prompt string, _ []models.File) (any, error) {
+	return m.Generate(ctx, prompt)
+}
+
+func (m *coordinatorModel) GenerateStream(ctx context.Context, prompt string) (<-chan models.StreamChunk, error) {
+	out, _ := m.Generate(ctx, prompt)
+	ch := make(chan models.StreamChunk, 1)
+	ch <- models.StreamChunk{Delta: fmt.Sprint(out), FullText: fmt.Sprint(out), Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestAutoDelegateFoldsSubAgentResultsIntoAnswer(t *testing.T) {
+	model := &coordinatorModel{replies: []string{
+		`{"delegate": {"subagent": "analyst", "instruction": "Find Q3 revenue"}}`,
+		"Q3 revenue was reported by the analyst.",
+	}}
+	sa := &countingSubAgent{}
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 8).WithEmbedder(memory.DummyEmbedder{})
+	a, err := New(Options{Model: model, Memory: mem, SubAgents: []SubAgent{sa}, AutoDelegate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := a.Generate(context.Background(), "s1", "what is our Q3 revenue?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Q3 revenue was reported by the analyst." {
+		t.Fatalf("output = %v", out)
+	}
+	if sa.runs != 1 {
+		t.Fatalf("sub-agent runs = %d", sa.runs)
+	}
+	if len(model.prompts) != 2 {
+		t.Fatalf("model calls = %d", len(model.prompts))
+	}
+	if !strings.Contains(model.prompts[0], "- analyst: answers questions") {
+		t.Fatalf("coordinator prompt lacks sub-agent descriptions:\n%s", model.prompts[0])
+	}
+	if !strings.Contains(model.prompts[1], "answer 1 to Find Q3 revenue") {
+		t.Fatalf("delegation result was not folded back:\n%s", model.prompts[1])
+	}
+}
+
+func TestAutoDelegateStopsAtMaxDelegations(t *testing.T) {
+	model := &coordinatorModel{replies: []string{
+		`{"delegate": {"subagent": "analyst", "instruction": "step one"}}`,
+		`{"delegate": {"subagent": "analyst", "instruction": "step two"}}`,
+		"final answer",
+	}}
+	sa := &countingSubAgent{}
+	a, err := New(Options{Model: model, Memory: memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{}), SubAgents: []SubAgent{sa}, AutoDelegate: true, MaxDelegations: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := a.Generate(context.Background(), "s1", "what is the plan?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sa.runs != 1 {
+		t.Fatalf("sub-agent runs = %d, want 1", sa.runs)
+	}
+	last := model.prompts[len(model.prompts)-1]
+	if !strings.Contains(last, "Do not delegate again") {
+		t.Fatalf("final prompt should forbid delegation:\n%s", last)
+	}
+	if out == "" {
+		t.Fatal("expected an answer")
+	}
+}
+
+func TestAutoDelegateFlagOverridesOption(t *testing.T) {
+	model := &coordinatorModel{replies: []string{
+		`{"delegate": {"subagent": "analyst", "instruction": "Find Q3 revenue"}}`,
+		"Q3 revenue was reported by the analyst.",
+	}}
+	sa := &countingSubAgent{}
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 8).WithEmbedder(memory.DummyEmbedder{})
+	a, err := New(Options{Model: model, Memory: mem, SubAgents: []SubAgent{sa}, Flags: flags.Static{flags.AutoDelegate: "true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Generate(context.Background(), "s1", "what is our Q3 revenue?"); err != nil {
+		t.Fatal(err)
+	}
+	if sa.runs != 1 {
+		t.Fatalf("sub-agent runs = %d, want the flag to enable delegation", sa.runs)
+	}
+	if !a.Manifest().Limits.AutoDelegate {
+		t.Fatal("manifest should report the flagged value")
+	}
+}
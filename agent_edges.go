@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ModelEdgeInferrer asks a model how a new memory relates to its nearest
+// stored ones. Install it with engine.WithEdgeInferrer.
+type ModelEdgeInferrer struct {
+	Model models.Agent
+}
+
+var _ memory.EdgeInferrer = ModelEdgeInferrer{}
+
+const edgeInferrerPrompt = `Decide how the new note relates to each earlier note.
+Use "follows" when it continues the earlier note, "explains" when it gives the reason for or details of it,
+"derived_from" when it restates or is built from it, and "relates_to" for other clear links.
+Leave out earlier notes that are unrelated.
+
+New note: %s
+
+Earlier notes:
+%s
+Reply with JSON only, in the form {"edges":[{"note":1,"type":"explains"}]}.
+Reply with {"edges":[]} when no earlier note is related.`
+
+func (x ModelEdgeInferrer) InferEdges(ctx context.Context, incoming memory.MemoryRecord, nearest []memory.MemoryRecord) ([]memory.GraphEdge, error) {
+	if x.Model == nil {
+		return nil, errors.New("edge inferrer has no model")
+	}
+	var notes strings.Builder
+	for i, rec := range nearest {
+		fmt.Fprintf(&notes, "%d. %s\n", i+1, truncate(sanitizeInput(rec.Content), 500))
+	}
+	raw, err := x.Model.Generate(ctx, fmt.Sprintf(edgeInferrerPrompt, truncate(sanitizeInput(incoming.Content), 1000), notes.String()))
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		Edges []struct {
+			Note int             `json:"note"`
+			Type memory.EdgeType `json:"type"`
+		} `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(fmt.Sprint(raw))), &reply); err != nil {
+		return nil, fmt.Errorf("edge inferrer: decode reply: %w", err)
+	}
+	var edges []memory.GraphEdge
+	for _, e := range reply.Edges {
+		if e.Note < 1 || e.Note > len(nearest) {
+			continue
+		}
+		edges = append(edges, memory.GraphEdge{Target: nearest[e.Note-1].ID, Type: e.Type})
+	}
+	return edges, nil
+}
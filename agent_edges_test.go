@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestModelEdgeInferrer(t *testing.T) {
+	ctx := context.Background()
+	nearest := []memory.MemoryRecord{{ID: 7, Content: "Checkout is down"}, {ID: 9, Content: "Lunch is at noon"}}
+	incoming := memory.MemoryRecord{Content: "Checkout is down because the payment keys rotated"}
+	reply := "```json\n{\"edges\":[{\"note\":1,\"type\":\"explains\"},{\"note\":5,\"type\":\"follows\"}]}\n```"
+	edges, err := ModelEdgeInferrer{Model: &stubModel{response: reply}}.InferEdges(ctx, incoming, nearest)
+	if err != nil {
+		t.Fatalf("InferEdges: %v", err)
+	}
+	if len(edges) != 1 || edges[0] != (memory.GraphEdge{Target: 7, Type: memory.EdgeType("explains")}) {
+		t.Fatalf("unexpected edges: %+v", edges)
+	}
+	if _, err := (ModelEdgeInferrer{Model: &stubModel{err: errors.New("offline")}}).InferEdges(ctx, incoming, nearest); err == nil {
+		t.Fatal("expected the model error")
+	}
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ModelFactExtractor asks a model for the (subject, predicate, object)
+// facts a memory states. Install it with engine.WithFactExtractor.
+type ModelFactExtractor struct {
+	Model models.Agent
+}
+
+var _ memory.FactExtractor = ModelFactExtractor{}
+
+const factExtractorPrompt = `Extract the facts stated in the note below as subject-predicate-object triples.
+Use short, canonical names for subjects and objects (e.g. "Alice", "Acme Corp", "the deploy pipeline").
+Only include facts the note states outright; skip greetings, questions and opinions.
+
+Note: %s
+
+Reply with JSON only, in the form {"facts":[{"subject":"...","predicate":"...","object":"..."}]}.
+Reply with {"facts":[]} when the note states no facts.`
+
+func (x ModelFactExtractor) ExtractFacts(ctx context.Context, content string) ([]memory.Fact, error) {
+	if x.Model == nil {
+		return nil, errors.New("fact extractor has no model")
+	}
+	raw, err := x.Model.Generate(ctx, fmt.Sprintf(factExtractorPrompt, truncate(sanitizeInput(content), 2000)))
+	if err != nil {
+		return nil, err
+	}
+	var reply struct {
+		Facts []memory.Fact `json:"facts"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(fmt.Sprint(raw))), &reply); err != nil {
+		return nil, fmt.Errorf("fact extractor: decode reply: %w", err)
+	}
+	return reply.Facts, nil
+}
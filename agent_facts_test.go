@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestModelFactExtractor(t *testing.T) {
+	ctx := context.Background()
+	reply := "```json\n{\"facts\":[{\"subject\":\"Alice\",\"predicate\":\"works at\",\"object\":\"Acme\"}]}\n```"
+	facts, err := ModelFactExtractor{Model: &stubModel{response: reply}}.ExtractFacts(ctx, "Alice works at Acme.")
+	if err != nil {
+		t.Fatalf("ExtractFacts: %v", err)
+	}
+	if len(facts) != 1 || facts[0].Subject != "Alice" || facts[0].Predicate != "works at" || facts[0].Object != "Acme" {
+		t.Fatalf("unexpected facts: %+v", facts)
+	}
+	if _, err := (ModelFactExtractor{Model: &stubModel{err: errors.New("offline")}}).ExtractFacts(ctx, "hi"); err == nil {
+		t.Fatal("expected the model error")
+	}
+}
@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+const (
+	// FeedbackImportanceStep is how far a rating of ±1 moves the importance
+	// of each memory retrieved for the rated turn.
+	FeedbackImportanceStep = 0.2
+
+	// minFeedbackImportance keeps downvoted memories above zero, which the
+	// memory engine treats as unscored and recomputes.
+	minFeedbackImportance = 0.01
+
+	turnHistorySize = 1024
+	turnHistoryTTL  = 24 * time.Hour
+)
+
+// ErrUnknownTurn is returned by Feedback for turns that were never recorded
+// or have aged out of the turn history.
+var ErrUnknownTurn = errors.New("unknown turn")
+
+// Turn is one model-completed exchange the agent can take feedback on.
+type Turn struct {
+	ID           string
+	SessionID    string
+	Input        string
+	Output       string
+	SystemPrompt string
+	// Context holds the memories retrieved for the turn.
+	Context []memory.MemoryRecord
+	Latency time.Duration
+	At      time.Time
+	// Scope is the call scope of the turn when the agent ran nested under
+	// another agent or a workflow.
+	Scope CallScope
+}
+
+// Feedback is a user's verdict on a turn.
+type Feedback struct {
+	Turn Turn
+	// Rating runs from -1 (bad) to 1 (good).
+	Rating  float64
+	Comment string
+	At      time.Time
+}
+
+// FeedbackSink receives every piece of feedback, for example a selfevolve
+// dataset collecting evaluation cases from production traffic.
+type FeedbackSink interface {
+	RecordFeedback(ctx context.Context, fb Feedback) error
+}
+
+// recordTurn remembers a completed turn for later feedback and returns its
+// ID, which is also written to the assistant memory's metadata.
+func (a *Agent) recordTurn(ctx context.Context, sessionID, input, output string, records []memory.MemoryRecord, started time.Time) string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	turn := Turn{
+		ID:        hex.EncodeToString(b[:]),
+		SessionID: sessionID,
+		Input:     input,
+		Output:    output,
+		Context:   append([]memory.MemoryRecord(nil), records...),
+		Latency:   time.Since(started),
+		At:        time.Now().UTC(),
+	}
+	turn.SystemPrompt = a.systemPromptFor(sessionID)
+	turn.Scope, _ = CallScopeFromContext(ctx)
+	a.mu.Lock()
+	if a.turns == nil {
+		a.turns = cache.NewLRUCache(turnHistorySize, turnHistoryTTL)
+	}
+	turns, prompts, mem := a.turns, a.Prompts, a.memory
+	a.mu.Unlock()
+
+	turns.Set(turn.ID, turn)
+	turns.Set("last:"+sessionID, turn.ID)
+	a.trackSession(ctx, turn)
+	if mem != nil {
+		mem.Engine.ObserveAnswer(records, output)
+	}
+	if observer, ok := prompts.(TurnObserver); ok {
+		observer.ObserveTurn(ctx, turn)
+	}
+	return turn.ID
+}
+
+// LastTurn returns the most recent turn recorded for sessionID.
+func (a *Agent) LastTurn(sessionID string) (Turn, bool) {
+	a.mu.Lock()
+	turns := a.turns
+	a.mu.Unlock()
+	if turns == nil {
+		return Turn{}, false
+	}
+	id, ok := turns.Get("last:" + sessionID)
+	if !ok {
+		return Turn{}, false
+	}
+	v, ok := turns.Get(id.(string))
+	if !ok {
+		return Turn{}, false
+	}
+	return v.(Turn), true
+}
+
+// Feedback records a user's rating of a turn. It stores the feedback in the
+// session's long-term memory, nudges the importance of every memory used to
+// answer the turn by FeedbackImportanceStep times the rating (when the
+// store implements memory.ImportanceUpdater), and forwards the feedback to
+// the agent's FeedbackSink. Ratings are clamped to [-1, 1].
+func (a *Agent) Feedback(ctx context.Context, sessionID, turnID string, rating float64, comment string) error {
+	a.mu.Lock()
+	turns, sink, mem := a.turns, a.FeedbackSink, a.memory
+	a.mu.Unlock()
+
+	var turn Turn
+	if turns != nil {
+		if v, ok := turns.Get(turnID); ok {
+			turn = v.(Turn)
+		}
+	}
+	if turn.ID == "" || turn.SessionID != sessionID {
+		return fmt.Errorf("%w %q in session %q", ErrUnknownTurn, turnID, sessionID)
+	}
+	fb := Feedback{
+		Turn:    turn,
+		Rating:  max(-1, min(1, rating)),
+		Comment: strings.TrimSpace(comment),
+		At:      time.Now().UTC(),
+	}
+
+	if a.ReadOnly {
+		mem = nil
+	}
+	var errs []error
+	if err := storeFeedback(ctx, mem, fb); err != nil {
+		errs = append(errs, fmt.Errorf("store feedback: %w", err))
+	}
+	if err := adjustImportance(ctx, mem, turn.Context, fb.Rating); err != nil {
+		errs = append(errs, fmt.Errorf("adjust importance: %w", err))
+	}
+	if sink != nil {
+		if err := sink.RecordFeedback(ctx, fb); err != nil {
+			errs = append(errs, fmt.Errorf("feedback sink: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func storeFeedback(ctx context.Context, mem *memory.SessionMemory, fb Feedback) error {
+	if mem == nil {
+		return nil
+	}
+	content := fmt.Sprintf("User rated the answer to %q %+.1f", truncate(strings.TrimSpace(fb.Turn.Input), 200), fb.Rating)
+	if fb.Comment != "" {
+		content += ": " + fb.Comment
+	}
+	meta := map[string]any{
+		"role":    "feedback",
+		"source":  "feedback",
+		"turn_id": fb.Turn.ID,
+		"rating":  fb.Rating,
+	}
+	if mem.Engine != nil {
+		_, err := mem.Engine.Store(ctx, fb.Turn.SessionID, content, meta)
+		return err
+	}
+	if mem.Bank == nil || mem.Bank.Store == nil {
+		return nil
+	}
+	embedding, err := mem.Embed(ctx, content)
+	if err != nil {
+		return err
+	}
+	return mem.Bank.Store.StoreMemory(ctx, fb.Turn.SessionID, content, meta, embedding)
+}
+
+// adjustImportance rescores the long-term records behind a turn. Short-term
+// records have no ID and are skipped.
+func adjustImportance(ctx context.Context, mem *memory.SessionMemory, records []memory.MemoryRecord, rating float64) error {
+	if mem == nil || rating == 0 {
+		return nil
+	}
+	var updater memory.ImportanceUpdater
+	if mem.Engine != nil {
+		updater = mem.Engine
+	} else if mem.Bank != nil {
+		updater, _ = mem.Bank.Store.(memory.ImportanceUpdater)
+	}
+	if updater == nil {
+		return nil
+	}
+	var errs []error
+	for _, rec := range records {
+		if rec.ID == 0 {
+			continue
+		}
+		importance := max(minFeedbackImportance, min(1, rec.Importance+FeedbackImportanceStep*rating))
+		if err := updater.UpdateImportance(ctx, rec.ID, importance); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+type feedbackRecorder struct{ got []Feedback }
+
+func (r *feedbackRecorder) RecordFeedback(_ context.Context, fb Feedback) error {
+	r.got = append(r.got, fb)
+	return nil
+}
+
+func TestFeedbackAdjustsImportanceOfUsedMemories(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 8).WithEmbedder(memory.DummyEmbedder{})
+	embedding, _ := mem.Embed(ctx, "refund policy: 30 days")
+	if err := store.StoreMemory(ctx, "s1", "refund policy: 30 days", map[string]any{"importance": 0.5}, embedding); err != nil {
+		t.Fatal(err)
+	}
+	sink := &feedbackRecorder{}
+	a, err := New(Options{Model: &stubModel{response: "ok"}, Memory: mem, FeedbackSink: sink})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Generate(ctx, "s1", "what is the refund policy?"); err != nil {
+		t.Fatal(err)
+	}
+	turn, ok := a.LastTurn("s1")
+	if !ok || turn.Input != "what is the refund policy?" || len(turn.Context) == 0 {
+		t.Fatalf("turn not recorded: %+v", turn)
+	}
+
+	if err := a.Feedback(ctx, "other", turn.ID, 1, ""); !errors.Is(err, ErrUnknownTurn) {
+		t.Fatalf("feedback from another session should be rejected, got %v", err)
+	}
+	if err := a.Feedback(ctx, "s1", turn.ID, 5, "spot on"); err != nil {
+		t.Fatal(err)
+	}
+
+	var importance float64
+	var feedbackStored bool
+	_ = store.Iterate(ctx, func(rec memory.MemoryRecord) bool {
+		switch rec.Content {
+		case "refund policy: 30 days":
+			importance = rec.Importance
+		default:
+			feedbackStored = feedbackStored || rec.Source == "feedback"
+		}
+		return true
+	})
+	if math.Abs(importance-(0.5+FeedbackImportanceStep)) > 1e-9 {
+		t.Fatalf("importance = %v, want %v", importance, 0.5+FeedbackImportanceStep)
+	}
+	if !feedbackStored {
+		t.Fatal("feedback should be stored in long-term memory")
+	}
+	if len(sink.got) != 1 || sink.got[0].Rating != 1 || sink.got[0].Comment != "spot on" {
+		t.Fatalf("sink got %+v", sink.got)
+	}
+}
+
+func TestTurnsTeachTheEngineWhichSourcesAnswersUse(t *testing.T) {
+	ctx := context.Background()
+	learner, _ := memory.NewSourceLearner("")
+	engine := memory.NewEngine(memory.NewInMemoryStore(), memory.Options{}).WithEmbedder(memory.DummyEmbedder{}).WithSourceLearner(learner)
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 8).WithEngine(engine)
+	if _, err := engine.Store(ctx, "s1", "refund policy: 30 days", map[string]any{"source": "handbook"}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(Options{Model: &stubModel{response: "The refund policy is 30 days."}, Memory: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Generate(ctx, "s1", "what is the refund policy?"); err != nil {
+		t.Fatal(err)
+	}
+	if stat := learner.Stats()["handbook"]; stat.Retrieved != 1 || stat.Used != 1 {
+		t.Fatalf("handbook stat = %+v, want one retrieval used in the answer", stat)
+	}
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ModelImportanceScorer asks a model how important a memory is to keep.
+// Install it with engine.WithImportanceScorer.
+type ModelImportanceScorer struct {
+	Model models.Agent
+}
+
+var _ memory.ImportanceScorer = ModelImportanceScorer{}
+
+const importanceScorerPrompt = `Rate how important it is for an assistant to remember this note long term, from 0 to 1.
+Credentials, decisions, deadlines, commitments and user preferences are close to 1, even when short.
+Small talk, greetings and transient status are close to 0.
+
+Note: %s
+
+Answer with the number only.`
+
+func (s ModelImportanceScorer) ScoreImportance(ctx context.Context, content string, _ map[string]any) (float64, error) {
+	if s.Model == nil {
+		return 0, errors.New("importance scorer has no model")
+	}
+	raw, err := s.Model.Generate(ctx, fmt.Sprintf(importanceScorerPrompt, truncate(sanitizeInput(content), 2000)))
+	if err != nil {
+		return 0, err
+	}
+	answer := strings.TrimSpace(fmt.Sprint(raw))
+	if fields := strings.Fields(answer); len(fields) > 0 {
+		if score, err := strconv.ParseFloat(strings.TrimRight(fields[0], ".,;"), 64); err == nil && score >= 0 && score <= 1 {
+			return score, nil
+		}
+	}
+	return 0, fmt.Errorf("importance scorer: unexpected reply %q", truncate(answer, 40))
+}
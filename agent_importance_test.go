@@ -0,0 +1,19 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestModelImportanceScorer(t *testing.T) {
+	ctx := context.Background()
+	score, err := ModelImportanceScorer{Model: &stubModel{response: "0.9"}}.ScoreImportance(ctx, "the deploy key rotates Friday", nil)
+	if err != nil || score != 0.9 {
+		t.Fatalf("ScoreImportance = %v, %v; want 0.9", score, err)
+	}
+	for _, reply := range []string{"very", "7"} {
+		if _, err := (ModelImportanceScorer{Model: &stubModel{response: reply}}).ScoreImportance(ctx, "hi", nil); err == nil {
+			t.Fatalf("expected an error for reply %q", reply)
+		}
+	}
+}
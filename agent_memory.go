@@ -2,7 +2,11 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,7 +20,10 @@ type memoryStoreTask struct {
 }
 
 type preparedMemoryStore struct {
-	agent       *Agent
+	agent *Agent
+	// scope carries the tenant and identity of the turn into the
+	// short-term buffer, without its deadline.
+	scope       context.Context
 	shared      *memory.SharedSession
 	memory      *memory.SessionMemory
 	sessionID   string
@@ -25,10 +32,18 @@ type preparedMemoryStore struct {
 	metadataRaw string
 	embedding   []float32
 	embedded    bool
+	// image is embedded alongside content when the session embedder
+	// understands images.
+	image     []byte
+	imageMIME string
 }
 
-// Flush persists session memory into the long-term store.
+// Flush persists session memory into the long-term store. It does nothing
+// while the agent is read-only.
 func (a *Agent) Flush(ctx context.Context, sessionID string) error {
+	if a.ReadOnly {
+		return nil
+	}
 	return a.memory.FlushToLongTerm(ctx, sessionID)
 }
 
@@ -47,12 +62,21 @@ func (a *Agent) Checkpoint() ([]byte, error) {
 	if a.Shared != nil {
 		state.JoinedSpaces = a.Shared.ExportJoinedSpaces()
 	}
+	a.sessionsMu.Lock()
+	if len(a.toolProviders) > 0 {
+		state.ToolProviders = make(map[string][]string, len(a.toolProviders))
+		for session, providers := range a.toolProviders {
+			state.ToolProviders[session] = append([]string(nil), providers...)
+		}
+	}
+	a.sessionsMu.Unlock()
 
 	return json.Marshal(state)
 }
 
 // Restore rehydrates the agent's state from a checkpoint.
-// It restores the system prompt and short-term memory.
+// It restores the system prompt, short-term memory, joined spaces and
+// per-session tool provider preferences.
 func (a *Agent) Restore(data []byte) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -68,12 +92,15 @@ func (a *Agent) Restore(data []byte) error {
 	if a.Shared != nil && len(state.JoinedSpaces) > 0 {
 		a.Shared.ImportJoinedSpaces(state.JoinedSpaces)
 	}
+	for session, providers := range state.ToolProviders {
+		a.SetToolProviders(session, providers...)
+	}
 
 	return nil
 }
 
-func (a *Agent) storeMemory(sessionID, role, content string, extra map[string]string) {
-	prepared, ok := a.prepareMemoryStore(sessionID, role, content, extra)
+func (a *Agent) storeMemory(ctx context.Context, sessionID, role, content string, extra map[string]string) {
+	prepared, ok := a.prepareMemoryStore(ctx, sessionID, role, content, extra)
 	if !ok {
 		return
 	}
@@ -85,12 +112,15 @@ func (a *Agent) storeMemory(sessionID, role, content string, extra map[string]st
 // commits the prepared record, retaining the synchronous visibility and
 // ordering guarantees of storeMemory while allowing callers to overlap the
 // expensive embedding request with model work.
-func (a *Agent) startMemoryStore(sessionID, role, content string, extra map[string]string) *memoryStoreTask {
-	prepared, ok := a.prepareMemoryStore(sessionID, role, content, extra)
+func (a *Agent) startMemoryStore(ctx context.Context, sessionID, role, content string, extra map[string]string) *memoryStoreTask {
+	prepared, ok := a.prepareMemoryStore(ctx, sessionID, role, content, extra)
 	if !ok {
 		return nil
 	}
+	return startPreparedMemoryStore(prepared)
+}
 
+func startPreparedMemoryStore(prepared preparedMemoryStore) *memoryStoreTask {
 	ready := make(chan preparedMemoryStore, 1)
 	task := &memoryStoreTask{ready: ready}
 	if prepared.memory == nil || prepared.memory.Embedder == nil {
@@ -106,8 +136,8 @@ func (a *Agent) startMemoryStore(sessionID, role, content string, extra map[stri
 	return task
 }
 
-func (a *Agent) prepareMemoryStore(sessionID, role, content string, extra map[string]string) (preparedMemoryStore, bool) {
-	if a == nil || strings.TrimSpace(content) == "" {
+func (a *Agent) prepareMemoryStore(ctx context.Context, sessionID, role, content string, extra map[string]string) (preparedMemoryStore, bool) {
+	if a == nil || a.ReadOnly || strings.TrimSpace(content) == "" {
 		return preparedMemoryStore{}, false
 	}
 
@@ -135,6 +165,7 @@ func (a *Agent) prepareMemoryStore(sessionID, role, content string, extra map[st
 
 	return preparedMemoryStore{
 		agent:       a,
+		scope:       context.WithoutCancel(ctx),
 		shared:      shared,
 		memory:      mem,
 		sessionID:   sessionID,
@@ -157,6 +188,31 @@ func (p *preparedMemoryStore) embed() {
 		p.embedding = embedding
 		p.embedded = true
 	}
+	p.embedImage()
+}
+
+// embedImage adds the image's own vector as a second embedding of the
+// record, so text queries can match what the image shows rather than only
+// its file name.
+func (p *preparedMemoryStore) embedImage() {
+	images, ok := p.memory.Embedder.(memory.ImageEmbedder)
+	if !ok || !p.embedded || len(p.image) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	vec, err := images.EmbedImage(ctx, p.image, p.imageMIME)
+	if err != nil || len(vec) == 0 {
+		return
+	}
+	meta := make(map[string]any, len(p.metadata)+1)
+	for k, v := range p.metadata {
+		meta[k] = v
+	}
+	meta[memory.EmbeddingMatrixKey] = [][]float32{vec}
+	if raw, err := json.Marshal(meta); err == nil {
+		p.metadataRaw = string(raw)
+	}
 }
 
 // Wait commits a background memory store exactly once. It is safe to call on
@@ -188,14 +244,11 @@ func (p preparedMemoryStore) commit() {
 
 	p.agent.mu.Lock()
 	defer p.agent.mu.Unlock()
-	p.memory.AddShortTerm(p.sessionID, p.content, p.metadataRaw, p.embedding)
+	p.memory.AddShortTermContext(p.scope, p.sessionID, p.content, p.metadataRaw, p.embedding)
 }
 
 func (a *Agent) retrieveContext(ctx context.Context, sessionID, query string, limit int) ([]memory.MemoryRecord, error) {
-	if a.Shared != nil {
-		return a.Shared.Retrieve(ctx, query, limit)
-	}
-	return a.memory.RetrieveContext(ctx, sessionID, query, limit)
+	return a.planRetrieval(ctx, sessionID, query, limit)
 }
 
 func metadataRole(metadata string) string {
@@ -237,3 +290,20 @@ func (a *Agent) EnsureSpaceGrants(sessionID string, spaces []string) {
 func (a *Agent) SessionMemory() *memory.SessionMemory {
 	return a.memory
 }
+
+// memoryFingerprint identifies the knowledge in records: which memories were
+// retrieved and what they say, ignoring scores that vary between searches.
+// Cached model responses are keyed by it; see models.WithContextFingerprint.
+func memoryFingerprint(records []memory.MemoryRecord) string {
+	keys := make([]string, 0, len(records))
+	for _, rec := range records {
+		keys = append(keys, fmt.Sprintf("%d\x00%s\x00%s\x00%s\x00%d", rec.ID, rec.SessionID, rec.Content, rec.Summary, rec.LastEmbedded.UnixNano()))
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0x1e})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestMemoryFingerprintTracksKnowledgeNotScores(t *testing.T) {
+	a := memory.MemoryRecord{ID: 1, SessionID: "s1", Content: "refunds within 30 days", Score: 0.91}
+	b := memory.MemoryRecord{ID: 2, SessionID: "s1", Content: "shipping is free over $50", Score: 0.40}
+	base := memoryFingerprint([]memory.MemoryRecord{a, b})
+
+	rescored, reordered := a, b
+	rescored.Score = 0.12
+	if got := memoryFingerprint([]memory.MemoryRecord{reordered, rescored}); got != base {
+		t.Fatal("score or order changes should not change the fingerprint")
+	}
+	edited := a
+	edited.Content = "refunds within 60 days"
+	if memoryFingerprint([]memory.MemoryRecord{edited, b}) == base {
+		t.Fatal("edited content must change the fingerprint")
+	}
+	if memoryFingerprint([]memory.MemoryRecord{a, b, {ID: 3, Content: "new policy"}}) == base {
+		t.Fatal("newly retrieved memories must change the fingerprint")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/Protocol-Lattice/go-agent/src/flags"
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
 	utcpTools "github.com/universal-tool-calling-protocol/go-utcp/src/tools"
@@ -89,6 +90,28 @@ func TestAgentUsesNativeToolCallsWhenModelSupportsThem(t *testing.T) {
 	}
 }
 
+func TestToolCallingFlagOffSkipsToolOrchestration(t *testing.T) {
+	model := &nativeToolModel{}
+	localTool := &stubTool{spec: ToolSpec{Name: "echo", Description: "Echoes input"}}
+	a, err := New(Options{
+		Model:  model,
+		Memory: memory.NewSessionMemory(&memory.MemoryBank{}, 4),
+		Tools:  []Tool{localTool},
+		Flags:  flags.Static{flags.ToolCalling: flags.ToolCallingOff},
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	out, err := a.Generate(context.Background(), "session", "run echo")
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if out != "fallback" || model.calls != 0 {
+		t.Fatalf("Generate = %q after %d tool-calling requests, want a plain completion", out, model.calls)
+	}
+}
+
 func TestNativeToolDefinitionsDefaultSchemaType(t *testing.T) {
 	definitions := nativeToolDefinitions([]utcpTools.Tool{{Name: "empty"}})
 	if len(definitions) != 1 {
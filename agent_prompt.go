@@ -23,14 +23,25 @@ var (
 	roleMemoryRe    = regexp.MustCompile(`(?mi)^Conversation memory`)
 )
 
-// buildPrompt assembles the full assistant prompt for normal LLM generation.
+// buildPrompt assembles the full assistant prompt for normal LLM generation
+// by flattening buildMessages.
+func (a *Agent) buildPrompt(ctx context.Context, sessionID, userInput string) (string, error) {
+	messages, err := a.buildMessages(ctx, sessionID, userInput)
+	if err != nil {
+		return "", err
+	}
+	prompt, _ := models.FlattenMessages(messages)
+	return prompt, nil
+}
+
+// buildMessages assembles the messages for normal LLM generation.
 // It does NOT include Toon markup. It NEVER formats for tool calls.
 // It simply injects system prompt, retrieved memory, and file context.
-func (a *Agent) buildPrompt(
+func (a *Agent) buildMessages(
 	ctx context.Context,
 	sessionID string,
 	userInput string,
-) (string, error) {
+) ([]models.Message, error) {
 	userInput = sanitizeInput(userInput)
 
 	queryType := classifyQuery(userInput)
@@ -49,7 +60,7 @@ func (a *Agent) buildPrompt(
 		if limit > 0 {
 			records, err = a.retrieveContext(ctx, sessionID, userInput, limit)
 			if err != nil {
-				return "", fmt.Errorf("retrieve context: %w", err)
+				return nil, fmt.Errorf("retrieve context: %w", err)
 			}
 		}
 
@@ -57,7 +68,7 @@ func (a *Agent) buildPrompt(
 		if a.contextLimit > 0 {
 			records, err = a.retrieveContext(ctx, sessionID, userInput, a.contextLimit)
 			if err != nil {
-				return "", fmt.Errorf("retrieve context: %w", err)
+				return nil, fmt.Errorf("retrieve context: %w", err)
 			}
 		}
 
@@ -65,35 +76,24 @@ func (a *Agent) buildPrompt(
 		// Unknown query type: keep prompt lean and avoid accidental noisy retrieval.
 	}
 
-	var sb strings.Builder
-	sb.Grow(4096)
-
-	if strings.TrimSpace(a.systemPrompt) != "" {
-		sb.WriteString(strings.TrimSpace(a.systemPrompt))
-		sb.WriteString("\n\n")
-	}
-
-	if len(records) > 0 {
-		sb.WriteString("Conversation memory (TOON):\n")
-		sb.WriteString(a.renderMemory(records))
-		sb.WriteString("\n\n")
+	sections, err := a.renderContextSections(ctx, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	files, err := a.RetrieveAttachmentFiles(ctx, sessionID, a.contextLimit)
 	if err != nil {
-		return "", fmt.Errorf("retrieve attachment files: %w", err)
-	}
-
-	if len(files) > 0 {
-		sb.WriteString(a.buildAttachmentPrompt("Session attachments (rehydrated)", files))
-		sb.WriteString("\n\n")
+		return nil, fmt.Errorf("retrieve attachment files: %w", err)
 	}
 
-	sb.WriteString("User: ")
-	sb.WriteString(userInput)
-	sb.WriteString("\n")
-
-	return sb.String(), nil
+	return a.renderMessages(ctx, PromptData{
+		SessionID:    sessionID,
+		SystemPrompt: a.systemPromptFor(sessionID),
+		Context:      sections,
+		Memory:       records,
+		Attachments:  []PromptAttachments{{Title: "Session attachments (rehydrated)", Files: files}},
+		Input:        userInput,
+	}, nil), nil
 }
 
 func intMin(a, b int) int {
@@ -105,6 +105,10 @@ func intMin(a, b int) int {
 
 // renderMemory formats retrieved memory records into a clean, token-efficient list.
 func (a *Agent) renderMemory(records []memory.MemoryRecord) string {
+	return renderMemoryTOON(records)
+}
+
+func renderMemoryTOON(records []memory.MemoryRecord) string {
 	if len(records) == 0 {
 		return "(no stored memory)\n"
 	}
@@ -167,44 +171,72 @@ func sanitizeInput(s string) string {
 	return s
 }
 
-func (a *Agent) storeAttachmentMemories(sessionID string, files []models.File) {
-	waitMemoryStoreTasks(a.startAttachmentMemoryStores(sessionID, files))
+func (a *Agent) storeAttachmentMemories(ctx context.Context, sessionID string, files []models.File) {
+	waitMemoryStoreTasks(a.startAttachmentMemoryStores(ctx, sessionID, files))
 }
 
 // startAttachmentMemoryStores prepares every attachment concurrently while
 // preserving file order when the tasks are committed.
-func (a *Agent) startAttachmentMemoryStores(sessionID string, files []models.File) []*memoryStoreTask {
+func (a *Agent) startAttachmentMemoryStores(ctx context.Context, sessionID string, files []models.File) []*memoryStoreTask {
 	tasks := make([]*memoryStoreTask, 0, len(files))
 	for i, file := range files {
 		name := strings.TrimSpace(file.Name)
 		if name == "" {
 			name = fmt.Sprintf("file_%d", i+1)
 		}
-		mime := strings.TrimSpace(file.MIME)
-		content := buildAttachmentMemoryContent(name, mime, file.Data)
-		extra := map[string]string{
-			"source":   "file_upload",
-			"filename": name,
-		}
-		if mime != "" {
-			extra["mime"] = mime
+		route := AttachmentMemory
+		if !a.ReadOnly {
+			route = a.Attachments.routeAttachment(ctx, file)
 		}
-		if size := len(file.Data); size > 0 {
-			extra["size_bytes"] = strconv.Itoa(size)
-		}
-		if len(file.Data) > 0 {
-			extra["data_base64"] = base64.StdEncoding.EncodeToString(file.Data)
+		switch route {
+		case AttachmentEphemeral:
+			tasks = append(tasks, nil)
+			continue
+		case AttachmentIngest, AttachmentBlob:
+			tasks = append(tasks, a.startRoutedAttachment(ctx, sessionID, route, name, file, func() (preparedMemoryStore, bool) {
+				return a.prepareAttachmentMemory(ctx, sessionID, name, file)
+			}))
+			continue
 		}
-		if isTextAttachment(mime, file.Data) {
-			extra["text"] = "true"
-		} else {
-			extra["text"] = "false"
+		prepared, ok := a.prepareAttachmentMemory(ctx, sessionID, name, file)
+		if !ok {
+			tasks = append(tasks, nil)
+			continue
 		}
-		tasks = append(tasks, a.startMemoryStore(sessionID, "attachment", content, extra))
+		tasks = append(tasks, startPreparedMemoryStore(prepared))
 	}
 	return tasks
 }
 
+// prepareAttachmentMemory prepares the attachment memory holding file.
+func (a *Agent) prepareAttachmentMemory(ctx context.Context, sessionID, name string, file models.File) (preparedMemoryStore, bool) {
+	mime := strings.TrimSpace(file.MIME)
+	content := buildAttachmentMemoryContent(name, mime, file.Data)
+	extra := map[string]string{
+		"source":   "file_upload",
+		"filename": name,
+	}
+	if mime != "" {
+		extra["mime"] = mime
+	}
+	if size := len(file.Data); size > 0 {
+		extra["size_bytes"] = strconv.Itoa(size)
+	}
+	if len(file.Data) > 0 {
+		extra["data_base64"] = base64.StdEncoding.EncodeToString(file.Data)
+	}
+	if isTextAttachment(mime, file.Data) {
+		extra["text"] = "true"
+	} else {
+		extra["text"] = "false"
+	}
+	prepared, ok := a.prepareMemoryStore(ctx, sessionID, "attachment", content, extra)
+	if ok && strings.HasPrefix(strings.ToLower(mime), "image/") {
+		prepared.image, prepared.imageMIME = file.Data, mime
+	}
+	return prepared, ok
+}
+
 func waitMemoryStoreTasks(tasks []*memoryStoreTask) {
 	for _, task := range tasks {
 		task.Wait()
@@ -242,6 +274,45 @@ func (a *Agent) RetrieveAttachmentFiles(ctx context.Context, sessionID string, l
 	return attachments, nil
 }
 
+// SearchAttachmentFiles returns the session's attachments that best match
+// query, such as "the diagram about the auth flow". Matches in long-term
+// memory come first, ranked by similarity; when the session embedder is a
+// memory.ImageEmbedder that includes what stored images show. Attachments
+// still in the short-term window follow, newest last.
+func (a *Agent) SearchAttachmentFiles(ctx context.Context, sessionID, query string, limit int) ([]models.File, error) {
+	if a == nil || a.memory == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = a.contextLimit
+		if limit <= 0 {
+			limit = 8
+		}
+	}
+	// Other memories compete for the same slots, so search deeper than the
+	// number of files wanted.
+	longTerm, err := a.memory.RetrieveLongTerm(ctx, sessionID, query, limit*4)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []models.File
+	seen := map[string]bool{}
+	for _, record := range append(longTerm, a.memory.ShortTermContext(ctx, sessionID)...) {
+		if len(attachments) >= limit {
+			break
+		}
+		file, ok := attachmentFromRecord(record)
+		key := file.Name + "\x00" + string(file.Data)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		attachments = append(attachments, file)
+	}
+	return attachments, nil
+}
+
 func attachmentFromRecord(record memory.MemoryRecord) (models.File, bool) {
 	if strings.TrimSpace(record.Metadata) == "" {
 		return models.File{}, false
@@ -365,6 +436,10 @@ func fileBackedWorkspaceRules(files []models.File) string {
 // buildAttachmentPrompt renders a compact, token-conscious list of files.
 // It never inlines non-text bytes. For text files, it shows a short preview.
 func (a *Agent) buildAttachmentPrompt(title string, files []models.File) string {
+	return renderAttachmentsTOON(title, files)
+}
+
+func renderAttachmentsTOON(title string, files []models.File) string {
 	if len(files) == 0 {
 		return ""
 	}
@@ -457,6 +532,35 @@ func previewText(_ string, data []byte) string {
 	return truncate(txt, maxPreview)
 }
 
+// PromptSelector chooses the system prompt for a session. Returning an
+// empty string falls back to the agent's own system prompt.
+type PromptSelector interface {
+	SystemPrompt(sessionID string) string
+}
+
+// TurnObserver is implemented by prompt selectors that watch completed
+// turns, for example to score a canary prompt. ObserveTurn runs before the
+// turn's response is returned, so slow scoring belongs in a goroutine.
+type TurnObserver interface {
+	ObserveTurn(ctx context.Context, turn Turn)
+}
+
+func (a *Agent) systemPromptFor(sessionID string) string {
+	a.mu.Lock()
+	prompt, prompts := a.systemPrompt, a.Prompts
+	fragments, budget := a.promptFragments, a.SystemPromptBudget
+	a.mu.Unlock()
+	if prompts != nil {
+		if chosen := prompts.SystemPrompt(sessionID); strings.TrimSpace(chosen) != "" {
+			prompt = chosen
+		}
+	}
+	if len(fragments) == 0 {
+		return prompt
+	}
+	return composeSystemPrompt(prompt, fragments, budget)
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Conventional orders for prompt fragments. Fragments with lower orders come
+// first, right after the base system prompt.
+const (
+	PromptOrderPersona = 100
+	PromptOrderMemory  = 200
+	PromptOrderTools   = 300
+	PromptOrderOutput  = 400
+)
+
+// PromptFragment is one layer of the system prompt contributed by a module,
+// such as memory instructions, tool usage notes or a persona. Fragments are
+// appended to the base system prompt sorted by Order, then Name.
+type PromptFragment struct {
+	// Name identifies the fragment; a later fragment with the same name
+	// replaces an earlier one.
+	Name    string
+	Order   int
+	Content string
+	// MaxChars, when positive, truncates Content to that many characters.
+	MaxChars int
+}
+
+// AddPromptFragment adds f to the system prompt, replacing any fragment with
+// the same name.
+func (a *Agent) AddPromptFragment(f PromptFragment) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.promptFragments = mergePromptFragments(a.promptFragments, f)
+}
+
+// RemovePromptFragment drops the fragment called name.
+func (a *Agent) RemovePromptFragment(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	kept := a.promptFragments[:0:0]
+	for _, f := range a.promptFragments {
+		if f.Name != name {
+			kept = append(kept, f)
+		}
+	}
+	a.promptFragments = kept
+}
+
+// PromptFragments returns the fragments in the order they are rendered.
+func (a *Agent) PromptFragments() []PromptFragment {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return sortedPromptFragments(a.promptFragments)
+}
+
+func mergePromptFragments(dst []PromptFragment, fragments ...PromptFragment) []PromptFragment {
+	out := append([]PromptFragment(nil), dst...)
+	for _, f := range fragments {
+		replaced := false
+		if f.Name != "" {
+			for i := range out {
+				if out[i].Name == f.Name {
+					out[i], replaced = f, true
+					break
+				}
+			}
+		}
+		if !replaced {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func sortedPromptFragments(fragments []PromptFragment) []PromptFragment {
+	out := append([]PromptFragment(nil), fragments...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Order != out[j].Order {
+			return out[i].Order < out[j].Order
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// composeSystemPrompt layers fragments under base. With a positive budget,
+// fragments that would push the prompt past budget characters are left out;
+// the base prompt is always kept.
+func composeSystemPrompt(base string, fragments []PromptFragment, budget int) string {
+	parts := []string{}
+	if base = strings.TrimSpace(base); base != "" {
+		parts = append(parts, base)
+	}
+	size := utf8.RuneCountInString(base)
+	for _, f := range sortedPromptFragments(fragments) {
+		content := strings.TrimSpace(f.Content)
+		if f.MaxChars > 0 && utf8.RuneCountInString(content) > f.MaxChars {
+			content = strings.TrimSpace(string([]rune(content)[:f.MaxChars]))
+		}
+		if content == "" {
+			continue
+		}
+		n := utf8.RuneCountInString(content)
+		if len(parts) > 0 {
+			n += 2
+		}
+		if budget > 0 && size+n > budget {
+			continue
+		}
+		parts = append(parts, content)
+		size += n
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestComposeSystemPromptOrdersAndBudgets(t *testing.T) {
+	fragments := []PromptFragment{
+		{Name: "tools", Order: PromptOrderTools, Content: "Use tools sparingly."},
+		{Name: "persona", Order: PromptOrderPersona, Content: "You are terse."},
+		{Name: "memory", Order: PromptOrderMemory, Content: "Cite memories by id and never invent them.", MaxChars: 20},
+	}
+
+	got := composeSystemPrompt("Base.", fragments, 0)
+	want := "Base.\n\nYou are terse.\n\nCite memories by id\n\nUse tools sparingly."
+	if got != want {
+		t.Fatalf("composed prompt:\n%q\nwant\n%q", got, want)
+	}
+
+	// The tools fragment does not fit; the base and earlier fragments stay.
+	got = composeSystemPrompt("Base.", fragments, len("Base.\n\nYou are terse.\n\nCite memories by id")+5)
+	if strings.Contains(got, "tools") || !strings.HasSuffix(got, "Cite memories by id") {
+		t.Fatalf("budgeted prompt = %q", got)
+	}
+}
+
+func TestAgentPromptFragmentsReplaceByName(t *testing.T) {
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	model := &coordinatorModel{replies: []string{"ok"}}
+	a, err := New(Options{
+		Model:           model,
+		Memory:          mem,
+		SystemPrompt:    "Base prompt.",
+		PromptFragments: []PromptFragment{{Name: "persona", Order: PromptOrderPersona, Content: "You are a pirate."}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	a.AddPromptFragment(PromptFragment{Name: "persona", Order: PromptOrderPersona, Content: "You are a librarian."})
+	a.AddPromptFragment(PromptFragment{Name: "style", Order: PromptOrderOutput, Content: "Answer in one line."})
+
+	if _, err := a.Generate(context.Background(), "s", "Tell me about the weather patterns in the north"); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	prompt := model.prompts[0]
+	if strings.Contains(prompt, "pirate") {
+		t.Fatalf("replaced fragment still rendered:\n%s", prompt)
+	}
+	if !strings.HasPrefix(prompt, "Base prompt.\n\nYou are a librarian.\n\nAnswer in one line.") {
+		t.Fatalf("unexpected prompt head:\n%s", prompt)
+	}
+
+	a.RemovePromptFragment("style")
+	if got := a.PromptFragments(); len(got) != 1 || got[0].Name != "persona" {
+		t.Fatalf("fragments after removal = %+v", got)
+	}
+}
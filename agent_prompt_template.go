@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// PromptTemplate renders the prompt for a turn's final model completion
+// from its parts. Set Options.PromptTemplate to change how memory,
+// attachments and roles are presented without forking the agent.
+type PromptTemplate interface {
+	Render(PromptData) string
+}
+
+// PromptData is everything a PromptTemplate may place in a prompt.
+type PromptData struct {
+	SessionID    string
+	SystemPrompt string
+	// Context holds the rendered ContextProvider sections.
+	Context string
+	Memory  []memory.MemoryRecord
+	// Rules are extra instructions for the turn, such as the workspace rules
+	// of a file-backed request.
+	Rules       string
+	Attachments []PromptAttachments
+	// Input is the user's message with role markers already quoted.
+	Input string
+	// Adapter lays the prompt out for the agent's model; see
+	// models.PromptAdapterFor. Nil keeps the plain layout.
+	Adapter models.PromptAdapter
+}
+
+// PromptAttachments is a titled group of files, e.g. the files sent with
+// the turn or those rehydrated from the session.
+type PromptAttachments struct {
+	Title string
+	Files []models.File
+}
+
+// DefaultPromptTemplate is the template agents use unless told otherwise.
+// Its zero value renders memory as TOON under "Conversation memory (TOON)"
+// and labels the user's message "User". With PromptData.Adapter set, the
+// adapter arranges the same parts, and UserLabel is left to it.
+type DefaultPromptTemplate struct {
+	UserLabel    string
+	MemoryHeader string
+	// RenderMemory and RenderAttachments replace the TOON renderings.
+	RenderMemory      func([]memory.MemoryRecord) string
+	RenderAttachments func(title string, files []models.File) string
+}
+
+func (t DefaultPromptTemplate) Render(d PromptData) string {
+	userLabel := t.UserLabel
+	if userLabel == "" {
+		userLabel = "User"
+	}
+	memoryHeader := t.MemoryHeader
+	if memoryHeader == "" {
+		memoryHeader = "Conversation memory (TOON)"
+	}
+	renderMemory := t.RenderMemory
+	if renderMemory == nil {
+		renderMemory = renderMemoryTOON
+	}
+	renderAttachments := t.RenderAttachments
+	if renderAttachments == nil {
+		renderAttachments = renderAttachmentsTOON
+	}
+
+	if d.Adapter != nil {
+		p := models.Prompt{
+			System: d.SystemPrompt,
+			Sections: []models.PromptSection{
+				{Title: "Context", Body: d.Context},
+				{Title: memoryHeader, Body: renderMemory(d.Memory)},
+				{Title: "Rules", Body: d.Rules},
+			},
+			Input: d.Input,
+		}
+		for _, group := range d.Attachments {
+			section := strings.TrimSpace(renderAttachments(group.Title, group.Files))
+			p.Sections = append(p.Sections, models.PromptSection{
+				Title: group.Title,
+				Body:  strings.TrimPrefix(section, group.Title+":"),
+			})
+		}
+		return d.Adapter.FormatPrompt(p)
+	}
+
+	var sb strings.Builder
+	sb.Grow(4096)
+	if systemPrompt := strings.TrimSpace(d.SystemPrompt); systemPrompt != "" {
+		sb.WriteString(systemPrompt)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(d.Context)
+	sb.WriteString(memoryHeader)
+	sb.WriteString(":\n")
+	sb.WriteString(renderMemory(d.Memory))
+	sb.WriteString("\n")
+	if d.Rules != "" {
+		sb.WriteString(d.Rules)
+		sb.WriteString("\n")
+	}
+	for _, group := range d.Attachments {
+		if section := renderAttachments(group.Title, group.Files); section != "" {
+			sb.WriteString(section)
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString(userLabel)
+	sb.WriteString(": ")
+	sb.WriteString(d.Input)
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func (a *Agent) renderPrompt(ctx context.Context, d PromptData) string {
+	if d.Adapter == nil {
+		d.Adapter = a.promptAdapter(ctx)
+	}
+	return a.promptTemplate().Render(d)
+}
+
+func (a *Agent) promptTemplate() PromptTemplate {
+	a.mu.Lock()
+	tmpl := a.PromptTemplate
+	a.mu.Unlock()
+	if tmpl == nil {
+		tmpl = DefaultPromptTemplate{}
+	}
+	return tmpl
+}
+
+// renderMessages renders a turn as messages, attaching files to the user
+// message. A models.ChatAgent gets the system prompt as a system message
+// and the template renders the rest with SystemPrompt cleared; an adapter
+// that formats whole conversations is left to the model, which applies it
+// to the messages itself. Other models get the full render as one user
+// message, which flattens back to the prompt renderPrompt returns. The
+// model is the one serving the turn on ctx.
+func (a *Agent) renderMessages(ctx context.Context, d PromptData, files []models.File) []models.Message {
+	if _, ok := a.modelFor(ctx).(models.ChatAgent); !ok {
+		return []models.Message{{Role: models.RoleUser, Content: a.renderPrompt(ctx, d), Files: files}}
+	}
+	if d.Adapter == nil {
+		d.Adapter = a.promptAdapter(ctx)
+	}
+	if _, ok := d.Adapter.(models.MessageFormatter); ok {
+		d.Adapter = nil
+	}
+	system := strings.TrimSpace(d.SystemPrompt)
+	d.SystemPrompt = ""
+	var messages []models.Message
+	if system != "" {
+		messages = append(messages, models.Message{Role: models.RoleSystem, Content: system})
+	}
+	return append(messages, models.Message{Role: models.RoleUser, Content: a.promptTemplate().Render(d), Files: files})
+}
+
+// promptAdapter returns Options.PromptAdapter, or the adapter that suits
+// the model serving the turn on ctx.
+func (a *Agent) promptAdapter(ctx context.Context) models.PromptAdapter {
+	a.mu.Lock()
+	adapter := a.PromptAdapter
+	a.mu.Unlock()
+	if adapter != nil {
+		return adapter
+	}
+	return models.PromptAdapterFor(a.modelFor(ctx))
+}
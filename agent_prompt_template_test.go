@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+func TestPromptTemplateCustomizesCompletionPrompt(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 4).WithEmbedder(memory.DummyEmbedder{})
+	embedding, _ := mem.Embed(ctx, "deploys happen on tuesdays")
+	if err := store.StoreMemory(ctx, "s1", "deploys happen on tuesdays", nil, embedding); err != nil {
+		t.Fatal(err)
+	}
+	model := &coordinatorModel{replies: []string{"tuesday"}}
+	a, err := New(Options{
+		Model:        model,
+		Memory:       mem,
+		SystemPrompt: "You are the release bot.",
+		PromptTemplate: DefaultPromptTemplate{
+			UserLabel:    "Customer",
+			MemoryHeader: "Known facts",
+			RenderMemory: func(records []memory.MemoryRecord) string {
+				var sb strings.Builder
+				for _, rec := range records {
+					fmt.Fprintf(&sb, "* %s\n", rec.Content)
+				}
+				return sb.String()
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Generate(ctx, "s1", "When do deploys usually happen around here"); err != nil {
+		t.Fatal(err)
+	}
+	prompt := model.prompts[len(model.prompts)-1]
+	for _, want := range []string{
+		"You are the release bot.\n\n",
+		"Known facts:\n* deploys happen on tuesdays\n",
+		"Customer: When do deploys usually happen around here\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "TOON") {
+		t.Fatalf("custom memory rendering should replace TOON:\n%s", prompt)
+	}
+}
+
+func TestDefaultPromptTemplateLayout(t *testing.T) {
+	got := DefaultPromptTemplate{}.Render(PromptData{
+		SystemPrompt: "System.",
+		Context:      "Env:\nstaging\n\n",
+		Input:        "hi",
+	})
+	want := "System.\n\nEnv:\nstaging\n\nConversation memory (TOON):\n(no stored memory)\n\nUser: hi\n"
+	if got != want {
+		t.Fatalf("Render =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPromptAdapterShapesTurnAndPlannerPrompts(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	model := &coordinatorModel{replies: []string{"tuesday"}}
+	a, err := New(Options{
+		Model:         model,
+		Memory:        mem,
+		SystemPrompt:  "You are the release bot.",
+		PromptAdapter: models.XMLPromptAdapter{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Generate(ctx, "s1", "When do deploys usually happen around here"); err != nil {
+		t.Fatal(err)
+	}
+	prompt := model.prompts[len(model.prompts)-1]
+	for _, want := range []string{
+		"You are the release bot.\n\n",
+		"<conversation_memory_toon>\n",
+		"<user_input>\nWhen do deploys usually happen around here\n</user_input>",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	planner := models.FormatPrompt(a.promptAdapter(ctx), models.Prompt{Sections: []models.PromptSection{{Title: "AVAILABLE UTCP TOOLS", Body: "echo"}}})
+	if planner != "<available_utcp_tools>\necho\n</available_utcp_tools>\n\n" {
+		t.Fatalf("planner prompt = %q", planner)
+	}
+}
+
+// chatModel is a coordinatorModel that takes structured messages.
+type chatModel struct {
+	coordinatorModel
+	calls [][]models.Message
+}
+
+func (m *chatModel) Chat(ctx context.Context, messages []models.Message) (models.Message, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, messages)
+	m.mu.Unlock()
+	out, err := m.Generate(ctx, "")
+	return models.Message{Role: models.RoleAssistant, Content: fmt.Sprint(out)}, err
+}
+
+func TestChatModelsGetSystemPromptAsItsOwnMessage(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	model := &chatModel{coordinatorModel: coordinatorModel{replies: []string{"tuesday"}}}
+	a, err := New(Options{Model: model, Memory: mem, SystemPrompt: "You are the release bot."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []models.File{{Name: "notes.txt", MIME: "text/plain", Data: []byte("deploy notes")}}
+	out, err := a.GenerateWithFiles(ctx, "s1", "When do deploys usually happen around here", files)
+	if err != nil || out != "tuesday" {
+		t.Fatalf("GenerateWithFiles = %v, %v", out, err)
+	}
+	if len(model.calls) == 0 {
+		t.Fatal("the turn should go through Chat")
+	}
+	messages := model.calls[len(model.calls)-1]
+	if len(messages) != 2 || messages[0].Role != models.RoleSystem || messages[0].Content != "You are the release bot." {
+		t.Fatalf("messages = %+v", messages)
+	}
+	user := messages[1]
+	if user.Role != models.RoleUser || strings.Contains(user.Content, "release bot") {
+		t.Fatalf("user message should carry the turn without the system prompt: %+v", user)
+	}
+	if !strings.Contains(user.Content, "User: When do deploys usually happen around here\n") {
+		t.Fatalf("user message missing input:\n%s", user.Content)
+	}
+}
@@ -0,0 +1,405 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ErrQuotaExceeded is wrapped by every QuotaExceededError.
+var ErrQuotaExceeded = errors.New("usage quota exceeded")
+
+// QuotaScope is what a quota counts usage for.
+type QuotaScope string
+
+const (
+	QuotaSession QuotaScope = "session"
+	QuotaTenant  QuotaScope = "tenant"
+)
+
+// QuotaAction is what happens to a turn that starts with its turn or token
+// quota spent.
+type QuotaAction string
+
+const (
+	// QuotaReject fails the turn with a QuotaExceededError.
+	QuotaReject QuotaAction = "reject"
+	// QuotaDegrade runs the turn on Quotas.Fallback, typically a cheaper
+	// model. Tool calls over quota are rejected either way.
+	QuotaDegrade QuotaAction = "degrade"
+)
+
+// QuotaLimits caps usage over fixed UTC windows: turns and tokens per day,
+// tool calls per hour. Zero fields are unlimited. Tokens are the same
+// estimates TurnBudget uses.
+type QuotaLimits struct {
+	TurnsPerDay      int   `json:"turns_per_day,omitempty"`
+	TokensPerDay     int64 `json:"tokens_per_day,omitempty"`
+	ToolCallsPerHour int   `json:"tool_calls_per_hour,omitempty"`
+}
+
+func (l QuotaLimits) isZero() bool {
+	return l.TurnsPerDay <= 0 && l.TokensPerDay <= 0 && l.ToolCallsPerHour <= 0
+}
+
+// Quotas enforces usage limits across turns, per session and per tenant.
+// A turn is checked when it starts; its tool calls are checked one by one
+// and its tokens counted as they are spent, so the turn that crosses the
+// token limit finishes and the next one is refused or degraded. Usage is
+// kept in memory and lost on restart.
+//
+// Use one Quotas per process and share it between agents that should draw
+// from the same allowance. Handler exposes the usage to operators.
+type Quotas struct {
+	Session QuotaLimits
+	Tenant  QuotaLimits
+	// Action defaults to QuotaReject; QuotaDegrade without a Fallback
+	// rejects as well.
+	Action   QuotaAction
+	Fallback models.Agent
+	// TenantOf names the tenant a call is billed to; the memory tenant of
+	// ctx (memory.ContextWithTenant) when nil. An empty tenant has no
+	// tenant quota.
+	TenantOf func(ctx context.Context, sessionID string) string
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	usage  map[quotaKey]*quotaCounter
+	pruned time.Time // hour of the last prune
+}
+
+type quotaKey struct {
+	scope QuotaScope
+	id    string
+}
+
+type quotaCounter struct {
+	day, hour time.Time
+	turns     int
+	tokens    int64
+	toolCalls int
+}
+
+// stale reports whether both of c's windows are over, so it holds no usage.
+func (c *quotaCounter) stale(now time.Time) bool {
+	return c.day.Before(now.Truncate(24*time.Hour)) && c.hour.Before(now.Truncate(time.Hour))
+}
+
+func (c *quotaCounter) roll(now time.Time) {
+	if day := now.Truncate(24 * time.Hour); !day.Equal(c.day) {
+		c.day, c.turns, c.tokens = day, 0, 0
+	}
+	if hour := now.Truncate(time.Hour); !hour.Equal(c.hour) {
+		c.hour, c.toolCalls = hour, 0
+	}
+}
+
+// QuotaUsage is the usage of one session or tenant in the current windows.
+type QuotaUsage struct {
+	Scope     QuotaScope  `json:"scope"`
+	ID        string      `json:"id"`
+	Turns     int         `json:"turns"`
+	Tokens    int64       `json:"tokens"`
+	ToolCalls int         `json:"tool_calls"`
+	Limits    QuotaLimits `json:"limits"`
+	// DayResets and HourResets are when the daily and hourly counts
+	// start over.
+	DayResets  time.Time `json:"day_resets"`
+	HourResets time.Time `json:"hour_resets"`
+}
+
+// QuotaExceededError reports the limit a turn or tool call ran into.
+type QuotaExceededError struct {
+	Scope QuotaScope `json:"scope"`
+	ID    string     `json:"id"`
+	// Limit is "turns_per_day", "tokens_per_day" or "tool_calls_per_hour".
+	Limit   string    `json:"limit"`
+	Max     int64     `json:"max"`
+	Used    int64     `json:"used"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%v: %s %s used %d of %s %d (resets %s)",
+		ErrQuotaExceeded, e.Scope, e.ID, e.Used, e.Limit, e.Max, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *QuotaExceededError) Unwrap() error { return ErrQuotaExceeded }
+
+func (q *Quotas) now() time.Time {
+	if q.Now != nil {
+		return q.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (q *Quotas) limits(scope QuotaScope) QuotaLimits {
+	if scope == QuotaTenant {
+		return q.Tenant
+	}
+	return q.Session
+}
+
+// counter returns the rolled-over counter of key; q.mu must be held.
+// Once an hour it drops the counters of sessions and tenants gone quiet.
+func (q *Quotas) counter(key quotaKey, now time.Time) *quotaCounter {
+	if q.usage == nil {
+		q.usage = map[quotaKey]*quotaCounter{}
+	}
+	if hour := now.Truncate(time.Hour); hour.After(q.pruned) {
+		q.pruned = hour
+		q.prune(now)
+	}
+	c := q.usage[key]
+	if c == nil {
+		c = &quotaCounter{}
+		q.usage[key] = c
+	}
+	c.roll(now)
+	return c
+}
+
+// prune drops the counters whose windows are all over; q.mu must be held.
+func (q *Quotas) prune(now time.Time) {
+	for key, c := range q.usage {
+		if c.stale(now) {
+			delete(q.usage, key)
+		}
+	}
+}
+
+// quotaTurn is the quota side of one turn: who it is billed to.
+type quotaTurn struct {
+	quotas *Quotas
+	keys   []quotaKey
+}
+
+func (q *Quotas) keysFor(ctx context.Context, sessionID string) []quotaKey {
+	tenant := memory.TenantFromContext(ctx)
+	if q.TenantOf != nil {
+		tenant = q.TenantOf(ctx, sessionID)
+	}
+	var keys []quotaKey
+	if !q.Session.isZero() {
+		keys = append(keys, quotaKey{QuotaSession, sessionID})
+	}
+	if tenant != "" && !q.Tenant.isZero() {
+		keys = append(keys, quotaKey{QuotaTenant, tenant})
+	}
+	return keys
+}
+
+// startTurn counts a turn, reporting whether it must run degraded. A turn
+// that is rejected is not counted.
+func (t *quotaTurn) startTurn() (degrade bool, err error) {
+	q := t.quotas
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var exceeded *QuotaExceededError
+keys:
+	for _, key := range t.keys {
+		c, l := q.counter(key, now), q.limits(key.scope)
+		switch {
+		case l.TurnsPerDay > 0 && c.turns >= l.TurnsPerDay:
+			exceeded = &QuotaExceededError{Limit: "turns_per_day", Max: int64(l.TurnsPerDay), Used: int64(c.turns)}
+		case l.TokensPerDay > 0 && c.tokens >= l.TokensPerDay:
+			exceeded = &QuotaExceededError{Limit: "tokens_per_day", Max: l.TokensPerDay, Used: c.tokens}
+		default:
+			continue
+		}
+		exceeded.Scope, exceeded.ID, exceeded.ResetAt = key.scope, key.id, c.day.Add(24*time.Hour)
+		break keys
+	}
+	if exceeded != nil && (q.Action != QuotaDegrade || q.Fallback == nil) {
+		return false, exceeded
+	}
+	for _, key := range t.keys {
+		q.counter(key, now).turns++
+	}
+	return exceeded != nil, nil
+}
+
+func (t *quotaTurn) chargeToolCall() error {
+	q := t.quotas
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, key := range t.keys {
+		c, l := q.counter(key, now), q.limits(key.scope)
+		if l.ToolCallsPerHour > 0 && c.toolCalls >= l.ToolCallsPerHour {
+			return &QuotaExceededError{
+				Scope: key.scope, ID: key.id, Limit: "tool_calls_per_hour",
+				Max: int64(l.ToolCallsPerHour), Used: int64(c.toolCalls), ResetAt: c.hour.Add(time.Hour),
+			}
+		}
+	}
+	for _, key := range t.keys {
+		q.counter(key, now).toolCalls++
+	}
+	return nil
+}
+
+func (t *quotaTurn) chargeTokens(n int64) {
+	q := t.quotas
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, key := range t.keys {
+		q.counter(key, now).tokens += n
+	}
+}
+
+type turnModelKey struct{}
+
+// beginQuotaTurn bills the turn under ctx to its session and tenant. It
+// fails when the turn is over quota and not degraded; a degraded turn's
+// context carries the fallback model.
+func (a *Agent) beginQuotaTurn(ctx context.Context, sessionID string) (context.Context, error) {
+	q := a.Quotas
+	if q == nil {
+		return ctx, nil
+	}
+	m := budgetFromContext(ctx)
+	if m == nil {
+		return ctx, nil
+	}
+	m.mu.Lock()
+	nested := m.quota != nil
+	m.mu.Unlock()
+	if nested {
+		// An agent called as a tool is billed with the turn that called it.
+		return ctx, nil
+	}
+	turn := &quotaTurn{quotas: q, keys: q.keysFor(ctx, sessionID)}
+	if len(turn.keys) == 0 {
+		return ctx, nil
+	}
+	degrade, err := turn.startTurn()
+	if err != nil {
+		return ctx, err
+	}
+	m.mu.Lock()
+	m.quota = turn
+	m.mu.Unlock()
+	if degrade {
+		ctx = context.WithValue(ctx, turnModelKey{}, q.Fallback)
+	}
+	return ctx, nil
+}
+
+// modelFor returns the model the turn under ctx runs on: the agent's, or
+// the quota fallback of a degraded turn.
+func (a *Agent) modelFor(ctx context.Context) models.Agent {
+	if m, ok := ctx.Value(turnModelKey{}).(models.Agent); ok && m != nil {
+		return m
+	}
+	return a.model
+}
+
+// Usage reports the current usage of a session or tenant.
+func (q *Quotas) Usage(scope QuotaScope, id string) QuotaUsage {
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := quotaKey{scope, id}
+	c, ok := q.usage[key]
+	if !ok {
+		c = &quotaCounter{}
+	}
+	c.roll(now)
+	return q.snapshot(key, c)
+}
+
+// List reports every session and tenant with usage in the current
+// windows, by scope and ID.
+func (q *Quotas) List() []QuotaUsage {
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune(now)
+	out := make([]QuotaUsage, 0, len(q.usage))
+	for key := range q.usage {
+		out = append(out, q.snapshot(key, q.counter(key, now)))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Scope != out[j].Scope {
+			return out[i].Scope < out[j].Scope
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Reset clears the usage of a session or tenant, reporting whether it had
+// any.
+func (q *Quotas) Reset(scope QuotaScope, id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := quotaKey{scope, id}
+	_, ok := q.usage[key]
+	delete(q.usage, key)
+	return ok
+}
+
+func (q *Quotas) snapshot(key quotaKey, c *quotaCounter) QuotaUsage {
+	return QuotaUsage{
+		Scope:      key.scope,
+		ID:         key.id,
+		Turns:      c.turns,
+		Tokens:     c.tokens,
+		ToolCalls:  c.toolCalls,
+		Limits:     q.limits(key.scope),
+		DayResets:  c.day.Add(24 * time.Hour),
+		HourResets: c.hour.Add(time.Hour),
+	}
+}
+
+// Handler serves usage to operators:
+//
+//	GET    /                 every session and tenant with usage (JSON)
+//	GET    /{scope}/{id}     one session or tenant; scope is session|tenant
+//	DELETE /{scope}/{id}     reset it
+//
+// It does no authentication of its own; mount it behind the admin auth of
+// the server, under a prefix with http.StripPrefix.
+func (q *Quotas) Handler() http.Handler {
+	mux := http.NewServeMux()
+	scopeOf := func(w http.ResponseWriter, r *http.Request) (QuotaScope, bool) {
+		scope := QuotaScope(r.PathValue("scope"))
+		if scope != QuotaSession && scope != QuotaTenant {
+			http.Error(w, "scope must be session or tenant", http.StatusNotFound)
+			return "", false
+		}
+		return scope, true
+	}
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, _ *http.Request) {
+		writeQuotaJSON(w, q.List())
+	})
+	mux.HandleFunc("GET /{scope}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if scope, ok := scopeOf(w, r); ok {
+			writeQuotaJSON(w, q.Usage(scope, r.PathValue("id")))
+		}
+	})
+	mux.HandleFunc("DELETE /{scope}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if scope, ok := scopeOf(w, r); ok {
+			q.Reset(scope, r.PathValue("id"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	return mux
+}
+
+func writeQuotaJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
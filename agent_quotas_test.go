@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+func newQuotaAgent(t *testing.T, model *stubModel, quotas *Quotas, tools ...Tool) *Agent {
+	t.Helper()
+	a, err := New(Options{
+		Model:  model,
+		Memory: memory.NewSessionMemory(&memory.MemoryBank{}, 0),
+		Tools:  tools,
+		Quotas: quotas,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func TestQuotasRejectTurnsOverDailyLimit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	quotas := &Quotas{Session: QuotaLimits{TurnsPerDay: 2}, Now: func() time.Time { return now }}
+	a := newQuotaAgent(t, &stubModel{response: "ok"}, quotas)
+	ctx := context.Background()
+
+	for i := range 2 {
+		if _, err := a.Generate(ctx, "s1", "hello there"); err != nil {
+			t.Fatalf("turn %d: %v", i+1, err)
+		}
+	}
+	_, err := a.Generate(ctx, "s1", "hello again")
+	var quotaErr *QuotaExceededError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("third turn err = %v, want a quota error", err)
+	}
+	if quotaErr.Scope != QuotaSession || quotaErr.ID != "s1" || quotaErr.Limit != "turns_per_day" || quotaErr.Used != 2 {
+		t.Fatalf("quota error = %+v", quotaErr)
+	}
+	if !quotaErr.ResetAt.Equal(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("reset at %v, want next UTC midnight", quotaErr.ResetAt)
+	}
+	if _, err := a.Generate(ctx, "s2", "hello there"); err != nil {
+		t.Fatalf("other sessions keep their own quota: %v", err)
+	}
+	if usage := quotas.Usage(QuotaSession, "s1"); usage.Turns != 2 || usage.Tokens == 0 {
+		t.Fatalf("usage = %+v", usage)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := a.Generate(ctx, "s1", "a new day"); err != nil {
+		t.Fatalf("quota should reset at midnight: %v", err)
+	}
+}
+
+func TestQuotasDegradeToFallbackModel(t *testing.T) {
+	quotas := &Quotas{
+		Tenant:   QuotaLimits{TokensPerDay: 1},
+		Action:   QuotaDegrade,
+		Fallback: &stubModel{response: "cheap"},
+	}
+	a := newQuotaAgent(t, &stubModel{response: "premium"}, quotas)
+	ctx := memory.ContextWithTenant(context.Background(), "acme")
+
+	first, err := a.Generate(ctx, "s1", "summarize the roadmap")
+	if err != nil || !strings.HasPrefix(first.(string), "premium") {
+		t.Fatalf("first turn = %v, %v", first, err)
+	}
+	second, err := a.Generate(ctx, "s2", "summarize the roadmap")
+	if err != nil || !strings.HasPrefix(second.(string), "cheap") {
+		t.Fatalf("over-quota turn = %v, %v; want the fallback model", second, err)
+	}
+	if usage := quotas.Usage(QuotaTenant, "acme"); usage.Turns != 2 {
+		t.Fatalf("tenant usage = %+v, want both turns", usage)
+	}
+}
+
+func TestQuotasRejectToolCallsOverHourlyLimit(t *testing.T) {
+	quotas := &Quotas{Session: QuotaLimits{ToolCallsPerHour: 1}}
+	a := newQuotaAgent(t, &stubModel{}, quotas, jsonTool{name: "lookup", content: "found"})
+	ctx, err := a.beginQuotaTurn(a.withTurnBudget(context.Background()), "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := a.executeTool(ctx, "s1", "lookup", nil); err != nil || result != "found" {
+		t.Fatalf("first call = %v, %v", result, err)
+	}
+	_, err = a.executeTool(ctx, "s1", "lookup", nil)
+	var quotaErr *QuotaExceededError
+	if !errors.As(err, &quotaErr) || quotaErr.Limit != "tool_calls_per_hour" {
+		t.Fatalf("second call err = %v", err)
+	}
+	if usage, _ := TurnUsageFromContext(ctx); usage.ToolCalls != 1 {
+		t.Fatalf("rejected call was charged to the turn: %+v", usage)
+	}
+}
+
+func TestQuotasHandlerInspectsAndResets(t *testing.T) {
+	quotas := &Quotas{Session: QuotaLimits{TurnsPerDay: 1}}
+	a := newQuotaAgent(t, &stubModel{response: "ok"}, quotas)
+	if _, err := a.Generate(context.Background(), "s1", "hello there"); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(quotas.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/session/s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usage QuotaUsage
+	err = json.NewDecoder(resp.Body).Decode(&usage)
+	resp.Body.Close()
+	if err != nil || usage.Turns != 1 || usage.Limits.TurnsPerDay != 1 {
+		t.Fatalf("usage = %+v, %v", usage, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/session/s1", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("reset = %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if _, err := a.Generate(context.Background(), "s1", "hello again"); err != nil {
+		t.Fatalf("turn after reset: %v", err)
+	}
+
+	resp, err = http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []QuotaUsage
+	err = json.NewDecoder(resp.Body).Decode(&all)
+	resp.Body.Close()
+	if err != nil || len(all) != 1 || all[0].ID != "s1" || all[0].Turns != 1 {
+		t.Fatalf("list = %+v, %v", all, err)
+	}
+	if resp, _ := http.Get(srv.URL + "/user/s1"); resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown scope should 404, got %v", resp)
+	}
+}
+
+func TestDegradedTurnsArePromptedForTheFallbackModel(t *testing.T) {
+	a, err := New(Options{
+		Model:        &chatModel{},
+		Memory:       memory.NewSessionMemory(&memory.MemoryBank{}, 0),
+		SystemPrompt: "You are the release bot.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), turnModelKey{}, models.Agent(&stubModel{response: "cheap"}))
+	messages := a.renderMessages(ctx, PromptData{SystemPrompt: a.systemPromptFor("s1"), Input: "ship it"}, nil)
+	if len(messages) != 1 || messages[0].Role != models.RoleUser || !strings.Contains(messages[0].Content, "release bot") {
+		t.Fatalf("a non-chat fallback should get the whole turn as one message: %+v", messages)
+	}
+}
+
+func TestQuotasReportFirstExceededLimitAndDropQuietCounters(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	quotas := &Quotas{
+		Session: QuotaLimits{TurnsPerDay: 1},
+		Tenant:  QuotaLimits{TurnsPerDay: 1},
+		Now:     func() time.Time { return now },
+	}
+	ctx := memory.ContextWithTenant(context.Background(), "acme")
+	turn := &quotaTurn{quotas: quotas, keys: quotas.keysFor(ctx, "s1")}
+	if _, err := turn.startTurn(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := turn.startTurn()
+	var quotaErr *QuotaExceededError
+	if !errors.As(err, &quotaErr) || quotaErr.Scope != QuotaSession {
+		t.Fatalf("err = %v; want the session limit, which is checked first", err)
+	}
+
+	now = now.Add(25 * time.Hour)
+	other := &quotaTurn{quotas: quotas, keys: quotas.keysFor(context.Background(), "s2")}
+	if _, err := other.startTurn(); err != nil {
+		t.Fatal(err)
+	}
+	if got := quotas.List(); len(got) != 1 || got[0].ID != "s2" {
+		t.Fatalf("List = %+v; counters from yesterday should be dropped", got)
+	}
+	quotas.mu.Lock()
+	defer quotas.mu.Unlock()
+	if len(quotas.usage) != 1 {
+		t.Fatalf("%d counters kept, want only s2's", len(quotas.usage))
+	}
+}
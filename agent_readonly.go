@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+// ErrReadOnly is returned when an agent in read-only mode is asked to run a
+// tool that is not marked ToolPolicy.ReadOnly.
+var ErrReadOnly = errors.New("agent is read-only")
+
+// checkReadOnlyTool refuses tools that may change state while the agent is
+// read-only.
+func (a *Agent) checkReadOnlyTool(toolName string) error {
+	if !a.ReadOnly || a.toolPolicy(toolName).ReadOnly {
+		return nil
+	}
+	return fmt.Errorf("%w: tool %s is not marked read-only", ErrReadOnly, toolName)
+}
+
+// readOnlyTools drops the tools a read-only agent may not run, so the
+// planner never picks them.
+func (a *Agent) readOnlyTools(list []tools.Tool) []tools.Tool {
+	if !a.ReadOnly {
+		return list
+	}
+	out := make([]tools.Tool, 0, len(list))
+	for _, t := range list {
+		if a.toolPolicy(t.Name).ReadOnly || PolicyFromTags(t.Tags).ReadOnly {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestReadOnlyAgentRetrievesButDoesNotWrite(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 8).WithEmbedder(memory.DummyEmbedder{})
+	embedding, _ := mem.Embed(ctx, "refund policy: 30 days")
+	if err := store.StoreMemory(ctx, "s1", "refund policy: 30 days", nil, embedding); err != nil {
+		t.Fatal(err)
+	}
+	writer := &stubTool{spec: ToolSpec{Name: "write_file"}}
+	reader := &stubTool{spec: ToolSpec{Name: "read_file", Policy: ToolPolicy{ReadOnly: true}}}
+	a, err := New(Options{Model: &stubModel{response: "ok"}, Memory: mem, Tools: []Tool{writer, reader}, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Generate(ctx, "s1", "what is the refund policy?"); err != nil {
+		t.Fatal(err)
+	}
+	if turn, _ := a.LastTurn("s1"); len(turn.Context) == 0 {
+		t.Fatal("read-only agent should still retrieve memories")
+	}
+	a.Save(ctx, "user", "remember this")
+	if err := a.Flush(ctx, "s1"); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	_ = store.Iterate(ctx, func(memory.MemoryRecord) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Fatalf("store has %d records, want only the seeded one", count)
+	}
+
+	if _, err := a.executeTool(ctx, "s1", "write_file", map[string]any{"input": "x"}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("mutating tool should be refused, got %v", err)
+	}
+	if writer.lastInput.Arguments != nil {
+		t.Fatal("mutating tool was invoked")
+	}
+	out, err := a.executeTool(ctx, "s1", "read_file", map[string]any{"input": "x"})
+	if err != nil || out != "x" {
+		t.Fatalf("read-only tool = %v, %v", out, err)
+	}
+	if got := PolicyFromTags([]string{"readonly"}); !got.ReadOnly {
+		t.Fatal("readonly tag not parsed")
+	}
+}
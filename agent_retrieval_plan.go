@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// RetrievalScope is which memory layers a query is answered from.
+type RetrievalScope int
+
+const (
+	RetrieveBoth RetrievalScope = iota
+	RetrieveShortTerm
+	RetrieveLongTerm
+	RetrieveNone
+)
+
+func (s RetrievalScope) String() string {
+	switch s {
+	case RetrieveShortTerm:
+		return "short_term"
+	case RetrieveLongTerm:
+		return "long_term"
+	case RetrieveNone:
+		return "none"
+	default:
+		return "both"
+	}
+}
+
+// RetrievalPlan is a planner's decision for one query.
+type RetrievalPlan struct {
+	Scope RetrievalScope
+	// Limit is the long-term search depth.
+	Limit  int
+	Reason string
+}
+
+// RetrievalPlanner decides, per query, which memory layers to search and
+// how deeply. limit is the agent's context limit. Set one with
+// Options.RetrievalPlanner; without one every query searches both layers
+// to the full limit.
+type RetrievalPlanner interface {
+	Plan(query string, limit int) RetrievalPlan
+}
+
+// RetrievalPlannerFunc adapts a function to RetrievalPlanner.
+type RetrievalPlannerFunc func(query string, limit int) RetrievalPlan
+
+func (f RetrievalPlannerFunc) Plan(query string, limit int) RetrievalPlan { return f(query, limit) }
+
+var (
+	// Follow-ups about what was just said are answered from the window.
+	recentCueRe = regexp.MustCompile(`(?i)\b(you just|just (said|told|mentioned|asked)|(your|the) (last|previous) (answer|reply|message|response)|above|that last|say that again|repeat that|rephrase( that| it)?|shorter|in other words|what do you mean|elaborate|expand on (that|it)|why is that|how so)\b`)
+	// Questions reaching back past the window need the store.
+	pastCueRe = regexp.MustCompile(`(?i)\b(remember|recall|last (week|month|year|time)|yesterday|ago|previously|earlier (today|this week)|we (discussed|talked about|decided)|did i (ever )?(tell|mention)|my (name|preference|preferences|birthday|address)|history)\b`)
+)
+
+// HeuristicRetrievalPlanner routes on query classification and recency cues:
+// math skips memory, follow-ups about the last few turns read only the
+// short-term window, questions about the past search long-term memory at full
+// depth, and short factoids search both at reduced depth.
+type HeuristicRetrievalPlanner struct{}
+
+func (HeuristicRetrievalPlanner) Plan(query string, limit int) RetrievalPlan {
+	switch {
+	case classifyQuery(query) == QueryMath:
+		return RetrievalPlan{Scope: RetrieveNone, Reason: "math"}
+	case pastCueRe.MatchString(query):
+		return RetrievalPlan{Scope: RetrieveBoth, Limit: limit, Reason: "past reference"}
+	case recentCueRe.MatchString(query):
+		return RetrievalPlan{Scope: RetrieveShortTerm, Reason: "recent follow-up"}
+	case classifyQuery(query) == QueryShortFactoid:
+		return RetrievalPlan{Scope: RetrieveBoth, Limit: intMin(limit/2, 3), Reason: "short factoid"}
+	default:
+		return RetrievalPlan{Scope: RetrieveBoth, Limit: limit, Reason: "complex"}
+	}
+}
+
+// planRetrieval applies a.RetrievalPlanner, if any. Shared sessions merge
+// layers across spaces, so for them only RetrieveNone and the depth apply.
+func (a *Agent) planRetrieval(ctx context.Context, sessionID, query string, limit int) ([]memory.MemoryRecord, error) {
+	a.mu.Lock()
+	planner, shared := a.RetrievalPlanner, a.Shared
+	a.mu.Unlock()
+	plan := RetrievalPlan{Scope: RetrieveBoth, Limit: limit}
+	if planner != nil {
+		plan = planner.Plan(strings.TrimSpace(query), limit)
+	}
+	if plan.Scope == RetrieveNone {
+		return nil, nil
+	}
+	if shared != nil {
+		return shared.Retrieve(ctx, query, plan.Limit)
+	}
+	switch plan.Scope {
+	case RetrieveShortTerm:
+		return a.memory.ShortTermContext(ctx, sessionID), nil
+	case RetrieveLongTerm:
+		return a.memory.RetrieveLongTerm(ctx, sessionID, query, plan.Limit)
+	default:
+		return a.memory.RetrieveContext(ctx, sessionID, query, plan.Limit)
+	}
+}
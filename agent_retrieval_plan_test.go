@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestHeuristicRetrievalPlanner(t *testing.T) {
+	cases := []struct {
+		query string
+		scope RetrievalScope
+		limit int
+	}{
+		{"12 * 7", RetrieveNone, 0},
+		{"Can you say that again but shorter please", RetrieveShortTerm, 0},
+		{"What did you just say about the rollout plan", RetrieveShortTerm, 0},
+		{"Do you remember what we decided about the rollout plan", RetrieveBoth, 8},
+		{"capital of France?", RetrieveBoth, 3},
+		{"Draft a migration plan for moving the billing service to Postgres", RetrieveBoth, 8},
+	}
+	for _, tc := range cases {
+		plan := HeuristicRetrievalPlanner{}.Plan(tc.query, 8)
+		if plan.Scope != tc.scope || plan.Limit != tc.limit {
+			t.Errorf("Plan(%q) = %s/%d (%s), want %s/%d", tc.query, plan.Scope, plan.Limit, plan.Reason, tc.scope, tc.limit)
+		}
+	}
+}
+
+func TestRecentFollowUpSkipsLongTermSearch(t *testing.T) {
+	ctx := context.Background()
+	store := &searchCountingStore{InMemoryStore: memory.NewInMemoryStore()}
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 8).WithEmbedder(memory.DummyEmbedder{})
+	model := &coordinatorModel{replies: []string{"Roll out to 5% first, then everyone.", "5% first."}}
+	a, err := New(Options{Model: model, Memory: mem, RetrievalPlanner: HeuristicRetrievalPlanner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Generate(ctx, "s1", "How should we roll out the new checkout flow"); err != nil {
+		t.Fatal(err)
+	}
+	searches := store.searchCalls.Load()
+	if searches == 0 {
+		t.Fatal("the opening question should search long-term memory")
+	}
+	if _, err := a.Generate(ctx, "s1", "Say that again but shorter"); err != nil {
+		t.Fatal(err)
+	}
+	// Attachment rehydration still runs; only the semantic search is skipped.
+	if followUp := store.searchCalls.Load() - searches; followUp != searches-1 {
+		t.Fatalf("follow-up made %d searches, want %d", followUp, searches-1)
+	}
+	if prompt := model.prompts[len(model.prompts)-1]; !strings.Contains(prompt, "Roll out to 5% first") {
+		t.Fatalf("follow-up prompt should carry the short-term window:\n%s", prompt)
+	}
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// CallScope links a nested agent call to the conversation that made it.
+// The agent stamps it on the context of every tool call, so agents exposed
+// as tools know their parent session and share one trace ID across the
+// whole call tree.
+type CallScope struct {
+	// ParentSession is the session of the agent that called the tool.
+	ParentSession string `json:"parent_session,omitempty"`
+	// TraceID is shared by every call under one top-level request.
+	TraceID string `json:"trace_id,omitempty"`
+	// Workflow identifies the workflow invocation the call belongs to.
+	Workflow string `json:"workflow,omitempty"`
+}
+
+type callScopeContextKey struct{}
+
+// ContextWithCallScope attaches scope to ctx.
+func ContextWithCallScope(ctx context.Context, scope CallScope) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, callScopeContextKey{}, scope)
+}
+
+// CallScopeFromContext returns the scope attached to ctx.
+func CallScopeFromContext(ctx context.Context) (CallScope, bool) {
+	if ctx == nil {
+		return CallScope{}, false
+	}
+	scope, ok := ctx.Value(callScopeContextKey{}).(CallScope)
+	return scope, ok
+}
+
+// ContextWithWorkflow marks ctx as part of the workflow invocation id, so
+// agent tools bound with WithWorkflowSession keep one session for it.
+func ContextWithWorkflow(ctx context.Context, id string) context.Context {
+	scope, _ := CallScopeFromContext(ctx)
+	scope.Workflow = id
+	return ContextWithCallScope(ctx, scope)
+}
+
+// withToolCallScope records sessionID as the parent of the tool calls made
+// under ctx, starting a trace if there is none yet.
+func withToolCallScope(ctx context.Context, sessionID string) context.Context {
+	scope, _ := CallScopeFromContext(ctx)
+	if scope.TraceID == "" {
+		scope.TraceID = newTraceID()
+	}
+	scope.ParentSession = sessionID
+	return ContextWithCallScope(ctx, scope)
+}
+
+func newTraceID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// scopeMetadata is the memory metadata recording who called the agent.
+func scopeMetadata(ctx context.Context) map[string]string {
+	scope, ok := CallScopeFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	meta := map[string]string{}
+	if scope.ParentSession != "" {
+		meta["parent_session"] = scope.ParentSession
+	}
+	if scope.TraceID != "" {
+		meta["trace_id"] = scope.TraceID
+	}
+	if scope.Workflow != "" {
+		meta["workflow"] = scope.Workflow
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// AgentToolOption configures an agent exposed with AsTool or AsUTCPTool.
+type AgentToolOption func(*agentToolConfig)
+
+type agentToolConfig struct {
+	workflowSession bool
+}
+
+// WithWorkflowSession binds the agent tool to one session per workflow
+// invocation, so a specialist called from several steps of the same
+// workflow keeps its context across them. Calls outside a workflow use the
+// default, per-parent session.
+func WithWorkflowSession() AgentToolOption {
+	return func(c *agentToolConfig) { c.workflowSession = true }
+}
+
+func newAgentToolConfig(opts []AgentToolOption) agentToolConfig {
+	var cfg agentToolConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// nestedSession picks the session a nested agent call runs in: the
+// workflow's session when bound to it, else one per parent session (parent,
+// or the scope's), else fallback.
+func (c agentToolConfig) nestedSession(ctx context.Context, name, parent, fallback string) string {
+	scope, _ := CallScopeFromContext(ctx)
+	if c.workflowSession && scope.Workflow != "" {
+		return fmt.Sprintf("workflow:%s.sub.%s", scope.Workflow, name)
+	}
+	if strings.TrimSpace(parent) == "" {
+		parent = scope.ParentSession
+	}
+	if strings.TrimSpace(parent) != "" {
+		return fmt.Sprintf("%s.sub.%s", parent, name)
+	}
+	return fallback
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestAgentToolsPropagateCallScope(t *testing.T) {
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	nested, _ := New(Options{Model: &stubModel{response: "nested"}, Memory: mem})
+	outer, err := New(Options{Model: &stubModel{}, Memory: mem, Tools: []Tool{
+		nested.AsTool("helper", "helps"),
+		nested.AsTool("analyst", "analyses", WithWorkflowSession()),
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := outer.executeTool(ctx, "alice", "helper", map[string]any{"instruction": "summarise the plan"}); err != nil {
+		t.Fatalf("executeTool: %v", err)
+	}
+	turn, ok := nested.LastTurn("alice.sub.helper")
+	if !ok {
+		t.Fatal("expected a turn in the per-parent sub-session")
+	}
+	if turn.Scope.ParentSession != "alice" || turn.Scope.TraceID == "" {
+		t.Fatalf("unexpected scope: %+v", turn.Scope)
+	}
+
+	// Two steps of one workflow, run from different sessions, share the
+	// analyst's session and the caller's trace.
+	wf := ContextWithCallScope(ContextWithWorkflow(ctx, "wf-7"), CallScope{TraceID: "trace-1", Workflow: "wf-7"})
+	for _, parent := range []string{"step-a", "step-b"} {
+		if _, err := outer.executeTool(wf, parent, "analyst", map[string]any{"instruction": "analyse the numbers from " + parent}); err != nil {
+			t.Fatalf("executeTool: %v", err)
+		}
+	}
+	turn, ok = nested.LastTurn("workflow:wf-7.sub.analyst")
+	if !ok {
+		t.Fatal("expected a turn in the workflow session")
+	}
+	if turn.Scope.TraceID != "trace-1" || turn.Scope.ParentSession != "step-b" || turn.Scope.Workflow != "wf-7" {
+		t.Fatalf("unexpected scope: %+v", turn.Scope)
+	}
+	history, err := mem.RetrieveContext(ctx, "workflow:wf-7.sub.analyst", "numbers", 10)
+	if err != nil {
+		t.Fatalf("RetrieveContext: %v", err)
+	}
+	if len(history) < 2 {
+		t.Fatalf("expected both steps in the workflow session, got %d records", len(history))
+	}
+}
+
+func TestAsUTCPToolReadsRemoteScope(t *testing.T) {
+	mem := memory.NewSessionMemory(&memory.MemoryBank{}, 0)
+	nested, _ := New(Options{Model: &stubModel{response: "ok"}, Memory: mem})
+	tool := nested.AsUTCPTool("local.agent", "desc")
+
+	_, err := tool.Handler(context.Background(), map[string]any{
+		"instruction":       "review the draft",
+		"parent_session_id": "orchestrator",
+		"trace_id":          "abc",
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	turn, ok := nested.LastTurn("orchestrator.sub.local.agent")
+	if !ok || turn.Scope.TraceID != "abc" {
+		t.Fatalf("turn = %+v, %v", turn, ok)
+	}
+}
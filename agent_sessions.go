@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ErrSessionNotFound is returned when a session is not in a SessionStore.
+var ErrSessionNotFound = errors.New("session not found")
+
+// DefaultSessionTitleAfter is how many turns a session has before it is
+// titled, unless Options.SessionTitleAfter says otherwise.
+const DefaultSessionTitleAfter = 3
+
+// SessionInfo describes a conversation for session lists.
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title,omitempty"`
+	Topics    []string  `json:"topics,omitempty"`
+	Turns     int       `json:"turns"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Metadata is free-form and left alone by the agent.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// SessionStore persists SessionInfo. Save creates or replaces a session;
+// List returns the most recently updated first.
+type SessionStore interface {
+	Save(ctx context.Context, info SessionInfo) error
+	Load(ctx context.Context, id string) (SessionInfo, error)
+	List(ctx context.Context) ([]SessionInfo, error)
+}
+
+// InMemorySessionStore keeps sessions in process.
+type InMemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]SessionInfo
+}
+
+// NewInMemorySessionStore returns an empty in-memory store.
+func NewInMemorySessionStore() *InMemorySessionStore {
+	return &InMemorySessionStore{sessions: map[string]SessionInfo{}}
+}
+
+func (s *InMemorySessionStore) Save(ctx context.Context, info SessionInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = map[string]SessionInfo{}
+	}
+	s.sessions[info.ID] = cloneSessionInfo(info)
+	return nil
+}
+
+func (s *InMemorySessionStore) Load(ctx context.Context, id string) (SessionInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return SessionInfo{}, err
+	}
+	s.mu.RLock()
+	info, ok := s.sessions[id]
+	s.mu.RUnlock()
+	if !ok {
+		return SessionInfo{}, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return cloneSessionInfo(info), nil
+}
+
+func (s *InMemorySessionStore) List(ctx context.Context) ([]SessionInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	out := make([]SessionInfo, 0, len(s.sessions))
+	for _, info := range s.sessions {
+		out = append(out, cloneSessionInfo(info))
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].UpdatedAt.Equal(out[j].UpdatedAt) {
+			return out[i].UpdatedAt.After(out[j].UpdatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func cloneSessionInfo(info SessionInfo) SessionInfo {
+	info.Topics = append([]string(nil), info.Topics...)
+	if info.Metadata != nil {
+		meta := make(map[string]string, len(info.Metadata))
+		for k, v := range info.Metadata {
+			meta[k] = v
+		}
+		info.Metadata = meta
+	}
+	return info
+}
+
+// SessionTitler names a session from its opening turns.
+type SessionTitler interface {
+	Title(ctx context.Context, turns []Turn) (title string, topics []string, err error)
+}
+
+// ModelSessionTitler asks a model for a title and topic tags.
+type ModelSessionTitler struct {
+	Model models.Agent
+	// MaxTopics caps the tags kept; 5 when 0.
+	MaxTopics int
+}
+
+const sessionTitlePrompt = `Give this conversation a short title (at most 8 words) and up to %d lowercase topic tags.
+Reply with JSON only: {"title": "...", "topics": ["...", "..."]}
+
+%s`
+
+func (t ModelSessionTitler) Title(ctx context.Context, turns []Turn) (string, []string, error) {
+	if t.Model == nil {
+		return "", nil, errors.New("session titler has no model")
+	}
+	maxTopics := t.MaxTopics
+	if maxTopics <= 0 {
+		maxTopics = 5
+	}
+	var sb strings.Builder
+	for _, turn := range turns {
+		fmt.Fprintf(&sb, "User: %s\nAssistant: %s\n", truncate(sanitizeInput(turn.Input), 500), truncate(turn.Output, 500))
+	}
+	raw, err := t.Model.Generate(ctx, fmt.Sprintf(sessionTitlePrompt, maxTopics, sb.String()))
+	if err != nil {
+		return "", nil, err
+	}
+	var reply struct {
+		Title  string   `json:"title"`
+		Topics []string `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(fmt.Sprint(raw))), &reply); err != nil || strings.TrimSpace(reply.Title) == "" {
+		// Fall back to the opening message so the session still gets a name.
+		return truncate(strings.TrimSpace(turns[0].Input), 60), nil, nil
+	}
+	topics := make([]string, 0, len(reply.Topics))
+	seen := map[string]bool{}
+	for _, topic := range reply.Topics {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic == "" || seen[topic] || len(topics) == maxTopics {
+			continue
+		}
+		seen[topic] = true
+		topics = append(topics, topic)
+	}
+	return truncate(strings.TrimSpace(reply.Title), 80), topics, nil
+}
+
+// trackSession counts turn against its session and, once the session has
+// SessionTitleAfter turns, titles it in the background.
+func (a *Agent) trackSession(ctx context.Context, turn Turn) {
+	a.mu.Lock()
+	sessions, after := a.Sessions, a.SessionTitleAfter
+	a.mu.Unlock()
+	if sessions == nil || a.ReadOnly {
+		return
+	}
+	if after <= 0 {
+		after = DefaultSessionTitleAfter
+	}
+	// Session state is read-modify-write; sessionsMu keeps concurrent
+	// turns from losing counts.
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	info, err := sessions.Load(ctx, turn.SessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		info, err = SessionInfo{ID: turn.SessionID, CreatedAt: turn.At}, nil
+	}
+	if err != nil {
+		return
+	}
+	info.Turns++
+	info.UpdatedAt = turn.At
+	if err := sessions.Save(ctx, info); err != nil || info.Title != "" {
+		return
+	}
+
+	if a.sessionOpenings == nil {
+		a.sessionOpenings = map[string][]Turn{}
+	}
+	opening := append(a.sessionOpenings[turn.SessionID], turn)
+	if len(opening) < after {
+		a.sessionOpenings[turn.SessionID] = opening
+		return
+	}
+	delete(a.sessionOpenings, turn.SessionID)
+	titleCtx := context.WithoutCancel(ctx)
+	a.sessionTitling.Add(1)
+	go func() {
+		defer a.sessionTitling.Done()
+		_ = a.titleSession(titleCtx, turn.SessionID, opening)
+	}()
+}
+
+func (a *Agent) titleSession(ctx context.Context, sessionID string, opening []Turn) error {
+	a.mu.Lock()
+	titler := a.SessionTitler
+	model := a.model
+	a.mu.Unlock()
+	if titler == nil {
+		titler = ModelSessionTitler{Model: model}
+	}
+	title, topics, err := titler.Title(ctx, opening)
+	if err != nil {
+		return err
+	}
+	return a.SetSessionTitle(ctx, sessionID, title, topics)
+}
+
+// SetSessionTitle sets a session's title and topics, for example when a user
+// renames a conversation. A later automatic title never overwrites it.
+func (a *Agent) SetSessionTitle(ctx context.Context, sessionID, title string, topics []string) error {
+	if a.Sessions == nil {
+		return errors.New("agent has no session store")
+	}
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	info, err := a.Sessions.Load(ctx, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		info, err = SessionInfo{ID: sessionID, CreatedAt: time.Now().UTC()}, nil
+	}
+	if err != nil {
+		return err
+	}
+	info.Title = title
+	info.Topics = append([]string(nil), topics...)
+	delete(a.sessionOpenings, sessionID)
+	return a.Sessions.Save(ctx, info)
+}
+
+// Session returns what the agent knows about a session.
+func (a *Agent) Session(ctx context.Context, sessionID string) (SessionInfo, error) {
+	if a.Sessions == nil {
+		return SessionInfo{}, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return a.Sessions.Load(ctx, sessionID)
+}
+
+// ListSessions returns the agent's sessions, most recently active first.
+func (a *Agent) ListSessions(ctx context.Context) ([]SessionInfo, error) {
+	if a.Sessions == nil {
+		return nil, nil
+	}
+	return a.Sessions.List(ctx)
+}
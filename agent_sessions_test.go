@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestSessionsAreTitledAfterOpeningTurns(t *testing.T) {
+	ctx := context.Background()
+	model := &coordinatorModel{replies: []string{
+		"Try reseating the toner.",
+		"Then clear the jam from the rear door.",
+		"```json\n{\"title\": \"Office printer paper jam\", \"topics\": [\"Printer\", \"hardware\", \"printer\"]}\n```",
+	}}
+	sessions := NewInMemorySessionStore()
+	a, err := New(Options{
+		Model:             model,
+		Memory:            memory.NewSessionMemory(&memory.MemoryBank{}, 4),
+		Sessions:          sessions,
+		SessionTitleAfter: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Generate(ctx, "s1", "The office printer keeps jamming on every page"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := a.Session(ctx, "s1"); err != nil || info.Turns != 1 || info.Title != "" {
+		t.Fatalf("after one turn: %+v, %v", info, err)
+	}
+	if _, err := a.Generate(ctx, "s1", "I already tried that and it still jams"); err != nil {
+		t.Fatal(err)
+	}
+	a.sessionTitling.Wait()
+
+	info, err := a.Session(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Office printer paper jam" || strings.Join(info.Topics, ",") != "printer,hardware" || info.Turns != 2 {
+		t.Fatalf("session = %+v", info)
+	}
+
+	// A rename sticks; later turns only bump the count.
+	if err := a.SetSessionTitle(ctx, "s1", "Printer", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Generate(ctx, "s1", "It works now, thanks a lot for the help"); err != nil {
+		t.Fatal(err)
+	}
+	a.sessionTitling.Wait()
+	list, err := a.ListSessions(ctx)
+	if err != nil || len(list) != 1 || list[0].Title != "Printer" || list[0].Turns != 3 {
+		t.Fatalf("ListSessions = %+v, %v", list, err)
+	}
+}
+
+func TestModelSessionTitlerFallsBackToOpeningMessage(t *testing.T) {
+	titler := ModelSessionTitler{Model: &coordinatorModel{replies: []string{"not json"}}}
+	title, topics, err := titler.Title(context.Background(), []Turn{{Input: "How do I rotate my API keys?"}})
+	if err != nil || title != "How do I rotate my API keys?" || len(topics) != 0 {
+		t.Fatalf("Title = %q, %v, %v", title, topics, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
@@ -14,6 +15,12 @@ import (
 // GenerateStream provides a streaming interface for the agent's generation process.
 // It follows the same logic as Generate but returns a channel of chunks.
 func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string) (<-chan models.StreamChunk, error) {
+	started := time.Now()
+	ctx = a.withTurnBudget(ctx)
+	ctx, err := a.beginQuotaTurn(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
 	if a.InputGuardrails != nil {
 		transformed, err := a.InputGuardrails.ValidateAndTransform(ctx, userInput)
 		if err != nil {
@@ -41,18 +48,21 @@ func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string)
 	}
 
 	// 0. DIRECT TOOL INVOCATION
-	if toolName, args, ok := a.detectDirectToolCall(trimmed); ok {
+	toolName, args, ok, ambiguous := a.detectDirectToolCall(sessionID, trimmed)
+	if ambiguous != nil {
+		return immediateStream(ambiguous.Prompt(), nil)
+	}
+	if ok {
 		result, err := a.executeTool(ctx, sessionID, toolName, args)
 		return immediateStream(result, err)
 	}
 
-	// 1. SUBAGENT COMMANDS
-	if handled, out, meta, err := a.handleCommand(ctx, sessionID, userInput); handled {
+	// 1. SUBAGENT COMMANDS (streamed as the sub-agent produces output)
+	if sa, instruction, ok, err := a.parseSubAgentCommand(userInput); ok {
 		if err != nil {
 			return nil, err
 		}
-		a.storeMemory(sessionID, "subagent", out, meta)
-		return immediateStream(out, nil)
+		return a.streamSubAgentCommand(ctx, sessionID, sa, instruction), nil
 	}
 
 	// CodeMode can make an LLM-backed selection pass before deciding that it
@@ -72,7 +82,7 @@ func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string)
 
 	// 2. CODEMODE
 	if a.CodeMode != nil {
-		handled, output, err := a.CodeMode.CallTool(ctx, userInput)
+		handled, output, err := a.callCodeMode(ctx, sessionID, userInput)
 		if err != nil {
 			return immediateStream(output, err)
 		}
@@ -83,12 +93,13 @@ func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string)
 
 	// 3. TOOL ORCHESTRATOR
 	prefetchWG.Wait()
+	ctx = models.WithContextFingerprint(ctx, memoryFingerprint(records))
 	if handled, output, err := a.toolOrchestrator(ctx, sessionID, userInput, records); handled {
 		return immediateStream(output, err)
 	}
 
 	// 5. STORE USER MEMORY
-	a.storeMemory(sessionID, "user", userInput, nil)
+	a.storeMemory(ctx, sessionID, "user", userInput, scopeMetadata(ctx))
 
 	// If it looked like a tool call but wasn't handled, return empty
 	if a.userLooksLikeToolCall(trimmed) {
@@ -97,30 +108,66 @@ func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string)
 
 	// 6. LLM COMPLETION (Streaming)
 	// Build prompt manually to use pre-fetched records
-	var sb strings.Builder
-	sb.Grow(4096)
-	sb.WriteString(a.systemPrompt)
-	sb.WriteString("\n\nConversation memory (TOON):\n")
-	sb.WriteString(a.renderMemory(records))
-	sb.WriteString("\n\nUser: ")
-	sb.WriteString(sanitizeInput(userInput))
-	sb.WriteString("\n\n")
-
-	prompt := sb.String()
-
-	stream, err := a.model.GenerateStream(ctx, prompt)
+	sections, err := a.renderContextSections(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
+	prompt := a.renderPrompt(ctx, PromptData{
+		SessionID:    sessionID,
+		SystemPrompt: a.systemPromptFor(sessionID),
+		Context:      sections,
+		Memory:       records,
+		Input:        sanitizeInput(userInput),
+	})
+	if err := chargeTokens(ctx, prompt); err != nil {
+		return nil, err
+	}
 
-	// Wrap the stream to intercept and store memory
-	outCh := make(chan models.StreamChunk)
+	if stops := a.StopSequences; len(stops) > 0 && models.StopSequences(ctx) == nil {
+		ctx = models.WithStopSequences(ctx, stops...)
+	}
+	stream, err := a.modelFor(ctx).GenerateStream(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrap the stream to intercept and store memory. Canceling ctx stops
+	// the turn: what the model produced so far is kept, tagged as
+	// interrupted, and reported on a final chunk if the caller still reads.
+	outCh := make(chan models.StreamChunk, 1)
+	interrupt := func(partial string) {
+		go drainStream(stream)
+		ctx := context.WithoutCancel(ctx)
+		if a.Guardrails != nil && partial != "" {
+			validated, gErr := a.Guardrails.ValidateAndRepair(ctx, partial)
+			if gErr != nil {
+				return
+			}
+			partial = validated
+		}
+		if partial != "" {
+			turnID := a.recordTurn(ctx, sessionID, userInput, partial, records, started)
+			a.storeMemory(ctx, sessionID, "assistant", partial, map[string]string{"turn_id": turnID, "interrupted": "true"})
+		}
+		select {
+		case outCh <- models.StreamChunk{Done: true, FullText: partial, Interrupted: true}:
+		default:
+		}
+	}
 
 	if a.Guardrails != nil {
 		go func() {
 			defer close(outCh)
 			var full strings.Builder
-			for chunk := range stream {
+			for {
+				chunk, ok, canceled := nextChunk(ctx, stream)
+				if canceled {
+					interrupt(full.String())
+					return
+				}
+				if !ok {
+					break
+				}
 				if chunk.Err != nil {
 					outCh <- chunk
 					return
@@ -139,13 +186,22 @@ func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string)
 
 			// Stream out the validated text as one chunk
 			outCh <- models.StreamChunk{Delta: validatedText, FullText: validatedText, Done: true}
-			a.storeMemory(sessionID, "assistant", validatedText, nil)
+			turnID := a.recordTurn(ctx, sessionID, userInput, validatedText, records, started)
+			a.storeMemory(ctx, sessionID, "assistant", validatedText, map[string]string{"turn_id": turnID})
 		}()
 	} else {
 		go func() {
 			defer close(outCh)
 			var full strings.Builder
-			for chunk := range stream {
+			for {
+				chunk, ok, canceled := nextChunk(ctx, stream)
+				if canceled {
+					interrupt(full.String())
+					return
+				}
+				if !ok {
+					break
+				}
 				if chunk.Err != nil {
 					outCh <- chunk
 					return
@@ -153,13 +209,39 @@ func (a *Agent) GenerateStream(ctx context.Context, sessionID, userInput string)
 				if chunk.Delta != "" {
 					full.WriteString(chunk.Delta)
 				}
-				outCh <- chunk
+				select {
+				case outCh <- chunk:
+				case <-ctx.Done():
+					interrupt(full.String())
+					return
+				}
 			}
 			// Store memory after completion
 			finalText := full.String()
-			a.storeMemory(sessionID, "assistant", finalText, nil)
+			turnID := a.recordTurn(ctx, sessionID, userInput, finalText, records, started)
+			a.storeMemory(ctx, sessionID, "assistant", finalText, map[string]string{"turn_id": turnID})
 		}()
 	}
 
 	return outCh, nil
 }
+
+// nextChunk receives from stream until ctx is canceled. A stream that
+// fails because of the cancellation counts as canceled too.
+func nextChunk(ctx context.Context, stream <-chan models.StreamChunk) (chunk models.StreamChunk, ok, canceled bool) {
+	select {
+	case chunk, ok = <-stream:
+		if (chunk.Err != nil || chunk.Interrupted) && ctx.Err() != nil {
+			return chunk, ok, true
+		}
+		return chunk, ok, false
+	case <-ctx.Done():
+		return models.StreamChunk{}, false, true
+	}
+}
+
+// drainStream discards the rest of a stream so its producer can finish.
+func drainStream(stream <-chan models.StreamChunk) {
+	for range stream {
+	}
+}
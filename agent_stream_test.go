@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// hangingStreamModel streams one chunk and then waits for cancellation.
+type hangingStreamModel struct {
+	stubModel
+	stops []string
+}
+
+func (m *hangingStreamModel) GenerateStream(ctx context.Context, _ string) (<-chan models.StreamChunk, error) {
+	m.stops = models.StopSequences(ctx)
+	ch := make(chan models.StreamChunk)
+	go func() {
+		defer close(ch)
+		ch <- models.StreamChunk{Delta: "the first half"}
+		<-ctx.Done()
+		ch <- models.StreamChunk{Done: true, FullText: "the first half", Err: ctx.Err()}
+	}()
+	return ch, nil
+}
+
+func TestGenerateStreamCancelKeepsInterruptedOutput(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 8).WithEmbedder(memory.DummyEmbedder{})
+	model := &hangingStreamModel{}
+	a, err := New(Options{Model: model, Memory: mem, StopSequences: []string{"\nUser:"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := a.GenerateStream(ctx, "s1", "explain the outage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := <-stream; first.Delta != "the first half" {
+		t.Fatalf("first chunk = %+v", first)
+	}
+	cancel()
+	var last models.StreamChunk
+	for chunk := range stream {
+		last = chunk
+	}
+	if !last.Interrupted || last.FullText != "the first half" || last.Err != nil {
+		t.Fatalf("final chunk = %+v, want the partial output marked interrupted", last)
+	}
+	if len(model.stops) != 1 || model.stops[0] != "\nUser:" {
+		t.Fatalf("model saw stop sequences %q", model.stops)
+	}
+
+	if err := a.Flush(context.Background(), "s1"); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	_ = store.Iterate(context.Background(), func(rec memory.MemoryRecord) bool {
+		if strings.Contains(rec.Content, "the first half") && strings.Contains(rec.Metadata, `"interrupted":"true"`) {
+			found = true
+		}
+		return !found
+	})
+	if !found {
+		t.Fatal("the interrupted output was not kept in memory")
+	}
+}
@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// subAgentToolPrefix names sub-agents in tool events, matching the
+// "subagent:<name>" command syntax.
+const subAgentToolPrefix = "subagent:"
+
+// subAgentResult is the outcome of one delegated run.
+type subAgentResult struct {
+	Output string
+	// Partial is set when the run was cut short by the sub-agent timeout
+	// after producing some output.
+	Partial bool
+	// Cached is set when the result came from the sub-agent result cache.
+	Cached bool
+}
+
+// defaultSubAgentCacheSize bounds the sub-agent result cache when
+// SubAgentCacheSize is unset.
+const defaultSubAgentCacheSize = 256
+
+// subAgentCacheKey identifies a delegation by session, sub-agent and
+// normalized instruction, so rephrasings that differ only in case,
+// spacing or trailing punctuation share an entry. Results never cross
+// sessions.
+func subAgentCacheKey(sessionID, name, instruction string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(instruction)), " ")
+	normalized = strings.TrimRight(normalized, ".!?;: ")
+	return cache.HashKey(sessionID + "\x00" + strings.ToLower(name) + "\x00" + normalized)
+}
+
+func (a *Agent) subAgentResults() *cache.LRUCache {
+	if a.SubAgentCacheTTL <= 0 {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.subAgentCache == nil {
+		size := a.SubAgentCacheSize
+		if size <= 0 {
+			size = defaultSubAgentCacheSize
+		}
+		a.subAgentCache = cache.NewLRUCache(size, a.SubAgentCacheTTL)
+	}
+	return a.subAgentCache
+}
+
+// StreamSubAgent runs sa and returns its output as a stream. Sub-agents
+// implementing StreamingSubAgent stream natively; others produce a single
+// final chunk.
+func StreamSubAgent(ctx context.Context, sa SubAgent, input string) (<-chan models.StreamChunk, error) {
+	if s, ok := sa.(StreamingSubAgent); ok {
+		return s.RunStream(ctx, input)
+	}
+	ch := make(chan models.StreamChunk, 1)
+	go func() {
+		defer close(ch)
+		out, err := sa.Run(ctx, input)
+		if err != nil {
+			ch <- models.StreamChunk{Err: err, Done: true}
+			return
+		}
+		ch <- models.StreamChunk{Delta: out, FullText: out, Done: true}
+	}()
+	return ch, nil
+}
+
+// runSubAgent delegates input to sa under the agent's SubAgentTimeout,
+// passing each piece of output to onDelta (when non-nil) and reporting
+// progress as tool events named "subagent:<name>". A run that times out
+// after producing output returns that output marked partial; one that
+// produced nothing returns the timeout error. With SubAgentCacheTTL set,
+// complete results are reused for repeated instructions.
+func (a *Agent) runSubAgent(ctx context.Context, sessionID string, sa SubAgent, input string, onDelta func(string)) (subAgentResult, error) {
+	results := a.subAgentResults()
+	key := subAgentCacheKey(sessionID, sa.Name(), input)
+	if results != nil {
+		if v, ok := results.Get(key); ok {
+			out := v.(string)
+			if onDelta != nil {
+				onDelta(out)
+			}
+			emitToolEvent(ctx, ToolEvent{Type: ToolEventResult, SessionID: sessionID, Tool: subAgentToolPrefix + sa.Name(), Result: out, Time: time.Now()})
+			return subAgentResult{Output: out, Cached: true}, nil
+		}
+	}
+
+	res, err := a.delegate(ctx, sessionID, sa, input, onDelta)
+	if err == nil && !res.Partial && results != nil {
+		results.Set(key, res.Output)
+	}
+	return res, err
+}
+
+// delegate performs one uncached sub-agent run for runSubAgent.
+func (a *Agent) delegate(ctx context.Context, sessionID string, sa SubAgent, input string, onDelta func(string)) (subAgentResult, error) {
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if a.SubAgentTimeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, a.SubAgentTimeout)
+	}
+	defer cancel()
+
+	name := subAgentToolPrefix + sa.Name()
+	started := time.Now()
+	emitToolEvent(ctx, ToolEvent{Type: ToolEventStart, SessionID: sessionID, Tool: name, Arguments: map[string]any{"instruction": input}, Time: started})
+	fail := func(err error) (subAgentResult, error) {
+		emitToolEvent(ctx, ToolEvent{Type: ToolEventError, SessionID: sessionID, Tool: name, Error: err.Error(), Time: time.Now(), Duration: time.Since(started)})
+		return subAgentResult{}, err
+	}
+
+	stream, err := StreamSubAgent(runCtx, sa, input)
+	if err != nil {
+		return fail(err)
+	}
+	var out strings.Builder
+	for {
+		select {
+		case chunk, ok := <-stream:
+			if !ok {
+				chunk = models.StreamChunk{Done: true}
+			}
+			if chunk.Err != nil {
+				if runCtx.Err() != nil && ctx.Err() == nil {
+					return a.subAgentTimedOut(ctx, sessionID, name, started, out.String())
+				}
+				return fail(chunk.Err)
+			}
+			if chunk.Delta != "" {
+				out.WriteString(chunk.Delta)
+				if onDelta != nil {
+					onDelta(chunk.Delta)
+				}
+				emitToolEvent(ctx, ToolEvent{Type: ToolEventChunk, SessionID: sessionID, Tool: name, Result: chunk.Delta, Time: time.Now()})
+			}
+			if chunk.Done {
+				result := out.String()
+				if chunk.FullText != "" {
+					result = chunk.FullText
+				}
+				emitToolEvent(ctx, ToolEvent{Type: ToolEventResult, SessionID: sessionID, Tool: name, Result: result, Time: time.Now(), Duration: time.Since(started)})
+				return subAgentResult{Output: result}, nil
+			}
+		case <-runCtx.Done():
+			if ctx.Err() != nil {
+				return fail(ctx.Err())
+			}
+			return a.subAgentTimedOut(ctx, sessionID, name, started, out.String())
+		}
+	}
+}
+
+func (a *Agent) subAgentTimedOut(ctx context.Context, sessionID, name string, started time.Time, partial string) (subAgentResult, error) {
+	err := fmt.Errorf("%s timed out after %s: %w", name, a.SubAgentTimeout, context.DeadlineExceeded)
+	emitToolEvent(ctx, ToolEvent{Type: ToolEventError, SessionID: sessionID, Tool: name, Result: partial, Error: err.Error(), Time: time.Now(), Duration: time.Since(started)})
+	if strings.TrimSpace(partial) == "" {
+		return subAgentResult{}, err
+	}
+	return subAgentResult{Output: partial, Partial: true}, nil
+}
+
+// partialNotice is appended to sub-agent output cut short by the timeout.
+func partialNotice(name string, timeout time.Duration) string {
+	return fmt.Sprintf("\n\n[%s%s timed out after %s; the output above is partial]", subAgentToolPrefix, name, timeout)
+}
+
+// parseSubAgentCommand recognises "subagent:<name> <instruction>".
+func (a *Agent) parseSubAgentCommand(userInput string) (sa SubAgent, instruction string, ok bool, err error) {
+	trimmed := strings.TrimSpace(userInput)
+	if !strings.HasPrefix(strings.ToLower(trimmed), subAgentToolPrefix) {
+		return nil, "", false, nil
+	}
+	payload := strings.TrimSpace(trimmed[len(subAgentToolPrefix):])
+	if payload == "" {
+		return nil, "", true, errors.New("subagent name is missing")
+	}
+	name, args := splitCommand(payload)
+	sa, found := a.lookupSubAgent(name)
+	if !found {
+		return nil, "", true, fmt.Errorf("unknown subagent: %s", name)
+	}
+	return sa, args, true, nil
+}
+
+// streamSubAgentCommand serves a sub-agent command for GenerateStream,
+// forwarding output as it arrives.
+func (a *Agent) streamSubAgentCommand(ctx context.Context, sessionID string, sa SubAgent, instruction string) <-chan models.StreamChunk {
+	out := make(chan models.StreamChunk)
+	go func() {
+		defer close(out)
+		send := func(chunk models.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		res, err := a.runSubAgent(ctx, sessionID, sa, instruction, func(delta string) {
+			send(models.StreamChunk{Delta: delta})
+		})
+		if err != nil {
+			send(models.StreamChunk{Err: err, Done: true})
+			return
+		}
+		final := models.StreamChunk{FullText: res.Output, Done: true}
+		meta := map[string]string{"subagent": sa.Name()}
+		if res.Cached {
+			meta["cached"] = "true"
+		}
+		if res.Partial {
+			notice := partialNotice(sa.Name(), a.SubAgentTimeout)
+			final.Delta = notice
+			final.FullText += notice
+			meta["partial"] = "true"
+		}
+		a.storeMemory(ctx, sessionID, "subagent", final.FullText, meta)
+		send(final)
+	}()
+	return out
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// stallingSubAgent streams one chunk and then waits for cancellation.
+type stallingSubAgent struct{}
+
+func (stallingSubAgent) Name() string        { return "researcher" }
+func (stallingSubAgent) Description() string { return "slow research" }
+func (stallingSubAgent) Run(ctx context.Context, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+func (stallingSubAgent) RunStream(ctx context.Context, _ string) (<-chan models.StreamChunk, error) {
+	ch := make(chan models.StreamChunk)
+	go func() {
+		defer close(ch)
+		select {
+		case ch <- models.StreamChunk{Delta: "found two sources"}:
+		case <-ctx.Done():
+			return
+		}
+		<-ctx.Done()
+		ch <- models.StreamChunk{Err: ctx.Err(), Done: true}
+	}()
+	return ch, nil
+}
+
+// blockingSubAgent never produces output.
+type blockingSubAgent struct{}
+
+func (blockingSubAgent) Name() string        { return "blocker" }
+func (blockingSubAgent) Description() string { return "never answers" }
+func (blockingSubAgent) Run(ctx context.Context, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func newSubAgentTestAgent(t *testing.T, subs ...SubAgent) *Agent {
+	t.Helper()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 8).WithEmbedder(memory.DummyEmbedder{})
+	a, err := New(Options{Model: &stubModel{response: "ok"}, Memory: mem, SubAgents: subs, SubAgentTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestSubAgentTimeoutSurfacesPartialOutput(t *testing.T) {
+	a := newSubAgentTestAgent(t, stallingSubAgent{}, blockingSubAgent{})
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	ctx := ContextWithToolEvents(context.Background(), func(ev ToolEvent) {
+		mu.Lock()
+		events = append(events, fmt.Sprintf("%s:%s", ev.Type, ev.Tool))
+		mu.Unlock()
+	})
+
+	out, err := a.Generate(ctx, "s1", "subagent:researcher compare vendors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := fmt.Sprint(out)
+	if !strings.HasPrefix(text, "found two sources") || !strings.Contains(text, "output above is partial") {
+		t.Fatalf("output = %q", text)
+	}
+	mu.Lock()
+	got := strings.Join(events, ",")
+	mu.Unlock()
+	if got != "start:subagent:researcher,chunk:subagent:researcher,error:subagent:researcher" {
+		t.Fatalf("events = %s", got)
+	}
+
+	if _, err := a.Generate(context.Background(), "s1", "subagent:blocker anything"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("a timed-out sub-agent with no output should fail, got %v", err)
+	}
+}
+
+func TestGenerateStreamForwardsSubAgentChunks(t *testing.T) {
+	a := newSubAgentTestAgent(t, stallingSubAgent{})
+	stream, err := a.GenerateStream(context.Background(), "s1", "subagent:researcher compare vendors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []models.StreamChunk
+	for c := range stream {
+		chunks = append(chunks, c)
+	}
+	if len(chunks) != 2 || chunks[0].Delta != "found two sources" || chunks[0].Done {
+		t.Fatalf("first chunk should carry partial output before completion: %+v", chunks)
+	}
+	last := chunks[len(chunks)-1]
+	if !last.Done || last.Err != nil || !strings.HasPrefix(last.FullText, "found two sources") || !strings.Contains(last.Delta, "partial") {
+		t.Fatalf("final chunk = %+v", last)
+	}
+}
+
+// countingSubAgent answers every instruction and counts its runs.
+type countingSubAgent struct {
+	mu   sync.Mutex
+	runs int
+}
+
+func (*countingSubAgent) Name() string        { return "analyst" }
+func (*countingSubAgent) Description() string { return "answers questions" }
+func (c *countingSubAgent) Run(_ context.Context, input string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs++
+	return fmt.Sprintf("answer %d to %s", c.runs, input), nil
+}
+
+func TestSubAgentResultsAreCachedPerSession(t *testing.T) {
+	sa := &countingSubAgent{}
+	a := newSubAgentTestAgent(t, sa)
+	a.SubAgentCacheTTL = time.Minute
+
+	first, err := a.Generate(context.Background(), "s1", "subagent:analyst What is the Q3 revenue?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := a.Generate(context.Background(), "s1", "subagent:analyst   what is the q3  revenue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first || sa.runs != 1 {
+		t.Fatalf("near-identical instruction should hit the cache: %q vs %q after %d runs", first, again, sa.runs)
+	}
+
+	if _, err := a.Generate(context.Background(), "s2", "subagent:analyst What is the Q3 revenue?"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Generate(context.Background(), "s1", "subagent:analyst What is the Q4 revenue?"); err != nil {
+		t.Fatal(err)
+	}
+	if sa.runs != 3 {
+		t.Fatalf("other sessions and instructions must not share entries, runs = %d", sa.runs)
+	}
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+	"github.com/Protocol-Lattice/go-agent/src/models/middleware"
+)
+
+const (
+	defaultSummaryInputTokens = 2000
+	defaultSummaryTokens      = 120
+)
+
+// LLMSummarizer asks a model to write the cluster summaries attached to
+// retrieved memories, in place of the engine's HeuristicSummarizer, which
+// only concatenates them. Install it with engine.WithSummarizer or the adk
+// WithSummarizer option. The clusters of one retrieval are packed into as
+// few calls as MaxInputTokens allows. A cluster the model leaves out gets
+// the heuristic summary. The zero value needs only Model.
+type LLMSummarizer struct {
+	Model models.Agent
+	// MaxInputTokens caps the memory text sent in one call; zero means
+	// 2000. A cluster larger than the cap is cut to fit.
+	MaxInputTokens int
+	// MaxSummaryTokens caps each summary; zero means 120.
+	MaxSummaryTokens int
+	// CostPerToken prices the estimated prompt and reply tokens in Usage.
+	CostPerToken float64
+
+	mu    sync.Mutex
+	usage SummarizerUsage
+}
+
+// SummarizerUsage is what an LLMSummarizer has spent. Tokens are estimated
+// from the prompt and reply text.
+type SummarizerUsage struct {
+	Calls    int     `json:"calls"`
+	Clusters int     `json:"clusters"`
+	Tokens   int64   `json:"tokens"`
+	Cost     float64 `json:"cost"`
+}
+
+var _ memory.BatchSummarizer = (*LLMSummarizer)(nil)
+
+const summarizerPrompt = `Write a short abstract of each numbered group of notes below. An abstract states what the notes say in at most %d words, in plain prose; do not list the notes or add facts.
+Reply with one line per group, formatted "[n] abstract".
+
+%s`
+
+var summaryLineRe = regexp.MustCompile(`^\[(\d+)\]\s*(.+)$`)
+
+// Summarize implements memory.Summarizer.
+func (s *LLMSummarizer) Summarize(ctx context.Context, cluster []memory.MemoryRecord) (string, error) {
+	out, err := s.SummarizeBatch(ctx, [][]memory.MemoryRecord{cluster})
+	if err != nil {
+		return "", err
+	}
+	return out[0], nil
+}
+
+// SummarizeBatch implements memory.BatchSummarizer.
+func (s *LLMSummarizer) SummarizeBatch(ctx context.Context, clusters [][]memory.MemoryRecord) ([]string, error) {
+	if s.Model == nil {
+		return nil, errors.New("summarizer has no model")
+	}
+	inputCap := s.MaxInputTokens
+	if inputCap <= 0 {
+		inputCap = defaultSummaryInputTokens
+	}
+	summaryCap := s.MaxSummaryTokens
+	if summaryCap <= 0 {
+		summaryCap = defaultSummaryTokens
+	}
+
+	out := make([]string, len(clusters))
+	var batch []int
+	var body strings.Builder
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		replies, err := s.call(ctx, body.String(), summaryCap, len(batch))
+		if err != nil {
+			return err
+		}
+		for n, c := range batch {
+			if reply := replies[n+1]; reply != "" {
+				out[c] = truncate(reply, summaryCap*4)
+			} else {
+				out[c], _ = memory.HeuristicSummarizer{}.Summarize(ctx, clusters[c])
+			}
+		}
+		batch = batch[:0]
+		body.Reset()
+		return nil
+	}
+	for c, cluster := range clusters {
+		if len(cluster) == 0 {
+			continue
+		}
+		block := renderSummaryCluster(cluster, inputCap*4)
+		if len(batch) > 0 && middleware.ApproximateTokenCount(body.String()+block) > int64(inputCap) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		batch = append(batch, c)
+		fmt.Fprintf(&body, "[%d]\n%s\n", len(batch), block)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Usage returns what the summarizer has spent so far.
+func (s *LLMSummarizer) Usage() SummarizerUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// call sends one batch of groups and returns the abstracts by group number.
+func (s *LLMSummarizer) call(ctx context.Context, groups string, summaryCap, clusters int) (map[int]string, error) {
+	prompt := fmt.Sprintf(summarizerPrompt, max(1, summaryCap*3/4), groups)
+	raw, err := s.Model.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("summarize: %w", err)
+	}
+	reply := fmt.Sprint(raw)
+	tokens := middleware.ApproximateTokenCount(prompt) + middleware.ApproximateTokenCount(reply)
+	s.mu.Lock()
+	s.usage.Calls++
+	s.usage.Clusters += clusters
+	s.usage.Tokens += tokens
+	s.usage.Cost += float64(tokens) * s.CostPerToken
+	s.mu.Unlock()
+
+	out := map[int]string{}
+	for _, line := range strings.Split(reply, "\n") {
+		m := summaryLineRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			out[n] = strings.TrimSpace(m[2])
+		}
+	}
+	// A one-group reply without the numbering is still the abstract.
+	if clusters == 1 && out[1] == "" {
+		out[1] = strings.TrimSpace(reply)
+	}
+	return out, nil
+}
+
+func renderSummaryCluster(cluster []memory.MemoryRecord, maxChars int) string {
+	var b strings.Builder
+	for _, rec := range cluster {
+		content := strings.Join(strings.Fields(sanitizeInput(rec.Content)), " ")
+		if content == "" {
+			continue
+		}
+		b.WriteString("- ")
+		b.WriteString(content)
+		b.WriteByte('\n')
+	}
+	return truncate(b.String(), maxChars)
+}
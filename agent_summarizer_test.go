@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestLLMSummarizerBatchesClustersAndAccountsCost(t *testing.T) {
+	ctx := context.Background()
+	model := &dynamicStubModel{responses: map[string]string{"abstract": "[1] The deploy freeze starts Friday."}}
+	s := &LLMSummarizer{Model: model, CostPerToken: 0.001}
+	clusters := [][]memory.MemoryRecord{
+		{{Content: "Deploy freeze starts Friday"}, {Content: "No deploys after Friday noon"}},
+		{{Content: "Lunch is at one"}},
+	}
+
+	out, err := s.SummarizeBatch(ctx, clusters)
+	if err != nil {
+		t.Fatalf("SummarizeBatch: %v", err)
+	}
+	if out[0] != "The deploy freeze starts Friday." {
+		t.Fatalf("summary[0] = %q", out[0])
+	}
+	if out[1] != "Lunch is at one" {
+		t.Fatalf("summary[1] = %q, want the heuristic fallback", out[1])
+	}
+	if !strings.Contains(model.lastPrompt, "[2]\n- Lunch is at one") {
+		t.Fatalf("prompt does not carry both groups:\n%s", model.lastPrompt)
+	}
+	usage := s.Usage()
+	if usage.Calls != 1 || usage.Clusters != 2 || usage.Tokens == 0 || usage.Cost != float64(usage.Tokens)*0.001 {
+		t.Fatalf("usage = %+v", usage)
+	}
+
+	s.MaxInputTokens = 5
+	if _, err := s.SummarizeBatch(ctx, clusters); err != nil {
+		t.Fatalf("SummarizeBatch: %v", err)
+	}
+	if got := s.Usage().Calls; got != 3 {
+		t.Fatalf("calls = %d, want one per cluster once they no longer fit together", got)
+	}
+}
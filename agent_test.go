@@ -558,7 +558,7 @@ func TestAgentSharesMemoryAcrossSpaces(t *testing.T) {
 		t.Fatalf("beta agent: %v", err)
 	}
 
-	alphaAgent.storeMemory("agent:alpha", "assistant", "Swarm update ready for review", nil)
+	alphaAgent.storeMemory(context.Background(), "agent:alpha", "assistant", "Swarm update ready for review", nil)
 
 	records, err := betaShared.Retrieve(ctx, "swarm update", 5)
 	if err != nil {
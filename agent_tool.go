@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Protocol-Lattice/go-agent/src/models"
 	utcp "github.com/universal-tool-calling-protocol/go-utcp"
 	"github.com/universal-tool-calling-protocol/go-utcp/src/providers/base"
 	"github.com/universal-tool-calling-protocol/go-utcp/src/providers/cli"
@@ -61,13 +62,18 @@ type AgentToolAdapter struct {
 	agent       *Agent
 	name        string
 	description string
+	config      agentToolConfig
 }
 
 type agentCLITransport struct {
 	inner repository.ClientTransport
 	tools map[string][]tools.Tool
+	// streams holds the streaming handler of each agent tool, by tool name.
+	streams map[string]utcpStreamHandler
 }
 
+type utcpStreamHandler func(ctx context.Context, args map[string]any) (transports.StreamResult, error)
+
 func (t *agentCLITransport) RegisterToolProvider(ctx context.Context, prov base.Provider) ([]tools.Tool, error) {
 	p, ok := prov.(*cli.CliProvider)
 	if !ok {
@@ -127,7 +133,14 @@ func (t *agentCLITransport) CallTool(ctx context.Context, toolName string, args
 
 func (t *agentCLITransport) CallToolStream(ctx context.Context, toolName string, args map[string]any, prov base.Provider) (transports.StreamResult, error) {
 	if p, ok := prov.(*cli.CliProvider); ok {
-		if _, ok := t.tools[p.Name]; ok {
+		if list, ok := t.tools[p.Name]; ok {
+			for _, tool := range list {
+				if tool.Name == toolName || strings.HasSuffix(tool.Name, "."+toolName) {
+					if stream := t.streams[tool.Name]; stream != nil {
+						return stream(ctx, args)
+					}
+				}
+			}
 			return nil, fmt.Errorf("streaming not supported for tool %s (provider %s)", toolName, p.Name)
 		}
 	}
@@ -137,12 +150,15 @@ func (t *agentCLITransport) CallToolStream(ctx context.Context, toolName string,
 	return nil, fmt.Errorf("unsupported provider type %T", prov)
 }
 
-// NewAgentTool creates a new tool that wraps an Agent.
-func NewAgentTool(name, description string, agent *Agent) Tool {
+// NewAgentTool creates a new tool that wraps an Agent. Each calling session
+// gets its own sub-session; see WithWorkflowSession for sharing one across a
+// workflow.
+func NewAgentTool(name, description string, agent *Agent, opts ...AgentToolOption) Tool {
 	return &AgentToolAdapter{
 		agent:       agent,
 		name:        name,
 		description: description,
+		config:      newAgentToolConfig(opts),
 	}
 }
 
@@ -169,10 +185,7 @@ func (t *AgentToolAdapter) Invoke(ctx context.Context, req ToolRequest) (ToolRes
 		return ToolResponse{}, fmt.Errorf("missing or invalid 'instruction' argument")
 	}
 
-	// Create a sub-session ID to keep context separate but related
-	subSessionID := fmt.Sprintf("%s.sub.%s", req.SessionID, t.name)
-
-	result, err := t.agent.Generate(ctx, subSessionID, instruction)
+	result, err := t.agent.Generate(ctx, t.subSession(ctx, req.SessionID), instruction)
 	if err != nil {
 		return ToolResponse{}, err
 	}
@@ -182,16 +195,110 @@ func (t *AgentToolAdapter) Invoke(ctx context.Context, req ToolRequest) (ToolRes
 	}, nil
 }
 
+// InvokeStream runs the wrapped agent with GenerateStream, so callers see
+// its answer as it is produced.
+func (t *AgentToolAdapter) InvokeStream(ctx context.Context, req ToolRequest) (<-chan models.StreamChunk, error) {
+	instruction, ok := req.Arguments["instruction"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing or invalid 'instruction' argument")
+	}
+	return t.agent.GenerateStream(ctx, t.subSession(ctx, req.SessionID), instruction)
+}
+
+// subSession keeps the nested agent's context separate from, but related
+// to, the calling session.
+func (t *AgentToolAdapter) subSession(ctx context.Context, parent string) string {
+	return t.config.nestedSession(ctx, t.name, parent, fmt.Sprintf("%s.sub.%s", parent, t.name))
+}
+
 // AsTool returns a Tool representation of the Agent.
-func (a *Agent) AsTool(name, description string) Tool {
-	return NewAgentTool(name, description, a)
+func (a *Agent) AsTool(name, description string, opts ...AgentToolOption) Tool {
+	return NewAgentTool(name, description, a, opts...)
+}
+
+// agentToolCall reads an AsUTCPTool call. Scope fields sent by remote
+// callers are merged into ctx, and the session is session_id when given,
+// else derived from the caller's scope, else defaultSession.
+func agentToolCall(ctx context.Context, inputs map[string]any, name, defaultSession string, cfg agentToolConfig) (context.Context, string, string, error) {
+	instruction, ok := inputs["instruction"].(string)
+	if !ok || strings.TrimSpace(instruction) == "" {
+		return nil, "", "", fmt.Errorf("missing or invalid 'instruction'")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	scope, _ := CallScopeFromContext(ctx)
+	merged := scope
+	for key, field := range map[string]*string{
+		"parent_session_id": &merged.ParentSession,
+		"trace_id":          &merged.TraceID,
+		"workflow_id":       &merged.Workflow,
+	} {
+		if v, _ := inputs[key].(string); strings.TrimSpace(v) != "" {
+			*field = strings.TrimSpace(v)
+		}
+	}
+	if merged != scope {
+		ctx = ContextWithCallScope(ctx, merged)
+	}
+
+	sessionID, _ := inputs["session_id"].(string)
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		sessionID = cfg.nestedSession(ctx, name, "", defaultSession)
+	}
+	return ctx, sessionID, instruction, nil
+}
+
+// utcpStream adapts GenerateStream to a UTCP stream of text deltas. Closing
+// the stream cancels the run.
+func (a *Agent) utcpStream(name, defaultSession string, cfg agentToolConfig) utcpStreamHandler {
+	return func(ctx context.Context, args map[string]any) (transports.StreamResult, error) {
+		ctx, sessionID, instruction, err := agentToolCall(ctx, args, name, defaultSession, cfg)
+		if err != nil {
+			return nil, err
+		}
+		runCtx, cancel := context.WithCancel(ctx)
+		chunks, err := a.GenerateStream(runCtx, sessionID, instruction)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		out := make(chan any)
+		go func() {
+			defer close(out)
+			for chunk := range chunks {
+				var item any
+				switch {
+				case chunk.Err != nil:
+					item = chunk.Err
+				case chunk.Delta != "":
+					item = chunk.Delta
+				default:
+					continue
+				}
+				select {
+				case out <- item:
+				case <-runCtx.Done():
+				}
+			}
+		}()
+		return transports.NewChannelStreamResult(out, func() error {
+			cancel()
+			return nil
+		}), nil
+	}
 }
 
 // AsUTCPTool exposes the agent as a UTCP tool with an in-process handler.
 // The tool accepts:
 // - instruction (required): user query for the agent
-// - session_id (optional): custom session id; defaults to a namespaced value derived from the tool name
-func (a *Agent) AsUTCPTool(name, description string) tools.Tool {
+// - session_id (optional): custom session id; defaults to a sub-session of the
+// calling agent's session, or a namespaced value derived from the tool name
+// - parent_session_id, trace_id, workflow_id (optional): call scope for remote
+// callers; in-process callers propagate it through the context
+func (a *Agent) AsUTCPTool(name, description string, opts ...AgentToolOption) tools.Tool {
+	cfg := newAgentToolConfig(opts)
 	providerName := strings.TrimSpace(name)
 	if parts := strings.Split(name, "."); len(parts) > 1 {
 		providerName = parts[0]
@@ -215,6 +322,18 @@ func (a *Agent) AsUTCPTool(name, description string) tools.Tool {
 					"type":        "string",
 					"description": "Optional session id; defaults to the provider-derived session.",
 				},
+				"parent_session_id": map[string]any{
+					"type":        "string",
+					"description": "Optional session of the calling agent.",
+				},
+				"trace_id": map[string]any{
+					"type":        "string",
+					"description": "Optional trace id shared by the calling request.",
+				},
+				"workflow_id": map[string]any{
+					"type":        "string",
+					"description": "Optional workflow invocation the call belongs to.",
+				},
 			},
 			Required: []string{"instruction"},
 		},
@@ -226,20 +345,9 @@ func (a *Agent) AsUTCPTool(name, description string) tools.Tool {
 			},
 		},
 		Handler: tools.ToolHandler(func(ctx context.Context, inputs map[string]interface{}) (any, error) {
-			rawInstruction, ok := inputs["instruction"].(string)
-			if !ok || strings.TrimSpace(rawInstruction) == "" {
-				return nil, fmt.Errorf("missing or invalid 'instruction'")
-			}
-
-			sessionID, _ := inputs["session_id"].(string)
-			sessionID = strings.TrimSpace(sessionID)
-			if sessionID == "" {
-				sessionID = defaultSession
-			}
-
-			execCtx := ctx
-			if execCtx == nil {
-				execCtx = context.Background()
+			execCtx, sessionID, rawInstruction, err := agentToolCall(ctx, inputs, name, defaultSession, cfg)
+			if err != nil {
+				return nil, err
 			}
 
 			out, err := a.Generate(execCtx, sessionID, rawInstruction)
@@ -254,13 +362,14 @@ func (a *Agent) AsUTCPTool(name, description string) tools.Tool {
 
 // RegisterAsUTCPProvider registers the agent as a UTCP tool on the provided client.
 // It installs a lightweight in-process transport under the "text" provider type
-// to route CallTool invocations directly to the agent's Generate method.
-func (a *Agent) RegisterAsUTCPProvider(ctx context.Context, client utcp.UtcpClientInterface, name, description string) error {
+// to route CallTool invocations directly to the agent's Generate method, and
+// CallToolStream invocations to GenerateStream, one text delta per item.
+func (a *Agent) RegisterAsUTCPProvider(ctx context.Context, client utcp.UtcpClientInterface, name, description string, opts ...AgentToolOption) error {
 	if client == nil {
 		return fmt.Errorf("utcp client is nil")
 	}
 
-	tool := a.AsUTCPTool(name, description)
+	tool := a.AsUTCPTool(name, description, opts...)
 	providerName := strings.TrimSpace(name)
 	if parts := strings.Split(name, "."); len(parts) > 1 {
 		providerName = parts[0]
@@ -290,6 +399,10 @@ func (a *Agent) RegisterAsUTCPProvider(ctx context.Context, client utcp.UtcpClie
 		shim.tools = make(map[string][]tools.Tool)
 	}
 	shim.tools[tp.Name] = []tools.Tool{tool}
+	if shim.streams == nil {
+		shim.streams = make(map[string]utcpStreamHandler)
+	}
+	shim.streams[tool.Name] = a.utcpStream(name, fmt.Sprintf("%s.session", providerName), newAgentToolConfig(opts))
 
 	_, err := client.RegisterToolProvider(ctx, tp)
 	return err
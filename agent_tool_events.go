@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+// ToolEventType identifies a stage of a single tool invocation.
+type ToolEventType string
+
+const (
+	// ToolEventStart is emitted before a tool is invoked.
+	ToolEventStart ToolEventType = "start"
+	// ToolEventChunk is emitted for each chunk of a streaming UTCP tool.
+	ToolEventChunk ToolEventType = "chunk"
+	// ToolEventResult is emitted when a tool returns successfully.
+	ToolEventResult ToolEventType = "result"
+	// ToolEventError is emitted when a tool invocation fails.
+	ToolEventError ToolEventType = "error"
+)
+
+// ToolEvent describes incremental progress of a tool call so servers can
+// surface "running <tool>…" updates before the final answer is ready.
+type ToolEvent struct {
+	Type      ToolEventType  `json:"type"`
+	SessionID string         `json:"session_id"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Result    any            `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Time      time.Time      `json:"time"`
+	Duration  time.Duration  `json:"duration_ns,omitempty"`
+}
+
+// ToolEventHandler receives tool events. Handlers run synchronously on the
+// goroutine executing the tool and should return quickly.
+type ToolEventHandler func(ToolEvent)
+
+type toolEventContextKey struct{}
+
+// ContextWithToolEvents returns a context that reports every tool invocation
+// made by the agent while serving the request to handler.
+func ContextWithToolEvents(ctx context.Context, handler ToolEventHandler) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, toolEventContextKey{}, handler)
+}
+
+// ToolEventsFromContext returns the handler registered by ContextWithToolEvents.
+func ToolEventsFromContext(ctx context.Context) (ToolEventHandler, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	handler, ok := ctx.Value(toolEventContextKey{}).(ToolEventHandler)
+	return handler, ok && handler != nil
+}
+
+func emitToolEvent(ctx context.Context, event ToolEvent) {
+	if handler, ok := ToolEventsFromContext(ctx); ok {
+		handler(event)
+	}
+}
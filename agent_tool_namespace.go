@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+// ToolNamespacePolicy decides how an unqualified tool name such as "echo"
+// resolves when several providers expose it ("alpha.echo", "beta.echo").
+// A name that stays ambiguous is not guessed: the caller gets an
+// AmbiguousToolError listing the qualified names.
+type ToolNamespacePolicy struct {
+	// RequirePrefixAbove, when positive, makes a base name that more than
+	// that many providers expose callable only by its qualified name, even
+	// if a provider is preferred.
+	RequirePrefixAbove int
+	// Prefer ranks providers for settling a shared base name. Preferences
+	// set per session with SetToolProviders are consulted first.
+	Prefer []string
+}
+
+// AmbiguousToolError reports an unqualified tool name that several
+// providers expose and no preference settles.
+type AmbiguousToolError struct {
+	Name string
+	// Candidates are the qualified names, sorted.
+	Candidates []string
+}
+
+func (e *AmbiguousToolError) Error() string {
+	return fmt.Sprintf("tool %q is ambiguous: %s", e.Name, strings.Join(e.Candidates, ", "))
+}
+
+// Prompt asks the user, or the planner, to pick a qualified name. It depends
+// only on the name and candidates, so the same ambiguity always reads the
+// same.
+func (e *AmbiguousToolError) Prompt() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Several providers expose a tool named %q. Call it by its full name:\n", e.Name)
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&sb, "- %s\n", c)
+	}
+	fmt.Fprintf(&sb, "For example: tool: %s {...}", e.Candidates[0])
+	return sb.String()
+}
+
+// SetToolProviders sets the providers sessionID prefers, best first, when an
+// unqualified tool name is shared. Calling it with no providers clears the
+// preference.
+func (a *Agent) SetToolProviders(sessionID string, providers ...string) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	if len(providers) == 0 {
+		delete(a.toolProviders, sessionID)
+		return
+	}
+	if a.toolProviders == nil {
+		a.toolProviders = make(map[string][]string)
+	}
+	a.toolProviders[sessionID] = append([]string(nil), providers...)
+}
+
+func (a *Agent) sessionToolProviders(sessionID string) []string {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	return a.toolProviders[sessionID]
+}
+
+// resolveToolName maps name to the qualified name of one of specs: an exact
+// match first, then the single tool whose base name it is, then the
+// candidate of the most preferred provider. It returns "" when nothing
+// matches.
+func (a *Agent) resolveToolName(specs []tools.Tool, sessionID, name string) (string, *AmbiguousToolError) {
+	nameLower := strings.ToLower(strings.TrimSpace(name))
+	if nameLower == "" {
+		return "", nil
+	}
+	var candidates []string
+	for _, spec := range specs {
+		fullLower := strings.ToLower(spec.Name)
+		if fullLower == nameLower {
+			return spec.Name, nil
+		}
+		if strings.HasSuffix(fullLower, "."+nameLower) {
+			candidates = append(candidates, spec.Name)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", nil
+	case 1:
+		return candidates[0], nil
+	}
+	sort.Strings(candidates)
+	ambiguous := &AmbiguousToolError{Name: strings.TrimSpace(name), Candidates: candidates}
+	if limit := a.ToolNamespaces.RequirePrefixAbove; limit > 0 && len(candidates) > limit {
+		return "", ambiguous
+	}
+	prefer := append(append([]string(nil), a.sessionToolProviders(sessionID)...), a.ToolNamespaces.Prefer...)
+	for _, provider := range prefer {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		for _, c := range candidates {
+			if toolProvider(c) == provider {
+				return c, nil
+			}
+		}
+	}
+	return "", ambiguous
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+func namespacedSpecs() []tools.Tool {
+	return []tools.Tool{
+		{Name: "beta.echo"},
+		{Name: "alpha.echo"},
+		{Name: "gamma.echo"},
+		{Name: "weather.forecast"},
+	}
+}
+
+func TestResolveToolNamePolicies(t *testing.T) {
+	a := &Agent{}
+	specs := namespacedSpecs()
+
+	if got, amb := a.resolveToolName(specs, "s", "forecast"); got != "weather.forecast" || amb != nil {
+		t.Fatalf("unique base name: got %q, %v", got, amb)
+	}
+	if got, amb := a.resolveToolName(specs, "s", "Beta.Echo"); got != "beta.echo" || amb != nil {
+		t.Fatalf("qualified name: got %q, %v", got, amb)
+	}
+	_, amb := a.resolveToolName(specs, "s", "echo")
+	if amb == nil || fmt.Sprint(amb.Candidates) != "[alpha.echo beta.echo gamma.echo]" {
+		t.Fatalf("expected sorted ambiguity, got %+v", amb)
+	}
+
+	a.ToolNamespaces.Prefer = []string{"gamma"}
+	if got, _ := a.resolveToolName(specs, "s", "echo"); got != "gamma.echo" {
+		t.Fatalf("agent preference: got %q", got)
+	}
+	a.SetToolProviders("tenant-b", "Beta")
+	if got, _ := a.resolveToolName(specs, "tenant-b", "echo"); got != "beta.echo" {
+		t.Fatalf("session preference should win: got %q", got)
+	}
+	if got, _ := a.resolveToolName(specs, "other", "echo"); got != "gamma.echo" {
+		t.Fatalf("other sessions keep the agent preference: got %q", got)
+	}
+
+	a.ToolNamespaces.RequirePrefixAbove = 2
+	if got, amb := a.resolveToolName(specs, "tenant-b", "echo"); got != "" || amb == nil {
+		t.Fatalf("three providers above the limit must require a prefix: got %q", got)
+	}
+	a.SetToolProviders("tenant-b")
+	if len(a.sessionToolProviders("tenant-b")) != 0 {
+		t.Fatal("preference not cleared")
+	}
+}
+
+func TestDirectAmbiguousToolCallAsksToDisambiguate(t *testing.T) {
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	a, err := New(Options{Model: &stubModel{response: "model"}, Memory: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.toolSpecsCache = namespacedSpecs()
+
+	out, err := a.Generate(context.Background(), "s", `tool: echo {"text":"hi"}`)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	reply := fmt.Sprint(out)
+	if !strings.Contains(reply, "- alpha.echo\n- beta.echo\n- gamma.echo") || strings.Contains(reply, "model |") {
+		t.Fatalf("expected a disambiguation prompt, got %q", reply)
+	}
+	again, _ := a.Generate(context.Background(), "s", `tool: echo {"text":"hi"}`)
+	if fmt.Sprint(again) != reply {
+		t.Fatal("disambiguation prompt is not deterministic")
+	}
+}
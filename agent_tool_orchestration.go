@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Protocol-Lattice/go-agent/src/flags"
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
 	"github.com/alpkeskin/gotoon"
@@ -20,6 +21,29 @@ const (
 	defaultToolObservationMaxBytes = 4000
 )
 
+// Fixed sections of the tool-planning prompts.
+const (
+	toolLoopRules = `1. If another tool is needed, set "use_tool": true.
+2. If the task is complete, set "use_tool": false and provide "final_answer".
+3. Use only exact tool names from AVAILABLE UTCP TOOLS.
+4. Do not stop after listing files when the user asked to create, modify, refactor, test, build, or add a feature.
+5. For project refactors, inspect relevant files before writing.
+6. Use filesystem.write for file changes.
+7. Use shell.run only for safe validation commands like gofmt, go test, or go build.
+8. For CodeMode, use codemode.run_code only when CodeMode is clearly the best tool.
+9. Return ONLY JSON.
+10. For file-backed requests, do not create or edit paths that appear only as illustrative examples; prefer attached existing paths.`
+	toolLoopShape = `{
+  "use_tool": true|false,
+  "tool_name": "provider.tool or empty",
+  "arguments": {},
+  "final_answer": "summary when done",
+  "reason": "short reason"
+}`
+	nativeToolLoopObjective = `Continue until the user request is complete. Call a tool when needed; when no
+more tools are needed, answer the user directly. Use only the provided tools.`
+)
+
 type ToolChoice struct {
 	UseTool     bool           `json:"use_tool"`
 	ToolName    string         `json:"tool_name"`
@@ -116,6 +140,10 @@ func (a *Agent) toolOrchestrator(
 	records []memory.MemoryRecord,
 	files ...models.File,
 ) (bool, string, error) {
+	strategy := flags.String(ctx, a.Flags, flags.ToolCalling, flags.ToolCallingNative)
+	if strategy == flags.ToolCallingOff {
+		return false, "", nil
+	}
 	lowerInput := strings.ToLower(strings.TrimSpace(userInput))
 	if !a.likelyNeedsToolCall(lowerInput) {
 		return false, "", nil
@@ -125,10 +153,11 @@ func (a *Agent) toolOrchestrator(
 	if a.CodeMode != nil {
 		toolList = appendCodeModeToolSpec(toolList)
 	}
+	toolList = a.readOnlyTools(toolList)
 	if len(toolList) == 0 {
 		return false, "", nil
 	}
-	if native, ok := a.model.(models.ToolCallingAgent); ok && len(files) == 0 {
+	if native, ok := a.modelFor(ctx).(models.ToolCallingAgent); ok && len(files) == 0 && strategy != flags.ToolCallingJSON {
 		handled, output, err := a.toolOrchestratorNative(ctx, sessionID, userInput, records, toolList, native)
 		if !errors.Is(err, models.ErrToolCallingUnsupported) {
 			return handled, output, err
@@ -140,6 +169,7 @@ func (a *Agent) toolOrchestrator(
 	fileDesc := a.buildAttachmentPrompt("Files available for this turn", files)
 	workspaceRules := fileBackedWorkspaceRules(files)
 	maxSteps := configuredToolLoopMaxSteps()
+	adapter := a.promptAdapter(ctx)
 
 	var (
 		observations      []string
@@ -147,71 +177,37 @@ func (a *Agent) toolOrchestrator(
 		lastToolCallValue string
 	)
 	for step := 1; step <= maxSteps; step++ {
-		choicePrompt := fmt.Sprintf(`
-You are an agentic UTCP tool execution loop.
-
-USER REQUEST:
-%q
-
-CONVERSATION MEMORY:
-%s
-
-FILES:
-%s
-
-WORKSPACE FILE SELECTION:
-%s
-
-AVAILABLE UTCP TOOLS:
-%s
-
-PREVIOUS TOOL OBSERVATIONS:
-%s
-
-OBJECTIVE:
-Continue working until the user request is complete.
-
-RULES:
-1. If another tool is needed, set "use_tool": true.
-2. If the task is complete, set "use_tool": false and provide "final_answer".
-3. Use only exact tool names from AVAILABLE UTCP TOOLS.
-4. Do not stop after listing files when the user asked to create, modify, refactor, test, build, or add a feature.
-5. For project refactors, inspect relevant files before writing.
-6. Use filesystem.write for file changes.
-7. Use shell.run only for safe validation commands like gofmt, go test, or go build.
-8. For CodeMode, use codemode.run_code only when CodeMode is clearly the best tool.
-9. Return ONLY JSON.
-10. For file-backed requests, do not create or edit paths that appear only as illustrative examples; prefer attached existing paths.
-
-JSON shape:
-{
-  "use_tool": true|false,
-  "tool_name": "provider.tool or empty",
-  "arguments": {},
-  "final_answer": "summary when done",
-  "reason": "short reason"
-}
-`,
-			userInput,
-			memoryDesc,
-			fileDesc,
-			workspaceRules,
-			toolDesc,
-			strings.Join(observations, "\n\n"),
-		)
+		choicePrompt := models.FormatPrompt(adapter, models.Prompt{
+			System: "You are an agentic UTCP tool execution loop.",
+			Sections: []models.PromptSection{
+				{Title: "USER REQUEST", Body: strconv.Quote(userInput)},
+				{Title: "CONVERSATION MEMORY", Body: memoryDesc},
+				{Title: "FILES", Body: fileDesc},
+				{Title: "WORKSPACE FILE SELECTION", Body: workspaceRules},
+				{Title: "AVAILABLE UTCP TOOLS", Body: toolDesc},
+				{Title: "PREVIOUS TOOL OBSERVATIONS", Body: strings.Join(observations, "\n\n")},
+				{Title: "OBJECTIVE", Body: "Continue working until the user request is complete."},
+				{Title: "RULES", Body: toolLoopRules},
+				{Title: "JSON shape", Body: toolLoopShape},
+			},
+		})
 
+		if err := chargeTokens(ctx, choicePrompt); err != nil {
+			return true, "", err
+		}
 		var (
 			raw any
 			err error
 		)
 		if len(files) > 0 {
-			raw, err = a.model.GenerateWithFiles(ctx, choicePrompt, files)
+			raw, err = a.modelFor(ctx).GenerateWithFiles(ctx, choicePrompt, files)
 		} else {
-			raw, err = a.model.Generate(ctx, choicePrompt)
+			raw, err = a.modelFor(ctx).Generate(ctx, choicePrompt)
 		}
 		if err != nil {
 			return false, "", err
 		}
+		_ = chargeTokens(ctx, fmt.Sprint(raw))
 
 		jsonStr := extractJSON(fmt.Sprint(raw))
 		if jsonStr == "" {
@@ -219,7 +215,7 @@ JSON shape:
 				return false, "", nil
 			}
 			final := fmt.Sprintf("Stopped because the tool planner did not return valid JSON after %d tool step(s). Last observation:\n%s", len(observations), lastToolObservation(observations))
-			a.storeMemory(sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
+			a.storeMemory(ctx, sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
 			return true, final, nil
 		}
 
@@ -229,7 +225,7 @@ JSON shape:
 				return false, "", nil
 			}
 			final := fmt.Sprintf("Stopped because the tool planner returned invalid JSON after %d tool step(s). Last observation:\n%s", len(observations), lastToolObservation(observations))
-			a.storeMemory(sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
+			a.storeMemory(ctx, sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
 			return true, final, nil
 		}
 
@@ -241,7 +237,7 @@ JSON shape:
 				}
 				final = fmt.Sprintf("Done. Last observation:\n%s", lastToolObservation(observations))
 			}
-			a.storeMemory(sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
+			a.storeMemory(ctx, sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
 			return true, final, nil
 		}
 
@@ -250,7 +246,17 @@ JSON shape:
 			return true, "", fmt.Errorf("tool loop selected empty tool name")
 		}
 		if !toolSpecExists(toolList, toolName) {
-			return true, "", fmt.Errorf("UTCP tool unknown: %s", toolName)
+			// Planners often drop the provider prefix. Resolve the base
+			// name, and on a tie show the planner the qualified names.
+			resolved, ambiguous := a.resolveToolName(toolList, sessionID, toolName)
+			if ambiguous != nil {
+				observations = append(observations, fmt.Sprintf("[step %d] %s", step, ambiguous.Prompt()))
+				continue
+			}
+			if resolved == "" {
+				return true, "", fmt.Errorf("UTCP tool unknown: %s", toolName)
+			}
+			toolName = resolved
 		}
 		if tc.Arguments == nil {
 			tc.Arguments = map[string]any{}
@@ -266,7 +272,7 @@ JSON shape:
 
 		result, err := a.executeTool(ctx, sessionID, toolName, tc.Arguments)
 		if err != nil {
-			a.storeMemory(sessionID, "assistant",
+			a.storeMemory(ctx, sessionID, "assistant",
 				fmt.Sprintf("tool %s error: %v", toolName, err),
 				map[string]string{
 					"tool":   toolName,
@@ -282,7 +288,7 @@ JSON shape:
 		observations = append(observations, formatToolObservation(step, toolName, tc.Arguments, rawOut))
 
 		toonBytes, _ := gotoon.Encode(rawOut)
-		a.storeMemory(sessionID, "assistant",
+		a.storeMemory(ctx, sessionID, "assistant",
 			fmt.Sprintf("%s\n\n.toon:\n%s", rawOut, string(toonBytes)),
 			map[string]string{
 				"tool":   toolName,
@@ -296,7 +302,7 @@ JSON shape:
 		maxSteps,
 		lastToolObservation(observations),
 	)
-	a.storeMemory(sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
+	a.storeMemory(ctx, sessionID, "assistant", final, map[string]string{"source": "tool_loop"})
 	return true, final, nil
 }
 
@@ -315,6 +321,7 @@ func (a *Agent) toolOrchestratorNative(
 
 	memoryDesc := a.renderMemory(records)
 	maxSteps := configuredToolLoopMaxSteps()
+	adapter := a.promptAdapter(ctx)
 	var (
 		observations      []string
 		lastToolCallKey   string
@@ -322,27 +329,24 @@ func (a *Agent) toolOrchestratorNative(
 	)
 
 	for step := 1; step <= maxSteps; step++ {
-		prompt := fmt.Sprintf(`
-You are an agentic tool execution loop using native tool calls.
-
-USER REQUEST:
-%q
-
-CONVERSATION MEMORY:
-%s
-
-PREVIOUS TOOL OBSERVATIONS:
-%s
-
-OBJECTIVE:
-Continue until the user request is complete. Call a tool when needed; when no
-more tools are needed, answer the user directly. Use only the provided tools.
-`, userInput, memoryDesc, strings.Join(observations, "\n\n"))
+		prompt := models.FormatPrompt(adapter, models.Prompt{
+			System: "You are an agentic tool execution loop using native tool calls.",
+			Sections: []models.PromptSection{
+				{Title: "USER REQUEST", Body: strconv.Quote(userInput)},
+				{Title: "CONVERSATION MEMORY", Body: memoryDesc},
+				{Title: "PREVIOUS TOOL OBSERVATIONS", Body: strings.Join(observations, "\n\n")},
+				{Title: "OBJECTIVE", Body: nativeToolLoopObjective},
+			},
+		})
 
+		if err := chargeTokens(ctx, prompt); err != nil {
+			return true, "", err
+		}
 		response, err := native.GenerateWithTools(ctx, prompt, definitions)
 		if err != nil {
 			return false, "", err
 		}
+		_ = chargeTokens(ctx, response.Content)
 		if len(response.ToolCalls) == 0 {
 			final := strings.TrimSpace(response.Content)
 			if final == "" {
@@ -351,7 +355,7 @@ more tools are needed, answer the user directly. Use only the provided tools.
 				}
 				final = fmt.Sprintf("Done. Last observation:\n%s", lastToolObservation(observations))
 			}
-			a.storeMemory(sessionID, "assistant", final, map[string]string{"source": "native_tool_loop"})
+			a.storeMemory(ctx, sessionID, "assistant", final, map[string]string{"source": "native_tool_loop"})
 			return true, final, nil
 		}
 
@@ -374,7 +378,7 @@ more tools are needed, answer the user directly. Use only the provided tools.
 
 			result, err := a.executeTool(ctx, sessionID, toolName, call.Arguments)
 			if err != nil {
-				a.storeMemory(sessionID, "assistant",
+				a.storeMemory(ctx, sessionID, "assistant",
 					fmt.Sprintf("tool %s error: %v", toolName, err),
 					map[string]string{"tool": toolName, "source": "native_tool_loop"},
 				)
@@ -386,7 +390,7 @@ more tools are needed, answer the user directly. Use only the provided tools.
 			lastToolCallValue = rawOut
 			observations = append(observations, formatToolObservation(step, toolName, call.Arguments, rawOut))
 			toonBytes, _ := gotoon.Encode(rawOut)
-			a.storeMemory(sessionID, "assistant",
+			a.storeMemory(ctx, sessionID, "assistant",
 				fmt.Sprintf("%s\n\n.toon:\n%s", rawOut, string(toonBytes)),
 				map[string]string{"tool": toolName, "source": "native_tool_loop"},
 			)
@@ -398,7 +402,7 @@ more tools are needed, answer the user directly. Use only the provided tools.
 		maxSteps,
 		lastToolObservation(observations),
 	)
-	a.storeMemory(sessionID, "assistant", final, map[string]string{"source": "native_tool_loop"})
+	a.storeMemory(ctx, sessionID, "assistant", final, map[string]string{"source": "native_tool_loop"})
 	return true, final, nil
 }
 
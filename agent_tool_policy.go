@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+// Retry defaults for idempotent tools.
+const (
+	DefaultToolAttempts   = 3
+	DefaultToolBackoff    = 200 * time.Millisecond
+	DefaultToolMaxBackoff = 5 * time.Second
+)
+
+// ErrTransient marks a tool failure worth retrying, such as a rate limit or
+// an unavailable upstream. Wrap it with fmt.Errorf("%w: ...", ErrTransient).
+var ErrTransient = errors.New("transient tool failure")
+
+// isTransient reports whether err may succeed on a later attempt: errors
+// wrapping ErrTransient, network timeouts, and attempts that ran past the
+// tool's own timeout.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTransient) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// toolPolicy returns the policy for toolName: the local tool's spec, then
+// ToolPolicies, then policy tags on the cached UTCP spec.
+func (a *Agent) toolPolicy(toolName string) ToolPolicy {
+	if _, spec, ok := a.lookupTool(toolName); ok && spec.Policy != (ToolPolicy{}) {
+		return spec.Policy
+	}
+	if p, ok := a.ToolPolicies[toolName]; ok {
+		return p
+	}
+	a.toolMu.RLock()
+	specs := a.toolSpecsCache
+	a.toolMu.RUnlock()
+	for _, t := range specs {
+		if t.Name == toolName {
+			return PolicyFromTags(t.Tags)
+		}
+	}
+	return ToolPolicy{}
+}
+
+// PolicyFromTags reads a ToolPolicy from UTCP tool tags, so remote
+// providers can declare one at registration: "idempotent", "timeout:5s",
+// "attempts:4", "backoff:250ms" and "readonly". Unknown or malformed tags
+// are ignored.
+func PolicyFromTags(tags []string) ToolPolicy {
+	var p ToolPolicy
+	for _, tag := range tags {
+		key, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), ":")
+		switch key {
+		case "idempotent":
+			p.Idempotent = true
+		case "readonly", "read-only", "read_only":
+			p.ReadOnly = true
+		case "timeout":
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				p.Timeout = d
+			}
+		case "attempts":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				p.MaxAttempts = n
+			}
+		case "backoff":
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				p.Backoff = d
+			}
+		}
+	}
+	return p
+}
+
+// Tags renders p as UTCP tool tags understood by PolicyFromTags.
+func (p ToolPolicy) Tags() []string {
+	var tags []string
+	if p.Idempotent {
+		tags = append(tags, "idempotent")
+	}
+	if p.Timeout > 0 {
+		tags = append(tags, "timeout:"+p.Timeout.String())
+	}
+	if p.MaxAttempts > 0 {
+		tags = append(tags, "attempts:"+strconv.Itoa(p.MaxAttempts))
+	}
+	if p.Backoff > 0 {
+		tags = append(tags, "backoff:"+p.Backoff.String())
+	}
+	if p.ReadOnly {
+		tags = append(tags, "readonly")
+	}
+	return tags
+}
+
+// WithPolicy returns a copy of tool tagged with p, for registering UTCP
+// tools whose calls the agent should time out or retry.
+func WithPolicy(tool tools.Tool, p ToolPolicy) tools.Tool {
+	tool.Tags = append(append([]string(nil), tool.Tags...), p.Tags()...)
+	return tool
+}
+
+// invokeWithPolicy runs invokeTool under p. Each attempt gets p.Timeout;
+// idempotent tools are retried with exponential backoff while failures are
+// transient and ctx is live. Streaming calls are never retried since their
+// chunks have already been reported.
+func (a *Agent) invokeWithPolicy(ctx context.Context, sessionID, toolName string, args map[string]any, p ToolPolicy) (any, error) {
+	attempts := 1
+	if p.Idempotent {
+		attempts = p.MaxAttempts
+		if attempts <= 0 {
+			attempts = DefaultToolAttempts
+		}
+	}
+	if a.streamsChunks(ctx, toolName, args) {
+		attempts = 1
+	}
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = DefaultToolBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultToolMaxBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := a.invokeAttempt(ctx, sessionID, toolName, args, p.Timeout)
+		if err == nil || attempt >= attempts || !isTransient(err) || ctx.Err() != nil {
+			if err != nil && attempt > 1 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return result, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// streamsChunks reports whether the call will emit chunk events.
+func (a *Agent) streamsChunks(ctx context.Context, toolName string, args map[string]any) bool {
+	if stream, _ := args["stream"].(bool); stream {
+		return true
+	}
+	tool, _, ok := a.lookupTool(toolName)
+	if !ok {
+		return false
+	}
+	_, streaming := tool.(StreamingTool)
+	return streaming && wantsToolStream(ctx, args)
+}
+
+func (a *Agent) invokeAttempt(ctx context.Context, sessionID, toolName string, args map[string]any, timeout time.Duration) (any, error) {
+	if timeout <= 0 {
+		return a.invokeTool(ctx, sessionID, toolName, args)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := a.invokeTool(attemptCtx, sessionID, toolName, args)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return nil, fmt.Errorf("tool %s timed out after %s: %w", toolName, timeout, context.DeadlineExceeded)
+	}
+	return result, err
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// flakyTool fails with err until it has been called failures times.
+type flakyTool struct {
+	spec     ToolSpec
+	failures int32
+	err      error
+	calls    atomic.Int32
+	block    bool
+}
+
+func (f *flakyTool) Spec() ToolSpec { return f.spec }
+
+func (f *flakyTool) Invoke(ctx context.Context, _ ToolRequest) (ToolResponse, error) {
+	n := f.calls.Add(1)
+	if f.block {
+		<-ctx.Done()
+		return ToolResponse{}, ctx.Err()
+	}
+	if n <= f.failures {
+		return ToolResponse{}, f.err
+	}
+	return ToolResponse{Content: fmt.Sprintf("ok after %d", n)}, nil
+}
+
+func newPolicyAgent(t *testing.T, tool Tool) *Agent {
+	t.Helper()
+	a, err := New(Options{Model: &stubModel{}, Memory: memory.NewSessionMemory(&memory.MemoryBank{}, 0), Tools: []Tool{tool}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func TestToolPolicyRetriesIdempotentTransientFailures(t *testing.T) {
+	tool := &flakyTool{
+		spec:     ToolSpec{Name: "lookup", Policy: ToolPolicy{Idempotent: true, MaxAttempts: 3, Backoff: time.Millisecond}},
+		failures: 2,
+		err:      fmt.Errorf("%w: 503 from upstream", ErrTransient),
+	}
+	a := newPolicyAgent(t, tool)
+
+	result, err := a.executeTool(context.Background(), "s", "lookup", nil)
+	if err != nil || result != "ok after 3" {
+		t.Fatalf("result = %v, %v", result, err)
+	}
+}
+
+func TestToolPolicyNeverRetriesNonIdempotentOrPermanentFailures(t *testing.T) {
+	transient := fmt.Errorf("%w: timeout", ErrTransient)
+	charge := &flakyTool{spec: ToolSpec{Name: "charge"}, failures: 1, err: transient}
+	if _, err := newPolicyAgent(t, charge).executeTool(context.Background(), "s", "charge", nil); !errors.Is(err, ErrTransient) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+	if n := charge.calls.Load(); n != 1 {
+		t.Fatalf("non-idempotent tool called %d times", n)
+	}
+
+	bad := &flakyTool{spec: ToolSpec{Name: "lookup", Policy: ToolPolicy{Idempotent: true, Backoff: time.Millisecond}}, failures: 5, err: errors.New("invalid argument")}
+	if _, err := newPolicyAgent(t, bad).executeTool(context.Background(), "s", "lookup", nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := bad.calls.Load(); n != 1 {
+		t.Fatalf("permanent failure retried: %d calls", n)
+	}
+}
+
+func TestToolPolicyTimeoutIsPerAttempt(t *testing.T) {
+	tool := &flakyTool{spec: ToolSpec{Name: "slow", Policy: ToolPolicy{Timeout: 10 * time.Millisecond, Idempotent: true, MaxAttempts: 2, Backoff: time.Millisecond}}, block: true}
+	_, err := newPolicyAgent(t, tool).executeTool(context.Background(), "s", "slow", nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 10ms") || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPolicyFromTagsRoundTrips(t *testing.T) {
+	p := ToolPolicy{Idempotent: true, Timeout: 5 * time.Second, MaxAttempts: 4, Backoff: 250 * time.Millisecond}
+	tags := append([]string{"search"}, p.Tags()...)
+	if got := PolicyFromTags(tags); got != p {
+		t.Fatalf("PolicyFromTags(%v) = %+v, want %+v", tags, got, p)
+	}
+}
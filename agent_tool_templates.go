@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// ToolResponseData is what a tool response template is rendered with.
+type ToolResponseData struct {
+	Tool      string
+	Arguments map[string]any
+	// Data is the tool output decoded from JSON, or the output itself when
+	// it is not a string. It is nil when a string output is not JSON.
+	Data any
+	// Raw is the output as it would have been shown to the model.
+	Raw string
+}
+
+var toolTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": func(sep string, items []any) string {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	},
+	"trunc": func(n int, s string) string {
+		if len(s) <= n {
+			return s
+		}
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return s[:n] + "…"
+	},
+}
+
+// compileToolTemplates parses Options.ToolTemplates. Templates are strict
+// about missing keys, so a response whose shape changed fails to render
+// and reaches the model raw rather than as a sentence full of "<no value>".
+func compileToolTemplates(sources map[string]string) (map[string]*template.Template, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]*template.Template, len(sources))
+	for name, src := range sources {
+		tmpl, err := template.New(name).Funcs(toolTemplateFuncs).Option("missingkey=error").Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("tool template %s: %w", name, err)
+		}
+		out[name] = tmpl
+	}
+	return out, nil
+}
+
+// renderToolResponse turns a structured tool output into the text the model
+// sees, using the tool's response template. Outputs of tools without a
+// template, and outputs the template cannot render, are returned as is.
+func (a *Agent) renderToolResponse(toolName string, args map[string]any, result any) any {
+	tmpl, ok := a.toolTemplates[toolName]
+	if !ok || result == nil {
+		return result
+	}
+	data := ToolResponseData{Tool: toolName, Arguments: args}
+	if s, isString := result.(string); isString {
+		data.Raw = s
+		var decoded any
+		if json.Unmarshal([]byte(strings.TrimSpace(s)), &decoded) == nil {
+			data.Data = decoded
+		}
+	} else {
+		data.Raw = fmt.Sprint(result)
+		// Round-trip through JSON so templates see the same maps and
+		// slices whether the tool is local or remote.
+		if b, err := json.Marshal(result); err == nil {
+			data.Raw = string(b)
+			_ = json.Unmarshal(b, &data.Data)
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return result
+	}
+	rendered := strings.TrimSpace(buf.String())
+	if rendered == "" {
+		return result
+	}
+	return rendered
+}
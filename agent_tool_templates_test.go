@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+type jsonTool struct {
+	name, content string
+}
+
+func (j jsonTool) Spec() ToolSpec { return ToolSpec{Name: j.name} }
+
+func (j jsonTool) Invoke(context.Context, ToolRequest) (ToolResponse, error) {
+	return ToolResponse{Content: j.content}, nil
+}
+
+func newTemplateAgent(t *testing.T, tool Tool, templates map[string]string) *Agent {
+	t.Helper()
+	a, err := New(Options{
+		Model:         &stubModel{},
+		Memory:        memory.NewSessionMemory(&memory.MemoryBank{}, 0),
+		Tools:         []Tool{tool},
+		ToolTemplates: templates,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func TestToolTemplateRendersStructuredOutput(t *testing.T) {
+	tool := jsonTool{name: "weather", content: `{"city":"Oslo","temp_c":4,"alerts":["wind","ice"]}`}
+	a := newTemplateAgent(t, tool, map[string]string{
+		"weather": `{{.Data.city}} is {{.Data.temp_c}}°C ({{.Arguments.units}}). Alerts: {{join ", " .Data.alerts}}.`,
+	})
+
+	result, err := a.executeTool(context.Background(), "s", "weather", map[string]any{"units": "metric"})
+	if err != nil {
+		t.Fatalf("executeTool: %v", err)
+	}
+	if want := "Oslo is 4°C (metric). Alerts: wind, ice."; result != want {
+		t.Fatalf("result = %q, want %q", result, want)
+	}
+}
+
+func TestToolTemplateFallsBackToRawOutput(t *testing.T) {
+	raw := `{"town":"Oslo"}`
+	a := newTemplateAgent(t, jsonTool{name: "weather", content: raw}, map[string]string{
+		"weather": `{{.Data.city}} is sunny.`,
+	})
+
+	result, err := a.executeTool(context.Background(), "s", "weather", nil)
+	if err != nil || result != raw {
+		t.Fatalf("result = %v, %v; want the raw output", result, err)
+	}
+
+	// Tools without a template are untouched.
+	b := newTemplateAgent(t, jsonTool{name: "other", content: raw}, map[string]string{"weather": "x"})
+	if result, _ := b.executeTool(context.Background(), "s", "other", nil); result != raw {
+		t.Fatalf("untemplated result = %v", result)
+	}
+}
+
+func TestToolTemplateRendersNonStringResults(t *testing.T) {
+	a := newTemplateAgent(t, jsonTool{name: "noop"}, map[string]string{
+		"remote.count": `{{.Tool}} found {{len .Data.items}} items.`,
+	})
+	got := a.renderToolResponse("remote.count", nil, map[string]any{"items": []string{"a", "b"}})
+	if got != "remote.count found 2 items." {
+		t.Fatalf("rendered = %v", got)
+	}
+}
+
+func TestNewRejectsInvalidToolTemplate(t *testing.T) {
+	_, err := New(Options{
+		Model:         &stubModel{},
+		Memory:        memory.NewSessionMemory(&memory.MemoryBank{}, 0),
+		ToolTemplates: map[string]string{"weather": "{{.Data.city"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "tool template weather") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -2,10 +2,12 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
 	utcp "github.com/universal-tool-calling-protocol/go-utcp"
 	"github.com/universal-tool-calling-protocol/go-utcp/src/providers/base"
 )
@@ -201,3 +203,117 @@ func TestAgent_RegisterAsUTCPProvider_CallToolWithCustomSession(t *testing.T) {
 		t.Fatalf("expected response to include 'hi', got %q", resp)
 	}
 }
+
+func TestExecuteToolEmitsToolEvents(t *testing.T) {
+	tool := &stubTool{spec: ToolSpec{Name: "echo", Description: "echo"}}
+	mem := memory.NewSessionMemory(&memory.MemoryBank{}, 0)
+	a, err := New(Options{Model: &stubModel{}, Memory: mem, Tools: []Tool{tool}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var events []ToolEvent
+	ctx := ContextWithToolEvents(context.Background(), func(ev ToolEvent) {
+		events = append(events, ev)
+	})
+
+	if _, err := a.executeTool(ctx, "s1", "echo", map[string]any{"input": "hi"}); err != nil {
+		t.Fatalf("executeTool: %v", err)
+	}
+	if _, err := a.executeTool(ctx, "s1", "missing", nil); err == nil {
+		t.Fatal("expected unknown tool error")
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Type != ToolEventStart || events[0].Tool != "echo" || events[0].SessionID != "s1" {
+		t.Fatalf("unexpected start event: %+v", events[0])
+	}
+	if events[1].Type != ToolEventResult || events[1].Result != "hi" {
+		t.Fatalf("unexpected result event: %+v", events[1])
+	}
+	if events[3].Type != ToolEventError || !strings.Contains(events[3].Error, "unknown tool") {
+		t.Fatalf("unexpected error event: %+v", events[3])
+	}
+}
+
+// wordStreamModel streams its reply one word at a time.
+type wordStreamModel struct{ reply string }
+
+func (m *wordStreamModel) Generate(context.Context, string) (any, error) { return m.reply, nil }
+
+func (m *wordStreamModel) GenerateWithFiles(ctx context.Context, prompt string, _ []models.File) (any, error) {
+	return m.Generate(ctx, prompt)
+}
+
+func (m *wordStreamModel) GenerateStream(context.Context, string) (<-chan models.StreamChunk, error) {
+	words := strings.SplitAfter(m.reply, " ")
+	ch := make(chan models.StreamChunk, len(words)+1)
+	for _, w := range words {
+		ch <- models.StreamChunk{Delta: w}
+	}
+	ch <- models.StreamChunk{Done: true, FullText: m.reply}
+	close(ch)
+	return ch, nil
+}
+
+func TestAgent_RegisterAsUTCPProvider_CallToolStream(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.NewSessionMemory(&memory.MemoryBank{}, 0)
+	nested, _ := New(Options{Model: &wordStreamModel{reply: "streamed nested answer"}, Memory: mem})
+
+	client, err := utcp.NewUTCPClient(ctx, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create utcp client: %v", err)
+	}
+	if err := nested.RegisterAsUTCPProvider(ctx, client, "local.agent", "desc"); err != nil {
+		t.Fatalf("register as utcp provider: %v", err)
+	}
+
+	stream, err := client.CallToolStream(ctx, "local.agent", map[string]any{"instruction": "tell me about streaming"})
+	if err != nil {
+		t.Fatalf("CallToolStream error: %v", err)
+	}
+	defer stream.Close()
+	var chunks []string
+	for {
+		item, err := stream.Next()
+		if err != nil {
+			break
+		}
+		chunks = append(chunks, item.(string))
+	}
+	if len(chunks) != 3 || strings.Join(chunks, "") != "streamed nested answer" {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+}
+
+func TestExecuteToolStreamsAgentTools(t *testing.T) {
+	mem := memory.NewSessionMemory(&memory.MemoryBank{}, 0)
+	nested, _ := New(Options{Model: &wordStreamModel{reply: "one two three"}, Memory: mem})
+	outer, err := New(Options{Model: &stubModel{}, Memory: mem, Tools: []Tool{nested.AsTool("helper", "helps")}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var chunks []string
+	ctx := ContextWithToolEvents(context.Background(), func(ev ToolEvent) {
+		if ev.Type == ToolEventChunk {
+			chunks = append(chunks, fmt.Sprint(ev.Result))
+		}
+	})
+	result, err := outer.executeTool(ctx, "s1", "helper", map[string]any{"instruction": "count to three please"})
+	if err != nil {
+		t.Fatalf("executeTool: %v", err)
+	}
+	if result != "one two three" || len(chunks) != 3 {
+		t.Fatalf("result %q, chunks %q", result, chunks)
+	}
+
+	// Without a listener the tool is invoked once, without streaming.
+	chunks = nil
+	if _, err := outer.executeTool(context.Background(), "s1", "helper", map[string]any{"instruction": "count again"}); err != nil || chunks != nil {
+		t.Fatalf("unexpected streaming: %v %q", err, chunks)
+	}
+}
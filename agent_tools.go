@@ -3,19 +3,27 @@ package agent
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/models"
 	"github.com/universal-tool-calling-protocol/go-utcp/src/plugins/codemode"
 	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
 )
 
 const defaultToolCacheTTL = 30 * time.Second
 
+// Rendered tool prompts are cached per provider; segments are keyed by the
+// signature of their tools, so the TTL only reclaims unused providers.
+const (
+	maxToolPromptSegments = 256
+	toolPromptSegmentTTL  = time.Hour
+)
+
 // userLooksLikeToolCall returns true if the user *likely* meant to call a tool.
 func (a *Agent) userLooksLikeToolCall(s string) bool {
 	s = strings.TrimSpace(strings.ToLower(s))
@@ -52,20 +60,16 @@ func (a *Agent) decideIfToolsNeeded(
 	tools string,
 ) (bool, error) {
 
-	prompt := fmt.Sprintf(`
-Decide if the following user query requires using ANY UTCP tools.
-
-USER QUERY:
-%q
-
-AVAILABLE UTCP TOOLS:
-%s
+	prompt := models.FormatPrompt(a.promptAdapter(ctx), models.Prompt{
+		System: "Decide if the following user query requires using ANY UTCP tools.",
+		Sections: []models.PromptSection{
+			{Title: "USER QUERY", Body: strconv.Quote(query)},
+			{Title: "AVAILABLE UTCP TOOLS", Body: tools},
+			{Title: "Respond ONLY in JSON", Body: `{ "needs": true } or { "needs": false }`},
+		},
+	})
 
-Respond ONLY in JSON:
-{ "needs": true } or { "needs": false }
-`, query, tools)
-
-	raw, err := a.model.Generate(ctx, prompt)
+	raw, err := a.modelFor(ctx).Generate(ctx, prompt)
 	if err != nil {
 		return false, err
 	}
@@ -92,27 +96,17 @@ func (a *Agent) selectTools(
 	tools string,
 ) ([]string, error) {
 
-	prompt := fmt.Sprintf(`
-Select ALL UTCP tools that match the user's intent.
-
-USER QUERY:
-%q
-
-AVAILABLE UTCP TOOLS:
-%s
-
-Respond ONLY in JSON:
-{
-  "tools": ["provider.tool", ...]
-}
-
-Rules:
-- Use ONLY names listed above.
-- NO modifications, NO guessing.
-- If multiple tools apply, include all.
-`, query, tools)
-
-	raw, err := a.model.Generate(ctx, prompt)
+	prompt := models.FormatPrompt(a.promptAdapter(ctx), models.Prompt{
+		System: "Select ALL UTCP tools that match the user's intent.",
+		Sections: []models.PromptSection{
+			{Title: "USER QUERY", Body: strconv.Quote(query)},
+			{Title: "AVAILABLE UTCP TOOLS", Body: tools},
+			{Title: "Respond ONLY in JSON", Body: "{\n  \"tools\": [\"provider.tool\", ...]\n}"},
+			{Title: "Rules", Body: "- Use ONLY names listed above.\n- NO modifications, NO guessing.\n- If multiple tools apply, include all."},
+		},
+	})
+
+	raw, err := a.modelFor(ctx).Generate(ctx, prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +133,50 @@ func (a *Agent) executeTool(
 		args = map[string]any{}
 	}
 
+	if err := a.checkReadOnlyTool(toolName); err != nil {
+		return nil, err
+	}
+	if err := chargeToolCall(ctx, toolName); err != nil {
+		return nil, err
+	}
+	ctx = withToolCallScope(ctx, sessionID)
+	started := time.Now()
+	emitToolEvent(ctx, ToolEvent{
+		Type:      ToolEventStart,
+		SessionID: sessionID,
+		Tool:      toolName,
+		Arguments: args,
+		Time:      started,
+	})
+	result, err := a.invokeWithPolicy(ctx, sessionID, toolName, args, a.toolPolicy(toolName))
+	event := ToolEvent{
+		Type:      ToolEventResult,
+		SessionID: sessionID,
+		Tool:      toolName,
+		Result:    result,
+		Time:      time.Now(),
+		Duration:  time.Since(started),
+	}
+	if err != nil {
+		event.Type = ToolEventError
+		event.Result = nil
+		event.Error = err.Error()
+	}
+	emitToolEvent(ctx, event)
+	if err == nil {
+		recordBudgetStep(ctx, toolName, result)
+		result = a.renderToolResponse(toolName, args, result)
+	}
+	return result, err
+}
+
+// invokeTool routes a call to CodeMode, the local catalog or the UTCP client.
+func (a *Agent) invokeTool(
+	ctx context.Context,
+	sessionID, toolName string,
+	args map[string]any,
+) (any, error) {
+
 	// 0. Built-in CodeMode tool.
 	// ToolSpecs exposes codemode.run_code from a.CodeMode, so execution must
 	// also route it here instead of forwarding it to the UTCP client.
@@ -169,29 +207,14 @@ func (a *Agent) executeTool(
 			}
 		}
 
-		result, err := a.CodeMode.Execute(ctx, codemode.CodeModeArgs{
-			Code:    code,
-			Timeout: timeout,
-		})
-		if err != nil {
-			return nil, err
-		}
-		if strings.TrimSpace(result.Stderr) != "" {
-			return nil, fmt.Errorf("codemode script produced stderr: %s", result.Stderr)
-		}
-
-		if result.Stdout != "" {
-			return map[string]any{
-				"value":  result.Value,
-				"stdout": result.Stdout,
-			}, nil
-		}
-
-		return result.Value, nil
+		return a.runCode(ctx, sessionID, code, timeout)
 	}
 
 	// 1. Locally registered tool.
 	if tool, _, ok := a.lookupTool(toolName); ok {
+		if streaming, ok := tool.(StreamingTool); ok && wantsToolStream(ctx, args) {
+			return streamLocalTool(ctx, streaming, sessionID, toolName, args)
+		}
 		response, err := tool.Invoke(ctx, ToolRequest{
 			SessionID: sessionID,
 			Arguments: args,
@@ -221,6 +244,13 @@ func (a *Agent) executeTool(
 				}
 				if chunk != nil {
 					sb.WriteString(fmt.Sprint(chunk))
+					emitToolEvent(ctx, ToolEvent{
+						Type:      ToolEventChunk,
+						SessionID: sessionID,
+						Tool:      toolName,
+						Result:    chunk,
+						Time:      time.Now(),
+					})
 				}
 			}
 			return sb.String(), nil
@@ -232,10 +262,60 @@ func (a *Agent) executeTool(
 	return nil, fmt.Errorf("unknown tool: %s", toolName)
 }
 
-func (a *Agent) detectDirectToolCall(s string) (string, map[string]any, bool) {
+// wantsToolStream reports whether a call should stream: the caller asked for
+// it, or someone is listening for tool events.
+func wantsToolStream(ctx context.Context, args map[string]any) bool {
+	if stream, ok := args["stream"].(bool); ok {
+		return stream
+	}
+	_, listening := ToolEventsFromContext(ctx)
+	return listening
+}
+
+// streamLocalTool runs a StreamingTool, reporting each delta as a chunk
+// event, and returns the full output.
+func streamLocalTool(ctx context.Context, tool StreamingTool, sessionID, toolName string, args map[string]any) (any, error) {
+	chunks, err := tool.InvokeStream(ctx, ToolRequest{SessionID: sessionID, Arguments: args})
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	var fullText string
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			err = chunk.Err
+			continue // drain so the producer can exit
+		}
+		if chunk.Delta != "" {
+			sb.WriteString(chunk.Delta)
+			emitToolEvent(ctx, ToolEvent{
+				Type:      ToolEventChunk,
+				SessionID: sessionID,
+				Tool:      toolName,
+				Result:    chunk.Delta,
+				Time:      time.Now(),
+			})
+		}
+		if chunk.Done && chunk.FullText != "" {
+			fullText = chunk.FullText
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sb.Len() == 0 {
+		return fullText, nil
+	}
+	return sb.String(), nil
+}
+
+// detectDirectToolCall recognises a tool call typed by the user. A call to
+// an unqualified name that several providers share returns the ambiguity
+// instead.
+func (a *Agent) detectDirectToolCall(sessionID, s string) (string, map[string]any, bool, *AmbiguousToolError) {
 	s = strings.TrimSpace(s)
 	if s == "" {
-		return "", nil, false
+		return "", nil, false, nil
 	}
 	lower := strings.ToLower(s)
 
@@ -248,10 +328,8 @@ func (a *Agent) detectDirectToolCall(s string) (string, map[string]any, bool) {
 			Arguments map[string]any `json:"arguments"`
 		}
 		if err := json.Unmarshal([]byte(s), &payload); err == nil && payload.Tool != "" {
-			if real, ok := a.resolveDirectToolName(payload.Tool); ok {
-				return real, payload.Arguments, ok
-			}
-			return "", nil, false
+			real, ambiguous := a.resolveDirectToolName(sessionID, payload.Tool)
+			return real, payload.Arguments, real != "", ambiguous
 		}
 	}
 
@@ -268,10 +346,8 @@ func (a *Agent) detectDirectToolCall(s string) (string, map[string]any, bool) {
 			var args map[string]any
 			_ = json.Unmarshal([]byte(argsStr), &args)
 
-			if real, ok := a.resolveDirectToolName(tool); ok {
-				return real, args, ok
-			}
-			return "", nil, false
+			real, ambiguous := a.resolveDirectToolName(sessionID, tool)
+			return real, args, real != "", ambiguous
 		}
 	}
 
@@ -285,64 +361,39 @@ func (a *Agent) detectDirectToolCall(s string) (string, map[string]any, bool) {
 
 		var args map[string]any
 		if err := json.Unmarshal([]byte(argsStr), &args); err == nil {
-			if real, ok := a.resolveDirectToolName(tool); ok {
-				return real, args, ok
-			}
-			return "", nil, false
+			real, ambiguous := a.resolveDirectToolName(sessionID, tool)
+			return real, args, real != "", ambiguous
 		}
 	}
 
-	return "", nil, false
+	return "", nil, false, nil
 }
 
 // resolveDirectToolName resolves a syntactically valid direct invocation. Tool
 // discovery is deliberately deferred until this point so ordinary prompts do
 // not trigger a potentially remote ToolSpecs refresh.
-func (a *Agent) resolveDirectToolName(name string) (string, bool) {
-	nameLower := strings.ToLower(strings.TrimSpace(name))
-	if nameLower == "" {
-		return "", false
-	}
-
-	for _, spec := range a.ToolSpecs() {
-		exact := spec.Name
-		fullLower := strings.ToLower(exact)
-
-		if fullLower == nameLower {
-			return exact, true
-		}
-		if strings.HasSuffix(fullLower, "."+nameLower) {
-			return exact, true
-		}
-	}
-
-	return "", false
+func (a *Agent) resolveDirectToolName(sessionID, name string) (string, *AmbiguousToolError) {
+	return a.resolveToolName(a.ToolSpecs(), sessionID, name)
 }
 
 func (a *Agent) handleCommand(ctx context.Context, sessionID, userInput string) (bool, string, map[string]string, error) {
-	trimmed := strings.TrimSpace(userInput)
-	lower := strings.ToLower(trimmed)
-
-	switch {
-	case strings.HasPrefix(lower, "subagent:"):
-		payload := strings.TrimSpace(trimmed[len("subagent:"):])
-		if payload == "" {
-			return true, "", nil, errors.New("subagent name is missing")
-		}
-		name, args := splitCommand(payload)
-		sa, ok := a.lookupSubAgent(name)
-		if !ok {
-			return true, "", nil, fmt.Errorf("unknown subagent: %s", name)
-		}
-		result, err := sa.Run(ctx, args)
-		if err != nil {
-			return true, "", nil, err
-		}
-		meta := map[string]string{"subagent": sa.Name()}
-		return true, result, meta, nil
-	default:
-		return false, "", nil, nil
+	sa, args, ok, err := a.parseSubAgentCommand(userInput)
+	if !ok || err != nil {
+		return ok, "", nil, err
+	}
+	res, err := a.runSubAgent(ctx, sessionID, sa, args, nil)
+	if err != nil {
+		return true, "", nil, err
 	}
+	meta := map[string]string{"subagent": sa.Name()}
+	if res.Cached {
+		meta["cached"] = "true"
+	}
+	if res.Partial {
+		res.Output += partialNotice(sa.Name(), a.SubAgentTimeout)
+		meta["partial"] = "true"
+	}
+	return true, res.Output, meta, nil
 }
 
 func parseToolArguments(raw string) map[string]any {
@@ -377,68 +428,120 @@ func toolCacheTTL() time.Duration {
 	return time.Duration(ms) * time.Millisecond
 }
 
+// toolPromptSegment is the rendered prompt for one provider's tools, tagged
+// with the signature of the specs it was rendered from.
+type toolPromptSegment struct {
+	etag     string
+	rendered string
+}
+
+// toolProvider names the cache segment a tool belongs to: the prefix of a
+// "provider.tool" name, or "local" for unqualified tools.
+func toolProvider(name string) string {
+	if provider, _, ok := strings.Cut(strings.TrimSpace(name), "."); ok && provider != "" {
+		return strings.ToLower(provider)
+	}
+	return "local"
+}
+
+// toolListSignature is an etag over everything the tool prompt renders, so a
+// segment is re-rendered exactly when one of its tools changes.
 func toolListSignature(specs []tools.Tool) string {
 	if len(specs) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
-	sb.Grow(len(specs) * 32)
+	sb.Grow(len(specs) * 64)
 
 	for _, t := range specs {
 		sb.WriteString(strings.ToLower(strings.TrimSpace(t.Name)))
 		sb.WriteByte('|')
 		sb.WriteString(strings.TrimSpace(t.Description))
 		sb.WriteByte('|')
-		sb.WriteString(strconv.Itoa(len(t.Inputs.Properties)))
+		inputs, _ := json.Marshal(t.Inputs)
+		sb.Write(inputs)
 		sb.WriteByte('|')
-		sb.WriteString(strconv.Itoa(len(t.Inputs.Required)))
+		outputs, _ := json.Marshal(t.Outputs)
+		sb.Write(outputs)
 		sb.WriteByte(';')
 	}
-	return sb.String()
+	return cache.HashKey(sb.String())
+}
+
+func (a *Agent) toolPromptSegments() *cache.LRUCache {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	if a.toolPrompts == nil {
+		a.toolPrompts = cache.NewLRUCache(maxToolPromptSegments, toolPromptSegmentTTL)
+	}
+	return a.toolPrompts
 }
 
+// cachedToolPrompt renders specs grouped by provider. Each provider's section
+// is cached under the signature of its tools, so a provider whose tools
+// change does not force re-rendering the others.
 func (a *Agent) cachedToolPrompt(specs []tools.Tool) string {
 	if len(specs) == 0 {
 		return ""
 	}
 
-	key := toolListSignature(specs)
-	now := time.Now()
-
-	a.toolMu.RLock()
-	prompt := a.toolPromptCache
-	cacheKey := a.toolPromptKey
-	promptExpiry := a.toolPromptExpiry
-	specExpiry := a.toolSpecsExpiry
-	a.toolMu.RUnlock()
-
-	if prompt != "" && key == cacheKey && (promptExpiry.IsZero() || now.Before(promptExpiry)) {
-		return prompt
+	var order []string
+	groups := map[string][]tools.Tool{}
+	for _, t := range specs {
+		provider := toolProvider(t.Name)
+		if _, ok := groups[provider]; !ok {
+			order = append(order, provider)
+		}
+		groups[provider] = append(groups[provider], t)
 	}
 
-	rendered := renderUtcpToolsForPrompt(specs)
-	expiry := specExpiry
-	if expiry.IsZero() || now.After(expiry) {
-		expiry = now.Add(toolCacheTTL())
+	segments := a.toolPromptSegments()
+	var sb strings.Builder
+	sb.WriteString(toolPromptHeader)
+	for _, provider := range order {
+		group := groups[provider]
+		etag := toolListSignature(group)
+		if cached, ok := segments.Get(provider); ok {
+			if seg := cached.(toolPromptSegment); seg.etag == etag {
+				sb.WriteString(seg.rendered)
+				continue
+			}
+		}
+		rendered := renderToolBlocks(group)
+		segments.Set(provider, toolPromptSegment{etag: etag, rendered: rendered})
+		sb.WriteString(rendered)
 	}
+	return sb.String()
+}
+
+// InvalidateToolCache drops cached tool specs so the next turn fetches them
+// again, along with the rendered prompt of the named providers ("local" for
+// unqualified tools). With no providers every rendered segment is dropped.
+func (a *Agent) InvalidateToolCache(providers ...string) {
+	segments := a.toolPromptSegments()
 
 	a.toolMu.Lock()
-	a.toolPromptCache = rendered
-	a.toolPromptKey = key
-	a.toolPromptExpiry = expiry
+	a.toolSpecsCache = nil
+	a.toolSpecsExpiry = time.Time{}
 	a.toolMu.Unlock()
 
-	return rendered
+	if len(providers) == 0 {
+		segments.Clear()
+		return
+	}
+	for _, provider := range providers {
+		segments.Delete(strings.ToLower(strings.TrimSpace(provider)))
+	}
 }
 
-func renderUtcpToolsForPrompt(specs []tools.Tool) string {
-	var sb strings.Builder
+const toolPromptHeader = "------------------------------------------------------------\n" +
+	"UTCP TOOL REFERENCE (INPUT + OUTPUT SCHEMAS)\n" +
+	"Use EXACT field names listed below. Do NOT invent new keys.\n" +
+	"------------------------------------------------------------\n\n"
 
-	sb.WriteString("------------------------------------------------------------\n")
-	sb.WriteString("UTCP TOOL REFERENCE (INPUT + OUTPUT SCHEMAS)\n")
-	sb.WriteString("Use EXACT field names listed below. Do NOT invent new keys.\n")
-	sb.WriteString("------------------------------------------------------------\n\n")
+func renderToolBlocks(specs []tools.Tool) string {
+	var sb strings.Builder
 
 	for _, t := range specs {
 
@@ -519,6 +622,16 @@ func (a *Agent) lookupSubAgent(name string) (SubAgent, bool) {
 	return a.subAgentDirectory.Lookup(name)
 }
 
+// UTCPSearchLimit is how many UTCP tools an agent discovers, read from the
+// utcp_search_tools_limit environment variable; 50 when unset or zero.
+func UTCPSearchLimit() int {
+	limit, err := strconv.Atoi(os.Getenv("utcp_search_tools_limit"))
+	if err != nil || limit == 0 {
+		return 50
+	}
+	return limit
+}
+
 // ToolSpecs returns the registered tool specifications in deterministic order.
 func (a *Agent) ToolSpecs() []tools.Tool {
 	now := time.Now()
@@ -577,17 +690,15 @@ func (a *Agent) ToolSpecs() []tools.Tool {
 		}
 	}
 
-	limit, err := strconv.Atoi(os.Getenv("utcp_search_tools_limit"))
-	if err != nil {
-		limit = 50
-	}
-	if limit == 0 {
-		limit = 50
-	}
-
 	// 3. Get UTCP tool specs and merge
 	if a.UTCPClient != nil {
-		utcpTools, _ := a.UTCPClient.SearchTools("", limit)
+		a.toolMu.Lock()
+		utcpTools := a.warmUTCPSpecs
+		a.warmUTCPSpecs = nil
+		a.toolMu.Unlock()
+		if utcpTools == nil {
+			utcpTools, _ = a.UTCPClient.SearchTools("", UTCPSearchLimit())
+		}
 		for _, tool := range utcpTools {
 			key := strings.ToLower(tool.Name)
 			if !seen[key] {
@@ -600,9 +711,6 @@ func (a *Agent) ToolSpecs() []tools.Tool {
 	a.toolMu.Lock()
 	a.toolSpecsCache = append([]tools.Tool(nil), allSpecs...)
 	a.toolSpecsExpiry = now.Add(toolCacheTTL())
-	a.toolPromptCache = ""
-	a.toolPromptKey = ""
-	a.toolPromptExpiry = time.Time{}
 	a.toolMu.Unlock()
 
 	return append([]tools.Tool(nil), allSpecs...)
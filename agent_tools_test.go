@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+func TestCachedToolPromptSegmentsByProvider(t *testing.T) {
+	a := &Agent{}
+	specs := []tools.Tool{
+		{Name: "echo", Description: "Echoes input"},
+		{Name: "weather.forecast", Description: "Forecast v1"},
+		{Name: "weather.alerts", Description: "Alerts"},
+	}
+
+	first := a.cachedToolPrompt(specs)
+	if !strings.HasPrefix(first, toolPromptHeader) || !strings.Contains(first, "Forecast v1") {
+		t.Fatalf("unexpected prompt:\n%s", first)
+	}
+	if n := a.toolPromptSegments().Len(); n != 2 {
+		t.Fatalf("expected local and weather segments, got %d", n)
+	}
+	local, _ := a.toolPrompts.Get("local")
+
+	specs[1].Description = "Forecast v2"
+	second := a.cachedToolPrompt(specs)
+	if strings.Contains(second, "Forecast v1") || !strings.Contains(second, "Forecast v2") {
+		t.Fatalf("changed provider not re-rendered:\n%s", second)
+	}
+	if again, _ := a.toolPrompts.Get("local"); again.(toolPromptSegment).etag != local.(toolPromptSegment).etag {
+		t.Fatal("unchanged provider segment was replaced")
+	}
+
+	a.toolSpecsCache = specs
+	a.InvalidateToolCache("Weather")
+	if a.toolSpecsCache != nil {
+		t.Fatal("spec cache survived invalidation")
+	}
+	if _, ok := a.toolPrompts.Get("weather"); ok {
+		t.Fatal("weather segment survived invalidation")
+	}
+	if _, ok := a.toolPrompts.Get("local"); !ok {
+		t.Fatal("local segment should be kept")
+	}
+	a.InvalidateToolCache()
+	if a.toolPrompts.Len() != 0 {
+		t.Fatal("expected every segment to be dropped")
+	}
+}
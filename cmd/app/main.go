@@ -14,6 +14,11 @@
 // Qdrant (memory) defaults:
 //
 //	-url http://localhost:6333 -collection adk_memories
+//
+// No keys and no Qdrant: run against a local Ollama with in-memory storage.
+//
+//	ollama pull llama3.2
+//	go run . -local -message "Say hello"
 package main
 
 import (
@@ -50,6 +55,7 @@ var (
 	flagTimeout      = flag.Duration("timeout", 90*time.Second, "Overall request timeout")
 	qdrantURL        = flag.String("qdrant-url", "http://localhost:6333", "Qdrant base URL")
 	qdrantCollection = flag.String("qdrant-collection", "adk_memories", "Qdrant collection name")
+	flagLocal        = flag.Bool("local", false, "Use the local profile: Ollama (-model, default "+adk.DefaultLocalModel+") and in-memory storage")
 )
 
 func main() {
@@ -76,15 +82,27 @@ func main() {
 	// 3) Build ADK with a model provider module bound to your flags
 	memOpts := engine.DefaultOptions()
 
-	kit, err := adk.New(ctx,
-		adk.WithDefaultSystemPrompt("You orchestrate a helpful assistant team."),
-		adk.WithModules(
+	var setup adk.Option
+	if *flagLocal {
+		profile := adk.LocalProfile{MemoryOptions: &memOpts}
+		if isFlagSet("model") {
+			profile.Model = *flagModel
+		}
+		setup = adk.WithLocalProfile(profile)
+		*flagProvider, *flagModel = "ollama", profile.Model
+	} else {
+		setup = adk.WithModules(
 			modules.NewModelModule("llm", func(c context.Context) (models.Agent, error) {
 				// Provider-agnostic: openai|gemini|anthropic|ollama|dummy
 				return models.NewLLMProvider(c, strings.ToLower(*flagProvider), *flagModel, "Swarm orchestration:")
 			}),
 			modules.InQdrantMemory(100000, *qdrantURL, *qdrantCollection, memory.AutoEmbedder(), &memOpts),
-		),
+		)
+	}
+
+	kit, err := adk.New(ctx,
+		adk.WithDefaultSystemPrompt("You orchestrate a helpful assistant team."),
+		setup,
 	)
 	if err != nil {
 		fail(fmt.Errorf("adk.New: %w", err))
@@ -115,6 +133,15 @@ func main() {
 	fmt.Println(out)
 }
 
+// isFlagSet reports whether name was given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
 func getMessage(flagMsg string, useStdin bool, r io.Reader) (string, error) {
 	if useStdin {
 		var b strings.Builder
@@ -2,177 +2,110 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"log"
-
-	adkmodules "github.com/Protocol-Lattice/go-agent/src/adk/modules"
-
-	"net/http"
-	"os"
 	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/adk"
+	adkmodules "github.com/Protocol-Lattice/go-agent/src/adk/modules"
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/memory/engine"
 	"github.com/Protocol-Lattice/go-agent/src/models"
 	"github.com/Protocol-Lattice/go-agent/src/subagents"
-	"github.com/universal-tool-calling-protocol/go-utcp"
+	"github.com/Protocol-Lattice/go-agent/src/utcpmock"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
 )
 
-var discovered bool
-
-func startServer(addr string) {
-	http.HandleFunc("/tools", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		raw, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
-			return
-		}
-		defer r.Body.Close()
-
-		// Discovery: first empty-body => discovery
-		if len(raw) == 0 && !discovered {
-			discovered = true
-			// Read discovery response from tools.json
-			data, err := os.ReadFile("tools.json")
-			if err != nil {
-				log.Printf("Failed to read tools.json: %v", err)
-				return
-			}
-			var discoveryResponse map[string]interface{}
-			if err := json.Unmarshal(data, &discoveryResponse); err != nil {
-				log.Printf("Failed to unmarshal tools.json: %v", err)
-				return
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(discoveryResponse); err != nil {
-				log.Printf("Failed to encode discovery response: %v", err)
-			}
-			return
-		}
-
-		// Try to parse the JSON
-		var probe map[string]interface{}
-		if err := json.Unmarshal(raw, &probe); err != nil {
-			http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
-			return
-		}
-
-		// Standard tool call (has "tool" field)
-		if toolName, hasToolField := probe["tool"].(string); hasToolField && toolName != "" {
-			// Empty-body after discovery => timestamp call
-			if len(raw) == 0 {
-				log.Printf("Empty body – timestamp call")
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(map[string]any{"result": time.Now().Format(time.RFC3339)})
-				return
-			}
-
-			var req struct {
-				Tool string                 `json:"tool"`
-				Args map[string]interface{} `json:"args"`
-			}
-			if err := json.Unmarshal(raw, &req); err != nil {
-				http.Error(w, fmt.Sprintf("invalid JSON for tool call: %v", err), http.StatusBadRequest)
-				return
-			}
-
-			log.Printf("Standard tool call: %s with args: %v", req.Tool, req.Args)
-			w.Header().Set("Content-Type", "application/json")
-
-			switch req.Tool {
-
-			case "http.echo":
-				msg, ok := req.Args["message"].(string)
+// mockTools are the tools the demo provider serves.
+func mockTools() []utcpmock.Tool {
+	numbers := tools.ToolInputOutputSchema{
+		Properties: map[string]any{"a": map[string]any{"type": "number"}, "b": map[string]any{"type": "number"}},
+		Required:   []string{"a", "b"},
+	}
+	return []utcpmock.Tool{
+		{
+			Name:        "echo",
+			Description: "Echo back a message",
+			Inputs:      tools.ToolInputOutputSchema{Properties: map[string]any{"message": map[string]any{"type": "string"}}, Required: []string{"message"}},
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				msg, ok := args["message"].(string)
 				if !ok {
-					http.Error(w, "missing 'message' argument for http.echo", http.StatusBadRequest)
-					return
+					return nil, fmt.Errorf("missing 'message' argument")
 				}
-				json.NewEncoder(w).Encode(map[string]any{"result": msg})
-
-			case "http.timestamp":
-				json.NewEncoder(w).Encode(map[string]any{"result": time.Now().Format(time.RFC3339)})
-
-			case "http.math.add":
-				a, aOk := req.Args["a"].(float64)
-				b, bOk := req.Args["b"].(float64)
+				return map[string]any{"result": msg}, nil
+			},
+		},
+		{
+			Name:        "timestamp",
+			Description: "Current server timestamp",
+			Handler: func(context.Context, map[string]any) (any, error) {
+				return map[string]any{"result": time.Now().Format(time.RFC3339)}, nil
+			},
+		},
+		{
+			Name:        "math.add",
+			Description: "Add two numbers",
+			Inputs:      numbers,
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				a, aOk := args["a"].(float64)
+				b, bOk := args["b"].(float64)
 				if !aOk || !bOk {
-					http.Error(w, "missing 'a' or 'b' arguments for http.math.add", http.StatusBadRequest)
-					return
+					return nil, fmt.Errorf("missing 'a' or 'b' arguments")
 				}
-				json.NewEncoder(w).Encode(map[string]any{"result": a + b})
-
-			case "http.math.multiply":
-				a, aOk := req.Args["a"].(float64)
-				b, bOk := req.Args["b"].(float64)
+				return map[string]any{"result": a + b}, nil
+			},
+		},
+		{
+			Name:        "math.multiply",
+			Description: "Multiply two numbers",
+			Inputs:      numbers,
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				a, aOk := args["a"].(float64)
+				b, bOk := args["b"].(float64)
 				if !aOk || !bOk {
-					http.Error(w, "missing 'a' or 'b' arguments for http.math.multiply", http.StatusBadRequest)
-					return
+					return nil, fmt.Errorf("missing 'a' or 'b' arguments")
 				}
-				json.NewEncoder(w).Encode(map[string]any{"result": a * b})
-
-			case "http.string.concat":
-				prefix, pOk := req.Args["prefix"].(string)
-				value, vOk := req.Args["value"].(string)
+				return map[string]any{"result": a * b}, nil
+			},
+		},
+		{
+			Name:        "string.concat",
+			Description: "Concatenate two strings",
+			Inputs: tools.ToolInputOutputSchema{
+				Properties: map[string]any{"prefix": map[string]any{"type": "string"}, "value": map[string]any{"type": "string"}},
+				Required:   []string{"prefix", "value"},
+			},
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				prefix, pOk := args["prefix"].(string)
+				value, vOk := args["value"].(string)
 				if !pOk || !vOk {
-					http.Error(w, "missing 'prefix' or 'value' arguments for http.string.concat", http.StatusBadRequest)
-					return
+					return nil, fmt.Errorf("missing 'prefix' or 'value' arguments")
 				}
-				json.NewEncoder(w).Encode(map[string]any{"result": prefix + value})
-
-			default:
-				http.Error(w, fmt.Sprintf("unknown tool: %s", req.Tool), http.StatusBadRequest)
-				return
-			}
-
-			return
-		}
-
-		// Direct echo call (has "message" field)
-		if _, hasMessage := probe["message"]; hasMessage {
-			log.Printf("Direct echo call with args: %v", probe)
-			w.Header().Set("Content-Type", "application/json")
-			msg, _ := probe["message"].(string)
-			json.NewEncoder(w).Encode(map[string]any{"result": msg})
-			return
-		}
-
-		// Handle math.add call from UTCP http transport (body is just args)
-		if _, hasA := probe["a"]; hasA {
-			if _, hasB := probe["b"]; hasB {
-				log.Printf("Direct math.add call with args: %v", probe)
-				a, _ := probe["a"].(float64)
-				b, _ := probe["b"].(float64)
-				json.NewEncoder(w).Encode(map[string]any{"sum": a + b})
-				return
-			}
-		}
-
-		// Unknown request format
-		log.Printf("Unknown request format: %v", probe)
-		http.Error(w, "unknown request format", http.StatusBadRequest)
-	})
-
-	log.Printf("HTTP mock server on %s", addr)
-	log.Fatal(http.ListenAndServe(addr, nil))
-
+				return map[string]any{"result": prefix + value}, nil
+			},
+		},
+		{
+			Name:        "stream.echo",
+			Description: "Stream back text input",
+			Inputs:      tools.ToolInputOutputSchema{Properties: map[string]any{"input": map[string]any{"type": "string"}}, Required: []string{"input"}},
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				return []any{args["input"]}, nil
+			},
+		},
+	}
 }
 
 func main() {
-	go startServer(":8080")
-	time.Sleep(200 * time.Millisecond)
 	ctx := context.Background()
-	cfg := &utcp.UtcpClientConfig{ProvidersFilePath: "provider.json"}
-	client, err := utcp.NewUTCPClient(ctx, cfg, nil, nil)
+	mock := utcpmock.New("http", mockTools()...)
+	addr, err := mock.ListenGRPC("127.0.0.1:0")
+	if err != nil {
+		log.Fatalf("mock server: %v", err)
+	}
+	defer mock.Close()
+	log.Printf("UTCP mock server on %s", addr)
+	client, err := mock.Client(ctx)
 	if err != nil {
 		log.Fatalf("client error: %v", err)
 	}
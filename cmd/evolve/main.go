@@ -0,0 +1,84 @@
+// cmd/evolve — review prompt optimisation runs recorded by selfevolve.
+//
+// The report command renders side-by-side prompt and response diffs and
+// per-evaluator score deltas between iterations of a saved history, so the
+// changes can be reviewed before a candidate prompt is promoted.
+//
+// Examples:
+//
+//	go run ./cmd/evolve report -history run.json
+//	go run ./cmd/evolve report -history run.json -from 3 -to 5 -format html -o review.html
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/selfevolve"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "report":
+		if err := report(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "evolve report:", err)
+			os.Exit(1)
+		}
+	case "-h", "-help", "--help", "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "evolve: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: evolve report -history FILE [-from N] [-to N] [-format markdown|html] [-o FILE]")
+}
+
+func report(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	history := fs.String("history", "", "History JSON written by selfevolve.History.Save")
+	from := fs.Int("from", 0, "First iteration number to include")
+	to := fs.Int("to", -1, "Last iteration number to include (default: latest)")
+	format := fs.String("format", "markdown", "Output format: markdown|html")
+	out := fs.String("o", "", "Write the report to this file instead of stdout")
+	_ = fs.Parse(args)
+	if *history == "" {
+		return fmt.Errorf("-history is required")
+	}
+
+	h, err := selfevolve.LoadHistory(*history)
+	if err != nil {
+		return err
+	}
+	r, err := h.Report(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	switch strings.ToLower(*format) {
+	case "markdown", "md":
+		return r.WriteMarkdown(w)
+	case "html":
+		return r.WriteHTML(w)
+	}
+	return fmt.Errorf("unknown format %q", *format)
+}
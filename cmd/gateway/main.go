@@ -4,7 +4,50 @@
 //
 //	POST /chat        synchronous chat: {session, message} → {response}
 //	POST /stream      SSE streaming:    {session, message} → text/event-stream
+//	                  (tool progress is sent as "event: tool" frames)
 //	GET  /health      liveness check:   → {ok: true}
+//	GET  /.well-known/agent.json
+//	                  capability manifest: tools, sub-agents, spaces, model, limits
+//	POST /a2a         A2A JSON-RPC: message/send, message/stream, tasks/get, tasks/cancel
+//	GET  /.well-known/agent-card.json
+//	                  A2A agent card
+//	GET  /sessions/{session}/transcript?format=markdown|html|json
+//	                  report of the session's chats, tool calls, citations and timings
+//	GET  /v1/sessions/{session}/search?q=...&k=10
+//	                  search one session's history: → {query, results: [...]}
+//	GET  /v1/search?q=...&sessions=a,b&k=10
+//	                  search across sessions; with -auth, defaults to every session the caller chatted in
+//	GET  /artifacts/{session}[/{name}[/versions]]
+//	                  artifacts saved by the agent (with -artifacts)
+//	POST <webhook>    routes from -webhooks: event payload → agent run
+//
+// The -webhooks file is a JSON array of routes mapping an endpoint to a
+// session template and a prompt template rendered with the event (see
+// src/webhooks), e.g.
+//
+//	[{"name": "github", "path": "/hooks/github", "provider": "github",
+//	  "secret_env": "GITHUB_WEBHOOK_SECRET", "events": ["pull_request"],
+//	  "session": "gh:{{.Payload.repository.full_name}}",
+//	  "prompt": "Review PR #{{.Payload.number}}: {{json .Payload.pull_request}}"}]
+//
+// The -auth file maps bearer tokens to caller identities. When set, every
+// endpoint except /health, the discovery documents and webhooks (which
+// check their own secrets) requires "Authorization: Bearer <token>", and
+// retrieval runs as the token's identity, so chunks ingested with an ACL
+// only reach the principals and groups it lists. A session belongs to the
+// principal that chatted in it first: other principals get 403 from /chat
+// and /stream, and its transcript, artifacts and search stay private to
+// its owner. Ownership is kept in the -owners file across restarts. A2A
+// contexts are claimed the same way under their "a2a:" sessions, which
+// /chat and /stream do not accept. Webhook sessions belong to their route,
+// and /chat and /stream refuse sessions a route's session template could
+// render. With -auth, a route without a provider is refused unless it sets
+// "allow_unsigned": true.
+//
+//	{"s3cr3t": {"principal": "alice@example.com", "groups": ["eng"]}}
+//
+// The -flags file holds feature flags such as {"tool_calling": "json",
+// "auto_delegate": true}; edits apply without a restart (see src/flags).
 //
 // Examples (no API key required — uses dummy model by default):
 //
@@ -26,14 +69,28 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/a2a"
+	"github.com/Protocol-Lattice/go-agent/src/artifacts"
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/flags"
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
+	"github.com/Protocol-Lattice/go-agent/src/transcript"
+	"github.com/Protocol-Lattice/go-agent/src/webhooks"
 )
 
+// maxSearchResults caps k on the search endpoints.
+const maxSearchResults = 100
+
 var (
 	flagAddr     = flag.String("addr", ":8080", "Listen address")
 	flagProvider = flag.String("provider", "dummy", "LLM provider: dummy|gemini|openai|anthropic|ollama")
@@ -41,6 +98,14 @@ var (
 	flagSystem   = flag.String("system", "You are a helpful assistant.", "System prompt")
 	flagTimeout  = flag.Duration("timeout", 60*time.Second, "Per-request timeout")
 	flagContext  = flag.Int("context", 8, "Max memory records retrieved per turn")
+	flagWebhooks = flag.String("webhooks", "", "JSON file of webhook routes that trigger agent runs")
+	flagName     = flag.String("name", "gateway", "Agent name published in the manifest")
+	flagDesc     = flag.String("description", "", "Agent description published in the manifest")
+	flagArtifact = flag.String("artifacts", "", "Directory for agent artifacts; enables the artifacts tool and /artifacts/")
+	flagPublic   = flag.String("public-url", "", "Externally reachable base URL advertised in the A2A card (default http://localhost<addr>)")
+	flagAuth     = flag.String("auth", "", "JSON file mapping bearer tokens to identities; requires auth on every endpoint but /health, discovery and webhooks")
+	flagFlags    = flag.String("flags", "", "JSON file of feature flags, re-read when it changes; AGENT_FLAG_* variables override it")
+	flagOwners   = flag.String("owners", "gateway-sessions.json", "JSON file recording which principal owns each session (with -auth)")
 )
 
 func main() {
@@ -48,15 +113,73 @@ func main() {
 
 	ctx := context.Background()
 
-	ag, err := buildAgent(ctx)
+	var store *artifacts.Store
+	if *flagArtifact != "" {
+		blobs, err := artifacts.NewFileBlobStore(*flagArtifact)
+		if err != nil {
+			log.Fatalf("artifacts: %v", err)
+		}
+		store = artifacts.NewStore(blobs)
+	}
+
+	ag, mem, err := buildAgent(ctx, store)
 	if err != nil {
 		log.Fatalf("build agent: %v", err)
 	}
 
+	identities, err := loadIdentities(*flagAuth)
+	if err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle("POST /chat", withTimeout(*flagTimeout, handleChat(ag)))
-	mux.Handle("POST /stream", withTimeout(*flagTimeout, handleStream(ag)))
+	books := newTranscripts()
+	owners, err := newSessionOwners(identities != nil, *flagOwners)
+	if err != nil {
+		log.Fatalf("owners: %v", err)
+	}
+	if store != nil {
+		mux.Handle("GET /artifacts/", withIdentity(identities, withArtifactOwner(owners, http.StripPrefix("/artifacts", artifacts.Handler(store)))))
+	}
+	mux.Handle("POST /chat", withIdentity(identities, withTimeout(*flagTimeout, handleChat(ag, books, owners))))
+	mux.Handle("POST /stream", withIdentity(identities, withTimeout(*flagTimeout, handleStream(ag, books, owners))))
+	mux.Handle("GET /sessions/{session}/transcript", withIdentity(identities, handleTranscript(books, owners)))
+	mux.Handle("GET /v1/sessions/{session}/search", withIdentity(identities, withTimeout(*flagTimeout, handleSessionSearch(mem, owners))))
+	mux.Handle("GET /v1/search", withIdentity(identities, withTimeout(*flagTimeout, handleSearch(mem, owners))))
 	mux.HandleFunc("GET /health", handleHealth)
+	mux.HandleFunc("GET "+agent.ManifestPath, handleManifest(ag))
+	publicURL := *flagPublic
+	if publicURL == "" {
+		publicURL = "http://localhost" + *flagAddr
+	}
+	a2aServer := a2a.NewServer(ag, a2a.CardFromManifest(ag.Manifest(), strings.TrimRight(publicURL, "/")+"/a2a"))
+	a2aServer.Claim = owners.claim
+	mux.Handle("POST /a2a", withIdentity(identities, a2aServer))
+	mux.Handle("GET "+a2a.CardPath, a2aServer)
+	if *flagWebhooks != "" {
+		routes, err := webhooks.LoadRoutes(*flagWebhooks)
+		if err != nil {
+			log.Fatalf("load webhooks: %v", err)
+		}
+		for _, rt := range routes {
+			if identities != nil && rt.Verify == nil && !rt.AllowUnsigned {
+				log.Fatalf("webhooks: route %q has no provider; set allow_unsigned to accept unsigned deliveries with -auth", rt.Name)
+			}
+		}
+		router, err := webhooks.NewRouter(map[string]webhooks.Runner{webhooks.DefaultAgent: ag}, routes...)
+		if err != nil {
+			log.Fatalf("webhooks: %v", err)
+		}
+		// Webhook sessions belong to their route, so no caller can read or
+		// write into them.
+		router.Claim = func(ctx context.Context, route, session string) error {
+			return owners.claim(memory.ContextWithIdentity(ctx, memory.Identity{Principal: "webhook:" + route}), session)
+		}
+		owners.reserved = router.Reserved
+		for _, path := range router.Paths() {
+			mux.Handle("POST "+path, router)
+		}
+	}
 
 	log.Printf("gateway listening on %s (provider=%s model=%s)", *flagAddr, *flagProvider, *flagModel)
 	if err := http.ListenAndServe(*flagAddr, mux); err != nil {
@@ -67,7 +190,7 @@ func main() {
 // buildAgent constructs the agent with in-memory storage.
 // Swap modules.InMemoryMemoryModule for InPostgresMemory / InQdrantMemory
 // to add persistence without changing any other code.
-func buildAgent(ctx context.Context) (*agent.Agent, error) {
+func buildAgent(ctx context.Context, store *artifacts.Store) (*agent.Agent, *memory.SessionMemory, error) {
 	var model models.Agent
 	var err error
 
@@ -77,7 +200,7 @@ func buildAgent(ctx context.Context) (*agent.Agent, error) {
 	} else {
 		model, err = models.NewLLMProvider(ctx, provider, *flagModel, "")
 		if err != nil {
-			return nil, fmt.Errorf("create model (%s): %w", provider, err)
+			return nil, nil, fmt.Errorf("create model (%s): %w", provider, err)
 		}
 	}
 
@@ -86,12 +209,31 @@ func buildAgent(ctx context.Context) (*agent.Agent, error) {
 		*flagContext,
 	)
 
-	return agent.New(agent.Options{
+	live := flags.Provider(flags.Env{Prefix: "AGENT_FLAG_"})
+	if *flagFlags != "" {
+		file, err := flags.NewFile(*flagFlags)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load flags: %w", err)
+		}
+		live = flags.Chain(live, file)
+	}
+
+	var tools []agent.Tool
+	if store != nil {
+		tools = append(tools, artifacts.NewTool(store))
+	}
+
+	ag, err := agent.New(agent.Options{
 		Model:        model,
+		Tools:        tools,
 		Memory:       mem,
 		SystemPrompt: *flagSystem,
 		ContextLimit: *flagContext,
+		Name:         *flagName,
+		Description:  *flagDesc,
+		Flags:        live,
 	})
+	return ag, mem, err
 }
 
 // chatRequest is the JSON body for POST /chat and POST /stream.
@@ -106,7 +248,61 @@ type chatResponse struct {
 	Session  string `json:"session"`
 }
 
-func handleChat(ag *agent.Agent) http.HandlerFunc {
+// transcripts records each session's exchanges for the transcript endpoint,
+// keeping the most recently active sessions.
+type transcripts struct {
+	mu   sync.Mutex
+	recs *cache.LRUCache // session -> *transcript.Recorder
+}
+
+func newTranscripts() *transcripts {
+	return &transcripts{recs: cache.NewLRUCache(1024, 24*time.Hour)}
+}
+
+func (ts *transcripts) recorder(session string, create bool) *transcript.Recorder {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if v, ok := ts.recs.Get(session); ok {
+		return v.(*transcript.Recorder)
+	}
+	if !create {
+		return nil
+	}
+	rec := transcript.NewRecorder("")
+	ts.recs.Set(session, rec)
+	return rec
+}
+
+func handleTranscript(ts *transcripts, owners *sessionOwners) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session := r.PathValue("session")
+		var rec *transcript.Recorder
+		if owners.owns(r.Context(), session) {
+			rec = ts.recorder(session, false)
+		}
+		if rec == nil {
+			writeError(w, http.StatusNotFound, "no transcript for session")
+			return
+		}
+		format := r.URL.Query().Get("format")
+		switch format {
+		case "html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+		case "", "markdown", "md":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		default:
+			writeError(w, http.StatusBadRequest, "format must be markdown, html or json")
+			return
+		}
+		if err := transcript.Write(w, rec.Transcript(), format); err != nil {
+			log.Printf("transcript: %v", err)
+		}
+	}
+}
+
+func handleChat(ag *agent.Agent, ts *transcripts, owners *sessionOwners) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req chatRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -118,11 +314,17 @@ func handleChat(ag *agent.Agent) http.HandlerFunc {
 			return
 		}
 
-		out, err := ag.Generate(r.Context(), req.Session, req.Message)
+		if !claimSession(w, r, owners, req.Session) {
+			return
+		}
+		rec := ts.recorder(req.Session, true)
+		started := time.Now()
+		out, err := ag.Generate(rec.Context(r.Context()), req.Session, req.Message)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		rec.Record(req.Session, req.Message, fmt.Sprint(out), started, ag)
 
 		writeJSON(w, http.StatusOK, chatResponse{
 			Response: fmt.Sprint(out),
@@ -135,9 +337,10 @@ func handleChat(ag *agent.Agent) http.HandlerFunc {
 //
 // Event format:
 //
+//	event: tool\ndata: <json>\n\n — tool call progress (start|chunk|result|error)
 //	data: <token>\n\n       — incremental text chunk
 //	data: [DONE]\n\n        — stream finished
-func handleStream(ag *agent.Agent) http.HandlerFunc {
+func handleStream(ag *agent.Agent, ts *transcripts, owners *sessionOwners) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req chatRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -154,46 +357,324 @@ func handleStream(ag *agent.Agent) http.HandlerFunc {
 			writeError(w, http.StatusInternalServerError, "streaming not supported by transport")
 			return
 		}
+		if !claimSession(w, r, owners, req.Session) {
+			return
+		}
 
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
 		w.WriteHeader(http.StatusOK)
 
-		ch, err := ag.GenerateStream(r.Context(), req.Session, req.Message)
-		if err != nil {
-			fmt.Fprintf(w, "data: error: %s\n\n", err.Error())
+		// Tool events may be emitted from goroutines owned by the agent, so
+		// every frame written to the response goes through the same lock.
+		// Those goroutines can outlive the handler, and w must not be
+		// written after it returns, so done turns send into a no-op.
+		var (
+			mu   sync.Mutex
+			done bool
+		)
+		send := func(format string, args ...any) {
+			mu.Lock()
+			defer mu.Unlock()
+			if done {
+				return
+			}
+			fmt.Fprintf(w, format, args...)
 			flusher.Flush()
+		}
+		defer func() {
+			mu.Lock()
+			done = true
+			mu.Unlock()
+		}()
+
+		rec := ts.recorder(req.Session, true)
+		started := time.Now()
+		ctx := agent.ContextWithToolEvents(r.Context(), func(ev agent.ToolEvent) {
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				payload, _ = json.Marshal(agent.ToolEvent{
+					Type:  ev.Type,
+					Tool:  ev.Tool,
+					Error: "unencodable tool event: " + err.Error(),
+				})
+			}
+			send("event: tool\ndata: %s\n\n", payload)
+		})
+		ctx = rec.Context(ctx)
+
+		ch, err := ag.GenerateStream(ctx, req.Session, req.Message)
+		if err != nil {
+			send("data: error: %s\n\n", err.Error())
 			return
 		}
+		// Drain what is left after an early return so the producer is not
+		// stuck sending.
+		defer func() {
+			go func() {
+				for range ch {
+				}
+			}()
+		}()
 
+		var answer strings.Builder
 		for chunk := range ch {
 			if chunk.Err != nil {
-				fmt.Fprintf(w, "data: error: %s\n\n", chunk.Err.Error())
-				flusher.Flush()
+				send("data: error: %s\n\n", chunk.Err.Error())
 				return
 			}
+			if chunk.Delta != "" {
+				answer.WriteString(chunk.Delta)
+				send("data: %s\n\n", chunk.Delta)
+			}
 			if chunk.Done {
-				fmt.Fprintf(w, "data: [DONE]\n\n")
-				flusher.Flush()
-				return
+				break
 			}
-			if chunk.Delta != "" {
-				fmt.Fprintf(w, "data: %s\n\n", chunk.Delta)
-				flusher.Flush()
+		}
+		rec.Record(req.Session, req.Message, answer.String(), started, ag)
+		send("data: [DONE]\n\n")
+	}
+}
+
+// searchResult is one hit returned by the search endpoints.
+type searchResult struct {
+	ID        int64     `json:"id,omitempty"`
+	Session   string    `json:"session"`
+	Role      string    `json:"role,omitempty"`
+	Content   string    `json:"content"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// searchResponse is the JSON body returned by the search endpoints.
+type searchResponse struct {
+	Query   string         `json:"query"`
+	Results []searchResult `json:"results"`
+}
+
+// errSessionOwned is returned when a caller posts to a session another
+// principal owns.
+var errSessionOwned = errors.New("session belongs to another caller")
+
+// sessionOwners records the principal that owns each session, so nobody
+// can chat in or search another caller's history. Claims are written to a
+// JSON file so they survive restarts. Without -auth every caller is the
+// anonymous principal and may use any session.
+type sessionOwners struct {
+	mu      sync.Mutex
+	enforce bool
+	path    string
+	owner   map[string]string // session -> principal
+	// reserved reports sessions kept for webhook runs, which /chat and
+	// /stream callers may not claim.
+	reserved func(session string) bool
+}
+
+func newSessionOwners(enforce bool, path string) (*sessionOwners, error) {
+	o := &sessionOwners{enforce: enforce, path: path, owner: map[string]string{}}
+	if !enforce || path == "" {
+		return o, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &o.owner); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// claim makes the caller the owner of session unless another principal
+// already is.
+func (o *sessionOwners) claim(ctx context.Context, session string) error {
+	if !o.enforce {
+		return nil
+	}
+	principal := callerPrincipal(ctx)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if owner, ok := o.owner[session]; ok {
+		if owner != principal {
+			return errSessionOwned
+		}
+		return nil
+	}
+	o.owner[session] = principal
+	if err := o.save(); err != nil {
+		delete(o.owner, session)
+		return fmt.Errorf("record session owner: %w", err)
+	}
+	return nil
+}
+
+// save writes the claims through a temporary file so a crash never leaves
+// a truncated file behind. The caller holds o.mu.
+func (o *sessionOwners) save() error {
+	if o.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(o.owner, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, o.path)
+}
+
+func (o *sessionOwners) owns(ctx context.Context, session string) bool {
+	if !o.enforce {
+		return true
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	owner, ok := o.owner[session]
+	return ok && owner == callerPrincipal(ctx)
+}
+
+// list returns the caller's sessions, sorted.
+func (o *sessionOwners) list(ctx context.Context) []string {
+	principal := callerPrincipal(ctx)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var out []string
+	for s, owner := range o.owner {
+		if owner == principal {
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// withArtifactOwner lets the caller read /artifacts/{session}/... only for
+// sessions they own. The session is cut from the escaped path and then
+// unescaped, as artifacts.Handler routes it, so "gh:org%2Frepo" is checked
+// as the session "gh:org/repo" it serves.
+func withArtifactOwner(owners *sessionOwners, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/artifacts/"), "/")
+		session, err := url.PathUnescape(segment)
+		if err != nil || !owners.owns(r.Context(), session) {
+			writeError(w, http.StatusNotFound, "unknown session")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// claimSession claims session for the caller, answering the request itself
+// when that fails.
+func claimSession(w http.ResponseWriter, r *http.Request, owners *sessionOwners, session string) bool {
+	if owners.enforce && owners.reserved != nil && owners.reserved(session) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("session %q is reserved for webhook runs", session))
+		return false
+	}
+	err := owners.claim(r.Context(), session)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errSessionOwned):
+		writeError(w, http.StatusForbidden, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+	return false
+}
+
+func callerPrincipal(ctx context.Context) string {
+	id, _ := memory.IdentityFromContext(ctx)
+	return strings.ToLower(id.Principal)
+}
+
+func handleSessionSearch(mem *memory.SessionMemory, owners *sessionOwners) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session := r.PathValue("session")
+		if !owners.owns(r.Context(), session) {
+			writeError(w, http.StatusNotFound, "unknown session")
+			return
+		}
+		runSearch(w, r, mem, []string{session})
+	}
+}
+
+func handleSearch(mem *memory.SessionMemory, owners *sessionOwners) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var sessions []string
+		if raw := r.URL.Query().Get("sessions"); raw != "" {
+			for _, s := range strings.Split(raw, ",") {
+				if s = strings.TrimSpace(s); s != "" && owners.owns(r.Context(), s) {
+					sessions = append(sessions, s)
+				}
 			}
+		} else if owners.enforce {
+			sessions = owners.list(r.Context())
+		} else {
+			// Without -auth every caller is anonymous, so there are no
+			// sessions of their own to default to.
+			writeError(w, http.StatusBadRequest, "sessions is required without -auth")
+			return
+		}
+		runSearch(w, r, mem, sessions)
+	}
+}
+
+func runSearch(w http.ResponseWriter, r *http.Request, mem *memory.SessionMemory, sessions []string) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	k := 10
+	if raw := r.URL.Query().Get("k"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxSearchResults {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("k must be between 1 and %d", maxSearchResults))
+			return
+		}
+		k = n
+	}
+	hits, err := mem.Search(r.Context(), query, k, sessions...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := searchResponse{Query: query, Results: make([]searchResult, len(hits))}
+	for i, h := range hits {
+		var meta struct {
+			Role string `json:"role"`
 		}
+		_ = json.Unmarshal([]byte(h.Metadata), &meta)
+		resp.Results[i] = searchResult{ID: h.ID, Session: h.SessionID, Role: meta.Role, Content: h.Content, Score: h.Score, CreatedAt: h.CreatedAt}
 	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func handleHealth(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
+// handleManifest publishes the agent's capabilities for discovery.
+func handleManifest(ag *agent.Agent) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, ag.Manifest())
+	}
+}
+
 func validateRequest(req chatRequest) error {
 	if strings.TrimSpace(req.Session) == "" {
 		return errors.New("session is required")
 	}
+	if strings.HasPrefix(req.Session, a2a.SessionID("")) {
+		return errors.New("sessions starting with " + a2a.SessionID("") + " are reserved for A2A contexts")
+	}
 	if strings.TrimSpace(req.Message) == "" {
 		return errors.New("message is required")
 	}
@@ -208,6 +689,44 @@ func withTimeout(d time.Duration, h http.Handler) http.Handler {
 	})
 }
 
+// loadIdentities reads the -auth token file; no file means no auth.
+func loadIdentities(path string) (map[string]memory.Identity, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var identities map[string]memory.Identity
+	if err := json.Unmarshal(raw, &identities); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for token, id := range identities {
+		if token == "" || strings.TrimSpace(id.Principal) == "" {
+			return nil, fmt.Errorf("parse %s: every token needs a principal", path)
+		}
+	}
+	return identities, nil
+}
+
+// withIdentity authenticates the bearer token and runs h as its identity.
+func withIdentity(identities map[string]memory.Identity, h http.Handler) http.Handler {
+	if identities == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		id, known := identities[strings.TrimSpace(token)]
+		if !ok || !known {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(memory.ContextWithIdentity(r.Context(), id)))
+	})
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
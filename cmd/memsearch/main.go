@@ -0,0 +1,280 @@
+// cmd/memsearch — query agent memory from the command line.
+//
+// The query runs through the same engine retrieval the agent uses, so the
+// hits are what the agent would see. Each hit is printed with its score
+// breakdown (similarity, keywords, importance, recency, source and their
+// weights), its graph edges and, on stores that keep a graph, its
+// neighbours.
+//
+// Search one or more sessions or shared spaces with -session and -space.
+// -source, -tag, -since and -until narrow the search; Qdrant and the
+// in-memory store apply them server-side through Engine.RetrieveFiltered,
+// other stores filter each session's hits. With -graph the tool writes the memory
+// graph of those sessions and spaces (every record when neither is set) as
+// DOT, GraphML or JSON instead of searching. Everything runs in the
+// -tenant tenant.
+//
+// Examples:
+//
+//	go run ./cmd/memsearch -snapshot memory.jsonl -session alice "deploy key"
+//	go run ./cmd/memsearch -store postgres -dsn postgres://... -space team:eng -since 72h -k 5 outage
+//	go run ./cmd/memsearch -store qdrant -space team:eng,team:design -source slack,notion -json roadmap
+//	go run ./cmd/memsearch -snapshot memory.jsonl -space 'team:*' -graph dot | dot -Tsvg > memory.svg
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/memory/engine"
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+)
+
+var (
+	flagStore            = flag.String("store", "memory", "Memory store: memory|postgres|qdrant|mongo")
+	flagSnapshot         = flag.String("snapshot", "", "Load an Engine.Export snapshot into the in-memory store first")
+	flagDSN              = flag.String("dsn", os.Getenv("DATABASE_URL"), "Postgres connection string (store=postgres)")
+	flagQdrantURL        = flag.String("qdrant-url", "http://localhost:6333", "Qdrant base URL (store=qdrant)")
+	flagQdrantCollection = flag.String("qdrant-collection", "adk_memories", "Qdrant collection name (store=qdrant)")
+	flagMongoURI         = flag.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI (store=mongo)")
+	flagMongoDatabase    = flag.String("mongo-db", "adk", "MongoDB database (store=mongo)")
+	flagMongoCollection  = flag.String("mongo-collection", "memories", "MongoDB collection (store=mongo)")
+	flagSession          = flag.String("session", "", "Comma-separated sessions to search")
+	flagSpace            = flag.String("space", "", "Comma-separated shared spaces to search")
+	flagSource           = flag.String("source", "", "Comma-separated sources to keep, e.g. slack,notion")
+	flagTag              = flag.String("tag", "", "Comma-separated tags; keep memories carrying any of them")
+	flagSince            = flag.String("since", "", "Keep memories created after this time (RFC 3339, or a duration ago such as 72h)")
+	flagUntil            = flag.String("until", "", "Keep memories created before this time (RFC 3339, or a duration ago)")
+	flagK                = flag.Int("k", 10, "Number of hits to print")
+	flagNeighbors        = flag.Int("neighbors", 3, "Graph neighbours to print per hit; 0 disables")
+	flagJSON             = flag.Bool("json", false, "Print hits as JSON")
+	flagGraph            = flag.String("graph", "", "Write the memory graph instead of searching: dot|graphml|json")
+	flagTimeout          = flag.Duration("timeout", 30*time.Second, "Overall search timeout")
+	flagTenant           = flag.String("tenant", "", "Tenant to search, export and load snapshots into; empty is the default tenant")
+)
+
+type hit struct {
+	Scope     string                `json:"scope"`
+	Record    model.MemoryRecord    `json:"record"`
+	Breakdown engine.ScoreBreakdown `json:"breakdown"`
+	Neighbors []model.MemoryRecord  `json:"neighbors,omitempty"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "memsearch:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, cancel := context.WithTimeout(memory.ContextWithTenant(context.Background(), *flagTenant), *flagTimeout)
+	defer cancel()
+	if *flagGraph != "" {
+		_, eng, err := openEngine(ctx)
+		if err != nil {
+			return err
+		}
+		return eng.ExportGraph(ctx, os.Stdout, memory.GraphExportOptions{
+			Format:   memory.GraphFormat(strings.ToLower(*flagGraph)),
+			Sessions: splitList(*flagSession),
+			Spaces:   splitList(*flagSpace),
+		})
+	}
+
+	query := strings.TrimSpace(strings.Join(flag.Args(), " "))
+	if query == "" {
+		return errors.New("pass a query, e.g. memsearch -session alice \"deploy key\"")
+	}
+	if *flagSession == "" && *flagSpace == "" {
+		return errors.New("pass -session or -space")
+	}
+	if *flagK <= 0 {
+		return errors.New("-k must be positive")
+	}
+	f, err := parseFilter()
+	if err != nil {
+		return err
+	}
+	vs, eng, err := openEngine(ctx)
+	if err != nil {
+		return err
+	}
+
+	records, err := eng.RetrieveFiltered(ctx, query, f, *flagK)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	hits := make([]hit, len(records))
+	for i, rec := range records {
+		scope := rec.Space
+		if scope == "" {
+			scope = rec.SessionID
+		}
+		hits[i] = hit{Scope: scope, Record: rec, Breakdown: eng.Breakdown(scope, rec)}
+	}
+	if gs, ok := vs.(store.GraphStore); ok && *flagNeighbors > 0 {
+		for i := range hits {
+			if hits[i].Record.ID == 0 {
+				continue
+			}
+			nbs, err := gs.Neighborhood(ctx, hits[i].Scope, []int64{hits[i].Record.ID}, 1, *flagNeighbors)
+			if err != nil {
+				return fmt.Errorf("neighbours of %d: %w", hits[i].Record.ID, err)
+			}
+			hits[i].Neighbors = nbs
+		}
+	}
+
+	if *flagJSON {
+		for i := range hits {
+			hits[i].Record.Embedding = nil
+			hits[i].Record.EmbeddingMatrix = nil
+			for j := range hits[i].Neighbors {
+				hits[i].Neighbors[j].Embedding = nil
+				hits[i].Neighbors[j].EmbeddingMatrix = nil
+			}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(hits)
+	}
+	if len(hits) == 0 {
+		fmt.Println("no matching memories")
+		return nil
+	}
+	for i, h := range hits {
+		printHit(i+1, h)
+	}
+	return nil
+}
+
+// openEngine opens the store and loads -snapshot into it.
+func openEngine(ctx context.Context) (memory.VectorStore, *memory.Engine, error) {
+	vs, err := openStore(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	eng := memory.NewEngine(vs, memory.DefaultOptions()).WithEmbedder(memory.AutoEmbedder())
+	if *flagSnapshot != "" {
+		if err := importSnapshot(ctx, eng, *flagSnapshot); err != nil {
+			return nil, nil, err
+		}
+	}
+	return vs, eng, nil
+}
+
+func openStore(ctx context.Context) (memory.VectorStore, error) {
+	if *flagSnapshot != "" && !strings.EqualFold(*flagStore, "memory") {
+		return nil, errors.New("-snapshot needs -store memory")
+	}
+	switch strings.ToLower(*flagStore) {
+	case "memory", "inmemory", "":
+		return memory.NewInMemoryStore(), nil
+	case "postgres":
+		if *flagDSN == "" {
+			return nil, errors.New("-store postgres needs -dsn or DATABASE_URL")
+		}
+		return memory.NewPostgresStore(ctx, *flagDSN)
+	case "qdrant":
+		return memory.NewQdrantStore(*flagQdrantURL, *flagQdrantCollection, secrets.Lookup("QDRANT_API_KEY")), nil
+	case "mongo", "mongodb":
+		return memory.NewMongoStore(ctx, *flagMongoURI, *flagMongoDatabase, *flagMongoCollection)
+	}
+	return nil, fmt.Errorf("unknown store %q", *flagStore)
+}
+
+func importSnapshot(ctx context.Context, eng *memory.Engine, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := eng.Import(ctx, f); err != nil {
+		return fmt.Errorf("import %s: %w", path, err)
+	}
+	return nil
+}
+
+func parseFilter() (model.Filter, error) {
+	f := model.Filter{
+		Sessions: splitList(*flagSession),
+		Spaces:   splitList(*flagSpace),
+		Sources:  splitList(*flagSource),
+		Tags:     splitList(*flagTag),
+	}
+	var err error
+	if f.Since, err = parseTime(*flagSince); err != nil {
+		return f, fmt.Errorf("-since: %w", err)
+	}
+	if f.Until, err = parseTime(*flagUntil); err != nil {
+		return f, fmt.Errorf("-until: %w", err)
+	}
+	return f, nil
+}
+
+// parseTime accepts RFC 3339 or a duration before now.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func printHit(n int, h hit) {
+	rec, b := h.Record, h.Breakdown
+	fmt.Printf("%d. [%.3f] #%d  %s", n, b.Total, rec.ID, h.Scope)
+	if rec.Source != "" {
+		fmt.Printf("  %s", rec.Source)
+	}
+	if !rec.CreatedAt.IsZero() {
+		fmt.Printf("  %s", rec.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Println()
+	fmt.Printf("   %s\n", snippet(rec.Content, 200))
+	w := b.Weights
+	fmt.Printf("   similarity %.2f×%.2f  keywords %.2f×%.2f  importance %.2f×%.2f  recency %.2f×%.2f  source %.2f×%.2f\n",
+		b.Similarity, w.Similarity, b.Keywords, w.Keywords, b.Importance, w.Importance, b.Recency, w.Recency, b.Source, w.Source)
+	if len(rec.GraphEdges) > 0 {
+		edges := make([]string, len(rec.GraphEdges))
+		for i, e := range rec.GraphEdges {
+			edges[i] = fmt.Sprintf("%s→#%d", e.Type, e.Target)
+		}
+		fmt.Printf("   edges: %s\n", strings.Join(edges, ", "))
+	}
+	for _, nb := range h.Neighbors {
+		fmt.Printf("   ↳ #%d %s\n", nb.ID, snippet(nb.Content, 120))
+	}
+	fmt.Println()
+}
+
+func snippet(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
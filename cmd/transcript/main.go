@@ -0,0 +1,101 @@
+// cmd/transcript — render agent session transcripts as shareable reports.
+//
+// A transcript is read from a JSON file (as written by transcript.Write with
+// format json) or fetched from a running gateway, and rendered as Markdown
+// or HTML with tool-call appendices, citations and timings.
+//
+// Examples:
+//
+//	go run ./cmd/transcript -in run.json -format html -o run.html
+//	go run ./cmd/transcript -gateway http://localhost:8080 -session alice
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/transcript"
+)
+
+var (
+	flagIn      = flag.String("in", "", "Transcript JSON file")
+	flagGateway = flag.String("gateway", "", "Gateway base URL to fetch the transcript from")
+	flagSession = flag.String("session", "", "Session to fetch from the gateway")
+	flagFormat  = flag.String("format", "markdown", "Output format: markdown|html|json")
+	flagOut     = flag.String("o", "", "Write the report to this file instead of stdout")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "transcript:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		t   transcript.Transcript
+		err error
+	)
+	switch {
+	case *flagIn != "":
+		t, err = readFile(*flagIn)
+	case *flagGateway != "" && *flagSession != "":
+		t, err = fetch(*flagGateway, *flagSession)
+	default:
+		return fmt.Errorf("pass -in FILE, or -gateway URL with -session ID")
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *flagOut != "" {
+		f, err := os.Create(*flagOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return transcript.Write(w, t, *flagFormat)
+}
+
+func readFile(path string) (transcript.Transcript, error) {
+	var t transcript.Transcript
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return t, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func fetch(gateway, session string) (transcript.Transcript, error) {
+	var t transcript.Transcript
+	u, err := url.JoinPath(gateway, "sessions", url.PathEscape(session), "transcript")
+	if err != nil {
+		return t, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(u + "?format=json")
+	if err != nil {
+		return t, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return t, fmt.Errorf("fetch %s: %s: %s", u, resp.Status, msg)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&t)
+	return t, err
+}
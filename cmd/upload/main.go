@@ -0,0 +1,340 @@
+// cmd/upload — ingest documents into long-term agent memory.
+//
+// Each file is converted to text (plain text, Markdown, HTML, JSON and PDF are
+// supported out of the box), chunked and stored under the given session.
+// PDFs use a pure-Go extractor by default; build with -tags=pdf to prefer
+// poppler's pdftotext when it is installed.
+//
+// With -source the tool syncs an S3 or GCS bucket prefix, a Confluence space,
+// a Notion workspace or a Google Drive folder instead of local files. Objects whose ETag is unchanged since the last run (tracked in
+// -sync-state) are skipped; Drive is followed through its changes API after
+// the first pass. -sync-interval keeps syncing on a schedule. Each object's
+// source permissions are stamped on its chunks as an ACL, and the space
+// grants they imply are written to -grants-file after every pass.
+//
+// With -bulk the arguments may also be directories or globs, and files are
+// ingested by -workers concurrent workers. A file that fails does not stop
+// the job. Finished files are recorded in -progress, so rerunning the same
+// command resumes where an interrupted run stopped. Throughput is printed
+// every -stats-interval, and -timeout bounds each file rather than the job.
+//
+// Examples:
+//
+//	go run ./cmd/upload -session docs handbook.pdf notes.md
+//	go run ./cmd/upload -store postgres -dsn postgres://... -session docs guide.html
+//	go run ./cmd/upload -store qdrant -qdrant-url http://localhost:6333 -session docs report.pdf
+//	go run ./cmd/upload -bulk -workers 8 -include '*.md' -progress docs.progress.json -session docs ./handbook
+//	go run ./cmd/upload -source s3://handbook/policies/ -sync-state sync.json -sync-interval 1h
+//	go run ./cmd/upload -source confluence://ENG -session team:eng -sync-state eng.json
+//	go run ./cmd/upload -source gdrive://1AbCdEfFolderID -space-prefix team: -sync-state drive.json -sync-interval 15m
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+	"github.com/Protocol-Lattice/go-agent/src/uploads"
+)
+
+var (
+	flagSession          = flag.String("session", "default", "Session ID the documents are stored under")
+	flagStore            = flag.String("store", "memory", "Memory store: memory|postgres|qdrant")
+	flagDSN              = flag.String("dsn", "", "Postgres connection string (store=postgres)")
+	flagQdrantURL        = flag.String("qdrant-url", "http://localhost:6333", "Qdrant base URL (store=qdrant)")
+	flagQdrantCollection = flag.String("qdrant-collection", "adk_memories", "Qdrant collection name (store=qdrant)")
+	flagSchema           = flag.String("schema", "", "Optional schema file (SQL for postgres, JSON for qdrant)")
+	flagPGIndex          = flag.String("pg-index", "", "Postgres vector index: ivfflat|hnsw|none (default ivfflat)")
+	flagChunkSize        = flag.Int("chunk-size", 2000, "Approximate chunk size in characters (tokens for -chunk-boundary=token)")
+	flagChunkOverlap     = flag.Int("chunk-overlap", 0, "Characters (or tokens) repeated between consecutive chunks")
+	flagChunkBoundary    = flag.String("chunk-boundary", "line", "Chunk boundary: line|sentence|paragraph|token")
+	flagJSON             = flag.Bool("json", false, "Print per-file results as JSON")
+	flagTimeout          = flag.Duration("timeout", 5*time.Minute, "Overall ingest timeout (per sync pass with -source)")
+	flagSource           = flag.String("source", "", "Sync a source instead of files: s3://bucket/prefix, gs://bucket/prefix, confluence://SPACE, notion:// or gdrive://FOLDER")
+	flagSyncState        = flag.String("sync-state", "", "File recording synced ETags (default: in-memory, full sync each run)")
+	flagSyncInterval     = flag.Duration("sync-interval", 0, "Repeat the sync at this interval (requires -source)")
+	flagSpacePrefix      = flag.String("space-prefix", "", "With gdrive://, store each top-level folder in the space <prefix><folder name>")
+	flagGrantsFile       = flag.String("grants-file", "", "With -source, write the space grants mirrored from source permissions to this JSON file")
+	flagBulk             = flag.Bool("bulk", false, "Ingest directories and globs as a resumable job with concurrent workers")
+	flagWorkers          = flag.Int("workers", uploads.DefaultBulkWorkers, "Files ingested at once (with -bulk)")
+	flagProgress         = flag.String("progress", "", "File recording finished files so an interrupted -bulk run resumes")
+	flagInclude          = flag.String("include", "", "With -bulk, only ingest files in directories whose name matches this glob")
+	flagEmbeddingPrice   = flag.Float64("embedding-price", 0, "Embedder price per million input tokens, for the cost estimate in the report")
+	flagStatsInterval    = flag.Duration("stats-interval", 10*time.Second, "How often -bulk prints throughput; 0 disables")
+)
+
+func main() {
+	flag.Parse()
+	if *flagSource != "" {
+		runSync()
+		return
+	}
+	if flag.NArg() == 0 {
+		fail(errors.New("no files provided"))
+	}
+	if *flagBulk {
+		runBulk()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *flagTimeout)
+	defer cancel()
+
+	mem, err := buildMemory(ctx)
+	if err != nil {
+		fail(err)
+	}
+
+	docs := make([]uploads.Document, 0, flag.NArg())
+	for _, path := range flag.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fail(fmt.Errorf("read %s: %w", path, err))
+		}
+		gitMeta, err := uploads.GitMetadata(ctx, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: git metadata for %s: %v\n", path, err)
+		}
+		docs = append(docs, uploads.Document{
+			File:     models.File{Name: filepath.Base(path), Data: data},
+			Metadata: gitMeta,
+		})
+	}
+
+	results, err := newPipeline(mem).IngestDocuments(ctx, *flagSession, docs...)
+	report(results)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func newPipeline(mem *memory.SessionMemory) *uploads.Pipeline {
+	boundary, ok := uploads.ParseBoundary(*flagChunkBoundary)
+	if !ok {
+		fail(fmt.Errorf("unknown chunk boundary: %s", *flagChunkBoundary))
+	}
+	pipeline := uploads.NewPipeline(mem)
+	pipeline.Options.Chunking = uploads.ChunkOptions{
+		Size:     *flagChunkSize,
+		Overlap:  *flagChunkOverlap,
+		Boundary: boundary,
+	}
+	pipeline.Options.EmbeddingPricePerMillion = *flagEmbeddingPrice
+	return pipeline
+}
+
+func runSync() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	mem, err := buildMemory(ctx)
+	if err != nil {
+		fail(err)
+	}
+	source, err := uploads.OpenSource(ctx, *flagSource)
+	if err != nil {
+		fail(err)
+	}
+	if drive, ok := source.(*uploads.DriveSource); ok {
+		drive.SpacePrefix = *flagSpacePrefix
+	}
+	spaces := memory.NewSpaceRegistry(0)
+	syncer := &uploads.Syncer{Source: source, Pipeline: newPipeline(mem), SessionID: *flagSession, Spaces: spaces}
+	if *flagSyncState != "" {
+		syncer.State = uploads.FileSyncState{Path: *flagSyncState}
+	}
+
+	pass := func() error {
+		passCtx, cancel := context.WithTimeout(ctx, *flagTimeout)
+		defer cancel()
+		rep, err := syncer.Sync(passCtx)
+		reportSync(rep)
+		if err == nil && *flagGrantsFile != "" {
+			err = writeGrants(*flagGrantsFile, spaces)
+		}
+		return err
+	}
+	if *flagSyncInterval <= 0 {
+		if err := pass(); err != nil {
+			fail(err)
+		}
+		return
+	}
+	ticker := time.NewTicker(*flagSyncInterval)
+	defer ticker.Stop()
+	for {
+		if err := pass(); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeGrants records the grants mirrored onto each space as
+// {"space": {"principal": "role"}}.
+func writeGrants(path string, spaces *memory.SpaceRegistry) error {
+	grants := map[string]map[string]memory.SpaceRole{}
+	for _, space := range spaces.Spaces() {
+		if len(space.ACL) > 0 {
+			grants[space.Name] = space.ACL
+		}
+	}
+	data, err := json.MarshalIndent(grants, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func runBulk() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	paths, err := uploads.ExpandPaths(flag.Args(), *flagInclude)
+	if err != nil {
+		fail(err)
+	}
+	mem, err := buildMemory(ctx)
+	if err != nil {
+		fail(err)
+	}
+	job := &uploads.BulkJob{
+		Pipeline:    newPipeline(mem),
+		SessionID:   *flagSession,
+		Workers:     *flagWorkers,
+		Metadata:    uploads.GitMetadata,
+		FileTimeout: *flagTimeout,
+	}
+	if *flagProgress != "" {
+		job.Progress = uploads.FileSyncState{Path: *flagProgress}
+	}
+
+	if *flagStatsInterval > 0 {
+		ticker := time.NewTicker(*flagStatsInterval)
+		defer ticker.Stop()
+		go func() {
+			for range ticker.C {
+				printBulkStatus(job.Status())
+			}
+		}()
+	}
+	rep, err := job.Run(ctx, paths)
+	if *flagJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(rep)
+	} else {
+		report(rep.Ingested)
+		for path, reason := range rep.Failures {
+			fmt.Fprintf(os.Stderr, "failed %s: %s\n", path, reason)
+		}
+		printBulkStatus(rep.BulkStatus)
+	}
+	if err != nil {
+		fail(err)
+	}
+	if len(rep.Failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printBulkStatus(s uploads.BulkStatus) {
+	fmt.Fprintf(os.Stderr, "%d/%d files ingested, %d already done, %d failed, %d chunks in %s (%.1f files/s, %.1f KiB/s)\n",
+		s.Done, s.Total, s.Skipped, s.Failed, s.Chunks, s.Elapsed.Round(time.Second), s.FilesPerSecond, s.BytesPerSecond/1024)
+}
+
+func reportSync(rep uploads.SyncReport) {
+	if *flagJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(rep)
+		return
+	}
+	report(rep.Ingested)
+	for _, uri := range rep.Removed {
+		fmt.Printf("%s\tremoved\n", uri)
+	}
+	for uri, reason := range rep.Skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s: %s\n", uri, reason)
+	}
+	fmt.Printf("synced %s: %d listed, %d unchanged, %d ingested, %d removed\n",
+		*flagSource, rep.Listed, rep.Unchanged, len(rep.Ingested), len(rep.Removed))
+}
+
+func buildMemory(ctx context.Context) (*memory.SessionMemory, error) {
+	var bank *memory.MemoryBank
+	switch strings.ToLower(*flagStore) {
+	case "memory":
+		bank = memory.NewMemoryBankWithStore(memory.NewInMemoryStore())
+	case "postgres":
+		if *flagDSN == "" {
+			return nil, errors.New("-dsn is required for store=postgres")
+		}
+		store, err := memory.NewPostgresStore(ctx, *flagDSN)
+		if err != nil {
+			return nil, err
+		}
+		store.Index = memory.PostgresIndex{Kind: *flagPGIndex}
+		bank = memory.NewMemoryBankWithStore(store)
+	case "qdrant":
+		bank = memory.NewMemoryBankWithStore(memory.NewQdrantStore(*flagQdrantURL, *flagQdrantCollection, secrets.Lookup("QDRANT_API_KEY")))
+	default:
+		return nil, fmt.Errorf("unknown store: %s", *flagStore)
+	}
+	// Postgres falls back to its built-in schema; Qdrant needs an explicit file.
+	if *flagSchema != "" || strings.EqualFold(*flagStore, "postgres") {
+		if err := bank.CreateSchema(ctx, *flagSchema); err != nil {
+			return nil, fmt.Errorf("create schema: %w", err)
+		}
+	}
+	return memory.NewSessionMemory(bank, 0), nil
+}
+
+func report(results []uploads.Result) {
+	if *flagJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(results)
+		return
+	}
+	var tokens int64
+	var cost float64
+	for _, r := range results {
+		fmt.Printf("%s\t%s\t%d chunks\t%d chars\t~%d tokens\t%s", r.Name, r.MIME, r.Chunks, r.Chars, r.EmbeddingTokens, r.Timings.Total().Round(time.Millisecond))
+		if r.Metadata.Title != "" {
+			fmt.Printf("\t%q", r.Metadata.Title)
+		}
+		fmt.Println()
+		for _, w := range r.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", r.Name, w)
+		}
+		tokens += r.EmbeddingTokens
+		cost += r.EstimatedCost
+	}
+	if len(results) > 1 || cost > 0 {
+		fmt.Printf("total\t~%d embedding tokens", tokens)
+		if cost > 0 {
+			fmt.Printf("\testimated cost %.4f", cost)
+		}
+		fmt.Println()
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
@@ -8,7 +8,7 @@ import (
 // Save stores a conversation turn into all shared spaces.
 // role should be "user" or "agent".
 func (agent *Agent) Save(ctx context.Context, role, content string) {
-	if agent.Shared == nil || strings.TrimSpace(content) == "" {
+	if agent.ReadOnly || agent.Shared == nil || strings.TrimSpace(content) == "" {
 		return
 	}
 	meta := map[string]string{"role": role}
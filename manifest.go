@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/flags"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// ManifestPath is where servers publish Agent.Manifest for discovery.
+const ManifestPath = "/.well-known/agent.json"
+
+// Manifest is a machine-readable description of an agent's capabilities,
+// letting other agents and orchestration layers decide what to delegate
+// before sending any work.
+type Manifest struct {
+	Name        string             `json:"name,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Model       ManifestModel      `json:"model"`
+	Tools       []ManifestTool     `json:"tools"`
+	SubAgents   []ManifestSubAgent `json:"subagents"`
+	// Spaces lists the shared memory spaces the agent has joined.
+	Spaces []string       `json:"spaces"`
+	Limits ManifestLimits `json:"limits"`
+}
+
+// ManifestModel identifies the language model behind the agent.
+type ManifestModel struct {
+	Provider string `json:"provider"`
+	Name     string `json:"name,omitempty"`
+}
+
+// ManifestTool describes one callable tool.
+type ManifestTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema,omitempty"`
+}
+
+// ManifestSubAgent describes one specialist the agent can delegate to.
+type ManifestSubAgent struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ManifestLimits reports the bounds the agent applies to each turn.
+type ManifestLimits struct {
+	ContextLimit     int           `json:"context_limit"`
+	ToolLoopMaxSteps int           `json:"tool_loop_max_steps"`
+	AutoDelegate     bool          `json:"auto_delegate"`
+	MaxDelegations   int           `json:"max_delegations,omitempty"`
+	SubAgentTimeout  time.Duration `json:"subagent_timeout_ns,omitempty"`
+}
+
+// Manifest describes the agent's tools, sub-agents, joined spaces, model and
+// limits as they are configured now.
+func (a *Agent) Manifest() Manifest {
+	autoDelegate := flags.Bool(context.Background(), a.Flags, flags.AutoDelegate, a.AutoDelegate)
+	m := Manifest{
+		Name:        a.Name,
+		Description: a.Description,
+		Model:       describeModel(a.model),
+		Tools:       []ManifestTool{},
+		SubAgents:   []ManifestSubAgent{},
+		Spaces:      []string{},
+		Limits: ManifestLimits{
+			ContextLimit:     a.contextLimit,
+			ToolLoopMaxSteps: configuredToolLoopMaxSteps(),
+			AutoDelegate:     autoDelegate,
+			SubAgentTimeout:  a.SubAgentTimeout,
+		},
+	}
+	if autoDelegate {
+		m.Limits.MaxDelegations = a.MaxDelegations
+		if m.Limits.MaxDelegations <= 0 {
+			m.Limits.MaxDelegations = defaultMaxDelegations
+		}
+	}
+
+	specs := a.ToolSpecs()
+	if a.CodeMode != nil {
+		specs = appendCodeModeToolSpec(specs)
+	}
+	for _, spec := range specs {
+		m.Tools = append(m.Tools, ManifestTool{
+			Name:        spec.Name,
+			Description: spec.Description,
+			InputSchema: spec.Inputs,
+		})
+	}
+	for _, sa := range a.SubAgents() {
+		m.SubAgents = append(m.SubAgents, ManifestSubAgent{
+			Name:        sa.Name(),
+			Description: strings.TrimSpace(sa.Description()),
+		})
+	}
+	if a.Shared != nil {
+		m.Spaces = append(m.Spaces, a.Shared.Spaces()...)
+		sort.Strings(m.Spaces)
+	}
+	return m
+}
+
+// describeModel names the provider and model ID of the built-in providers,
+// looking through caching wrappers. Other models report their Go type.
+func describeModel(model models.Agent) ManifestModel {
+	switch m := model.(type) {
+	case *models.CachedLLM:
+		return describeModel(m.Agent)
+	case *models.GeminiLLM:
+		return ManifestModel{Provider: "gemini", Name: m.Model}
+	case *models.VertexLLM:
+		return ManifestModel{Provider: "vertex", Name: m.Model}
+	case *models.OpenAILLM:
+		return ManifestModel{Provider: "openai", Name: m.Model}
+	case *models.AnthropicLLM:
+		return ManifestModel{Provider: "anthropic", Name: m.Model}
+	case *models.OllamaLLM:
+		return ManifestModel{Provider: "ollama", Name: m.Model}
+	case *models.OpenRouterLLM:
+		return ManifestModel{Provider: "openrouter", Name: m.Model}
+	case *models.DummyLLM:
+		return ManifestModel{Provider: "dummy"}
+	case nil:
+		return ManifestModel{}
+	default:
+		return ManifestModel{Provider: fmt.Sprintf("%T", model)}
+	}
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+func TestManifestDescribesCapabilities(t *testing.T) {
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 6)
+	a, err := New(Options{
+		Model:           models.NewDummyLLM(""),
+		Memory:          mem,
+		ContextLimit:    6,
+		Name:            "coordinator",
+		Description:     "answers finance questions",
+		Tools:           []Tool{&stubTool{spec: ToolSpec{Name: "echo", Description: "echoes input", InputSchema: map[string]any{"type": "object"}}}},
+		SubAgents:       []SubAgent{&countingSubAgent{}},
+		AutoDelegate:    true,
+		SubAgentTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := a.Manifest()
+	if m.Name != "coordinator" || m.Model.Provider != "dummy" {
+		t.Fatalf("identity = %q / %+v", m.Name, m.Model)
+	}
+	if len(m.Tools) != 1 || m.Tools[0].Name != "echo" || m.Tools[0].Description != "echoes input" {
+		t.Fatalf("tools = %+v", m.Tools)
+	}
+	if len(m.SubAgents) != 1 || m.SubAgents[0].Name != "analyst" {
+		t.Fatalf("subagents = %+v", m.SubAgents)
+	}
+	if m.Limits.ContextLimit != 6 || m.Limits.MaxDelegations != defaultMaxDelegations || m.Limits.SubAgentTimeout != time.Second {
+		t.Fatalf("limits = %+v", m.Limits)
+	}
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"tools", "subagents", "spaces", "model", "limits"} {
+		if _, ok := decoded[key]; !ok {
+			t.Fatalf("manifest JSON lacks %q: %s", key, raw)
+		}
+	}
+}
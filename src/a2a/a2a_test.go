@@ -0,0 +1,203 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// echoRunner answers with the session and input it was given.
+type echoRunner struct {
+	mu       sync.Mutex
+	sessions []string
+}
+
+func (r *echoRunner) Generate(_ context.Context, sessionID, input string) (any, error) {
+	r.mu.Lock()
+	r.sessions = append(r.sessions, sessionID)
+	r.mu.Unlock()
+	return "echo: " + input, nil
+}
+
+// chunkRunner streams its answer word by word.
+type chunkRunner struct{ echoRunner }
+
+func (r *chunkRunner) GenerateStream(_ context.Context, _, input string) (<-chan models.StreamChunk, error) {
+	words := strings.Fields("streamed reply to " + input)
+	ch := make(chan models.StreamChunk, len(words))
+	for i, w := range words {
+		if i > 0 {
+			w = " " + w
+		}
+		ch <- models.StreamChunk{Delta: w, Done: i == len(words)-1}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func newTestServer(t *testing.T, runner Runner) (*httptest.Server, *Server) {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	a2a := NewServer(runner, AgentCard{Name: "Echo Agent", Description: "Repeats things.", URL: srv.URL + "/a2a"})
+	mux.Handle(CardPath, a2a)
+	mux.Handle("/a2a", a2a)
+	return srv, a2a
+}
+
+func TestSendMessageCompletesTaskWithArtifact(t *testing.T) {
+	runner := &echoRunner{}
+	srv, _ := newTestServer(t, runner)
+	client := NewClient(srv.URL + "/a2a")
+	ctx := context.Background()
+
+	task, err := client.SendText(ctx, "", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status.State != TaskCompleted || task.Text() != "echo: hello" {
+		t.Fatalf("task = %+v", task)
+	}
+	if len(task.History) != 2 || task.History[0].Role != "user" || task.History[1].Role != "agent" {
+		t.Fatalf("history = %+v", task.History)
+	}
+
+	// Follow-ups in the same context share the agent session.
+	if _, err := client.SendText(ctx, task.ContextID, "again"); err != nil {
+		t.Fatal(err)
+	}
+	if runner.sessions[0] != SessionID(task.ContextID) || runner.sessions[1] != runner.sessions[0] {
+		t.Fatalf("sessions = %v", runner.sessions)
+	}
+
+	got, err := client.GetTask(ctx, task.ID)
+	if err != nil || got.ID != task.ID || got.Status.State != TaskCompleted {
+		t.Fatalf("tasks/get = %+v, %v", got, err)
+	}
+	if _, err := client.CancelTask(ctx, task.ID); !isCode(err, CodeTaskNotCancelable) {
+		t.Fatalf("cancelling a finished task: %v", err)
+	}
+	if _, err := client.GetTask(ctx, "missing"); !isCode(err, CodeTaskNotFound) {
+		t.Fatalf("tasks/get of unknown task: %v", err)
+	}
+}
+
+func TestStreamMessageSendsArtifactChunks(t *testing.T) {
+	srv, server := newTestServer(t, &chunkRunner{})
+	if !server.Card.Capabilities.Streaming {
+		t.Fatal("a streaming runner should advertise streaming")
+	}
+	client := NewClient(srv.URL + "/a2a")
+
+	var kinds []string
+	task, err := client.StreamMessage(context.Background(), Message{Kind: "message", Role: "user", Parts: []Part{TextPart("ping")}}, func(ev any) {
+		switch ev := ev.(type) {
+		case Task:
+			kinds = append(kinds, "task")
+		case TaskStatusUpdateEvent:
+			kinds = append(kinds, string(ev.Status.State))
+		case TaskArtifactUpdateEvent:
+			kinds = append(kinds, "artifact")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status.State != TaskCompleted || task.Text() != "streamed reply to ping" {
+		t.Fatalf("task = %+v", task)
+	}
+	if got := strings.Join(kinds, ","); got != "task,working,artifact,artifact,artifact,artifact,completed" {
+		t.Fatalf("events = %s", got)
+	}
+}
+
+func TestContextsAndTasksBelongToTheirCaller(t *testing.T) {
+	a2a := NewServer(&echoRunner{}, AgentCard{Name: "Echo Agent"})
+	var claimed []string
+	a2a.Claim = func(ctx context.Context, sessionID string) error {
+		claimed = append(claimed, sessionID)
+		return nil
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := memory.Identity{Principal: r.Header.Get("X-Principal")}
+		a2a.ServeHTTP(w, r.WithContext(memory.ContextWithIdentity(r.Context(), id)))
+	}))
+	t.Cleanup(srv.Close)
+	as := func(principal string) *Client {
+		c := NewClient(srv.URL)
+		c.HTTPClient = &http.Client{Transport: headerTransport{"X-Principal": principal}}
+		return c
+	}
+	alice, mallory := as("alice"), as("mallory")
+	ctx := context.Background()
+
+	task, err := alice.SendText(ctx, "", "secret plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0] != SessionID(task.ContextID) {
+		t.Fatalf("claimed = %v", claimed)
+	}
+	if _, err := mallory.SendText(ctx, task.ContextID, "what was the plan?"); !isCode(err, CodeInvalidRequest) {
+		t.Fatalf("continuing another caller's context: %v", err)
+	}
+	if _, err := mallory.GetTask(ctx, task.ID); !isCode(err, CodeTaskNotFound) {
+		t.Fatalf("tasks/get of another caller's task: %v", err)
+	}
+	if _, err := mallory.CancelTask(ctx, task.ID); !isCode(err, CodeTaskNotFound) {
+		t.Fatalf("tasks/cancel of another caller's task: %v", err)
+	}
+	if _, err := alice.SendText(ctx, task.ContextID, "again"); err != nil {
+		t.Fatalf("owner follow-up: %v", err)
+	}
+}
+
+// headerTransport adds fixed headers to every request.
+type headerTransport map[string]string
+
+func (h headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	for k, v := range h {
+		r.Header.Set(k, v)
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestToolDiscoversAndCallsRemoteAgent(t *testing.T) {
+	runner := &echoRunner{}
+	srv, _ := newTestServer(t, runner)
+
+	tool, err := NewTool(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.Spec().Name != "a2a.echo_agent" || tool.Spec().Description != "Repeats things." {
+		t.Fatalf("spec = %+v", tool.Spec())
+	}
+	for range 2 {
+		resp, err := tool.Invoke(context.Background(), agent.ToolRequest{SessionID: "s1", Arguments: map[string]any{"message": "hi"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Content != "echo: hi" || resp.Metadata["state"] != string(TaskCompleted) {
+			t.Fatalf("response = %+v", resp)
+		}
+	}
+	if len(runner.sessions) != 2 || runner.sessions[0] != runner.sessions[1] {
+		t.Fatalf("one agent session should map to one A2A context: %v", runner.sessions)
+	}
+}
+
+func isCode(err error, code int) bool {
+	var rpcErr *Error
+	return errors.As(err, &rpcErr) && rpcErr.Code == code
+}
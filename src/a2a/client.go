@@ -0,0 +1,271 @@
+package a2a
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Client calls a remote A2A agent.
+type Client struct {
+	// URL is the agent's JSON-RPC endpoint, as published in its card.
+	URL        string
+	HTTPClient *http.Client
+
+	nextID atomic.Int64
+}
+
+// NewClient returns a client for the JSON-RPC endpoint at url.
+func NewClient(url string) *Client {
+	return &Client{URL: url, HTTPClient: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// FetchCard reads the AgentCard published under baseURL.
+func FetchCard(ctx context.Context, httpClient *http.Client, baseURL string) (AgentCard, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	u, err := url.JoinPath(baseURL, CardPath)
+	if err != nil {
+		return AgentCard{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return AgentCard{}, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return AgentCard{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return AgentCard{}, fmt.Errorf("a2a: fetch card: %s", resp.Status)
+	}
+	var card AgentCard
+	if err := json.NewDecoder(resp.Body).Decode(&card); err != nil {
+		return AgentCard{}, fmt.Errorf("a2a: decode card: %w", err)
+	}
+	return card, nil
+}
+
+// SendText sends text as a user message in contextID (empty starts a new
+// context) and waits for the resulting task.
+func (c *Client) SendText(ctx context.Context, contextID, text string) (Task, error) {
+	return c.SendMessage(ctx, Message{Kind: "message", MessageID: newID(), Role: "user", ContextID: contextID, Parts: []Part{TextPart(text)}})
+}
+
+// SendMessage calls message/send. Agents that reply with a bare message
+// instead of a task get it wrapped in a completed Task.
+func (c *Client) SendMessage(ctx context.Context, msg Message) (Task, error) {
+	raw, err := c.call(ctx, "message/send", MessageSendParams{Message: msg})
+	if err != nil {
+		return Task{}, err
+	}
+	return decodeTaskOrMessage(raw)
+}
+
+// StreamMessage calls message/stream and passes every event to onEvent:
+// a Task, TaskStatusUpdateEvent or TaskArtifactUpdateEvent. It returns the
+// task as assembled from the events once the stream ends.
+func (c *Client) StreamMessage(ctx context.Context, msg Message, onEvent func(any)) (Task, error) {
+	body, err := c.post(ctx, "message/stream", MessageSendParams{Message: msg})
+	if err != nil {
+		return Task{}, err
+	}
+	defer body.Close()
+
+	var task Task
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var resp rpcResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &resp); err != nil {
+			return task, fmt.Errorf("a2a: decode stream event: %w", err)
+		}
+		if resp.Error != nil {
+			return task, resp.Error
+		}
+		event, err := decodeEvent(resp.Result)
+		if err != nil {
+			return task, err
+		}
+		switch ev := event.(type) {
+		case Task:
+			task = ev
+		case TaskStatusUpdateEvent:
+			task.ID, task.ContextID, task.Status = ev.TaskID, ev.ContextID, ev.Status
+		case TaskArtifactUpdateEvent:
+			task.Artifacts = mergeArtifact(task.Artifacts, ev)
+		}
+		if onEvent != nil {
+			onEvent(event)
+		}
+	}
+	return task, scanner.Err()
+}
+
+// GetTask calls tasks/get.
+func (c *Client) GetTask(ctx context.Context, id string) (Task, error) {
+	raw, err := c.call(ctx, "tasks/get", TaskIDParams{ID: id})
+	if err != nil {
+		return Task{}, err
+	}
+	var task Task
+	err = json.Unmarshal(raw, &task)
+	return task, err
+}
+
+// CancelTask calls tasks/cancel.
+func (c *Client) CancelTask(ctx context.Context, id string) (Task, error) {
+	raw, err := c.call(ctx, "tasks/cancel", TaskIDParams{ID: id})
+	if err != nil {
+		return Task{}, err
+	}
+	var task Task
+	err = json.Unmarshal(raw, &task)
+	return task, err
+}
+
+func (c *Client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	body, err := c.post(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var resp rpcResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("a2a: decode %s response: %w", method, err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) post(ctx context.Context, method string, params any) (io.ReadCloser, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: rawParams})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("a2a: %s: %s: %s", method, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
+
+func decodeTaskOrMessage(raw json.RawMessage) (Task, error) {
+	event, err := decodeEvent(raw)
+	if err != nil {
+		return Task{}, err
+	}
+	switch ev := event.(type) {
+	case Task:
+		return ev, nil
+	case Message:
+		return Task{
+			Kind:      "task",
+			ID:        ev.TaskID,
+			ContextID: ev.ContextID,
+			Status:    TaskStatus{State: TaskCompleted, Message: &ev, Timestamp: time.Now().UTC()},
+		}, nil
+	default:
+		return Task{}, fmt.Errorf("a2a: unexpected %T result", event)
+	}
+}
+
+// decodeEvent decodes a result by its "kind" discriminator.
+func decodeEvent(raw json.RawMessage) (any, error) {
+	var head struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, fmt.Errorf("a2a: decode result: %w", err)
+	}
+	var (
+		event any
+		err   error
+	)
+	switch head.Kind {
+	case "task":
+		var t Task
+		err = json.Unmarshal(raw, &t)
+		event = t
+	case "message":
+		var m Message
+		err = json.Unmarshal(raw, &m)
+		event = m
+	case "status-update":
+		var ev TaskStatusUpdateEvent
+		err = json.Unmarshal(raw, &ev)
+		event = ev
+	case "artifact-update":
+		var ev TaskArtifactUpdateEvent
+		err = json.Unmarshal(raw, &ev)
+		event = ev
+	default:
+		return nil, fmt.Errorf("a2a: unknown result kind %q", head.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("a2a: decode %s: %w", head.Kind, err)
+	}
+	return event, nil
+}
+
+func mergeArtifact(artifacts []Artifact, ev TaskArtifactUpdateEvent) []Artifact {
+	for i := range artifacts {
+		if artifacts[i].ArtifactID != ev.Artifact.ArtifactID {
+			continue
+		}
+		if ev.Append {
+			artifacts[i].Parts = appendParts(artifacts[i].Parts, ev.Artifact.Parts)
+		} else {
+			artifacts[i] = ev.Artifact
+		}
+		return artifacts
+	}
+	return append(artifacts, ev.Artifact)
+}
+
+// appendParts extends streamed text in place so a chunked artifact reads as
+// one text part.
+func appendParts(parts, more []Part) []Part {
+	for _, p := range more {
+		if n := len(parts); n > 0 && p.Kind == "text" && parts[n-1].Kind == "text" {
+			parts[n-1].Text += p.Text
+			continue
+		}
+		parts = append(parts, p)
+	}
+	return parts
+}
@@ -0,0 +1,446 @@
+package a2a
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+const (
+	taskHistorySize = 4096
+	taskHistoryTTL  = 24 * time.Hour
+)
+
+// Runner is the part of *agent.Agent a Server needs.
+type Runner interface {
+	Generate(ctx context.Context, sessionID, userInput string) (any, error)
+}
+
+// StreamRunner is implemented by runners that stream, like *agent.Agent.
+// Server uses it for message/stream so artifacts arrive as they are
+// generated.
+type StreamRunner interface {
+	Runner
+	GenerateStream(ctx context.Context, sessionID, userInput string) (<-chan models.StreamChunk, error)
+}
+
+// Server exposes a Runner as an A2A agent. It answers JSON-RPC requests
+// posted to any path it is mounted on, and serves Card on GET requests, so
+// mounting it at CardPath as well makes the agent discoverable.
+//
+// Each A2A context maps to the agent session "a2a:<contextId>", so follow-up
+// messages in a context share memory. Contexts and tasks belong to the
+// principal on the context of the request that created them; other callers
+// can neither continue the context nor get or cancel its tasks.
+type Server struct {
+	Card AgentCard
+	// Claim, when set, is called with the agent session of every context a
+	// message is sent in, and the message is rejected if it fails. Use it
+	// to record session ownership beyond the server's own task history.
+	Claim func(ctx context.Context, sessionID string) error
+
+	runner   Runner
+	tasks    *cache.LRUCache // task ID -> *taskEntry
+	ctxMu    sync.Mutex
+	contexts *cache.LRUCache // context ID -> owning principal
+}
+
+type taskEntry struct {
+	mu     sync.Mutex
+	owner  string
+	task   Task
+	cancel context.CancelFunc
+}
+
+func (e *taskEntry) snapshot() Task {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t := e.task
+	t.Artifacts = append([]Artifact(nil), t.Artifacts...)
+	t.History = append([]Message(nil), t.History...)
+	return t
+}
+
+// NewServer serves runner under card. Card.Capabilities.Streaming is set
+// when runner is a StreamRunner.
+func NewServer(runner Runner, card AgentCard) *Server {
+	if card.ProtocolVersion == "" {
+		card.ProtocolVersion = ProtocolVersion
+	}
+	if len(card.DefaultInputModes) == 0 {
+		card.DefaultInputModes = []string{"text/plain"}
+	}
+	if len(card.DefaultOutputModes) == 0 {
+		card.DefaultOutputModes = []string{"text/plain"}
+	}
+	if card.Skills == nil {
+		card.Skills = []Skill{}
+	}
+	_, card.Capabilities.Streaming = runner.(StreamRunner)
+	return &Server{
+		Card:     card,
+		runner:   runner,
+		tasks:    cache.NewLRUCache(taskHistorySize, taskHistoryTTL),
+		contexts: cache.NewLRUCache(taskHistorySize, taskHistoryTTL),
+	}
+}
+
+// CardFromManifest builds an AgentCard for an agent served at url,
+// advertising its sub-agents and tools as skills.
+func CardFromManifest(m agent.Manifest, url string) AgentCard {
+	card := AgentCard{Name: m.Name, Description: m.Description, URL: url, Version: "1.0.0"}
+	for _, sa := range m.SubAgents {
+		card.Skills = append(card.Skills, Skill{ID: "subagent:" + sa.Name, Name: sa.Name, Description: sa.Description, Tags: []string{"subagent"}})
+	}
+	for _, tool := range m.Tools {
+		card.Skills = append(card.Skills, Skill{ID: "tool:" + tool.Name, Name: tool.Name, Description: tool.Description, Tags: []string{"tool"}})
+	}
+	return card
+}
+
+// SessionID is the agent session used for an A2A context.
+func SessionID(contextID string) string { return "a2a:" + contextID }
+
+// caller is the principal requests under ctx run as.
+func caller(ctx context.Context) string {
+	id, _ := memory.IdentityFromContext(ctx)
+	return strings.ToLower(id.Principal)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, s.Card)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPC(w, nil, nil, &Error{Code: CodeParseError, Message: "invalid JSON: " + err.Error()})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		writeRPC(w, req.ID, nil, &Error{Code: CodeInvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+		return
+	}
+
+	switch req.Method {
+	case "message/send":
+		var params MessageSendParams
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		task, rpcErr := s.send(r.Context(), params)
+		writeRPC(w, req.ID, task, rpcErr)
+	case "message/stream":
+		var params MessageSendParams
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		s.stream(w, r, req.ID, params)
+	case "tasks/get":
+		var params TaskIDParams
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		entry, rpcErr := s.lookup(r.Context(), params.ID)
+		if rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		writeRPC(w, req.ID, entry.snapshot(), nil)
+	case "tasks/cancel":
+		var params TaskIDParams
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			writeRPC(w, req.ID, nil, rpcErr)
+			return
+		}
+		task, rpcErr := s.cancel(r.Context(), params.ID)
+		writeRPC(w, req.ID, task, rpcErr)
+	default:
+		writeRPC(w, req.ID, nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method})
+	}
+}
+
+// lookup finds a task of the caller on ctx; other callers' tasks are
+// reported as not found.
+func (s *Server) lookup(ctx context.Context, id string) (*taskEntry, *Error) {
+	if v, ok := s.tasks.Get(id); ok && v.(*taskEntry).owner == caller(ctx) {
+		return v.(*taskEntry), nil
+	}
+	return nil, &Error{Code: CodeTaskNotFound, Message: fmt.Sprintf("task %q not found", id)}
+}
+
+// start registers a task for msg. The run's context outlives the HTTP
+// request only for non-blocking sends.
+func (s *Server) start(ctx context.Context, msg Message) (*taskEntry, context.Context, *Error) {
+	input := strings.TrimSpace(msg.Text())
+	if input == "" {
+		return nil, nil, &Error{Code: CodeInvalidParams, Message: "message has no text parts"}
+	}
+	if msg.TaskID != "" {
+		if entry, rpcErr := s.lookup(ctx, msg.TaskID); rpcErr == nil && !entry.snapshot().Status.State.Terminal() {
+			return nil, nil, &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("task %q is still running", msg.TaskID)}
+		}
+	}
+	if msg.ContextID == "" {
+		msg.ContextID = newID()
+	}
+	if rpcErr := s.claimContext(ctx, msg.ContextID); rpcErr != nil {
+		return nil, nil, rpcErr
+	}
+	if msg.MessageID == "" {
+		msg.MessageID = newID()
+	}
+	msg.Kind, msg.Role = "message", "user"
+
+	runCtx, cancel := context.WithCancel(ctx)
+	entry := &taskEntry{
+		owner: caller(ctx),
+		task: Task{
+			Kind:      "task",
+			ID:        newID(),
+			ContextID: msg.ContextID,
+			Status:    TaskStatus{State: TaskSubmitted, Timestamp: time.Now().UTC()},
+		},
+		cancel: cancel,
+	}
+	msg.TaskID = entry.task.ID
+	entry.task.History = []Message{msg}
+	s.tasks.Set(entry.task.ID, entry)
+	return entry, runCtx, nil
+}
+
+// claimContext makes the caller on ctx the owner of contextID unless
+// another principal already is.
+func (s *Server) claimContext(ctx context.Context, contextID string) *Error {
+	principal := caller(ctx)
+	s.ctxMu.Lock()
+	defer s.ctxMu.Unlock()
+	if v, ok := s.contexts.Get(contextID); ok && v.(string) != principal {
+		return &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("context %q belongs to another caller", contextID)}
+	}
+	if s.Claim != nil {
+		if err := s.Claim(ctx, SessionID(contextID)); err != nil {
+			return &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("context %q: %v", contextID, err)}
+		}
+	}
+	s.contexts.Set(contextID, principal)
+	return nil
+}
+
+func (s *Server) send(ctx context.Context, params MessageSendParams) (Task, *Error) {
+	blocking := params.Configuration == nil || params.Configuration.Blocking == nil || *params.Configuration.Blocking
+	if !blocking {
+		ctx = context.WithoutCancel(ctx)
+	}
+	entry, runCtx, rpcErr := s.start(ctx, params.Message)
+	if rpcErr != nil {
+		return Task{}, rpcErr
+	}
+	if !blocking {
+		go s.run(runCtx, entry, nil)
+		return entry.snapshot(), nil
+	}
+	s.run(runCtx, entry, nil)
+	return entry.snapshot(), nil
+}
+
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, id any, params MessageSendParams) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeRPC(w, id, nil, &Error{Code: CodeInternalError, Message: "streaming not supported by transport"})
+		return
+	}
+	entry, runCtx, rpcErr := s.start(r.Context(), params.Message)
+	if rpcErr != nil {
+		writeRPC(w, id, nil, rpcErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	emit := func(event any) {
+		result, _ := json.Marshal(event)
+		frame, _ := json.Marshal(rpcResponse{JSONRPC: "2.0", ID: id, Result: result})
+		fmt.Fprintf(w, "data: %s\n\n", frame)
+		flusher.Flush()
+	}
+	emit(entry.snapshot())
+	s.run(runCtx, entry, emit)
+}
+
+// run drives a task to a terminal state. emit, when set, receives status
+// and artifact update events.
+func (s *Server) run(ctx context.Context, entry *taskEntry, emit func(any)) {
+	defer entry.cancel()
+	snap := entry.snapshot()
+	input := snap.History[0].Text()
+	sessionID := SessionID(snap.ContextID)
+	artifactID := newID()
+
+	if !s.setStatus(entry, TaskWorking, "", emit) {
+		return
+	}
+
+	var (
+		output string
+		err    error
+	)
+	if streamer, ok := s.runner.(StreamRunner); ok && emit != nil {
+		output, err = s.runStream(ctx, streamer, entry, sessionID, input, artifactID, emit)
+	} else {
+		var out any
+		out, err = s.runner.Generate(ctx, sessionID, input)
+		output = fmt.Sprint(out)
+	}
+	if err != nil {
+		s.setStatus(entry, TaskFailed, err.Error(), emit)
+		return
+	}
+
+	artifact := Artifact{ArtifactID: artifactID, Name: "response", Parts: []Part{TextPart(output)}}
+	entry.mu.Lock()
+	if entry.task.Status.State.Terminal() {
+		entry.mu.Unlock()
+		return
+	}
+	entry.task.Artifacts = append(entry.task.Artifacts, artifact)
+	entry.mu.Unlock()
+	s.setStatus(entry, TaskCompleted, output, emit)
+}
+
+func (s *Server) runStream(ctx context.Context, streamer StreamRunner, entry *taskEntry, sessionID, input, artifactID string, emit func(any)) (string, error) {
+	ch, err := streamer.GenerateStream(ctx, sessionID, input)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	first := true
+	for chunk := range ch {
+		if chunk.Err != nil {
+			return "", chunk.Err
+		}
+		if chunk.Delta != "" {
+			sb.WriteString(chunk.Delta)
+			emit(TaskArtifactUpdateEvent{
+				Kind:      "artifact-update",
+				TaskID:    entry.task.ID,
+				ContextID: entry.task.ContextID,
+				Artifact:  Artifact{ArtifactID: artifactID, Name: "response", Parts: []Part{TextPart(chunk.Delta)}},
+				Append:    !first,
+				LastChunk: chunk.Done,
+			})
+			first = false
+		}
+		if chunk.Done {
+			if chunk.FullText != "" {
+				return chunk.FullText, nil
+			}
+			break
+		}
+	}
+	return sb.String(), ctx.Err()
+}
+
+// setStatus moves a task to state unless it already finished, recording
+// text as the agent's status message. It reports whether the change was
+// applied.
+func (s *Server) setStatus(entry *taskEntry, state TaskState, text string, emit func(any)) bool {
+	entry.mu.Lock()
+	if entry.task.Status.State.Terminal() {
+		entry.mu.Unlock()
+		return false
+	}
+	status := TaskStatus{State: state, Timestamp: time.Now().UTC()}
+	if text != "" {
+		msg := Message{
+			Kind:      "message",
+			MessageID: newID(),
+			Role:      "agent",
+			Parts:     []Part{TextPart(text)},
+			ContextID: entry.task.ContextID,
+			TaskID:    entry.task.ID,
+		}
+		status.Message = &msg
+		entry.task.History = append(entry.task.History, msg)
+	}
+	entry.task.Status = status
+	event := TaskStatusUpdateEvent{
+		Kind:      "status-update",
+		TaskID:    entry.task.ID,
+		ContextID: entry.task.ContextID,
+		Status:    status,
+		Final:     state.Terminal(),
+	}
+	entry.mu.Unlock()
+	if emit != nil {
+		emit(event)
+	}
+	return true
+}
+
+func (s *Server) cancel(ctx context.Context, id string) (Task, *Error) {
+	entry, rpcErr := s.lookup(ctx, id)
+	if rpcErr != nil {
+		return Task{}, rpcErr
+	}
+	if !s.setStatus(entry, TaskCanceled, "", nil) {
+		return Task{}, &Error{Code: CodeTaskNotCancelable, Message: fmt.Sprintf("task %q already %s", id, entry.snapshot().Status.State)}
+	}
+	entry.cancel()
+	return entry.snapshot(), nil
+}
+
+func decodeParams(raw json.RawMessage, v any) *Error {
+	if len(raw) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "params are required"}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func writeRPC(w http.ResponseWriter, id any, result any, rpcErr *Error) {
+	resp := rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			resp.Result = raw
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
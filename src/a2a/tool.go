@@ -0,0 +1,105 @@
+package a2a
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+)
+
+// Tool lets an agent delegate to a remote A2A agent. Each agent session
+// keeps its own A2A context, so the remote agent sees one continuous
+// conversation per session.
+type Tool struct {
+	Client *Client
+	// Name and Description present the remote agent to the model; NewTool
+	// fills them from the agent card.
+	Name        string
+	Description string
+
+	mu       sync.Mutex
+	contexts map[string]string // session ID -> A2A context ID
+}
+
+// NewTool discovers the agent at baseURL and wraps it as a tool named
+// "a2a.<card name>".
+func NewTool(ctx context.Context, baseURL string) (*Tool, error) {
+	client := NewClient(baseURL)
+	card, err := FetchCard(ctx, client.HTTPClient, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if card.URL != "" {
+		client.URL = card.URL
+	}
+	desc := card.Description
+	for _, skill := range card.Skills {
+		desc += fmt.Sprintf("\n- %s: %s", skill.Name, skill.Description)
+	}
+	return &Tool{Client: client, Name: "a2a." + toolName(card.Name), Description: strings.TrimSpace(desc)}, nil
+}
+
+func (t *Tool) Spec() agent.ToolSpec {
+	return agent.ToolSpec{
+		Name:        t.Name,
+		Description: t.Description,
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"message": map[string]any{
+					"type":        "string",
+					"description": "Self-contained request for the remote agent.",
+				},
+			},
+			"required": []string{"message"},
+		},
+	}
+}
+
+func (t *Tool) Invoke(ctx context.Context, req agent.ToolRequest) (agent.ToolResponse, error) {
+	if t.Client == nil {
+		return agent.ToolResponse{}, fmt.Errorf("%s tool has no client", t.Name)
+	}
+	message, _ := req.Arguments["message"].(string)
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return agent.ToolResponse{}, fmt.Errorf("message is required")
+	}
+
+	t.mu.Lock()
+	contextID := t.contexts[req.SessionID]
+	t.mu.Unlock()
+
+	task, err := t.Client.SendText(ctx, contextID, message)
+	if err != nil {
+		return agent.ToolResponse{}, err
+	}
+	if task.ContextID != "" {
+		t.mu.Lock()
+		if t.contexts == nil {
+			t.contexts = map[string]string{}
+		}
+		t.contexts[req.SessionID] = task.ContextID
+		t.mu.Unlock()
+	}
+	meta := map[string]string{"task_id": task.ID, "context_id": task.ContextID, "state": string(task.Status.State)}
+	if task.Status.State == TaskFailed || task.Status.State == TaskRejected {
+		return agent.ToolResponse{Metadata: meta}, fmt.Errorf("remote agent %s task: %s", task.Status.State, task.Text())
+	}
+	return agent.ToolResponse{Content: task.Text(), Metadata: meta}, nil
+}
+
+func toolName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}), "_")
+	if name == "" {
+		return "agent"
+	}
+	return name
+}
+
+var _ agent.Tool = (*Tool)(nil)
@@ -0,0 +1,213 @@
+// Package a2a implements the agent-to-agent (A2A) protocol: JSON-RPC 2.0
+// over HTTP with the message/send, message/stream, tasks/get and
+// tasks/cancel methods, plus agent card discovery. Server exposes a go-agent
+// to A2A clients; Client and Tool let a go-agent call agents built with
+// other frameworks.
+package a2a
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is the A2A protocol version spoken by this package.
+const ProtocolVersion = "0.3.0"
+
+// CardPath is where A2A agents publish their AgentCard.
+const CardPath = "/.well-known/agent-card.json"
+
+// TaskState is the lifecycle state of a Task.
+type TaskState string
+
+const (
+	TaskSubmitted     TaskState = "submitted"
+	TaskWorking       TaskState = "working"
+	TaskInputRequired TaskState = "input-required"
+	TaskCompleted     TaskState = "completed"
+	TaskCanceled      TaskState = "canceled"
+	TaskFailed        TaskState = "failed"
+	TaskRejected      TaskState = "rejected"
+)
+
+// Terminal reports whether no further updates follow the state.
+func (s TaskState) Terminal() bool {
+	switch s {
+	case TaskCompleted, TaskCanceled, TaskFailed, TaskRejected:
+		return true
+	}
+	return false
+}
+
+// Part is one piece of message or artifact content. Only text parts are
+// produced here; other kinds round-trip untouched.
+type Part struct {
+	Kind     string         `json:"kind"`
+	Text     string         `json:"text,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// TextPart builds a text Part.
+func TextPart(text string) Part { return Part{Kind: "text", Text: text} }
+
+// Message is one turn from a user or an agent.
+type Message struct {
+	Kind      string `json:"kind"`
+	MessageID string `json:"messageId"`
+	// Role is "user" or "agent".
+	Role      string `json:"role"`
+	Parts     []Part `json:"parts"`
+	ContextID string `json:"contextId,omitempty"`
+	TaskID    string `json:"taskId,omitempty"`
+}
+
+// Text joins the message's text parts.
+func (m Message) Text() string { return partsText(m.Parts) }
+
+// Artifact is an output produced by a task.
+type Artifact struct {
+	ArtifactID  string `json:"artifactId"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Parts       []Part `json:"parts"`
+}
+
+// TaskStatus is a task's current state with an optional agent message.
+type TaskStatus struct {
+	State     TaskState `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Task is a unit of work created by message/send or message/stream.
+type Task struct {
+	Kind      string     `json:"kind"`
+	ID        string     `json:"id"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	History   []Message  `json:"history,omitempty"`
+}
+
+// Text returns the task's artifact text, falling back to its status
+// message.
+func (t Task) Text() string {
+	var parts []string
+	for _, a := range t.Artifacts {
+		if text := partsText(a.Parts); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 && t.Status.Message != nil {
+		return t.Status.Message.Text()
+	}
+	return strings.Join(parts, "\n")
+}
+
+// TaskStatusUpdateEvent reports a state change during message/stream.
+type TaskStatusUpdateEvent struct {
+	Kind      string     `json:"kind"`
+	TaskID    string     `json:"taskId"`
+	ContextID string     `json:"contextId"`
+	Status    TaskStatus `json:"status"`
+	Final     bool       `json:"final"`
+}
+
+// TaskArtifactUpdateEvent carries artifact content during message/stream.
+// With Append set, Parts extend the artifact sent earlier under the same ID.
+type TaskArtifactUpdateEvent struct {
+	Kind      string   `json:"kind"`
+	TaskID    string   `json:"taskId"`
+	ContextID string   `json:"contextId"`
+	Artifact  Artifact `json:"artifact"`
+	Append    bool     `json:"append,omitempty"`
+	LastChunk bool     `json:"lastChunk,omitempty"`
+}
+
+// AgentCard advertises an agent to A2A clients.
+type AgentCard struct {
+	ProtocolVersion    string       `json:"protocolVersion"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description"`
+	URL                string       `json:"url"`
+	Version            string       `json:"version"`
+	Capabilities       Capabilities `json:"capabilities"`
+	DefaultInputModes  []string     `json:"defaultInputModes"`
+	DefaultOutputModes []string     `json:"defaultOutputModes"`
+	Skills             []Skill      `json:"skills"`
+}
+
+// Capabilities lists optional protocol features an agent supports.
+type Capabilities struct {
+	Streaming bool `json:"streaming"`
+}
+
+// Skill is one thing an agent can do.
+type Skill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// MessageSendParams is the params object of message/send and
+// message/stream.
+type MessageSendParams struct {
+	Message       Message               `json:"message"`
+	Configuration *MessageConfiguration `json:"configuration,omitempty"`
+}
+
+// MessageConfiguration tunes message/send. A nil Blocking waits for the
+// task to finish.
+type MessageConfiguration struct {
+	Blocking *bool `json:"blocking,omitempty"`
+}
+
+// TaskIDParams is the params object of tasks/get and tasks/cancel.
+type TaskIDParams struct {
+	ID string `json:"id"`
+}
+
+// JSON-RPC error codes used by A2A.
+const (
+	CodeParseError        = -32700
+	CodeInvalidRequest    = -32600
+	CodeMethodNotFound    = -32601
+	CodeInvalidParams     = -32602
+	CodeInternalError     = -32603
+	CodeTaskNotFound      = -32001
+	CodeTaskNotCancelable = -32002
+)
+
+// Error is a JSON-RPC error returned by an A2A agent.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return "a2a: " + e.Message }
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+func partsText(parts []Part) string {
+	var texts []string
+	for _, p := range parts {
+		if p.Kind == "text" && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -34,7 +34,12 @@ type AgentDevelopmentKit struct {
 	subAgentProvider []SubAgentProvider
 
 	defaultSystemPrompt string
+	promptFragments     []agent.PromptFragment
 	defaultContextLimit int
+	warmupPrompt        string
+	warmState           *WarmState
+	warm                *warmBuild
+	summarizer          memory.Summarizer
 
 	agentOptions []AgentOption
 	UTCP         utcp.UtcpClientInterface
@@ -123,6 +128,7 @@ func (k *AgentDevelopmentKit) UseModelProvider(provider ModelProvider) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
 	k.modelProvider = provider
+	k.warm = nil
 }
 
 // ModelProvider returns the currently registered model provider.
@@ -155,6 +161,7 @@ func (k *AgentDevelopmentKit) UseToolProvider(provider ToolProvider) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
 	k.toolProviders = append(k.toolProviders, provider)
+	k.warm = nil
 }
 
 // ToolProviders returns the registered tool providers in order.
@@ -174,6 +181,7 @@ func (k *AgentDevelopmentKit) UseSubAgentProvider(provider SubAgentProvider) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
 	k.subAgentProvider = append(k.subAgentProvider, provider)
+	k.warm = nil
 }
 
 // SubAgentProviders returns the registered sub-agent providers.
@@ -227,6 +235,30 @@ func (k *AgentDevelopmentKit) DefaultSystemPrompt() string {
 	return k.defaultSystemPrompt
 }
 
+// UsePromptFragment layers a fragment into the system prompt of agents built
+// by the kit. A fragment with the same name as an earlier one replaces it.
+func (k *AgentDevelopmentKit) UsePromptFragment(fragment agent.PromptFragment) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i, existing := range k.promptFragments {
+		if fragment.Name != "" && existing.Name == fragment.Name {
+			k.promptFragments[i] = fragment
+			return
+		}
+	}
+	k.promptFragments = append(k.promptFragments, fragment)
+}
+
+// PromptFragments returns the registered prompt fragments in registration
+// order.
+func (k *AgentDevelopmentKit) PromptFragments() []agent.PromptFragment {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	out := make([]agent.PromptFragment, len(k.promptFragments))
+	copy(out, k.promptFragments)
+	return out
+}
+
 // SetDefaultContextLimit updates the default retrieval window size.
 func (k *AgentDevelopmentKit) SetDefaultContextLimit(limit int) {
 	k.mu.Lock()
@@ -258,6 +290,7 @@ func (k *AgentDevelopmentKit) BuildAgent(ctx context.Context, opts ...AgentOptio
 	toolProviders := append([]ToolProvider(nil), k.toolProviders...)
 	subAgentProviders := append([]SubAgentProvider(nil), k.subAgentProvider...)
 	defaultPrompt := k.defaultSystemPrompt
+	fragments := append([]agent.PromptFragment(nil), k.promptFragments...)
 	defaultLimit := k.defaultContextLimit
 	defaultAgentOptions := append([]AgentOption(nil), k.agentOptions...)
 	utcp := k.UTCP
@@ -271,9 +304,13 @@ func (k *AgentDevelopmentKit) BuildAgent(ctx context.Context, opts ...AgentOptio
 		return nil, fmt.Errorf("kit requires a memory provider")
 	}
 
-	model, err := modelProvider(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("model provider: %w", err)
+	warmed := k.takeWarmBuild()
+	model := warmed.model
+	if model == nil {
+		var err error
+		if model, err = modelProvider(ctx); err != nil {
+			return nil, fmt.Errorf("model provider: %w", err)
+		}
 	}
 
 	bundle, err := memoryProvider(ctx)
@@ -291,33 +328,55 @@ func (k *AgentDevelopmentKit) BuildAgent(ctx context.Context, opts ...AgentOptio
 	if bundle.Shared != nil {
 		k.sharedFactory = bundle.Shared
 	}
+	if k.summarizer != nil && bundle.Session.Engine != nil {
+		if llm, ok := k.summarizer.(*agent.LLMSummarizer); ok && llm.Model == nil {
+			llm.Model = model
+		}
+		bundle.Session.Engine.WithSummarizer(k.summarizer)
+	}
 	k.mu.Unlock()
 
-	toolBundles := make([]ToolBundle, 0, len(toolProviders))
-	for _, provider := range toolProviders {
-		bundle, err := provider(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("tool provider: %w", err)
+	toolBundles := warmed.tools
+	if toolBundles == nil {
+		toolBundles = make([]ToolBundle, 0, len(toolProviders))
+		for _, provider := range toolProviders {
+			bundle, err := provider(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("tool provider: %w", err)
+			}
+			toolBundles = append(toolBundles, bundle)
 		}
-		toolBundles = append(toolBundles, bundle)
 	}
 
-	subBundles := make([]SubAgentBundle, 0, len(subAgentProviders))
-	for _, provider := range subAgentProviders {
-		bundle, err := provider(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("sub-agent provider: %w", err)
+	// Memory and tool fragments come first so kit-level fragments with the
+	// same name override them.
+	layered := append([]agent.PromptFragment(nil), bundle.Prompts...)
+	for _, tb := range toolBundles {
+		layered = append(layered, tb.Prompts...)
+	}
+	fragments = append(layered, fragments...)
+
+	subBundles := warmed.subAgents
+	if subBundles == nil {
+		subBundles = make([]SubAgentBundle, 0, len(subAgentProviders))
+		for _, provider := range subAgentProviders {
+			bundle, err := provider(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("sub-agent provider: %w", err)
+			}
+			subBundles = append(subBundles, bundle)
 		}
-		subBundles = append(subBundles, bundle)
 	}
 
 	agentOpts := agent.Options{
-		Model:        model,
-		Memory:       bundle.Session,
-		SystemPrompt: defaultPrompt,
-		ContextLimit: defaultLimit,
-		UTCPClient:   utcp,
-		CodeMode:     codeMode,
+		Model:           model,
+		Memory:          bundle.Session,
+		SystemPrompt:    defaultPrompt,
+		PromptFragments: fragments,
+		ContextLimit:    defaultLimit,
+		UTCPClient:      utcp,
+		UTCPToolSpecs:   warmed.toolSpecs,
+		CodeMode:        codeMode,
 	}
 
 	for _, opt := range defaultAgentOptions {
@@ -387,10 +446,17 @@ func (k *AgentDevelopmentKit) BuildAgent(ctx context.Context, opts ...AgentOptio
 	}
 	agentOpts.SubAgents = append(agentOpts.SubAgents, aggregatedSubAgents...)
 
+	warm, err := k.takeWarmState()
+	if err != nil {
+		return nil, err
+	}
 	built, err := agent.New(agentOpts)
 	if err != nil {
 		return nil, err
 	}
+	if err := restoreWarmState(built, warm); err != nil {
+		return nil, err
+	}
 	return built, nil
 }
 
@@ -2,6 +2,8 @@ package adk_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +15,8 @@ import (
 	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
 	"github.com/Protocol-Lattice/go-agent/src/subagents"
+	"github.com/universal-tool-calling-protocol/go-utcp"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
 )
 
 const (
@@ -207,3 +211,334 @@ func TestKitSharedSession(t *testing.T) {
 		t.Fatalf("shared memory not retrieved: %+v", records)
 	}
 }
+
+type warmupCountingModel struct {
+	*models.DummyLLM
+	prompts []string
+}
+
+func (m *warmupCountingModel) Generate(ctx context.Context, prompt string) (any, error) {
+	m.prompts = append(m.prompts, prompt)
+	return m.DummyLLM.Generate(ctx, prompt)
+}
+
+func TestKitWarmup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	model := &warmupCountingModel{DummyLLM: models.NewDummyLLM("warm:")}
+	memoryOpts := DefaultMemoryOptions()
+
+	kitInstance, err := adk.New(ctx,
+		adk.WithWarmupGeneration(""),
+		adk.WithModules(
+			kitmodules.NewModelModule("coordinator", kitmodules.StaticModelProvider(model)),
+			kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts),
+		),
+	)
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+
+	if err := kitInstance.Warmup(ctx); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	if len(model.prompts) != 1 {
+		t.Fatalf("expected a single warmup generation, got %d", len(model.prompts))
+	}
+}
+
+// searchCounter is a UTCP client that only answers SearchTools.
+type searchCounter struct {
+	utcp.UtcpClientInterface
+	searches int
+	limit    int
+}
+
+func (c *searchCounter) SearchTools(_ string, limit int) ([]tools.Tool, error) {
+	c.searches++
+	c.limit = limit
+	return []tools.Tool{{Name: "weather.lookup", Description: "Looks up the weather."}}, nil
+}
+
+func TestKitBuildAgentReusesWarmup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memoryOpts := DefaultMemoryOptions()
+	search := &searchCounter{}
+	var modelCalls, toolCalls, subAgentCalls int
+	kitInstance, err := adk.New(ctx,
+		adk.WithUTCP(search),
+		adk.WithModules(kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts)),
+	)
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	kitInstance.UseModelProvider(func(context.Context) (models.Agent, error) {
+		modelCalls++
+		return models.NewDummyLLM("Coordinator:"), nil
+	})
+	kitInstance.UseToolProvider(func(context.Context) (adk.ToolBundle, error) {
+		toolCalls++
+		return adk.ToolBundle{}, nil
+	})
+	kitInstance.UseSubAgentProvider(func(context.Context) (adk.SubAgentBundle, error) {
+		subAgentCalls++
+		return adk.SubAgentBundle{}, nil
+	})
+
+	if err := kitInstance.Warmup(ctx); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+	built, err := kitInstance.BuildAgent(ctx)
+	if err != nil {
+		t.Fatalf("BuildAgent: %v", err)
+	}
+	if modelCalls != 1 || toolCalls != 1 || subAgentCalls != 1 {
+		t.Fatalf("provider calls = model %d, tools %d, sub-agents %d; want one each", modelCalls, toolCalls, subAgentCalls)
+	}
+	specs := built.ToolSpecs()
+	if search.searches != 1 || len(specs) != 1 || specs[0].Name != "weather.lookup" {
+		t.Fatalf("searches = %d, specs = %+v; want the warmed discovery reused", search.searches, specs)
+	}
+	if search.limit != agent.UTCPSearchLimit() {
+		t.Fatalf("warmup searched %d tools, want the agent's limit %d", search.limit, agent.UTCPSearchLimit())
+	}
+
+	if _, err := kitInstance.BuildAgent(ctx); err != nil {
+		t.Fatalf("second BuildAgent: %v", err)
+	}
+	if modelCalls != 2 {
+		t.Fatalf("model calls = %d; a warmup should only serve one build", modelCalls)
+	}
+}
+
+func TestKitWithSummarizerUsesCoordinatorModel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memoryOpts := DefaultMemoryOptions()
+	summarizer := &agent.LLMSummarizer{}
+	kitInstance, err := adk.New(ctx,
+		adk.WithSummarizer(summarizer),
+		adk.WithModules(
+			kitmodules.NewModelModule("coordinator", kitmodules.StaticModelProvider(models.NewDummyLLM("Coordinator:"))),
+			kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts),
+		),
+	)
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	built, err := kitInstance.BuildAgent(ctx)
+	if err != nil {
+		t.Fatalf("BuildAgent: %v", err)
+	}
+	if summarizer.Model == nil {
+		t.Fatal("summarizer should inherit the coordinator model")
+	}
+	bundle, err := kitInstance.MemoryProvider()(ctx)
+	if err != nil {
+		t.Fatalf("memory provider: %v", err)
+	}
+	if _, err := bundle.Session.Engine.Store(ctx, "session", "The deploy freeze starts Friday", nil); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if _, err := built.Generate(ctx, "session", "when is the deploy freeze?"); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if summarizer.Usage().Calls == 0 {
+		t.Fatal("the engine should summarize through the LLM")
+	}
+}
+
+func TestKitWarmupReportsProviderErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kitInstance, err := adk.New(ctx,
+		adk.WithModules(
+			kitmodules.NewModelModule("broken", func(context.Context) (models.Agent, error) {
+				return nil, errors.New("no credentials")
+			}),
+		),
+	)
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+
+	err = kitInstance.Warmup(ctx)
+	if err == nil || !strings.Contains(err.Error(), "no credentials") {
+		t.Fatalf("expected model provider error, got %v", err)
+	}
+}
+
+func TestKitLayersPromptFragments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memoryOpts := DefaultMemoryOptions()
+	kitInstance, err := adk.New(ctx,
+		adk.WithDefaultSystemPrompt("Base."),
+		adk.WithPromptFragments(agent.PromptFragment{Name: "tools", Order: agent.PromptOrderTools, Content: "Prefer the kit's wording."}),
+		adk.WithModules(
+			kitmodules.NewModelModule("coordinator", kitmodules.StaticModelProvider(models.NewDummyLLM("Coordinator:"))),
+			kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts),
+			kitmodules.NewPromptModule("persona", agent.PromptFragment{Name: "persona", Order: agent.PromptOrderPersona, Content: "You are helpful."}),
+		),
+	)
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	kitInstance.UseToolProvider(func(context.Context) (adk.ToolBundle, error) {
+		return adk.ToolBundle{Prompts: []agent.PromptFragment{
+			{Name: "tools", Order: agent.PromptOrderTools, Content: "Call tools with JSON."},
+			{Name: "search", Order: agent.PromptOrderTools, Content: "Search before answering."},
+		}}, nil
+	})
+
+	built, err := kitInstance.BuildAgent(ctx)
+	if err != nil {
+		t.Fatalf("BuildAgent: %v", err)
+	}
+	var got []string
+	for _, f := range built.PromptFragments() {
+		got = append(got, f.Name+"="+f.Content)
+	}
+	want := []string{"persona=You are helpful.", "search=Search before answering.", "tools=Prefer the kit's wording."}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("fragments = %v, want %v", got, want)
+	}
+}
+
+func TestKitWarmStateRestoresAgent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memoryOpts := DefaultMemoryOptions()
+	modules := func() adk.Option {
+		return adk.WithModules(
+			kitmodules.NewModelModule("coordinator", kitmodules.StaticModelProvider(models.NewDummyLLM("Coordinator:"))),
+			kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts),
+		)
+	}
+
+	blue, err := adk.New(ctx, adk.WithDefaultSystemPrompt("Blue prompt."), adk.WithDefaultContextLimit(5), modules())
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	original, err := blue.BuildAgent(ctx)
+	if err != nil {
+		t.Fatalf("BuildAgent: %v", err)
+	}
+	if _, err := original.Generate(ctx, "session", "the door is blue"); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	original.SetToolProviders("session", "local")
+	saved, err := blue.SaveWarmState(original)
+	if err != nil {
+		t.Fatalf("SaveWarmState: %v", err)
+	}
+
+	green, err := adk.New(ctx, adk.WithWarmState(saved), modules())
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	if cfg := green.Config(); cfg.SystemPrompt != "Blue prompt." || cfg.ContextLimit != 5 {
+		t.Fatalf("config not restored: %+v", cfg)
+	}
+	restored, err := green.BuildAgent(ctx)
+	if err != nil {
+		t.Fatalf("BuildAgent: %v", err)
+	}
+	checkpoint, err := restored.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	var state agent.AgentState
+	if err := json.Unmarshal(checkpoint, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.ShortTerm["session"]) == 0 || strings.Join(state.ToolProviders["session"], ",") != "local" {
+		t.Fatalf("agent state not restored: %+v", state)
+	}
+
+	missing, err := adk.New(ctx, adk.WithWarmState(saved), adk.WithModules(
+		kitmodules.NewModelModule("coordinator-v2", kitmodules.StaticModelProvider(models.NewDummyLLM("Coordinator:"))),
+		kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts),
+	))
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	if _, err := missing.BuildAgent(ctx); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("expected missing module error, got %v", err)
+	}
+}
+
+func TestKitValidateReportsEachStage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memoryOpts := DefaultMemoryOptions()
+	kitInstance, err := adk.New(ctx, adk.WithModules(
+		kitmodules.NewModelModule("coordinator", kitmodules.StaticModelProvider(models.NewDummyLLM("Coordinator:"))),
+		kitmodules.InMemoryMemoryModule(4, memory.DummyEmbedder{}, &memoryOpts),
+	))
+	if err != nil {
+		t.Fatalf("kit.New: %v", err)
+	}
+	report := kitInstance.Validate(ctx)
+	if !report.OK() {
+		t.Fatalf("expected a passing report:\n%s", report)
+	}
+	statuses := map[string]adk.CheckStatus{}
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	if statuses["model"] != adk.CheckOK || statuses["memory store"] != adk.CheckOK || statuses["embedder"] != adk.CheckWarn || statuses["utcp"] != adk.CheckSkipped {
+		t.Fatalf("unexpected statuses:\n%s", report)
+	}
+
+	kitInstance.UseToolProvider(func(context.Context) (adk.ToolBundle, error) {
+		return adk.ToolBundle{}, errors.New("tool registry unreachable")
+	})
+	kitInstance.UseSubAgentProvider(func(context.Context) (adk.SubAgentBundle, error) {
+		panic("nil config")
+	})
+	report = kitInstance.Validate(ctx)
+	err = report.Err()
+	if report.OK() || err == nil || !strings.Contains(err.Error(), "tool registry unreachable") || !strings.Contains(err.Error(), "panic: nil config") {
+		t.Fatalf("expected provider failures, got %v\n%s", err, report)
+	}
+}
+
+func TestKitLocalProfile(t *testing.T) {
+	ctx := context.Background()
+	kit, err := adk.New(ctx, adk.WithLocalProfile(adk.LocalProfile{Model: "qwen2.5:0.5b"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := kit.Bootstrap(ctx); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if names := kit.Config().Modules; len(names) != 1 || names[0] != "local" {
+		t.Fatalf("unexpected modules: %v", names)
+	}
+	model, err := kit.ModelProvider()(ctx)
+	if err != nil {
+		t.Fatalf("model provider: %v", err)
+	}
+	if ollama, ok := model.(*models.OllamaLLM); !ok || ollama.Model != "qwen2.5:0.5b" {
+		t.Fatalf("expected the Ollama model, got %#v", model)
+	}
+	bundle, err := kit.MemoryProvider()(ctx)
+	if err != nil {
+		t.Fatalf("memory provider: %v", err)
+	}
+	if bundle.Session == nil || bundle.Session.Engine == nil || bundle.Shared == nil {
+		t.Fatalf("incomplete memory bundle: %+v", bundle)
+	}
+	if _, ok := bundle.Session.Bank.Store.(*memory.InMemoryStore); !ok {
+		t.Fatalf("expected the in-memory store, got %T", bundle.Session.Bank.Store)
+	}
+}
@@ -0,0 +1,93 @@
+package adk
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// Defaults used by Local when a LocalProfile field is empty.
+const (
+	DefaultLocalModel          = "llama3.2"
+	DefaultLocalEmbeddingModel = "nomic-embed-text"
+	defaultLocalMemoryWindow   = 8
+)
+
+// LocalProfile describes a kit that needs no external services: an Ollama
+// model and in-memory storage. Empty fields take the defaults above.
+type LocalProfile struct {
+	// Model is the Ollama model to chat with.
+	Model string
+	// EmbeddingModel is the Ollama model used for embeddings. Embedding
+	// falls back to the dummy embedder while Ollama is unreachable.
+	EmbeddingModel string
+	// MemoryWindow is the number of recent turns kept per session.
+	MemoryWindow int
+	// MemoryOptions tunes the memory engine; nil uses memory.DefaultOptions.
+	MemoryOptions *memory.Options
+}
+
+// Local configures the kit for a laptop with zero infrastructure: the
+// default Ollama model on OLLAMA_HOST (http://localhost:11434 when unset)
+// and in-memory storage, with no API keys, databases or vector stores. It
+// works the same on Linux, macOS and Windows, on amd64 and arm64.
+// Memories last as long as the process; snapshot the engine to keep them.
+func Local() Option {
+	return WithLocalProfile(LocalProfile{})
+}
+
+// WithLocalProfile is Local with a custom profile.
+func WithLocalProfile(profile LocalProfile) Option {
+	return WithModule(localModule{profile: profile})
+}
+
+// localModule provisions the model and memory providers of a LocalProfile.
+type localModule struct {
+	profile LocalProfile
+}
+
+func (localModule) Name() string { return "local" }
+
+func (m localModule) Provision(_ context.Context, kit *AgentDevelopmentKit) error {
+	profile := m.profile
+	if profile.Model == "" {
+		profile.Model = DefaultLocalModel
+	}
+	if profile.EmbeddingModel == "" {
+		profile.EmbeddingModel = DefaultLocalEmbeddingModel
+	}
+	if profile.MemoryWindow <= 0 {
+		profile.MemoryWindow = defaultLocalMemoryWindow
+	}
+	opts := memory.DefaultOptions()
+	if profile.MemoryOptions != nil {
+		opts = *profile.MemoryOptions
+	}
+
+	embedder, err := memory.NewOllamaEmbedder(profile.EmbeddingModel)
+	if err != nil {
+		return err
+	}
+	bank := memory.NewMemoryBankWithStore(memory.NewInMemoryStore())
+	mem := memory.NewSessionMemory(bank, profile.MemoryWindow).WithEmbedder(embedder)
+	engine := memory.NewEngine(bank.Store, opts).WithEmbedder(embedder)
+	engine.WithLogger(log.New(os.Stderr, "memory-engine: ", log.LstdFlags))
+	mem.WithEngine(engine)
+	bundle := MemoryBundle{
+		Session: mem,
+		Shared: func(local string, spaces ...string) *memory.SharedSession {
+			return memory.NewSharedSession(mem, local, spaces...)
+		},
+	}
+
+	kit.UseModelProvider(func(context.Context) (models.Agent, error) {
+		return models.NewOllamaLLM(profile.Model, "")
+	})
+	kit.UseMemoryProvider(func(context.Context) (MemoryBundle, error) {
+		return bundle, nil
+	})
+	return nil
+}
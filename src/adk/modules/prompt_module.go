@@ -0,0 +1,33 @@
+package modules
+
+import (
+	"context"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/adk"
+)
+
+// PromptModule contributes system prompt fragments, such as a persona or
+// house style, to the kit.
+type PromptModule struct {
+	name      string
+	fragments []agent.PromptFragment
+}
+
+// NewPromptModule creates a module that registers the supplied fragments. If
+// name is empty the module will expose "prompt".
+func NewPromptModule(name string, fragments ...agent.PromptFragment) *PromptModule {
+	if name == "" {
+		name = "prompt"
+	}
+	return &PromptModule{name: name, fragments: fragments}
+}
+
+func (m *PromptModule) Name() string { return m.name }
+
+func (m *PromptModule) Provision(_ context.Context, kitInstance *adk.AgentDevelopmentKit) error {
+	for _, f := range m.fragments {
+		kitInstance.UsePromptFragment(f)
+	}
+	return nil
+}
@@ -3,8 +3,10 @@ package adk
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
 	"github.com/Protocol-Lattice/go-agent/src/models"
 	"github.com/universal-tool-calling-protocol/go-utcp"
 	"github.com/universal-tool-calling-protocol/go-utcp/src/plugins/codemode"
@@ -40,6 +42,17 @@ func WithDefaultSystemPrompt(prompt string) Option {
 	}
 }
 
+// WithPromptFragments layers fragments into the system prompt of every agent
+// built by the kit. See agent.PromptFragment for ordering.
+func WithPromptFragments(fragments ...agent.PromptFragment) Option {
+	return func(kit *AgentDevelopmentKit) error {
+		for _, f := range fragments {
+			kit.UsePromptFragment(f)
+		}
+		return nil
+	}
+}
+
 // WithDefaultContextLimit overrides the default context window size used when
 // constructing agents.
 func WithDefaultContextLimit(limit int) Option {
@@ -80,6 +93,38 @@ func WithSubAgents(subAgents ...agent.SubAgent) Option {
 	}
 }
 
+// WithWarmupGeneration makes Warmup issue a single generation with prompt so
+// provider connections and model caches are hot before the first request. An
+// empty prompt falls back to a short ping.
+func WithWarmupGeneration(prompt string) Option {
+	return func(kit *AgentDevelopmentKit) error {
+		prompt = strings.TrimSpace(prompt)
+		if prompt == "" {
+			prompt = defaultWarmupPrompt
+		}
+		kit.warmupPrompt = prompt
+		return nil
+	}
+}
+
+// WithSummarizer installs s as the cluster summarizer of the memory engine
+// behind every agent the kit builds. An *agent.LLMSummarizer without a Model
+// uses the kit's coordinator model, so cluster summaries become abstracts
+// written by the LLM:
+//
+//	adk.WithSummarizer(&agent.LLMSummarizer{MaxSummaryTokens: 80})
+//
+// Memory without an engine is unaffected.
+func WithSummarizer(s memory.Summarizer) Option {
+	return func(kit *AgentDevelopmentKit) error {
+		if s == nil {
+			return fmt.Errorf("summarizer cannot be nil")
+		}
+		kit.summarizer = s
+		return nil
+	}
+}
+
 func WithUTCP(client utcp.UtcpClientInterface) Option {
 	return func(kit *AgentDevelopmentKit) error {
 		kit.UTCP = client
@@ -92,7 +137,7 @@ func WithCodeModeUtcp(client utcp.UtcpClientInterface, model models.Agent) Optio
 		if client == nil {
 			return fmt.Errorf("codemode UTCP client cannot be nil")
 		}
-		kit.CodeMode = codemode.NewCodeModeUTCP(client, model)
+		kit.CodeMode = codemode.NewCodeModeUTCP(agent.RecordCodeRunSteps(client), model)
 		return nil
 	}
 }
@@ -22,6 +22,9 @@ type SharedSessionFactory func(local string, spaces ...string) *memory.SharedSes
 type MemoryBundle struct {
 	Session *memory.SessionMemory
 	Shared  SharedSessionFactory
+	// Prompts are system prompt fragments describing how to use the memory,
+	// layered into the coordinator's system prompt.
+	Prompts []agent.PromptFragment
 }
 
 // MemoryProvider provisions the conversational memory layer used by agents and
@@ -34,6 +37,9 @@ type MemoryProvider func(ctx context.Context) (MemoryBundle, error)
 type ToolBundle struct {
 	Catalog agent.ToolCatalog
 	Tools   []agent.Tool
+	// Prompts are usage instructions for the tools, layered into the
+	// coordinator's system prompt.
+	Prompts []agent.PromptFragment
 }
 
 // ToolProvider returns a ToolBundle to be merged into the agent configuration.
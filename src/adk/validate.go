@@ -0,0 +1,218 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// CheckStatus is the outcome of one Validate check.
+type CheckStatus string
+
+const (
+	CheckOK      CheckStatus = "ok"
+	CheckWarn    CheckStatus = "warn"
+	CheckFailed  CheckStatus = "fail"
+	CheckSkipped CheckStatus = "skip"
+)
+
+// Check is one line of a Diagnostics report.
+type Check struct {
+	Name    string        `json:"name"`
+	Status  CheckStatus   `json:"status"`
+	Detail  string        `json:"detail,omitempty"`
+	Err     error         `json:"-"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Diagnostics is the report produced by Validate.
+type Diagnostics struct {
+	Checks []Check `json:"checks"`
+}
+
+// OK reports whether no check failed. Warnings do not count as failures.
+func (d Diagnostics) OK() bool {
+	return d.Err() == nil
+}
+
+// Err joins the errors of the failed checks, or returns nil.
+func (d Diagnostics) Err() error {
+	var errs []error
+	for _, c := range d.Checks {
+		if c.Status == CheckFailed {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String renders the report one check per line.
+func (d Diagnostics) String() string {
+	var b strings.Builder
+	for _, c := range d.Checks {
+		line := fmt.Sprintf("[%-4s] %-16s", c.Status, c.Name)
+		switch {
+		case c.Err != nil:
+			line += " " + c.Err.Error()
+		case c.Detail != "":
+			line += " " + c.Detail
+		}
+		if elapsed := c.Elapsed.Round(time.Millisecond); elapsed > 0 {
+			line += fmt.Sprintf(" (%s)", elapsed)
+		}
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Validate exercises the kit's configuration end to end and reports each
+// stage instead of stopping at the first error: module bootstrap, model
+// construction and a one-line generation, memory provider construction
+// (which opens database connections), a store round trip that also
+// surfaces a missing table or collection, an embedding call, the tool and
+// sub-agent providers, and UTCP tool discovery. A panic in a provider is
+// reported as a failed check. Unlike Warmup, Validate does not keep the
+// providers' results.
+func (k *AgentDevelopmentKit) Validate(ctx context.Context) Diagnostics {
+	var d Diagnostics
+	if !d.run("modules", func() (CheckStatus, string, error) {
+		if err := k.Bootstrap(ctx); err != nil {
+			return CheckFailed, "", err
+		}
+		return CheckOK, fmt.Sprintf("%d registered", len(k.Modules())), nil
+	}) {
+		return d
+	}
+
+	k.mu.RLock()
+	modelProvider := k.modelProvider
+	memoryProvider := k.memoryProvider
+	toolProviders := append([]ToolProvider(nil), k.toolProviders...)
+	subAgentProviders := append([]SubAgentProvider(nil), k.subAgentProvider...)
+	prompt := k.warmupPrompt
+	utcp := k.UTCP
+	k.mu.RUnlock()
+	if prompt == "" {
+		prompt = defaultWarmupPrompt
+	}
+
+	d.run("model", func() (CheckStatus, string, error) {
+		if modelProvider == nil {
+			return CheckFailed, "", errors.New("no model provider registered")
+		}
+		model, err := modelProvider(ctx)
+		if err != nil {
+			return CheckFailed, "", err
+		}
+		if model == nil {
+			return CheckFailed, "", errors.New("model provider returned nil")
+		}
+		if _, err := model.Generate(ctx, prompt); err != nil {
+			return CheckFailed, "", fmt.Errorf("generation: %w", err)
+		}
+		return CheckOK, "generation succeeded", nil
+	})
+
+	var bundle MemoryBundle
+	haveMemory := d.run("memory", func() (CheckStatus, string, error) {
+		if memoryProvider == nil {
+			return CheckFailed, "", errors.New("no memory provider registered")
+		}
+		var err error
+		if bundle, err = memoryProvider(ctx); err != nil {
+			return CheckFailed, "", err
+		}
+		if bundle.Session == nil {
+			return CheckFailed, "", errors.New("session memory is nil")
+		}
+		return CheckOK, "", nil
+	})
+	if haveMemory {
+		d.run("memory store", func() (CheckStatus, string, error) {
+			bank := bundle.Session.Bank
+			if bank == nil || bank.Store == nil {
+				return CheckSkipped, "short-term memory only", nil
+			}
+			n, err := bank.Store.Count(ctx)
+			if err != nil {
+				return CheckFailed, "", err
+			}
+			return CheckOK, fmt.Sprintf("%d records", n), nil
+		})
+		d.run("embedder", func() (CheckStatus, string, error) {
+			embedder := bundle.Session.Embedder
+			if embedder == nil {
+				embedder = memory.AutoEmbedder()
+			}
+			// Call the embedder directly: SessionMemory.Embed hides
+			// provider errors behind the dummy fallback.
+			vec, err := embedder.Embed(ctx, "validate")
+			if err != nil {
+				return CheckFailed, "", err
+			}
+			if _, dummy := embedder.(memory.DummyEmbedder); dummy {
+				return CheckWarn, "dummy embedder; retrieval is not semantic", nil
+			}
+			return CheckOK, fmt.Sprintf("%d dimensions", len(vec)), nil
+		})
+	}
+
+	for i, provider := range toolProviders {
+		d.run(fmt.Sprintf("tools #%d", i+1), func() (CheckStatus, string, error) {
+			bundle, err := provider(ctx)
+			if err != nil {
+				return CheckFailed, "", err
+			}
+			return CheckOK, fmt.Sprintf("%d tools", len(bundle.Tools)), nil
+		})
+	}
+	for i, provider := range subAgentProviders {
+		d.run(fmt.Sprintf("sub-agents #%d", i+1), func() (CheckStatus, string, error) {
+			bundle, err := provider(ctx)
+			if err != nil {
+				return CheckFailed, "", err
+			}
+			return CheckOK, fmt.Sprintf("%d sub-agents", len(bundle.SubAgents)), nil
+		})
+	}
+
+	d.run("utcp", func() (CheckStatus, string, error) {
+		if utcp == nil {
+			return CheckSkipped, "no UTCP client", nil
+		}
+		tools, err := utcp.SearchTools("", agent.UTCPSearchLimit())
+		if err != nil {
+			return CheckFailed, "", err
+		}
+		if len(tools) == 0 {
+			return CheckWarn, "no tools discovered", nil
+		}
+		return CheckOK, fmt.Sprintf("%d tools discovered", len(tools)), nil
+	})
+	return d
+}
+
+// run records the outcome of fn as a check and reports whether it passed.
+func (d *Diagnostics) run(name string, fn func() (CheckStatus, string, error)) (passed bool) {
+	start := time.Now()
+	check := Check{Name: name}
+	defer func() {
+		if r := recover(); r != nil {
+			check.Status, check.Err = CheckFailed, fmt.Errorf("panic: %v", r)
+		}
+		check.Elapsed = time.Since(start)
+		d.Checks = append(d.Checks, check)
+		passed = check.Status != CheckFailed
+	}()
+	check.Status, check.Detail, check.Err = fn()
+	if check.Status == CheckFailed && check.Err == nil {
+		check.Err = errors.New(check.Detail)
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package adk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent"
+)
+
+// WarmStateVersion is the bundle version written by SaveWarmState.
+const WarmStateVersion = 1
+
+// KitConfig captures the parts of a kit's configuration that survive a
+// redeploy: the names of the registered modules and the prompt defaults.
+// Providers themselves are code and are registered again by the new binary.
+type KitConfig struct {
+	Modules         []string               `json:"modules,omitempty"`
+	SystemPrompt    string                 `json:"system_prompt,omitempty"`
+	ContextLimit    int                    `json:"context_limit,omitempty"`
+	PromptFragments []agent.PromptFragment `json:"prompt_fragments,omitempty"`
+}
+
+// WarmState bundles a kit configuration with an agent checkpoint so a new
+// process can pick up where the previous one stopped, e.g. during a
+// blue/green deploy.
+type WarmState struct {
+	Version int             `json:"version"`
+	SavedAt time.Time       `json:"saved_at"`
+	Config  KitConfig       `json:"config"`
+	Agent   json.RawMessage `json:"agent,omitempty"`
+}
+
+// Config returns the kit's current configuration.
+func (k *AgentDevelopmentKit) Config() KitConfig {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	cfg := KitConfig{
+		SystemPrompt:    k.defaultSystemPrompt,
+		ContextLimit:    k.defaultContextLimit,
+		PromptFragments: append([]agent.PromptFragment(nil), k.promptFragments...),
+	}
+	for _, module := range k.modules {
+		cfg.Modules = append(cfg.Modules, module.Name())
+	}
+	return cfg
+}
+
+// SaveWarmState serialises the kit configuration together with a checkpoint
+// of a. Pass nil to save the configuration only.
+func (k *AgentDevelopmentKit) SaveWarmState(a *agent.Agent) ([]byte, error) {
+	state := WarmState{Version: WarmStateVersion, SavedAt: time.Now().UTC(), Config: k.Config()}
+	if a != nil {
+		checkpoint, err := a.Checkpoint()
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint agent: %w", err)
+		}
+		state.Agent = checkpoint
+	}
+	return json.Marshal(state)
+}
+
+// WithWarmState seeds the kit from a bundle produced by SaveWarmState. The
+// saved prompt defaults are applied immediately, so options listed after
+// WithWarmState override them. The next BuildAgent call checks that every
+// saved module has been registered again and restores the agent checkpoint
+// into the agent it builds.
+func WithWarmState(data []byte) Option {
+	return func(k *AgentDevelopmentKit) error {
+		var state WarmState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("decode warm state: %w", err)
+		}
+		if state.Version < 1 || state.Version > WarmStateVersion {
+			return fmt.Errorf("unsupported warm state version %d", state.Version)
+		}
+		if state.Config.SystemPrompt != "" {
+			k.SetDefaultSystemPrompt(state.Config.SystemPrompt)
+		}
+		if state.Config.ContextLimit > 0 {
+			k.SetDefaultContextLimit(state.Config.ContextLimit)
+		}
+		for _, fragment := range state.Config.PromptFragments {
+			k.UsePromptFragment(fragment)
+		}
+		k.mu.Lock()
+		k.warmState = &state
+		k.mu.Unlock()
+		return nil
+	}
+}
+
+// takeWarmState returns the pending warm state, if any, after checking that
+// the modules it was saved with are registered. The state is consumed so
+// only the first agent built from the kit is restored.
+func (k *AgentDevelopmentKit) takeWarmState() (*WarmState, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	state := k.warmState
+	if state == nil {
+		return nil, nil
+	}
+	registered := make(map[string]bool, len(k.modules))
+	for _, module := range k.modules {
+		registered[module.Name()] = true
+	}
+	var missing []string
+	for _, name := range state.Config.Modules {
+		if !registered[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("warm state expects modules that are not registered: %v", missing)
+	}
+	k.warmState = nil
+	return state, nil
+}
+
+// restoreWarmState applies a pending checkpoint to a freshly built agent.
+func restoreWarmState(a *agent.Agent, state *WarmState) error {
+	if state == nil || len(state.Agent) == 0 {
+		return nil
+	}
+	if err := a.Restore(state.Agent); err != nil {
+		return fmt.Errorf("restore warm state: %w", err)
+	}
+	return nil
+}
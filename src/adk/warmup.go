@@ -0,0 +1,146 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+const defaultWarmupPrompt = "Reply with OK."
+
+// Warmup front-loads the expensive parts of the first request after a deploy:
+// it bootstraps modules, constructs the model and memory providers, checks
+// that the memory store and its schema are reachable, resolves tool providers and
+// primes UTCP tool discovery. When configured via WithWarmupGeneration it also
+// runs a tiny generation. All stages are attempted; failures are joined.
+// What it builds is kept for the next BuildAgent, which reuses it instead of
+// calling the providers again.
+func (k *AgentDevelopmentKit) Warmup(ctx context.Context) error {
+	if err := k.Bootstrap(ctx); err != nil {
+		return err
+	}
+
+	k.mu.RLock()
+	modelProvider := k.modelProvider
+	memoryProvider := k.memoryProvider
+	toolProviders := append([]ToolProvider(nil), k.toolProviders...)
+	subAgentProviders := append([]SubAgentProvider(nil), k.subAgentProvider...)
+	prompt := k.warmupPrompt
+	utcp := k.UTCP
+	k.mu.RUnlock()
+
+	var errs []error
+	var warm warmBuild
+
+	if modelProvider != nil {
+		model, err := modelProvider(ctx)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("warmup model provider: %w", err))
+		case prompt != "" && model != nil:
+			if _, err := model.Generate(ctx, prompt); err != nil {
+				errs = append(errs, fmt.Errorf("warmup generation: %w", err))
+			}
+		}
+		if err == nil {
+			warm.model = model
+		}
+	}
+
+	if memoryProvider != nil {
+		bundle, err := memoryProvider(ctx)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("warmup memory provider: %w", err))
+		case bundle.Session == nil:
+			errs = append(errs, errors.New("warmup memory provider: session memory is nil"))
+		default:
+			k.mu.Lock()
+			if k.memoryInstance == nil {
+				k.memoryInstance = bundle.Session
+			}
+			if bundle.Shared != nil && k.sharedFactory == nil {
+				k.sharedFactory = bundle.Shared
+			}
+			k.mu.Unlock()
+
+			// Count round-trips to the backend, so it surfaces both
+			// connectivity problems and a missing table or collection.
+			if bank := bundle.Session.Bank; bank != nil && bank.Store != nil {
+				if _, err := bank.Store.Count(ctx); err != nil {
+					errs = append(errs, fmt.Errorf("warmup memory store: %w", err))
+				}
+			}
+		}
+	}
+
+	// Bundles are only kept when every provider succeeded, so BuildAgent
+	// reports the failure itself.
+	toolBundles := make([]ToolBundle, 0, len(toolProviders))
+	for _, provider := range toolProviders {
+		bundle, err := provider(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("warmup tool provider: %w", err))
+			toolBundles = nil
+			continue
+		}
+		if toolBundles != nil {
+			toolBundles = append(toolBundles, bundle)
+		}
+	}
+	warm.tools = toolBundles
+	subBundles := make([]SubAgentBundle, 0, len(subAgentProviders))
+	for _, provider := range subAgentProviders {
+		bundle, err := provider(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("warmup sub-agent provider: %w", err))
+			subBundles = nil
+			continue
+		}
+		if subBundles != nil {
+			subBundles = append(subBundles, bundle)
+		}
+	}
+	warm.subAgents = subBundles
+
+	if utcp != nil {
+		specs, err := utcp.SearchTools("", agent.UTCPSearchLimit())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("warmup tool discovery: %w", err))
+		} else {
+			warm.toolSpecs = append([]tools.Tool{}, specs...)
+		}
+	}
+
+	k.mu.Lock()
+	k.warm = &warm
+	k.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// warmBuild holds what Warmup built for the next BuildAgent. A nil field
+// was not warmed and is built by BuildAgent as usual.
+type warmBuild struct {
+	model     models.Agent
+	tools     []ToolBundle
+	subAgents []SubAgentBundle
+	toolSpecs []tools.Tool
+}
+
+// takeWarmBuild returns what Warmup built, if anything, and consumes it so
+// only the first agent built after a warmup reuses it.
+func (k *AgentDevelopmentKit) takeWarmBuild() warmBuild {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	warm := k.warm
+	k.warm = nil
+	if warm == nil {
+		return warmBuild{}
+	}
+	return *warm
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"sync/atomic"
+
+	goagent "github.com/Protocol-Lattice/go-agent"
 )
 
 var invocationCounter atomic.Uint64
 
-// Context is passed to workflow nodes during graph execution.
+// Context is passed to workflow nodes during graph execution. Its context
+// carries the invocation as the agent call scope's workflow, so agent tools
+// bound with goagent.WithWorkflowSession share a session across nodes.
 type Context struct {
 	context.Context
 
@@ -21,10 +25,11 @@ func newContext(ctx context.Context, sessionID string) Context {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	invocationID := fmt.Sprintf("workflow-%d", invocationCounter.Add(1))
 	return Context{
-		Context:      ctx,
+		Context:      goagent.ContextWithWorkflow(ctx, invocationID),
 		SessionID:    sessionID,
-		InvocationID: fmt.Sprintf("workflow-%d", invocationCounter.Add(1)),
+		InvocationID: invocationID,
 		State:        map[string]any{},
 	}
 }
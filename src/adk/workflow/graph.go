@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	goagent "github.com/Protocol-Lattice/go-agent"
 )
 
 const defaultMaxSteps = 128
@@ -197,7 +199,7 @@ func (g *Graph) executeRun(ctx context.Context, store RunStore, state *RunState)
 		state.JoinStates = make(map[string]JoinState, len(g.joins))
 	}
 	wctx := Context{
-		Context:      ctx,
+		Context:      goagent.ContextWithWorkflow(ctx, state.InvocationID),
 		SessionID:    state.SessionID,
 		InvocationID: state.InvocationID,
 		State:        state.ContextState,
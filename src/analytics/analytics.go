@@ -0,0 +1,296 @@
+// Package analytics aggregates how agents are used — query categories, tool
+// frequencies, latency — without keeping what users said. Inputs are reduced
+// to a category as they arrive and session IDs are replaced by keyed hashes,
+// so an export can leave the team that owns the conversations. Counts can be
+// made differentially private with Laplace noise and small buckets
+// suppressed.
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	mrand "math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+)
+
+// OtherCategory is the category of queries no rule matches.
+const OtherCategory = "other"
+
+// Categorizer maps a query to a category. It sees the raw input, which is
+// discarded afterwards.
+type Categorizer func(query string) string
+
+// Keywords builds a Categorizer from keyword lists: the first category, in
+// name order, with a keyword contained in the lowercased query wins.
+func Keywords(categories map[string][]string) Categorizer {
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return func(query string) string {
+		q := strings.ToLower(query)
+		for _, name := range names {
+			for _, kw := range categories[name] {
+				if strings.Contains(q, strings.ToLower(kw)) {
+					return name
+				}
+			}
+		}
+		return OtherCategory
+	}
+}
+
+// DefaultCategories is used when Options.Categorize is nil.
+var DefaultCategories = map[string][]string{
+	"code":     {"code", "function", "bug", "compile", "refactor", "test", "stack trace"},
+	"data":     {"sql", "query", "csv", "table", "chart", "report", "metrics"},
+	"search":   {"find", "search", "look up", "where is", "latest"},
+	"support":  {"error", "broken", "doesn't work", "can't", "help", "refund", "account"},
+	"writing":  {"write", "draft", "summarize", "summarise", "rewrite", "email", "translate"},
+	"planning": {"plan", "schedule", "roadmap", "steps", "remind"},
+}
+
+// Options configures a Collector.
+type Options struct {
+	Categorize Categorizer
+	// Salt keys the session hashes. Without one a random salt is drawn, so
+	// hashes cannot be linked across collectors.
+	Salt []byte
+	// Epsilon, when positive, adds Laplace noise with scale 1/Epsilon to
+	// every exported count. Smaller is more private.
+	Epsilon float64
+	// MinCount drops buckets whose exported count is below it.
+	MinCount int
+	// IncludeEvents exports one anonymized row per turn. Rows are not
+	// noised, so leave this off when exports need differential privacy.
+	IncludeEvents bool
+	rand          *mrand.Rand
+}
+
+// Event is an anonymized turn.
+type Event struct {
+	Session  string    `json:"session"`
+	Category string    `json:"category"`
+	Tools    []string  `json:"tools,omitempty"`
+	Latency  string    `json:"latency"`
+	Hour     time.Time `json:"hour"`
+}
+
+// Report is an export.
+type Report struct {
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Turns      int            `json:"turns"`
+	Sessions   int            `json:"sessions"`
+	Categories map[string]int `json:"categories"`
+	Tools      map[string]int `json:"tools"`
+	ToolErrors map[string]int `json:"tool_errors,omitempty"`
+	Latency    map[string]int `json:"latency"`
+	// Suppressed counts buckets dropped for falling under MinCount.
+	Suppressed int     `json:"suppressed,omitempty"`
+	Epsilon    float64 `json:"epsilon,omitempty"`
+	MinCount   int     `json:"min_count,omitempty"`
+	Events     []Event `json:"events,omitempty"`
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Collector accumulates usage. Feed it turns with RecordTurn; wrap request
+// contexts with Context to count tool calls.
+type Collector struct {
+	opts Options
+
+	mu         sync.Mutex
+	turns      int
+	sessions   map[string]bool
+	categories map[string]int
+	tools      map[string]int
+	toolErrors map[string]int
+	latency    map[string]int
+	pending    map[string][]string // hashed session -> tools since its last turn
+	events     []Event
+	from, to   time.Time
+}
+
+// NewCollector returns an empty collector.
+func NewCollector(opts Options) *Collector {
+	if opts.Categorize == nil {
+		opts.Categorize = Keywords(DefaultCategories)
+	}
+	if len(opts.Salt) == 0 {
+		opts.Salt = make([]byte, 32)
+		_, _ = rand.Read(opts.Salt)
+	}
+	if opts.rand == nil {
+		opts.rand = mrand.New(mrand.NewPCG(mrand.Uint64(), mrand.Uint64()))
+	}
+	return &Collector{
+		opts:       opts,
+		sessions:   map[string]bool{},
+		categories: map[string]int{},
+		tools:      map[string]int{},
+		toolErrors: map[string]int{},
+		latency:    map[string]int{},
+		pending:    map[string][]string{},
+	}
+}
+
+// Context counts the agent's tool calls on ctx, passing events on to any
+// handler already registered.
+func (c *Collector) Context(ctx context.Context) context.Context {
+	prev, _ := agent.ToolEventsFromContext(ctx)
+	return agent.ContextWithToolEvents(ctx, func(ev agent.ToolEvent) {
+		c.toolEvent(ev)
+		if prev != nil {
+			prev(ev)
+		}
+	})
+}
+
+func (c *Collector) toolEvent(ev agent.ToolEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev.Type {
+	case agent.ToolEventStart:
+		c.tools[ev.Tool]++
+		if c.opts.IncludeEvents {
+			session := c.hash(ev.SessionID)
+			c.pending[session] = append(c.pending[session], ev.Tool)
+		}
+	case agent.ToolEventError:
+		c.toolErrors[ev.Tool]++
+	}
+}
+
+// RecordTurn counts a completed turn. Only the input's category, the
+// latency bucket and the hour are kept.
+func (c *Collector) RecordTurn(turn agent.Turn) {
+	category := c.opts.Categorize(turn.Input)
+	bucket := LatencyBucket(turn.Latency)
+	at := turn.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	hour := at.UTC().Truncate(time.Hour)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session := c.hash(turn.SessionID)
+	c.turns++
+	c.sessions[session] = true
+	c.categories[category]++
+	c.latency[bucket]++
+	if c.from.IsZero() || hour.Before(c.from) {
+		c.from = hour
+	}
+	if hour.After(c.to) {
+		c.to = hour
+	}
+	if c.opts.IncludeEvents {
+		c.events = append(c.events, Event{Session: session, Category: category, Tools: c.pending[session], Latency: bucket, Hour: hour})
+		delete(c.pending, session)
+	}
+}
+
+// Export returns the aggregates so far, noised and suppressed per Options.
+func (c *Collector) Export() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := Report{
+		From:     c.from,
+		To:       c.to.Add(time.Hour),
+		Epsilon:  c.opts.Epsilon,
+		MinCount: c.opts.MinCount,
+	}
+	if c.from.IsZero() {
+		r.To = time.Time{}
+	}
+	r.Turns = c.noisy(c.turns)
+	r.Sessions = c.noisy(len(c.sessions))
+	r.Categories = c.buckets(c.categories, &r.Suppressed)
+	r.Tools = c.buckets(c.tools, &r.Suppressed)
+	r.ToolErrors = c.buckets(c.toolErrors, &r.Suppressed)
+	r.Latency = c.buckets(c.latency, &r.Suppressed)
+	if c.opts.IncludeEvents {
+		r.Events = append([]Event(nil), c.events...)
+	}
+	return r
+}
+
+// Reset discards everything collected.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turns = 0
+	c.sessions = map[string]bool{}
+	c.categories = map[string]int{}
+	c.tools = map[string]int{}
+	c.toolErrors = map[string]int{}
+	c.latency = map[string]int{}
+	c.pending = map[string][]string{}
+	c.events = nil
+	c.from, c.to = time.Time{}, time.Time{}
+}
+
+func (c *Collector) buckets(counts map[string]int, suppressed *int) map[string]int {
+	out := make(map[string]int, len(counts))
+	for k, n := range counts {
+		v := c.noisy(n)
+		if v <= 0 || v < c.opts.MinCount {
+			*suppressed++
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// noisy adds Laplace(1/ε) noise to a count with sensitivity one.
+func (c *Collector) noisy(n int) int {
+	if c.opts.Epsilon <= 0 {
+		return n
+	}
+	u := c.opts.rand.Float64() - 0.5
+	noise := -math.Copysign(1, u) * math.Log(1-2*math.Abs(u)) / c.opts.Epsilon
+	if v := int(math.Round(float64(n) + noise)); v > 0 {
+		return v
+	}
+	return 0
+}
+
+func (c *Collector) hash(sessionID string) string {
+	mac := hmac.New(sha256.New, c.opts.Salt)
+	mac.Write([]byte(sessionID))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// LatencyBucket names the coarse latency range of d.
+func LatencyBucket(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "<1s"
+	case d < 5*time.Second:
+		return "1-5s"
+	case d < 30*time.Second:
+		return "5-30s"
+	default:
+		return ">=30s"
+	}
+}
@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	mrand "math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+)
+
+func TestCollectorAggregatesWithoutContent(t *testing.T) {
+	c := NewCollector(Options{Salt: []byte("k"), IncludeEvents: true})
+	ctx := c.Context(context.Background())
+	emit, _ := agent.ToolEventsFromContext(ctx)
+	at := time.Date(2026, 3, 1, 10, 42, 0, 0, time.UTC)
+
+	emit(agent.ToolEvent{Type: agent.ToolEventStart, SessionID: "alice", Tool: "sql.run"})
+	emit(agent.ToolEvent{Type: agent.ToolEventError, SessionID: "alice", Tool: "sql.run"})
+	c.RecordTurn(agent.Turn{SessionID: "alice", Input: "Run this SQL against my secret-customers table", Latency: 2 * time.Second, At: at})
+	c.RecordTurn(agent.Turn{SessionID: "bob", Input: "Please draft an email to my landlord", Latency: 300 * time.Millisecond, At: at.Add(time.Hour)})
+	c.RecordTurn(agent.Turn{SessionID: "alice", Input: "hmm", At: at})
+
+	r := c.Export()
+	if r.Turns != 3 || r.Sessions != 2 {
+		t.Fatalf("unexpected totals %+v", r)
+	}
+	if r.Categories["data"] != 1 || r.Categories["writing"] != 1 || r.Categories[OtherCategory] != 1 {
+		t.Fatalf("unexpected categories %v", r.Categories)
+	}
+	if r.Tools["sql.run"] != 1 || r.ToolErrors["sql.run"] != 1 || r.Latency["1-5s"] != 1 || r.Latency["<1s"] != 2 {
+		t.Fatalf("unexpected tools or latency %+v", r)
+	}
+	if !r.From.Equal(at.Truncate(time.Hour)) || !r.To.Equal(at.Truncate(time.Hour).Add(2*time.Hour)) {
+		t.Fatalf("unexpected span %s - %s", r.From, r.To)
+	}
+	if len(r.Events) != 3 || len(r.Events[0].Tools) != 1 || r.Events[0].Session != r.Events[2].Session || r.Events[0].Session == r.Events[1].Session {
+		t.Fatalf("unexpected events %+v", r.Events)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	for _, leaked := range []string{"alice", "bob", "secret-customers", "landlord"} {
+		if strings.Contains(buf.String(), leaked) {
+			t.Fatalf("export leaked %q:\n%s", leaked, buf.String())
+		}
+	}
+}
+
+func TestCollectorNoiseAndSuppression(t *testing.T) {
+	c := NewCollector(Options{Epsilon: 1, MinCount: 5, rand: mrand.New(mrand.NewPCG(1, 2))})
+	for i := range 200 {
+		input := "write a poem"
+		if i == 0 {
+			input = "fix this bug"
+		}
+		c.RecordTurn(agent.Turn{SessionID: "s", Input: input})
+	}
+	r := c.Export()
+	if r.Categories["writing"] < 190 || r.Categories["writing"] > 210 {
+		t.Fatalf("noise should be small relative to large counts, got %d", r.Categories["writing"])
+	}
+	if _, ok := r.Categories["code"]; ok || r.Suppressed == 0 {
+		t.Fatalf("a single-turn bucket should be suppressed: %+v", r)
+	}
+
+	c.Reset()
+	if r := c.Export(); r.Turns > 5 || len(r.Categories) != 0 {
+		t.Fatalf("Reset should clear counts, got %+v", r)
+	}
+}
@@ -0,0 +1,288 @@
+// Package artifacts stores named, versioned outputs that agents produce —
+// reports, diffs, images, CSVs — in a BlobStore, so responses and memory can
+// carry a short artifact:// reference instead of the content itself.
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Artifact describes one stored version of a named artifact.
+type Artifact struct {
+	SessionID   string    `json:"session_id"`
+	Name        string    `json:"name"`
+	Version     int       `json:"version"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Description string    `json:"description,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+// URI identifies this version as artifact://<session>/<name>@v<version>.
+func (a Artifact) URI() string {
+	return fmt.Sprintf("artifact://%s/%s@v%d", url.PathEscape(a.SessionID), url.PathEscape(a.Name), a.Version)
+}
+
+// ParseURI splits an artifact:// reference. A reference without a version
+// yields version 0, meaning the latest.
+func ParseURI(uri string) (sessionID, name string, version int, err error) {
+	rest, ok := strings.CutPrefix(uri, "artifact://")
+	if !ok {
+		return "", "", 0, fmt.Errorf("artifacts: %q is not an artifact URI", uri)
+	}
+	if at := strings.LastIndex(rest, "@v"); at >= 0 {
+		version, err = strconv.Atoi(rest[at+2:])
+		if err != nil || version <= 0 {
+			return "", "", 0, fmt.Errorf("artifacts: bad version in %q", uri)
+		}
+		rest = rest[:at]
+	}
+	rawSession, rawName, ok := strings.Cut(rest, "/")
+	if !ok || rawSession == "" || rawName == "" {
+		return "", "", 0, fmt.Errorf("artifacts: %q needs a session and a name", uri)
+	}
+	if sessionID, err = url.PathUnescape(rawSession); err != nil {
+		return "", "", 0, err
+	}
+	if name, err = url.PathUnescape(rawName); err != nil {
+		return "", "", 0, err
+	}
+	return sessionID, name, version, nil
+}
+
+// Store versions artifacts per session and name on top of a BlobStore.
+// Every Put adds a version; earlier versions stay retrievable until the
+// artifact is deleted.
+type Store struct {
+	Blobs BlobStore
+	// MaxVersions, when positive, drops the oldest versions beyond it.
+	MaxVersions int
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewStore returns a Store over blobs.
+func NewStore(blobs BlobStore) *Store {
+	return &Store{Blobs: blobs}
+}
+
+func (s *Store) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now().UTC()
+}
+
+// checkKey rejects session IDs and names that are empty or would escape
+// their segment of a blob key: PathEscape leaves "." and ".." as they are.
+func checkKey(parts ...string) error {
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" || part == "." || part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, part)
+		}
+	}
+	return nil
+}
+
+func artifactPrefix(sessionID, name string) string {
+	return url.PathEscape(sessionID) + "/" + url.PathEscape(name) + "/"
+}
+
+func indexKey(sessionID, name string) string {
+	return artifactPrefix(sessionID, name) + "index.json"
+}
+
+func contentKey(sessionID, name string, version int) string {
+	return artifactPrefix(sessionID, name) + "v" + strconv.Itoa(version)
+}
+
+// Put stores data as the next version of sessionID's artifact name. An
+// empty contentType is inferred from the name's extension, then the data.
+func (s *Store) Put(ctx context.Context, sessionID, name, contentType string, data []byte, description string) (Artifact, error) {
+	name = strings.TrimSpace(name)
+	if err := checkKey(sessionID, name); err != nil {
+		return Artifact{}, err
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, err := s.versions(ctx, sessionID, name)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Artifact{}, err
+	}
+	art := Artifact{
+		SessionID:   sessionID,
+		Name:        name,
+		Version:     1,
+		ContentType: contentType,
+		Size:        len(data),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Description: strings.TrimSpace(description),
+		Created:     s.clock(),
+	}
+	if n := len(versions); n > 0 {
+		art.Version = versions[n-1].Version + 1
+	}
+	if err := s.Blobs.Put(ctx, contentKey(sessionID, name, art.Version), data); err != nil {
+		return Artifact{}, err
+	}
+	versions = append(versions, art)
+
+	var dropped []Artifact
+	if s.MaxVersions > 0 && len(versions) > s.MaxVersions {
+		dropped = versions[:len(versions)-s.MaxVersions]
+		versions = versions[len(versions)-s.MaxVersions:]
+	}
+	if err := s.writeIndex(ctx, sessionID, name, versions); err != nil {
+		return Artifact{}, err
+	}
+	for _, old := range dropped {
+		if err := s.Blobs.Delete(ctx, contentKey(sessionID, name, old.Version)); err != nil {
+			return art, err
+		}
+	}
+	return art, nil
+}
+
+// Get returns a version of an artifact and its content; version 0 is the
+// latest.
+func (s *Store) Get(ctx context.Context, sessionID, name string, version int) (Artifact, []byte, error) {
+	s.mu.Lock()
+	versions, err := s.versions(ctx, sessionID, name)
+	s.mu.Unlock()
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	art := versions[len(versions)-1]
+	if version > 0 {
+		found := false
+		for _, v := range versions {
+			if v.Version == version {
+				art, found = v, true
+				break
+			}
+		}
+		if !found {
+			return Artifact{}, nil, fmt.Errorf("%w: %s version %d", ErrNotFound, name, version)
+		}
+	}
+	data, err := s.Blobs.Get(ctx, contentKey(sessionID, name, art.Version))
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	return art, data, nil
+}
+
+// Resolve fetches the artifact an artifact:// URI points at.
+func (s *Store) Resolve(ctx context.Context, uri string) (Artifact, []byte, error) {
+	sessionID, name, version, err := ParseURI(uri)
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	return s.Get(ctx, sessionID, name, version)
+}
+
+// Versions returns every stored version of an artifact, oldest first.
+func (s *Store) Versions(ctx context.Context, sessionID, name string) ([]Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions(ctx, sessionID, name)
+}
+
+// List returns the latest version of each of sessionID's artifacts, by
+// name.
+func (s *Store) List(ctx context.Context, sessionID string) ([]Artifact, error) {
+	if err := checkKey(sessionID); err != nil {
+		return nil, err
+	}
+	keys, err := s.Blobs.List(ctx, url.PathEscape(sessionID)+"/")
+	if err != nil {
+		return nil, err
+	}
+	var out []Artifact
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/index.json") {
+			continue
+		}
+		versions, err := s.readIndex(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) > 0 {
+			out = append(out, versions[len(versions)-1])
+		}
+	}
+	return out, nil
+}
+
+// Delete removes every version of an artifact.
+func (s *Store) Delete(ctx context.Context, sessionID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, err := s.versions(ctx, sessionID, name)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := s.Blobs.Delete(ctx, contentKey(sessionID, name, v.Version)); err != nil {
+			return err
+		}
+	}
+	return s.Blobs.Delete(ctx, indexKey(sessionID, name))
+}
+
+func (s *Store) versions(ctx context.Context, sessionID, name string) ([]Artifact, error) {
+	if err := checkKey(sessionID, name); err != nil {
+		return nil, err
+	}
+	versions, err := s.readIndex(ctx, indexKey(sessionID, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return versions, nil
+}
+
+func (s *Store) readIndex(ctx context.Context, key string) ([]Artifact, error) {
+	raw, err := s.Blobs.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var versions []Artifact
+	if err := json.Unmarshal(raw, &versions); err != nil {
+		return nil, fmt.Errorf("artifacts: decode index %s: %w", key, err)
+	}
+	return versions, nil
+}
+
+func (s *Store) writeIndex(ctx context.Context, sessionID, name string, versions []Artifact) error {
+	raw, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+	return s.Blobs.Put(ctx, indexKey(sessionID, name), raw)
+}
@@ -0,0 +1,172 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+)
+
+func TestStoreVersionsArtifacts(t *testing.T) {
+	for name, newBlobs := range map[string]func(t *testing.T) BlobStore{
+		"memory": func(*testing.T) BlobStore { return NewMemoryBlobStore() },
+		"file": func(t *testing.T) BlobStore {
+			b, err := NewFileBlobStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return b
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewStore(newBlobs(t))
+			store.MaxVersions = 2
+
+			for _, body := range []string{"a,b\n1,2\n", "a,b\n3,4\n", "a,b\n5,6\n"} {
+				if _, err := store.Put(ctx, "s/1", "results.csv", "", []byte(body), ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := store.Put(ctx, "s/1", "notes.md", "", []byte("# Notes"), "summary"); err != nil {
+				t.Fatal(err)
+			}
+
+			art, data, err := store.Get(ctx, "s/1", "results.csv", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if art.Version != 3 || string(data) != "a,b\n5,6\n" || !strings.HasPrefix(art.ContentType, "text/csv") {
+				t.Fatalf("latest = %+v %q", art, data)
+			}
+			if _, _, err := store.Get(ctx, "s/1", "results.csv", 1); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("version beyond MaxVersions should be gone, got %v", err)
+			}
+			if _, data, err := store.Resolve(ctx, "artifact://s%2F1/results.csv@v2"); err != nil || string(data) != "a,b\n3,4\n" {
+				t.Fatalf("resolve v2 = %q, %v", data, err)
+			}
+
+			list, err := store.List(ctx, "s/1")
+			if err != nil || len(list) != 2 {
+				t.Fatalf("list = %+v, %v", list, err)
+			}
+			if other, _ := store.List(ctx, "s2"); len(other) != 0 {
+				t.Fatalf("artifacts leaked across sessions: %+v", other)
+			}
+
+			if err := store.Delete(ctx, "s/1", "results.csv"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Versions(ctx, "s/1", "results.csv"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("deleted artifact still has versions: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreKeepsDotNamesFromCrossingSessions(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(blobs)
+	for _, session := range []string{"alice", "bob"} {
+		if _, err := store.Put(ctx, session, "notes.md", "", []byte(session+"'s notes"), ""); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"..", "."} {
+			if _, err := store.Put(ctx, session, name, "", []byte("from "+session), ""); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Put(%q, %q) = %v, want ErrInvalidKey", session, name, err)
+			}
+			if _, _, err := store.Get(ctx, session, name, 0); !errors.Is(err, ErrInvalidKey) {
+				t.Fatalf("Get(%q, %q) = %v, want ErrInvalidKey", session, name, err)
+			}
+		}
+	}
+	if _, err := store.Put(ctx, "..", "notes.md", "", []byte("x"), ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Put in session .. = %v, want ErrInvalidKey", err)
+	}
+	for _, session := range []string{"alice", "bob"} {
+		if _, data, err := store.Get(ctx, session, "notes.md", 0); err != nil || string(data) != session+"'s notes" {
+			t.Fatalf("%s's notes = %q, %v", session, data, err)
+		}
+	}
+	if err := blobs.Put(ctx, "alice/../index.json", []byte("[]")); err == nil {
+		t.Fatal("FileBlobStore accepted a key leaving its directory")
+	}
+}
+
+func TestParseURIRoundTrip(t *testing.T) {
+	art := Artifact{SessionID: "team:alpha", Name: "q3 report.md", Version: 4}
+	session, name, version, err := ParseURI(art.URI())
+	if err != nil || session != art.SessionID || name != art.Name || version != 4 {
+		t.Fatalf("ParseURI(%s) = %q %q %d %v", art.URI(), session, name, version, err)
+	}
+	if _, _, v, err := ParseURI("artifact://s/name.txt"); err != nil || v != 0 {
+		t.Fatalf("unversioned URI: %d %v", v, err)
+	}
+	if _, _, _, err := ParseURI("https://example.com"); err == nil {
+		t.Fatal("expected an error for a non-artifact URI")
+	}
+}
+
+func TestToolSavesAndReadsBack(t *testing.T) {
+	tool := NewTool(NewStore(NewMemoryBlobStore()))
+	tool.InlineBytes = 10
+	ctx := context.Background()
+
+	resp, err := tool.Invoke(ctx, agent.ToolRequest{SessionID: "s1", Arguments: map[string]any{
+		"action": "save", "name": "diff.patch", "content": "--- a\n+++ b\n@@ change @@",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Metadata["artifact"] != "artifact://s1/diff.patch@v1" {
+		t.Fatalf("save = %+v", resp)
+	}
+	resp, err = tool.Invoke(ctx, agent.ToolRequest{SessionID: "s1", Arguments: map[string]any{"action": "get", "name": "diff.patch"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Content, "--- a\n+++ ") || !strings.Contains(resp.Content, "truncated") {
+		t.Fatalf("get = %q", resp.Content)
+	}
+	if _, err := tool.Invoke(ctx, agent.ToolRequest{SessionID: "s2", Arguments: map[string]any{"action": "get", "name": "diff.patch"}}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("other sessions must not see the artifact: %v", err)
+	}
+}
+
+func TestHandlerServesContentAndVersions(t *testing.T) {
+	store := NewStore(NewMemoryBlobStore())
+	ctx := context.Background()
+	store.Put(ctx, "s1", "report.md", "", []byte("v1"), "")
+	store.Put(ctx, "s1", "report.md", "", []byte("v2"), "")
+	srv := httptest.NewServer(http.StripPrefix("/artifacts", Handler(store)))
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+	if resp, body := get("/artifacts/s1/report.md?version=1"); resp.StatusCode != 200 || body != "v1" || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/markdown") {
+		t.Fatalf("content: %d %q %s", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+	if _, body := get("/artifacts/s1/report.md/versions"); !strings.Contains(body, `"version":2`) {
+		t.Fatalf("versions = %s", body)
+	}
+	if resp, _ := get("/artifacts/s1/missing.md"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing artifact status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,75 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Handler serves a Store read-only over HTTP:
+//
+//	GET {session}                  latest version of each artifact (JSON)
+//	GET {session}/{name}           artifact content; ?version=N picks a version
+//	GET {session}/{name}/versions  every version (JSON)
+//
+// Mount it under a prefix with http.StripPrefix, e.g. "/artifacts/".
+func Handler(store *Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{session}", func(w http.ResponseWriter, r *http.Request) {
+		arts, err := store.List(r.Context(), r.PathValue("session"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if arts == nil {
+			arts = []Artifact{}
+		}
+		writeJSON(w, arts)
+	})
+	mux.HandleFunc("GET /{session}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		version := 0
+		if raw := r.URL.Query().Get("version"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v <= 0 {
+				http.Error(w, "version must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			version = v
+		}
+		art, data, err := store.Get(r.Context(), r.PathValue("session"), r.PathValue("name"), version)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", art.ContentType)
+		w.Header().Set("ETag", `"`+art.SHA256+`"`)
+		w.Header().Set("X-Artifact-Version", strconv.Itoa(art.Version))
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("GET /{session}/{name}/versions", func(w http.ResponseWriter, r *http.Request) {
+		arts, err := store.Versions(r.Context(), r.PathValue("session"), r.PathValue("name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, arts)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalidKey):
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
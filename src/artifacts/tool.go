@@ -0,0 +1,194 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// DefaultInlineBytes caps how much artifact content the tool's get action
+// returns to the model.
+const DefaultInlineBytes = 8 << 10
+
+// Tool exposes a Store to an agent as the "artifacts" tool with the actions
+// save, get, list and versions. Artifacts are scoped to the calling
+// session.
+type Tool struct {
+	Store *Store
+	// InlineBytes overrides DefaultInlineBytes.
+	InlineBytes int
+}
+
+// NewTool wires an artifacts tool over store.
+func NewTool(store *Store) *Tool {
+	return &Tool{Store: store}
+}
+
+func (t *Tool) Spec() agent.ToolSpec {
+	return agent.ToolSpec{
+		Name:        "artifacts",
+		Description: "Saves large or structured outputs (reports, diffs, CSVs) as named, versioned artifacts and reads them back. Reply with the returned artifact:// reference instead of pasting the content.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"save", "get", "list", "versions"},
+					"description": "What to do.",
+				},
+				"name": map[string]any{
+					"type":        "string",
+					"description": "Artifact name with an extension, e.g. report.md or results.csv.",
+				},
+				"content": map[string]any{
+					"type":        "string",
+					"description": "Content to save.",
+				},
+				"content_type": map[string]any{
+					"type":        "string",
+					"description": "Optional MIME type; inferred from the name when omitted.",
+				},
+				"description": map[string]any{
+					"type":        "string",
+					"description": "Optional one-line summary of the artifact.",
+				},
+				"version": map[string]any{
+					"type":        "integer",
+					"description": "Version to get; omit for the latest.",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+}
+
+func (t *Tool) Invoke(ctx context.Context, req agent.ToolRequest) (agent.ToolResponse, error) {
+	if t.Store == nil {
+		return agent.ToolResponse{}, fmt.Errorf("artifacts tool has no store")
+	}
+	action, _ := req.Arguments["action"].(string)
+	name, _ := req.Arguments["name"].(string)
+	name = strings.TrimSpace(name)
+
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "save":
+		content, _ := req.Arguments["content"].(string)
+		contentType, _ := req.Arguments["content_type"].(string)
+		description, _ := req.Arguments["description"].(string)
+		art, err := t.Store.Put(ctx, req.SessionID, name, strings.TrimSpace(contentType), []byte(content), description)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		return agent.ToolResponse{
+			Content:  fmt.Sprintf("Saved %s (version %d, %d bytes).", art.URI(), art.Version, art.Size),
+			Metadata: metadata(art),
+		}, nil
+	case "get":
+		if name == "" {
+			return agent.ToolResponse{}, fmt.Errorf("name is required")
+		}
+		art, data, err := t.Store.Get(ctx, req.SessionID, name, intArg(req.Arguments["version"]))
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		return agent.ToolResponse{Content: t.inline(art, data), Metadata: metadata(art)}, nil
+	case "list":
+		arts, err := t.Store.List(ctx, req.SessionID)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if len(arts) == 0 {
+			return agent.ToolResponse{Content: "No artifacts saved in this session."}, nil
+		}
+		return agent.ToolResponse{Content: describeAll(arts)}, nil
+	case "versions":
+		if name == "" {
+			return agent.ToolResponse{}, fmt.Errorf("name is required")
+		}
+		arts, err := t.Store.Versions(ctx, req.SessionID, name)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		return agent.ToolResponse{Content: describeAll(arts)}, nil
+	default:
+		return agent.ToolResponse{}, fmt.Errorf("unknown artifacts action %q", action)
+	}
+}
+
+// inline renders text content up to the inline limit; binary content is
+// described rather than returned.
+func (t *Tool) inline(art Artifact, data []byte) string {
+	limit := t.InlineBytes
+	if limit <= 0 {
+		limit = DefaultInlineBytes
+	}
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("%s is binary (%s, %d bytes); fetch it by reference.", art.URI(), art.ContentType, art.Size)
+	}
+	if len(data) <= limit {
+		return string(data)
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s\n\n[truncated: first %d of %d bytes of %s]", data[:cut], cut, len(data), art.URI())
+}
+
+func describeAll(arts []Artifact) string {
+	var sb strings.Builder
+	for _, a := range arts {
+		fmt.Fprintf(&sb, "- %s %s %d bytes %s", a.URI(), a.ContentType, a.Size, a.Created.Format("2006-01-02 15:04"))
+		if a.Description != "" {
+			sb.WriteString(" — " + a.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func metadata(a Artifact) map[string]string {
+	return map[string]string{
+		"artifact": a.URI(),
+		"name":     a.Name,
+		"version":  fmt.Sprint(a.Version),
+		"sha256":   a.SHA256,
+	}
+}
+
+func intArg(v any) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	case string:
+		var i int
+		fmt.Sscan(n, &i)
+		return i
+	}
+	return 0
+}
+
+var _ agent.Tool = (*Tool)(nil)
+
+var _ agent.AttachmentBlobStore = (*Store)(nil)
+
+// StoreAttachment saves a file sent to an agent as an artifact of its
+// session and returns the artifact URI, so an agent.AttachmentPolicy can
+// route images here.
+func (s *Store) StoreAttachment(ctx context.Context, sessionID string, file models.File) (string, error) {
+	name := strings.TrimSpace(file.Name)
+	if name == "" {
+		name = "attachment"
+	}
+	art, err := s.Put(ctx, sessionID, name, file.MIME, file.Data, "attachment")
+	if err != nil {
+		return "", err
+	}
+	return art.URI(), nil
+}
@@ -101,6 +101,16 @@ func (c *LRUCache) Set(key string, value any) {
 	}
 }
 
+// Delete removes key from the cache
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
 // Clear removes all entries from the cache
 func (c *LRUCache) Clear() {
 	c.mu.Lock()
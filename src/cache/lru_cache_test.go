@@ -89,3 +89,19 @@ func TestLRUCache_TTL(t *testing.T) {
 		t.Error("expected value to be expired")
 	}
 }
+
+func TestLRUCache_Delete(t *testing.T) {
+	cache := NewLRUCache(2, time.Minute)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Delete("a")
+	cache.Delete("missing")
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected 'a' to be deleted")
+	}
+	cache.Set("c", 3)
+	if _, ok := cache.Get("b"); !ok {
+		t.Error("expected 'b' to survive; the deleted slot should be reused")
+	}
+}
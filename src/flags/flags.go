@@ -0,0 +1,204 @@
+// Package flags lets the agent and the memory engine read feature toggles at
+// run time, so behaviour can be tuned in production without a redeploy. A
+// Provider answers flag lookups; this package ships environment, JSON file
+// and static providers, and ProviderFunc adapts a LaunchDarkly or
+// OpenFeature client in a few lines.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Flags consulted by the agent and the memory engine.
+const (
+	// AutoDelegate overrides Agent.AutoDelegate.
+	AutoDelegate = "auto_delegate"
+	// ToolCalling picks the tool orchestration strategy: "native" uses the
+	// model's tool-calling API when it has one (the default), "json" always
+	// uses the JSON prompt protocol and "off" disables tool calls.
+	ToolCalling = "tool_calling"
+	// Summaries overrides engine Options.EnableSummaries.
+	Summaries = "memory_summaries"
+)
+
+// Tool orchestration strategies for ToolCalling.
+const (
+	ToolCallingNative = "native"
+	ToolCallingJSON   = "json"
+	ToolCallingOff    = "off"
+)
+
+// Provider resolves flag values by key. ok is false when the provider has
+// no value for the flag, so the caller keeps its configured default. The
+// context carries the request, including the caller's identity, for
+// providers that target flags per user.
+type Provider interface {
+	Flag(ctx context.Context, key string) (value string, ok bool)
+}
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func(ctx context.Context, key string) (string, bool)
+
+// Flag calls f.
+func (f ProviderFunc) Flag(ctx context.Context, key string) (string, bool) { return f(ctx, key) }
+
+// Bool reads key from p as a boolean, returning fallback when p is nil or
+// has no valid value.
+func Bool(ctx context.Context, p Provider, key string, fallback bool) bool {
+	if p == nil {
+		return fallback
+	}
+	raw, ok := p.Flag(ctx, key)
+	if !ok {
+		return fallback
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// String reads key from p, returning fallback when p is nil or has no
+// value.
+func String(ctx context.Context, p Provider, key, fallback string) string {
+	if p == nil {
+		return fallback
+	}
+	raw, ok := p.Flag(ctx, key)
+	if raw = strings.TrimSpace(raw); !ok || raw == "" {
+		return fallback
+	}
+	return raw
+}
+
+// Int reads key from p as an integer, returning fallback when p is nil or
+// has no valid value.
+func Int(ctx context.Context, p Provider, key string, fallback int) int {
+	if p == nil {
+		return fallback
+	}
+	raw, ok := p.Flag(ctx, key)
+	if !ok {
+		return fallback
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// Env reads flags from environment variables named Prefix plus the
+// upper-cased key, e.g. AGENT_FLAG_TOOL_CALLING for Prefix "AGENT_FLAG_".
+// The environment is read on every lookup.
+type Env struct {
+	Prefix string
+}
+
+// Flag implements Provider.
+func (e Env) Flag(_ context.Context, key string) (string, bool) {
+	v, ok := os.LookupEnv(e.Prefix + strings.ToUpper(key))
+	return v, ok && v != ""
+}
+
+// Static serves fixed values, typically in tests.
+type Static map[string]string
+
+// Flag implements Provider.
+func (s Static) Flag(_ context.Context, key string) (string, bool) {
+	v, ok := s[key]
+	return v, ok
+}
+
+// File serves flags from a JSON object such as
+// {"tool_calling": "json", "memory_summaries": false}. The file is re-read
+// when its modification time changes, checked at most once per Interval
+// (one second when zero), so edits apply without a restart. A file that
+// goes missing or stops parsing keeps the last good values.
+type File struct {
+	Path     string
+	Interval time.Duration
+
+	mu      sync.Mutex
+	values  map[string]string
+	modTime time.Time
+	checked time.Time
+}
+
+// NewFile loads path, failing if it cannot be read or parsed.
+func NewFile(path string) (*File, error) {
+	f := &File{Path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	f.checked = time.Now()
+	return f, nil
+}
+
+// Flag implements Provider.
+func (f *File) Flag(_ context.Context, key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	interval := f.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if now := time.Now(); now.Sub(f.checked) >= interval {
+		f.checked = now
+		if info, err := os.Stat(f.Path); err == nil && !info.ModTime().Equal(f.modTime) {
+			_ = f.load(info.ModTime())
+		}
+	}
+	v, ok := f.values[key]
+	return v, ok
+}
+
+// load replaces the values with the file's; the caller holds f.mu or owns
+// f exclusively.
+func (f *File) load(modTime time.Time) error {
+	raw, err := os.ReadFile(f.Path)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse flags %s: %w", f.Path, err)
+	}
+	values := make(map[string]string, len(doc))
+	for k, v := range doc {
+		if v != nil {
+			values[k] = fmt.Sprint(v)
+		}
+	}
+	f.values = values
+	f.modTime = modTime
+	return nil
+}
+
+// Chain asks providers in order and returns the first value found, so an
+// environment override can sit in front of a flag service.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context, key string) (string, bool) {
+		for _, p := range providers {
+			if p == nil {
+				continue
+			}
+			if v, ok := p.Flag(ctx, key); ok {
+				return v, true
+			}
+		}
+		return "", false
+	})
+}
@@ -0,0 +1,80 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHelpersFallBackOnMissingOrInvalidValues(t *testing.T) {
+	ctx := context.Background()
+	p := Static{"on": "true", "steps": "7", "strategy": " json ", "bad": "maybe"}
+
+	if !Bool(ctx, p, "on", false) || Bool(ctx, p, "bad", false) || !Bool(ctx, p, "missing", true) {
+		t.Fatal("Bool did not honour values and fallbacks")
+	}
+	if got := Int(ctx, p, "steps", 3); got != 7 {
+		t.Fatalf("Int = %d, want 7", got)
+	}
+	if got := Int(ctx, p, "bad", 3); got != 3 {
+		t.Fatalf("Int(bad) = %d, want fallback 3", got)
+	}
+	if got := String(ctx, p, "strategy", ToolCallingNative); got != ToolCallingJSON {
+		t.Fatalf("String = %q, want json", got)
+	}
+	if got := String(ctx, nil, "strategy", ToolCallingNative); got != ToolCallingNative {
+		t.Fatalf("String(nil provider) = %q, want fallback", got)
+	}
+}
+
+func TestEnvAndChain(t *testing.T) {
+	t.Setenv("FLAGS_TEST_TOOL_CALLING", "off")
+	ctx := context.Background()
+	p := Chain(nil, Env{Prefix: "FLAGS_TEST_"}, Static{ToolCalling: "json", Summaries: "false"})
+
+	if got := String(ctx, p, ToolCalling, ""); got != ToolCallingOff {
+		t.Fatalf("tool_calling = %q, want the environment override", got)
+	}
+	if Bool(ctx, p, Summaries, true) {
+		t.Fatal("memory_summaries should fall through to the static provider")
+	}
+}
+
+func TestFileReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"auto_delegate": true}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	f.Interval = time.Nanosecond
+	ctx := context.Background()
+	if !Bool(ctx, f, AutoDelegate, false) {
+		t.Fatal("auto_delegate should be on")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"auto_delegate": false}`), 0o600); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if Bool(ctx, f, AutoDelegate, true) {
+		t.Fatal("auto_delegate should be off after the edit")
+	}
+
+	if err := os.WriteFile(path, []byte(`{not json`), 0o600); err != nil {
+		t.Fatalf("corrupt: %v", err)
+	}
+	if err := os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute)); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if Bool(ctx, f, AutoDelegate, true) {
+		t.Fatal("a broken edit should keep the last good values")
+	}
+}
@@ -0,0 +1,139 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/webhooks"
+)
+
+func fakePagerDuty(t *testing.T) (*PagerDuty, *[]string) {
+	t.Helper()
+	var updates []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token=tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/incidents/P1":
+			if r.Header.Get("From") == "" {
+				http.Error(w, "From required", http.StatusBadRequest)
+				return
+			}
+			var body struct {
+				Incident map[string]string `json:"incident"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			updates = append(updates, body.Incident["status"])
+			fmt.Fprintf(w, `{"incident":{"id":"P1","incident_number":42,"title":"API down","status":%q}}`, body.Incident["status"])
+		case r.URL.Path == "/incidents/P1/log_entries":
+			fmt.Fprint(w, `{"more":false,"log_entries":[
+				{"id":"L2","type":"acknowledge_log_entry","summary":"Acknowledged by Ada","created_at":"2024-05-01T10:05:00Z"},
+				{"id":"L1","type":"trigger_log_entry","summary":"Triggered via API","created_at":"2024-05-01T10:00:00Z"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &PagerDuty{Token: "tok", From: "oncall@example.com", BaseURL: srv.URL}, &updates
+}
+
+func TestToolWorksIncidentLifecycle(t *testing.T) {
+	ctx := context.Background()
+	pd, updates := fakePagerDuty(t)
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	spaces := &Spaces{Registry: mem.Spaces, Responders: []string{"agent:oncall"}}
+	tool := NewTool(pd, mem, spaces)
+
+	invoke := func(args map[string]any) agent.ToolResponse {
+		t.Helper()
+		resp, err := tool.Invoke(ctx, agent.ToolRequest{Arguments: args})
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return resp
+	}
+
+	invoke(map[string]any{"action": "acknowledge", "incident_id": "P1"})
+	if !mem.Spaces.CanWrite("incident:P1", "agent:oncall") {
+		t.Fatal("acknowledge should open the incident space for responders")
+	}
+
+	resp := invoke(map[string]any{"action": "timeline", "incident_id": "P1"})
+	if first := strings.Index(resp.Content, "Triggered"); first < 0 || first > strings.Index(resp.Content, "Acknowledged") {
+		t.Fatalf("timeline should be oldest first: %q", resp.Content)
+	}
+	invoke(map[string]any{"action": "timeline", "incident_id": "P1"})
+	var stored int
+	_ = store.Iterate(ctx, func(rec memory.MemoryRecord) bool {
+		if rec.SessionID != "incident:P1" || !strings.Contains(rec.Metadata, `"source":"pagerduty"`) {
+			t.Fatalf("timeline record misplaced: %+v", rec)
+		}
+		stored++
+		return true
+	})
+	if stored != 2 {
+		t.Fatalf("expected each log entry stored once, got %d", stored)
+	}
+
+	invoke(map[string]any{"action": "resolve", "incident_id": "P1", "resolution": "rolled back"})
+	if mem.Spaces.CanWrite("incident:P1", "agent:oncall") || !mem.Spaces.CanRead("incident:P1", "agent:oncall") {
+		t.Fatal("resolve should archive the space read-only")
+	}
+	if strings.Join(*updates, ",") != "acknowledged,resolved" {
+		t.Fatalf("updates = %v", *updates)
+	}
+}
+
+func TestWebhookHookOpensAndArchivesSpaces(t *testing.T) {
+	spaces := &Spaces{Registry: memory.NewSpaceRegistry(0), Responders: []string{"agent:oncall"}}
+	var sessions []string
+	runner := runnerFunc(func(_ context.Context, session, _ string) (any, error) {
+		sessions = append(sessions, session)
+		return "ok", nil
+	})
+	router, err := webhooks.NewRouter(map[string]webhooks.Runner{webhooks.DefaultAgent: runner}, webhooks.Route{
+		Path:    "/hooks/pagerduty",
+		Session: SessionTemplate,
+		Wait:    true,
+		Before:  spaces.WebhookHook(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliver := func(id, kind string) {
+		body := fmt.Sprintf(`{"event":{"id":%q,"event_type":%q,"data":{"id":"P9","type":"incident"}}}`, id, kind)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hooks/pagerduty", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d %s", kind, rec.Code, rec.Body)
+		}
+	}
+
+	deliver("e1", "incident.triggered")
+	if !spaces.Registry.CanWrite("incident:P9", "agent:oncall") {
+		t.Fatal("triggered incident should open its space")
+	}
+	deliver("e2", "incident.resolved")
+	if spaces.Registry.CanWrite("incident:P9", "agent:oncall") {
+		t.Fatal("resolved incident should archive its space")
+	}
+	if len(sessions) != 2 || sessions[0] != "incident:P9" {
+		t.Fatalf("runs went to %v", sessions)
+	}
+}
+
+type runnerFunc func(ctx context.Context, session, prompt string) (any, error)
+
+func (f runnerFunc) Generate(ctx context.Context, session, prompt string) (any, error) {
+	return f(ctx, session, prompt)
+}
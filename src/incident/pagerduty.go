@@ -0,0 +1,178 @@
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+)
+
+const pagerDutyAPIBase = "https://api.pagerduty.com"
+
+// PagerDuty is a minimal REST API v2 client for the incident actions an
+// agent needs.
+type PagerDuty struct {
+	Token string
+	// From is the email of the PagerDuty user acknowledging or resolving;
+	// the API requires it for incident updates.
+	From string
+	// BaseURL overrides the API endpoint; used by tests.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewPagerDutyFromEnv reads PAGERDUTY_API_TOKEN and PAGERDUTY_FROM_EMAIL.
+func NewPagerDutyFromEnv() *PagerDuty {
+	return &PagerDuty{
+		Token: secrets.Lookup("PAGERDUTY_API_TOKEN", "PAGERDUTY_TOKEN"),
+		From:  secrets.Lookup("PAGERDUTY_FROM_EMAIL", "PAGERDUTY_FROM"),
+	}
+}
+
+// Incident is the subset of a PagerDuty incident the tools report.
+type Incident struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"incident_number"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	Urgency   string    `json:"urgency"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	Service   struct {
+		Summary string `json:"summary"`
+	} `json:"service"`
+}
+
+// TimelineEntry is one incident log entry.
+type TimelineEntry struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+	Agent     struct {
+		Summary string `json:"summary"`
+	} `json:"agent"`
+}
+
+// Incident fetches one incident.
+func (p *PagerDuty) Incident(ctx context.Context, id string) (Incident, error) {
+	var resp struct {
+		Incident Incident `json:"incident"`
+	}
+	err := p.call(ctx, http.MethodGet, "/incidents/"+url.PathEscape(id), nil, nil, &resp)
+	return resp.Incident, err
+}
+
+// Acknowledge marks the incident acknowledged.
+func (p *PagerDuty) Acknowledge(ctx context.Context, id string) (Incident, error) {
+	return p.update(ctx, id, map[string]any{"status": "acknowledged"})
+}
+
+// Resolve marks the incident resolved, recording resolution when given.
+func (p *PagerDuty) Resolve(ctx context.Context, id, resolution string) (Incident, error) {
+	fields := map[string]any{"status": "resolved"}
+	if resolution != "" {
+		fields["resolution"] = resolution
+	}
+	return p.update(ctx, id, fields)
+}
+
+func (p *PagerDuty) update(ctx context.Context, id string, fields map[string]any) (Incident, error) {
+	if p.From == "" {
+		return Incident{}, fmt.Errorf("pagerduty: a From email is required to update incidents")
+	}
+	fields["type"] = "incident_reference"
+	var resp struct {
+		Incident Incident `json:"incident"`
+	}
+	err := p.call(ctx, http.MethodPut, "/incidents/"+url.PathEscape(id), nil, map[string]any{"incident": fields}, &resp)
+	return resp.Incident, err
+}
+
+// Timeline returns the incident's log entries, oldest first.
+func (p *PagerDuty) Timeline(ctx context.Context, id string) ([]TimelineEntry, error) {
+	var entries []TimelineEntry
+	const limit = 100
+	for offset := 0; ; offset += limit {
+		query := url.Values{
+			"is_overview": {"true"},
+			"limit":       {strconv.Itoa(limit)},
+			"offset":      {strconv.Itoa(offset)},
+		}
+		var page struct {
+			LogEntries []TimelineEntry `json:"log_entries"`
+			More       bool            `json:"more"`
+		}
+		if err := p.call(ctx, http.MethodGet, "/incidents/"+url.PathEscape(id)+"/log_entries", query, nil, &page); err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.LogEntries...)
+		if !page.More || len(page.LogEntries) == 0 {
+			break
+		}
+	}
+	// The API lists newest first.
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+func (p *PagerDuty) call(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	if p.Token == "" {
+		return fmt.Errorf("pagerduty: API token is empty")
+	}
+	base := p.BaseURL
+	if base == "" {
+		base = pagerDutyAPIBase
+	}
+	u := strings.TrimRight(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token token="+p.Token)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.From != "" {
+		req.Header.Set("From", p.From)
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pagerduty: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("pagerduty: %s %s: http %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("pagerduty: decode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package incident bundles what an agent needs to work an incident: a
+// PagerDuty tool, a shared incident:<id> space per incident that opens when
+// the incident triggers and is archived when it resolves, and helpers to
+// drive both from PagerDuty webhooks.
+//
+// Timeline entries stored by the tool carry the "pagerduty" memory source,
+// so a source boost such as "pagerduty=1.5" ranks them above chatter.
+package incident
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/webhooks"
+)
+
+const (
+	// SpacePrefix prefixes incident space names.
+	SpacePrefix = "incident:"
+	// Source is the memory source recorded on PagerDuty-derived records.
+	Source = "pagerduty"
+	// DefaultRetention is how long an archived incident space stays
+	// readable for post-incident review.
+	DefaultRetention = 30 * 24 * time.Hour
+)
+
+// SpaceName returns the shared space for incident id.
+func SpaceName(id string) string { return SpacePrefix + strings.TrimSpace(id) }
+
+// Spaces opens and archives incident spaces in a registry.
+type Spaces struct {
+	Registry *memory.SpaceRegistry
+	// Responders are granted writer on every incident space, typically
+	// the responding agents and the on-call rotation.
+	Responders []string
+	// TTL bounds how long an open incident space lives; zero uses the
+	// registry default.
+	TTL time.Duration
+	// Retention defaults to DefaultRetention.
+	Retention time.Duration
+}
+
+// Open creates (or refreshes) the incident's space and grants writers to
+// the configured responders plus any given here. It returns the space name.
+func (s *Spaces) Open(id string, responders ...string) (string, error) {
+	if s == nil || s.Registry == nil {
+		return "", fmt.Errorf("incident: no space registry configured")
+	}
+	if strings.TrimSpace(id) == "" {
+		return "", fmt.Errorf("incident: incident id is empty")
+	}
+	name := SpaceName(id)
+	acl := map[string]memory.SpaceRole{}
+	for _, r := range append(append([]string(nil), s.Responders...), responders...) {
+		if r = strings.TrimSpace(r); r != "" {
+			acl[r] = memory.SpaceRoleWriter
+		}
+	}
+	s.Registry.Upsert(name, s.TTL, acl)
+	return name, nil
+}
+
+// Archive downgrades the incident space's writers to readers and lets the
+// space expire after the retention period; admins keep their role so they
+// can reopen it. Archiving an unknown incident is a no-op.
+func (s *Spaces) Archive(id string) error {
+	if s == nil || s.Registry == nil {
+		return fmt.Errorf("incident: no space registry configured")
+	}
+	name := SpaceName(id)
+	space, ok := s.Registry.Get(name)
+	if !ok {
+		return nil
+	}
+	retention := s.Retention
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	for principal, role := range space.ACL {
+		if role != memory.SpaceRoleWriter {
+			continue
+		}
+		if err := s.Registry.Grant(name, principal, memory.SpaceRoleReader, retention); err != nil {
+			return err
+		}
+	}
+	s.Registry.Upsert(name, retention, nil)
+	return nil
+}
+
+// WebhookHook returns a webhooks.Route Before hook for PagerDuty v3
+// webhooks: incident.triggered opens the incident space, incident.resolved
+// archives it.
+func (s *Spaces) WebhookHook() func(context.Context, webhooks.Event) error {
+	return func(_ context.Context, ev webhooks.Event) error {
+		data := pagerDutyEventData(ev)
+		id, _ := data["id"].(string)
+		if id == "" {
+			return nil
+		}
+		switch ev.Kind {
+		case "incident.triggered", "incident.reopened":
+			_, err := s.Open(id)
+			return err
+		case "incident.resolved":
+			return s.Archive(id)
+		}
+		return nil
+	}
+}
+
+// SessionTemplate is a webhooks session template that routes PagerDuty
+// incident events to the incident's space.
+const SessionTemplate = SpacePrefix + "{{.Payload.event.data.id}}"
+
+func pagerDutyEventData(ev webhooks.Event) map[string]any {
+	payload, _ := ev.Payload.(map[string]any)
+	event, _ := payload["event"].(map[string]any)
+	data, _ := event["data"].(map[string]any)
+	return data
+}
@@ -0,0 +1,193 @@
+package incident
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// Tool exposes PagerDuty to an agent as the "pagerduty" tool with the
+// actions get, acknowledge, resolve and timeline.
+type Tool struct {
+	Client *PagerDuty
+	// Memory, when set, receives timeline entries in the incident space so
+	// every agent working the incident can retrieve them.
+	Memory *memory.SessionMemory
+	// Spaces, when set, opens the incident space on acknowledge and
+	// archives it on resolve.
+	Spaces *Spaces
+
+	mu     sync.Mutex
+	stored map[string]bool // log entry IDs already written to memory
+}
+
+// NewTool wires a PagerDuty tool. mem and spaces may be nil.
+func NewTool(client *PagerDuty, mem *memory.SessionMemory, spaces *Spaces) *Tool {
+	return &Tool{Client: client, Memory: mem, Spaces: spaces}
+}
+
+func (t *Tool) Spec() agent.ToolSpec {
+	return agent.ToolSpec{
+		Name:        "pagerduty",
+		Description: "Works a PagerDuty incident: fetch it, acknowledge it, resolve it, or read its timeline.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"get", "acknowledge", "resolve", "timeline"},
+					"description": "What to do with the incident.",
+				},
+				"incident_id": map[string]any{
+					"type":        "string",
+					"description": "PagerDuty incident ID, e.g. PGR0VU2.",
+				},
+				"resolution": map[string]any{
+					"type":        "string",
+					"description": "Optional resolution note when resolving.",
+				},
+			},
+			"required": []string{"action", "incident_id"},
+		},
+	}
+}
+
+func (t *Tool) Invoke(ctx context.Context, req agent.ToolRequest) (agent.ToolResponse, error) {
+	if t.Client == nil {
+		return agent.ToolResponse{}, fmt.Errorf("pagerduty tool has no client")
+	}
+	action, _ := req.Arguments["action"].(string)
+	id, _ := req.Arguments["incident_id"].(string)
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return agent.ToolResponse{}, fmt.Errorf("incident_id is required")
+	}
+	meta := map[string]string{"incident_id": id, "space": SpaceName(id)}
+
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "get":
+		inc, err := t.Client.Incident(ctx, id)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		return agent.ToolResponse{Content: describe(inc), Metadata: meta}, nil
+	case "acknowledge", "ack":
+		inc, err := t.Client.Acknowledge(ctx, id)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if t.Spaces != nil {
+			if _, err := t.Spaces.Open(id); err != nil {
+				return agent.ToolResponse{}, err
+			}
+		}
+		return agent.ToolResponse{Content: "Acknowledged. " + describe(inc), Metadata: meta}, nil
+	case "resolve":
+		resolution, _ := req.Arguments["resolution"].(string)
+		inc, err := t.Client.Resolve(ctx, id, strings.TrimSpace(resolution))
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if t.Spaces != nil {
+			if err := t.Spaces.Archive(id); err != nil {
+				return agent.ToolResponse{}, err
+			}
+		}
+		return agent.ToolResponse{Content: "Resolved. " + describe(inc), Metadata: meta}, nil
+	case "timeline":
+		entries, err := t.Client.Timeline(ctx, id)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if err := t.remember(ctx, id, entries); err != nil {
+			return agent.ToolResponse{}, err
+		}
+		var sb strings.Builder
+		for _, e := range entries {
+			fmt.Fprintf(&sb, "%s  %s", e.CreatedAt.UTC().Format("2006-01-02 15:04:05Z"), e.Summary)
+			if e.Agent.Summary != "" {
+				fmt.Fprintf(&sb, " (%s)", e.Agent.Summary)
+			}
+			sb.WriteString("\n")
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("No timeline entries.")
+		}
+		meta["entries"] = fmt.Sprint(len(entries))
+		return agent.ToolResponse{Content: strings.TrimSpace(sb.String()), Metadata: meta}, nil
+	}
+	return agent.ToolResponse{}, fmt.Errorf("unknown pagerduty action %q", action)
+}
+
+// remember stores timeline entries not written before in the incident
+// space, tagged with the pagerduty source.
+func (t *Tool) remember(ctx context.Context, id string, entries []TimelineEntry) error {
+	if t.Memory == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stored == nil {
+		t.stored = map[string]bool{}
+	}
+	space := SpaceName(id)
+	for _, e := range entries {
+		if e.ID == "" || t.stored[e.ID] {
+			continue
+		}
+		content := fmt.Sprintf("[%s %s] %s", space, e.CreatedAt.UTC().Format("2006-01-02 15:04:05Z"), e.Summary)
+		meta := map[string]any{
+			"source":       Source,
+			"incident_id":  id,
+			"log_entry_id": e.ID,
+			"entry_type":   e.Type,
+		}
+		if err := storeRecord(ctx, t.Memory, space, content, meta); err != nil {
+			return fmt.Errorf("store timeline entry %s: %w", e.ID, err)
+		}
+		t.stored[e.ID] = true
+	}
+	return nil
+}
+
+func storeRecord(ctx context.Context, mem *memory.SessionMemory, space, content string, meta map[string]any) error {
+	if mem.Engine != nil {
+		_, err := mem.Engine.Store(ctx, space, content, meta)
+		return err
+	}
+	embedding, err := mem.Embed(ctx, content)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return mem.Bank.StoreMemory(ctx, space, content, string(raw), embedding)
+}
+
+func describe(inc Incident) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Incident #%d %s: %s", inc.Number, inc.ID, inc.Title)
+	if inc.Status != "" {
+		fmt.Fprintf(&sb, " [%s", inc.Status)
+		if inc.Urgency != "" {
+			fmt.Fprintf(&sb, ", %s urgency", inc.Urgency)
+		}
+		sb.WriteString("]")
+	}
+	if inc.Service.Summary != "" {
+		fmt.Fprintf(&sb, " on %s", inc.Service.Summary)
+	}
+	if inc.HTMLURL != "" {
+		sb.WriteString(" — " + inc.HTMLURL)
+	}
+	return sb.String()
+}
+
+var _ agent.Tool = (*Tool)(nil)
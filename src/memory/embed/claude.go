@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 )
 
 // ClaudeEmbedder proxies to Anthropic-recommended Voyage AI embeddings.
@@ -27,7 +29,7 @@ type ClaudeEmbedder struct {
 }
 
 func NewClaudeEmbedder(model string) (Embedder, error) {
-	apiKey := os.Getenv("VOYAGE_API_KEY")
+	apiKey := secrets.Lookup("VOYAGE_API_KEY")
 	if model == "" {
 		model = "voyage-3.5"
 	}
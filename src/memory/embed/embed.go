@@ -3,8 +3,7 @@ package embed
 import (
 	"context"
 	"errors"
-	"os"
-	"strings"
+	"fmt"
 )
 
 // Embedder is a pluggable text-embedding provider.
@@ -12,6 +11,36 @@ type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 }
 
+// BatchEmbedder is an Embedder that embeds several texts in one call.
+type BatchEmbedder interface {
+	Embedder
+	EmbedMany(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedMany embeds texts with one EmbedMany call when e supports it and one
+// Embed call per text otherwise.
+func EmbedMany(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	if b, ok := e.(BatchEmbedder); ok {
+		vecs, err := b.EmbedMany(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vecs) != len(texts) {
+			return nil, fmt.Errorf("embed many: got %d vectors for %d texts", len(vecs), len(texts))
+		}
+		return vecs, nil
+	}
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
+
 // ---------- Dummy (fallback) ----------
 type DummyEmbedder struct{}
 
@@ -29,39 +58,25 @@ func DummyEmbedding(text string) []float32 {
 }
 
 // AutoEmbedder chooses a provider from env:
-// ADK_EMBED_PROVIDER=openai|google|gemini|ollama|claude
+// ADK_EMBED_PROVIDER=openai|google|gemini|ollama|claude|fastembed
 // ADK_EMBED_MODEL=<model string>
-// If not set, it infers from available API keys/OLLAMA_HOST, else dummy.
+// ADK_EMBED_FALLBACK=warn|dummy|strict
+// When no provider can be built it falls back to DummyEmbedder, logging a
+// warning unless the fallback mode is dummy. In strict mode the returned
+// embedder fails every call; use ResolveEmbedder to fail at startup instead.
 func AutoEmbedder() Embedder {
-	provider := strings.ToLower(strings.TrimSpace(os.Getenv("ADK_EMBED_PROVIDER")))
-	model := strings.TrimSpace(os.Getenv("ADK_EMBED_MODEL"))
-
-	switch provider {
-	case "openai":
-		if e, err := NewOpenAIEmbedder(model); err == nil {
-			return e
-		}
-	case "google", "gemini", "vertex", "vertexai":
-		if e, err := NewVertexAIEmbedder(model); err == nil {
-			return e
-		}
-	case "ollama":
-		if e, err := NewOllamaEmbedder(model); err == nil {
-			return e
-		}
-	case "claude", "anthropic":
-		if e, err := NewClaudeEmbedder(model); err == nil {
-			return e
-		}
-	case "fastembed":
-		if opts := defaultFastEmbedOptions(); opts != nil {
-			if e, err := NewFastEmbeed(context.Background(), opts); err == nil {
-				return e
-			}
-		}
+	mode := FallbackModeFromEnv()
+	e, err := ResolveEmbedder(mode)
+	switch {
+	case err == nil:
+		return e
+	case mode == FallbackStrict:
+		logFallback(err)
+		return unavailableEmbedder{err: err}
+	case mode == FallbackWarn:
+		logFallback(err)
 	}
-
-	return DummyEmbedder{}
+	return e
 }
 
 // safeEmbed is a helper that never fails (falls back to DummyEmbedding).
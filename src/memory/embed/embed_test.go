@@ -76,3 +76,33 @@ func TestAutoEmbedderFallback(t *testing.T) {
 		t.Fatalf("expected AutoEmbedder to fall back to DummyEmbedder, got %T", embedder)
 	}
 }
+
+func TestResolveEmbedderFallbackModes(t *testing.T) {
+	t.Setenv("ADK_EMBED_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_KEY", "")
+
+	e, err := ResolveEmbedder(FallbackWarn)
+	var warning *FallbackWarning
+	if !errors.As(err, &warning) || warning.Provider != "openai" || warning.Reason == nil {
+		t.Fatalf("expected typed fallback warning, got %v", err)
+	}
+	if _, ok := e.(DummyEmbedder); !ok {
+		t.Fatalf("expected DummyEmbedder alongside the warning, got %T", e)
+	}
+
+	e, err = ResolveEmbedder(FallbackStrict)
+	if e != nil || !errors.Is(err, ErrEmbedderUnavailable) || !errors.As(err, &warning) {
+		t.Fatalf("strict mode: got embedder %T err %v", e, err)
+	}
+}
+
+func TestAutoEmbedderStrictFailsCalls(t *testing.T) {
+	t.Setenv("ADK_EMBED_PROVIDER", "")
+	t.Setenv("ADK_EMBED_FALLBACK", "strict")
+
+	_, err := AutoEmbedder().Embed(context.Background(), "query")
+	if !IsUnavailable(err) {
+		t.Fatalf("expected strict embedder to fail, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package embed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FallbackMode selects what AutoEmbedder does when no real provider can be
+// configured. It is read from ADK_EMBED_FALLBACK.
+type FallbackMode string
+
+const (
+	// FallbackWarn returns DummyEmbedder and logs a prominent warning. This is
+	// the default.
+	FallbackWarn FallbackMode = "warn"
+	// FallbackDummy returns DummyEmbedder silently, for tests and offline demos
+	// that knowingly rely on hash embeddings.
+	FallbackDummy FallbackMode = "dummy"
+	// FallbackStrict refuses to fall back: ResolveEmbedder returns an error and
+	// AutoEmbedder returns an embedder whose every call fails.
+	FallbackStrict FallbackMode = "strict"
+)
+
+// ErrEmbedderUnavailable is returned in strict mode when no embedding
+// provider could be configured.
+var ErrEmbedderUnavailable = errors.New("embed: no embedding provider available")
+
+// FallbackWarning explains why the dummy embedder was selected. Retrieval
+// over dummy vectors is effectively keyword hashing, so callers that care
+// about recall should surface it.
+type FallbackWarning struct {
+	// Provider is the requested ADK_EMBED_PROVIDER, empty when none was set.
+	Provider string
+	// Reason is the construction error, nil when no provider was requested.
+	Reason error
+}
+
+func (w *FallbackWarning) Error() string {
+	if w.Provider == "" {
+		return "embed: ADK_EMBED_PROVIDER not set; using dummy embeddings (retrieval quality will be poor)"
+	}
+	if w.Reason == nil {
+		return fmt.Sprintf("embed: unknown provider %q; using dummy embeddings (retrieval quality will be poor)", w.Provider)
+	}
+	return fmt.Sprintf("embed: provider %q unavailable: %v; using dummy embeddings (retrieval quality will be poor)", w.Provider, w.Reason)
+}
+
+func (w *FallbackWarning) Unwrap() error { return w.Reason }
+
+// FallbackModeFromEnv reads ADK_EMBED_FALLBACK, defaulting to FallbackWarn
+// for empty or unrecognised values.
+func FallbackModeFromEnv() FallbackMode {
+	switch mode := FallbackMode(strings.ToLower(strings.TrimSpace(os.Getenv("ADK_EMBED_FALLBACK")))); mode {
+	case FallbackDummy, FallbackStrict:
+		return mode
+	}
+	return FallbackWarn
+}
+
+// ResolveEmbedder is AutoEmbedder with the fallback made explicit. When a
+// provider is configured it returns it with a nil error. Otherwise, in warn
+// and dummy modes it returns DummyEmbedder together with a *FallbackWarning;
+// in strict mode it returns a nil Embedder and an error matching both
+// ErrEmbedderUnavailable and *FallbackWarning.
+func ResolveEmbedder(mode FallbackMode) (Embedder, error) {
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("ADK_EMBED_PROVIDER")))
+	model := strings.TrimSpace(os.Getenv("ADK_EMBED_MODEL"))
+
+	e, err := newProviderEmbedder(provider, model)
+	if err == nil && e != nil {
+		return e, nil
+	}
+
+	warning := &FallbackWarning{Provider: provider, Reason: err}
+	if mode == FallbackStrict {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedderUnavailable, warning)
+	}
+	return DummyEmbedder{}, warning
+}
+
+func newProviderEmbedder(provider, model string) (Embedder, error) {
+	switch provider {
+	case "":
+		return nil, nil
+	case "openai":
+		return NewOpenAIEmbedder(model)
+	case "google", "gemini", "vertex", "vertexai":
+		return NewVertexAIEmbedder(model)
+	case "ollama":
+		return NewOllamaEmbedder(model)
+	case "claude", "anthropic":
+		return NewClaudeEmbedder(model)
+	case "voyage-multimodal":
+		return NewVoyageMultimodalEmbedder(model)
+	case "vertex-multimodal":
+		return NewVertexMultimodalEmbedder(context.Background(), model, 0)
+	case "fastembed":
+		opts := defaultFastEmbedOptions()
+		if opts == nil {
+			return nil, errors.New("fastembed options unavailable")
+		}
+		return NewFastEmbeed(context.Background(), opts)
+	}
+	return nil, nil
+}
+
+// unavailableEmbedder is what AutoEmbedder hands out in strict mode so that
+// the first Store or Retrieve fails instead of silently using dummy vectors.
+type unavailableEmbedder struct{ err error }
+
+func (u unavailableEmbedder) Embed(context.Context, string) ([]float32, error) {
+	return nil, u.err
+}
+
+// IsUnavailable reports whether err came from a strict-mode embedder, which
+// callers must propagate rather than replace with DummyEmbedding.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrEmbedderUnavailable)
+}
+
+var fallbackLogged sync.Map // warning text -> struct{}
+
+// logFallback prints each distinct warning once per process; AutoEmbedder is
+// called for every engine and session memory, and repeating it adds nothing.
+func logFallback(err error) {
+	msg := err.Error()
+	if _, seen := fallbackLogged.LoadOrStore(msg, struct{}{}); seen {
+		return
+	}
+	log.Printf("WARNING: %s (set ADK_EMBED_FALLBACK=strict to fail instead, or =dummy to silence)", msg)
+}
@@ -75,3 +75,8 @@ func (e *FastEmbedder) EmbedPassages(ctx context.Context, docs []string) ([][]fl
 func (e *FastEmbedder) Embed(ctx context.Context, q string) ([]float32, error) {
 	return e.m.QueryEmbed(q)
 }
+
+// EmbedMany embeds stored content, which FastEmbed treats as passages.
+func (e *FastEmbedder) EmbedMany(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.EmbedPassages(ctx, texts)
+}
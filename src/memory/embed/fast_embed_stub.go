@@ -26,3 +26,7 @@ func (FastEmbedder) EmbedPassages(ctx context.Context, docs []string) ([][]float
 func (FastEmbedder) Embed(ctx context.Context, q string) ([]float32, error) {
 	return nil, fmt.Errorf("fastembed support not included")
 }
+
+func (FastEmbedder) EmbedMany(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, fmt.Errorf("fastembed support not included")
+}
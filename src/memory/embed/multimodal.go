@@ -0,0 +1,191 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// ImageEmbedder is an Embedder that also embeds images, into the same
+// vector space as its text embeddings (CLIP-style models, Gemini and
+// Voyage multimodal embeddings), so a text query can retrieve an image.
+type ImageEmbedder interface {
+	Embedder
+	EmbedImage(ctx context.Context, data []byte, mimeType string) ([]float32, error)
+}
+
+// VoyageMultimodalEmbedder embeds text and images with Voyage AI's
+// multimodal model. Requires VOYAGE_API_KEY.
+// Defaults:
+//   - model: "voyage-multimodal-3"
+//   - endpoint: "https://api.voyageai.com/v1/multimodalembeddings" (override via VOYAGE_API_BASE)
+type VoyageMultimodalEmbedder struct {
+	client   *http.Client
+	apiKey   string
+	model    string
+	endpoint string
+}
+
+func NewVoyageMultimodalEmbedder(model string) (*VoyageMultimodalEmbedder, error) {
+	apiKey := secrets.Lookup("VOYAGE_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("missing VOYAGE_API_KEY")
+	}
+	if model == "" {
+		model = "voyage-multimodal-3"
+	}
+	endpoint := os.Getenv("VOYAGE_API_BASE")
+	if endpoint == "" {
+		endpoint = "https://api.voyageai.com/v1/multimodalembeddings"
+	}
+	return &VoyageMultimodalEmbedder{
+		client:   &http.Client{Timeout: 60 * time.Second},
+		apiKey:   apiKey,
+		model:    model,
+		endpoint: endpoint,
+	}, nil
+}
+
+func (v *VoyageMultimodalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return v.embed(ctx, map[string]any{"type": "text", "text": text})
+}
+
+func (v *VoyageMultimodalEmbedder) EmbedImage(ctx context.Context, data []byte, mimeType string) ([]float32, error) {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return v.embed(ctx, map[string]any{
+		"type":         "image_base64",
+		"image_base64": "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+func (v *VoyageMultimodalEmbedder) embed(ctx context.Context, content map[string]any) ([]float32, error) {
+	payload := map[string]any{
+		"model":  v.model,
+		"inputs": []any{map[string]any{"content": []any{content}}},
+	}
+	var out struct {
+		Data []struct {
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := postJSON(ctx, v.client, v.endpoint, "Bearer "+v.apiKey, payload, &out); err != nil {
+		return nil, fmt.Errorf("voyage multimodal embeddings: %w", err)
+	}
+	if len(out.Data) == 0 || len(out.Data[0].Embedding) == 0 {
+		return nil, ErrNotSupported
+	}
+	return f64toF32(out.Data[0].Embedding), nil
+}
+
+// VertexMultimodalEmbedder embeds text and images with Vertex AI's
+// multimodalembedding model, authenticating with Application Default
+// Credentials.
+// Defaults:
+//   - model: "multimodalembedding@001"
+//   - project: GOOGLE_CLOUD_PROJECT
+//   - location: GOOGLE_CLOUD_LOCATION, else "us-central1"
+type VertexMultimodalEmbedder struct {
+	client    *http.Client
+	endpoint  string
+	dimension int
+}
+
+// NewVertexMultimodalEmbedder builds the embedder. dimension is 128, 256,
+// 512 or 1408; 0 keeps the model default.
+func NewVertexMultimodalEmbedder(ctx context.Context, model string, dimension int) (*VertexMultimodalEmbedder, error) {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		return nil, errors.New("missing GOOGLE_CLOUD_PROJECT")
+	}
+	location := os.Getenv("GOOGLE_CLOUD_LOCATION")
+	if location == "" {
+		location = "us-central1"
+	}
+	if model == "" {
+		model = "multimodalembedding@001"
+	}
+	client, _, err := htransport.NewClient(ctx, option.WithScopes("https://www.googleapis.com/auth/cloud-platform"))
+	if err != nil {
+		return nil, err
+	}
+	return &VertexMultimodalEmbedder{
+		client: client,
+		endpoint: fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/google/models/%s:predict",
+			location, project, location, model),
+		dimension: dimension,
+	}, nil
+}
+
+func (v *VertexMultimodalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return v.predict(ctx, map[string]any{"text": text}, false)
+}
+
+func (v *VertexMultimodalEmbedder) EmbedImage(ctx context.Context, data []byte, _ string) ([]float32, error) {
+	return v.predict(ctx, map[string]any{"image": map[string]any{"bytesBase64Encoded": base64.StdEncoding.EncodeToString(data)}}, true)
+}
+
+func (v *VertexMultimodalEmbedder) predict(ctx context.Context, instance map[string]any, image bool) ([]float32, error) {
+	payload := map[string]any{"instances": []any{instance}}
+	if v.dimension > 0 {
+		payload["parameters"] = map[string]any{"dimension": v.dimension}
+	}
+	var out struct {
+		Predictions []struct {
+			TextEmbedding  []float64 `json:"textEmbedding"`
+			ImageEmbedding []float64 `json:"imageEmbedding"`
+		} `json:"predictions"`
+	}
+	if err := postJSON(ctx, v.client, v.endpoint, "", payload, &out); err != nil {
+		return nil, fmt.Errorf("vertex multimodal embeddings: %w", err)
+	}
+	if len(out.Predictions) == 0 {
+		return nil, ErrNotSupported
+	}
+	vec := out.Predictions[0].TextEmbedding
+	if image {
+		vec = out.Predictions[0].ImageEmbedding
+	}
+	if len(vec) == 0 {
+		return nil, ErrNotSupported
+	}
+	return f64toF32(vec), nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint, authorization string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		slurp, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(slurp)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,69 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVoyageMultimodalEmbedderRequests(t *testing.T) {
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.5,0.25]}]}`))
+	}))
+	defer srv.Close()
+
+	v := &VoyageMultimodalEmbedder{client: srv.Client(), apiKey: "key", model: "voyage-multimodal-3", endpoint: srv.URL}
+	var _ ImageEmbedder = v
+	if vec, err := v.Embed(context.Background(), "auth flow"); err != nil || len(vec) != 2 || vec[0] != 0.5 {
+		t.Fatalf("Embed = %v, %v", vec, err)
+	}
+	if _, err := v.EmbedImage(context.Background(), []byte("\x89PNG\r\n\x1a\n"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	content := func(body map[string]any) map[string]any {
+		inputs := body["inputs"].([]any)
+		return inputs[0].(map[string]any)["content"].([]any)[0].(map[string]any)
+	}
+	if c := content(bodies[0]); c["type"] != "text" || c["text"] != "auth flow" {
+		t.Fatalf("text request content = %v", c)
+	}
+	if c := content(bodies[1]); c["type"] != "image_base64" || !strings.HasPrefix(c["image_base64"].(string), "data:image/png;base64,") {
+		t.Fatalf("image request content = %v", c)
+	}
+}
+
+func TestVertexMultimodalEmbedderPicksModality(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Instances  []map[string]any `json:"instances"`
+			Parameters map[string]any   `json:"parameters"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Parameters["dimension"] != float64(128) {
+			t.Errorf("parameters = %v", body.Parameters)
+		}
+		_, _ = w.Write([]byte(`{"predictions":[{"textEmbedding":[1,0],"imageEmbedding":[0,1]}]}`))
+	}))
+	defer srv.Close()
+
+	v := &VertexMultimodalEmbedder{client: srv.Client(), endpoint: srv.URL, dimension: 128}
+	text, err := v.Embed(context.Background(), "diagram")
+	if err != nil || text[0] != 1 {
+		t.Fatalf("Embed = %v, %v", text, err)
+	}
+	image, err := v.EmbedImage(context.Background(), []byte("png"), "image/png")
+	if err != nil || image[1] != 1 {
+		t.Fatalf("EmbedImage = %v, %v", image, err)
+	}
+}
@@ -2,8 +2,9 @@ package embed
 
 import (
 	"context"
-	"os"
+	"errors"
 
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -13,9 +14,9 @@ type OpenAIEmbedder struct {
 }
 
 func NewOpenAIEmbedder(model string) (Embedder, error) {
-	key := os.Getenv("OPENAI_API_KEY")
+	key := secrets.Lookup("OPENAI_API_KEY", "OPENAI_KEY")
 	if key == "" {
-		key = os.Getenv("OPENAI_KEY")
+		return nil, errors.New("missing OPENAI_API_KEY or OPENAI_KEY")
 	}
 	cfg := openai.DefaultConfig(key)
 	cli := openai.NewClientWithConfig(cfg)
@@ -40,3 +41,28 @@ func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 	// resp.Data[0].Embedding is []float32 in go-openai
 	return resp.Data[0].Embedding, nil
 }
+
+// EmbedMany embeds texts in a single request.
+func (e *OpenAIEmbedder) EmbedMany(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model: openai.EmbeddingModel(e.model),
+		Input: texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, ErrNotSupported
+	}
+	out := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(out) || len(d.Embedding) == 0 {
+			return nil, ErrNotSupported
+		}
+		out[d.Index] = d.Embedding
+	}
+	return out, nil
+}
@@ -3,8 +3,8 @@ package embed
 import (
 	"context"
 	"errors"
-	"os"
 
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 	genai "github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
@@ -15,10 +15,7 @@ type VertexAIEmbedder struct {
 }
 
 func NewVertexAIEmbedder(model string) (Embedder, error) {
-	apiKey := os.Getenv("GOOGLE_API_KEY")
-	if apiKey == "" {
-		apiKey = os.Getenv("GEMINI_API_KEY")
-	}
+	apiKey := secrets.Lookup("GOOGLE_API_KEY", "GEMINI_API_KEY")
 	if apiKey == "" {
 		return nil, errors.New("missing GOOGLE_API_KEY or GEMINI_API_KEY")
 	}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// RetrieveAsOf answers query the way Retrieve would have at asOf: only
+// records of the session created at or before asOf are considered, graph
+// edges to later records are dropped before the neighbourhood is expanded,
+// and recency is measured from asOf. It scans the store instead of using
+// its vector index, so it is meant for audits rather than the hot path.
+// Records deleted or pruned since asOf cannot be reconstructed, and stored
+// importance reflects its current value. Like Retrieve, it only returns
+// records of the tenant on ctx that its identity may see. RetrieveAsOf
+// never writes to the store.
+func (e *Engine) RetrieveAsOf(ctx context.Context, sessionID, query string, limit int, asOf time.Time) ([]model.MemoryRecord, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	keywords := extractKeywords(query)
+	embedding, err := e.embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+
+	// Records the caller may not see are dropped before scoring, so they
+	// never crowd visible ones out of the candidates.
+	id, _ := model.IdentityFromContext(ctx)
+	var known []model.MemoryRecord
+	err = e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if sessionID != "" && rec.SessionID != sessionID {
+			return true
+		}
+		if rec.CreatedAt.After(asOf) {
+			return true
+		}
+		if !ownedBy(ctx, rec) || !model.RecordACL(rec).Allows(id) || isTombstoned(rec, asOf) {
+			return true
+		}
+		known = append(known, rec)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(known) == 0 {
+		return nil, nil
+	}
+	graph := newAsOfGraph(known)
+
+	similarityQuery := model.NewCosineQuery(embedding)
+	for i := range known {
+		known[i].Score = similarityQuery.MaxSimilarity(known[i])
+	}
+	sort.SliceStable(known, func(i, j int) bool { return known[i].Score > known[j].Score })
+	searchLimit := limit * 4
+	if searchLimit < limit {
+		searchLimit = limit
+	}
+	candidates := append([]model.MemoryRecord(nil), known[:min(searchLimit, len(known))]...)
+
+	var lexical map[string]float64
+	if e.hybridEnabled() {
+		hits, err := e.lexicalCandidates(ctx, sessionID, query, searchLimit)
+		if err != nil {
+			e.logf("lexical search: %v", err)
+		} else {
+			// The index reflects the store today; keep hits that existed
+			// at asOf and use their historical edges.
+			existing := hits[:0]
+			for _, hit := range hits {
+				if rec, ok := graph.byKey[lexicalKey(hit.rec)]; ok {
+					hit.rec = rec
+					existing = append(existing, hit)
+				}
+			}
+			candidates, lexical = mergeLexical(candidates, existing)
+		}
+	}
+	if e.opts.GraphNeighborhoodLimit > 0 {
+		candidates = append(candidates, graph.neighborhood(candidates, e.opts.GraphNeighborhoodHops, e.opts.GraphNeighborhoodLimit)...)
+	}
+	return e.rank(ctx, sessionID, embedding, keywords, candidates, lexical, limit, asOf.UTC(), false)
+}
+
+// asOfGraph is the memory graph restricted to the records that existed
+// at a point in time. An edge only existed once both of its endpoints did.
+type asOfGraph struct {
+	byID     map[int64]model.MemoryRecord
+	byKey    map[string]model.MemoryRecord
+	adjacent map[int64][]asOfEdge
+}
+
+// asOfEdge is one side of an edge: the memory it leads to and its weight.
+type asOfEdge struct {
+	to     int64
+	weight float64
+}
+
+// newAsOfGraph indexes known and trims each record's GraphEdges to
+// targets inside known.
+func newAsOfGraph(known []model.MemoryRecord) asOfGraph {
+	g := asOfGraph{
+		byID:     make(map[int64]model.MemoryRecord, len(known)),
+		byKey:    make(map[string]model.MemoryRecord, len(known)),
+		adjacent: map[int64][]asOfEdge{},
+	}
+	for _, rec := range known {
+		if rec.ID != 0 {
+			g.byID[rec.ID] = rec
+		}
+	}
+	for i := range known {
+		rec := &known[i]
+		edges := rec.GraphEdges
+		if len(edges) == 0 {
+			edges = model.ValidGraphEdges(model.DecodeMetadata(rec.Metadata))
+		}
+		var kept []model.GraphEdge
+		for _, edge := range edges {
+			if _, ok := g.byID[edge.Target]; !ok || rec.ID == 0 {
+				continue
+			}
+			kept = append(kept, edge)
+			g.adjacent[rec.ID] = append(g.adjacent[rec.ID], asOfEdge{edge.Target, edge.Strength()})
+			g.adjacent[edge.Target] = append(g.adjacent[edge.Target], asOfEdge{rec.ID, edge.Strength()})
+		}
+		rec.GraphEdges = kept
+		if rec.ID != 0 {
+			g.byID[rec.ID] = *rec
+		}
+		g.byKey[lexicalKey(*rec)] = *rec
+	}
+	return g
+}
+
+// neighborhood walks the historical edges from seeds, in either direction
+// as the stores do, and returns up to limit records within hops that are
+// not seeds themselves. Nearer records come first, and among records at
+// the same distance those reached over heavier edges.
+func (g asOfGraph) neighborhood(seeds []model.MemoryRecord, hops, limit int) []model.MemoryRecord {
+	if hops <= 0 {
+		hops = 1
+	}
+	visited := make(map[int64]bool, len(seeds))
+	var frontier []int64
+	for _, seed := range seeds {
+		if seed.ID != 0 && !visited[seed.ID] {
+			visited[seed.ID] = true
+			frontier = append(frontier, seed.ID)
+		}
+	}
+	var out []model.MemoryRecord
+	for depth := 0; depth < hops && len(frontier) > 0; depth++ {
+		var reached []asOfEdge
+		for _, id := range frontier {
+			reached = append(reached, g.adjacent[id]...)
+		}
+		sort.SliceStable(reached, func(i, j int) bool { return reached[i].weight > reached[j].weight })
+		var next []int64
+		for _, nb := range reached {
+			if visited[nb.to] {
+				continue
+			}
+			visited[nb.to] = true
+			next = append(next, nb.to)
+			out = append(out, g.byID[nb.to])
+			if len(out) >= limit {
+				return out
+			}
+		}
+		frontier = next
+	}
+	return out
+}
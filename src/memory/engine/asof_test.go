@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestRetrieveAsOfIgnoresLaterRecordsAndEdges(t *testing.T) {
+	ctx := context.Background()
+	s := storepkg.NewInMemoryStore()
+	_ = s.StoreMemory(ctx, "s", "restart the api service", map[string]any{
+		// Points at a record that is only written after the cutoff.
+		"graph_edges": []model.GraphEdge{{Target: 3, Type: model.EdgeFollows}},
+	}, []float32{1, 0})
+	_ = s.StoreMemory(ctx, "s", "team blue owns the api service", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 1, Type: model.EdgeExplains}},
+	}, []float32{0.9, 0.44})
+	_ = s.StoreMemory(ctx, "other", "api service notes from another session", nil, []float32{1, 0})
+	time.Sleep(5 * time.Millisecond)
+	cutoff := time.Now().UTC()
+	time.Sleep(5 * time.Millisecond)
+	_ = s.StoreMemory(ctx, "s", "drain the api service before a restart", nil, []float32{1, 0.05})
+
+	e := NewEngine(s, Options{}).WithEmbedder(angleEmbedder{"api service": 1})
+	then, err := e.RetrieveAsOf(ctx, "s", "api service", 5, cutoff)
+	if err != nil {
+		t.Fatalf("RetrieveAsOf: %v", err)
+	}
+	ids := map[int64]model.MemoryRecord{}
+	for _, rec := range then {
+		ids[rec.ID] = rec
+	}
+	if len(then) != 2 || ids[1].ID != 1 || ids[2].ID != 2 {
+		t.Fatalf("expected records 1 and 2 as of the cutoff, got %+v", then)
+	}
+	if len(ids[1].GraphEdges) != 0 {
+		t.Fatalf("edge to a later record survived: %+v", ids[1].GraphEdges)
+	}
+	if len(ids[2].GraphEdges) != 1 || ids[2].GraphEdges[0].Target != 1 {
+		t.Fatalf("edge between earlier records lost: %+v", ids[2].GraphEdges)
+	}
+
+	now, err := e.Retrieve(ctx, "s", "api service", 5)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if len(now) != 3 {
+		t.Fatalf("expected the later record in a current retrieval, got %d records", len(now))
+	}
+}
+
+func TestRetrieveAsOfFiltersBeforeCuttingCandidates(t *testing.T) {
+	ctx := context.Background()
+	s := storepkg.NewInMemoryStore()
+	// Closer matches that the caller may not see outnumber limit*4.
+	for range 8 {
+		_ = s.StoreMemory(ctx, "s", "globex api service runbook", map[string]any{model.MetaTenant: "globex"}, []float32{1, 0})
+		_ = s.StoreMemory(ctx, "s", "restricted api service notes", map[string]any{model.MetaACLPrincipals: []string{"bob"}}, []float32{1, 0})
+	}
+	_ = s.StoreMemory(ctx, "s", "acme api service owners", map[string]any{model.MetaTenant: "acme"}, []float32{0.6, 0.8})
+	cutoff := time.Now().Add(time.Minute)
+
+	e := NewEngine(s, Options{}).WithEmbedder(angleEmbedder{"api service": 1})
+	acme := model.ContextWithIdentity(model.ContextWithTenant(ctx, "acme"), model.Identity{Principal: "alice"})
+	got, err := e.RetrieveAsOf(acme, "s", "api service", 1, cutoff)
+	if err != nil {
+		t.Fatalf("RetrieveAsOf: %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "acme api service owners" {
+		t.Fatalf("expected acme's own record, got %+v", got)
+	}
+}
+
+func TestAsOfGraphNeighborhoodFollowsEdgesBothWays(t *testing.T) {
+	g := newAsOfGraph([]model.MemoryRecord{
+		{ID: 1, GraphEdges: []model.GraphEdge{{Target: 2, Type: model.EdgeFollows}}},
+		{ID: 2},
+		{ID: 3, GraphEdges: []model.GraphEdge{{Target: 2, Type: model.EdgeFollows}}},
+		{ID: 4, GraphEdges: []model.GraphEdge{{Target: 9, Type: model.EdgeFollows}}},
+	})
+	got := g.neighborhood([]model.MemoryRecord{{ID: 1}}, 2, 10)
+	if len(got) != 2 || got[0].ID != 2 || got[1].ID != 3 {
+		t.Fatalf("unexpected neighbourhood: %+v", got)
+	}
+	if got := g.neighborhood([]model.MemoryRecord{{ID: 1}}, 1, 10); len(got) != 1 {
+		t.Fatalf("hops not respected: %+v", got)
+	}
+	if len(g.byID[4].GraphEdges) != 0 {
+		t.Fatal("edge to a missing record kept")
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/embed"
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// MemoryInput is one memory of a StoreBatch call.
+type MemoryInput struct {
+	Content  string
+	Metadata map[string]any
+}
+
+// StoreBatch stores inputs like Store, but embeds every content in one
+// embedder call when the embedder implements embed.BatchEmbedder and writes
+// them through the store's bulk path when it implements store.BatchStore.
+// Inputs that duplicate a stored memory or an earlier input are not
+// written. The result has one record per input, in order: the new record,
+// or the one it duplicates.
+func (e *Engine) StoreBatch(ctx context.Context, sessionID string, inputs []MemoryInput) ([]model.MemoryRecord, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	texts := make([]string, len(inputs))
+	for i, in := range inputs {
+		texts[i] = in.Content
+	}
+	embeddings, err := e.embedMany(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embed content: %w", err)
+	}
+
+	results := make([]model.MemoryRecord, len(inputs))
+	// writeOf maps an input to its entry in pending, or -1 when it was a
+	// duplicate already resolved into results.
+	writeOf := make([]int, len(inputs))
+	var pending []pendingWrite
+	var earlier []model.MemoryRecord
+	for i, in := range inputs {
+		w, dup, err := e.prepareWrite(ctx, sessionID, in.Content, in.Metadata, embeddings[i], earlier)
+		if err != nil {
+			return nil, err
+		}
+		writeOf[i] = -1
+		if dup != nil {
+			results[i] = *dup
+			// A duplicate of an earlier input resolves once that input is
+			// written.
+			for j, p := range pending {
+				if dup.ID == 0 && p.record.Content == dup.Content {
+					writeOf[i] = j
+					break
+				}
+			}
+			continue
+		}
+		writeOf[i] = len(pending)
+		pending = append(pending, w)
+		earlier = append(earlier, w.record)
+	}
+
+	if len(pending) > 0 {
+		writes := make([]store.MemoryWrite, len(pending))
+		for i, w := range pending {
+			writes[i] = store.MemoryWrite{Content: w.record.Content, Metadata: w.metadata, Embedding: w.record.Embedding}
+		}
+		if err := store.StoreMemories(ctx, e.store, sessionID, writes); err != nil {
+			return nil, err
+		}
+		stored := make([]model.MemoryRecord, len(pending))
+		for i, w := range pending {
+			stored[i] = e.commitWrite(ctx, w)
+		}
+		for i, j := range writeOf {
+			if j >= 0 {
+				results[i] = stored[j]
+			}
+		}
+		if e.inlinePrune() {
+			if err := e.Prune(ctx); err != nil {
+				e.logf("prune error: %v", err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// embedMany embeds texts in one call where the embedder allows it, with
+// the same fallbacks as embed.
+func (e *Engine) embedMany(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.embedder == nil {
+		e.embedder = embed.AutoEmbedder()
+	}
+	if _, ok := e.embedder.(embed.BatchEmbedder); ok {
+		vecs, err := embed.EmbedMany(ctx, e.embedder, texts)
+		if embed.IsUnavailable(err) {
+			return nil, err
+		}
+		if err == nil {
+			for i, vec := range vecs {
+				if len(vec) == 0 {
+					vecs[i] = embed.DummyEmbedding(texts[i])
+				}
+			}
+			return vecs, nil
+		}
+		e.logf("batch embed: %v; embedding one at a time", err)
+	}
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec, err := e.embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
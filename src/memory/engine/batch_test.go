@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+type batchEmbedder struct {
+	vectors    map[string][]float32
+	batchCalls int
+}
+
+func (b *batchEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	return b.vectors[text], nil
+}
+
+func (b *batchEmbedder) EmbedMany(_ context.Context, texts []string) ([][]float32, error) {
+	b.batchCalls++
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = b.vectors[text]
+	}
+	return out, nil
+}
+
+type bulkOnlyStore struct {
+	*storepkg.InMemoryStore
+	bulkWrites [][]storepkg.MemoryWrite
+}
+
+func (s *bulkOnlyStore) StoreMemory(context.Context, string, string, map[string]any, []float32) error {
+	return errors.New("StoreMemory called during a batch")
+}
+
+func (s *bulkOnlyStore) StoreMemories(ctx context.Context, sessionID string, writes []storepkg.MemoryWrite) error {
+	s.bulkWrites = append(s.bulkWrites, writes)
+	return s.InMemoryStore.StoreMemories(ctx, sessionID, writes)
+}
+
+func TestStoreBatchEmbedsOnceAndWritesInBulk(t *testing.T) {
+	ctx := context.Background()
+	inner := storepkg.NewInMemoryStore()
+	if err := inner.StoreMemory(ctx, "s", "already known", nil, []float32{0, 0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	st := &bulkOnlyStore{InMemoryStore: inner}
+	emb := &batchEmbedder{vectors: map[string][]float32{
+		"alpha fact":    {1, 0, 0},
+		"beta fact":     {0, 1, 0},
+		"already known": {0, 0, 1},
+	}}
+	e := NewEngine(st, Options{}).WithEmbedder(emb)
+
+	got, err := e.StoreBatch(ctx, "s", []MemoryInput{
+		{Content: "alpha fact"},
+		{Content: "beta fact", Metadata: map[string]any{"source": "docs"}},
+		{Content: "alpha fact"},
+		{Content: "already known"},
+	})
+	if err != nil {
+		t.Fatalf("StoreBatch: %v", err)
+	}
+	if emb.batchCalls != 1 {
+		t.Fatalf("expected one EmbedMany call, got %d", emb.batchCalls)
+	}
+	if len(st.bulkWrites) != 1 || len(st.bulkWrites[0]) != 2 {
+		t.Fatalf("expected one bulk write of 2 records, got %v", st.bulkWrites)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected a record per input, got %d", len(got))
+	}
+	if got[0].ID == 0 || got[2].ID != got[0].ID {
+		t.Fatalf("in-batch duplicate should resolve to the first write: %d vs %d", got[2].ID, got[0].ID)
+	}
+	if got[1].Source != "docs" || got[1].Content != "beta fact" {
+		t.Fatalf("unexpected record for second input: %+v", got[1])
+	}
+	if got[3].Content != "already known" || got[3].ID == 0 {
+		t.Fatalf("stored duplicate should return the existing record: %+v", got[3])
+	}
+	if n, _ := st.Count(ctx); n != 3 {
+		t.Fatalf("expected 3 records in the store, got %d", n)
+	}
+	if snap := e.MetricsSnapshot(); snap.Stored != 2 || snap.Deduplicated != 2 {
+		t.Fatalf("unexpected metrics: %+v", snap)
+	}
+}
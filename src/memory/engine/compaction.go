@@ -0,0 +1,294 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// MetaCompactedFrom lists, on a compacted record, the IDs of the records it
+// replaced.
+const MetaCompactedFrom = "compacted_from"
+
+const defaultCompactMinCluster = 3
+
+// CompactionReport describes one Compact pass.
+type CompactionReport struct {
+	// Scanned is the number of records examined.
+	Scanned int `json:"scanned"`
+	// Mergeable is the number of records in clusters eligible for merging;
+	// Mergeable/Scanned is compared with Options.CompactSummaryRatio.
+	Mergeable int `json:"mergeable"`
+	// Clusters and Removed count the clusters merged and the records they
+	// replaced.
+	Clusters int `json:"clusters"`
+	Removed  int `json:"removed"`
+}
+
+// compactionMember is the part of a record a merge needs.
+type compactionMember struct {
+	id         int64
+	importance float64
+	source     string
+	createdAt  time.Time
+	edges      []model.GraphEdge
+	facts      []Fact
+}
+
+type compactionCluster struct {
+	sessionID string
+	space     string
+	tenant    string
+	summary   string
+	acl       model.ACL
+	members   []compactionMember
+}
+
+// Compact merges every cluster of at least Options.CompactMinCluster
+// records that share a tenant, session, space, access list and summary
+// into one canonical record holding the summary. The canonical record lists
+// the replaced IDs under MetaCompactedFrom, keeps the members' graph edges
+// to records outside the cluster, their facts and their highest importance.
+func (e *Engine) Compact(ctx context.Context) (CompactionReport, error) {
+	if e.store == nil {
+		return CompactionReport{}, errors.New("memory engine has no store")
+	}
+	e.pruneMu.Lock()
+	defer e.pruneMu.Unlock()
+	report, clusters, err := e.planCompaction(ctx)
+	if err != nil {
+		return report, err
+	}
+	return e.applyCompaction(ctx, report, clusters)
+}
+
+// planCompaction scans the store for clusters worth merging.
+func (e *Engine) planCompaction(ctx context.Context) (CompactionReport, []*compactionCluster, error) {
+	var report CompactionReport
+	minCluster := e.opts.CompactMinCluster
+	if minCluster < 2 {
+		minCluster = defaultCompactMinCluster
+	}
+	scanned, groups, err := e.summaryGroups(ctx, nil)
+	report.Scanned = scanned
+	if err != nil {
+		return report, nil, err
+	}
+	var clusters []*compactionCluster
+	for _, group := range groups {
+		if len(group.members) >= minCluster {
+			report.Mergeable += len(group.members)
+			clusters = append(clusters, group)
+		}
+	}
+	return report, clusters, nil
+}
+
+// summaryGroups scans the store and groups the live records that share a
+// tenant, session, space, access list and summary, in the order the groups
+// are first seen. Live records outside any group, such as those that are
+// their own summary, are passed to rest when it is set. It returns the
+// number of records scanned.
+func (e *Engine) summaryGroups(ctx context.Context, rest func(model.MemoryRecord)) (int, []*compactionCluster, error) {
+	scanned := 0
+	groups := map[string]*compactionCluster{}
+	var order []string
+	now := e.clock()
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		scanned++
+		if isTombstoned(rec, now) {
+			return ctx.Err() == nil
+		}
+		meta := model.DecodeMetadata(rec.Metadata)
+		summary := strings.TrimSpace(rec.Summary)
+		if summary == "" {
+			summary = strings.TrimSpace(model.StringFromAny(meta["summary"]))
+		}
+		// A record that already is its summary has nothing to fold in.
+		if summary == "" || canonicalKey(summary) == canonicalKey(rec.Content) {
+			if rest != nil {
+				rest(rec)
+			}
+			return ctx.Err() == nil
+		}
+		acl := model.RecordACL(rec)
+		space := recordSpace(rec)
+		tenant := model.RecordTenant(rec)
+		key := summaryGroupKey(tenant, rec.SessionID, space, acl, summary)
+		group, ok := groups[key]
+		if !ok {
+			group = &compactionCluster{sessionID: rec.SessionID, space: space, tenant: tenant, summary: summary, acl: acl}
+			groups[key] = group
+			order = append(order, key)
+		}
+		importance := rec.Importance
+		if importance == 0 {
+			importance = model.FloatFromAny(meta["importance"])
+		}
+		group.members = append(group.members, compactionMember{
+			id:         rec.ID,
+			importance: importance,
+			source:     rec.Source,
+			createdAt:  rec.CreatedAt,
+			edges:      model.ValidGraphEdges(meta),
+			facts:      decodeFacts(meta[MetaFacts]),
+		})
+		return ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return scanned, nil, err
+	}
+	out := make([]*compactionCluster, len(order))
+	for i, key := range order {
+		out[i] = groups[key]
+	}
+	return scanned, out, nil
+}
+
+func summaryGroupKey(tenant, sessionID, space string, acl model.ACL, summary string) string {
+	return tenantKey(tenant, strings.Join([]string{sessionID, space, strings.Join(acl.Principals, ","), strings.Join(acl.Groups, ","), canonicalKey(summary)}, "␟"))
+}
+
+func (e *Engine) applyCompaction(ctx context.Context, report CompactionReport, clusters []*compactionCluster) (CompactionReport, error) {
+	for _, cluster := range clusters {
+		if err := e.mergeCluster(ctx, cluster); err != nil {
+			return report, fmt.Errorf("compact cluster of %d: %w", len(cluster.members), err)
+		}
+		report.Clusters++
+		report.Removed += len(cluster.members)
+		e.metrics.IncCompacted(len(cluster.members))
+	}
+	return report, nil
+}
+
+func (e *Engine) mergeCluster(ctx context.Context, cluster *compactionCluster) error {
+	ids := make([]int64, 0, len(cluster.members))
+	inCluster := make(map[int64]struct{}, len(cluster.members))
+	for _, m := range cluster.members {
+		ids = append(ids, m.id)
+		inCluster[m.id] = struct{}{}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var importance float64
+	source := cluster.members[0].source
+	seenEdge := map[model.GraphEdge]struct{}{}
+	var edges []model.GraphEdge
+	var facts []Fact
+	for _, m := range cluster.members {
+		importance = max(importance, m.importance)
+		facts = append(facts, m.facts...)
+		if m.source != source {
+			source = "default"
+		}
+		for _, edge := range m.edges {
+			if _, internal := inCluster[edge.Target]; internal {
+				continue
+			}
+			if _, dup := seenEdge[edge]; !dup {
+				seenEdge[edge] = struct{}{}
+				edges = append(edges, edge)
+			}
+		}
+	}
+	if source == "" {
+		source = "default"
+	}
+
+	embedding, err := e.embed(ctx, cluster.summary)
+	if err != nil {
+		return fmt.Errorf("embed summary: %w", err)
+	}
+	now := e.clock().UTC()
+	metadata := map[string]any{
+		"space":           cluster.space,
+		"source":          source,
+		"summary":         cluster.summary,
+		"importance":      importance,
+		"last_embedded":   now.Format(time.RFC3339Nano),
+		MetaCompactedFrom: ids,
+	}
+	cluster.acl.Apply(metadata)
+	if cluster.tenant != "" {
+		metadata[model.MetaTenant] = cluster.tenant
+	}
+	// The summary is written and read back in its members' tenant.
+	ctx = model.ContextWithTenant(ctx, cluster.tenant)
+	if len(edges) > 0 {
+		metadata["graph_edges"] = edges
+	}
+	if facts = cleanFacts(facts); len(facts) > 0 {
+		metadata[MetaFacts] = facts
+	}
+	if err := e.store.StoreMemory(ctx, cluster.sessionID, cluster.summary, metadata, embedding); err != nil {
+		return err
+	}
+	if err := e.store.DeleteMemory(ctx, ids); err != nil {
+		return err
+	}
+	e.forgetLexical(ids)
+	e.forgetFacts(ids)
+
+	stored := e.readBack(ctx, model.MemoryRecord{
+		SessionID:  cluster.sessionID,
+		Space:      cluster.space,
+		Content:    cluster.summary,
+		Metadata:   model.StringFromAny(metadata),
+		Embedding:  embedding,
+		Importance: importance,
+		Source:     source,
+		Summary:    cluster.summary,
+		CreatedAt:  now,
+		GraphEdges: edges,
+	})
+	e.observeLexical(stored)
+	e.observeFacts(stored)
+	if graphStore, ok := e.store.(store.GraphStore); ok && stored.ID != 0 {
+		if err := graphStore.UpsertGraph(ctx, stored, edges); err != nil {
+			e.logf("upsert graph: %v", err)
+		}
+	}
+	return nil
+}
+
+// maybeCompact runs a compaction from the background pruner when the
+// duplicate rate since the last one, or the share of mergeable records,
+// reaches its threshold.
+func (e *Engine) maybeCompact(ctx context.Context) {
+	if e.opts.CompactSummaryRatio <= 0 && e.opts.CompactDuplicateRatio <= 0 {
+		return
+	}
+	e.pruneMu.Lock()
+	defer e.pruneMu.Unlock()
+
+	snap := e.metrics.Snapshot()
+	writes := (snap.Stored - e.compactStored) + (snap.Deduplicated - e.compactDeduplicated)
+	dupTriggered := e.opts.CompactDuplicateRatio > 0 && writes > 0 &&
+		float64(snap.Deduplicated-e.compactDeduplicated)/float64(writes) >= e.opts.CompactDuplicateRatio
+
+	report, clusters, err := e.planCompaction(ctx)
+	if err != nil {
+		e.logf("plan compaction: %v", err)
+		return
+	}
+	summaryTriggered := e.opts.CompactSummaryRatio > 0 && report.Scanned > 0 &&
+		float64(report.Mergeable)/float64(report.Scanned) >= e.opts.CompactSummaryRatio
+	if len(clusters) == 0 || (!dupTriggered && !summaryTriggered) {
+		return
+	}
+	if report, err = e.applyCompaction(ctx, report, clusters); err != nil {
+		e.logf("compaction: %v", err)
+		return
+	}
+	e.compactStored, e.compactDeduplicated = snap.Stored, snap.Deduplicated
+	e.logf("compacted %d records into %d", report.Removed, report.Clusters)
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestCompactMergesClustersSharingASummary(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	put := func(content string, meta map[string]any) int64 {
+		t.Helper()
+		if err := store.StoreMemory(ctx, "s", content, meta, []float32{1, 0}); err != nil {
+			t.Fatal(err)
+		}
+		var id int64
+		_ = store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+			if rec.Content == content {
+				id = rec.ID
+			}
+			return true
+		})
+		return id
+	}
+	outside := put("the runbook lives in the wiki", nil)
+	var members []int64
+	for i, importance := range []float64{0.2, 0.9, 0.4} {
+		meta := map[string]any{"summary": "Deploys are frozen on Fridays", "importance": importance, "source": "chat"}
+		if i == 0 {
+			meta["graph_edges"] = []model.GraphEdge{{Target: outside, Type: model.EdgeExplains}}
+		}
+		members = append(members, put(fmt.Sprintf("friday freeze note %d", i), meta))
+	}
+	put("pair one", map[string]any{"summary": "Only two"})
+	put("pair two", map[string]any{"summary": "Only two"})
+	put("restricted a", map[string]any{"summary": "Deploys are frozen on Fridays", "acl_groups": []string{"sre"}})
+
+	e := NewEngine(store, Options{EnableSummaries: false}).WithEmbedder(angleEmbedder{})
+	report, err := e.Compact(ctx)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if report.Clusters != 1 || report.Removed != 3 || report.Scanned != 7 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	var canonical *model.MemoryRecord
+	left := 0
+	_ = store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		left++
+		if rec.Content == "Deploys are frozen on Fridays" {
+			canonical = &rec
+		}
+		return true
+	})
+	if left != 5 || canonical == nil {
+		t.Fatalf("expected 5 records including the canonical one, got %d (canonical %v)", left, canonical != nil)
+	}
+	meta := model.DecodeMetadata(canonical.Metadata)
+	if got := fmt.Sprint(meta[MetaCompactedFrom]); got != fmt.Sprint(members) {
+		t.Fatalf("compacted_from = %v, want %v", got, members)
+	}
+	if canonical.Importance != 0.9 || canonical.Source != "chat" {
+		t.Fatalf("canonical record lost importance or source: %+v", canonical)
+	}
+	edges := model.ValidGraphEdges(meta)
+	if len(edges) != 1 || edges[0].Target != outside {
+		t.Fatalf("provenance edge not preserved: %+v", edges)
+	}
+	if snap := e.MetricsSnapshot(); snap.Compacted != 3 {
+		t.Fatalf("Compacted = %d, want 3", snap.Compacted)
+	}
+}
+
+func TestMaybeCompactHonoursSummaryRatio(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	for i := 0; i < 3; i++ {
+		_ = store.StoreMemory(ctx, "s", fmt.Sprintf("note %d", i), map[string]any{"summary": "shared"}, []float32{1, 0})
+	}
+	for i := 0; i < 3; i++ {
+		_ = store.StoreMemory(ctx, "s", fmt.Sprintf("other %d", i), nil, []float32{0, 1})
+	}
+
+	e := NewEngine(store, Options{CompactSummaryRatio: 0.6}).WithEmbedder(angleEmbedder{})
+	e.maybeCompact(ctx)
+	if n, _ := store.Count(ctx); n != 6 {
+		t.Fatalf("compaction ran below the ratio: %d records", n)
+	}
+
+	e.opts.CompactSummaryRatio = 0.5
+	e.maybeCompact(ctx)
+	if n, _ := store.Count(ctx); n != 4 {
+		t.Fatalf("expected compaction to leave 4 records, got %d", n)
+	}
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// MetaConflictsWith holds, on a memory that contradicted earlier ones, the
+// IDs of the records it conflicts with.
+const MetaConflictsWith = "conflicts_with"
+
+// ConflictJudge decides whether an incoming memory contradicts one already
+// stored, e.g. "the launch is on Monday" against "the launch is on
+// Friday". Engine.Store consults it for close pairs that are not
+// duplicates.
+type ConflictJudge interface {
+	Contradicts(ctx context.Context, existing, incoming string) (bool, error)
+}
+
+// ConflictJudgeFunc adapts a function to ConflictJudge.
+type ConflictJudgeFunc func(ctx context.Context, existing, incoming string) (bool, error)
+
+func (f ConflictJudgeFunc) Contradicts(ctx context.Context, existing, incoming string) (bool, error) {
+	return f(ctx, existing, incoming)
+}
+
+// WithConflictJudge turns on conflict detection. A new memory is checked
+// against the stored records at or above Options.ConflictSimilarity whose
+// importance is at least Options.ConflictImportance. When the judge finds a
+// contradiction both memories are kept: the new one gets an
+// EdgeConflictsWith edge to the old one and lists it under
+// MetaConflictsWith, and the pair is reported by Conflicts until it is
+// resolved.
+func (e *Engine) WithConflictJudge(j ConflictJudge) *Engine {
+	e.conflicts = j
+	return e
+}
+
+// Conflict is an unresolved contradiction between two memories.
+type Conflict struct {
+	// Incoming is the newer memory, which carries the conflicts_with edge.
+	Incoming model.MemoryRecord `json:"incoming"`
+	// Existing is the memory it contradicts.
+	Existing model.MemoryRecord `json:"existing"`
+}
+
+// Conflicts lists the unresolved conflicts of the tenant on ctx, newest
+// first. A
+// conflict is resolved once either memory is tombstoned or updated, or
+// after DismissConflict.
+func (e *Engine) Conflicts(ctx context.Context) ([]Conflict, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	now := e.clock()
+	byID := map[int64]model.MemoryRecord{}
+	var flagged []model.MemoryRecord
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.ID == 0 || isTombstoned(rec, now) || !ownedBy(ctx, rec) {
+			return ctx.Err() == nil
+		}
+		byID[rec.ID] = rec
+		if len(conflictTargets(rec)) > 0 {
+			flagged = append(flagged, rec)
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var out []Conflict
+	for _, rec := range flagged {
+		for _, target := range conflictTargets(rec) {
+			if existing, ok := byID[target]; ok {
+				out = append(out, Conflict{Incoming: rec, Existing: existing})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Incoming, out[j].Incoming
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return out, nil
+}
+
+// ResolveConflict settles a conflict in favour of the memory with ID keep
+// by tombstoning the other one. The store must implement
+// store.MetadataUpdater.
+func (e *Engine) ResolveConflict(ctx context.Context, c Conflict, keep int64) error {
+	switch keep {
+	case c.Incoming.ID:
+		return e.Tombstone(ctx, c.Existing.ID, "conflict resolved")
+	case c.Existing.ID:
+		return e.Tombstone(ctx, c.Incoming.ID, "conflict resolved")
+	}
+	return fmt.Errorf("memory %d is not part of the conflict", keep)
+}
+
+// DismissConflict keeps both memories and drops the conflicts_with link,
+// for pairs the judge got wrong or that are both true. The store must
+// implement store.MetadataUpdater.
+func (e *Engine) DismissConflict(ctx context.Context, c Conflict) error {
+	if e.store == nil {
+		return errors.New("memory engine has no store")
+	}
+	u, ok := e.store.(store.MetadataUpdater)
+	if !ok {
+		return store.ErrMetadataUpdatesUnsupported
+	}
+	rec, err := e.lookup(ctx, c.Incoming.ID)
+	if err != nil {
+		return err
+	}
+	meta := model.DecodeMetadata(rec.Metadata)
+	var edges []model.GraphEdge
+	for _, edge := range model.ValidGraphEdges(meta) {
+		if edge.Type != model.EdgeConflictsWith || edge.Target != c.Existing.ID {
+			edges = append(edges, edge)
+		}
+	}
+	var remaining []int64
+	for _, id := range conflictTargets(rec) {
+		if id != c.Existing.ID {
+			remaining = append(remaining, id)
+		}
+	}
+	meta["graph_edges"] = edges
+	if len(remaining) > 0 {
+		meta[MetaConflictsWith] = remaining
+	} else {
+		delete(meta, MetaConflictsWith)
+	}
+	rec.Metadata = model.StringFromAny(meta)
+	rec.GraphEdges = edges
+	return u.UpdateMetadata(ctx, rec)
+}
+
+// detectConflicts asks the judge about the close, important candidates of
+// a new memory and returns EdgeConflictsWith edges to those it
+// contradicts.
+func (e *Engine) detectConflicts(ctx context.Context, opts Options, candidates []model.MemoryRecord, content string, embedding []float32) []model.GraphEdge {
+	if e.conflicts == nil {
+		return nil
+	}
+	var edges []model.GraphEdge
+	seen := map[int64]bool{}
+	for _, cand := range candidates {
+		if cand.ID == 0 || seen[cand.ID] {
+			continue
+		}
+		seen[cand.ID] = true
+		if model.MaxCosineSimilarity(embedding, cand) < opts.ConflictSimilarity {
+			continue
+		}
+		importance := cand.Importance
+		if importance == 0 {
+			importance = model.FloatFromAny(model.DecodeMetadata(cand.Metadata)["importance"])
+		}
+		if importance < opts.ConflictImportance {
+			continue
+		}
+		contradicts, err := e.conflicts.Contradicts(ctx, cand.Content, content)
+		if err != nil {
+			e.logf("conflict judge: %v", err)
+			continue
+		}
+		if contradicts {
+			e.metrics.IncConflicts()
+			edges = append(edges, model.GraphEdge{Target: cand.ID, Type: model.EdgeConflictsWith})
+		}
+	}
+	return edges
+}
+
+// conflictTargets returns the IDs rec has an EdgeConflictsWith edge to.
+func conflictTargets(rec model.MemoryRecord) []int64 {
+	edges := rec.GraphEdges
+	if len(edges) == 0 {
+		edges = model.ValidGraphEdges(model.DecodeMetadata(rec.Metadata))
+	}
+	var ids []int64
+	for _, edge := range edges {
+		if edge.Type == model.EdgeConflictsWith {
+			ids = append(ids, edge.Target)
+		}
+	}
+	return ids
+}
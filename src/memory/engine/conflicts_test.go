@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestConflictDetectionAndResolution(t *testing.T) {
+	ctx := context.Background()
+	const (
+		launch   = "The launch is on Monday"
+		moved    = "The launch is on Friday"
+		trivia   = "The launch party has cake"
+		unrelate = "Lunch is at noon"
+	)
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(angleEmbedder{
+		launch: 0.955, moved: 1, trivia: 0.825, unrelate: 0.1,
+	})
+	var judged []string
+	e.WithConflictJudge(ConflictJudgeFunc(func(_ context.Context, existing, incoming string) (bool, error) {
+		judged = append(judged, existing+" / "+incoming)
+		return existing == launch && incoming == moved, nil
+	}))
+
+	original, err := e.Store(ctx, "s", launch, map[string]any{"importance": 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Store(ctx, "s", trivia, map[string]any{"importance": 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Store(ctx, "s", unrelate, map[string]any{"importance": 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	judged = nil
+	correction, err := e.Store(ctx, "s", moved, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(judged) != 1 {
+		t.Fatalf("only the close, important memory should be judged: %v", judged)
+	}
+	if len(correction.GraphEdges) != 1 || correction.GraphEdges[0] != (model.GraphEdge{Target: original.ID, Type: model.EdgeConflictsWith}) {
+		t.Fatalf("expected a conflicts_with edge: %+v", correction.GraphEdges)
+	}
+
+	conflicts, err := e.Conflicts(ctx)
+	if err != nil {
+		t.Fatalf("Conflicts: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Incoming.ID != correction.ID || conflicts[0].Existing.ID != original.ID {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+	if got := e.MetricsSnapshot().Conflicts; got != 1 {
+		t.Fatalf("conflicts metric = %d, want 1", got)
+	}
+
+	if err := e.ResolveConflict(ctx, conflicts[0], 42); err == nil {
+		t.Fatal("expected an error for a memory outside the conflict")
+	}
+	if err := e.DismissConflict(ctx, conflicts[0]); err != nil {
+		t.Fatalf("DismissConflict: %v", err)
+	}
+	if conflicts, _ := e.Conflicts(ctx); len(conflicts) != 0 {
+		t.Fatalf("dismissed conflict still listed: %+v", conflicts)
+	}
+
+	// Resolving keeps one side and tombstones the other.
+	if err := e.ResolveConflict(ctx, conflicts[0], correction.ID); err != nil {
+		t.Fatalf("ResolveConflict: %v", err)
+	}
+	rec, err := e.lookup(ctx, original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isTombstoned(rec, e.clock()) {
+		t.Fatal("losing memory was not tombstoned")
+	}
+}
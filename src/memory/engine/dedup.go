@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// DuplicateJudge decides whether an incoming memory restates one already
+// stored. Engine.Store consults it for pairs whose embeddings are close but
+// whose wording differs, where cosine similarity alone is unreliable.
+type DuplicateJudge interface {
+	SameFact(ctx context.Context, existing, incoming string) (bool, error)
+}
+
+// DuplicateJudgeFunc adapts a function to DuplicateJudge.
+type DuplicateJudgeFunc func(ctx context.Context, existing, incoming string) (bool, error)
+
+func (f DuplicateJudgeFunc) SameFact(ctx context.Context, existing, incoming string) (bool, error) {
+	return f(ctx, existing, incoming)
+}
+
+// WithDuplicateJudge sets the second-stage duplicate check. Pairs at or
+// above Options.DuplicateBorderline (DuplicateSimilarity when unset) that
+// are not near-identical in wording are sent to the judge.
+func (e *Engine) WithDuplicateJudge(j DuplicateJudge) *Engine {
+	e.judge = j
+	return e
+}
+
+// isDuplicate runs the duplicate check for an incoming memory against one
+// candidate at cosine similarity sim. Without a judge or a borderline band
+// it is the plain threshold test.
+func (e *Engine) isDuplicate(ctx context.Context, opts Options, cand model.MemoryRecord, content string, sim float64) bool {
+	if e.judge == nil && opts.DuplicateBorderline <= 0 {
+		return sim >= opts.DuplicateSimilarity
+	}
+	floor := opts.DuplicateBorderline
+	if floor <= 0 || floor > opts.DuplicateSimilarity {
+		floor = opts.DuplicateSimilarity
+	}
+	if sim < floor {
+		return false
+	}
+	if textSimilarity(cand.Content, content) >= opts.DuplicateTextSimilarity {
+		return true
+	}
+	if e.judge != nil {
+		e.metrics.IncDuplicateJudged()
+		same, err := e.judge.SameFact(ctx, cand.Content, content)
+		if err == nil {
+			return same
+		}
+		e.logf("duplicate judge: %v", err)
+	}
+	// Undecided: trust the embedding only above the strict threshold.
+	return sim >= opts.DuplicateSimilarity
+}
+
+// textSimilarity is the Jaccard overlap of the two texts' term sets.
+func textSimilarity(a, b string) float64 {
+	left := map[string]struct{}{}
+	for _, t := range lexicalTerms(a) {
+		left[t] = struct{}{}
+	}
+	right := map[string]struct{}{}
+	for _, t := range lexicalTerms(b) {
+		right[t] = struct{}{}
+	}
+	if len(left) == 0 && len(right) == 0 {
+		return 1
+	}
+	shared := 0
+	for t := range right {
+		if _, ok := left[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(left)+len(right)-shared)
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// angleEmbedder maps each known text to a unit vector at a fixed cosine
+// from the x axis, so tests can place pairs exactly.
+type angleEmbedder map[string]float64
+
+func (a angleEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	cos := a[text]
+	return []float32{float32(cos), float32(math.Sqrt(1 - cos*cos))}, nil
+}
+
+func TestStoreSecondStageDuplicateCheck(t *testing.T) {
+	ctx := context.Background()
+	const (
+		original   = "The deploy window is Friday at 5pm"
+		paraphrase = "Deploys happen on Fridays, five in the evening"
+		reworded   = "The deploy window is Friday at 5pm sharp"
+		distinct   = "The deploy window is Monday at 5pm"
+	)
+	embedder := angleEmbedder{original: 1, paraphrase: 0.9, reworded: 0.9, distinct: 0.99}
+
+	var judged []string
+	judge := DuplicateJudgeFunc(func(_ context.Context, existing, incoming string) (bool, error) {
+		judged = append(judged, incoming)
+		return incoming == paraphrase, nil
+	})
+	memStore := storepkg.NewInMemoryStore()
+	e := NewEngine(memStore, Options{DuplicateBorderline: 0.85}).WithEmbedder(embedder).WithDuplicateJudge(judge)
+	for _, text := range []string{original, paraphrase, reworded, distinct} {
+		if _, err := e.Store(ctx, "s", text, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The paraphrase is judged a duplicate, the rewording overlaps enough
+	// to skip the judge, and the distinct fact survives despite its cosine.
+	if n, _ := memStore.Count(ctx); n != 2 {
+		t.Fatalf("stored %d records, want 2", n)
+	}
+	if len(judged) != 2 || judged[0] != paraphrase || judged[1] != distinct {
+		t.Fatalf("judged %q, want the paraphrase and the distinct fact", judged)
+	}
+	if snap := e.MetricsSnapshot(); snap.Deduplicated != 2 || snap.DuplicateJudged != 2 {
+		t.Fatalf("metrics = %+v", snap)
+	}
+}
+
+func TestStoreWithoutJudgeKeepsThreshold(t *testing.T) {
+	ctx := context.Background()
+	embedder := angleEmbedder{"alpha beta gamma": 1, "alpha beta gamma delta": 0.9, "unrelated words entirely": 0.99}
+	memStore := storepkg.NewInMemoryStore()
+	e := NewEngine(memStore, Options{DuplicateBorderline: 0.85}).WithEmbedder(embedder)
+	for _, text := range []string{"alpha beta gamma", "alpha beta gamma delta", "unrelated words entirely"} {
+		if _, err := e.Store(ctx, "s", text, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Without a judge, borderline pairs need overlapping wording and pairs
+	// above DuplicateSimilarity still merge on cosine alone.
+	if n, _ := memStore.Count(ctx); n != 2 {
+		t.Fatalf("stored %d records, want 2", n)
+	}
+	if got := textSimilarity("alpha beta gamma", "alpha beta gamma delta"); got != 0.75 {
+		t.Fatalf("textSimilarity = %v", got)
+	}
+}
+
+func TestStoreDeduplicatesOnlyWithinTheSameACL(t *testing.T) {
+	ctx := context.Background()
+	memStore := storepkg.NewInMemoryStore()
+	e := NewEngine(memStore, Options{}).WithEmbedder(angleEmbedder{"salary bands": 1})
+	restricted := func() map[string]any { return map[string]any{"acl_groups": []string{"HR"}} }
+
+	writes := []map[string]any{nil, restricted(), {"acl_groups": []string{"hr"}}}
+	for _, meta := range writes {
+		if _, err := e.Store(ctx, "s", "salary bands", meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The restricted copy is kept apart from the public one; the second
+	// restricted write, whose group differs only in case, folds into it.
+	if n, _ := memStore.Count(ctx); n != 2 {
+		t.Fatalf("stored %d records, want 2", n)
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// maxInferredEdges caps the edges an EdgeInferrer may add to one memory.
+const maxInferredEdges = 5
+
+// EdgeInferrer proposes graph edges from a new memory to the nearest
+// stored ones. Engine.Store calls it with the memory being written (which
+// has no ID yet) and its nearest live neighbours, most similar first.
+// Edges to records outside nearest are ignored.
+type EdgeInferrer interface {
+	InferEdges(ctx context.Context, incoming model.MemoryRecord, nearest []model.MemoryRecord) ([]model.GraphEdge, error)
+}
+
+// EdgeInferrerFunc adapts a function to EdgeInferrer.
+type EdgeInferrerFunc func(ctx context.Context, incoming model.MemoryRecord, nearest []model.MemoryRecord) ([]model.GraphEdge, error)
+
+func (f EdgeInferrerFunc) InferEdges(ctx context.Context, incoming model.MemoryRecord, nearest []model.MemoryRecord) ([]model.GraphEdge, error) {
+	return f(ctx, incoming, nearest)
+}
+
+// WithEdgeInferrer links new memories into the graph automatically. On
+// Store and StoreBatch the inferrer's edges are added to those passed in
+// metadata and persisted through store.GraphStore with the record, so
+// graphs no longer have to be built by hand. A failing inferrer is logged
+// and the memory is stored with the edges it already had.
+func (e *Engine) WithEdgeInferrer(x EdgeInferrer) *Engine {
+	e.inferrer = x
+	return e
+}
+
+// HeuristicEdgeInferrer links memories by similarity and time, without a
+// model. Each close neighbour gets at most one edge:
+//
+//   - EdgeDerivedFrom, when the new memory all but restates it;
+//   - EdgeExplains, when the new memory is close and at least twice as
+//     long, i.e. it elaborates on the neighbour;
+//   - EdgeFollows, when the neighbour is from the same session and was
+//     written within FollowWindow before;
+//   - EdgeRelatesTo otherwise.
+//
+// Edge weights are the cosine similarity of the pair.
+type HeuristicEdgeInferrer struct {
+	// MinSimilarity is the cosine similarity a neighbour needs to be
+	// linked at all; 0.75 when zero.
+	MinSimilarity float64
+	// DerivedSimilarity is the similarity from which the new memory is
+	// taken as derived from the neighbour; 0.92 when zero.
+	DerivedSimilarity float64
+	// FollowWindow is how recent a same-session neighbour must be for
+	// EdgeFollows; 30 minutes when zero.
+	FollowWindow time.Duration
+	// MaxEdges caps the edges per memory; 3 when zero.
+	MaxEdges int
+}
+
+var _ EdgeInferrer = HeuristicEdgeInferrer{}
+
+func (h HeuristicEdgeInferrer) InferEdges(_ context.Context, incoming model.MemoryRecord, nearest []model.MemoryRecord) ([]model.GraphEdge, error) {
+	if h.MinSimilarity <= 0 {
+		h.MinSimilarity = 0.75
+	}
+	if h.DerivedSimilarity <= 0 {
+		h.DerivedSimilarity = 0.92
+	}
+	if h.FollowWindow <= 0 {
+		h.FollowWindow = 30 * time.Minute
+	}
+	if h.MaxEdges <= 0 {
+		h.MaxEdges = 3
+	}
+	var edges []model.GraphEdge
+	for _, cand := range nearest {
+		if len(edges) == h.MaxEdges {
+			break
+		}
+		sim := model.MaxCosineSimilarity(incoming.Embedding, cand)
+		if sim < h.MinSimilarity {
+			continue
+		}
+		edge := model.GraphEdge{Target: cand.ID, Type: model.EdgeRelatesTo, Weight: min(sim, 1)}
+		gap := incoming.CreatedAt.Sub(cand.CreatedAt)
+		switch {
+		case sim >= h.DerivedSimilarity:
+			edge.Type = model.EdgeDerivedFrom
+		case utf8.RuneCountInString(incoming.Content) >= 2*utf8.RuneCountInString(cand.Content):
+			edge.Type = model.EdgeExplains
+		case cand.SessionID == incoming.SessionID && gap >= 0 && gap <= h.FollowWindow:
+			edge.Type = model.EdgeFollows
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
+
+// inferEdges asks the EdgeInferrer for edges from incoming to candidates
+// and keeps the valid ones that point at a candidate incoming has no edge
+// to yet.
+func (e *Engine) inferEdges(ctx context.Context, incoming model.MemoryRecord, candidates []model.MemoryRecord, existing []model.GraphEdge) []model.GraphEdge {
+	if e.inferrer == nil {
+		return nil
+	}
+	nearest := make([]model.MemoryRecord, 0, len(candidates))
+	allowed := map[int64]bool{}
+	for _, cand := range candidates {
+		if cand.ID == 0 || allowed[cand.ID] {
+			continue
+		}
+		allowed[cand.ID] = true
+		nearest = append(nearest, cand)
+	}
+	if len(nearest) == 0 {
+		return nil
+	}
+	sort.SliceStable(nearest, func(i, j int) bool {
+		return model.MaxCosineSimilarity(incoming.Embedding, nearest[i]) > model.MaxCosineSimilarity(incoming.Embedding, nearest[j])
+	})
+	proposed, err := e.inferrer.InferEdges(ctx, incoming, nearest)
+	if err != nil {
+		e.logf("edge inference: %v", err)
+		return nil
+	}
+	linked := map[int64]bool{}
+	for _, edge := range existing {
+		linked[edge.Target] = true
+	}
+	var out []model.GraphEdge
+	for _, edge := range proposed {
+		if len(out) == maxInferredEdges {
+			break
+		}
+		if !allowed[edge.Target] || linked[edge.Target] || edge.Validate() != nil {
+			continue
+		}
+		linked[edge.Target] = true
+		out = append(out, edge)
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestHeuristicEdgeInferrer(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	unit := func(cos float64) []float32 {
+		return []float32{float32(cos), float32(math.Sqrt(1 - cos*cos))}
+	}
+	incoming := model.MemoryRecord{SessionID: "s", Content: "The deploy failed because the migration locked the users table", Embedding: unit(1), CreatedAt: now}
+	nearest := []model.MemoryRecord{
+		{ID: 1, SessionID: "s", Content: "The deploy failed because the migration locked users", Embedding: unit(0.95), CreatedAt: now.Add(-time.Hour)},
+		{ID: 2, SessionID: "s", Content: "The deploy failed", Embedding: unit(0.85), CreatedAt: now.Add(-time.Hour)},
+		{ID: 3, SessionID: "s", Content: "Rolling back the deploy of the users service now", Embedding: unit(0.8), CreatedAt: now.Add(-5 * time.Minute)},
+		{ID: 4, SessionID: "other", Content: "Deploys of the users service need a migration review", Embedding: unit(0.78), CreatedAt: now.Add(-5 * time.Minute)},
+		{ID: 5, SessionID: "s", Content: "Lunch is at noon", Embedding: unit(0.1), CreatedAt: now.Add(-time.Minute)},
+	}
+	edges, err := HeuristicEdgeInferrer{MaxEdges: 4}.InferEdges(context.Background(), incoming, nearest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.EdgeType{model.EdgeDerivedFrom, model.EdgeExplains, model.EdgeFollows, model.EdgeRelatesTo}
+	if len(edges) != len(want) {
+		t.Fatalf("edges = %+v", edges)
+	}
+	for i, edge := range edges {
+		if edge.Target != nearest[i].ID || edge.Type != want[i] || edge.Weight <= 0 || edge.Weight > 1 {
+			t.Fatalf("edge %d = %+v, want %s to %d", i, edge, want[i], nearest[i].ID)
+		}
+	}
+}
+
+func TestStoreInfersEdgesToNearestMemories(t *testing.T) {
+	ctx := context.Background()
+	const (
+		outage = "The checkout service is down"
+		cause  = "Checkout is down because the payment provider rotated its keys"
+		lunch  = "Lunch is at noon"
+	)
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(angleEmbedder{outage: 1, cause: 0.9, lunch: 0.1})
+	var offered []int64
+	e.WithEdgeInferrer(EdgeInferrerFunc(func(_ context.Context, incoming model.MemoryRecord, nearest []model.MemoryRecord) ([]model.GraphEdge, error) {
+		offered = offered[:0]
+		var edges []model.GraphEdge
+		for _, rec := range nearest {
+			offered = append(offered, rec.ID)
+			if rec.Content == outage {
+				edges = append(edges, model.GraphEdge{Target: rec.ID, Type: model.EdgeExplains})
+			}
+		}
+		// Edges to records that were not offered, or of unknown type, are dropped.
+		return append(edges, model.GraphEdge{Target: 999, Type: model.EdgeFollows}, model.GraphEdge{Target: 1, Type: "causes"}), nil
+	}))
+
+	first, err := e.Store(ctx, "s", outage, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Store(ctx, "s", lunch, nil); err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.Store(ctx, "s", cause, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offered) != 2 || offered[0] != first.ID {
+		t.Fatalf("nearest should be offered most similar first: %v", offered)
+	}
+	if len(second.GraphEdges) != 1 || second.GraphEdges[0] != (model.GraphEdge{Target: first.ID, Type: model.EdgeExplains}) {
+		t.Fatalf("inferred edges = %+v", second.GraphEdges)
+	}
+	paths, err := e.GraphQuery(ctx, model.GraphQuery{Seeds: []int64{second.ID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0].End().ID != first.ID {
+		t.Fatalf("inferred edge not persisted: %+v", paths)
+	}
+}
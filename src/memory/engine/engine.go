@@ -7,12 +7,15 @@ import (
 	"log"
 	"math"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
+	"github.com/Protocol-Lattice/go-agent/src/flags"
 	"github.com/Protocol-Lattice/go-agent/src/memory/embed"
 	"github.com/Protocol-Lattice/go-agent/src/memory/model"
 	"github.com/Protocol-Lattice/go-agent/src/memory/store"
@@ -24,10 +27,42 @@ type Engine struct {
 	opts       Options
 	embedder   embed.Embedder
 	summarizer Summarizer
+	judge      DuplicateJudge
+	extractor  FactExtractor
+	conflicts  ConflictJudge
+	inferrer   EdgeInferrer
+	scorer     ImportanceScorer
+	flags      flags.Provider
+	sources    *SourceLearner
+	spaces     []spaceOverride
 	metrics    *Metrics
 	logger     *log.Logger
 	clock      func() time.Time
 	mu         sync.Mutex
+
+	sessionsOnce sync.Once
+	sessions     *sessionIndex
+
+	lexicalOnce sync.Once
+	lexical     *lexicalIndex
+
+	factsOnce sync.Once
+	facts     *factIndex
+
+	// Background importance scoring; see WithImportanceScorer.
+	scoreSlots   chan struct{}
+	scoring      sync.WaitGroup
+	scorePending atomic.Int32
+
+	// pruneMu serialises Prune passes; backgroundPrune counts running
+	// pruners.
+	pruneMu         sync.Mutex
+	backgroundPrune atomic.Int32
+
+	// Store and dedup counts at the last background compaction, guarded
+	// by pruneMu.
+	compactStored       int64
+	compactDeduplicated int64
 }
 
 // NewEngine constructs an advanced memory engine on top of a VectorStore implementation.
@@ -66,6 +101,19 @@ func (e *Engine) WithSummarizer(s Summarizer) *Engine {
 	return e
 }
 
+// WithEncryptor seals memory content, summaries and attachment payloads
+// with enc before they reach the store, and opens them on retrieval. See
+// store.EncryptedStore.
+func (e *Engine) WithEncryptor(enc store.ContentEncryptor) *Engine {
+	if enc == nil || e.store == nil {
+		return e
+	}
+	if encrypted, err := store.NewEncryptedStore(e.store, enc); err == nil {
+		e.store = encrypted
+	}
+	return e
+}
+
 // WithLogger overrides the default logger.
 func (e *Engine) WithLogger(logger *log.Logger) *Engine {
 	if logger != nil {
@@ -74,6 +122,18 @@ func (e *Engine) WithLogger(logger *log.Logger) *Engine {
 	return e
 }
 
+// WithFlags consults p for live toggles, currently flags.Summaries, which
+// overrides Options.EnableSummaries per call.
+func (e *Engine) WithFlags(p flags.Provider) *Engine {
+	e.flags = p
+	return e
+}
+
+// summariesEnabled reports whether cluster summaries are on for this call.
+func (e *Engine) summariesEnabled(ctx context.Context) bool {
+	return flags.Bool(ctx, e.flags, flags.Summaries, e.opts.EnableSummaries)
+}
+
 func (e *Engine) logf(format string, args ...any) {
 	if e.logger != nil {
 		e.logger.Printf(format, args...)
@@ -94,10 +154,64 @@ func (e *Engine) Store(ctx context.Context, sessionID, content string, metadata
 	if err != nil {
 		return model.MemoryRecord{}, fmt.Errorf("embed content: %w", err)
 	}
+	rec, _, err := e.StoreEmbedded(ctx, sessionID, content, metadata, embedding)
+	return rec, err
+}
+
+// Embed returns the embedding Store would compute for text.
+func (e *Engine) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.embed(ctx, text)
+}
+
+// StoreEmbedded is Store with an embedding the caller already computed,
+// normally with Embed. It also reports whether content duplicated a stored
+// memory, in which case nothing is written and the returned record is the
+// existing one.
+func (e *Engine) StoreEmbedded(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) (model.MemoryRecord, bool, error) {
+	if e.store == nil {
+		return model.MemoryRecord{}, false, errors.New("memory engine has no store")
+	}
+	w, dup, err := e.prepareWrite(ctx, sessionID, content, metadata, embedding, nil)
+	if err != nil {
+		return model.MemoryRecord{}, false, err
+	}
+	if dup != nil {
+		return *dup, true, nil
+	}
+	if err := e.store.StoreMemory(ctx, sessionID, content, w.metadata, embedding); err != nil {
+		return model.MemoryRecord{}, false, err
+	}
+	stored := e.commitWrite(ctx, w)
+	if e.inlinePrune() {
+		if err := e.Prune(ctx); err != nil {
+			e.logf("prune error: %v", err)
+		}
+	}
+	return stored, false, nil
+}
+
+// pendingWrite is a memory that passed the duplicate check and is ready to
+// be written.
+type pendingWrite struct {
+	record   model.MemoryRecord
+	metadata map[string]any
+	edges    []model.GraphEdge
+	// rescore asks the ImportanceScorer to replace the heuristic
+	// importance once the record is stored.
+	rescore bool
+}
+
+// prepareWrite fills in the derived metadata of a new memory and runs the
+// duplicate check against the store and against earlier, not yet written
+// records. It returns the existing record when content is a duplicate.
+func (e *Engine) prepareWrite(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32, earlier []model.MemoryRecord) (pendingWrite, *model.MemoryRecord, error) {
 	now := e.clock().UTC()
 	if metadata == nil {
 		metadata = map[string]any{}
 	}
+	if err := StampTenant(ctx, metadata); err != nil {
+		return pendingWrite{}, nil, err
+	}
 	if _, ok := metadata["space"]; !ok {
 		metadata["space"] = sessionID
 	}
@@ -105,20 +219,50 @@ func (e *Engine) Store(ctx context.Context, sessionID, content string, metadata
 		metadata["source"] = "default"
 	}
 	edges := model.SanitizeGraphEdges(metadata)
+	rescore := e.scorer != nil && model.FloatFromAny(metadata["importance"]) <= 0
 	importance := importanceScore(content, metadata)
 	metadata["importance"] = importance
-	// Deduplication based on cosine similarity.
+	// Deduplication based on cosine similarity, with an optional second
+	// stage for borderline pairs.
 	candidates, err := e.store.SearchMemory(ctx, sessionID, embedding, 5)
 	if err != nil {
-		return model.MemoryRecord{}, err
-	}
-	for _, cand := range candidates {
+		return pendingWrite{}, nil, err
+	}
+	// Only records with the same audience take part in deduplication,
+	// conflicts, edges and the cluster summary: otherwise a restricted
+	// write could fold into a public record, or a summary quote records its
+	// readers may not see.
+	candidates = sameAudience(append(candidates, earlier...), model.RecordACL(model.MemoryRecord{Metadata: model.StringFromAny(metadata)}))
+	opts := e.optionsFor(model.StringFromAny(metadata["space"]))
+	live := withoutTombstones(candidates, now)
+	for _, cand := range live {
 		sim := model.MaxCosineSimilarity(embedding, cand)
-		if sim >= e.opts.DuplicateSimilarity {
+		if e.isDuplicate(ctx, opts, cand, content, sim) {
 			e.metrics.IncDeduplicated()
-			return cand, nil
+			return pendingWrite{}, &cand, nil
+		}
+	}
+	if conflicts := e.detectConflicts(ctx, opts, live, content, embedding); len(conflicts) > 0 {
+		ids := make([]int64, len(conflicts))
+		for i, edge := range conflicts {
+			ids[i] = edge.Target
+		}
+		edges = append(edges, conflicts...)
+		metadata["graph_edges"] = edges
+		metadata[MetaConflictsWith] = ids
+	}
+	if facts, related := e.extractFacts(ctx, sessionID, content, edges); len(facts) > 0 {
+		metadata[MetaFacts] = facts
+		if len(related) > 0 {
+			edges = append(edges, related...)
+			metadata["graph_edges"] = edges
 		}
 	}
+	incoming := model.MemoryRecord{SessionID: sessionID, Content: content, Embedding: embedding, CreatedAt: now}
+	if inferred := e.inferEdges(ctx, incoming, live, edges); len(inferred) > 0 {
+		edges = append(edges, inferred...)
+		metadata["graph_edges"] = edges
+	}
 	// Cluster summary for the new record.
 	newRecord := model.MemoryRecord{
 		SessionID:    sessionID,
@@ -130,7 +274,7 @@ func (e *Engine) Store(ctx context.Context, sessionID, content string, metadata
 		CreatedAt:    now,
 		LastEmbedded: now,
 	}
-	if e.opts.EnableSummaries {
+	if e.summariesEnabled(ctx) {
 		summary, sumErr := e.clusterSummary(ctx, append(candidates, newRecord), newRecord)
 		if sumErr != nil {
 			e.logf("failed to summarize cluster: %v", sumErr)
@@ -139,32 +283,94 @@ func (e *Engine) Store(ctx context.Context, sessionID, content string, metadata
 		}
 	}
 	metadata["last_embedded"] = now.UTC().Format(time.RFC3339Nano)
-	if err := e.store.StoreMemory(ctx, sessionID, content, metadata, embedding); err != nil {
-		return model.MemoryRecord{}, err
+	return pendingWrite{record: newRecord, metadata: metadata, edges: edges, rescore: rescore}, nil, nil
+}
+
+// sameAudience keeps the records guarded by exactly acl, ignoring order
+// and case.
+func sameAudience(records []model.MemoryRecord, acl model.ACL) []model.MemoryRecord {
+	key := audienceKey(acl)
+	var out []model.MemoryRecord
+	for _, rec := range records {
+		if audienceKey(model.RecordACL(rec)) == key {
+			out = append(out, rec)
+		}
 	}
-	stored := newRecord
-	if results, err := e.store.SearchMemory(ctx, sessionID, embedding, 1); err == nil && len(results) > 0 {
-		stored = results[0]
+	return out
+}
+
+// audienceKey renders acl so that equal ACLs share a key.
+func audienceKey(acl model.ACL) string {
+	if acl.IsZero() {
+		return ""
+	}
+	norm := func(list []string) string {
+		out := make([]string, len(list))
+		for i, s := range list {
+			out[i] = strings.ToLower(s)
+		}
+		sort.Strings(out)
+		return strings.Join(slices.Compact(out), ",")
 	}
+	return norm(acl.Principals) + "|" + norm(acl.Groups) + "␟"
+}
+
+// commitWrite finishes a write the store has accepted: it reads the record
+// back and updates the session, lexical, fact and graph indexes.
+func (e *Engine) commitWrite(ctx context.Context, w pendingWrite) model.MemoryRecord {
+	stored := e.readBack(ctx, w.record)
 	if stored.Space == "" {
-		stored.Space = sessionID
+		stored.Space = w.record.SessionID
 	}
 	if len(stored.GraphEdges) == 0 {
-		stored.GraphEdges = edges
+		stored.GraphEdges = w.edges
 	}
 	e.metrics.IncStored()
-	if err := e.Prune(ctx); err != nil {
-		e.logf("prune error: %v", err)
-	}
-	stored.Metadata = model.StringFromAny(metadata)
-	stored.Summary = model.StringFromAny(metadata["summary"])
-	stored.Importance = importance
+	e.observeSession(model.MemoryRecord{SessionID: w.record.SessionID, Content: w.record.Content, Embedding: w.record.Embedding, Metadata: model.StringFromAny(w.metadata), CreatedAt: w.record.CreatedAt})
+	stored.Metadata = model.StringFromAny(w.metadata)
+	stored.Summary = model.StringFromAny(w.metadata["summary"])
+	stored.Importance = w.record.Importance
+	e.observeLexical(stored)
+	e.observeFacts(stored)
 	if graphStore, ok := e.store.(store.GraphStore); ok {
 		if err := graphStore.UpsertGraph(ctx, stored, stored.GraphEdges); err != nil {
 			e.logf("upsert graph: %v", err)
 		}
 	}
-	return stored, nil
+	if w.rescore {
+		e.scoreLater(ctx, stored, w.metadata)
+	}
+	return stored
+}
+
+// readBack fetches a just-written record for its store-assigned ID, falling
+// back to written when the store cannot find it. Near-identical embeddings
+// tie, so it matches on content rather than trusting the top hit, and takes
+// the newest match when an older record has the same content.
+func (e *Engine) readBack(ctx context.Context, written model.MemoryRecord) model.MemoryRecord {
+	results, err := e.store.SearchMemory(ctx, written.SessionID, written.Embedding, 5)
+	if err != nil {
+		return written
+	}
+	var found *model.MemoryRecord
+	for i := range results {
+		if results[i].Content == written.Content && (found == nil || results[i].CreatedAt.After(found.CreatedAt)) {
+			found = &results[i]
+		}
+	}
+	if found == nil {
+		return written
+	}
+	return *found
+}
+
+// UpdateImportance rescores a stored memory when the store implements
+// store.ImportanceUpdater and is a no-op otherwise.
+func (e *Engine) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if u, ok := e.store.(store.ImportanceUpdater); ok {
+		return u.UpdateImportance(ctx, id, importance)
+	}
+	return nil
 }
 
 // Retrieve performs weighted retrieval with MMR diversification and optional re-embedding.
@@ -175,21 +381,55 @@ func (e *Engine) Retrieve(ctx context.Context, sessionID, query string, limit in
 	if limit <= 0 {
 		return nil, nil
 	}
-	keywords := extractKeywords(query)
+	defer func(started time.Time) { e.metrics.ObserveRetrieve(time.Since(started)) }(time.Now())
+	set, err := e.gatherCandidates(ctx, sessionID, query, limit)
+	if err != nil || len(set.records) == 0 {
+		return nil, err
+	}
+	return e.rank(ctx, sessionID, set.embedding, set.keywords, set.records, set.lexical, limit, e.clock().UTC(), true)
+}
+
+// candidateSet is what Retrieve ranks: the query's embedding and keywords,
+// and the records found for it. The first vectorHits records come from
+// vector search; lexical holds BM25 scores by lexicalKey and graph the IDs
+// added by graph expansion.
+type candidateSet struct {
+	embedding  []float32
+	keywords   []string
+	records    []model.MemoryRecord
+	vectorHits int
+	lexical    map[string]float64
+	graph      map[int64]struct{}
+}
+
+func (e *Engine) gatherCandidates(ctx context.Context, sessionID, query string, limit int) (candidateSet, error) {
+	set := candidateSet{keywords: extractKeywords(query)}
 	embedding, err := e.embed(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("embed query: %w", err)
+		return set, fmt.Errorf("embed query: %w", err)
 	}
+	set.embedding = embedding
 	searchLimit := limit * 4
 	if searchLimit < limit {
 		searchLimit = limit
 	}
 	candidates, err := e.store.SearchMemory(ctx, sessionID, embedding, searchLimit)
 	if err != nil {
-		return nil, err
+		return set, err
+	}
+	set.vectorHits = len(candidates)
+	// Hybrid retrieval adds exact term matches the embedding missed, such as
+	// names, IDs and code symbols.
+	if e.hybridEnabled() {
+		hits, err := e.lexicalCandidates(ctx, sessionID, query, searchLimit)
+		if err != nil {
+			e.logf("lexical search: %v", err)
+		} else {
+			candidates, set.lexical = mergeLexical(candidates, hits)
+		}
 	}
 	if len(candidates) == 0 {
-		return nil, nil
+		return set, nil
 	}
 	similarityQuery := model.NewCosineQuery(embedding)
 	if graphStore, ok := e.store.(store.GraphStore); ok && e.opts.GraphNeighborhoodLimit > 0 {
@@ -208,6 +448,7 @@ func (e *Engine) Retrieve(ctx context.Context, sessionID, query string, limit in
 			if err != nil {
 				e.logf("graph neighborhood: %v", err)
 			} else if len(neighbors) > 0 {
+				set.graph = map[int64]struct{}{}
 				existingByID := make(map[int64]struct{}, len(candidates))
 				existingKey := make(map[string]struct{})
 				for _, cand := range candidates {
@@ -224,6 +465,7 @@ func (e *Engine) Retrieve(ctx context.Context, sessionID, query string, limit in
 							continue
 						}
 						existingByID[nb.ID] = struct{}{}
+						set.graph[nb.ID] = struct{}{}
 					} else {
 						key := nb.SessionID + "\u241F" + strings.TrimSpace(nb.Content)
 						if _, ok := existingKey[key]; ok {
@@ -239,33 +481,108 @@ func (e *Engine) Retrieve(ctx context.Context, sessionID, query string, limit in
 			}
 		}
 	}
-	weights := e.opts.normalizedWeights()
-	now := e.clock().UTC()
+	set.records = candidates
+	return set, nil
+}
+
+// RetrieveFiltered is Retrieve over the records matching filter, across
+// all of its sessions and spaces. The filter runs inside the store when it
+// implements store.FilteredSearcher, as Qdrant and the in-memory store do;
+// other stores are searched per session and filtered afterwards. Lexical
+// matches and graph neighbours are not mixed in, so every hit satisfies
+// filter. Per-space options apply when the filter names a single scope.
+func (e *Engine) RetrieveFiltered(ctx context.Context, query string, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	defer func(started time.Time) { e.metrics.ObserveFiltered(time.Since(started)) }(time.Now())
+	embedding, err := e.embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	candidates, err := store.SearchFiltered(ctx, e.store, embedding, filter, limit*4)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	scope := ""
+	if scopes := filter.Scopes(); len(scopes) == 1 {
+		scope = scopes[0]
+	}
+	return e.rank(ctx, scope, embedding, extractKeywords(query), candidates, nil, limit, e.clock().UTC(), true)
+}
+
+// rank scores candidates against the query, diversifies them with MMR and
+// orders the selection. Recency is measured from now; writeBack allows
+// drifted selections to be re-embedded and the edges between selections
+// to be reinforced.
+func (e *Engine) rank(ctx context.Context, sessionID string, embedding []float32, keywords []string, candidates []model.MemoryRecord, lexical map[string]float64, limit int, now time.Time, writeBack bool) ([]model.MemoryRecord, error) {
+	candidates, opts := e.scoreCandidates(ctx, sessionID, embedding, keywords, candidates, lexical, now)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	selected := mmrSelect(candidates, embedding, limit, opts.LambdaMMR, opts.temporalMMR(now))
+	if e.summariesEnabled(ctx) {
+		if err := e.populateSummaries(ctx, selected); err != nil {
+			e.logf("populate summaries: %v", err)
+		}
+	}
+	if writeBack {
+		if err := e.reembedOnDrift(ctx, selected); err != nil {
+			e.logf("reembed drift: %v", err)
+		}
+		e.reinforceEdges(ctx, selected, now)
+	}
+	e.metrics.IncRetrieved(len(selected))
+	sortRanked(selected)
+	return selected, nil
+}
+
+// scoreCandidates drops the candidates the caller may not see and sets the
+// similarity, keyword, importance and weighted scores of the rest. It also
+// returns the options they were scored under.
+func (e *Engine) scoreCandidates(ctx context.Context, sessionID string, embedding []float32, keywords []string, candidates []model.MemoryRecord, lexical map[string]float64, now time.Time) ([]model.MemoryRecord, Options) {
+	similarityQuery := model.NewCosineQuery(embedding)
+	// Neighbours pulled in through the graph are filtered too, so an edge
+	// never leaks a record the caller may not read, nor one of another
+	// tenant.
+	candidates = withoutTombstones(model.FilterVisible(ctx, model.FilterTenant(ctx, candidates)), now)
+	opts := e.optionsFor(sessionID)
+	if len(candidates) == 0 {
+		return nil, opts
+	}
+	for i := range candidates {
+		candidates[i].Score = similarityQuery.MaxSimilarity(candidates[i])
+	}
+	if lexical != nil {
+		e.fuseScores(candidates, lexical)
+	}
+	weights := opts.normalizedWeights()
 	for i := range candidates {
 		rec := &candidates[i]
 		meta := model.DecodeMetadata(rec.Metadata)
 		if rec.Importance == 0 {
 			rec.Importance = importanceScore(rec.Content, meta)
 		}
-		rec.Score = similarityQuery.MaxSimilarity(*rec)
 		rec.KeywordScore = keywordMatchScore(rec.Content, rec.Summary, meta, keywords)
-		recency := recencyScore(now.Sub(rec.CreatedAt), e.opts.HalfLife)
+		recency := recencyScore(now.Sub(rec.CreatedAt), opts.HalfLife)
 		if e.metrics != nil {
 			e.metrics.ObserveRecency(recency)
 		}
-		sourceScore := e.sourceScore(rec.Source)
+		sourceScore := e.sourceScore(opts, rec.Source)
 		rec.WeightedScore = weights.Similarity*rec.Score + weights.Keywords*rec.KeywordScore + weights.Importance*rec.Importance + weights.Recency*recency + weights.Source*sourceScore
 	}
-	selected := mmrSelect(candidates, embedding, limit, e.opts.LambdaMMR)
-	if e.opts.EnableSummaries {
-		if err := e.populateSummaries(ctx, selected); err != nil {
-			e.logf("populate summaries: %v", err)
-		}
-	}
-	if err := e.reembedOnDrift(ctx, selected); err != nil {
-		e.logf("reembed drift: %v", err)
-	}
-	e.metrics.IncRetrieved(len(selected))
+	return candidates, opts
+}
+
+// sortRanked orders a selection for the prompt: most important first, then
+// by weighted relevance, then newest first.
+func sortRanked(selected []model.MemoryRecord) {
 	sort.Slice(selected, func(i, j int) bool {
 		// 1) Highest importance first (hard rule)
 		if selected[i].Importance != selected[j].Importance {
@@ -278,14 +595,49 @@ func (e *Engine) Retrieve(ctx context.Context, sessionID, query string, limit in
 		// 3) Finally by recency (newer first)
 		return selected[i].CreatedAt.After(selected[j].CreatedAt)
 	})
+}
 
-	return selected, nil
+// ScoreBreakdown lists the components a retrieved record was ranked by.
+// Each component is in [0, 1]; Total is their sum under Weights and
+// matches the record's WeightedScore.
+type ScoreBreakdown struct {
+	Similarity float64      `json:"similarity"`
+	Keywords   float64      `json:"keywords"`
+	Importance float64      `json:"importance"`
+	Recency    float64      `json:"recency"`
+	Source     float64      `json:"source"`
+	Weights    ScoreWeights `json:"weights"`
+	Total      float64      `json:"total"`
+}
+
+// Breakdown explains the WeightedScore of rec, a record returned by
+// Retrieve for sessionID.
+func (e *Engine) Breakdown(sessionID string, rec model.MemoryRecord) ScoreBreakdown {
+	return e.breakdownAt(e.optionsFor(sessionID), rec, e.clock().UTC())
+}
+
+func (e *Engine) breakdownAt(opts Options, rec model.MemoryRecord, now time.Time) ScoreBreakdown {
+	b := ScoreBreakdown{
+		Similarity: rec.Score,
+		Keywords:   rec.KeywordScore,
+		Importance: rec.Importance,
+		Recency:    recencyScore(now.Sub(rec.CreatedAt), opts.HalfLife),
+		Source:     e.sourceScore(opts, rec.Source),
+		Weights:    opts.normalizedWeights(),
+	}
+	w := b.Weights
+	b.Total = w.Similarity*b.Similarity + w.Keywords*b.Keywords + w.Importance*b.Importance + w.Recency*b.Recency + w.Source*b.Source
+	return b
 }
+
 func (e *Engine) embed(ctx context.Context, text string) ([]float32, error) {
 	if e.embedder == nil {
 		e.embedder = embed.AutoEmbedder()
 	}
 	vec, err := e.embedder.Embed(ctx, text)
+	if embed.IsUnavailable(err) {
+		return nil, err
+	}
 	if err != nil || len(vec) == 0 {
 		return embed.DummyEmbedding(text), nil
 	}
@@ -318,15 +670,30 @@ func (e *Engine) reembedOnDrift(ctx context.Context, records []model.MemoryRecor
 }
 
 func (e *Engine) populateSummaries(ctx context.Context, records []model.MemoryRecord) error {
-	if e.summarizer == nil || !e.opts.EnableSummaries {
+	if e.summarizer == nil || !e.summariesEnabled(ctx) {
 		return nil
 	}
 	clusters := clusterRecords(records, e.opts.ClusterSimilarity)
-	for _, cluster := range clusters {
-		summary, err := e.summarizer.Summarize(ctx, cluster)
-		if err != nil {
+	var summaries []string
+	if batcher, ok := e.summarizer.(BatchSummarizer); ok && len(clusters) > 1 {
+		var err error
+		if summaries, err = batcher.SummarizeBatch(ctx, clusters); err != nil {
 			return err
 		}
+		if len(summaries) != len(clusters) {
+			return fmt.Errorf("summarizer returned %d summaries for %d clusters", len(summaries), len(clusters))
+		}
+	}
+	for c, cluster := range clusters {
+		var summary string
+		if summaries != nil {
+			summary = summaries[c]
+		} else {
+			var err error
+			if summary, err = e.summarizer.Summarize(ctx, cluster); err != nil {
+				return err
+			}
+		}
 		e.metrics.IncClustersSummarized()
 		for i := range cluster {
 			for j := range records {
@@ -354,17 +721,17 @@ func (e *Engine) clusterSummary(ctx context.Context, records []model.MemoryRecor
 	return "", nil
 }
 
-func (e *Engine) sourceScore(source string) float64 {
+func (o Options) sourceScore(source string) float64 {
 	if source == "" {
 		source = "default"
 	}
-	if e.opts.SourceBoost == nil {
+	if o.SourceBoost == nil {
 		return 1
 	}
-	if val, ok := e.opts.SourceBoost[strings.ToLower(source)]; ok {
+	if val, ok := o.SourceBoost[strings.ToLower(source)]; ok {
 		return clamp(val, 0, 1)
 	}
-	if val, ok := e.opts.SourceBoost["default"]; ok {
+	if val, ok := o.SourceBoost["default"]; ok {
 		return clamp(val, 0, 1)
 	}
 	return 1
@@ -437,9 +804,19 @@ func extractKeywords(text string) []string {
 }
 
 func keywordMatchScore(content, summary string, metadata map[string]any, keywords []string) float64 {
-	if len(keywords) == 0 {
+	matched, unique := matchKeywords(content, summary, metadata, keywords)
+	if unique == 0 {
 		return 0
 	}
+	return float64(len(matched)) / float64(unique)
+}
+
+// matchKeywords returns the distinct keywords found in a record's content,
+// summary or metadata, and how many distinct keywords there were.
+func matchKeywords(content, summary string, metadata map[string]any, keywords []string) ([]string, int) {
+	if len(keywords) == 0 {
+		return nil, 0
+	}
 	sb := strings.Builder{}
 	writeNormalized := func(value string) {
 		trimmed := strings.TrimSpace(value)
@@ -458,9 +835,9 @@ func keywordMatchScore(content, summary string, metadata map[string]any, keyword
 	}
 	haystack := sb.String()
 	if haystack == "" {
-		return 0
+		return nil, 0
 	}
-	matches := 0
+	var matched []string
 	unique := make(map[string]struct{}, len(keywords))
 	for _, kw := range keywords {
 		if kw == "" {
@@ -471,13 +848,10 @@ func keywordMatchScore(content, summary string, metadata map[string]any, keyword
 		}
 		unique[kw] = struct{}{}
 		if strings.Contains(haystack, kw) {
-			matches++
+			matched = append(matched, kw)
 		}
 	}
-	if len(unique) == 0 {
-		return 0
-	}
-	return float64(matches) / float64(len(unique))
+	return matched, len(unique)
 }
 
 func flattenMetadata(meta map[string]any) []string {
@@ -514,11 +888,27 @@ func flattenMetadata(meta map[string]any) []string {
 	return values
 }
 
-func mmrSelect(records []model.MemoryRecord, query []float32, limit int, lambda float64) []model.MemoryRecord {
+func mmrSelect(records []model.MemoryRecord, query []float32, limit int, lambda float64, temporal temporalMMR) []model.MemoryRecord {
+	order, _ := mmrOrder(records, query, limit, lambda, temporal)
+	out := make([]model.MemoryRecord, len(order))
+	for i, idx := range order {
+		out[i] = records[idx]
+	}
+	return out
+}
+
+// mmrOrder returns the indices of the records MMR selects, in selection
+// order, with the diversity penalty each paid when picked: (1-lambda)
+// times its highest similarity to the records picked before it, plus the
+// temporal penalty when its time window was already covered. When every
+// record fits in limit nothing is diversified and the penalties are zero.
+func mmrOrder(records []model.MemoryRecord, query []float32, limit int, lambda float64, temporal temporalMMR) ([]int, []float64) {
 	if limit >= len(records) {
-		out := make([]model.MemoryRecord, len(records))
-		copy(out, records)
-		return out
+		order := make([]int, len(records))
+		for i := range order {
+			order[i] = i
+		}
+		return order, make([]float64, len(records))
 	}
 	if lambda < 0 {
 		lambda = 0
@@ -526,36 +916,67 @@ func mmrSelect(records []model.MemoryRecord, query []float32, limit int, lambda
 	if lambda > 1 {
 		lambda = 1
 	}
-	remaining := make([]model.MemoryRecord, len(records))
-	copy(remaining, records)
-	selected := make([]model.MemoryRecord, 0, limit)
-	for len(selected) < limit && len(remaining) > 0 {
+	remaining := make([]int, len(records))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	order := make([]int, 0, limit)
+	penalties := make([]float64, 0, limit)
+	covered := map[int64]bool{}
+	haveOld := false
+	for len(order) < limit && len(remaining) > 0 {
+		// The last slot goes to an old record if none made it so far.
+		needOld := false
+		if !temporal.cutoff.IsZero() && !haveOld && len(order) == limit-1 {
+			for _, idx := range remaining {
+				if temporal.old(records[idx]) {
+					needOld = true
+					break
+				}
+			}
+		}
 		bestIdx := 0
 		bestScore := math.Inf(-1)
-		for i, cand := range remaining {
+		var bestPenalty float64
+		for i, idx := range remaining {
+			cand := records[idx]
+			if needOld && !temporal.old(cand) {
+				continue
+			}
 			relevance := cand.WeightedScore
 			if relevance == 0 {
 				relevance = model.MaxCosineSimilarity(query, cand)
 			}
 			var maxSim float64
-			for _, sel := range selected {
-				if sim := model.RecordSimilarity(cand, sel); sim > maxSim {
+			for _, sel := range order {
+				if sim := model.RecordSimilarity(cand, records[sel]); sim > maxSim {
 					maxSim = sim
 				}
 			}
-			score := lambda*relevance - (1-lambda)*maxSim
+			penalty := (1 - lambda) * maxSim
+			if temporal.window > 0 && covered[temporal.bucket(cand)] {
+				penalty += temporal.penalty
+			}
+			score := lambda*relevance - penalty
 			if lambda == 0 {
 				score = -maxSim
 			}
 			if score > bestScore {
 				bestScore = score
 				bestIdx = i
+				bestPenalty = penalty
 			}
 		}
-		selected = append(selected, remaining[bestIdx])
+		picked := records[remaining[bestIdx]]
+		if temporal.window > 0 {
+			covered[temporal.bucket(picked)] = true
+		}
+		haveOld = haveOld || (!temporal.cutoff.IsZero() && temporal.old(picked))
+		order = append(order, remaining[bestIdx])
+		penalties = append(penalties, bestPenalty)
 		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
 	}
-	return selected
+	return order, penalties
 }
 
 func clusterRecords(records []model.MemoryRecord, threshold float64) [][]model.MemoryRecord {
@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Protocol-Lattice/go-agent/src/flags"
 	embedpkg "github.com/Protocol-Lattice/go-agent/src/memory/embed"
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
 	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
 )
 
@@ -154,3 +156,182 @@ func TestEngineReembedOnDrift(t *testing.T) {
 		t.Fatalf("expected drift re-embedding metric to increment")
 	}
 }
+
+func TestEngineWithEncryptorKeepsPlaintextOutOfTheStore(t *testing.T) {
+	memStore := storepkg.NewInMemoryStore()
+	keys := storepkg.StaticKeys{Current: "k", Keys: map[string][]byte{"k": []byte("0123456789abcdef0123456789abcdef")}}
+	engine := NewEngine(memStore, Options{EnableSummaries: true}).
+		WithEmbedder(embedpkg.DummyEmbedder{}).
+		WithEncryptor(storepkg.NewAESGCMEncryptor(keys))
+	ctx := context.Background()
+
+	if _, err := engine.Store(ctx, "ops", "The vault passphrase rotates monthly", nil); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	records, err := engine.Retrieve(ctx, "ops", "vault passphrase", 1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("retrieve: %v, %d records", err, len(records))
+	}
+	if records[0].Content != "The vault passphrase rotates monthly" {
+		t.Fatalf("expected decrypted content, got %q", records[0].Content)
+	}
+	raw, _ := memStore.SearchMemory(ctx, "ops", records[0].Embedding, 1)
+	if len(raw) != 1 || raw[0].Content == records[0].Content || raw[0].Summary == records[0].Summary {
+		t.Fatalf("store holds plaintext: %+v", raw)
+	}
+}
+
+func TestEngineBreakdownMatchesWeightedScore(t *testing.T) {
+	opts := Options{
+		Weights:     ScoreWeights{Similarity: 0.5, Keywords: 0.2, Importance: 0.1, Recency: 0.1, Source: 0.1},
+		HalfLife:    24 * time.Hour,
+		SourceBoost: map[string]float64{"default": 0.2, "pagerduty": 1},
+	}
+	engine := NewEngine(storepkg.NewInMemoryStore(), opts).WithEmbedder(embedpkg.DummyEmbedder{})
+	ctx := context.Background()
+	if _, err := engine.Store(ctx, "team", "Database failover runbook", map[string]any{"source": "pagerduty"}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	records, err := engine.Retrieve(ctx, "team", "failover", 1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("retrieve = %d records, %v", len(records), err)
+	}
+	b := engine.Breakdown("team", records[0])
+	if diff := b.Total - records[0].WeightedScore; diff > 1e-6 || diff < -1e-6 {
+		t.Fatalf("breakdown total %.6f, weighted score %.6f", b.Total, records[0].WeightedScore)
+	}
+	if b.Source != 1 || b.Recency < 0.99 {
+		t.Fatalf("source %.2f recency %.2f, want a boosted, fresh record", b.Source, b.Recency)
+	}
+}
+
+func TestEngineFlagsToggleSummaries(t *testing.T) {
+	live := flags.Static{flags.Summaries: "false"}
+	engine := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(embedpkg.DummyEmbedder{}).WithSummarizer(HeuristicSummarizer{}).WithFlags(live)
+	ctx := context.Background()
+	if _, err := engine.Store(ctx, "team", "Critical production outage impacting users", nil); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	records, err := engine.Retrieve(ctx, "team", "production outage", 1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("retrieve = %d records, %v", len(records), err)
+	}
+	if records[0].Summary != "" {
+		t.Fatalf("summary %q populated with the flag off", records[0].Summary)
+	}
+
+	live[flags.Summaries] = "true"
+	records, err = engine.Retrieve(ctx, "team", "production outage", 1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("retrieve = %d records, %v", len(records), err)
+	}
+	if records[0].Summary == "" {
+		t.Fatal("summary missing with the flag back on")
+	}
+}
+
+type countingBatchSummarizer struct{ batches, singles int }
+
+func (s *countingBatchSummarizer) Summarize(context.Context, []model.MemoryRecord) (string, error) {
+	s.singles++
+	return "single", nil
+}
+
+func (s *countingBatchSummarizer) SummarizeBatch(_ context.Context, clusters [][]model.MemoryRecord) ([]string, error) {
+	s.batches++
+	out := make([]string, len(clusters))
+	for i := range out {
+		out[i] = "batched"
+	}
+	return out, nil
+}
+
+func TestEngineSummarizesClustersInOneBatch(t *testing.T) {
+	summarizer := &countingBatchSummarizer{}
+	engine := NewEngine(storepkg.NewInMemoryStore(), Options{ClusterSimilarity: 0.999}).WithEmbedder(embedpkg.DummyEmbedder{}).WithSummarizer(summarizer)
+	ctx := context.Background()
+	for _, content := range []string{"Critical production outage impacting users", "Quarterly planning offsite agenda"} {
+		if _, err := engine.Store(ctx, "team", content, nil); err != nil {
+			t.Fatalf("store: %v", err)
+		}
+	}
+	// Store summarizes each new record's own cluster.
+	summarizer.singles = 0
+	records, err := engine.Retrieve(ctx, "team", "production outage planning", 2)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("retrieve = %d records, %v", len(records), err)
+	}
+	if summarizer.batches != 1 || summarizer.singles != 0 {
+		t.Fatalf("batches = %d, singles = %d; want one batch", summarizer.batches, summarizer.singles)
+	}
+	for _, rec := range records {
+		if rec.Summary != "batched" {
+			t.Fatalf("summary = %q, want the batched one", rec.Summary)
+		}
+	}
+}
+
+func TestEngineRetrieveFilteredKeepsOnlyMatchingRecords(t *testing.T) {
+	engine := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(embedpkg.DummyEmbedder{})
+	ctx := context.Background()
+	for _, w := range []struct{ session, content, source string }{
+		{"team:eng", "Deploy freeze starts Friday", "slack"},
+		{"team:eng", "Deploy checklist lives in Notion", "notion"},
+		{"team:ops", "Deploy pager rotation", "slack"},
+	} {
+		if _, err := engine.Store(ctx, w.session, w.content, map[string]any{"source": w.source}); err != nil {
+			t.Fatalf("store: %v", err)
+		}
+	}
+	records, err := engine.RetrieveFiltered(ctx, "deploy", model.Filter{Spaces: []string{"team:eng", "team:ops"}, Sources: []string{"slack"}}, 5)
+	if err != nil {
+		t.Fatalf("RetrieveFiltered: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want the two slack memories", len(records))
+	}
+	for _, rec := range records {
+		if rec.Source != "slack" {
+			t.Fatalf("record from %q slipped through the filter", rec.Source)
+		}
+	}
+}
+
+func TestMMRTemporalDiversity(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	var records []model.MemoryRecord
+	for i := range 4 {
+		records = append(records, model.MemoryRecord{
+			ID:            int64(i + 1),
+			Content:       "deploy notes",
+			Embedding:     []float32{1, float32(i) * 0.01},
+			WeightedScore: 0.9 - float64(i)*0.01,
+			CreatedAt:     now.Add(-time.Duration(i) * time.Hour),
+		})
+	}
+	last := model.MemoryRecord{ID: 5, Content: "last week's deploy", Embedding: []float32{1, 0.05}, WeightedScore: 0.7, CreatedAt: now.Add(-5 * 24 * time.Hour)}
+	old := model.MemoryRecord{ID: 6, Content: "original deploy design", Embedding: []float32{1, 0.06}, WeightedScore: 0.5, CreatedAt: now.Add(-60 * 24 * time.Hour)}
+	records = append(records, last, old)
+	ids := func(recs []model.MemoryRecord) []int64 {
+		out := make([]int64, len(recs))
+		for i, rec := range recs {
+			out[i] = rec.ID
+		}
+		return out
+	}
+
+	plain := ids(mmrSelect(records, []float32{1, 0}, 3, 0.7, Options{}.temporalMMR(now)))
+	if plain[0] != 1 || plain[1] != 2 || plain[2] != 3 {
+		t.Fatalf("without temporal options = %v, want the three most recent", plain)
+	}
+
+	spread := ids(mmrSelect(records, []float32{1, 0}, 3, 0.7, Options{TemporalWindow: 7 * 24 * time.Hour, TemporalPenalty: 0.3}.temporalMMR(now)))
+	if spread[0] != 1 || spread[1] != 5 || spread[2] != 6 {
+		t.Fatalf("with a temporal window = %v, want one record per window", spread)
+	}
+
+	reserved := ids(mmrSelect(records, []float32{1, 0}, 3, 0.7, Options{MinOlderThan: 30 * 24 * time.Hour}.temporalMMR(now)))
+	if reserved[0] != 1 || reserved[1] != 2 || reserved[2] != 6 {
+		t.Fatalf("with MinOlderThan = %v, want the last slot to go to the old record", reserved)
+	}
+}
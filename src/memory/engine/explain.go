@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// Where a retrieval candidate was found. A record can have several.
+const (
+	OriginVector  = "vector"
+	OriginLexical = "lexical"
+	OriginGraph   = "graph"
+)
+
+// Explanation says why a candidate did or did not make it into a Retrieve
+// result.
+type Explanation struct {
+	Record model.MemoryRecord `json:"record"`
+	// Selected reports whether Retrieve would return the record; Rank is
+	// its 1-based position in that result, zero when not selected.
+	Selected bool `json:"selected"`
+	Rank     int  `json:"rank,omitempty"`
+	// Origins lists how the record was found: OriginVector, OriginLexical
+	// and OriginGraph.
+	Origins []string       `json:"origins"`
+	Score   ScoreBreakdown `json:"score"`
+	// Lexical is the record's BM25 score under hybrid retrieval.
+	Lexical float64 `json:"lexical,omitempty"`
+	// MatchedTerms are the query keywords found in the record's content,
+	// summary or metadata.
+	MatchedTerms []string `json:"matched_terms,omitempty"`
+	// MMRPenalty is what the record's similarity to records picked before
+	// it, and any temporal penalty, cost it during diversification; zero
+	// when every candidate fit.
+	MMRPenalty float64 `json:"mmr_penalty,omitempty"`
+	// GraphFrom lists, for records reached through graph expansion, the
+	// candidates they share an edge with.
+	GraphFrom []int64 `json:"graph_from,omitempty"`
+}
+
+// Explain runs the ranking of Retrieve for query without its side effects
+// (re-embedding, edge reinforcement, summaries and metrics) and reports
+// every candidate it considered: the selected records first, in the order
+// Retrieve returns them, then the rest by weighted score.
+func (e *Engine) Explain(ctx context.Context, sessionID, query string, limit int) ([]Explanation, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	set, err := e.gatherCandidates(ctx, sessionID, query, limit)
+	if err != nil || len(set.records) == 0 {
+		return nil, err
+	}
+	vector := make(map[string]struct{}, set.vectorHits)
+	for _, rec := range set.records[:set.vectorHits] {
+		vector[lexicalKey(rec)] = struct{}{}
+	}
+	now := e.clock().UTC()
+	candidates, opts := e.scoreCandidates(ctx, sessionID, set.embedding, set.keywords, set.records, set.lexical, now)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	order, penalties := mmrOrder(candidates, set.embedding, limit, opts.LambdaMMR, opts.temporalMMR(now))
+	penalty := make(map[int]float64, len(order))
+	for i, idx := range order {
+		penalty[idx] = penalties[i]
+	}
+
+	out := make([]Explanation, len(candidates))
+	for i, rec := range candidates {
+		key := lexicalKey(rec)
+		x := Explanation{Record: rec, Score: e.breakdownAt(opts, rec, now)}
+		if _, ok := vector[key]; ok {
+			x.Origins = append(x.Origins, OriginVector)
+		}
+		if score, ok := set.lexical[key]; ok {
+			x.Origins = append(x.Origins, OriginLexical)
+			x.Lexical = score
+		}
+		if _, ok := set.graph[rec.ID]; ok && rec.ID != 0 {
+			x.Origins = append(x.Origins, OriginGraph)
+			x.GraphFrom = graphLinks(rec, candidates)
+		}
+		x.MatchedTerms, _ = matchKeywords(rec.Content, rec.Summary, model.DecodeMetadata(rec.Metadata), set.keywords)
+		x.MMRPenalty, x.Selected = penalty[i]
+		out[i] = x
+	}
+
+	selected := make([]model.MemoryRecord, 0, len(order))
+	for _, idx := range order {
+		selected = append(selected, candidates[idx])
+	}
+	sortRanked(selected)
+	rank := make(map[string]int, len(selected))
+	for i, rec := range selected {
+		rank[lexicalKey(rec)] = i + 1
+	}
+	for i := range out {
+		out[i].Rank = rank[lexicalKey(out[i].Record)]
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri, rj := out[i].Rank, out[j].Rank
+		if (ri == 0) != (rj == 0) {
+			return ri != 0
+		}
+		if ri != 0 {
+			return ri < rj
+		}
+		return out[i].Record.WeightedScore > out[j].Record.WeightedScore
+	})
+	return out, nil
+}
+
+// graphLinks returns the IDs of the candidates rec has an edge to or from.
+func graphLinks(rec model.MemoryRecord, candidates []model.MemoryRecord) []int64 {
+	var links []int64
+	seen := map[int64]bool{rec.ID: true}
+	add := func(id int64) {
+		if !seen[id] {
+			seen[id] = true
+			links = append(links, id)
+		}
+	}
+	in := make(map[int64]bool, len(candidates))
+	for _, cand := range candidates {
+		in[cand.ID] = true
+		for _, edge := range cand.GraphEdges {
+			if edge.Target == rec.ID {
+				add(cand.ID)
+			}
+		}
+	}
+	for _, edge := range rec.GraphEdges {
+		if in[edge.Target] {
+			add(edge.Target)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i] < links[j] })
+	return links
+}
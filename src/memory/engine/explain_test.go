@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestExplainMatchesRetrieve(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{DuplicateSimilarity: 1.01, HybridWeight: 0.5, LambdaMMR: 0.5}).WithEmbedder(topicEmbedder{})
+	for i := range 6 {
+		if _, err := e.Store(ctx, "s", fmt.Sprintf("printer note %d about the paper tray", i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := e.Store(ctx, "s", "invoice ZX4411 was paid twice", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	const query = "printer tray ZX4411"
+	got, err := e.Explain(ctx, "s", query, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := e.Retrieve(ctx, "s", query, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 7 {
+		t.Fatalf("explained %d candidates, want all 7", len(got))
+	}
+	for i, rec := range want {
+		x := got[i]
+		if !x.Selected || x.Rank != i+1 || x.Record.ID != rec.ID {
+			t.Fatalf("explanation %d = %+v, want record %d at rank %d", i, x, rec.ID, i+1)
+		}
+		if diff := x.Score.Total - rec.WeightedScore; diff > 1e-6 || diff < -1e-6 {
+			t.Fatalf("score total %.6f, weighted score %.6f", x.Score.Total, rec.WeightedScore)
+		}
+	}
+	for _, x := range got[len(want):] {
+		if x.Selected || x.Rank != 0 {
+			t.Fatalf("unselected candidate explained as %+v", x)
+		}
+	}
+
+	var lexical, penalised bool
+	for _, x := range got {
+		if strings.Contains(x.Record.Content, "ZX4411") {
+			lexical = slices.Contains(x.Origins, OriginLexical) && x.Lexical > 0 && slices.Contains(x.MatchedTerms, "zx4411")
+		}
+		if x.Selected && x.MMRPenalty > 0 {
+			penalised = true
+		}
+	}
+	if !lexical {
+		t.Fatalf("ZX4411 record lacks its lexical origin or matched term: %+v", got)
+	}
+	if !penalised {
+		t.Fatal("no selected record paid an MMR penalty, though the printer notes are identical")
+	}
+}
@@ -0,0 +1,322 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// MetaFacts holds, on a record, the facts extracted from its content.
+const MetaFacts = "facts"
+
+// maxFactEdges caps the EdgeRelatesTo edges a new record gets to earlier
+// records that share an entity with it.
+const maxFactEdges = 8
+
+// Fact is a (subject, predicate, object) triple stated by a memory, such
+// as ("Alice", "works at", "Acme").
+type Fact struct {
+	Subject   string `json:"subject"`
+	Predicate string `json:"predicate"`
+	Object    string `json:"object"`
+}
+
+// FactExtractor turns memory content into facts. Engine.Store runs it on
+// every memory that passes the duplicate check.
+type FactExtractor interface {
+	ExtractFacts(ctx context.Context, content string) ([]Fact, error)
+}
+
+// FactExtractorFunc adapts a function to FactExtractor.
+type FactExtractorFunc func(ctx context.Context, content string) ([]Fact, error)
+
+func (f FactExtractorFunc) ExtractFacts(ctx context.Context, content string) ([]Fact, error) {
+	return f(ctx, content)
+}
+
+// WithFactExtractor enables fact extraction on Store and StoreBatch. The
+// facts are saved under MetaFacts, and the new record gets an
+// EdgeRelatesTo edge to the most recent earlier records of its session
+// with facts about the same subject or object. An extractor error is
+// logged and the memory is stored without facts.
+func (e *Engine) WithFactExtractor(x FactExtractor) *Engine {
+	e.extractor = x
+	return e
+}
+
+// KnownFact is a fact together with the memory that states it.
+type KnownFact struct {
+	Fact
+	RecordID  int64     `json:"record_id"`
+	SessionID string    `json:"session_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FactsAbout answers "what does the agent know about entity": the facts
+// whose subject or object is entity (compared case-insensitively), newest
+// first. An empty sessionID searches every session of the tenant on ctx.
+// Tombstoned records are left out.
+func (e *Engine) FactsAbout(ctx context.Context, sessionID, entity string) ([]KnownFact, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	idx, err := e.loadedFactIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := entityKey(entity)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	tenant := model.TenantFromContext(ctx)
+	var out []KnownFact
+	for id := range idx.byEntity[key] {
+		entry := idx.records[id]
+		if (sessionID != "" && entry.sessionID != sessionID) || entry.tenant != tenant {
+			continue
+		}
+		for _, f := range entry.facts {
+			if entityKey(f.Subject) == key || entityKey(f.Object) == key {
+				out = append(out, KnownFact{Fact: f, RecordID: id, SessionID: entry.sessionID, CreatedAt: entry.createdAt})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].RecordID > out[j].RecordID
+	})
+	return out, nil
+}
+
+// extractFacts runs the extractor for a new memory and links it to earlier
+// records that share an entity. It returns the facts and the new edges.
+func (e *Engine) extractFacts(ctx context.Context, sessionID, content string, existing []model.GraphEdge) ([]Fact, []model.GraphEdge) {
+	if e.extractor == nil {
+		return nil, nil
+	}
+	facts, err := e.extractor.ExtractFacts(ctx, content)
+	if err != nil {
+		e.logf("extract facts: %v", err)
+		return nil, nil
+	}
+	facts = cleanFacts(facts)
+	if len(facts) == 0 {
+		return nil, nil
+	}
+	idx, err := e.loadedFactIndex(ctx)
+	if err != nil {
+		e.logf("fact index: %v", err)
+		return facts, nil
+	}
+	linked := make(map[int64]bool, len(existing))
+	for _, edge := range existing {
+		linked[edge.Target] = true
+	}
+	tenant := model.TenantFromContext(ctx)
+	idx.mu.RLock()
+	var related []int64
+	for _, f := range facts {
+		for _, key := range []string{entityKey(f.Subject), entityKey(f.Object)} {
+			for id := range idx.byEntity[key] {
+				if entry := idx.records[id]; !linked[id] && entry.sessionID == sessionID && entry.tenant == tenant {
+					linked[id] = true
+					related = append(related, id)
+				}
+			}
+		}
+	}
+	sort.Slice(related, func(i, j int) bool {
+		a, b := idx.records[related[i]], idx.records[related[j]]
+		if !a.createdAt.Equal(b.createdAt) {
+			return a.createdAt.After(b.createdAt)
+		}
+		return related[i] > related[j]
+	})
+	idx.mu.RUnlock()
+	if len(related) > maxFactEdges {
+		related = related[:maxFactEdges]
+	}
+	edges := make([]model.GraphEdge, len(related))
+	for i, id := range related {
+		edges[i] = model.GraphEdge{Target: id, Type: model.EdgeRelatesTo}
+	}
+	return facts, edges
+}
+
+// cleanFacts trims the triples and drops incomplete ones and repeats.
+func cleanFacts(facts []Fact) []Fact {
+	seen := make(map[Fact]bool, len(facts))
+	out := facts[:0]
+	for _, f := range facts {
+		f = Fact{Subject: strings.TrimSpace(f.Subject), Predicate: strings.TrimSpace(f.Predicate), Object: strings.TrimSpace(f.Object)}
+		if f.Subject == "" || f.Predicate == "" || f.Object == "" {
+			continue
+		}
+		key := Fact{Subject: entityKey(f.Subject), Predicate: strings.ToLower(f.Predicate), Object: entityKey(f.Object)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// RecordFacts returns the facts stored on rec under MetaFacts.
+func RecordFacts(rec model.MemoryRecord) []Fact {
+	return decodeFacts(model.DecodeMetadata(rec.Metadata)[MetaFacts])
+}
+
+func decodeFacts(raw any) []Fact {
+	switch v := raw.(type) {
+	case nil:
+		return nil
+	case []Fact:
+		return v
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var facts []Fact
+	if err := json.Unmarshal(data, &facts); err != nil {
+		return nil
+	}
+	return cleanFacts(facts)
+}
+
+func entityKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// factIndex maps entities to the records with facts about them. It is
+// built from the store on first use and kept current by the engine's own
+// writes, prunes and tombstones, like the lexical index.
+type factIndex struct {
+	mu       sync.RWMutex
+	loaded   bool
+	byEntity map[string]map[int64]struct{}
+	records  map[int64]factEntry
+}
+
+type factEntry struct {
+	sessionID string
+	tenant    string
+	createdAt time.Time
+	facts     []Fact
+}
+
+func (idx *factIndex) add(rec model.MemoryRecord, facts []Fact) {
+	if rec.ID == 0 || len(facts) == 0 {
+		return
+	}
+	idx.records[rec.ID] = factEntry{sessionID: rec.SessionID, tenant: model.RecordTenant(rec), createdAt: rec.CreatedAt, facts: facts}
+	for _, f := range facts {
+		for _, key := range []string{entityKey(f.Subject), entityKey(f.Object)} {
+			ids, ok := idx.byEntity[key]
+			if !ok {
+				ids = map[int64]struct{}{}
+				idx.byEntity[key] = ids
+			}
+			ids[rec.ID] = struct{}{}
+		}
+	}
+}
+
+func (idx *factIndex) remove(id int64) {
+	entry, ok := idx.records[id]
+	if !ok {
+		return
+	}
+	delete(idx.records, id)
+	for _, f := range entry.facts {
+		for _, key := range []string{entityKey(f.Subject), entityKey(f.Object)} {
+			delete(idx.byEntity[key], id)
+			if len(idx.byEntity[key]) == 0 {
+				delete(idx.byEntity, key)
+			}
+		}
+	}
+}
+
+func (e *Engine) factIndex() *factIndex {
+	e.factsOnce.Do(func() {
+		e.facts = &factIndex{byEntity: map[string]map[int64]struct{}{}, records: map[int64]factEntry{}}
+	})
+	return e.facts
+}
+
+// loadedFactIndex returns the fact index, reading the store on first use.
+func (e *Engine) loadedFactIndex(ctx context.Context) (*factIndex, error) {
+	idx := e.factIndex()
+	idx.mu.RLock()
+	loaded := idx.loaded
+	idx.mu.RUnlock()
+	if loaded {
+		return idx, nil
+	}
+	now := e.clock()
+	type indexed struct {
+		rec   model.MemoryRecord
+		facts []Fact
+	}
+	var found []indexed
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if facts := RecordFacts(rec); len(facts) > 0 && !isTombstoned(rec, now) {
+			found = append(found, indexed{rec: rec, facts: facts})
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.loaded {
+		for _, f := range found {
+			idx.add(f.rec, f.facts)
+		}
+		idx.loaded = true
+	}
+	return idx, nil
+}
+
+// observeFacts indexes a stored record once the index has been loaded.
+func (e *Engine) observeFacts(rec model.MemoryRecord) {
+	if e.facts == nil {
+		return
+	}
+	facts := RecordFacts(rec)
+	if len(facts) == 0 {
+		return
+	}
+	idx := e.factIndex()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.loaded {
+		idx.add(rec, facts)
+	}
+}
+
+// forgetFacts drops deleted or tombstoned records from the index.
+func (e *Engine) forgetFacts(ids []int64) {
+	if e.facts == nil {
+		return
+	}
+	idx := e.factIndex()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, id := range ids {
+		idx.remove(id)
+	}
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestFactExtractionLinksAndAnswers(t *testing.T) {
+	ctx := context.Background()
+	known := map[string][]Fact{
+		"Alice joined Acme in March":  {{Subject: "Alice", Predicate: "works at", Object: "Acme"}},
+		"Acme ships from Rotterdam":   {{Subject: " acme ", Predicate: "ships from", Object: "Rotterdam"}, {Subject: "", Predicate: "is", Object: "x"}},
+		"the weather was nice":        nil,
+		"Bob met Alice at the meetup": {{Subject: "Bob", Predicate: "met", Object: "Alice"}},
+	}
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(angleEmbedder{
+		"Alice joined Acme in March":  0,
+		"Acme ships from Rotterdam":   0.5,
+		"the weather was nice":        1,
+		"Bob met Alice at the meetup": 1.5,
+	}).WithFactExtractor(FactExtractorFunc(func(_ context.Context, content string) ([]Fact, error) {
+		return known[content], nil
+	}))
+
+	alice, err := e.Store(ctx, "s", "Alice joined Acme in March", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme, err := e.Store(ctx, "s", "Acme ships from Rotterdam", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weather, err := e.Store(ctx, "s", "the weather was nice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Store(ctx, "other", "Bob met Alice at the meetup", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(acme.GraphEdges) != 1 || acme.GraphEdges[0] != (model.GraphEdge{Target: alice.ID, Type: model.EdgeRelatesTo}) {
+		t.Fatalf("expected a relates_to edge to the Alice record: %+v", acme.GraphEdges)
+	}
+	if len(weather.GraphEdges) != 0 || len(RecordFacts(weather)) != 0 {
+		t.Fatalf("record without facts was linked: %+v", weather)
+	}
+	if facts := RecordFacts(acme); len(facts) != 1 || facts[0].Subject != "acme" {
+		t.Fatalf("facts not cleaned: %+v", facts)
+	}
+
+	about, err := e.FactsAbout(ctx, "s", "ACME")
+	if err != nil {
+		t.Fatalf("FactsAbout: %v", err)
+	}
+	if len(about) != 2 || about[0].RecordID != acme.ID || about[1].RecordID != alice.ID {
+		t.Fatalf("unexpected facts about Acme: %+v", about)
+	}
+	everywhere, err := e.FactsAbout(ctx, "", "alice")
+	if err != nil {
+		t.Fatalf("FactsAbout: %v", err)
+	}
+	if len(everywhere) != 2 {
+		t.Fatalf("expected Alice facts from both sessions: %+v", everywhere)
+	}
+
+	if err := e.Tombstone(ctx, alice.ID, "wrong"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+	about, err = e.FactsAbout(ctx, "s", "acme")
+	if err != nil {
+		t.Fatalf("FactsAbout: %v", err)
+	}
+	if len(about) != 1 || about[0].RecordID != acme.ID {
+		t.Fatalf("tombstoned facts still answered: %+v", about)
+	}
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// GraphFormat selects the output of ExportGraph.
+type GraphFormat string
+
+const (
+	// GraphDOT is Graphviz DOT.
+	GraphDOT GraphFormat = "dot"
+	// GraphML is GraphML, which Gephi and yEd open directly.
+	GraphML GraphFormat = "graphml"
+	// GraphJSON is node-link JSON: {"nodes": [...], "edges": [...]}.
+	GraphJSON GraphFormat = "json"
+)
+
+// graphLabelLen caps node labels, in runes.
+const graphLabelLen = 60
+
+// GraphExportOptions selects what ExportGraph writes.
+type GraphExportOptions struct {
+	// Format defaults to GraphJSON.
+	Format GraphFormat
+	// Spaces and Sessions keep only records in these spaces or sessions. A
+	// trailing "*" matches a prefix, e.g. "team:*". Both empty keeps every
+	// record.
+	Spaces   []string
+	Sessions []string
+}
+
+// GraphNode is a record in an exported graph.
+type GraphNode struct {
+	ID         int64     `json:"id"`
+	SessionID  string    `json:"session_id"`
+	Space      string    `json:"space,omitempty"`
+	Label      string    `json:"label"`
+	Source     string    `json:"source,omitempty"`
+	Importance float64   `json:"importance"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GraphLink is an edge in an exported graph.
+type GraphLink struct {
+	Source int64          `json:"source"`
+	Target int64          `json:"target"`
+	Type   model.EdgeType `json:"type"`
+}
+
+// ExportGraph writes the memory graph, records as nodes and their
+// GraphEdges as edges, for Graphviz, Gephi or a notebook. Only records of
+// the tenant on ctx are exported. Tombstoned records are left out, and so
+// are edges whose target is not exported.
+func (e *Engine) ExportGraph(ctx context.Context, w io.Writer, opts GraphExportOptions) error {
+	if e.store == nil {
+		return errors.New("memory engine has no store")
+	}
+	if opts.Format == "" {
+		opts.Format = GraphJSON
+	}
+	switch opts.Format {
+	case GraphDOT, GraphML, GraphJSON:
+	default:
+		return fmt.Errorf("unknown graph format %q", opts.Format)
+	}
+
+	now := e.clock().UTC()
+	var nodes []GraphNode
+	var links []GraphLink
+	exported := map[int64]struct{}{}
+	var iterErr error
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if iterErr = ctx.Err(); iterErr != nil {
+			return false
+		}
+		if rec.ID == 0 || !ownedBy(ctx, rec) || !opts.keep(rec) || isTombstoned(rec, now) {
+			return true
+		}
+		meta := model.DecodeMetadata(rec.Metadata)
+		importance := rec.Importance
+		if importance == 0 {
+			importance = importanceScore(rec.Content, meta)
+		}
+		source := rec.Source
+		if source == "" {
+			source = model.StringFromAny(meta["source"])
+		}
+		nodes = append(nodes, GraphNode{
+			ID:         rec.ID,
+			SessionID:  rec.SessionID,
+			Space:      recordSpace(rec),
+			Label:      graphLabel(rec.Content),
+			Source:     source,
+			Importance: importance,
+			CreatedAt:  rec.CreatedAt,
+		})
+		exported[rec.ID] = struct{}{}
+		edges := rec.GraphEdges
+		if len(edges) == 0 {
+			edges = model.ValidGraphEdges(meta)
+		}
+		for _, edge := range edges {
+			links = append(links, GraphLink{Source: rec.ID, Target: edge.Target, Type: edge.Type})
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if iterErr != nil {
+		return iterErr
+	}
+	kept := links[:0]
+	for _, l := range links {
+		if _, ok := exported[l.Target]; ok {
+			kept = append(kept, l)
+		}
+	}
+	links = kept
+
+	bw := bufio.NewWriter(w)
+	switch opts.Format {
+	case GraphDOT:
+		writeDOT(bw, nodes, links)
+	case GraphML:
+		writeGraphML(bw, nodes, links)
+	default:
+		if nodes == nil {
+			nodes = []GraphNode{}
+		}
+		if links == nil {
+			links = []GraphLink{}
+		}
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Nodes []GraphNode `json:"nodes"`
+			Edges []GraphLink `json:"edges"`
+		}{nodes, links}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func (o GraphExportOptions) keep(rec model.MemoryRecord) bool {
+	if len(o.Spaces) == 0 && len(o.Sessions) == 0 {
+		return true
+	}
+	return matchAny(o.Spaces, recordSpace(rec)) || matchAny(o.Sessions, rec.SessionID)
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		} else if p == value {
+			return true
+		}
+	}
+	return false
+}
+
+func graphLabel(content string) string {
+	label := strings.Join(strings.Fields(content), " ")
+	if r := []rune(label); len(r) > graphLabelLen {
+		label = string(r[:graphLabelLen-1]) + "…"
+	}
+	return label
+}
+
+func writeDOT(w *bufio.Writer, nodes []GraphNode, links []GraphLink) {
+	w.WriteString("digraph memory {\n\tnode [shape=box];\n")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "\tm%d [label=%s, tooltip=%s];\n", n.ID, strconv.Quote(n.Label), strconv.Quote(fmt.Sprintf("%s · %s · importance %.2f", n.Space, n.Source, n.Importance)))
+	}
+	for _, l := range links {
+		fmt.Fprintf(w, "\tm%d -> m%d [label=%s];\n", l.Source, l.Target, strconv.Quote(string(l.Type)))
+	}
+	w.WriteString("}\n")
+}
+
+func writeGraphML(w *bufio.Writer, nodes []GraphNode, links []GraphLink) {
+	w.WriteString(xml.Header)
+	w.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, k := range []struct{ id, target, name, typ string }{
+		{"label", "node", "label", "string"},
+		{"session", "node", "session_id", "string"},
+		{"space", "node", "space", "string"},
+		{"source", "node", "source", "string"},
+		{"importance", "node", "importance", "double"},
+		{"created", "node", "created_at", "string"},
+		{"type", "edge", "type", "string"},
+	} {
+		fmt.Fprintf(w, "  <key id=%q for=%q attr.name=%q attr.type=%q/>\n", k.id, k.target, k.name, k.typ)
+	}
+	w.WriteString(`  <graph id="memory" edgedefault="directed">` + "\n")
+	data := func(key, value string) {
+		fmt.Fprintf(w, "      <data key=%q>", key)
+		xml.EscapeText(w, []byte(value))
+		w.WriteString("</data>\n")
+	}
+	for _, n := range nodes {
+		fmt.Fprintf(w, "    <node id=\"m%d\">\n", n.ID)
+		data("label", n.Label)
+		data("session", n.SessionID)
+		data("space", n.Space)
+		data("source", n.Source)
+		data("importance", strconv.FormatFloat(n.Importance, 'f', -1, 64))
+		data("created", n.CreatedAt.UTC().Format(time.RFC3339))
+		w.WriteString("    </node>\n")
+	}
+	for i, l := range links {
+		fmt.Fprintf(w, "    <edge id=\"e%d\" source=\"m%d\" target=\"m%d\">\n", i, l.Source, l.Target)
+		data("type", string(l.Type))
+		w.WriteString("    </edge>\n")
+	}
+	w.WriteString("  </graph>\n</graphml>\n")
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestExportGraphFormatsAndFilters(t *testing.T) {
+	ctx := context.Background()
+	s := storepkg.NewInMemoryStore()
+	_ = s.StoreMemory(ctx, "team:eng", "restart the api service", nil, []float32{1, 0})
+	_ = s.StoreMemory(ctx, "team:eng", `the "api" runbook`, map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 1, Type: model.EdgeExplains}, {Target: 3, Type: model.EdgeFollows}},
+	}, []float32{0, 1})
+	_ = s.StoreMemory(ctx, "alice", "lunch at noon", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 2, Type: model.EdgeFollows}},
+	}, []float32{1, 1})
+	e := NewEngine(s, Options{})
+
+	var out bytes.Buffer
+	if err := e.ExportGraph(ctx, &out, GraphExportOptions{Spaces: []string{"team:*"}}); err != nil {
+		t.Fatalf("ExportGraph json: %v", err)
+	}
+	var graph struct {
+		Nodes []GraphNode `json:"nodes"`
+		Edges []GraphLink `json:"edges"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &graph); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	// The edge to record 3 leaves the filtered graph and is dropped.
+	if len(graph.Nodes) != 2 || len(graph.Edges) != 1 || graph.Edges[0] != (GraphLink{Source: 2, Target: 1, Type: model.EdgeExplains}) {
+		t.Fatalf("unexpected graph %+v", graph)
+	}
+
+	out.Reset()
+	if err := e.ExportGraph(ctx, &out, GraphExportOptions{Format: GraphDOT}); err != nil {
+		t.Fatalf("ExportGraph dot: %v", err)
+	}
+	for _, want := range []string{"digraph memory {", `m2 [label="the \"api\" runbook"`, `m2 -> m1 [label="explains"]`, `m3 -> m2 [label="follows"]`} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("DOT output lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := e.ExportGraph(ctx, &out, GraphExportOptions{Format: GraphML, Sessions: []string{"alice"}}); err != nil {
+		t.Fatalf("ExportGraph graphml: %v", err)
+	}
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct{} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("GraphML is not valid XML: %v", err)
+	}
+	if len(doc.Nodes) != 1 || doc.Nodes[0].ID != "m3" || len(doc.Edges) != 0 {
+		t.Fatalf("unexpected GraphML %+v", doc)
+	}
+
+	if err := e.ExportGraph(ctx, &out, GraphExportOptions{Format: "svg"}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
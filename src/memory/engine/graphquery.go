@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// GraphQuery walks the memory graph as q describes and returns the paths it
+// finds, for tools that reason over how memories connect. Paths through a
+// tombstoned memory, or one the caller on ctx may not see, are dropped.
+func (e *Engine) GraphQuery(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	if e == nil || e.store == nil {
+		return nil, nil
+	}
+	paths, err := store.QueryGraph(ctx, e.store, q)
+	if err != nil {
+		return nil, err
+	}
+	now := e.clock().UTC()
+	kept := paths[:0]
+	for _, path := range paths {
+		if len(withoutTombstones(model.FilterVisible(ctx, path.Nodes), now)) == len(path.Nodes) {
+			kept = append(kept, path)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestGraphQueryDropsPathsThroughTombstones(t *testing.T) {
+	ctx := context.Background()
+	s := storepkg.NewInMemoryStore()
+	_ = s.StoreMemory(ctx, "s", "deploy", nil, []float32{1})
+	_ = s.StoreMemory(ctx, "s", "old runbook", map[string]any{
+		"graph_edges":    []model.GraphEdge{{Target: 1, Type: model.EdgeExplains}},
+		MetaTombstonedAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	}, []float32{1})
+	_ = s.StoreMemory(ctx, "s", "new runbook", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 2, Type: model.EdgeSupersedes}, {Target: 1, Type: model.EdgeExplains}},
+	}, []float32{1})
+	e := NewEngine(s, Options{})
+
+	paths, err := e.GraphQuery(ctx, model.GraphQuery{Seeds: []int64{1}, MaxHops: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0].End().Content != "new runbook" {
+		t.Fatalf("paths = %+v, want only the live runbook", paths)
+	}
+}
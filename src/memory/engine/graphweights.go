@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// MetaEdgesDecayedAt holds the RFC 3339 time Prune last decayed a record's
+// graph edges. Decay resumes from it, or from an edge's LastReinforced
+// when that is later, so repeated passes never decay the same hours twice.
+const MetaEdgesDecayedAt = "graph_edges_decayed_at"
+
+const (
+	defaultGraphEdgeMinWeight = 0.05
+	// edgeDecayStep is the smallest relative weight loss Prune writes
+	// back, so passes in quick succession leave records alone.
+	edgeDecayStep = 0.01
+)
+
+// reinforceEdges strengthens the edges whose two memories were both
+// selected. The updated edges are written back through
+// store.MetadataUpdater; other stores keep their weights.
+func (e *Engine) reinforceEdges(ctx context.Context, selected []model.MemoryRecord, now time.Time) {
+	rate := e.opts.GraphReinforcement
+	if rate <= 0 || len(selected) < 2 {
+		return
+	}
+	if _, ok := e.store.(store.MetadataUpdater); !ok {
+		return
+	}
+	ids := make(map[int64]bool, len(selected))
+	for _, rec := range selected {
+		if rec.ID != 0 {
+			ids[rec.ID] = true
+		}
+	}
+	for _, rec := range selected {
+		meta := model.DecodeMetadata(rec.Metadata)
+		edges := append([]model.GraphEdge(nil), model.ValidGraphEdges(meta)...)
+		anchor := edgeDecayAnchor(rec, meta)
+		changed := false
+		for i, edge := range edges {
+			if !ids[edge.Target] || edge.Target == rec.ID {
+				continue
+			}
+			edges[i] = e.decayEdge(edge, anchor, now).Reinforce(now, min(rate, 1))
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if err := e.rewriteEdges(ctx, rec, meta, edges); err != nil {
+			e.logf("reinforce graph edges of %d: %v", rec.ID, err)
+		}
+	}
+}
+
+// decayEdges weakens the edges of records, dropping those that fall below
+// GraphEdgeMinWeight. Records whose edges would barely change are skipped.
+func (e *Engine) decayEdges(ctx context.Context, records []model.MemoryRecord, now time.Time) error {
+	if _, ok := e.store.(store.MetadataUpdater); !ok {
+		return nil
+	}
+	floor := e.opts.GraphEdgeMinWeight
+	if floor <= 0 {
+		floor = defaultGraphEdgeMinWeight
+	}
+	for _, rec := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		meta := model.DecodeMetadata(rec.Metadata)
+		edges := model.ValidGraphEdges(meta)
+		anchor := edgeDecayAnchor(rec, meta)
+		kept := make([]model.GraphEdge, 0, len(edges))
+		changed := false
+		for _, edge := range edges {
+			decayed := e.decayEdge(edge, anchor, now)
+			if decayed.Strength() < floor {
+				changed = true
+				continue
+			}
+			if 1-decayed.Strength()/edge.Strength() >= edgeDecayStep {
+				changed = true
+			}
+			kept = append(kept, decayed)
+		}
+		if !changed {
+			continue
+		}
+		meta[MetaEdgesDecayedAt] = now.Format(time.RFC3339Nano)
+		if err := e.rewriteEdges(model.ContextWithTenant(ctx, model.RecordTenant(rec)), rec, meta, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decayEdge applies the decay an edge has accrued since anchor or since it
+// was last reinforced, whichever is later.
+func (e *Engine) decayEdge(edge model.GraphEdge, anchor, now time.Time) model.GraphEdge {
+	if edge.LastReinforced.After(anchor) {
+		anchor = edge.LastReinforced
+	}
+	return edge.Decay(now.Sub(anchor), e.opts.GraphEdgeHalfLife)
+}
+
+// edgeDecayAnchor is the time a record's edges were last decayed, or its
+// creation time before the first pass.
+func edgeDecayAnchor(rec model.MemoryRecord, meta map[string]any) time.Time {
+	if at := model.TimeFromAny(meta[MetaEdgesDecayedAt]); !at.IsZero() {
+		return at
+	}
+	return rec.CreatedAt
+}
+
+// rewriteEdges stores edges as the graph edges of rec.
+func (e *Engine) rewriteEdges(ctx context.Context, rec model.MemoryRecord, meta map[string]any, edges []model.GraphEdge) error {
+	u, ok := e.store.(store.MetadataUpdater)
+	if !ok {
+		return store.ErrMetadataUpdatesUnsupported
+	}
+	if len(edges) > 0 {
+		meta["graph_edges"] = edges
+	} else {
+		delete(meta, "graph_edges")
+	}
+	rec.Metadata = model.StringFromAny(meta)
+	rec.GraphEdges = edges
+	if err := u.UpdateMetadata(ctx, rec); err != nil {
+		return err
+	}
+	if graphStore, ok := e.store.(store.GraphStore); ok {
+		if err := graphStore.UpsertGraph(ctx, rec, edges); err != nil {
+			e.logf("upsert graph: %v", err)
+		}
+	}
+	return nil
+}
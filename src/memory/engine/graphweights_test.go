@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestEdgesStrengthenWhenCoRetrievedAndDecayInPrune(t *testing.T) {
+	ctx := context.Background()
+	s := storepkg.NewInMemoryStore()
+	_ = s.StoreMemory(ctx, "s", "restart the api service", nil, []float32{1, 0})
+	_ = s.StoreMemory(ctx, "s", "team blue owns the api service", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 1, Type: model.EdgeExplains, Weight: 0.5}},
+	}, []float32{0.9, 0.44})
+
+	now := time.Now().UTC()
+	e := NewEngine(s, Options{
+		Clock:              func() time.Time { return now },
+		GraphReinforcement: 0.5,
+		GraphEdgeHalfLife:  time.Hour,
+	}).WithEmbedder(angleEmbedder{"api service": 1})
+	edge := func() (model.GraphEdge, bool) {
+		recs, _ := s.GetMemories(ctx, []int64{2})
+		if len(recs) != 1 || len(recs[0].GraphEdges) != 1 {
+			return model.GraphEdge{}, false
+		}
+		return recs[0].GraphEdges[0], true
+	}
+
+	if got, err := e.Retrieve(ctx, "s", "api service", 5); err != nil || len(got) != 2 {
+		t.Fatalf("Retrieve = %d records, %v", len(got), err)
+	}
+	reinforced, ok := edge()
+	if !ok || math.Abs(reinforced.Weight-0.75) > 1e-6 || !reinforced.LastReinforced.Equal(now) {
+		t.Fatalf("edge after co-retrieval = %+v, want weight 0.75 reinforced now", reinforced)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := e.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	decayed, ok := edge()
+	if !ok || math.Abs(decayed.Weight-0.1875) > 1e-6 {
+		t.Fatalf("edge after two half-lives = %+v, want weight 0.1875", decayed)
+	}
+	// A second pass at the same time must not decay the edge again.
+	if err := e.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := edge(); math.Abs(again.Weight-decayed.Weight) > 1e-6 {
+		t.Fatalf("repeated Prune decayed the edge to %v", again.Weight)
+	}
+
+	now = now.Add(3 * time.Hour)
+	if err := e.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if faded, ok := edge(); ok {
+		t.Fatalf("edge below the minimum weight survived: %+v", faded)
+	}
+}
+
+func TestAsOfGraphNeighborhoodPrefersHeavierEdges(t *testing.T) {
+	g := newAsOfGraph([]model.MemoryRecord{
+		{ID: 1},
+		{ID: 2, GraphEdges: []model.GraphEdge{{Target: 1, Type: model.EdgeRelatesTo, Weight: 0.2}}},
+		{ID: 3, GraphEdges: []model.GraphEdge{{Target: 1, Type: model.EdgeRelatesTo, Weight: 0.9}}},
+	})
+	got := g.neighborhood([]model.MemoryRecord{{ID: 1}}, 1, 1)
+	if len(got) != 1 || got[0].ID != 3 {
+		t.Fatalf("neighborhood = %+v, want the heavier neighbour 3", got)
+	}
+}
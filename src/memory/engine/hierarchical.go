@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// MetaChunkIDs lists, on a document-level record, the IDs of the chunks
+// the document was split into.
+const MetaChunkIDs = "chunk_ids"
+
+const defaultHierarchicalParents = 3
+
+// RetrieveHierarchical is a coarse-to-fine Retrieve. It first searches for
+// parent records, which are the summary records of MaterializeSummaries and
+// the document-level records of uploads that list MetaChunkIDs. It then
+// expands the Options.HierarchicalParents parents closest to the query into
+// their members and ranks only those. On a large corpus this ranks the
+// chunks of a few relevant documents instead of the nearest chunks of
+// every document. With no parent among the hits it is Retrieve.
+func (e *Engine) RetrieveHierarchical(ctx context.Context, sessionID, query string, limit int) ([]model.MemoryRecord, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	embedding, err := e.embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	// Parents are a small share of the records, so search wider than
+	// Retrieve does to find enough of them.
+	candidates, err := e.store.SearchMemory(ctx, sessionID, embedding, limit*8)
+	if err != nil {
+		return nil, err
+	}
+	now := e.clock().UTC()
+	similarity := model.NewCosineQuery(embedding)
+	var parents []model.MemoryRecord
+	for _, cand := range withoutTombstones(model.FilterVisible(ctx, model.FilterTenant(ctx, candidates)), now) {
+		if len(parentMemberIDs(cand)) > 0 {
+			cand.Score = similarity.MaxSimilarity(cand)
+			parents = append(parents, cand)
+		}
+	}
+	if len(parents) == 0 {
+		return e.Retrieve(ctx, sessionID, query, limit)
+	}
+	sort.SliceStable(parents, func(i, j int) bool { return parents[i].Score > parents[j].Score })
+	n := e.optionsFor(sessionID).HierarchicalParents
+	if n <= 0 {
+		n = defaultHierarchicalParents
+	}
+	if len(parents) > n {
+		parents = parents[:n]
+	}
+
+	var ids []int64
+	seen := map[int64]struct{}{}
+	for _, parent := range parents {
+		for _, id := range parentMemberIDs(parent) {
+			if _, dup := seen[id]; !dup {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	members, err := store.GetMemories(ctx, e.store, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return e.Retrieve(ctx, sessionID, query, limit)
+	}
+	return e.rank(ctx, sessionID, embedding, extractKeywords(query), members, nil, limit, now, true)
+}
+
+// parentMemberIDs returns the members of a summary or document-level
+// record, or nil for any other record.
+func parentMemberIDs(rec model.MemoryRecord) []int64 {
+	meta := model.DecodeMetadata(rec.Metadata)
+	if _, ok := meta[MetaSummaryOf]; ok {
+		return summaryMemberIDs(rec)
+	}
+	return int64sFromAny(meta[MetaChunkIDs])
+}
+
+func int64sFromAny(v any) []int64 {
+	switch ids := v.(type) {
+	case []int64:
+		return ids
+	case []any:
+		out := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			switch n := id.(type) {
+			case json.Number:
+				if i, err := n.Int64(); err == nil {
+					out = append(out, i)
+				}
+			default:
+				if f := model.FloatFromAny(id); f > 0 {
+					out = append(out, int64(f))
+				}
+			}
+		}
+		return out
+	}
+	return nil
+}
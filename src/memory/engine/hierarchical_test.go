@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestRetrieveHierarchicalExpandsTheClosestParents(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	put := func(content string, meta map[string]any, vec []float32) int64 {
+		t.Helper()
+		if err := store.StoreMemory(ctx, "s", content, meta, vec); err != nil {
+			t.Fatal(err)
+		}
+		var id int64
+		_ = store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+			if rec.Content == content {
+				id = rec.ID
+			}
+			return true
+		})
+		return id
+	}
+	// The chunks of the unrelated document sit closest to the query, but
+	// its document record does not.
+	a1 := put("rollback steps", nil, []float32{0.8, 0.6})
+	a2 := put("rollback owners", nil, []float32{0.7, 0.71})
+	put("Document: rollback.md", map[string]any{"record": "document", MetaChunkIDs: []int64{a1, a2}}, []float32{1, 0})
+	b1 := put("lunch menu", nil, []float32{0.99, 0.1})
+	put("Document: lunch.md", map[string]any{"record": "document", MetaChunkIDs: []int64{b1}}, []float32{0, 1})
+
+	e := NewEngine(store, Options{HierarchicalParents: 1}).WithEmbedder(&batchEmbedder{vectors: map[string][]float32{"query": {1, 0}}})
+	got, err := e.RetrieveHierarchical(ctx, "s", "query", 5)
+	if err != nil {
+		t.Fatalf("RetrieveHierarchical: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d records, want the two chunks of rollback.md: %+v", len(got), got)
+	}
+	for _, rec := range got {
+		if rec.ID != a1 && rec.ID != a2 {
+			t.Fatalf("unexpected record %q", rec.Content)
+		}
+	}
+
+	flat := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(&batchEmbedder{vectors: map[string][]float32{"query": {1, 0}}})
+	if err := flat.store.StoreMemory(ctx, "s", "no parents here", nil, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := flat.RetrieveHierarchical(ctx, "s", "query", 5); err != nil || len(got) != 1 {
+		t.Fatalf("without parents = %+v, %v; want plain retrieval", got, err)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+const (
+	// maxPendingScores bounds the memories waiting to be scored; writes
+	// beyond it keep their heuristic importance.
+	maxPendingScores = 256
+	// scoreConcurrency bounds the ImportanceScorer calls in flight.
+	scoreConcurrency = 4
+	// importanceScoreTimeout bounds one ImportanceScorer call.
+	importanceScoreTimeout = 30 * time.Second
+)
+
+// ImportanceScorer rates how important a memory is to keep, from 0 (small
+// talk) to 1 (credentials, decisions, deadlines).
+type ImportanceScorer interface {
+	ScoreImportance(ctx context.Context, content string, metadata map[string]any) (float64, error)
+}
+
+// ImportanceScorerFunc adapts a function to ImportanceScorer.
+type ImportanceScorerFunc func(ctx context.Context, content string, metadata map[string]any) (float64, error)
+
+func (f ImportanceScorerFunc) ScoreImportance(ctx context.Context, content string, metadata map[string]any) (float64, error) {
+	return f(ctx, content, metadata)
+}
+
+// WithImportanceScorer replaces the length-and-keyword importance
+// heuristic with s. Scoring happens in the background so Store does not
+// wait for it: a memory is written with its heuristic importance, then
+// rescored through store.ImportanceUpdater once s answers. Memories stored
+// with an explicit "importance" are left alone, and a scorer error keeps
+// the heuristic value. Call FlushImportance to wait for pending scores.
+// Pair it with Options.RetainImportance to exempt critical memories from
+// TTL expiry however short they are.
+func (e *Engine) WithImportanceScorer(s ImportanceScorer) *Engine {
+	e.scorer = s
+	e.scoreSlots = make(chan struct{}, scoreConcurrency)
+	return e
+}
+
+// FlushImportance waits until every queued importance score has been
+// written, or ctx is done.
+func (e *Engine) FlushImportance(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.scoring.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scoreLater queues rec for the importance scorer.
+func (e *Engine) scoreLater(ctx context.Context, rec model.MemoryRecord, metadata map[string]any) {
+	if _, ok := e.store.(store.ImportanceUpdater); !ok || rec.ID == 0 {
+		return
+	}
+	if e.scorePending.Add(1) > maxPendingScores {
+		e.scorePending.Add(-1)
+		e.logf("importance scorer: queue full, memory %d keeps its heuristic score", rec.ID)
+		return
+	}
+	meta := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		meta[k] = v
+	}
+	ctx = context.WithoutCancel(ctx)
+	e.scoring.Add(1)
+	go func() {
+		defer e.scoring.Done()
+		defer e.scorePending.Add(-1)
+		e.scoreSlots <- struct{}{}
+		defer func() { <-e.scoreSlots }()
+		ctx, cancel := context.WithTimeout(ctx, importanceScoreTimeout)
+		defer cancel()
+		score, err := e.scorer.ScoreImportance(ctx, rec.Content, meta)
+		if err != nil {
+			e.logf("importance scorer: memory %d: %v", rec.ID, err)
+			return
+		}
+		score = clamp(score, 0, 1)
+		if score == rec.Importance {
+			return
+		}
+		if err := e.UpdateImportance(ctx, rec.ID, score); err != nil {
+			e.logf("importance scorer: update memory %d: %v", rec.ID, err)
+		}
+	}()
+}
+
+// retained reports whether rec is important enough to outlive the TTL.
+func (o Options) retained(rec model.MemoryRecord) bool {
+	if o.RetainImportance <= 0 {
+		return false
+	}
+	importance := rec.Importance
+	if importance == 0 {
+		importance = model.FloatFromAny(model.DecodeMetadata(rec.Metadata)["importance"])
+	}
+	return importance >= o.RetainImportance
+}
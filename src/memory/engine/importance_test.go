@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestImportanceScorerRescoresAndRetains(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	st := storepkg.NewInMemoryStore()
+	e := NewEngine(st, Options{
+		TTL:              time.Hour,
+		RetainImportance: 0.9,
+		Clock:            func() time.Time { return now },
+	}).WithEmbedder(angleEmbedder{"db password is hunter2": 1, "nice weather": 0.1, "pinned": 0.5})
+	var scored []string
+	e.WithImportanceScorer(ImportanceScorerFunc(func(_ context.Context, content string, _ map[string]any) (float64, error) {
+		scored = append(scored, content)
+		if strings.Contains(content, "password") {
+			return 1.4, nil
+		}
+		return 0.05, nil
+	}))
+
+	secret, err := e.Store(ctx, "s", "db password is hunter2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.FlushImportance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	chatter, err := e.Store(ctx, "s", "nice weather", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.FlushImportance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Store(ctx, "s", "pinned", map[string]any{"importance": 0.3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.FlushImportance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(scored) != 2 {
+		t.Fatalf("memories with explicit importance must not be rescored: %v", scored)
+	}
+
+	importance := map[int64]float64{}
+	if err := st.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		importance[rec.ID] = rec.Importance
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if importance[secret.ID] != 1 || importance[chatter.ID] != 0.05 {
+		t.Fatalf("importance not written behind: %v", importance)
+	}
+
+	// Past the TTL only the critical memory survives.
+	now = now.Add(2 * time.Hour)
+	if err := e.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := st.Count(ctx); n != 1 {
+		t.Fatalf("expected only the retained memory, %d left", n)
+	}
+}
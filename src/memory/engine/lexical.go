@@ -0,0 +1,312 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// Fusion selects how hybrid retrieval combines lexical and vector scores.
+type Fusion string
+
+const (
+	// FusionWeighted blends normalised BM25 into cosine similarity by
+	// Options.HybridWeight.
+	FusionWeighted Fusion = "weighted"
+	// FusionRRF ranks candidates by reciprocal rank fusion of the vector
+	// and lexical rankings, weighting the lexical side by HybridWeight.
+	FusionRRF Fusion = "rrf"
+)
+
+const (
+	bm25K1      = 1.2
+	bm25B       = 0.75
+	defaultRRFK = 60
+)
+
+// lexicalIndex is an in-process BM25 index over record content. It is
+// built from the store on first hybrid retrieval and kept current by the
+// engine's own writes and prunes; records deleted behind the engine's back
+// linger until RebuildLexicalIndex.
+type lexicalIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*lexicalDoc
+	postings map[string]map[string]struct{}
+	totalLen int
+	loaded   bool
+}
+
+type lexicalDoc struct {
+	rec    model.MemoryRecord
+	tf     map[string]int
+	length int
+}
+
+type lexicalHit struct {
+	rec   model.MemoryRecord
+	score float64
+}
+
+func newLexicalIndex() *lexicalIndex {
+	return &lexicalIndex{docs: map[string]*lexicalDoc{}, postings: map[string]map[string]struct{}{}}
+}
+
+func (e *Engine) lexicalIndex() *lexicalIndex {
+	e.lexicalOnce.Do(func() { e.lexical = newLexicalIndex() })
+	return e.lexical
+}
+
+func (e *Engine) hybridEnabled() bool { return e.opts.HybridWeight > 0 }
+
+// lexicalKey identifies a record in the index: its ID when the store
+// assigned one, else its session and content.
+func lexicalKey(rec model.MemoryRecord) string {
+	if rec.ID != 0 {
+		return "#" + strconv.FormatInt(rec.ID, 10)
+	}
+	return rec.SessionID + "␟" + strings.TrimSpace(rec.Content)
+}
+
+// lexicalTerms tokenises text for BM25. Underscores stay inside tokens so
+// code identifiers match whole; stop words are dropped.
+func lexicalTerms(text string) []string {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+	terms := tokens[:0]
+	for _, tok := range tokens {
+		if _, stop := commonStopWords[tok]; stop {
+			continue
+		}
+		terms = append(terms, tok)
+	}
+	return terms
+}
+
+func (idx *lexicalIndex) add(rec model.MemoryRecord) {
+	key := lexicalKey(rec)
+	idx.remove(key)
+	terms := lexicalTerms(rec.Content)
+	if len(terms) == 0 {
+		return
+	}
+	doc := &lexicalDoc{rec: rec, tf: make(map[string]int, len(terms)), length: len(terms)}
+	for _, term := range terms {
+		doc.tf[term]++
+	}
+	for term := range doc.tf {
+		if idx.postings[term] == nil {
+			idx.postings[term] = map[string]struct{}{}
+		}
+		idx.postings[term][key] = struct{}{}
+	}
+	idx.docs[key] = doc
+	idx.totalLen += doc.length
+}
+
+func (idx *lexicalIndex) remove(key string) {
+	doc, ok := idx.docs[key]
+	if !ok {
+		return
+	}
+	for term := range doc.tf {
+		delete(idx.postings[term], key)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLen -= doc.length
+	delete(idx.docs, key)
+}
+
+// search scores the documents of sessionID (all sessions when empty)
+// containing any query term, best first.
+func (idx *lexicalIndex) search(sessionID string, terms []string, limit int) []lexicalHit {
+	if len(idx.docs) == 0 || len(terms) == 0 || limit <= 0 {
+		return nil
+	}
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+	scores := map[string]float64{}
+	seen := map[string]struct{}{}
+	for _, term := range terms {
+		if _, dup := seen[term]; dup {
+			continue
+		}
+		seen[term] = struct{}{}
+		posting := idx.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key := range posting {
+			doc := idx.docs[key]
+			if sessionID != "" && doc.rec.SessionID != sessionID {
+				continue
+			}
+			tf := float64(doc.tf[term])
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.length)/avgLen)
+			scores[key] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+	hits := make([]lexicalHit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, lexicalHit{rec: idx.docs[key].rec, score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return lexicalKey(hits[i].rec) < lexicalKey(hits[j].rec)
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// observeLexical indexes a stored record once the index has been built;
+// before that the first hybrid retrieval loads it from the store.
+func (e *Engine) observeLexical(rec model.MemoryRecord) {
+	if !e.hybridEnabled() {
+		return
+	}
+	idx := e.lexicalIndex()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.loaded {
+		idx.add(rec)
+	}
+}
+
+// forgetLexical drops pruned records from the index.
+func (e *Engine) forgetLexical(ids []int64) {
+	if !e.hybridEnabled() {
+		return
+	}
+	idx := e.lexicalIndex()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, id := range ids {
+		idx.remove(lexicalKey(model.MemoryRecord{ID: id}))
+	}
+}
+
+// RebuildLexicalIndex reindexes every stored record for hybrid retrieval.
+// Retrieve calls it once on first use; call it again after records are
+// deleted or rewritten outside the engine.
+func (e *Engine) RebuildLexicalIndex(ctx context.Context) error {
+	if e.store == nil {
+		return errors.New("memory engine has no store")
+	}
+	rebuilt := newLexicalIndex()
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		rebuilt.add(rec)
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	idx := e.lexicalIndex()
+	idx.mu.Lock()
+	idx.docs, idx.postings, idx.totalLen, idx.loaded = rebuilt.docs, rebuilt.postings, rebuilt.totalLen, true
+	idx.mu.Unlock()
+	return nil
+}
+
+// lexicalCandidates returns the BM25 matches for query, loading the index
+// on first use.
+func (e *Engine) lexicalCandidates(ctx context.Context, sessionID, query string, limit int) ([]lexicalHit, error) {
+	idx := e.lexicalIndex()
+	idx.mu.RLock()
+	loaded := idx.loaded
+	idx.mu.RUnlock()
+	if !loaded {
+		if err := e.RebuildLexicalIndex(ctx); err != nil {
+			return nil, err
+		}
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.search(sessionID, lexicalTerms(query), limit), nil
+}
+
+// mergeLexical adds the lexical hits missing from the vector candidates and
+// returns the BM25 score of every hit by lexicalKey.
+func mergeLexical(candidates []model.MemoryRecord, hits []lexicalHit) ([]model.MemoryRecord, map[string]float64) {
+	scores := make(map[string]float64, len(hits))
+	present := make(map[string]struct{}, len(candidates))
+	for _, cand := range candidates {
+		present[lexicalKey(cand)] = struct{}{}
+	}
+	for _, hit := range hits {
+		key := lexicalKey(hit.rec)
+		scores[key] = hit.score
+		if _, ok := present[key]; !ok {
+			present[key] = struct{}{}
+			candidates = append(candidates, hit.rec)
+		}
+	}
+	return candidates, scores
+}
+
+// fuseScores replaces each candidate's Score with its hybrid score. Vector
+// scores are the cosine similarities already on the candidates; lexical
+// holds the BM25 score by lexicalKey.
+func (e *Engine) fuseScores(candidates []model.MemoryRecord, lexical map[string]float64) {
+	w := math.Min(e.opts.HybridWeight, 1)
+	if e.opts.HybridFusion != FusionRRF {
+		var maxBM25 float64
+		for _, s := range lexical {
+			maxBM25 = math.Max(maxBM25, s)
+		}
+		for i := range candidates {
+			var bm25 float64
+			if maxBM25 > 0 {
+				bm25 = lexical[lexicalKey(candidates[i])] / maxBM25
+			}
+			candidates[i].Score = (1-w)*candidates[i].Score + w*bm25
+		}
+		return
+	}
+
+	k := float64(e.opts.RRFK)
+	if k <= 0 {
+		k = defaultRRFK
+	}
+	rank := func(score func(int) float64) []int {
+		order := make([]int, len(candidates))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return score(order[a]) > score(order[b]) })
+		ranks := make([]int, len(candidates))
+		for r, i := range order {
+			ranks[i] = r + 1
+		}
+		return ranks
+	}
+	vecRank := rank(func(i int) float64 { return candidates[i].Score })
+	lexRank := rank(func(i int) float64 { return lexical[lexicalKey(candidates[i])] })
+	// Dividing by the best achievable fused score keeps RRF in [0, 1] like
+	// the similarity it replaces.
+	best := 1 / (k + 1)
+	for i := range candidates {
+		fused := (1 - w) / (k + float64(vecRank[i]))
+		if lexical[lexicalKey(candidates[i])] > 0 {
+			fused += w / (k + float64(lexRank[i]))
+		}
+		candidates[i].Score = fused / best
+	}
+}
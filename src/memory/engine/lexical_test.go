@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestHybridRetrievalFindsExactTerms(t *testing.T) {
+	ctx := context.Background()
+	memStore := storepkg.NewInMemoryStore()
+	seed := NewEngine(memStore, Options{DuplicateSimilarity: 1.01}).WithEmbedder(topicEmbedder{})
+	for i := range 12 {
+		if _, err := seed.Store(ctx, "s", fmt.Sprintf("printer note %d about the paper tray", i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := seed.Store(ctx, "s", "printer fails with error ZX4411 calling parse_config", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fusion := range []Fusion{FusionWeighted, FusionRRF} {
+		e := NewEngine(memStore, Options{DuplicateSimilarity: 1.01, HybridWeight: 0.5, HybridFusion: fusion}).WithEmbedder(topicEmbedder{})
+		for _, query := range []string{"ZX4411", "who calls parse_config"} {
+			got, err := e.Retrieve(ctx, "s", query, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || !strings.Contains(got[0].Content, "ZX4411") {
+				t.Fatalf("%s: Retrieve(%q) = %+v, want the ZX4411 record", fusion, query, got)
+			}
+		}
+		// Records stored through the engine join the loaded index.
+		if _, err := e.Store(ctx, "s", "printer reports QQ77 after reboot", nil); err != nil {
+			t.Fatal(err)
+		}
+		got, err := e.Retrieve(ctx, "s", "QQ77", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || !strings.Contains(got[0].Content, "QQ77") {
+			t.Fatalf("%s: Retrieve(QQ77) = %+v", fusion, got)
+		}
+	}
+}
+
+func TestLexicalIndexScopesSessionsAndForgets(t *testing.T) {
+	idx := newLexicalIndex()
+	for _, rec := range []struct {
+		id      int64
+		session string
+		content string
+	}{
+		{1, "a", "deploy runbook for billing"},
+		{2, "b", "billing billing billing outage"},
+		{3, "a", "unrelated notes"},
+	} {
+		idx.add(model.MemoryRecord{ID: rec.id, SessionID: rec.session, Content: rec.content})
+	}
+	if hits := idx.search("", lexicalTerms("billing"), 5); len(hits) != 2 || hits[0].rec.ID != 2 {
+		t.Fatalf("search all = %+v", hits)
+	}
+	if hits := idx.search("a", lexicalTerms("billing"), 5); len(hits) != 1 || hits[0].rec.ID != 1 {
+		t.Fatalf("search a = %+v", hits)
+	}
+	idx.remove(lexicalKey(model.MemoryRecord{ID: 2}))
+	if hits := idx.search("", lexicalTerms("outage"), 5); len(hits) != 0 {
+		t.Fatalf("removed record still matches: %+v", hits)
+	}
+}
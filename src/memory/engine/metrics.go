@@ -1,29 +1,77 @@
 package engine
 
-import "sync/atomic"
+import (
+	"sync/atomic"
+	"time"
+)
 
 // Metrics captures lightweight runtime counters for observability.
 type Metrics struct {
 	stored             atomic.Int64
 	retrieved          atomic.Int64
 	deduplicated       atomic.Int64
+	duplicateJudged    atomic.Int64
 	reembedded         atomic.Int64
 	pruned             atomic.Int64
 	clustersSummarized atomic.Int64
 	ttlExpired         atomic.Int64
 	sizeEvicted        atomic.Int64
+	compacted          atomic.Int64
+	conflicts          atomic.Int64
 	recencySamples     atomic.Int64
 	recencySumMicros   atomic.Int64
+	retrieveLatency    latencyHistogram
+	filteredLatency    latencyHistogram
 }
 
-func (m *Metrics) IncStored()             { m.stored.Add(1) }
-func (m *Metrics) IncRetrieved(n int)     { m.retrieved.Add(int64(n)) }
-func (m *Metrics) IncDeduplicated()       { m.deduplicated.Add(1) }
-func (m *Metrics) IncReembedded()         { m.reembedded.Add(1) }
-func (m *Metrics) IncPruned(n int)        { m.pruned.Add(int64(n)) }
-func (m *Metrics) IncClustersSummarized() { m.clustersSummarized.Add(1) }
-func (m *Metrics) IncTTLExpired(n int)    { m.ttlExpired.Add(int64(n)) }
-func (m *Metrics) IncSizeEvicted(n int)   { m.sizeEvicted.Add(int64(n)) }
+// latencyBuckets are the upper bounds, in seconds, of the retrieval latency
+// histograms.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// latencyHistogram counts observations per bucket of latencyBuckets; the
+// last slot is the +Inf overflow.
+type latencyHistogram struct {
+	buckets   [12]atomic.Int64
+	count     atomic.Int64
+	sumMicros atomic.Int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	secs := d.Seconds()
+	i := 0
+	for i < len(latencyBuckets) && secs > latencyBuckets[i] {
+		i++
+	}
+	h.buckets[i].Add(1)
+	h.count.Add(1)
+	h.sumMicros.Add(d.Microseconds())
+}
+
+// cumulative returns the observation count at or below each bucket bound,
+// the total count and the sum in seconds, as Prometheus histograms expect.
+func (h *latencyHistogram) cumulative() (map[float64]uint64, uint64, float64) {
+	out := make(map[float64]uint64, len(latencyBuckets))
+	var running int64
+	for i, bound := range latencyBuckets {
+		running += h.buckets[i].Load()
+		out[bound] = uint64(running)
+	}
+	return out, uint64(h.count.Load()), float64(h.sumMicros.Load()) / 1e6
+}
+
+func (m *Metrics) IncStored()                      { m.stored.Add(1) }
+func (m *Metrics) IncRetrieved(n int)              { m.retrieved.Add(int64(n)) }
+func (m *Metrics) IncDeduplicated()                { m.deduplicated.Add(1) }
+func (m *Metrics) IncDuplicateJudged()             { m.duplicateJudged.Add(1) }
+func (m *Metrics) IncReembedded()                  { m.reembedded.Add(1) }
+func (m *Metrics) IncPruned(n int)                 { m.pruned.Add(int64(n)) }
+func (m *Metrics) IncClustersSummarized()          { m.clustersSummarized.Add(1) }
+func (m *Metrics) IncTTLExpired(n int)             { m.ttlExpired.Add(int64(n)) }
+func (m *Metrics) IncSizeEvicted(n int)            { m.sizeEvicted.Add(int64(n)) }
+func (m *Metrics) IncCompacted(n int)              { m.compacted.Add(int64(n)) }
+func (m *Metrics) IncConflicts()                   { m.conflicts.Add(1) }
+func (m *Metrics) ObserveRetrieve(d time.Duration) { m.retrieveLatency.observe(d) }
+func (m *Metrics) ObserveFiltered(d time.Duration) { m.filteredLatency.observe(d) }
 func (m *Metrics) ObserveRecency(decay float64) {
 	if decay < 0 {
 		decay = 0
@@ -40,11 +88,14 @@ type MetricsSnapshot struct {
 	Stored             int64   `json:"stored"`
 	Retrieved          int64   `json:"retrieved"`
 	Deduplicated       int64   `json:"deduplicated"`
+	DuplicateJudged    int64   `json:"duplicate_judged"`
 	Reembedded         int64   `json:"reembedded"`
 	Pruned             int64   `json:"pruned"`
 	ClustersSummarized int64   `json:"clusters_summarized"`
 	TTLExpired         int64   `json:"ttl_expired"`
 	SizeEvicted        int64   `json:"size_evicted"`
+	Compacted          int64   `json:"compacted"`
+	Conflicts          int64   `json:"conflicts"`
 	RecencySamples     int64   `json:"recency_samples"`
 	RecencyDecayAvg    float64 `json:"recency_decay_avg"`
 }
@@ -63,11 +114,14 @@ func (m *Metrics) Snapshot() MetricsSnapshot {
 		Stored:             m.stored.Load(),
 		Retrieved:          m.retrieved.Load(),
 		Deduplicated:       m.deduplicated.Load(),
+		DuplicateJudged:    m.duplicateJudged.Load(),
 		Reembedded:         m.reembedded.Load(),
 		Pruned:             m.pruned.Load(),
 		ClustersSummarized: m.clustersSummarized.Load(),
 		TTLExpired:         m.ttlExpired.Load(),
 		SizeEvicted:        m.sizeEvicted.Load(),
+		Compacted:          m.compacted.Load(),
+		Conflicts:          m.conflicts.Load(),
 		RecencySamples:     samples,
 		RecencyDecayAvg:    avg,
 	}
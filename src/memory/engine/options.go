@@ -1,6 +1,10 @@
 package engine
 
-import "time"
+import (
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
 
 // ScoreWeights controls the contribution of each scoring component during retrieval.
 type ScoreWeights struct {
@@ -13,19 +17,121 @@ type ScoreWeights struct {
 
 // Options configures the advanced memory engine.
 type Options struct {
-	Weights                ScoreWeights
-	LambdaMMR              float64
-	HalfLife               time.Duration
-	ClusterSimilarity      float64
-	DriftThreshold         float64
-	DuplicateSimilarity    float64
-	TTL                    time.Duration
-	MaxSize                int
-	SourceBoost            map[string]float64
-	Clock                  func() time.Time
-	EnableSummaries        bool
-	GraphNeighborhoodHops  int
-	GraphNeighborhoodLimit int
+	Weights             ScoreWeights
+	LambdaMMR           float64
+	HalfLife            time.Duration
+	ClusterSimilarity   float64
+	DriftThreshold      float64
+	DuplicateSimilarity float64
+	// DuplicateBorderline, when set below DuplicateSimilarity, widens the
+	// duplicate check to pairs from this cosine similarity up: they count
+	// as duplicates when their wording overlaps by DuplicateTextSimilarity
+	// or a DuplicateJudge says so.
+	DuplicateBorderline float64
+	// DuplicateTextSimilarity is the term overlap (Jaccard) at which a
+	// close pair is a duplicate without asking the judge; 0.8 when zero.
+	DuplicateTextSimilarity float64
+	TTL                     time.Duration
+	MaxSize                 int
+	SourceBoost             map[string]float64
+	Clock                   func() time.Time
+	EnableSummaries         bool
+	GraphNeighborhoodHops   int
+	GraphNeighborhoodLimit  int
+	// GraphReinforcement, in (0, 1], strengthens graph edges between
+	// memories Retrieve returns together: the edge's weight moves that
+	// share of the way to 1. Zero leaves weights alone.
+	GraphReinforcement float64
+	// GraphEdgeHalfLife makes Prune decay edge weights, halving them
+	// every half-life an edge goes without reinforcement. Zero disables
+	// decay.
+	GraphEdgeHalfLife time.Duration
+	// GraphEdgeMinWeight is the weight below which Prune drops a decayed
+	// edge; 0.05 when zero.
+	GraphEdgeMinWeight float64
+	// SessionEmbeddingDecay is the weight of each new memory in its
+	// session's rolling embedding, in (0, 1]. Higher tracks topic drift
+	// faster.
+	SessionEmbeddingDecay float64
+	// HybridWeight, in (0, 1], turns on hybrid retrieval: a BM25 index over
+	// record content adds lexical matches to the vector candidates and is
+	// fused into their similarity. Zero keeps retrieval purely vector based.
+	HybridWeight float64
+	// HybridFusion picks how the two scores combine; FusionWeighted when
+	// empty.
+	HybridFusion Fusion
+	// RRFK is the rank offset for FusionRRF; 60 when zero.
+	RRFK int
+	// DisableInlinePrune stops Store from running Prune after every write.
+	// Pair it with StartPruner or call Prune yourself.
+	DisableInlinePrune bool
+	// CompactMinCluster is the smallest group of records sharing a summary
+	// that Compact merges; 3 when zero.
+	CompactMinCluster int
+	// CompactSummaryRatio and CompactDuplicateRatio make the background
+	// pruner compact the store once mergeable records reach that share of
+	// the store, or deduplicated writes that share of writes since the last
+	// compaction. Zero disables each trigger.
+	CompactSummaryRatio   float64
+	CompactDuplicateRatio float64
+	// ConflictSimilarity is the cosine similarity from which a new memory
+	// is checked against a stored one by the ConflictJudge; 0.8 when zero.
+	ConflictSimilarity float64
+	// ConflictImportance is the importance a stored memory needs to be
+	// checked for conflicts; 0.5 when zero.
+	ConflictImportance float64
+	// RetainImportance, when positive, exempts memories whose importance
+	// reaches it from TTL expiry. Size eviction already spares them in
+	// proportion to their importance.
+	RetainImportance float64
+	// SummaryRecords makes the background pruner run MaterializeSummaries
+	// after each pass, keeping a summary record per cluster.
+	SummaryRecords bool
+	// HierarchicalParents is the number of parent records RetrieveHierarchical
+	// expands into their members; 3 when zero.
+	HierarchicalParents int
+	// TemporalWindow adds time to MMR's notion of redundancy: candidates
+	// are bucketed into windows of this length by creation time, and one
+	// from a window the selection already covers pays TemporalPenalty
+	// (0.15 when zero) on top of its similarity penalty. This keeps a
+	// burst of recent, similar memories from filling every slot. Zero
+	// disables it.
+	TemporalWindow  time.Duration
+	TemporalPenalty float64
+	// MinOlderThan, when positive, reserves the last slot of a retrieval
+	// for the best candidate at least this old, unless one was already
+	// selected or none exists.
+	MinOlderThan time.Duration
+}
+
+const defaultTemporalPenalty = 0.15
+
+// temporalMMR is the temporal part of MMR selection at one moment.
+type temporalMMR struct {
+	window  time.Duration
+	penalty float64
+	// cutoff is the creation time a reserved old record must predate;
+	// zero when no slot is reserved.
+	cutoff time.Time
+}
+
+func (o Options) temporalMMR(now time.Time) temporalMMR {
+	t := temporalMMR{window: o.TemporalWindow, penalty: o.TemporalPenalty}
+	if t.penalty <= 0 {
+		t.penalty = defaultTemporalPenalty
+	}
+	if o.MinOlderThan > 0 {
+		t.cutoff = now.Add(-o.MinOlderThan)
+	}
+	return t
+}
+
+func (t temporalMMR) bucket(rec model.MemoryRecord) int64 {
+	return rec.CreatedAt.UnixNano() / int64(t.window)
+}
+
+func (t temporalMMR) old(rec model.MemoryRecord) bool {
+	return !rec.CreatedAt.IsZero() && rec.CreatedAt.Before(t.cutoff)
 }
 
 // DefaultOptions returns the recommended defaults for the advanced memory engine.
@@ -38,17 +144,21 @@ func DefaultOptions() Options {
 			Recency:    0.10,
 			Source:     0.05,
 		},
-		LambdaMMR:              0.7,
-		HalfLife:               72 * time.Hour,
-		ClusterSimilarity:      0.83,
-		DriftThreshold:         0.90,
-		DuplicateSimilarity:    0.97,
-		TTL:                    720 * time.Hour,
-		MaxSize:                200_000,
-		SourceBoost:            map[string]float64{"default": 1},
-		EnableSummaries:        true,
-		GraphNeighborhoodHops:  2,
-		GraphNeighborhoodLimit: 32,
+		LambdaMMR:               0.7,
+		HalfLife:                72 * time.Hour,
+		ClusterSimilarity:       0.83,
+		DriftThreshold:          0.90,
+		DuplicateSimilarity:     0.97,
+		DuplicateTextSimilarity: 0.8,
+		ConflictSimilarity:      0.8,
+		ConflictImportance:      0.5,
+		TTL:                     720 * time.Hour,
+		MaxSize:                 200_000,
+		SourceBoost:             map[string]float64{"default": 1},
+		EnableSummaries:         true,
+		GraphNeighborhoodHops:   2,
+		GraphNeighborhoodLimit:  32,
+		SessionEmbeddingDecay:   0.2,
 	}
 }
 
@@ -72,6 +182,15 @@ func (o Options) withDefaults() Options {
 	if o.DuplicateSimilarity == 0 {
 		o.DuplicateSimilarity = defaults.DuplicateSimilarity
 	}
+	if o.DuplicateTextSimilarity == 0 {
+		o.DuplicateTextSimilarity = defaults.DuplicateTextSimilarity
+	}
+	if o.ConflictSimilarity == 0 {
+		o.ConflictSimilarity = defaults.ConflictSimilarity
+	}
+	if o.ConflictImportance == 0 {
+		o.ConflictImportance = defaults.ConflictImportance
+	}
 	if o.TTL == 0 {
 		o.TTL = defaults.TTL
 	}
@@ -90,6 +209,9 @@ func (o Options) withDefaults() Options {
 	if o.GraphNeighborhoodLimit == 0 {
 		o.GraphNeighborhoodLimit = defaults.GraphNeighborhoodLimit
 	}
+	if o.SessionEmbeddingDecay <= 0 || o.SessionEmbeddingDecay > 1 {
+		o.SessionEmbeddingDecay = defaults.SessionEmbeddingDecay
+	}
 	return o
 }
 
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storeCountTimeout bounds the store count behind the size gauge, so a slow
+// store does not stall a scrape.
+const storeCountTimeout = 2 * time.Second
+
+var (
+	promCounters = []struct {
+		desc  *prometheus.Desc
+		value func(MetricsSnapshot) int64
+	}{
+		{memoryDesc("stored_total", "Memories written."), func(s MetricsSnapshot) int64 { return s.Stored }},
+		{memoryDesc("retrieved_total", "Memories returned by retrieval."), func(s MetricsSnapshot) int64 { return s.Retrieved }},
+		{memoryDesc("deduplicated_total", "Writes folded into an existing memory."), func(s MetricsSnapshot) int64 { return s.Deduplicated }},
+		{memoryDesc("duplicate_judged_total", "Near-duplicates sent to the duplicate judge."), func(s MetricsSnapshot) int64 { return s.DuplicateJudged }},
+		{memoryDesc("reembedded_total", "Memories re-embedded after embedding drift."), func(s MetricsSnapshot) int64 { return s.Reembedded }},
+		{memoryDesc("pruned_total", "Memories deleted by pruning."), func(s MetricsSnapshot) int64 { return s.Pruned }},
+		{memoryDesc("ttl_expired_total", "Memories pruned for exceeding their TTL."), func(s MetricsSnapshot) int64 { return s.TTLExpired }},
+		{memoryDesc("size_evicted_total", "Memories pruned to keep a store under MaxSize."), func(s MetricsSnapshot) int64 { return s.SizeEvicted }},
+		{memoryDesc("compacted_total", "Memories folded into compaction summaries."), func(s MetricsSnapshot) int64 { return s.Compacted }},
+		{memoryDesc("clusters_summarized_total", "Retrieval clusters given a summary."), func(s MetricsSnapshot) int64 { return s.ClustersSummarized }},
+		{memoryDesc("conflicts_total", "Writes found to contradict a stored fact."), func(s MetricsSnapshot) int64 { return s.Conflicts }},
+	}
+	promRecency      = memoryDesc("recency_decay_avg", "Average recency score of ranked candidates.")
+	promStoreRecords = memoryDesc("store_records", "Records in the vector store.")
+	promLatency      = prometheus.NewDesc("agent_memory_retrieval_duration_seconds", "Retrieval latency by operation.", []string{"op"}, nil)
+)
+
+func memoryDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc("agent_memory_"+name, help, nil, nil)
+}
+
+// RegisterMetrics exposes the engine's counters to Prometheus under the
+// agent_memory_ prefix: the MetricsSnapshot counters, retrieval latency
+// histograms for Retrieve and RetrieveFiltered (op "retrieve" and
+// "filtered"), and a gauge of records in the store, counted on each scrape.
+// Serve reg with promhttp as usual.
+func (e *Engine) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(metricsCollector{e})
+}
+
+type metricsCollector struct{ e *Engine }
+
+func (c metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range promCounters {
+		ch <- m.desc
+	}
+	ch <- promRecency
+	ch <- promStoreRecords
+	ch <- promLatency
+}
+
+func (c metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	snap := c.e.MetricsSnapshot()
+	for _, m := range promCounters {
+		ch <- prometheus.MustNewConstMetric(m.desc, prometheus.CounterValue, float64(m.value(snap)))
+	}
+	ch <- prometheus.MustNewConstMetric(promRecency, prometheus.GaugeValue, snap.RecencyDecayAvg)
+	if c.e.metrics != nil {
+		for op, h := range map[string]*latencyHistogram{"retrieve": &c.e.metrics.retrieveLatency, "filtered": &c.e.metrics.filteredLatency} {
+			buckets, count, sum := h.cumulative()
+			ch <- prometheus.MustNewConstHistogram(promLatency, count, sum, buckets, op)
+		}
+	}
+	if c.e.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeCountTimeout)
+	defer cancel()
+	n, err := c.e.store.Count(ctx)
+	if err != nil {
+		// An invalid metric would fail the whole scrape; leave the gauge
+		// out instead.
+		c.e.logf("metrics: count store: %v", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(promStoreRecords, prometheus.GaugeValue, float64(n))
+}
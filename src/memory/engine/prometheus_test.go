@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	embedpkg "github.com/Protocol-Lattice/go-agent/src/memory/embed"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterMetricsExportsCountersLatencyAndSize(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(embedpkg.DummyEmbedder{})
+	for _, content := range []string{"Database failover runbook", "Quarterly invoice totals"} {
+		if _, err := e.Store(ctx, "team", content, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := e.Retrieve(ctx, "team", "failover", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := e.RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	got := map[string]float64{}
+	var retrieveCount uint64
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			switch {
+			case m.GetCounter() != nil:
+				got[f.GetName()] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				got[f.GetName()] = m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "op" && l.GetValue() == "retrieve" {
+						retrieveCount = m.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+		}
+	}
+	if got["agent_memory_stored_total"] != 2 || got["agent_memory_retrieved_total"] != 1 {
+		t.Fatalf("counters = %v", got)
+	}
+	if got["agent_memory_store_records"] != 2 {
+		t.Fatalf("store_records = %v, want 2", got["agent_memory_store_records"])
+	}
+	if retrieveCount != 1 {
+		t.Fatalf("retrieve latency samples = %d, want 1", retrieveCount)
+	}
+	if err := e.RegisterMetrics(reg); err == nil {
+		t.Fatal("registering the same engine twice should fail")
+	}
+}
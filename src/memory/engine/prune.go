@@ -123,13 +123,17 @@ type pruneCandidate struct {
 	importance float64
 	content    string
 	metadata   string
+	space      string
 }
 
-// Prune applies TTL, size and deduplication policies.
+// Prune applies TTL, size and deduplication policies, then decays graph
+// edge weights when GraphEdgeHalfLife is set.
 func (e *Engine) Prune(ctx context.Context) (err error) {
 	if e.store == nil {
 		return nil
 	}
+	e.pruneMu.Lock()
+	defer e.pruneMu.Unlock()
 
 	now := e.clock().UTC()
 	seen := make(map[string]int64, 1024)
@@ -140,14 +144,35 @@ func (e *Engine) Prune(ctx context.Context) (err error) {
 	}()
 	var spoolErr error
 	survivors := 0
+	// Per-space caps, keyed by record space; resolved once per space.
+	spaceTTL := map[string]time.Duration{}
+	spaceCap := map[string]int{}
+	spaceCount := map[string]int{}
+	// Records with graph edges to decay once the deletions are done.
+	var decaying []model.MemoryRecord
 
 	if err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
-		if !rec.CreatedAt.IsZero() && now.Sub(rec.CreatedAt) > e.opts.TTL {
+		space := ""
+		ttl := e.opts.TTL
+		if len(e.spaces) > 0 {
+			space = recordSpace(rec)
+			if _, ok := spaceTTL[space]; !ok {
+				spaceTTL[space] = e.opts.TTL
+				if s, ok := e.spaceFor(space); ok {
+					spaceTTL[space] = s.opts.inherit(e.opts).TTL
+					if s.opts.MaxSize > 0 {
+						spaceCap[space] = s.opts.MaxSize
+					}
+				}
+			}
+			ttl = spaceTTL[space]
+		}
+		if !rec.CreatedAt.IsZero() && now.Sub(rec.CreatedAt) > ttl && !e.opts.retained(rec) {
 			spoolErr = spool.append(pendingDeletion{id: rec.ID, ttl: true})
 			return spoolErr == nil
 		}
 
-		key := canonicalKey(rec.Content)
+		key := tenantKey(model.RecordTenant(rec), audienceKey(model.RecordACL(rec))+canonicalKey(rec.Content))
 		if _, ok := seen[key]; ok {
 			spoolErr = spool.append(pendingDeletion{id: rec.ID})
 			if spoolErr != nil {
@@ -166,8 +191,13 @@ func (e *Engine) Prune(ctx context.Context) (err error) {
 			importance: rec.Importance,
 			content:    rec.Content,
 			metadata:   rec.Metadata,
+			space:      space,
 		})
 		survivors++
+		spaceCount[space]++
+		if e.opts.GraphEdgeHalfLife > 0 && len(rec.GraphEdges) > 0 {
+			decaying = append(decaying, rec)
+		}
 		return true
 	}); err != nil {
 		return err
@@ -182,16 +212,65 @@ func (e *Engine) Prune(ctx context.Context) (err error) {
 		return err
 	}
 
-	if survivors <= e.opts.MaxSize {
+	// Spaces over their own MaxSize are trimmed first; what remains is held
+	// to the engine-wide cap.
+	var evict []int64
+	for space, limit := range spaceCap {
+		if spaceCount[space] <= limit {
+			continue
+		}
+		ids := sizeEvictions(candidates, spaceCount[space]-limit, now, func(c *pruneCandidate) bool { return c.space == space })
+		evict = append(evict, ids...)
+		survivors -= len(ids)
+	}
+	if len(evict) > 0 {
+		evicted := make(map[int64]struct{}, len(evict))
+		for _, id := range evict {
+			evicted[id] = struct{}{}
+		}
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if _, ok := evicted[c.id]; !ok {
+				kept = append(kept, c)
+			}
+		}
+		candidates = kept
+	}
+	if survivors > e.opts.MaxSize {
+		evict = append(evict, sizeEvictions(candidates, survivors-e.opts.MaxSize, now, nil)...)
+	}
+	if len(evict) > 0 {
+		if err := e.deleteSizeEvictions(ctx, evict); err != nil {
+			return err
+		}
+	}
+	if len(decaying) == 0 {
 		return nil
 	}
+	evicted := make(map[int64]bool, len(evict))
+	for _, id := range evict {
+		evicted[id] = true
+	}
+	kept := decaying[:0]
+	for _, rec := range decaying {
+		if !evicted[rec.ID] {
+			kept = append(kept, rec)
+		}
+	}
+	return e.decayEdges(ctx, kept, now)
+}
 
-	overflow := survivors - e.opts.MaxSize
+// sizeEvictions picks the overflow candidates with the highest prune score,
+// considering only those accepted by match when it is set.
+func sizeEvictions(candidates []pruneCandidate, overflow int, now time.Time, match func(*pruneCandidate) bool) []int64 {
 	h := make(minHeap, 0, overflow)
 	heap.Init(&h)
 
 	for i := range candidates {
 		candidate := &candidates[i]
+		if match != nil && !match(candidate) {
+			continue
+		}
 		importance := candidate.importance
 		if importance == 0 {
 			importance = importanceScore(candidate.content, model.DecodeMetadata(candidate.metadata))
@@ -212,8 +291,7 @@ func (e *Engine) Prune(ctx context.Context) (err error) {
 	for h.Len() > 0 {
 		evict = append(evict, heap.Pop(&h).(item).id)
 	}
-
-	return e.deleteSizeEvictions(ctx, evict)
+	return evict
 }
 
 func (e *Engine) deletePrunedRecords(ctx context.Context, spool *deletionSpool) error {
@@ -240,6 +318,8 @@ func (e *Engine) deletePrunedRecords(ctx context.Context, spool *deletionSpool)
 		if err := e.store.DeleteMemory(ctx, ids); err != nil {
 			return err
 		}
+		e.forgetLexical(ids)
+		e.forgetFacts(ids)
 		if e.metrics != nil {
 			e.metrics.IncPruned(len(ids))
 			if ttlCount > 0 {
@@ -256,6 +336,8 @@ func (e *Engine) deleteSizeEvictions(ctx context.Context, ids []int64) error {
 		if err := e.store.DeleteMemory(ctx, batch); err != nil {
 			return err
 		}
+		e.forgetLexical(batch)
+		e.forgetFacts(batch)
 		if e.metrics != nil {
 			e.metrics.IncPruned(len(batch))
 			e.metrics.IncSizeEvicted(len(batch))
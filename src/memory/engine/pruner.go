@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPruneInterval = 5 * time.Minute
+	pruneJitter          = 0.1
+)
+
+// StartPruner runs Prune every interval (five minutes when zero) in a
+// background goroutine until ctx is done or the returned stop function is
+// called. Each wait is jittered by ±10% so replicas sharing a store do not
+// prune in lockstep. After each pass it compacts the store when
+// Options.CompactSummaryRatio or CompactDuplicateRatio is reached, and
+// refreshes summary records when Options.SummaryRecords is set. While
+// the pruner runs, Store no longer prunes inline.
+// Stop waits for an in-flight pass to finish and is safe to call twice.
+func (e *Engine) StartPruner(ctx context.Context, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultPruneInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	e.backgroundPrune.Add(1)
+	go func() {
+		defer close(done)
+		defer e.backgroundPrune.Add(-1)
+		timer := time.NewTimer(jittered(interval))
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if err := e.Prune(ctx); err != nil && ctx.Err() == nil {
+					e.logf("background prune: %v", err)
+				}
+				e.maybeCompact(ctx)
+				if e.opts.SummaryRecords {
+					if _, err := e.MaterializeSummaries(ctx); err != nil && ctx.Err() == nil {
+						e.logf("materialize summaries: %v", err)
+					}
+				}
+				timer.Reset(jittered(interval))
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
+
+// inlinePrune reports whether Store should prune after each write.
+func (e *Engine) inlinePrune() bool {
+	return !e.opts.DisableInlinePrune && e.backgroundPrune.Load() == 0
+}
+
+func jittered(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * (1 + (rand.Float64()*2-1)*pruneJitter))
+}
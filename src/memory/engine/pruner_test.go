@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestStartPrunerPrunesInBackground(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	for _, content := range []string{"expired one", "expired two"} {
+		if err := store.StoreMemory(ctx, "s", content, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC().Add(2 * time.Hour)
+	e := NewEngine(store, Options{TTL: time.Hour, Clock: func() time.Time { return now }})
+	stop := e.StartPruner(ctx, 10*time.Millisecond)
+	defer stop()
+
+	if e.inlinePrune() {
+		t.Fatal("Store should not prune inline while the pruner runs")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n, err := store.Count(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background pruner left %d expired records", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	stop()
+	if !e.inlinePrune() {
+		t.Fatal("inline pruning should resume once the pruner stops")
+	}
+}
+
+func TestDisableInlinePruneSkipsPruneOnStore(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	if err := store.StoreMemory(ctx, "s", "stale entry", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Add(2 * time.Hour)
+	e := NewEngine(store, Options{TTL: time.Hour, DisableInlinePrune: true, Clock: func() time.Time { return now }}).
+		WithEmbedder(angleEmbedder{})
+	if _, err := e.Store(ctx, "s", "fresh entry", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.Count(ctx); n != 2 {
+		t.Fatalf("expected the stale record to survive Store, got %d records", n)
+	}
+	if snap := e.MetricsSnapshot(); snap.Pruned != 0 {
+		t.Fatalf("unexpected prune during Store: %+v", snap)
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// ErrUnknownSession is returned by FindSimilarSessions for a session with no
+// stored memories.
+var ErrUnknownSession = errors.New("session has no memories")
+
+// sessionRecent bounds the memories kept per session for summaries.
+const sessionRecent = 8
+
+// SimilarSession is a past session that resembles the one searched for.
+type SimilarSession struct {
+	SessionID  string    `json:"session_id"`
+	Similarity float64   `json:"similarity"`
+	Memories   int       `json:"memories"`
+	LastActive time.Time `json:"last_active"`
+	// Summary condenses the session's most recent memories.
+	Summary string `json:"summary"`
+}
+
+// sessionVector is a session's rolling embedding: an exponential moving
+// average of its memories' embeddings, so it follows how the conversation
+// evolves while remembering what it started with.
+type sessionVector struct {
+	sessionID  string
+	tenant     string
+	vec        []float64
+	count      int
+	lastActive time.Time
+	recent     []model.MemoryRecord
+}
+
+type sessionIndex struct {
+	mu sync.Mutex
+	// sessions is keyed by tenantKey(tenant, session ID).
+	sessions map[string]*sessionVector
+	loaded   bool
+}
+
+func (e *Engine) sessionIndex() *sessionIndex {
+	e.sessionsOnce.Do(func() {
+		e.sessions = &sessionIndex{sessions: map[string]*sessionVector{}}
+	})
+	return e.sessions
+}
+
+// observeSession folds a stored memory into its session's embedding.
+func (e *Engine) observeSession(rec model.MemoryRecord) {
+	if rec.SessionID == "" || len(rec.Embedding) == 0 {
+		return
+	}
+	idx := e.sessionIndex()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.observe(rec, e.opts.SessionEmbeddingDecay)
+}
+
+func (idx *sessionIndex) observe(rec model.MemoryRecord, decay float64) {
+	tenant := model.RecordTenant(rec)
+	key := tenantKey(tenant, rec.SessionID)
+	sv := idx.sessions[key]
+	if sv == nil || len(sv.vec) != len(rec.Embedding) {
+		sv = &sessionVector{sessionID: rec.SessionID, tenant: tenant, vec: float32To64(rec.Embedding)}
+		idx.sessions[key] = sv
+	} else {
+		for i, v := range rec.Embedding {
+			sv.vec[i] = (1-decay)*sv.vec[i] + decay*float64(v)
+		}
+	}
+	sv.count++
+	if rec.CreatedAt.After(sv.lastActive) {
+		sv.lastActive = rec.CreatedAt
+	}
+	rec.Embedding, rec.EmbeddingMatrix = nil, nil
+	sv.recent = append(sv.recent, rec)
+	if len(sv.recent) > sessionRecent {
+		sv.recent = sv.recent[len(sv.recent)-sessionRecent:]
+	}
+}
+
+// RebuildSessionEmbeddings recomputes every session embedding from the
+// store, oldest memory first. FindSimilarSessions calls it once on first
+// use so sessions stored before the engine started are searchable.
+func (e *Engine) RebuildSessionEmbeddings(ctx context.Context) error {
+	if e.store == nil {
+		return errors.New("memory engine has no store")
+	}
+	var records []model.MemoryRecord
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.SessionID != "" && len(rec.Embedding) > 0 {
+			records = append(records, rec)
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+
+	rebuilt := &sessionIndex{sessions: map[string]*sessionVector{}, loaded: true}
+	for _, rec := range records {
+		rebuilt.observe(rec, e.opts.SessionEmbeddingDecay)
+	}
+	idx := e.sessionIndex()
+	idx.mu.Lock()
+	idx.sessions, idx.loaded = rebuilt.sessions, true
+	idx.mu.Unlock()
+	return nil
+}
+
+// FindSimilarSessions returns up to k other sessions of the tenant on ctx
+// whose rolling embeddings are closest to sessionID's, most similar first,
+// each with a summary of its recent memories that can be imported as
+// context.
+func (e *Engine) FindSimilarSessions(ctx context.Context, sessionID string, k int) ([]SimilarSession, error) {
+	if k <= 0 {
+		k = 5
+	}
+	idx := e.sessionIndex()
+	idx.mu.Lock()
+	loaded := idx.loaded
+	idx.mu.Unlock()
+	if !loaded {
+		if err := e.RebuildSessionEmbeddings(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	tenant := model.TenantFromContext(ctx)
+	idx.mu.Lock()
+	target := idx.sessions[tenantKey(tenant, sessionID)]
+	if target == nil {
+		idx.mu.Unlock()
+		return nil, ErrUnknownSession
+	}
+	query := float32Slice(target.vec)
+	type candidate struct {
+		SimilarSession
+		recent []model.MemoryRecord
+	}
+	var candidates []candidate
+	for _, sv := range idx.sessions {
+		if sv == target || sv.tenant != tenant || len(sv.vec) != len(query) {
+			continue
+		}
+		sim := model.CosineSimilarity(query, float32Slice(sv.vec))
+		if math.IsNaN(sim) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			SimilarSession: SimilarSession{SessionID: sv.sessionID, Similarity: sim, Memories: sv.count, LastActive: sv.lastActive},
+			recent:         append([]model.MemoryRecord(nil), sv.recent...),
+		})
+	}
+	idx.mu.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Similarity != candidates[j].Similarity {
+			return candidates[i].Similarity > candidates[j].Similarity
+		}
+		return candidates[i].SessionID < candidates[j].SessionID
+	})
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	out := make([]SimilarSession, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.SimilarSession
+		if e.summarizer == nil || len(c.recent) == 0 {
+			continue
+		}
+		summary, err := e.summarizer.Summarize(ctx, c.recent)
+		if err != nil {
+			e.logf("summarize session %s: %v", c.SessionID, err)
+			continue
+		}
+		out[i].Summary = summary
+	}
+	return out, nil
+}
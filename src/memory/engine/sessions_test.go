@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// topicEmbedder embeds text onto one axis per topic keyword.
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	text = strings.ToLower(text)
+	vec := make([]float32, 4)
+	for i, topic := range []string{"printer", "invoice", "password", "refund"} {
+		if strings.Contains(text, topic) {
+			vec[i] = 1
+		}
+	}
+	vec[3] += 0.01 // never all-zero
+	return vec, nil
+}
+
+func TestFindSimilarSessions(t *testing.T) {
+	ctx := context.Background()
+	memStore := storepkg.NewInMemoryStore()
+	e := NewEngine(memStore, Options{DuplicateSimilarity: 1.01}).WithEmbedder(topicEmbedder{})
+
+	stores := map[string][]string{
+		"old-printer": {"printer shows paper jam", "printer jam cleared after reboot"},
+		"old-billing": {"invoice total is wrong", "invoice reissued"},
+		"new":         {"my printer will not print"},
+	}
+	for session, contents := range stores {
+		for _, c := range contents {
+			if _, err := e.Store(ctx, session, c, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// A fresh engine over the same store rebuilds embeddings on first use.
+	fresh := NewEngine(memStore, Options{}).WithEmbedder(topicEmbedder{})
+	for _, eng := range []*Engine{e, fresh} {
+		similar, err := eng.FindSimilarSessions(ctx, "new", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(similar) != 1 || similar[0].SessionID != "old-printer" || similar[0].Memories != 2 {
+			t.Fatalf("FindSimilarSessions = %+v, want old-printer", similar)
+		}
+		if !strings.Contains(similar[0].Summary, "paper jam") {
+			t.Fatalf("summary = %q", similar[0].Summary)
+		}
+	}
+
+	if _, err := e.FindSimilarSessions(ctx, "nobody", 3); !errors.Is(err, ErrUnknownSession) {
+		t.Fatalf("unknown session err = %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// SnapshotFormat and SnapshotVersion identify the snapshot Export writes.
+// Import refuses other formats and newer versions.
+const (
+	SnapshotFormat  = "go-agent-memory"
+	SnapshotVersion = 1
+)
+
+// snapshotHeader is the first line of a snapshot.
+type snapshotHeader struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// snapshotRecord is one record line of a snapshot. ID is the record's ID
+// in the exporting store; edges refer to those IDs.
+type snapshotRecord struct {
+	ID         int64             `json:"id"`
+	SessionID  string            `json:"session_id"`
+	Space      string            `json:"space,omitempty"`
+	Content    string            `json:"content"`
+	Metadata   json.RawMessage   `json:"metadata,omitempty"`
+	Embedding  []float32         `json:"embedding,omitempty"`
+	GraphEdges []model.GraphEdge `json:"graph_edges,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// ImportReport describes one Import.
+type ImportReport struct {
+	// Imported is the number of records written.
+	Imported int `json:"imported"`
+	// Edges counts the graph edges carried over to the new IDs. Dropped
+	// counts edges whose target was not in the snapshot, or that pointed at
+	// a later record of a store that keeps edges only in metadata.
+	Edges   int `json:"edges"`
+	Dropped int `json:"dropped_edges"`
+}
+
+// Export writes every record of the tenant on ctx as a JSONL snapshot: a
+// header line, then one line per record with its content, metadata,
+// embedding and graph edges. Import reads it back into any store.
+func (e *Engine) Export(ctx context.Context, w io.Writer) error {
+	if e.store == nil {
+		return errors.New("memory engine has no store")
+	}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Format: SnapshotFormat, Version: SnapshotVersion, ExportedAt: e.clock().UTC()}); err != nil {
+		return err
+	}
+	var writeErr error
+	err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if writeErr = ctx.Err(); writeErr != nil {
+			return false
+		}
+		if !ownedBy(ctx, rec) {
+			return true
+		}
+		line := snapshotRecord{
+			ID:         rec.ID,
+			SessionID:  rec.SessionID,
+			Space:      rec.Space,
+			Content:    rec.Content,
+			Embedding:  rec.Embedding,
+			GraphEdges: rec.GraphEdges,
+			CreatedAt:  rec.CreatedAt,
+		}
+		if json.Valid([]byte(rec.Metadata)) {
+			line.Metadata = json.RawMessage(rec.Metadata)
+		}
+		if len(line.GraphEdges) == 0 {
+			line.GraphEdges = model.ValidGraphEdges(model.DecodeMetadata(rec.Metadata))
+		}
+		writeErr = enc.Encode(line)
+		return writeErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// Import stores the records of a snapshot written by Export. Records keep
+// their content, metadata and embedding but get new IDs (and creation
+// times) from the store; graph edges are rewritten to the new IDs. Import
+// skips the duplicate check, so importing a snapshot twice stores its
+// records twice. Records without an embedding are embedded again. Records
+// are written into the tenant on ctx, and a snapshot naming another tenant
+// is rejected with ErrTenantMismatch.
+func (e *Engine) Import(ctx context.Context, r io.Reader) (ImportReport, error) {
+	var report ImportReport
+	if e.store == nil {
+		return report, errors.New("memory engine has no store")
+	}
+	dec := json.NewDecoder(r)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return report, fmt.Errorf("read snapshot header: %w", err)
+	}
+	if header.Format != SnapshotFormat {
+		return report, fmt.Errorf("not a memory snapshot: format %q", header.Format)
+	}
+	if header.Version < 1 || header.Version > SnapshotVersion {
+		return report, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	graphStore, hasGraph := e.store.(store.GraphStore)
+	newIDs := make(map[int64]int64)
+	// deferred holds records with edges to records not yet imported; they
+	// are resolved once every ID is known.
+	type deferredEdges struct {
+		record model.MemoryRecord
+		edges  []model.GraphEdge
+	}
+	var deferred []deferredEdges
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		var rec snapshotRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return report, fmt.Errorf("read snapshot record %d: %w", line, err)
+		}
+		stored, forward, err := e.importRecord(ctx, rec, newIDs, &report)
+		if err != nil {
+			return report, fmt.Errorf("import record %d: %w", rec.ID, err)
+		}
+		if rec.ID != 0 && stored.ID != 0 {
+			newIDs[rec.ID] = stored.ID
+		}
+		if forward {
+			deferred = append(deferred, deferredEdges{record: stored, edges: rec.GraphEdges})
+		}
+	}
+
+	for _, d := range deferred {
+		var edges []model.GraphEdge
+		for _, edge := range d.edges {
+			if target, ok := newIDs[edge.Target]; ok {
+				edges = append(edges, model.GraphEdge{Target: target, Type: edge.Type})
+			}
+		}
+		resolved := len(edges) - len(d.record.GraphEdges)
+		if !hasGraph || d.record.ID == 0 {
+			report.Dropped += len(d.edges) - len(d.record.GraphEdges)
+			continue
+		}
+		d.record.GraphEdges = edges
+		if err := graphStore.UpsertGraph(ctx, d.record, edges); err != nil {
+			return report, fmt.Errorf("upsert graph of record %d: %w", d.record.ID, err)
+		}
+		report.Edges += resolved
+		report.Dropped += len(d.edges) - len(edges)
+	}
+	return report, nil
+}
+
+// importRecord writes one snapshot record with the edges whose targets are
+// already imported. forward reports edges to targets still to come.
+func (e *Engine) importRecord(ctx context.Context, rec snapshotRecord, newIDs map[int64]int64, report *ImportReport) (model.MemoryRecord, bool, error) {
+	metadata := model.DecodeMetadata(string(rec.Metadata))
+	if _, ok := metadata["space"]; !ok && rec.Space != "" {
+		metadata["space"] = rec.Space
+	}
+	if err := StampTenant(ctx, metadata); err != nil {
+		return model.MemoryRecord{}, false, err
+	}
+	delete(metadata, "graph_edges")
+	var edges []model.GraphEdge
+	forward := false
+	for _, edge := range rec.GraphEdges {
+		if target, ok := newIDs[edge.Target]; ok {
+			edges = append(edges, model.GraphEdge{Target: target, Type: edge.Type})
+		} else {
+			forward = true
+		}
+	}
+	if len(edges) > 0 {
+		metadata["graph_edges"] = edges
+	}
+
+	embedding := rec.Embedding
+	if len(embedding) == 0 {
+		var err error
+		if embedding, err = e.embed(ctx, rec.Content); err != nil {
+			return model.MemoryRecord{}, false, fmt.Errorf("embed content: %w", err)
+		}
+	}
+	if err := e.store.StoreMemory(ctx, rec.SessionID, rec.Content, metadata, embedding); err != nil {
+		return model.MemoryRecord{}, false, err
+	}
+	stored := e.commitWrite(ctx, pendingWrite{
+		record: model.MemoryRecord{
+			SessionID:  rec.SessionID,
+			Content:    rec.Content,
+			Metadata:   model.StringFromAny(metadata),
+			Embedding:  embedding,
+			Importance: model.FloatFromAny(metadata["importance"]),
+			Source:     model.StringFromAny(metadata["source"]),
+			CreatedAt:  e.clock().UTC(),
+		},
+		metadata: metadata,
+		edges:    edges,
+	})
+	stored.GraphEdges = edges
+	report.Imported++
+	report.Edges += len(edges)
+	return stored, forward, nil
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := storepkg.NewInMemoryStore()
+	// Shift the source IDs so that edges only survive if they are rewritten.
+	for i := 0; i < 3; i++ {
+		_ = src.StoreMemory(ctx, "scratch", "filler", nil, []float32{1, 1})
+	}
+	_ = src.DeleteMemory(ctx, []int64{1, 2, 3})
+	_ = src.StoreMemory(ctx, "s", "the deploy runbook", map[string]any{"source": "docs", "importance": 0.8}, []float32{1, 0})
+	_ = src.StoreMemory(ctx, "s", "deploys need the runbook", map[string]any{
+		"space":       "team",
+		"graph_edges": []model.GraphEdge{{Target: 4, Type: model.EdgeDerivedFrom}, {Target: 99, Type: model.EdgeFollows}},
+	}, []float32{0, 1})
+
+	var buf bytes.Buffer
+	if err := NewEngine(src, Options{}).Export(ctx, &buf); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if first := strings.SplitN(buf.String(), "\n", 2)[0]; !strings.Contains(first, `"format":"go-agent-memory"`) || !strings.Contains(first, `"version":1`) {
+		t.Fatalf("missing header: %s", first)
+	}
+
+	dst := storepkg.NewInMemoryStore()
+	e := NewEngine(dst, Options{}).WithEmbedder(angleEmbedder{})
+	report, err := e.Import(ctx, &buf)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Imported != 2 || report.Edges != 1 || report.Dropped != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	byContent := map[string]model.MemoryRecord{}
+	_ = dst.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		byContent[rec.Content] = rec
+		return true
+	})
+	runbook, derived := byContent["the deploy runbook"], byContent["deploys need the runbook"]
+	if runbook.Source != "docs" || runbook.Importance != 0.8 || len(runbook.Embedding) != 2 || runbook.Embedding[0] != 1 {
+		t.Fatalf("record lost its metadata or embedding: %+v", runbook)
+	}
+	if derived.Space != "team" {
+		t.Fatalf("space = %q, want team", derived.Space)
+	}
+	if len(derived.GraphEdges) != 1 || derived.GraphEdges[0].Target != runbook.ID {
+		t.Fatalf("edge not rewritten to the new ID %d: %+v", runbook.ID, derived.GraphEdges)
+	}
+}
+
+func TestImportRejectsUnknownSnapshots(t *testing.T) {
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{})
+	for _, header := range []string{
+		`{"format":"something-else","version":1}`,
+		`{"format":"go-agent-memory","version":2}`,
+	} {
+		if _, err := e.Import(context.Background(), strings.NewReader(header+"\n")); err == nil {
+			t.Fatalf("Import accepted %s", header)
+		}
+	}
+}
+
+func TestExportAndImportStayInTheContextTenant(t *testing.T) {
+	acme := model.ContextWithTenant(context.Background(), "acme")
+	globex := model.ContextWithTenant(context.Background(), "globex")
+	src := storepkg.NewInMemoryStore()
+	e := NewEngine(src, Options{}).WithEmbedder(angleEmbedder{"acme plan": 1, "globex plan": 0.5})
+	if _, err := e.Store(acme, "s", "acme plan", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Store(globex, "s", "globex plan", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var snapshot, graph bytes.Buffer
+	if err := e.Export(acme, &snapshot); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if err := e.ExportGraph(acme, &graph, GraphExportOptions{}); err != nil {
+		t.Fatalf("ExportGraph: %v", err)
+	}
+	for name, out := range map[string]string{"snapshot": snapshot.String(), "graph": graph.String()} {
+		if !strings.Contains(out, "acme plan") || strings.Contains(out, "globex") {
+			t.Fatalf("%s crosses tenants:\n%s", name, out)
+		}
+	}
+
+	dst := NewEngine(storepkg.NewInMemoryStore(), Options{})
+	if _, err := dst.Import(globex, bytes.NewReader(snapshot.Bytes())); !errors.Is(err, ErrTenantMismatch) {
+		t.Fatalf("Import into another tenant: err = %v, want ErrTenantMismatch", err)
+	}
+	if report, err := dst.Import(acme, bytes.NewReader(snapshot.Bytes())); err != nil || report.Imported != 1 {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+}
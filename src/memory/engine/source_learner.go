@@ -0,0 +1,250 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+const (
+	defaultSourceLearningRate = 0.1
+	defaultSourceWeightFloor  = 0.05
+	// defaultSourceUseOverlap is the share of a record's keywords that must
+	// appear in an answer for the record to count as used.
+	defaultSourceUseOverlap = 0.5
+)
+
+// SourceStat is what a SourceLearner knows about one source.
+type SourceStat struct {
+	// Weight scales Options.SourceBoost for the source, in [Floor, 1].
+	Weight    float64   `json:"weight"`
+	Retrieved int       `json:"retrieved"`
+	Used      int       `json:"used"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SourceLearner learns per-source ranking weights from which retrieved
+// memories end up in answers. Every source starts at weight 1; after each
+// answer, the weight of each source that was retrieved moves by Rate toward
+// the share of its records the answer used. Sources whose records are
+// retrieved but never used decay to Floor and sink in the ranking, while
+// sources the answers rely on keep their weight. Install it with
+// Engine.WithSourceLearner, which multiplies Options.SourceBoost by the
+// learned weight, so a configured boost is a starting point rather than a
+// fixed value.
+type SourceLearner struct {
+	// Rate is the step toward each observed use rate; zero means 0.1.
+	Rate float64
+	// Floor is the lowest weight a source decays to; zero means 0.05.
+	Floor float64
+	// UseOverlap is the share of a record's keywords an answer must contain
+	// for the record to count as used; zero means 0.5.
+	UseOverlap float64
+
+	path  string
+	mu    sync.Mutex
+	stats map[string]SourceStat
+}
+
+// NewSourceLearner returns a learner that persists its weights to path as
+// JSON after every observation, loading them first when the file exists.
+// An empty path keeps the weights in memory only.
+func NewSourceLearner(path string) (*SourceLearner, error) {
+	l := &SourceLearner{path: path, stats: map[string]SourceStat{}}
+	if path == "" {
+		return l, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &l.stats); err != nil {
+		return nil, fmt.Errorf("parse source weights %s: %w", path, err)
+	}
+	if l.stats == nil {
+		l.stats = map[string]SourceStat{}
+	}
+	return l, nil
+}
+
+// Weight returns the learned weight of source, and false when the source
+// has not been observed.
+func (l *SourceLearner) Weight(source string) (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stat, ok := l.stats[sourceKey(source)]
+	return stat.Weight, ok
+}
+
+// Stats returns a copy of the learned statistics, keyed by source.
+func (l *SourceLearner) Stats() map[string]SourceStat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]SourceStat, len(l.stats))
+	for k, v := range l.stats {
+		out[k] = v
+	}
+	return out
+}
+
+// Sources lists the observed sources, highest weight first.
+func (l *SourceLearner) Sources() []string {
+	stats := l.Stats()
+	out := make([]string, 0, len(stats))
+	for k := range stats {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if stats[out[i]].Weight != stats[out[j]].Weight {
+			return stats[out[i]].Weight > stats[out[j]].Weight
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// Observe updates the weights from one answer and the memories retrieved
+// for it, then saves them. A record counts as used when the answer contains
+// at least UseOverlap of its keywords.
+func (l *SourceLearner) Observe(retrieved []model.MemoryRecord, answer string, now time.Time) error {
+	if len(retrieved) == 0 {
+		return nil
+	}
+	overlap := l.UseOverlap
+	if overlap <= 0 {
+		overlap = defaultSourceUseOverlap
+	}
+	answerWords := map[string]struct{}{}
+	for _, w := range extractKeywords(answer) {
+		answerWords[w] = struct{}{}
+	}
+	type tally struct{ retrieved, used int }
+	round := map[string]*tally{}
+	for _, rec := range retrieved {
+		key := sourceKey(rec.Source)
+		t := round[key]
+		if t == nil {
+			t = &tally{}
+			round[key] = t
+		}
+		t.retrieved++
+		if usedIn(rec, answerWords, overlap) {
+			t.used++
+		}
+	}
+
+	rate := l.Rate
+	if rate <= 0 {
+		rate = defaultSourceLearningRate
+	}
+	floor := l.Floor
+	if floor <= 0 {
+		floor = defaultSourceWeightFloor
+	}
+	l.mu.Lock()
+	for key, t := range round {
+		stat, ok := l.stats[key]
+		if !ok {
+			stat.Weight = 1
+		}
+		target := float64(t.used) / float64(t.retrieved)
+		stat.Weight = clamp(stat.Weight+rate*(target-stat.Weight), floor, 1)
+		stat.Retrieved += t.retrieved
+		stat.Used += t.used
+		stat.UpdatedAt = now.UTC()
+		l.stats[key] = stat
+	}
+	l.mu.Unlock()
+	return l.Save()
+}
+
+// Save writes the weights to the learner's path, replacing the file
+// atomically. It does nothing for an in-memory learner.
+func (l *SourceLearner) Save() error {
+	if l.path == "" {
+		return nil
+	}
+	l.mu.Lock()
+	raw, err := json.MarshalIndent(l.stats, "", "  ")
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
+
+func usedIn(rec model.MemoryRecord, answerWords map[string]struct{}, overlap float64) bool {
+	words := extractKeywords(rec.Content)
+	if len(words) == 0 {
+		return false
+	}
+	hits := 0
+	for _, w := range words {
+		if _, ok := answerWords[w]; ok {
+			hits++
+		}
+	}
+	return float64(hits)/float64(len(words)) >= overlap
+}
+
+// sourceKey normalises source the way Options.SourceBoost is keyed.
+func sourceKey(source string) string {
+	if source = strings.ToLower(strings.TrimSpace(source)); source == "" {
+		return "default"
+	}
+	return source
+}
+
+// WithSourceLearner makes ranking scale each source's Options.SourceBoost
+// by l's learned weight. Feed it outcomes with ObserveAnswer.
+func (e *Engine) WithSourceLearner(l *SourceLearner) *Engine {
+	e.sources = l
+	return e
+}
+
+// ObserveAnswer reports the memories retrieved for an answer to the
+// engine's SourceLearner, if it has one. Failures to persist the weights
+// are logged.
+func (e *Engine) ObserveAnswer(retrieved []model.MemoryRecord, answer string) {
+	if e == nil || e.sources == nil {
+		return
+	}
+	if err := e.sources.Observe(retrieved, answer, e.clock()); err != nil {
+		e.logf("save source weights: %v", err)
+	}
+}
+
+// sourceScore is the configured boost of source scaled by its learned
+// weight.
+func (e *Engine) sourceScore(opts Options, source string) float64 {
+	score := opts.sourceScore(source)
+	if e.sources != nil {
+		if w, ok := e.sources.Weight(source); ok {
+			score *= w
+		}
+	}
+	return score
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestSourceLearnerDecaysUnusedSourcesAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sources.json")
+	l, err := NewSourceLearner(path)
+	if err != nil {
+		t.Fatalf("NewSourceLearner: %v", err)
+	}
+	l.Rate = 0.5
+	retrieved := []model.MemoryRecord{
+		{Source: "runbook", Content: "Restart the ingest workers before rotating credentials"},
+		{Source: "Slack", Content: "lunch order thread for friday"},
+	}
+	answer := "Restart the ingest workers, then rotate the credentials."
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	for range 10 {
+		if err := l.Observe(retrieved, answer, now); err != nil {
+			t.Fatalf("Observe: %v", err)
+		}
+	}
+	if w, _ := l.Weight("runbook"); w != 1 {
+		t.Fatalf("runbook weight = %v, want 1", w)
+	}
+	if w, _ := l.Weight("slack"); w != defaultSourceWeightFloor {
+		t.Fatalf("slack weight = %v, want the floor", w)
+	}
+	if got := l.Sources(); len(got) != 2 || got[0] != "runbook" {
+		t.Fatalf("sources = %v, want runbook first", got)
+	}
+
+	reloaded, err := NewSourceLearner(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	stat := reloaded.Stats()["slack"]
+	if stat.Retrieved != 10 || stat.Used != 0 || !stat.UpdatedAt.Equal(now) {
+		t.Fatalf("reloaded slack stat = %+v", stat)
+	}
+}
+
+func TestEngineRanksWithLearnedSourceWeights(t *testing.T) {
+	l, _ := NewSourceLearner("")
+	engine := NewEngine(storepkg.NewInMemoryStore(), Options{SourceBoost: map[string]float64{"slack": 0.3}}).WithSourceLearner(l)
+
+	if got := engine.Breakdown("team", model.MemoryRecord{Source: "slack"}).Source; got != 0.3 {
+		t.Fatalf("unobserved source score = %v, want the static boost", got)
+	}
+	engine.ObserveAnswer([]model.MemoryRecord{{Source: "slack", Content: "unrelated chatter"}}, "The deploy is on Tuesday.")
+	if got := engine.Breakdown("team", model.MemoryRecord{Source: "slack"}).Source; math.Abs(got-0.27) > 1e-9 {
+		t.Fatalf("source score = %v, want the boost scaled by the learned 0.9", got)
+	}
+	engine.ObserveAnswer([]model.MemoryRecord{{Source: "slack", Content: "unrelated chatter"}}, "The deploy is on Tuesday.")
+	if got := engine.Breakdown("team", model.MemoryRecord{Source: "slack"}).Source; got >= 0.27 {
+		t.Fatalf("source score = %v, want it to keep decaying", got)
+	}
+}
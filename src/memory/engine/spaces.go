@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"path"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// spaceOverride is one entry of WithSpaceOptions.
+type spaceOverride struct {
+	pattern string
+	opts    Options
+}
+
+// WithSpaceOptions overrides Options per memory space. Keys are space names
+// or path.Match patterns such as "incident:*"; an exact name wins over a
+// pattern and a longer pattern over a shorter one. Zero fields inherit the
+// engine's options. Store uses the overrides for duplicate detection,
+// Retrieve for scoring and MMR, and Prune for TTL and MaxSize, which caps
+// each matching space on its own in addition to the engine-wide cap.
+func (e *Engine) WithSpaceOptions(spaces map[string]Options) *Engine {
+	e.spaces = e.spaces[:0]
+	for pattern, opts := range spaces {
+		if pattern == "" {
+			continue
+		}
+		e.spaces = append(e.spaces, spaceOverride{pattern: pattern, opts: opts})
+	}
+	sort.Slice(e.spaces, func(i, j int) bool { return e.spaces[i].pattern < e.spaces[j].pattern })
+	return e
+}
+
+// spaceFor returns the override governing space, if any.
+func (e *Engine) spaceFor(space string) (spaceOverride, bool) {
+	var best spaceOverride
+	found := false
+	for _, s := range e.spaces {
+		if s.pattern == space {
+			return s, true
+		}
+		if ok, _ := path.Match(s.pattern, space); ok && (!found || len(s.pattern) > len(best.pattern)) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// optionsFor returns the effective options for space.
+func (e *Engine) optionsFor(space string) Options {
+	if s, ok := e.spaceFor(space); ok {
+		return s.opts.inherit(e.opts)
+	}
+	return e.opts
+}
+
+// recordSpace is the space a stored record belongs to; records written
+// before spaces existed fall back to their session.
+func recordSpace(rec model.MemoryRecord) string {
+	if rec.Space != "" {
+		return rec.Space
+	}
+	if space := model.StringFromAny(model.DecodeMetadata(rec.Metadata)["space"]); space != "" {
+		return space
+	}
+	return rec.SessionID
+}
+
+// inherit fills the zero fields of o from base. Engine-level settings
+// (clock, summaries, drift, graph expansion, hybrid indexing) always come
+// from base.
+func (o Options) inherit(base Options) Options {
+	out := base
+	if o.Weights != (ScoreWeights{}) {
+		out.Weights = o.Weights
+	}
+	if o.LambdaMMR != 0 {
+		out.LambdaMMR = o.LambdaMMR
+	}
+	if o.HalfLife != 0 {
+		out.HalfLife = o.HalfLife
+	}
+	if o.TemporalWindow != 0 {
+		out.TemporalWindow = o.TemporalWindow
+	}
+	if o.TemporalPenalty != 0 {
+		out.TemporalPenalty = o.TemporalPenalty
+	}
+	if o.MinOlderThan != 0 {
+		out.MinOlderThan = o.MinOlderThan
+	}
+	if o.DuplicateSimilarity != 0 {
+		out.DuplicateSimilarity = o.DuplicateSimilarity
+	}
+	if o.DuplicateBorderline != 0 {
+		out.DuplicateBorderline = o.DuplicateBorderline
+	}
+	if o.DuplicateTextSimilarity != 0 {
+		out.DuplicateTextSimilarity = o.DuplicateTextSimilarity
+	}
+	if o.ConflictSimilarity != 0 {
+		out.ConflictSimilarity = o.ConflictSimilarity
+	}
+	if o.ConflictImportance != 0 {
+		out.ConflictImportance = o.ConflictImportance
+	}
+	if o.TTL != 0 {
+		out.TTL = o.TTL
+	}
+	if o.MaxSize != 0 {
+		out.MaxSize = o.MaxSize
+	}
+	if o.SourceBoost != nil {
+		out.SourceBoost = o.SourceBoost
+	}
+	return out
+}
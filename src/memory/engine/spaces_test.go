@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestSpaceOptionsPrecedence(t *testing.T) {
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{TTL: 30 * 24 * time.Hour}).WithSpaceOptions(map[string]Options{
+		"incident:*":      {TTL: 7 * 24 * time.Hour},
+		"incident:sev1-*": {TTL: 90 * 24 * time.Hour},
+		"incident:sev1-7": {TTL: time.Hour},
+	})
+	cases := map[string]time.Duration{
+		"incident:db-outage": 7 * 24 * time.Hour,
+		"incident:sev1-3":    90 * 24 * time.Hour,
+		"incident:sev1-7":    time.Hour,
+		"team:design":        30 * 24 * time.Hour,
+	}
+	for space, want := range cases {
+		if got := e.optionsFor(space).TTL; got != want {
+			t.Errorf("optionsFor(%q).TTL = %v, want %v", space, got, want)
+		}
+	}
+	if got := e.optionsFor("incident:x"); got.MaxSize != e.opts.MaxSize || got.Weights != e.opts.Weights {
+		t.Fatalf("unset override fields should inherit engine options: %+v", got)
+	}
+}
+
+func TestPruneAppliesSpaceTTL(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	for _, space := range []string{"team:design", "incident:42", "scratch"} {
+		if err := store.StoreMemory(ctx, "s", "note for "+space, map[string]any{"space": space}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().UTC().Add(10 * 24 * time.Hour)
+	e := NewEngine(store, Options{TTL: 9 * 24 * time.Hour, Clock: func() time.Time { return now }}).WithSpaceOptions(map[string]Options{
+		"team:design": {TTL: 90 * 24 * time.Hour},
+		"incident:*":  {TTL: 7 * 24 * time.Hour},
+	})
+	if err := e.Prune(ctx); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	left := spacesIn(t, store)
+	if len(left) != 1 || left["team:design"] != 1 {
+		t.Fatalf("expected only team:design to survive, got %v", left)
+	}
+}
+
+func TestPruneAppliesSpaceMaxSize(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	for i := 0; i < 4; i++ {
+		for _, space := range []string{"chat", "docs"} {
+			if err := store.StoreMemory(ctx, "s", fmt.Sprintf("%s entry %d", space, i), map[string]any{"space": space}, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	e := NewEngine(store, Options{}).WithSpaceOptions(map[string]Options{"chat": {MaxSize: 2}})
+	if err := e.Prune(ctx); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	left := spacesIn(t, store)
+	if left["chat"] != 2 || left["docs"] != 4 {
+		t.Fatalf("expected chat capped at 2 and docs untouched, got %v", left)
+	}
+	if got := e.MetricsSnapshot().SizeEvicted; got != 2 {
+		t.Fatalf("SizeEvicted = %d, want 2", got)
+	}
+}
+
+func spacesIn(t *testing.T, store *storepkg.InMemoryStore) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	if err := store.Iterate(context.Background(), func(rec model.MemoryRecord) bool {
+		counts[recordSpace(rec)]++
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return counts
+}
@@ -12,6 +12,14 @@ type Summarizer interface {
 	Summarize(ctx context.Context, cluster []model.MemoryRecord) (string, error)
 }
 
+// BatchSummarizer is implemented by summarizers that can summarize several
+// clusters in one call, such as an LLM that answers for all of them in a
+// single completion. The result holds one summary per cluster, in order.
+type BatchSummarizer interface {
+	Summarizer
+	SummarizeBatch(ctx context.Context, clusters [][]model.MemoryRecord) ([]string, error)
+}
+
 // HeuristicSummarizer produces deterministic summaries suitable for tests.
 type HeuristicSummarizer struct{}
 
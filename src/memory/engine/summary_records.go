@@ -0,0 +1,247 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// MetaSummaryOf marks a summary record and lists the IDs of the records it
+// summarises. The record also has an EdgeDerivedFrom edge to each of them.
+const MetaSummaryOf = "summary_of"
+
+// minSummaryMembers is the smallest cluster that gets a summary record; a
+// single record is its own summary.
+const minSummaryMembers = 2
+
+// SummaryReport describes one MaterializeSummaries pass.
+type SummaryReport struct {
+	// Scanned is the number of records examined.
+	Scanned int `json:"scanned"`
+	// Created and Updated count the summary records written and the ones
+	// whose member list changed; Removed counts stale ones deleted.
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// IsSummaryRecord reports whether rec is a summary record written by
+// MaterializeSummaries.
+func IsSummaryRecord(rec model.MemoryRecord) bool {
+	_, ok := model.DecodeMetadata(rec.Metadata)[MetaSummaryOf]
+	return ok
+}
+
+// MaterializeSummaries stores each cluster summary as a record of its own.
+// Every group of at least two records that share a tenant, session, space,
+// access list and summary gets one summary record holding the summary as
+// its content, with an EdgeDerivedFrom edge to each member and the member
+// IDs under MetaSummaryOf. Unlike Compact it keeps the members, so a
+// summary can be retrieved, ranked and pruned on its own and
+// SummaryMembers drills back into its members. The pass is idempotent: a
+// summary record whose members changed is relinked, and one whose cluster
+// is gone, because its members were pruned, compacted or re-summarised, is
+// deleted. Set Options.SummaryRecords to run it from the background pruner.
+func (e *Engine) MaterializeSummaries(ctx context.Context) (SummaryReport, error) {
+	if e.store == nil {
+		return SummaryReport{}, errors.New("memory engine has no store")
+	}
+	e.pruneMu.Lock()
+	defer e.pruneMu.Unlock()
+
+	var report SummaryReport
+	existing := map[string][]model.MemoryRecord{}
+	scanned, groups, err := e.summaryGroups(ctx, func(rec model.MemoryRecord) {
+		if IsSummaryRecord(rec) {
+			key := summaryGroupKey(model.RecordTenant(rec), rec.SessionID, recordSpace(rec), model.RecordACL(rec), rec.Content)
+			existing[key] = append(existing[key], rec)
+		}
+	})
+	report.Scanned = scanned
+	if err != nil {
+		return report, err
+	}
+
+	var stale []int64
+	for _, group := range groups {
+		ids := clusterIDs(group)
+		if len(ids) < minSummaryMembers {
+			continue
+		}
+		key := summaryGroupKey(group.tenant, group.sessionID, group.space, group.acl, group.summary)
+		recs := existing[key]
+		delete(existing, key)
+		if len(recs) > 0 {
+			for _, dup := range recs[1:] {
+				stale = append(stale, dup.ID)
+			}
+			current := recs[0]
+			if slices.Equal(summaryMemberIDs(current), ids) {
+				continue
+			}
+			relinked, err := e.relinkSummary(ctx, current, group, ids)
+			if err != nil {
+				return report, fmt.Errorf("relink summary %d: %w", current.ID, err)
+			}
+			if relinked {
+				report.Updated++
+				continue
+			}
+			// The store cannot rewrite metadata, so replace the record.
+			stale = append(stale, current.ID)
+		}
+		if err := e.storeSummary(ctx, group, ids); err != nil {
+			return report, fmt.Errorf("store summary of %d: %w", len(ids), err)
+		}
+		report.Created++
+	}
+	for _, recs := range existing {
+		for _, rec := range recs {
+			stale = append(stale, rec.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := e.store.DeleteMemory(ctx, stale); err != nil {
+			return report, err
+		}
+		e.forgetLexical(stale)
+		report.Removed = len(stale)
+	}
+	return report, nil
+}
+
+// SummaryMembers returns the records of the tenant on ctx that summary
+// summarises, in ID order. Members deleted since the summary was written
+// are skipped.
+func (e *Engine) SummaryMembers(ctx context.Context, summary model.MemoryRecord) ([]model.MemoryRecord, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	members, err := store.GetMemories(ctx, e.store, summaryMemberIDs(summary))
+	if err != nil {
+		return nil, err
+	}
+	return withoutTombstones(members, e.clock()), nil
+}
+
+// summaryMemberIDs returns the sorted member IDs of a summary record, from
+// its EdgeDerivedFrom edges.
+func summaryMemberIDs(rec model.MemoryRecord) []int64 {
+	edges := rec.GraphEdges
+	if len(edges) == 0 {
+		edges = model.ValidGraphEdges(model.DecodeMetadata(rec.Metadata))
+	}
+	var ids []int64
+	for _, edge := range edges {
+		if edge.Type == model.EdgeDerivedFrom {
+			ids = append(ids, edge.Target)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+func clusterIDs(cluster *compactionCluster) []int64 {
+	ids := make([]int64, 0, len(cluster.members))
+	for _, m := range cluster.members {
+		if m.id != 0 {
+			ids = append(ids, m.id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func summaryEdges(ids []int64) []model.GraphEdge {
+	edges := make([]model.GraphEdge, len(ids))
+	for i, id := range ids {
+		edges[i] = model.GraphEdge{Target: id, Type: model.EdgeDerivedFrom}
+	}
+	return edges
+}
+
+func (e *Engine) storeSummary(ctx context.Context, cluster *compactionCluster, ids []int64) error {
+	var importance float64
+	source := cluster.members[0].source
+	for _, m := range cluster.members {
+		importance = max(importance, m.importance)
+		if m.source != source {
+			source = "default"
+		}
+	}
+	if source == "" {
+		source = "default"
+	}
+	embedding, err := e.embed(ctx, cluster.summary)
+	if err != nil {
+		return fmt.Errorf("embed summary: %w", err)
+	}
+	now := e.clock().UTC()
+	edges := summaryEdges(ids)
+	metadata := map[string]any{
+		"space":         cluster.space,
+		"source":        source,
+		"summary":       cluster.summary,
+		"importance":    importance,
+		"last_embedded": now.Format(time.RFC3339Nano),
+		"graph_edges":   edges,
+		MetaSummaryOf:   ids,
+	}
+	cluster.acl.Apply(metadata)
+	if cluster.tenant != "" {
+		metadata[model.MetaTenant] = cluster.tenant
+	}
+	ctx = model.ContextWithTenant(ctx, cluster.tenant)
+	if err := e.store.StoreMemory(ctx, cluster.sessionID, cluster.summary, metadata, embedding); err != nil {
+		return err
+	}
+	stored := e.readBack(ctx, model.MemoryRecord{
+		SessionID:  cluster.sessionID,
+		Space:      cluster.space,
+		Content:    cluster.summary,
+		Metadata:   model.StringFromAny(metadata),
+		Embedding:  embedding,
+		Importance: importance,
+		Source:     source,
+		Summary:    cluster.summary,
+		CreatedAt:  now,
+		GraphEdges: edges,
+	})
+	e.observeLexical(stored)
+	if graphStore, ok := e.store.(store.GraphStore); ok && stored.ID != 0 {
+		if err := graphStore.UpsertGraph(ctx, stored, edges); err != nil {
+			e.logf("upsert graph: %v", err)
+		}
+	}
+	return nil
+}
+
+// relinkSummary points an existing summary record at ids. It reports false
+// when the store cannot update metadata.
+func (e *Engine) relinkSummary(ctx context.Context, rec model.MemoryRecord, cluster *compactionCluster, ids []int64) (bool, error) {
+	updater, ok := e.store.(store.MetadataUpdater)
+	if !ok {
+		return false, nil
+	}
+	meta := model.DecodeMetadata(rec.Metadata)
+	edges := summaryEdges(ids)
+	meta["graph_edges"] = edges
+	meta[MetaSummaryOf] = ids
+	rec.Metadata = model.StringFromAny(meta)
+	rec.GraphEdges = edges
+	ctx = model.ContextWithTenant(ctx, cluster.tenant)
+	if err := updater.UpdateMetadata(ctx, rec); err != nil {
+		return false, err
+	}
+	if graphStore, ok := e.store.(store.GraphStore); ok {
+		if err := graphStore.UpsertGraph(ctx, rec, edges); err != nil {
+			e.logf("upsert graph: %v", err)
+		}
+	}
+	return true, nil
+}
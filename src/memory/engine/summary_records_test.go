@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestMaterializeSummariesKeepsOneRecordPerCluster(t *testing.T) {
+	ctx := context.Background()
+	store := storepkg.NewInMemoryStore()
+	put := func(content, summary string) {
+		t.Helper()
+		if err := store.StoreMemory(ctx, "s", content, map[string]any{"summary": summary, "source": "chat"}, []float32{1, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("friday freeze note 1", "Deploys are frozen on Fridays")
+	put("friday freeze note 2", "Deploys are frozen on Fridays")
+	put("a lone note", "Lonely")
+
+	e := NewEngine(store, Options{EnableSummaries: false}).WithEmbedder(angleEmbedder{})
+	report, err := e.MaterializeSummaries(ctx)
+	if err != nil {
+		t.Fatalf("MaterializeSummaries: %v", err)
+	}
+	if report.Created != 1 || report.Removed != 0 {
+		t.Fatalf("first pass = %+v, want one summary record", report)
+	}
+	summaries := summaryRecords(t, store)
+	if len(summaries) != 1 || summaries[0].Content != "Deploys are frozen on Fridays" || summaries[0].Source != "chat" {
+		t.Fatalf("summary records = %+v", summaries)
+	}
+	members, err := e.SummaryMembers(ctx, summaries[0])
+	if err != nil || len(members) != 2 || members[0].Content != "friday freeze note 1" {
+		t.Fatalf("SummaryMembers = %+v, %v", members, err)
+	}
+
+	if report, _ := e.MaterializeSummaries(ctx); report.Created != 0 || report.Updated != 0 || report.Removed != 0 {
+		t.Fatalf("second pass = %+v, want nothing to do", report)
+	}
+
+	put("friday freeze note 3", "Deploys are frozen on Fridays")
+	if report, _ := e.MaterializeSummaries(ctx); report.Updated != 1 {
+		t.Fatalf("after a new member = %+v, want the summary relinked", report)
+	}
+	summaries = summaryRecords(t, store)
+	if ids := summaryMemberIDs(summaries[0]); len(ids) != 3 {
+		t.Fatalf("member ids = %v, want three", ids)
+	}
+
+	// Pruning the members leaves the summary without a cluster.
+	if err := store.DeleteMemory(ctx, summaryMemberIDs(summaries[0])); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ := e.MaterializeSummaries(ctx); report.Removed != 1 {
+		t.Fatalf("after pruning members = %+v, want the summary removed", report)
+	}
+	if got := summaryRecords(t, store); len(got) != 0 {
+		t.Fatalf("summary records left = %+v", got)
+	}
+}
+
+func summaryRecords(t *testing.T, store storepkg.VectorStore) []model.MemoryRecord {
+	t.Helper()
+	var out []model.MemoryRecord
+	if err := store.Iterate(context.Background(), func(rec model.MemoryRecord) bool {
+		if IsSummaryRecord(rec) {
+			out = append(out, rec)
+		}
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	slices.SortFunc(out, func(a, b model.MemoryRecord) int { return int(a.ID - b.ID) })
+	return out
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// ErrTenantMismatch is returned when a write names a tenant other than the
+// one on its context.
+var ErrTenantMismatch = errors.New("memory tenant does not match the context tenant")
+
+// StampTenant records the tenant on ctx in metadata. Tenants are chosen
+// through model.ContextWithTenant only: metadata naming another tenant, or
+// naming one when ctx has none, is rejected so that a caller cannot write
+// into a tenant it does not read from.
+func StampTenant(ctx context.Context, metadata map[string]any) error {
+	tenant := model.TenantFromContext(ctx)
+	if named := model.StringFromAny(metadata[model.MetaTenant]); named != "" && named != tenant {
+		return fmt.Errorf("%w: %q", ErrTenantMismatch, named)
+	}
+	if tenant == "" {
+		delete(metadata, model.MetaTenant)
+	} else {
+		metadata[model.MetaTenant] = tenant
+	}
+	return nil
+}
+
+// ownedBy reports whether rec belongs to the tenant on ctx.
+func ownedBy(ctx context.Context, rec model.MemoryRecord) bool {
+	return model.RecordTenant(rec) == model.TenantFromContext(ctx)
+}
+
+// tenantKey namespaces key by tenant so that keys of different tenants
+// never collide. The default tenant keeps key unchanged.
+func tenantKey(tenant, key string) string {
+	if tenant == "" {
+		return key
+	}
+	return tenant + "␟" + key
+}
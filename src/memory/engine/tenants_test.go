@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestTenantsAreIsolated(t *testing.T) {
+	bg := context.Background()
+	acme := model.ContextWithTenant(bg, "acme")
+	globex := model.ContextWithTenant(bg, "globex")
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(angleEmbedder{"deploy": 1})
+
+	a, err := e.Store(acme, "s", "deploy key is hunter2", nil)
+	if err != nil {
+		t.Fatalf("store acme: %v", err)
+	}
+	// The same content in another tenant is not a duplicate of acme's.
+	g, err := e.Store(globex, "s", "deploy key is hunter2", nil)
+	if err != nil {
+		t.Fatalf("store globex: %v", err)
+	}
+	if a.ID == 0 || g.ID == 0 || a.ID == g.ID {
+		t.Fatalf("expected two records, got %d and %d", a.ID, g.ID)
+	}
+	if model.RecordTenant(g) != "globex" {
+		t.Fatalf("record tenant = %q, want globex", model.RecordTenant(g))
+	}
+
+	for _, tc := range []struct {
+		ctx  context.Context
+		want int64
+	}{{acme, a.ID}, {globex, g.ID}} {
+		got, err := e.Retrieve(tc.ctx, "s", "deploy", 5)
+		if err != nil {
+			t.Fatalf("retrieve: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != tc.want {
+			t.Fatalf("tenant %s retrieved %+v, want only %d", model.TenantFromContext(tc.ctx), got, tc.want)
+		}
+	}
+	if got, _ := e.Retrieve(bg, "s", "deploy", 5); len(got) != 0 {
+		t.Fatalf("default tenant retrieved %+v", got)
+	}
+
+	if _, err := e.Update(globex, a.ID, "deploy key rotated", nil); !errors.Is(err, ErrMemoryNotFound) {
+		t.Fatalf("cross-tenant update error = %v, want ErrMemoryNotFound", err)
+	}
+	if _, err := e.Store(acme, "s", "note", map[string]any{model.MetaTenant: "globex"}); !errors.Is(err, ErrTenantMismatch) {
+		t.Fatalf("mismatched tenant error = %v, want ErrTenantMismatch", err)
+	}
+
+	if err := e.Prune(bg); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if n, _ := e.store.Count(bg); n != 2 {
+		t.Fatalf("prune left %d records, want both tenants' copies", n)
+	}
+}
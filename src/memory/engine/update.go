@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+// Metadata keys written by Update and Tombstone.
+const (
+	// MetaTombstonedAt holds the RFC 3339 time a record was tombstoned.
+	MetaTombstonedAt = "tombstoned_at"
+	// MetaTombstoneReason holds the reason given to Tombstone.
+	MetaTombstoneReason = "tombstone_reason"
+	// MetaSupersedes holds, on a corrected record, the ID of the version
+	// it replaced.
+	MetaSupersedes = "supersedes"
+	// MetaSupersededBy holds, on a replaced record, the ID of its
+	// correction.
+	MetaSupersededBy = "superseded_by"
+)
+
+var (
+	// ErrMemoryNotFound is returned when no stored record has the given ID.
+	ErrMemoryNotFound = errors.New("memory not found")
+	// ErrTombstoned is returned when updating a tombstoned record.
+	ErrTombstoned = errors.New("memory is tombstoned")
+)
+
+// Update corrects a stored memory. It writes a new version with content,
+// re-embedded, and the old record's metadata overlaid with metadata (a nil
+// value removes a key). The new version keeps the old graph edges and gains
+// an EdgeSupersedes edge to the old record, which is tombstoned rather than
+// deleted so History and RetrieveAsOf still see it. The store must
+// implement store.MetadataUpdater.
+func (e *Engine) Update(ctx context.Context, id int64, content string, metadata map[string]any) (model.MemoryRecord, error) {
+	if e.store == nil {
+		return model.MemoryRecord{}, errors.New("memory engine has no store")
+	}
+	if _, ok := e.store.(store.MetadataUpdater); !ok {
+		return model.MemoryRecord{}, store.ErrMetadataUpdatesUnsupported
+	}
+	old, err := e.lookup(ctx, id)
+	if err != nil {
+		return model.MemoryRecord{}, err
+	}
+	if isTombstoned(old, e.clock()) {
+		return model.MemoryRecord{}, fmt.Errorf("update memory %d: %w", id, ErrTombstoned)
+	}
+
+	meta := model.DecodeMetadata(old.Metadata)
+	// Derived values describe the old content and are recomputed.
+	for _, key := range []string{"summary", "last_embedded", model.EmbeddingMatrixKey, MetaSupersededBy, MetaFacts, MetaConflictsWith} {
+		delete(meta, key)
+	}
+	for key, value := range metadata {
+		if key == model.MetaTenant {
+			continue
+		}
+		if value == nil {
+			delete(meta, key)
+		} else {
+			meta[key] = value
+		}
+	}
+	// The correction settles any conflict the old version was flagged with.
+	var edges []model.GraphEdge
+	for _, edge := range model.ValidGraphEdges(model.DecodeMetadata(old.Metadata)) {
+		if edge.Type != model.EdgeConflictsWith {
+			edges = append(edges, edge)
+		}
+	}
+	edges = append(edges, model.GraphEdge{Target: old.ID, Type: model.EdgeSupersedes})
+	if facts, related := e.extractFacts(ctx, old.SessionID, content, edges); len(facts) > 0 {
+		meta[MetaFacts] = facts
+		edges = append(edges, related...)
+	}
+	meta["graph_edges"] = edges
+	meta[MetaSupersedes] = old.ID
+	if _, ok := meta["space"]; !ok && old.Space != "" {
+		meta["space"] = old.Space
+	}
+	now := e.clock().UTC()
+	meta["last_embedded"] = now.Format(time.RFC3339Nano)
+
+	embedding, err := e.embed(ctx, content)
+	if err != nil {
+		return model.MemoryRecord{}, fmt.Errorf("embed content: %w", err)
+	}
+	importance := importanceScore(content, meta)
+	meta["importance"] = importance
+	if err := e.store.StoreMemory(ctx, old.SessionID, content, meta, embedding); err != nil {
+		return model.MemoryRecord{}, err
+	}
+	stored := e.commitWrite(ctx, pendingWrite{
+		record: model.MemoryRecord{
+			SessionID:    old.SessionID,
+			Content:      content,
+			Metadata:     model.StringFromAny(meta),
+			Embedding:    embedding,
+			Importance:   importance,
+			Source:       model.StringFromAny(meta["source"]),
+			CreatedAt:    now,
+			LastEmbedded: now,
+		},
+		metadata: meta,
+		edges:    edges,
+	})
+	if err := e.tombstone(ctx, old, "superseded", map[string]any{MetaSupersededBy: stored.ID}); err != nil {
+		return stored, fmt.Errorf("tombstone memory %d: %w", id, err)
+	}
+	return stored, nil
+}
+
+// Tombstone soft-deletes a memory: the record stays in the store with
+// MetaTombstonedAt and MetaTombstoneReason set, but retrieval, duplicate
+// checks and compaction skip it from then on. Tombstoning an already
+// tombstoned record is a no-op. The store must implement
+// store.MetadataUpdater.
+func (e *Engine) Tombstone(ctx context.Context, id int64, reason string) error {
+	if e.store == nil {
+		return errors.New("memory engine has no store")
+	}
+	rec, err := e.lookup(ctx, id)
+	if err != nil {
+		return err
+	}
+	if isTombstoned(rec, e.clock()) {
+		return nil
+	}
+	return e.tombstone(ctx, rec, reason, nil)
+}
+
+func (e *Engine) tombstone(ctx context.Context, rec model.MemoryRecord, reason string, extra map[string]any) error {
+	u, ok := e.store.(store.MetadataUpdater)
+	if !ok {
+		return store.ErrMetadataUpdatesUnsupported
+	}
+	meta := model.DecodeMetadata(rec.Metadata)
+	meta[MetaTombstonedAt] = e.clock().UTC().Format(time.RFC3339Nano)
+	if reason != "" {
+		meta[MetaTombstoneReason] = reason
+	}
+	for key, value := range extra {
+		meta[key] = value
+	}
+	rec.Metadata = model.StringFromAny(meta)
+	if err := u.UpdateMetadata(ctx, rec); err != nil {
+		return err
+	}
+	e.forgetLexical([]int64{rec.ID})
+	e.forgetFacts([]int64{rec.ID})
+	return nil
+}
+
+// History returns every version of the memory id belongs to, newest first,
+// by following MetaSupersedes and MetaSupersededBy links. Tombstoned
+// versions are included.
+func (e *Engine) History(ctx context.Context, id int64) ([]model.MemoryRecord, error) {
+	if e.store == nil {
+		return nil, errors.New("memory engine has no store")
+	}
+	byID := map[int64]model.MemoryRecord{}
+	if err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.ID != 0 && ownedBy(ctx, rec) {
+			byID[rec.ID] = rec
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	rec, ok := byID[id]
+	if !ok {
+		return nil, fmt.Errorf("memory %d: %w", id, ErrMemoryNotFound)
+	}
+	seen := map[int64]bool{id: true}
+	// Walk forward to the newest version, then back through every older one.
+	for {
+		next, ok := byID[metaID(rec, MetaSupersededBy)]
+		if !ok || seen[next.ID] {
+			break
+		}
+		seen[next.ID] = true
+		rec = next
+	}
+	versions := []model.MemoryRecord{rec}
+	visited := map[int64]bool{rec.ID: true}
+	for {
+		prev, ok := byID[metaID(rec, MetaSupersedes)]
+		if !ok || visited[prev.ID] {
+			break
+		}
+		visited[prev.ID] = true
+		versions = append(versions, prev)
+		rec = prev
+	}
+	return versions, nil
+}
+
+// lookup finds a record of the tenant on ctx by ID with a scan of the
+// store.
+func (e *Engine) lookup(ctx context.Context, id int64) (model.MemoryRecord, error) {
+	var found *model.MemoryRecord
+	if err := e.store.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.ID == id {
+			found = &rec
+			return false
+		}
+		return true
+	}); err != nil {
+		return model.MemoryRecord{}, err
+	}
+	// Another tenant's record is reported missing rather than forbidden.
+	if found == nil || !ownedBy(ctx, *found) {
+		return model.MemoryRecord{}, fmt.Errorf("memory %d: %w", id, ErrMemoryNotFound)
+	}
+	return *found, nil
+}
+
+// isTombstoned reports whether rec had been tombstoned at t.
+func isTombstoned(rec model.MemoryRecord, t time.Time) bool {
+	at := model.TimeFromAny(model.DecodeMetadata(rec.Metadata)[MetaTombstonedAt])
+	return !at.IsZero() && !at.After(t)
+}
+
+// withoutTombstones returns the records not yet tombstoned at t.
+func withoutTombstones(records []model.MemoryRecord, t time.Time) []model.MemoryRecord {
+	out := make([]model.MemoryRecord, 0, len(records))
+	for _, rec := range records {
+		if !isTombstoned(rec, t) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+func metaID(rec model.MemoryRecord, key string) int64 {
+	return int64(model.FloatFromAny(model.DecodeMetadata(rec.Metadata)[key]))
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	storepkg "github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestUpdateSupersedesAndTombstones(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(storepkg.NewInMemoryStore(), Options{}).WithEmbedder(angleEmbedder{
+		"the office is in Berlin": 1,
+		"the office is in Munich": 0.6,
+		"lunch is at noon":        0.1,
+	})
+	note, err := e.Store(ctx, "s", "lunch is at noon", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fact, err := e.Store(ctx, "s", "the office is in Berlin", map[string]any{
+		"source":      "chat",
+		"graph_edges": []model.GraphEdge{{Target: note.ID, Type: model.EdgeFollows}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := e.Update(ctx, fact.ID, "the office is in Munich", map[string]any{"source": "hr"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.ID == 0 || updated.ID == fact.ID || updated.Source != "hr" {
+		t.Fatalf("unexpected new version: %+v", updated)
+	}
+	if len(updated.GraphEdges) != 2 || updated.GraphEdges[0].Target != note.ID || updated.GraphEdges[1] != (model.GraphEdge{Target: fact.ID, Type: model.EdgeSupersedes}) {
+		t.Fatalf("edges not carried over: %+v", updated.GraphEdges)
+	}
+
+	results, err := e.Retrieve(ctx, "s", "the office is in Berlin", 5)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	var sawUpdate bool
+	for _, rec := range results {
+		if rec.ID == fact.ID {
+			t.Fatal("superseded record was retrieved")
+		}
+		sawUpdate = sawUpdate || rec.ID == updated.ID
+	}
+	if !sawUpdate {
+		t.Fatalf("correction not retrieved: %+v", results)
+	}
+
+	history, err := e.History(ctx, fact.ID)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 2 || history[0].ID != updated.ID || history[1].ID != fact.ID {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if meta := model.DecodeMetadata(history[1].Metadata); meta[MetaTombstoneReason] != "superseded" || metaID(history[1], MetaSupersededBy) != updated.ID {
+		t.Fatalf("old version not tombstoned: %v", meta)
+	}
+	if _, err := e.Update(ctx, fact.ID, "the office is in Hamburg", nil); !errors.Is(err, ErrTombstoned) {
+		t.Fatalf("expected ErrTombstoned, got %v", err)
+	}
+	if _, err := e.Update(ctx, 999, "x", nil); !errors.Is(err, ErrMemoryNotFound) {
+		t.Fatalf("expected ErrMemoryNotFound, got %v", err)
+	}
+
+	if err := e.Tombstone(ctx, note.ID, "wrong"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+	again, err := e.Store(ctx, "s", "lunch is at noon", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID == note.ID {
+		t.Fatal("new write was deduplicated onto a tombstone")
+	}
+}
+
+func TestUpdateNeedsMetadataUpdates(t *testing.T) {
+	ctx := context.Background()
+	s := struct{ storepkg.VectorStore }{storepkg.NewInMemoryStore()}
+	e := NewEngine(s, Options{}).WithEmbedder(angleEmbedder{})
+	rec, _ := e.Store(ctx, "s", "a fact", nil)
+	if _, err := e.Update(ctx, rec.ID, "a better fact", nil); !errors.Is(err, storepkg.ErrMetadataUpdatesUnsupported) {
+		t.Fatalf("expected ErrMetadataUpdatesUnsupported, got %v", err)
+	}
+}
@@ -13,39 +13,106 @@ import (
 
 // Type aliases preserving the original public API.
 type (
-	Engine              = memengine.Engine
-	Options             = memengine.Options
-	ScoreWeights        = memengine.ScoreWeights
-	Metrics             = memengine.Metrics
-	MetricsSnapshot     = memengine.MetricsSnapshot
-	Summarizer          = memengine.Summarizer
-	HeuristicSummarizer = memengine.HeuristicSummarizer
-
-	MemoryRecord = model.MemoryRecord
-	GraphEdge    = model.GraphEdge
-	EdgeType     = model.EdgeType
-
-	MemoryBank    = sessionpkg.MemoryBank
-	SessionMemory = sessionpkg.SessionMemory
-	SpaceRegistry = sessionpkg.SpaceRegistry
-	SpaceRole     = sessionpkg.SpaceRole
-	Space         = sessionpkg.Space
-	SharedSession = sessionpkg.SharedSession
+	Engine                = memengine.Engine
+	Options               = memengine.Options
+	ScoreWeights          = memengine.ScoreWeights
+	Metrics               = memengine.Metrics
+	MetricsSnapshot       = memengine.MetricsSnapshot
+	Summarizer            = memengine.Summarizer
+	BatchSummarizer       = memengine.BatchSummarizer
+	HeuristicSummarizer   = memengine.HeuristicSummarizer
+	SimilarSession        = memengine.SimilarSession
+	Fusion                = memengine.Fusion
+	DuplicateJudge        = memengine.DuplicateJudge
+	DuplicateJudgeFunc    = memengine.DuplicateJudgeFunc
+	CompactionReport      = memengine.CompactionReport
+	SummaryReport         = memengine.SummaryReport
+	MemoryInput           = memengine.MemoryInput
+	ImportReport          = memengine.ImportReport
+	Fact                  = memengine.Fact
+	FactExtractor         = memengine.FactExtractor
+	FactExtractorFunc     = memengine.FactExtractorFunc
+	KnownFact             = memengine.KnownFact
+	ConflictJudge         = memengine.ConflictJudge
+	ConflictJudgeFunc     = memengine.ConflictJudgeFunc
+	Conflict              = memengine.Conflict
+	EdgeInferrer          = memengine.EdgeInferrer
+	EdgeInferrerFunc      = memengine.EdgeInferrerFunc
+	HeuristicEdgeInferrer = memengine.HeuristicEdgeInferrer
+	ImportanceScorer      = memengine.ImportanceScorer
+	ScoreBreakdown        = memengine.ScoreBreakdown
+	Explanation           = memengine.Explanation
+	SourceLearner         = memengine.SourceLearner
+	SourceStat            = memengine.SourceStat
+	GraphFormat           = memengine.GraphFormat
+	GraphExportOptions    = memengine.GraphExportOptions
+	GraphNode             = memengine.GraphNode
+	GraphLink             = memengine.GraphLink
+	ImportanceScorerFunc  = memengine.ImportanceScorerFunc
+
+	MemoryRecord   = model.MemoryRecord
+	Identity       = model.Identity
+	ACL            = model.ACL
+	GraphEdge      = model.GraphEdge
+	EdgeType       = model.EdgeType
+	GraphQuery     = model.GraphQuery
+	GraphPath      = model.GraphPath
+	GraphHop       = model.GraphHop
+	GraphDirection = model.GraphDirection
+	Filter         = model.Filter
+
+	MemoryBank      = sessionpkg.MemoryBank
+	SessionMemory   = sessionpkg.SessionMemory
+	SpaceRegistry   = sessionpkg.SpaceRegistry
+	SpaceRole       = sessionpkg.SpaceRole
+	Space           = sessionpkg.Space
+	SharedSession   = sessionpkg.SharedSession
+	ConflictPolicy  = sessionpkg.ConflictPolicy
+	BlackboardEntry = sessionpkg.BlackboardEntry
 
 	VectorStore       = storepkg.VectorStore
 	SchemaInitializer = storepkg.SchemaInitializer
+	ImportanceUpdater = storepkg.ImportanceUpdater
+	MetadataUpdater   = storepkg.MetadataUpdater
 	GraphStore        = storepkg.GraphStore
+	GraphQuerier      = storepkg.GraphQuerier
+	BatchStore        = storepkg.BatchStore
+	MemoryWrite       = storepkg.MemoryWrite
+	FilteredSearcher  = storepkg.FilteredSearcher
+	RecordGetter      = storepkg.RecordGetter
 
 	InMemoryStore           = storepkg.InMemoryStore
 	PostgresStore           = storepkg.PostgresStore
+	PostgresIndex           = storepkg.PostgresIndex
+	PostgresOptions         = storepkg.PostgresOptions
 	QdrantStore             = storepkg.QdrantStore
+	QdrantGRPCStore         = storepkg.QdrantGRPCStore
 	Neo4jStore              = storepkg.Neo4jStore
 	MongoStore              = storepkg.MongoStore
 	Distance                = storepkg.Distance
 	CreateCollectionRequest = storepkg.CreateCollectionRequest
+	DimensionMismatchError  = storepkg.DimensionMismatchError
+	ShadowStore             = storepkg.ShadowStore
+	ShadowOptions           = storepkg.ShadowOptions
+	ShadowDiff              = storepkg.ShadowDiff
+	TieredStore             = storepkg.TieredStore
+	TieredOptions           = storepkg.TieredOptions
+	IntegrityStore          = storepkg.IntegrityStore
+	IntegrityOptions        = storepkg.IntegrityOptions
+	IntegrityFinding        = storepkg.IntegrityFinding
+	QuarantinedMemory       = storepkg.QuarantinedMemory
+	EncryptedStore          = storepkg.EncryptedStore
+	ContentEncryptor        = storepkg.ContentEncryptor
+	AESGCMEncryptor         = storepkg.AESGCMEncryptor
+	KeyProvider             = storepkg.KeyProvider
+	StaticKeys              = storepkg.StaticKeys
 
-	Embedder      = embedpkg.Embedder
-	DummyEmbedder = embedpkg.DummyEmbedder
+	Embedder        = embedpkg.Embedder
+	ImageEmbedder   = embedpkg.ImageEmbedder
+	BatchEmbedder   = embedpkg.BatchEmbedder
+	DummyEmbedder   = embedpkg.DummyEmbedder
+	FallbackMode    = embedpkg.FallbackMode
+	FallbackWarning = embedpkg.FallbackWarning
 )
 type MarkdownStore = markdownpkg.Store
 type MarkdownRecord = markdownpkg.Record
@@ -53,28 +120,100 @@ type MarkdownRecord = markdownpkg.Record
 var NewMarkdownStore = markdownpkg.NewStore
 
 const (
-	EdgeFollows     = model.EdgeFollows
-	EdgeExplains    = model.EdgeExplains
-	EdgeContradicts = model.EdgeContradicts
-	EdgeDerivedFrom = model.EdgeDerivedFrom
+	EdgeFollows       = model.EdgeFollows
+	EdgeExplains      = model.EdgeExplains
+	EdgeContradicts   = model.EdgeContradicts
+	EdgeDerivedFrom   = model.EdgeDerivedFrom
+	EdgeSupersedes    = model.EdgeSupersedes
+	EdgeRelatesTo     = model.EdgeRelatesTo
+	EdgeConflictsWith = model.EdgeConflictsWith
+	EdgeSummarizes    = model.EdgeSummarizes
+
+	GraphBoth     = model.GraphBoth
+	GraphOutgoing = model.GraphOutgoing
+	GraphIncoming = model.GraphIncoming
+
+	EmbeddingMatrixKey = model.EmbeddingMatrixKey
+
+	MetaACLPrincipals = model.MetaACLPrincipals
+	MetaACLGroups     = model.MetaACLGroups
+	MetaTenant        = model.MetaTenant
+	MetaTags          = model.MetaTags
 
 	SpaceRoleReader = sessionpkg.SpaceRoleReader
 	SpaceRoleWriter = sessionpkg.SpaceRoleWriter
 	SpaceRoleAdmin  = sessionpkg.SpaceRoleAdmin
+
+	FusionWeighted = memengine.FusionWeighted
+	FusionRRF      = memengine.FusionRRF
+
+	OriginVector  = memengine.OriginVector
+	OriginLexical = memengine.OriginLexical
+	OriginGraph   = memengine.OriginGraph
+
+	MetaCompactedFrom   = memengine.MetaCompactedFrom
+	MetaSummaryOf       = memengine.MetaSummaryOf
+	MetaChunkIDs        = memengine.MetaChunkIDs
+	MetaTombstonedAt    = memengine.MetaTombstonedAt
+	MetaTombstoneReason = memengine.MetaTombstoneReason
+	MetaSupersedes      = memengine.MetaSupersedes
+	MetaSupersededBy    = memengine.MetaSupersededBy
+	MetaFacts           = memengine.MetaFacts
+	MetaConflictsWith   = memengine.MetaConflictsWith
+	MetaEdgesDecayedAt  = memengine.MetaEdgesDecayedAt
+	SnapshotFormat      = memengine.SnapshotFormat
+	GraphDOT            = memengine.GraphDOT
+	GraphML             = memengine.GraphML
+	GraphJSON           = memengine.GraphJSON
+	SnapshotVersion     = memengine.SnapshotVersion
+
+	LastWriterWins     = sessionpkg.LastWriterWins
+	AppendAll          = sessionpkg.AppendAll
+	MetaCompactedTurns = sessionpkg.MetaCompactedTurns
+
+	DefaultQdrantUpsertBatch = storepkg.DefaultQdrantUpsertBatch
+
+	PostgresIndexIVFFlat = storepkg.PostgresIndexIVFFlat
+	PostgresIndexHNSW    = storepkg.PostgresIndexHNSW
+	PostgresIndexNone    = storepkg.PostgresIndexNone
+
+	DefaultPostgresCopyThreshold = storepkg.DefaultPostgresCopyThreshold
 )
 
 var (
-	ErrNotSupported = embedpkg.ErrNotSupported
+	ErrNotSupported               = embedpkg.ErrNotSupported
+	ErrEmbedderUnavailable        = embedpkg.ErrEmbedderUnavailable
+	ErrDimensionMismatch          = storepkg.ErrDimensionMismatch
+	ErrUnknownSession             = memengine.ErrUnknownSession
+	ErrMemoryNotFound             = memengine.ErrMemoryNotFound
+	ErrTenantMismatch             = memengine.ErrTenantMismatch
+	ErrTombstoned                 = memengine.ErrTombstoned
+	ErrMetadataUpdatesUnsupported = storepkg.ErrMetadataUpdatesUnsupported
+	ErrQuarantined                = storepkg.ErrQuarantined
+
+	ContextWithIdentity = model.ContextWithIdentity
+	IdentityFromContext = model.IdentityFromContext
+	ContextWithTenant   = model.ContextWithTenant
+	TenantFromContext   = model.TenantFromContext
+	RecordACL           = model.RecordACL
+	RecordFacts         = memengine.RecordFacts
+	IsSummaryRecord     = memengine.IsSummaryRecord
+	FilterVisible       = model.FilterVisible
+	FilterTenant        = model.FilterTenant
 
 	NewEngine              = memengine.NewEngine
+	NewSourceLearner       = memengine.NewSourceLearner
 	DefaultOptions         = memengine.DefaultOptions
 	NewMemoryBank          = sessionpkg.NewMemoryBank
 	NewMemoryBankWithStore = sessionpkg.NewMemoryBankWithStore
 	NewSessionMemory       = sessionpkg.NewSessionMemory
 	NewSharedSession       = sessionpkg.NewSharedSession
 	NewSpaceRegistry       = sessionpkg.NewSpaceRegistry
+	SeqOf                  = sessionpkg.SeqOf
+	SortBySeq              = sessionpkg.SortBySeq
 
 	AutoEmbedder        = embedpkg.AutoEmbedder
+	ResolveEmbedder     = embedpkg.ResolveEmbedder
 	DummyEmbedding      = embedpkg.DummyEmbedding
 	NewOpenAIEmbedder   = embedpkg.NewOpenAIEmbedder
 	NewVertexAIEmbedder = embedpkg.NewVertexAIEmbedder
@@ -82,11 +221,28 @@ var (
 	NewFastEmbeed       = embedpkg.NewFastEmbeed
 	NewClaudeEmbedder   = embedpkg.NewClaudeEmbedder
 
-	NewInMemoryStore = storepkg.NewInMemoryStore
-	NewPostgresStore = storepkg.NewPostgresStore
-	NewQdrantStore   = storepkg.NewQdrantStore
-	NewNeo4jStore    = storepkg.NewNeo4jStore
-	NewMongoStore    = storepkg.NewMongoStore
+	NewVoyageMultimodalEmbedder = embedpkg.NewVoyageMultimodalEmbedder
+	NewVertexMultimodalEmbedder = embedpkg.NewVertexMultimodalEmbedder
+
+	NewInMemoryStore   = storepkg.NewInMemoryStore
+	NewPostgresStore   = storepkg.NewPostgresStore
+	NewQdrantStore     = storepkg.NewQdrantStore
+	NewQdrantStoreGRPC = storepkg.NewQdrantStoreGRPC
+	NewNeo4jStore      = storepkg.NewNeo4jStore
+	NewMongoStore      = storepkg.NewMongoStore
+	NewShadowStore     = storepkg.NewShadowStore
+	NewTieredStore     = storepkg.NewTieredStore
+	NewIntegrityStore  = storepkg.NewIntegrityStore
+	NewEncryptedStore  = storepkg.NewEncryptedStore
+	NewAESGCMEncryptor = storepkg.NewAESGCMEncryptor
+	StoreMemories      = storepkg.StoreMemories
+	SearchFiltered     = storepkg.SearchFiltered
+	GetMemories        = storepkg.GetMemories
+	QueryGraph         = storepkg.QueryGraph
+	EmbedMany          = embedpkg.EmbedMany
+
+	NewPostgresStoreWithOptions = storepkg.NewPostgresStoreWithOptions
+	NewInMemoryStoreFromWAL     = storepkg.NewInMemoryStoreFromWAL
 )
 
 // ChunkText splits long text into roughly `chunkSize` rune segments
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"context"
+	"strings"
+)
+
+// Metadata keys holding a record's access list.
+const (
+	MetaACLPrincipals = "acl_principals"
+	MetaACLGroups     = "acl_groups"
+)
+
+// Identity is who a retrieval runs on behalf of.
+type Identity struct {
+	Principal string   `json:"principal"`
+	Groups    []string `json:"groups,omitempty"`
+}
+
+// ACL restricts a record to the listed principals and members of the listed
+// groups. A record without one is visible to everybody.
+type ACL struct {
+	Principals []string `json:"principals,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+}
+
+// IsZero reports whether the ACL restricts nothing.
+func (a ACL) IsZero() bool { return len(a.Principals) == 0 && len(a.Groups) == 0 }
+
+// Allows reports whether id may see a record guarded by a. Principals and
+// groups compare case-insensitively.
+func (a ACL) Allows(id Identity) bool {
+	if a.IsZero() {
+		return true
+	}
+	if id.Principal != "" && containsFold(a.Principals, id.Principal) {
+		return true
+	}
+	for _, g := range id.Groups {
+		if g != "" && containsFold(a.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply writes the ACL into record metadata, leaving meta alone when the
+// ACL is empty.
+func (a ACL) Apply(meta map[string]any) {
+	if len(a.Principals) > 0 {
+		meta[MetaACLPrincipals] = append([]string(nil), a.Principals...)
+	}
+	if len(a.Groups) > 0 {
+		meta[MetaACLGroups] = append([]string(nil), a.Groups...)
+	}
+}
+
+// RecordACL reads the ACL stamped on rec. Lists may be stored as JSON
+// arrays or comma-separated strings.
+func RecordACL(rec MemoryRecord) ACL {
+	meta := DecodeMetadata(rec.Metadata)
+	return ACL{Principals: stringList(meta[MetaACLPrincipals]), Groups: stringList(meta[MetaACLGroups])}
+}
+
+type identityContextKey struct{}
+
+// ContextWithIdentity makes retrievals under ctx run on behalf of id.
+func ContextWithIdentity(ctx context.Context, id Identity) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+// IdentityFromContext returns the identity attached to ctx.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	if ctx == nil {
+		return Identity{}, false
+	}
+	id, ok := ctx.Value(identityContextKey{}).(Identity)
+	return id, ok
+}
+
+// FilterVisible drops the records the identity on ctx may not see. Without
+// an identity only unrestricted records remain.
+func FilterVisible(ctx context.Context, records []MemoryRecord) []MemoryRecord {
+	id, _ := IdentityFromContext(ctx)
+	out := make([]MemoryRecord, 0, len(records))
+	for _, rec := range records {
+		if RecordACL(rec).Allows(id) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+func stringList(v any) []string {
+	var out []string
+	switch t := v.(type) {
+	case []string:
+		out = t
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	case string:
+		out = strings.Split(t, ",")
+	}
+	var cleaned []string
+	for _, s := range out {
+		if s = strings.TrimSpace(s); s != "" {
+			cleaned = append(cleaned, s)
+		}
+	}
+	return cleaned
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
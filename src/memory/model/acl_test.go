@@ -0,0 +1,58 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestACLAllows(t *testing.T) {
+	acl := ACL{Principals: []string{"alice@example.com"}, Groups: []string{"eng"}}
+	cases := []struct {
+		id   Identity
+		want bool
+	}{
+		{Identity{Principal: "Alice@Example.com"}, true},
+		{Identity{Principal: "bob", Groups: []string{"ENG"}}, true},
+		{Identity{Principal: "bob", Groups: []string{"sales"}}, false},
+		{Identity{}, false},
+	}
+	for _, tc := range cases {
+		if got := acl.Allows(tc.id); got != tc.want {
+			t.Errorf("Allows(%+v) = %v, want %v", tc.id, got, tc.want)
+		}
+	}
+	if !(ACL{}).Allows(Identity{}) {
+		t.Error("an empty ACL should allow everyone")
+	}
+}
+
+func TestFilterVisible(t *testing.T) {
+	meta := map[string]any{}
+	ACL{Principals: []string{"alice"}}.Apply(meta)
+	raw, _ := json.Marshal(meta)
+	records := []MemoryRecord{
+		{ID: 1, Content: "public"},
+		{ID: 2, Content: "private", Metadata: string(raw)},
+		{ID: 3, Content: "listed", Metadata: `{"acl_groups":"eng, ops"}`},
+	}
+
+	ids := func(recs []MemoryRecord) []int64 {
+		var out []int64
+		for _, r := range recs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	if got := ids(FilterVisible(context.Background(), records)); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("anonymous retrieval saw %v, want [1]", got)
+	}
+	alice := ContextWithIdentity(context.Background(), Identity{Principal: "alice"})
+	if got := ids(FilterVisible(alice, records)); len(got) != 2 || got[1] != 2 {
+		t.Fatalf("alice saw %v, want [1 2]", got)
+	}
+	ops := ContextWithIdentity(context.Background(), Identity{Principal: "carol", Groups: []string{"ops"}})
+	if got := ids(FilterVisible(ops, records)); len(got) != 2 || got[1] != 3 {
+		t.Fatalf("ops saw %v, want [1 3]", got)
+	}
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// MetaTags is the metadata key holding a record's tags.
+const MetaTags = "tags"
+
+// Filter narrows a search to records with particular attributes. Within a
+// field a record matches when it has any of the listed values; Spaces and
+// Sessions together match a record in any of them. Empty fields and zero
+// times match everything.
+type Filter struct {
+	Spaces   []string
+	Sessions []string
+	Sources  []string
+	Tags     []string
+	// Since and Until bound CreatedAt, inclusively.
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero reports whether f matches every record.
+func (f Filter) IsZero() bool {
+	return len(f.Spaces) == 0 && len(f.Sessions) == 0 && len(f.Sources) == 0 && len(f.Tags) == 0 && f.Since.IsZero() && f.Until.IsZero()
+}
+
+// Scopes lists the sessions and spaces f names, sessions first.
+func (f Filter) Scopes() []string {
+	return append(append([]string(nil), f.Sessions...), f.Spaces...)
+}
+
+// Matches reports whether rec passes f.
+func (f Filter) Matches(rec MemoryRecord) bool {
+	if len(f.Spaces) > 0 || len(f.Sessions) > 0 {
+		space := rec.Space
+		if space == "" {
+			space = rec.SessionID
+		}
+		if !slices.Contains(f.Spaces, space) && !slices.Contains(f.Sessions, rec.SessionID) {
+			return false
+		}
+	}
+	if len(f.Sources) > 0 && !slices.Contains(f.Sources, rec.Source) {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		found := false
+		for _, tag := range RecordTags(rec) {
+			if slices.Contains(f.Tags, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && rec.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && rec.CreatedAt.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// RecordTags reads the tags stored in rec's metadata, as a JSON array or a
+// comma-separated string.
+func RecordTags(rec MemoryRecord) []string {
+	return stringList(DecodeMetadata(rec.Metadata)[MetaTags])
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatches(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rec := MemoryRecord{SessionID: "alice", Space: "team:eng", Source: "slack", CreatedAt: at, Metadata: `{"tags":["deploy","infra"]}`}
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"zero", Filter{}, true},
+		{"space", Filter{Spaces: []string{"team:eng"}}, true},
+		{"session or space", Filter{Spaces: []string{"team:design"}, Sessions: []string{"alice"}}, true},
+		{"other scope", Filter{Spaces: []string{"team:design"}, Sessions: []string{"bob"}}, false},
+		{"source", Filter{Sources: []string{"notion", "slack"}}, true},
+		{"other source", Filter{Sources: []string{"notion"}}, false},
+		{"any tag", Filter{Tags: []string{"infra", "billing"}}, true},
+		{"missing tag", Filter{Tags: []string{"billing"}}, false},
+		{"inside range", Filter{Since: at.Add(-time.Hour), Until: at}, true},
+		{"too old", Filter{Since: at.Add(time.Minute)}, false},
+		{"too new", Filter{Until: at.Add(-time.Minute)}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.filter.Matches(rec); got != tc.want {
+			t.Errorf("%s: Matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
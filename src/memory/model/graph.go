@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"time"
 )
 
 // EdgeType enumerates supported knowledge graph relationships between memories.
@@ -15,19 +17,66 @@ const (
 	EdgeExplains    EdgeType = "explains"
 	EdgeContradicts EdgeType = "contradicts"
 	EdgeDerivedFrom EdgeType = "derived_from"
+	// EdgeSupersedes links a corrected memory to the version it replaced.
+	EdgeSupersedes EdgeType = "supersedes"
+	// EdgeRelatesTo links memories with extracted facts about a shared
+	// entity.
+	EdgeRelatesTo EdgeType = "relates_to"
+	// EdgeConflictsWith links a memory to an earlier one the engine's
+	// conflict judge found it contradicts. Unlike EdgeContradicts, which
+	// callers assert, it marks a conflict awaiting resolution.
+	EdgeConflictsWith EdgeType = "conflicts_with"
+	// EdgeSummarizes links a compacted conversation summary to the turns
+	// it replaced in short-term memory.
+	EdgeSummarizes EdgeType = "summarizes"
 )
 
 var validEdgeTypes = map[EdgeType]struct{}{
-	EdgeFollows:     {},
-	EdgeExplains:    {},
-	EdgeContradicts: {},
-	EdgeDerivedFrom: {},
+	EdgeFollows:       {},
+	EdgeExplains:      {},
+	EdgeContradicts:   {},
+	EdgeDerivedFrom:   {},
+	EdgeSupersedes:    {},
+	EdgeRelatesTo:     {},
+	EdgeConflictsWith: {},
+	EdgeSummarizes:    {},
 }
 
 // GraphEdge represents a typed, directed connection between two memory nodes.
+// Weight is how strongly the memories are related; zero means the edge was
+// stored without one and counts as 1. LastReinforced is when the two
+// memories were last retrieved together.
 type GraphEdge struct {
-	Target int64    `json:"target"`
-	Type   EdgeType `json:"type"`
+	Target         int64     `json:"target"`
+	Type           EdgeType  `json:"type"`
+	Weight         float64   `json:"weight,omitempty"`
+	LastReinforced time.Time `json:"last_reinforced,omitzero"`
+}
+
+// Strength returns the edge's weight, 1 for an unweighted edge.
+func (g GraphEdge) Strength() float64 {
+	if g.Weight == 0 {
+		return 1
+	}
+	return g.Weight
+}
+
+// Reinforce moves the edge's weight a rate of the way towards 1 and marks
+// it reinforced at now.
+func (g GraphEdge) Reinforce(now time.Time, rate float64) GraphEdge {
+	s := g.Strength()
+	g.Weight = s + rate*(1-s)
+	g.LastReinforced = now
+	return g
+}
+
+// Decay halves the edge's weight every halfLife of elapsed.
+func (g GraphEdge) Decay(elapsed, halfLife time.Duration) GraphEdge {
+	if halfLife <= 0 || elapsed <= 0 {
+		return g
+	}
+	g.Weight = g.Strength() * math.Exp2(-float64(elapsed)/float64(halfLife))
+	return g
 }
 
 // Validate ensures the edge definition is usable.
@@ -104,6 +153,8 @@ func decodeSingleEdge(raw any) GraphEdge {
 		if typ, ok := edge["type"].(string); ok {
 			ge.Type = EdgeType(typ)
 		}
+		ge.Weight, _ = edge["weight"].(float64)
+		ge.LastReinforced = TimeFromAny(edge["last_reinforced"])
 		return ge
 	case []any:
 		if len(edge) == 2 {
@@ -0,0 +1,90 @@
+package model
+
+// GraphDirection selects which edges a graph query follows from a node.
+type GraphDirection string
+
+const (
+	// GraphBoth follows edges either way. It is the default.
+	GraphBoth GraphDirection = ""
+	// GraphOutgoing follows edges from the node to their targets.
+	GraphOutgoing GraphDirection = "out"
+	// GraphIncoming follows edges pointing at the node back to their source.
+	GraphIncoming GraphDirection = "in"
+)
+
+// GraphQuery walks the memory graph from Seeds and returns the path to each
+// memory it reaches, shortest first.
+type GraphQuery struct {
+	Seeds []int64
+	// SessionID keeps the walk to one session's memories; empty allows all.
+	SessionID string
+	// EdgeTypes limits the walk to these relationships; empty allows all.
+	EdgeTypes []EdgeType
+	Direction GraphDirection
+	// MinWeight skips edges whose Strength is below it.
+	MinWeight float64
+	// MaxHops bounds the length of a path. It defaults to 1.
+	MaxHops int
+	// Targets, when set, keeps only the paths that end at one of them, so
+	// the query answers how memories connect.
+	Targets []int64
+	// Limit caps the number of paths. It defaults to 50.
+	Limit int
+}
+
+const defaultGraphQueryLimit = 50
+
+// Normalized returns q with its defaults filled in.
+func (q GraphQuery) Normalized() GraphQuery {
+	if q.MaxHops <= 0 {
+		q.MaxHops = 1
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultGraphQueryLimit
+	}
+	return q
+}
+
+// Allows reports whether the query may follow link.
+func (q GraphQuery) Allows(link GraphHop) bool {
+	if link.Strength() < q.MinWeight {
+		return false
+	}
+	if len(q.EdgeTypes) == 0 {
+		return true
+	}
+	for _, t := range q.EdgeTypes {
+		if t == link.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// GraphHop is one stored edge of a path, From one memory To another.
+type GraphHop struct {
+	From   int64    `json:"from"`
+	To     int64    `json:"to"`
+	Type   EdgeType `json:"type"`
+	Weight float64  `json:"weight,omitempty"`
+}
+
+// Strength returns the link's weight, 1 for an unweighted edge.
+func (l GraphHop) Strength() float64 {
+	return GraphEdge{Weight: l.Weight}.Strength()
+}
+
+// GraphPath is a walk from a seed: Hops[i] joins Nodes[i] and Nodes[i+1],
+// in whichever direction the edge points.
+type GraphPath struct {
+	Nodes []MemoryRecord `json:"nodes"`
+	Hops  []GraphHop     `json:"hops"`
+}
+
+// End returns the memory the path reaches.
+func (p GraphPath) End() MemoryRecord {
+	if len(p.Nodes) == 0 {
+		return MemoryRecord{}
+	}
+	return p.Nodes[len(p.Nodes)-1]
+}
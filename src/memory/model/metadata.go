@@ -132,6 +132,9 @@ func HydrateRecordFromMetadata(rec *MemoryRecord, meta map[string]any) {
 			rec.Space = space
 		}
 	}
+	if rec.TenantID == "" {
+		rec.TenantID = StringFromAny(meta[MetaTenant])
+	}
 	if len(rec.GraphEdges) == 0 {
 		rec.GraphEdges = ValidGraphEdges(meta)
 	}
@@ -7,6 +7,7 @@ type MemoryRecord struct {
 	ID              int64       `json:"id"`
 	SessionID       string      `json:"session_id"`
 	Space           string      `json:"space"`
+	TenantID        string      `json:"tenant_id,omitempty"`
 	Content         string      `json:"content"`
 	Metadata        string      `json:"metadata"`
 	Embedding       []float32   `json:"embedding"`
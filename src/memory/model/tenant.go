@@ -0,0 +1,49 @@
+package model
+
+import "context"
+
+// MetaTenant is the metadata key holding the tenant a record belongs to.
+const MetaTenant = "tenant_id"
+
+type tenantContextKey struct{}
+
+// ContextWithTenant scopes the memory reads and writes under ctx to tenant.
+// Stores only return records of that tenant, and the engine stamps it on
+// every record it writes. An empty tenant is the default, untenanted scope.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant attached to ctx, or "" when there is
+// none.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// RecordTenant returns the tenant rec belongs to.
+func RecordTenant(rec MemoryRecord) string {
+	if rec.TenantID != "" {
+		return rec.TenantID
+	}
+	return StringFromAny(DecodeMetadata(rec.Metadata)[MetaTenant])
+}
+
+// FilterTenant drops the records that do not belong to the tenant on ctx.
+// Without a tenant only untenanted records remain.
+func FilterTenant(ctx context.Context, records []MemoryRecord) []MemoryRecord {
+	tenant := TenantFromContext(ctx)
+	out := make([]MemoryRecord, 0, len(records))
+	for _, rec := range records {
+		if RecordTenant(rec) == tenant {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFilterTenant(t *testing.T) {
+	records := []MemoryRecord{
+		{ID: 1},
+		{ID: 2, TenantID: "acme"},
+		{ID: 3, Metadata: `{"tenant_id":"acme"}`},
+		{ID: 4, TenantID: "globex"},
+	}
+	ids := func(recs []MemoryRecord) []int64 {
+		var out []int64
+		for _, r := range recs {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	if got := ids(FilterTenant(context.Background(), records)); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("default tenant sees %v, want [1]", got)
+	}
+	if got := ids(FilterTenant(ContextWithTenant(context.Background(), "acme"), records)); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("acme sees %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	memengine "github.com/Protocol-Lattice/go-agent/src/memory/engine"
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// MetaCompactedTurns holds, on a summary written by Compact, the number of
+// turns it replaced.
+const MetaCompactedTurns = "compacted_turns"
+
+const defaultCompactKeep = 4
+
+// WithSummarizer sets the summarizer Compact uses.
+func (sm *SessionMemory) WithSummarizer(s memengine.Summarizer) *SessionMemory {
+	sm.Summarizer = s
+	return sm
+}
+
+// Compact collapses the oldest short-term turns of sessionID into one
+// summary, keeping the newest CompactKeep turns as they are. The replaced
+// turns are written to long-term memory first, so nothing is lost, and the
+// summary takes their place at the front of the buffer with role
+// "summary" and an EdgeSummarizes edge to each of them. The summary itself
+// reaches long-term memory with the next flush. Compact returns the
+// summary, or a zero record when fewer than two turns are old enough to
+// compact.
+func (sm *SessionMemory) Compact(ctx context.Context, sessionID string) (model.MemoryRecord, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	keep := sm.CompactKeep
+	if keep <= 0 {
+		keep = defaultCompactKeep
+	}
+	tenant := model.TenantFromContext(ctx)
+	key := bufferKey(tenant, sessionID)
+	buf := sm.shortTerm[key]
+	if len(buf)-keep < 2 {
+		return model.MemoryRecord{}, nil
+	}
+	older := append([]model.MemoryRecord(nil), buf[:len(buf)-keep]...)
+
+	summarizer := sm.Summarizer
+	if summarizer == nil {
+		summarizer = memengine.HeuristicSummarizer{}
+	}
+	summary, err := summarizer.Summarize(ctx, older)
+	if err != nil {
+		return model.MemoryRecord{}, fmt.Errorf("summarize turns: %w", err)
+	}
+	if strings.TrimSpace(summary) == "" {
+		return model.MemoryRecord{}, errors.New("summarize turns: empty summary")
+	}
+
+	ids, err := sm.persistTurns(ctx, sessionID, older)
+	if err != nil {
+		return model.MemoryRecord{}, err
+	}
+	var edges []model.GraphEdge
+	for _, id := range ids {
+		if id != 0 {
+			edges = append(edges, model.GraphEdge{Target: id, Type: model.EdgeSummarizes})
+		}
+	}
+	meta := map[string]any{"role": "summary", MetaCompactedTurns: len(older)}
+	if len(edges) > 0 {
+		meta["graph_edges"] = edges
+	}
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return model.MemoryRecord{}, err
+	}
+	embedding, err := sm.Embed(ctx, summary)
+	if err != nil {
+		return model.MemoryRecord{}, err
+	}
+	rec := model.MemoryRecord{
+		SessionID:  sessionID,
+		Space:      sessionID,
+		TenantID:   tenant,
+		Content:    summary,
+		Metadata:   string(raw),
+		Embedding:  embedding,
+		GraphEdges: edges,
+	}
+	rest := sm.shortTerm[key][len(older):]
+	sm.shortTerm[key] = append([]model.MemoryRecord{rec}, rest...)
+	sm.touch(key)
+	return rec, nil
+}
+
+// persistTurns writes turns to long-term memory and returns their record
+// IDs, zero where the store cannot report one.
+func (sm *SessionMemory) persistTurns(ctx context.Context, sessionID string, turns []model.MemoryRecord) ([]int64, error) {
+	ids := make([]int64, len(turns))
+	if sm.Engine != nil {
+		inputs := make([]memengine.MemoryInput, len(turns))
+		for i, r := range turns {
+			inputs[i] = memengine.MemoryInput{Content: r.Content, Metadata: model.DecodeMetadata(r.Metadata)}
+		}
+		stored, err := sm.Engine.StoreBatch(ctx, sessionID, inputs)
+		if err != nil {
+			return nil, err
+		}
+		for i, rec := range stored {
+			ids[i] = rec.ID
+		}
+		return ids, nil
+	}
+	if err := sm.Bank.StoreMemories(ctx, sessionID, turns); err != nil {
+		return nil, err
+	}
+	// The bank's write path reports no IDs, so look each turn up by its
+	// embedding.
+	for i, turn := range turns {
+		if len(turn.Embedding) == 0 {
+			continue
+		}
+		hits, err := sm.Bank.SearchMemory(ctx, sessionID, turn.Embedding, 5)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range hits {
+			if hit.Content == turn.Content && hit.ID > ids[i] {
+				ids[i] = hit.ID
+			}
+		}
+	}
+	return ids, nil
+}
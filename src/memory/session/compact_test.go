@@ -0,0 +1,73 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	memengine "github.com/Protocol-Lattice/go-agent/src/memory/engine"
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/store"
+)
+
+func TestCompactSummarizesOldestTurns(t *testing.T) {
+	ctx := context.Background()
+	for _, withEngine := range []bool{false, true} {
+		t.Run(fmt.Sprintf("engine=%v", withEngine), func(t *testing.T) {
+			vs := store.NewInMemoryStore()
+			sm := NewSessionMemory(NewMemoryBankWithStore(vs), 10).WithEmbedder(stubEmbedder{})
+			if withEngine {
+				sm.WithEngine(memengine.NewEngine(vs, memengine.Options{}).WithEmbedder(stubEmbedder{}))
+			}
+			var summarized []string
+			sm.WithSummarizer(summarizerFunc(func(_ context.Context, turns []model.MemoryRecord) (string, error) {
+				for _, turn := range turns {
+					summarized = append(summarized, turn.Content)
+				}
+				return "The user planned the launch.", nil
+			}))
+			sm.CompactKeep = 2
+			for i := range 5 {
+				content := fmt.Sprintf("turn %d %s", i, string(rune('a'+i)))
+				sm.AddShortTerm("s", content, `{"role":"user"}`, []float32{float32(i + 1), 1})
+			}
+
+			summary, err := sm.Compact(ctx, "s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(summarized) != 3 || summarized[0] != "turn 0 a" {
+				t.Fatalf("summarized %v, want the three oldest turns", summarized)
+			}
+			buf := sm.ShortTerm("s")
+			if len(buf) != 3 || buf[0].Content != "The user planned the launch." || buf[1].Content != "turn 3 d" {
+				t.Fatalf("short-term after compaction: %+v", buf)
+			}
+			meta := model.DecodeMetadata(summary.Metadata)
+			if meta["role"] != "summary" || model.FloatFromAny(meta[MetaCompactedTurns]) != 3 {
+				t.Fatalf("summary metadata = %v", meta)
+			}
+			if len(summary.GraphEdges) != 3 {
+				t.Fatalf("summary edges = %+v", summary.GraphEdges)
+			}
+			originals, err := store.GetMemories(ctx, vs, []int64{summary.GraphEdges[0].Target})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(originals) != 1 || originals[0].Content != "turn 0 a" || summary.GraphEdges[0].Type != model.EdgeSummarizes {
+				t.Fatalf("edge should point at the stored original: %+v", originals)
+			}
+
+			summarized = nil
+			if _, err := sm.Compact(ctx, "s"); err != nil || summarized != nil {
+				t.Fatalf("a buffer within CompactKeep+1 should be left alone: %v %v", err, summarized)
+			}
+		})
+	}
+}
+
+type summarizerFunc func(context.Context, []model.MemoryRecord) (string, error)
+
+func (f summarizerFunc) Summarize(ctx context.Context, turns []model.MemoryRecord) (string, error) {
+	return f(ctx, turns)
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// WithIdleTimeout makes sessions idle for longer than timeout eligible for
+// ExpireIdle, which flushes them to long-term memory and drops their
+// short-term buffers. onExpire may be nil.
+func (sm *SessionMemory) WithIdleTimeout(timeout time.Duration, onExpire func(ctx context.Context, sessionID string, err error)) *SessionMemory {
+	sm.IdleTimeout = timeout
+	sm.OnExpire = onExpire
+	return sm
+}
+
+// ExpireIdle flushes every session whose short-term buffer has been idle for
+// IdleTimeout and returns the sessions it released. A session whose flush
+// fails keeps its buffer and is retried on the next call. Each buffer is
+// flushed as the tenant that wrote it, and OnExpire gets a context carrying
+// that tenant.
+func (sm *SessionMemory) ExpireIdle(ctx context.Context) []string {
+	if sm.IdleTimeout <= 0 {
+		return nil
+	}
+	sm.mu.Lock()
+	now := sm.now()
+	var idle []string
+	for key := range sm.shortTerm {
+		last, ok := sm.lastActive[key]
+		if !ok {
+			// Buffers restored before tracking began start their clock now.
+			sm.touch(key)
+			continue
+		}
+		if now.Sub(last) >= sm.IdleTimeout {
+			idle = append(idle, key)
+		}
+	}
+	sm.mu.Unlock()
+
+	expired := make([]string, 0, len(idle))
+	for _, key := range idle {
+		if ctx.Err() != nil {
+			break
+		}
+		tenant, sessionID := splitBufferKey(key)
+		flushed, err := sm.expire(ctx, key)
+		if !flushed && err == nil {
+			continue
+		}
+		if err == nil {
+			expired = append(expired, sessionID)
+		}
+		if sm.OnExpire != nil {
+			sm.OnExpire(model.ContextWithTenant(ctx, tenant), sessionID, err)
+		}
+	}
+	return expired
+}
+
+// expire flushes the buffer under key unless it became active again since
+// the sweep looked at it.
+func (sm *SessionMemory) expire(ctx context.Context, key string) (bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	last, ok := sm.lastActive[key]
+	if !ok || sm.now().Sub(last) < sm.IdleTimeout {
+		return false, nil
+	}
+	if err := sm.flushLocked(ctx, key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RunIdleExpiry calls ExpireIdle every interval until ctx is done. A zero
+// interval sweeps once per IdleTimeout.
+func (sm *SessionMemory) RunIdleExpiry(ctx context.Context, interval time.Duration) error {
+	if sm.IdleTimeout <= 0 {
+		return errors.New("session: idle timeout must be positive")
+	}
+	if interval <= 0 {
+		interval = sm.IdleTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			sm.ExpireIdle(ctx)
+		}
+	}
+}
+
+// touch marks the buffer under key active. Callers hold sm.mu.
+func (sm *SessionMemory) touch(key string) {
+	if sm.lastActive == nil {
+		sm.lastActive = make(map[string]time.Time)
+	}
+	sm.lastActive[key] = sm.now()
+}
+
+func (sm *SessionMemory) now() time.Time {
+	if sm.clock != nil {
+		return sm.clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func TestExpireIdleFlushesAbandonedSessions(t *testing.T) {
+	ctx := context.Background()
+	st := &stubVectorStore{}
+	now := time.Unix(1000, 0)
+	var expired []string
+	sm := NewSessionMemory(NewMemoryBankWithStore(st), 8).WithIdleTimeout(time.Minute, func(_ context.Context, sessionID string, err error) {
+		if err != nil {
+			t.Errorf("unexpected flush error for %s: %v", sessionID, err)
+		}
+		expired = append(expired, sessionID)
+	})
+	sm.clock = func() time.Time { return now }
+
+	sm.AddShortTerm("idle", "forgotten tab", "", nil)
+	now = now.Add(45 * time.Second)
+	sm.AddShortTerm("busy", "still typing", "", nil)
+	now = now.Add(30 * time.Second)
+
+	if got := sm.ExpireIdle(ctx); len(got) != 1 || got[0] != "idle" {
+		t.Fatalf("ExpireIdle = %v, want [idle]", got)
+	}
+	if len(expired) != 1 || expired[0] != "idle" {
+		t.Fatalf("OnExpire calls = %v", expired)
+	}
+	if len(st.stored) != 1 || st.stored[0].content != "forgotten tab" {
+		t.Fatalf("stored = %+v, want the idle session flushed", st.stored)
+	}
+	short := sm.ExportShortTerm()
+	if _, ok := short["idle"]; ok {
+		t.Fatal("idle session buffer should be released")
+	}
+	if len(short["busy"]) != 1 {
+		t.Fatal("active session buffer should be kept")
+	}
+}
+
+func TestExpireIdleKeepsBufferWhenFlushFails(t *testing.T) {
+	ctx := context.Background()
+	st := &stubVectorStore{storeErr: errors.New("db down")}
+	now := time.Unix(1000, 0)
+	var flushErr error
+	sm := NewSessionMemory(NewMemoryBankWithStore(st), 8).WithIdleTimeout(time.Minute, func(_ context.Context, _ string, err error) {
+		flushErr = err
+	})
+	sm.clock = func() time.Time { return now }
+	sm.AddShortTerm("s1", "keep me", "", nil)
+	now = now.Add(2 * time.Minute)
+
+	if got := sm.ExpireIdle(ctx); len(got) != 0 {
+		t.Fatalf("ExpireIdle = %v, want nothing released", got)
+	}
+	if flushErr == nil {
+		t.Fatal("OnExpire should receive the flush error")
+	}
+	if len(sm.ExportShortTerm()["s1"]) != 1 {
+		t.Fatal("buffer should survive a failed flush")
+	}
+
+	st.storeErr = nil
+	if got := sm.ExpireIdle(ctx); len(got) != 1 {
+		t.Fatalf("retry ExpireIdle = %v, want [s1]", got)
+	}
+}
+
+func TestExpireIdleFlushesAsTheWritingTenant(t *testing.T) {
+	st := &stubVectorStore{}
+	now := time.Unix(1000, 0)
+	var tenants []string
+	sm := NewSessionMemory(NewMemoryBankWithStore(st), 8).WithIdleTimeout(time.Minute, func(ctx context.Context, _ string, _ error) {
+		tenants = append(tenants, model.TenantFromContext(ctx))
+	})
+	sm.clock = func() time.Time { return now }
+
+	acme := model.ContextWithTenant(context.Background(), "acme")
+	sm.AddShortTermContext(acme, "s1", "acme roadmap", "", nil)
+	sm.AddShortTerm("s1", "public notes", "", nil)
+	if got := sm.ShortTermContext(acme, "s1"); len(got) != 1 || got[0].Content != "acme roadmap" || got[0].TenantID != "acme" {
+		t.Fatalf("acme buffer = %+v", got)
+	}
+	now = now.Add(2 * time.Minute)
+
+	if got := sm.ExpireIdle(context.Background()); len(got) != 2 {
+		t.Fatalf("ExpireIdle = %v, want both buffers released", got)
+	}
+	byContent := map[string]any{}
+	for _, m := range st.stored {
+		byContent[m.content] = m.metadata[model.MetaTenant]
+	}
+	if byContent["acme roadmap"] != "acme" {
+		t.Fatalf("acme turn stored with tenant %v", byContent["acme roadmap"])
+	}
+	if tenant, ok := byContent["public notes"]; !ok || (tenant != nil && tenant != "") {
+		t.Fatalf("untenanted turn stored with tenant %v", tenant)
+	}
+	sort.Strings(tenants)
+	if len(tenants) != 2 || tenants[0] != "" || tenants[1] != "acme" {
+		t.Fatalf("OnExpire tenants = %q", tenants)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory/embed"
 	memengine "github.com/Protocol-Lattice/go-agent/src/memory/engine"
@@ -20,13 +22,39 @@ type MemoryBank struct {
 // SessionMemory wraps MemoryBank with short- and long-term layers
 // including a configurable short-term buffer and embedding provider.
 type SessionMemory struct {
-	Bank          *MemoryBank
+	Bank *MemoryBank
+	// shortTerm holds each buffer under bufferKey(tenant, session), and
+	// writers the identity that last wrote it, so flushes that run without
+	// the writer's context still store as its tenant and identity.
 	shortTerm     map[string][]model.MemoryRecord
+	writers       map[string]model.Identity
 	mu            sync.RWMutex
 	shortTermSize int
 	Embedder      embed.Embedder
 	Engine        *memengine.Engine
 	Spaces        *SpaceRegistry
+
+	// IdleTimeout, when positive, lets ExpireIdle flush sessions whose
+	// short-term buffer has not been touched for that long. OnExpire is
+	// called for each one, with the flush error if it failed.
+	IdleTimeout time.Duration
+	OnExpire    func(ctx context.Context, sessionID string, err error)
+	lastActive  map[string]time.Time
+	clock       func() time.Time
+
+	// OnSpaceWrite, when set, is called after a SharedSession stores a
+	// long-term record in a shared space, e.g. to fire webhooks. It runs on
+	// the writer's goroutine and should not block.
+	OnSpaceWrite func(ctx context.Context, rec model.MemoryRecord)
+
+	// Summarizer writes the summaries of Compact; a HeuristicSummarizer,
+	// which only concatenates the turns, when nil. CompactKeep is the
+	// number of newest turns Compact leaves verbatim; 4 when zero.
+	Summarizer  memengine.Summarizer
+	CompactKeep int
+
+	logOnce  sync.Once
+	spaceLog *spaceLog
 }
 
 // NewMemoryBank creates a new Postgres-backed memory bank.
@@ -48,6 +76,8 @@ func NewSessionMemory(bank *MemoryBank, shortTermSize int) *SessionMemory {
 	return &SessionMemory{
 		Bank:          bank,
 		shortTerm:     make(map[string][]model.MemoryRecord),
+		writers:       make(map[string]model.Identity),
+		lastActive:    make(map[string]time.Time),
 		shortTermSize: shortTermSize,
 		Embedder:      embed.AutoEmbedder(),
 		Spaces:        NewSpaceRegistry(0),
@@ -56,45 +86,113 @@ func NewSessionMemory(bank *MemoryBank, shortTermSize int) *SessionMemory {
 
 // AddShortTerm stores in ephemeral session cache
 func (sm *SessionMemory) AddShortTerm(sessionID, content, metadata string, embedding []float32) {
+	sm.AddShortTermContext(context.Background(), sessionID, content, metadata, embedding)
+}
+
+// AddShortTermContext stores in the session cache of the tenant on ctx.
+// The tenant and identity on ctx are kept with the buffer and used when it
+// is flushed.
+func (sm *SessionMemory) AddShortTermContext(ctx context.Context, sessionID, content, metadata string, embedding []float32) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	sm.addShortTermLocked(ctx, sessionID, content, metadata, embedding)
+}
+
+func (sm *SessionMemory) addShortTermLocked(ctx context.Context, sessionID, content, metadata string, embedding []float32) {
+	tenant := model.TenantFromContext(ctx)
+	key := bufferKey(tenant, sessionID)
+	record := model.MemoryRecord{SessionID: sessionID, Space: sessionID, TenantID: tenant, Content: content, Metadata: metadata, Embedding: embedding}
+	sm.shortTerm[key] = append(sm.shortTerm[key], record)
+	if id, ok := model.IdentityFromContext(ctx); ok {
+		if sm.writers == nil {
+			sm.writers = make(map[string]model.Identity)
+		}
+		sm.writers[key] = id
+	}
+	sm.touch(key)
 
-	record := model.MemoryRecord{SessionID: sessionID, Space: sessionID, Content: content, Metadata: metadata, Embedding: embedding}
-	sm.shortTerm[sessionID] = append(sm.shortTerm[sessionID], record)
+	if len(sm.shortTerm[key]) > sm.shortTermSize {
+		sm.shortTerm[key] = sm.shortTerm[key][len(sm.shortTerm[key])-sm.shortTermSize:]
+	}
+}
 
-	if len(sm.shortTerm[sessionID]) > sm.shortTermSize {
-		sm.shortTerm[sessionID] = sm.shortTerm[sessionID][len(sm.shortTerm[sessionID])-sm.shortTermSize:]
+// bufferKey is the short-term buffer of sessionID in tenant. Untenanted
+// buffers are keyed by the session alone.
+func bufferKey(tenant, sessionID string) string {
+	if tenant == "" {
+		return sessionID
 	}
+	return tenant + "\x1f" + sessionID
 }
 
-// FlushToLongTerm writes the short-term cache to the configured vector store.
+// splitBufferKey reverses bufferKey.
+func splitBufferKey(key string) (tenant, sessionID string) {
+	if tenant, sessionID, ok := strings.Cut(key, "\x1f"); ok {
+		return tenant, sessionID
+	}
+	return "", key
+}
+
+// FlushToLongTerm writes the short-term cache of the tenant on ctx to the
+// configured vector store.
 func (sm *SessionMemory) FlushToLongTerm(ctx context.Context, sessionID string) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	return sm.flushLocked(ctx, bufferKey(model.TenantFromContext(ctx), sessionID))
+}
 
-	records := sm.shortTerm[sessionID]
-	for _, r := range records {
+// flushLocked writes the buffer under key as its tenant and, unless ctx
+// carries one, its writer's identity.
+func (sm *SessionMemory) flushLocked(ctx context.Context, key string) error {
+	tenant, sessionID := sm.bufferScope(key)
+	ctx = model.ContextWithTenant(ctx, tenant)
+	if _, ok := model.IdentityFromContext(ctx); !ok {
+		if id, ok := sm.writers[key]; ok {
+			ctx = model.ContextWithIdentity(ctx, id)
+		}
+	}
+	records := sm.shortTerm[key]
+	// Flushing in one batch lets the embedder and store take their bulk
+	// paths, which for Postgres means a single COPY.
+	if len(records) > 0 {
 		if sm.Engine != nil {
-			meta := model.DecodeMetadata(r.Metadata)
-			if _, err := sm.Engine.Store(ctx, sessionID, r.Content, meta); err != nil {
-				return err
+			inputs := make([]memengine.MemoryInput, len(records))
+			for i, r := range records {
+				inputs[i] = memengine.MemoryInput{Content: r.Content, Metadata: model.DecodeMetadata(r.Metadata)}
 			}
-		} else {
-			if err := sm.Bank.StoreMemory(ctx, sessionID, r.Content, r.Metadata, r.Embedding); err != nil {
+			if _, err := sm.Engine.StoreBatch(ctx, sessionID, inputs); err != nil {
 				return err
 			}
+		} else if err := sm.Bank.StoreMemories(ctx, sessionID, records); err != nil {
+			return err
 		}
 	}
-	delete(sm.shortTerm, sessionID)
+	delete(sm.shortTerm, key)
+	delete(sm.writers, key)
+	delete(sm.lastActive, key)
 	return nil
 }
 
+// bufferScope returns the tenant and session of the buffer under key. The
+// tenant recorded on its turns wins, so buffers imported from a checkpoint
+// keep theirs.
+func (sm *SessionMemory) bufferScope(key string) (tenant, sessionID string) {
+	tenant, sessionID = splitBufferKey(key)
+	if buf := sm.shortTerm[key]; len(buf) > 0 && buf[0].TenantID != "" {
+		tenant = buf[0].TenantID
+	}
+	return tenant, sessionID
+}
+
 // Embed ensures an embedder is available and returns the embedding for the text.
 func (sm *SessionMemory) Embed(ctx context.Context, text string) ([]float32, error) {
 	if sm.Embedder == nil {
 		sm.Embedder = embed.AutoEmbedder()
 	}
 	vec, err := sm.Embedder.Embed(ctx, text)
+	if embed.IsUnavailable(err) {
+		return nil, err
+	}
 	if err != nil || len(vec) == 0 {
 		return embed.DummyEmbedding(text), nil
 	}
@@ -103,30 +201,45 @@ func (sm *SessionMemory) Embed(ctx context.Context, text string) ([]float32, err
 
 // RetrieveContext returns combined short- and long-term memory
 func (sm *SessionMemory) RetrieveContext(ctx context.Context, sessionID, query string, limit int) ([]model.MemoryRecord, error) {
-	var longTerm []model.MemoryRecord
-	if sm.Engine != nil {
-		records, err := sm.Engine.Retrieve(ctx, sessionID, query, limit)
-		if err != nil {
-			return nil, err
-		}
-		longTerm = records
-	} else if sm.Bank != nil {
-		queryEmbedding, err := sm.Embed(ctx, query)
-		if err != nil {
-			return nil, err
-		}
-		records, err := sm.Bank.SearchMemory(ctx, sessionID, queryEmbedding, limit)
-		if err != nil {
-			return nil, err
-		}
-		longTerm = records
+	longTerm, err := sm.RetrieveLongTerm(ctx, sessionID, query, limit)
+	if err != nil {
+		return nil, err
 	}
+	return append(sm.ShortTermContext(ctx, sessionID), longTerm...), nil
+}
 
-	sm.mu.RLock()
-	shortTerm := sm.shortTerm[sessionID]
-	sm.mu.RUnlock()
+// ShortTerm returns a copy of the session's short-term buffer, oldest first.
+func (sm *SessionMemory) ShortTerm(sessionID string) []model.MemoryRecord {
+	return sm.ShortTermContext(context.Background(), sessionID)
+}
 
-	return append(shortTerm, longTerm...), nil
+// ShortTermContext returns a copy of the short-term buffer the tenant on ctx
+// holds for sessionID, oldest first.
+func (sm *SessionMemory) ShortTermContext(ctx context.Context, sessionID string) []model.MemoryRecord {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	key := bufferKey(model.TenantFromContext(ctx), sessionID)
+	buf := sm.shortTerm[key]
+	if len(buf) == 0 {
+		return nil
+	}
+	sm.touch(key)
+	return append([]model.MemoryRecord(nil), buf...)
+}
+
+// RetrieveLongTerm searches only the long-term store.
+func (sm *SessionMemory) RetrieveLongTerm(ctx context.Context, sessionID, query string, limit int) ([]model.MemoryRecord, error) {
+	if sm.Engine != nil {
+		return sm.Engine.Retrieve(ctx, sessionID, query, limit)
+	}
+	if sm.Bank == nil {
+		return nil, nil
+	}
+	queryEmbedding, err := sm.Embed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return sm.Bank.SearchMemory(ctx, sessionID, queryEmbedding, limit)
 }
 
 // WithEmbedder overrides the embedder used by the session memory.
@@ -148,22 +261,59 @@ func (mb *MemoryBank) StoreMemory(ctx context.Context, sessionID, content, metad
 	if mb == nil || mb.Store == nil {
 		return nil
 	}
+	meta, err := bankMetadata(ctx, sessionID, metadata)
+	if err != nil {
+		return err
+	}
+	return mb.Store.StoreMemory(ctx, sessionID, content, meta, embedding)
+}
+
+// StoreMemories inserts records as long-term memories of sessionID, through
+// the store's bulk path when it has one.
+func (mb *MemoryBank) StoreMemories(ctx context.Context, sessionID string, records []model.MemoryRecord) error {
+	if mb == nil || mb.Store == nil || len(records) == 0 {
+		return nil
+	}
+	writes := make([]store.MemoryWrite, len(records))
+	for i, r := range records {
+		meta, err := bankMetadata(ctx, sessionID, r.Metadata)
+		if err != nil {
+			return err
+		}
+		writes[i] = store.MemoryWrite{Content: r.Content, Metadata: meta, Embedding: r.Embedding}
+	}
+	return store.StoreMemories(ctx, mb.Store, sessionID, writes)
+}
+
+// bankMetadata decodes metadata, defaulting its space to sessionID, and
+// stamps the tenant on ctx the way the engine does.
+func bankMetadata(ctx context.Context, sessionID, metadata string) (map[string]any, error) {
 	meta := map[string]any{}
 	if metadata != "" {
 		_ = json.Unmarshal([]byte(metadata), &meta)
 	}
+	if meta == nil { // metadata was "null"
+		meta = map[string]any{}
+	}
 	if _, ok := meta["space"]; !ok {
 		meta["space"] = sessionID
 	}
-	return mb.Store.StoreMemory(ctx, sessionID, content, meta, embedding)
+	if err := memengine.StampTenant(ctx, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
 }
 
-// SearchMemory returns top-k similar memories
+// SearchMemory returns top-k similar memories the identity on ctx may see.
 func (mb *MemoryBank) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
 	if mb == nil || mb.Store == nil {
 		return nil, nil
 	}
-	return mb.Store.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+	recs, err := mb.Store.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+	if err != nil {
+		return nil, err
+	}
+	return model.FilterVisible(ctx, recs), nil
 }
 
 // CreateSchema initialises the backing store if it supports schema management.
@@ -189,7 +339,9 @@ func (mb *MemoryBank) Close() error {
 	return nil
 }
 
-// ExportShortTerm returns a snapshot of the in-memory short-term history.
+// ExportShortTerm returns a snapshot of the in-memory short-term history,
+// keyed by session. Buffers of a tenant are keyed by the tenant and the
+// session, and their turns carry the tenant.
 func (sm *SessionMemory) ExportShortTerm() map[string][]model.MemoryRecord {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -208,4 +360,9 @@ func (sm *SessionMemory) ImportShortTerm(data map[string][]model.MemoryRecord) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	sm.shortTerm = data
+	sm.writers = make(map[string]model.Identity)
+	sm.lastActive = make(map[string]time.Time, len(data))
+	for key := range data {
+		sm.touch(key)
+	}
 }
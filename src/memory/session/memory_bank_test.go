@@ -3,11 +3,13 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory/embed"
+	memengine "github.com/Protocol-Lattice/go-agent/src/memory/engine"
 	"github.com/Protocol-Lattice/go-agent/src/memory/model"
 	"github.com/Protocol-Lattice/go-agent/src/memory/store"
 )
@@ -206,3 +208,63 @@ func TestSessionMemoryRetrieveContextCombinesSources(t *testing.T) {
 		t.Fatalf("unexpected record ordering: %#v", records)
 	}
 }
+
+type batchStubVectorStore struct {
+	stubVectorStore
+	batches [][]store.MemoryWrite
+}
+
+func (s *batchStubVectorStore) StoreMemories(_ context.Context, _ string, writes []store.MemoryWrite) error {
+	s.batches = append(s.batches, writes)
+	return nil
+}
+
+func TestFlushToLongTermWritesOneBatch(t *testing.T) {
+	ctx := context.Background()
+	st := &batchStubVectorStore{}
+	sm := NewSessionMemory(NewMemoryBankWithStore(st), 8)
+	sm.AddShortTerm("s1", "first", `{"source":"chat"}`, []float32{1})
+	sm.AddShortTerm("s1", "second", "", []float32{2})
+	if err := sm.FlushToLongTerm(ctx, "s1"); err != nil {
+		t.Fatalf("FlushToLongTerm: %v", err)
+	}
+	if len(st.stored) != 0 || len(st.batches) != 1 || len(st.batches[0]) != 2 {
+		t.Fatalf("stored %d singly and %d batches, want one batch of two", len(st.stored), len(st.batches))
+	}
+	first := st.batches[0][0]
+	if first.Content != "first" || first.Metadata["source"] != "chat" || first.Metadata["space"] != "s1" {
+		t.Fatalf("first write = %+v", first)
+	}
+
+	sm.Engine = memengine.NewEngine(st, memengine.DefaultOptions()).WithEmbedder(stubEmbedder{vec: []float32{1, 0}})
+	sm.AddShortTerm("s1", "third", "", nil)
+	sm.AddShortTerm("s1", "fourth", "", nil)
+	if err := sm.FlushToLongTerm(ctx, "s1"); err != nil {
+		t.Fatalf("engine FlushToLongTerm: %v", err)
+	}
+	if len(st.batches) != 2 {
+		t.Fatalf("batches = %d, want the engine flush batched too", len(st.batches))
+	}
+}
+
+func TestMemoryBankStampsTheContextTenant(t *testing.T) {
+	st := &stubVectorStore{}
+	bank := NewMemoryBankWithStore(st)
+	ctx := model.ContextWithTenant(context.Background(), "acme")
+
+	if err := bank.StoreMemory(ctx, "s", "hello", `{"tenant_id":"acme"}`, []float32{1}); err != nil {
+		t.Fatalf("StoreMemory: %v", err)
+	}
+	if err := bank.StoreMemory(ctx, "s", "hello", "", []float32{1}); err != nil {
+		t.Fatalf("StoreMemory: %v", err)
+	}
+	for _, m := range st.stored {
+		if m.metadata[model.MetaTenant] != "acme" {
+			t.Fatalf("metadata = %v, want the context tenant", m.metadata)
+		}
+	}
+	err := bank.StoreMemory(context.Background(), "s", "hello", `{"tenant_id":"acme"}`, []float32{1})
+	if !errors.Is(err, memengine.ErrTenantMismatch) || len(st.stored) != 2 {
+		t.Fatalf("metadata naming a tenant off ctx: err = %v", err)
+	}
+}
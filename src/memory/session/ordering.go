@@ -0,0 +1,246 @@
+package session
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// Metadata keys stamped on every write a SharedSession makes to a space.
+const (
+	MetaSeq    = "seq"
+	MetaWriter = "writer"
+)
+
+// A SharedSession remembers up to maxPendingWrites of its own long-term
+// writes for pendingWriteWindow, returning them from retrieval until the
+// store does.
+const (
+	maxPendingWrites   = 64
+	pendingWriteWindow = 30 * time.Second
+)
+
+// ConflictPolicy decides what a blackboard key holds after concurrent writes.
+type ConflictPolicy int
+
+const (
+	// LastWriterWins keeps only the entry with the highest sequence number.
+	LastWriterWins ConflictPolicy = iota
+	// AppendAll keeps every entry in sequence order.
+	AppendAll
+)
+
+// BlackboardEntry is one write to a structured key in a space.
+type BlackboardEntry struct {
+	Space  string    `json:"space"`
+	Key    string    `json:"key"`
+	Value  string    `json:"value"`
+	Writer string    `json:"writer"`
+	Seq    uint64    `json:"seq"`
+	At     time.Time `json:"at"`
+}
+
+// spaceLog orders writes to shared spaces. Sequence numbers are per space,
+// strictly increasing, and shared by every SharedSession over the same
+// SessionMemory, so all participants agree on the order of writes.
+type spaceLog struct {
+	mu         sync.Mutex
+	seq        map[string]uint64
+	policies   map[string]ConflictPolicy
+	blackboard map[string]map[string][]BlackboardEntry
+}
+
+func (sm *SessionMemory) log() *spaceLog {
+	sm.logOnce.Do(func() {
+		sm.spaceLog = &spaceLog{
+			seq:        map[string]uint64{},
+			policies:   map[string]ConflictPolicy{},
+			blackboard: map[string]map[string][]BlackboardEntry{},
+		}
+	})
+	return sm.spaceLog
+}
+
+func (l *spaceLog) next(space string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq[space]++
+	return l.seq[space]
+}
+
+// LastSeq returns the sequence number of the latest write to space.
+func (sm *SessionMemory) LastSeq(space string) uint64 {
+	l := sm.log()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq[space]
+}
+
+// SetConflictPolicy chooses how blackboard keys in space resolve concurrent
+// writes. Spaces default to LastWriterWins.
+func (sm *SessionMemory) SetConflictPolicy(space string, policy ConflictPolicy) {
+	l := sm.log()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policies[space] = policy
+}
+
+// SeqOf returns the space sequence number stamped on rec, or 0 for records
+// written outside a SharedSession.
+func SeqOf(rec model.MemoryRecord) uint64 {
+	raw, ok := model.DecodeMetadata(rec.Metadata)[MetaSeq]
+	if !ok {
+		return 0
+	}
+	switch v := raw.(type) {
+	case float64:
+		return uint64(v)
+	case string:
+		n, _ := strconv.ParseUint(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// SortBySeq orders records from one space by sequence number, i.e. in the
+// order they were written.
+func SortBySeq(records []model.MemoryRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return SeqOf(records[i]) < SeqOf(records[j])
+	})
+}
+
+// Since returns the short-term records written to space after seq, in order.
+// Readers poll it with the last sequence number they saw to consume a space
+// as a log.
+func (ss *SharedSession) Since(space string, seq uint64) ([]model.MemoryRecord, error) {
+	if ss == nil || ss.base == nil {
+		return nil, errors.New("nil shared session")
+	}
+	if !ss.canRead(space) {
+		return nil, ErrSpaceForbidden
+	}
+	ss.base.mu.RLock()
+	var out []model.MemoryRecord
+	for _, rec := range ss.base.shortTerm[space] {
+		if SeqOf(rec) > seq {
+			out = append(out, rec)
+		}
+	}
+	ss.base.mu.RUnlock()
+	SortBySeq(out)
+	return out, nil
+}
+
+// SetKey writes value under a structured key in space and returns the entry
+// with its sequence number. The space's ConflictPolicy decides whether it
+// replaces or joins earlier entries.
+func (ss *SharedSession) SetKey(space, key, value string) (BlackboardEntry, error) {
+	if ss == nil || ss.base == nil {
+		return BlackboardEntry{}, errors.New("nil shared session")
+	}
+	space, key = strings.TrimSpace(space), strings.TrimSpace(key)
+	if space == "" || key == "" {
+		return BlackboardEntry{}, errors.New("space and key are required")
+	}
+	if !ss.canWrite(space) {
+		return BlackboardEntry{}, ErrSpaceForbidden
+	}
+	l := ss.base.log()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq[space]++
+	entry := BlackboardEntry{Space: space, Key: key, Value: value, Writer: ss.local, Seq: l.seq[space], At: ss.base.now()}
+	keys := l.blackboard[space]
+	if keys == nil {
+		keys = map[string][]BlackboardEntry{}
+		l.blackboard[space] = keys
+	}
+	if l.policies[space] == AppendAll {
+		keys[key] = append(keys[key], entry)
+	} else {
+		keys[key] = []BlackboardEntry{entry}
+	}
+	return entry, nil
+}
+
+// GetKey returns the latest entry for key in space.
+func (ss *SharedSession) GetKey(space, key string) (BlackboardEntry, bool) {
+	entries := ss.KeyHistory(space, key)
+	if len(entries) == 0 {
+		return BlackboardEntry{}, false
+	}
+	return entries[len(entries)-1], true
+}
+
+// KeyHistory returns every retained entry for key in space, oldest first.
+// Under LastWriterWins that is at most one.
+func (ss *SharedSession) KeyHistory(space, key string) []BlackboardEntry {
+	if ss == nil || ss.base == nil || !ss.canRead(space) {
+		return nil
+	}
+	l := ss.base.log()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]BlackboardEntry(nil), l.blackboard[space][key]...)
+}
+
+// Keys lists the blackboard keys set in space.
+func (ss *SharedSession) Keys(space string) []string {
+	if ss == nil || ss.base == nil || !ss.canRead(space) {
+		return nil
+	}
+	l := ss.base.log()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := make([]string, 0, len(l.blackboard[space]))
+	for k := range l.blackboard[space] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rememberWrite keeps a long-term write until retrieval has seen it, so the
+// writer reads it back even from a store that indexes asynchronously.
+func (ss *SharedSession) rememberWrite(rec model.MemoryRecord) {
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = ss.base.now()
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.pending = append(ss.pending, rec)
+	if len(ss.pending) > maxPendingWrites {
+		ss.pending = ss.pending[len(ss.pending)-maxPendingWrites:]
+	}
+}
+
+// ownWrites returns the remembered writes to allowed sessions that are not
+// among found, forgetting those that are and those past the window.
+func (ss *SharedSession) ownWrites(allowed map[string]struct{}, found []model.MemoryRecord) []model.MemoryRecord {
+	seen := make(map[string]struct{}, len(found))
+	for _, rec := range found {
+		seen[rec.SessionID+"\u241F"+strings.TrimSpace(rec.Content)] = struct{}{}
+	}
+	cutoff := ss.base.now().Add(-pendingWriteWindow)
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var missing []model.MemoryRecord
+	kept := ss.pending[:0]
+	for _, rec := range ss.pending {
+		if _, ok := seen[rec.SessionID+"\u241F"+strings.TrimSpace(rec.Content)]; ok || rec.CreatedAt.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, rec)
+		if _, ok := allowed[rec.SessionID]; ok {
+			missing = append(missing, rec)
+		}
+	}
+	ss.pending = kept
+	return missing
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSharedSpaceWritesAreSequenced(t *testing.T) {
+	sm := NewSessionMemory(NewMemoryBankWithStore(&stubVectorStore{}), 100)
+	sm.WithEmbedder(stubEmbedder{vec: []float32{1}})
+	sm.Spaces = nil // open spaces, no ACLs
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		ss := NewSharedSession(sm, fmt.Sprintf("agent-%d", w), "team:x")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10 {
+				if err := ss.AddShortTo("team:x", fmt.Sprintf("note %d", i), nil); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	reader := NewSharedSession(sm, "reader", "team:x")
+	all, err := reader.Since("team:x", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 40 || sm.LastSeq("team:x") != 40 {
+		t.Fatalf("got %d records, last seq %d; want 40", len(all), sm.LastSeq("team:x"))
+	}
+	for i, rec := range all {
+		if SeqOf(rec) != uint64(i+1) {
+			t.Fatalf("record %d has seq %d", i, SeqOf(rec))
+		}
+	}
+	tail, _ := reader.Since("team:x", 38)
+	if len(tail) != 2 || SeqOf(tail[0]) != 39 {
+		t.Fatalf("Since(38) = %d records", len(tail))
+	}
+}
+
+func TestSharedSessionReadsItsOwnLongTermWrites(t *testing.T) {
+	ctx := context.Background()
+	// The stub store never returns anything from search, like an index
+	// that has not caught up yet.
+	sm := NewSessionMemory(NewMemoryBankWithStore(&stubVectorStore{}), 8)
+	sm.WithEmbedder(stubEmbedder{vec: []float32{1}})
+	sm.Spaces = nil
+	writer := NewSharedSession(sm, "agent-a", "team:x")
+	other := NewSharedSession(sm, "agent-b", "team:x")
+
+	rec, err := writer.StoreLongTo(ctx, "team:x", "deploy is frozen", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SeqOf(rec) != 1 {
+		t.Fatalf("long-term write seq = %d, want 1", SeqOf(rec))
+	}
+	got, _ := writer.Retrieve(ctx, "deploy", 5)
+	if len(got) != 1 || got[0].Content != "deploy is frozen" {
+		t.Fatalf("writer should see its own write, got %+v", got)
+	}
+	if got, _ := other.Retrieve(ctx, "deploy", 5); len(got) != 0 {
+		t.Fatalf("other participants only see what the store returns, got %+v", got)
+	}
+}
+
+func TestBlackboardConflictPolicies(t *testing.T) {
+	sm := NewSessionMemory(&MemoryBank{}, 8)
+	sm.Spaces = nil
+	a := NewSharedSession(sm, "agent-a", "team:x")
+	b := NewSharedSession(sm, "agent-b", "team:x")
+
+	if _, err := a.SetKey("team:x", "status", "drafting"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.SetKey("team:x", "status", "reviewing"); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := a.GetKey("team:x", "status")
+	if !ok || got.Value != "reviewing" || got.Writer != "agent-b" || got.Seq != 2 {
+		t.Fatalf("last writer should win, got %+v", got)
+	}
+	if n := len(a.KeyHistory("team:x", "status")); n != 1 {
+		t.Fatalf("last-writer-wins keeps one entry, got %d", n)
+	}
+
+	sm.SetConflictPolicy("team:x", AppendAll)
+	_, _ = a.SetKey("team:x", "findings", "cache is cold")
+	_, _ = b.SetKey("team:x", "findings", "db is slow")
+	history := b.KeyHistory("team:x", "findings")
+	if len(history) != 2 || history[0].Value != "cache is cold" || history[1].Seq <= history[0].Seq {
+		t.Fatalf("append-all should keep both in order, got %+v", history)
+	}
+	if keys := a.Keys("team:x"); len(keys) != 2 || keys[0] != "findings" {
+		t.Fatalf("Keys = %v", keys)
+	}
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// Search looks for query in the history of each of sessionIDs: long-term
+// retrieval plus the short-term buffer. Every hit's Score is its cosine
+// similarity to the query, so hits from both sources merge on one scale;
+// they are sorted best first and at most limit are kept. Retrieval runs
+// as the identity and tenant on ctx, so records the caller may not see are
+// never returned, and a record found through more than one session is kept
+// once.
+func (sm *SessionMemory) Search(ctx context.Context, query string, limit int, sessionIDs ...string) ([]model.MemoryRecord, error) {
+	if limit <= 0 || len(sessionIDs) == 0 {
+		return nil, nil
+	}
+	var hits []model.MemoryRecord
+	seen := map[int64]struct{}{}
+	var queryVec []float32
+	similarity := func(rec model.MemoryRecord) (float64, error) {
+		if len(rec.Embedding) == 0 && len(rec.EmbeddingMatrix) == 0 {
+			// Nothing to compare; the store's similarity is the best we have.
+			return rec.Score, nil
+		}
+		if queryVec == nil {
+			vec, err := sm.Embed(ctx, query)
+			if err != nil {
+				return 0, err
+			}
+			queryVec = vec
+		}
+		return model.MaxCosineSimilarity(queryVec, rec), nil
+	}
+	for _, id := range sessionIDs {
+		for _, rec := range model.FilterVisible(ctx, model.FilterTenant(ctx, sm.peekShortTerm(ctx, id))) {
+			score, err := similarity(rec)
+			if err != nil {
+				return nil, fmt.Errorf("search session %s: %w", id, err)
+			}
+			rec.Score = score
+			hits = append(hits, rec)
+		}
+		records, err := sm.RetrieveLongTerm(ctx, id, query, limit)
+		if err != nil {
+			return nil, fmt.Errorf("search session %s: %w", id, err)
+		}
+		for _, rec := range records {
+			if rec.ID != 0 {
+				if _, dup := seen[rec.ID]; dup {
+					continue
+				}
+				seen[rec.ID] = struct{}{}
+			}
+			score, err := similarity(rec)
+			if err != nil {
+				return nil, fmt.Errorf("search session %s: %w", id, err)
+			}
+			rec.Score = score
+			hits = append(hits, rec)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// peekShortTerm copies the short-term buffer the tenant on ctx holds for
+// sessionID without counting as activity, so searching does not hold off
+// idle expiry.
+func (sm *SessionMemory) peekShortTerm(ctx context.Context, sessionID string) []model.MemoryRecord {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return append([]model.MemoryRecord(nil), sm.shortTerm[bufferKey(model.TenantFromContext(ctx), sessionID)]...)
+}
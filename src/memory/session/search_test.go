@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func TestSearchMergesSessionsAndHonoursACLs(t *testing.T) {
+	st := &stubVectorStore{searchResp: []model.MemoryRecord{
+		{ID: 1, SessionID: "a", Content: "deploy key rotated", Score: 0.4},
+		{ID: 2, SessionID: "b", Content: "deploy checklist", Score: 0.9},
+		{ID: 3, SessionID: "b", Content: "salary bands", Score: 0.95, Metadata: `{"acl_principals":["hr@example.com"]}`},
+	}}
+	sm := NewSessionMemory(NewMemoryBankWithStore(st), 8).WithEmbedder(stubEmbedder{})
+	sm.AddShortTerm("a", "deploy now", `{"role":"user"}`, []float32{1})
+	ctx := model.ContextWithIdentity(context.Background(), model.Identity{Principal: "bob@example.com"})
+
+	hits, err := sm.Search(ctx, "deploy", 10, "a", "b")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 3 || hits[0].Content != "deploy now" || hits[1].ID != 2 || hits[2].ID != 1 {
+		t.Fatalf("hits = %+v, want the short-term turn, then records 2 and 1 once each", hits)
+	}
+
+	hits, err = sm.Search(ctx, "deploy", 1, "a", "b")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Content != "deploy now" {
+		t.Fatalf("limited hits = %+v, want the short-term turn", hits)
+	}
+}
+
+func TestSearchKeepsShortTermTurnsOfTheCallersTenant(t *testing.T) {
+	sm := NewSessionMemory(NewMemoryBankWithStore(&stubVectorStore{}), 8).WithEmbedder(stubEmbedder{})
+	// A checkpoint restored by an older build holds a tenant's turn under
+	// the bare session key.
+	sm.ImportShortTerm(map[string][]model.MemoryRecord{
+		"a": {{SessionID: "a", TenantID: "acme", Content: "acme deploy plan", Embedding: []float32{1}}},
+	})
+	sm.AddShortTermContext(model.ContextWithTenant(context.Background(), "acme"), "a", "acme deploy notes", "", []float32{1})
+
+	hits, err := sm.Search(context.Background(), "deploy", 10, "a")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Fatalf("hits = %+v, want no turns of another tenant", hits)
+	}
+	hits, err = sm.Search(model.ContextWithTenant(context.Background(), "acme"), "deploy", 10, "a")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].Content != "acme deploy notes" {
+		t.Fatalf("acme hits = %+v", hits)
+	}
+}
+
+func TestSearchRanksOnQuerySimilarity(t *testing.T) {
+	st := &stubVectorStore{searchResp: []model.MemoryRecord{
+		{ID: 1, SessionID: "a", Content: "boosted but off topic", Embedding: []float32{0, 1}, WeightedScore: 0.99},
+		{ID: 2, SessionID: "a", Content: "on topic", Embedding: []float32{1, 0.1}, WeightedScore: 0.3},
+	}}
+	sm := NewSessionMemory(NewMemoryBankWithStore(st), 8).WithEmbedder(stubEmbedder{vec: []float32{1, 0}})
+
+	hits, err := sm.Search(context.Background(), "deploy", 10, "a")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != 2 || hits[0].Score < 0.9 || hits[1].Score > 0.1 {
+		t.Fatalf("hits = %+v, want records ordered by cosine similarity to the query", hits)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -39,6 +40,7 @@ type SharedSession struct {
 	mu       sync.RWMutex
 	joined   map[string]struct{}
 	registry *SpaceRegistry
+	pending  []model.MemoryRecord // own long-term writes not yet seen by retrieval
 }
 
 // NewSharedSession binds a local sessionID and optional initial shared spaces.
@@ -132,12 +134,22 @@ func (ss *SharedSession) AddShortTo(space, content string, metadata map[string]s
 	if !ss.canWrite(space) {
 		return ErrSpaceForbidden
 	}
-	metaBytes, _ := json.Marshal(metadata)
 	emb, err := ss.base.Embed(context.Background(), content)
 	if err != nil {
 		return err
 	}
-	ss.base.AddShortTerm(space, content, string(metaBytes), emb)
+	stamped := make(map[string]string, len(metadata)+2)
+	for k, v := range metadata {
+		stamped[k] = v
+	}
+	stamped[MetaWriter] = ss.local
+	// The sequence number is taken under the buffer lock so buffer order
+	// and sequence order agree.
+	ss.base.mu.Lock()
+	defer ss.base.mu.Unlock()
+	stamped[MetaSeq] = strconv.FormatUint(ss.base.log().next(space), 10)
+	metaBytes, _ := json.Marshal(stamped)
+	ss.base.addShortTermLocked(context.Background(), space, content, string(metaBytes), emb)
 	return nil
 }
 
@@ -176,8 +188,22 @@ func (ss *SharedSession) StoreLongTo(ctx context.Context, sessionID, content str
 	if sessionID != ss.local && !ss.canWrite(sessionID) {
 		return model.MemoryRecord{}, ErrSpaceForbidden
 	}
+	if sessionID != ss.local {
+		stamped := make(map[string]any, len(metadata)+2)
+		for k, v := range metadata {
+			stamped[k] = v
+		}
+		stamped[MetaWriter] = ss.local
+		stamped[MetaSeq] = ss.base.log().next(sessionID)
+		metadata = stamped
+	}
 	if ss.base.Engine != nil {
-		return ss.base.Engine.Store(ctx, sessionID, content, metadata)
+		rec, err := ss.base.Engine.Store(ctx, sessionID, content, metadata)
+		if err == nil {
+			ss.rememberWrite(rec)
+			ss.notifySpaceWrite(ctx, rec)
+		}
+		return rec, err
 	}
 	// Bank-only path: compute embedding and store.
 	emb, err := ss.base.Embed(ctx, content)
@@ -189,7 +215,18 @@ func (ss *SharedSession) StoreLongTo(ctx context.Context, sessionID, content str
 		return model.MemoryRecord{}, err
 	}
 	// Best-effort record (ID may be zero if not re-fetched from store).
-	return model.MemoryRecord{SessionID: sessionID, Content: content, Metadata: string(metaBytes), Embedding: emb}, nil
+	rec := model.MemoryRecord{SessionID: sessionID, Space: sessionID, Content: content, Metadata: string(metaBytes), Embedding: emb}
+	ss.rememberWrite(rec)
+	ss.notifySpaceWrite(ctx, rec)
+	return rec, nil
+}
+
+// notifySpaceWrite reports a write to a shared space to OnSpaceWrite.
+func (ss *SharedSession) notifySpaceWrite(ctx context.Context, rec model.MemoryRecord) {
+	if rec.SessionID == ss.local || ss.base.OnSpaceWrite == nil {
+		return
+	}
+	ss.base.OnSpaceWrite(ctx, rec)
 }
 
 // BroadcastLong writes a long-term memory to the local session and all spaces.
@@ -333,6 +370,11 @@ func (ss *SharedSession) retrieve(ctx context.Context, query string, limit int,
 	for _, r := range short {
 		push(&merged, r)
 	}
+	// Read-your-writes: this participant's own long-term writes come back
+	// even before the store returns them.
+	for _, r := range ss.ownWrites(allowed, long) {
+		push(&merged, r)
+	}
 	for _, r := range long {
 		push(&merged, r)
 	}
@@ -168,6 +168,17 @@ func (sr *SpaceRegistry) Grant(spaceName, principal string, role SpaceRole, ttl
 	return nil
 }
 
+// Get returns a copy of an active space.
+func (sr *SpaceRegistry) Get(spaceName string) (*Space, bool) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	space := sr.spaces[strings.TrimSpace(spaceName)]
+	if space == nil || space.expired(sr.now()) {
+		return nil, false
+	}
+	return space.clone(), true
+}
+
 // Revoke removes a principal from the space ACL.
 func (sr *SpaceRegistry) Revoke(spaceName, principal string) {
 	sr.mu.Lock()
@@ -252,6 +263,21 @@ func (sr *SpaceRegistry) List(principal string) []string {
 	return out
 }
 
+// Spaces returns copies of the active spaces, sorted by name.
+func (sr *SpaceRegistry) Spaces() []*Space {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	now := sr.now()
+	out := make([]*Space, 0, len(sr.spaces))
+	for _, space := range sr.spaces {
+		if space != nil && !space.expired(now) {
+			out = append(out, space.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
 // Prune removes expired spaces.
 func (sr *SpaceRegistry) Prune() []string {
 	sr.mu.Lock()
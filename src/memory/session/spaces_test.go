@@ -82,6 +82,14 @@ func TestSpaceRegistryListAndPrune(t *testing.T) {
 	if len(list) != 2 || list[0] != "alpha" || list[1] != "beta" {
 		t.Fatalf("unexpected list of spaces: %v", list)
 	}
+	all := sr.Spaces()
+	if len(all) != 3 || all[0].Name != "alpha" || all[2].ACL["bob"] != SpaceRoleAdmin {
+		t.Fatalf("unexpected spaces: %+v", all)
+	}
+	all[0].ACL["mallory"] = SpaceRoleAdmin
+	if sr.CanRead("alpha", "mallory") {
+		t.Fatal("Spaces must return copies")
+	}
 
 	sr.clock = func() time.Time { return now.Add(2 * time.Minute) }
 	removed := sr.Prune()
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrDimensionMismatch is matched (via errors.Is) by every
+// *DimensionMismatchError.
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// DimensionMismatchError reports a vector whose length differs from the
+// dimension the store was created with. It usually means the embedder
+// changed (for example AutoEmbedder picked a different backend) after
+// records were written.
+type DimensionMismatchError struct {
+	Op       string // "store", "search" or "update"
+	Expected int
+	Got      int
+}
+
+func (e *DimensionMismatchError) Error() string {
+	return fmt.Sprintf("memory store: %s embedding has %d dimensions but the store expects %d; the embedder likely changed, re-embed existing memories or recreate the schema", e.Op, e.Got, e.Expected)
+}
+
+func (e *DimensionMismatchError) Is(target error) bool { return target == ErrDimensionMismatch }
+
+// DimensionAware is implemented by stores that validate embedding sizes.
+// Dimensions returns 0 until the size is known.
+type DimensionAware interface {
+	Dimensions() int
+	SetDimensions(n int)
+}
+
+// dimensionGuard records the expected embedding size for a store. The size
+// comes from CreateSchema when the backend declares it, from SetDimensions,
+// or otherwise from the first non-empty vector written.
+type dimensionGuard struct {
+	dims atomic.Int64
+}
+
+// Dimensions returns the expected embedding size, or 0 when unknown.
+func (g *dimensionGuard) Dimensions() int { return int(g.dims.Load()) }
+
+// SetDimensions fixes the expected embedding size; n <= 0 clears it.
+func (g *dimensionGuard) SetDimensions(n int) {
+	if n < 0 {
+		n = 0
+	}
+	g.dims.Store(int64(n))
+}
+
+// checkWrite validates a vector about to be persisted, adopting its size
+// when none has been recorded yet.
+func (g *dimensionGuard) checkWrite(op string, vec []float32) error {
+	if len(vec) == 0 {
+		return nil
+	}
+	if g.dims.CompareAndSwap(0, int64(len(vec))) {
+		return nil
+	}
+	return g.check(op, vec)
+}
+
+// check validates vec against the recorded size without adopting it.
+func (g *dimensionGuard) check(op string, vec []float32) error {
+	want := g.Dimensions()
+	if want == 0 || len(vec) == 0 || len(vec) == want {
+		return nil
+	}
+	return &DimensionMismatchError{Op: op, Expected: want, Got: len(vec)}
+}
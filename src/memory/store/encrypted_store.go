@@ -0,0 +1,393 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// encryptedPrefix marks a value sealed by AESGCMEncryptor. Values without it
+// are treated as plaintext written before encryption was enabled.
+const encryptedPrefix = "enc:v1:"
+
+// encryptedMetadataKeys are the metadata values EncryptedStore seals next to
+// the content: the cluster summary and attachment payloads.
+var encryptedMetadataKeys = []string{"summary", "data_base64"}
+
+// KeyProvider supplies data keys, typically from a KMS. Key returns the
+// key new values are sealed with; KeyByID returns an older key by the ID
+// stored with each value, so keys can rotate without re-encrypting.
+type KeyProvider interface {
+	Key(ctx context.Context) (id string, key []byte, err error)
+	KeyByID(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider over fixed keys, for tests and deployments
+// that load keys from configuration. Current names the key used for new
+// values.
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+// Key returns the current key.
+func (k StaticKeys) Key(ctx context.Context) (string, []byte, error) {
+	key, err := k.KeyByID(ctx, k.Current)
+	return k.Current, key, err
+}
+
+// KeyByID returns the key named id.
+func (k StaticKeys) KeyByID(_ context.Context, id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", id)
+	}
+	return key, nil
+}
+
+// ContentEncryptor seals and opens the text EncryptedStore protects. scope
+// is bound to the ciphertext, so a value copied to another session does not
+// decrypt there. Decrypt must return values it did not seal unchanged, so
+// records written before encryption was enabled stay readable.
+type ContentEncryptor interface {
+	Encrypt(ctx context.Context, scope, plaintext string) (string, error)
+	Decrypt(ctx context.Context, scope, value string) (string, error)
+}
+
+// AESGCMEncryptor is a ContentEncryptor using AES-GCM with keys from a
+// KeyProvider. Sealed values read "enc:v1:<key id>:<base64 nonce and
+// ciphertext>".
+type AESGCMEncryptor struct {
+	keys KeyProvider
+}
+
+// NewAESGCMEncryptor returns an encryptor using keys. Keys must be 16, 24 or
+// 32 bytes long.
+func NewAESGCMEncryptor(keys KeyProvider) *AESGCMEncryptor {
+	return &AESGCMEncryptor{keys: keys}
+}
+
+// Encrypt seals plaintext with the current key.
+func (e *AESGCMEncryptor) Encrypt(ctx context.Context, scope, plaintext string) (string, error) {
+	id, key, err := e.keys.Key(ctx)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(id, ":") {
+		return "", fmt.Errorf("encryption key id %q contains a colon", id)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(scope))
+	return encryptedPrefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt and returns any other value
+// unchanged.
+func (e *AESGCMEncryptor) Decrypt(ctx context.Context, scope, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	id, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	key, err := e.keys.KeyByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value: too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(scope))
+	if err != nil {
+		return "", fmt.Errorf("decrypt with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptedStore encrypts memory content, summaries and attachment payloads
+// before they reach the wrapped VectorStore and decrypts them on the way
+// back. Embeddings and the remaining metadata stay in the clear so the
+// store can still search and filter. Each value is bound to its session.
+type EncryptedStore struct {
+	primary VectorStore
+	enc     ContentEncryptor
+}
+
+// NewEncryptedStore wraps primary so its text is sealed with enc.
+func NewEncryptedStore(primary VectorStore, enc ContentEncryptor) (*EncryptedStore, error) {
+	if primary == nil {
+		return nil, errors.New("primary vector store is nil")
+	}
+	if enc == nil {
+		return nil, errors.New("content encryptor is nil")
+	}
+	return &EncryptedStore{primary: primary, enc: enc}, nil
+}
+
+// Primary returns the wrapped store.
+func (s *EncryptedStore) Primary() VectorStore { return s.primary }
+
+// StoreMemory seals content and the protected metadata, then writes.
+func (s *EncryptedStore) StoreMemory(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
+	sealed, meta, err := s.seal(ctx, sessionID, content, metadata)
+	if err != nil {
+		return err
+	}
+	return s.primary.StoreMemory(ctx, sessionID, sealed, meta, embedding)
+}
+
+// StoreMemories seals every write and sends them through the primary's bulk
+// path when it has one.
+func (s *EncryptedStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	sealedWrites := make([]MemoryWrite, len(writes))
+	for i, w := range writes {
+		content, meta, err := s.seal(ctx, sessionID, w.Content, w.Metadata)
+		if err != nil {
+			return err
+		}
+		sealedWrites[i] = MemoryWrite{Content: content, Metadata: meta, Embedding: w.Embedding}
+	}
+	return StoreMemories(ctx, s.primary, sessionID, sealedWrites)
+}
+
+// SearchMemory searches the primary and decrypts the results.
+func (s *EncryptedStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	records, err := s.primary.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAll(ctx, records)
+}
+
+// SearchMemoryFiltered searches the primary with filter and decrypts the
+// results. Every field a filter reads is stored in the clear.
+func (s *EncryptedStore) SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	records, err := SearchFiltered(ctx, s.primary, queryEmbedding, filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAll(ctx, records)
+}
+
+// UpdateEmbedding forwards to the primary.
+func (s *EncryptedStore) UpdateEmbedding(ctx context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
+	return s.primary.UpdateEmbedding(ctx, id, embedding, lastEmbedded)
+}
+
+// UpdateImportance forwards to the primary when it supports it.
+func (s *EncryptedStore) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if u, ok := s.primary.(ImportanceUpdater); ok {
+		return u.UpdateImportance(ctx, id, importance)
+	}
+	return nil
+}
+
+// UpdateMetadata seals the protected metadata and forwards to the primary.
+func (s *EncryptedStore) UpdateMetadata(ctx context.Context, record model.MemoryRecord) error {
+	u, ok := s.primary.(MetadataUpdater)
+	if !ok {
+		return ErrMetadataUpdatesUnsupported
+	}
+	meta, err := s.sealMetadata(ctx, record.SessionID, model.DecodeMetadata(record.Metadata))
+	if err != nil {
+		return err
+	}
+	record.Metadata = model.StringFromAny(meta)
+	return u.UpdateMetadata(ctx, record)
+}
+
+// DeleteMemory forwards to the primary.
+func (s *EncryptedStore) DeleteMemory(ctx context.Context, ids []int64) error {
+	return s.primary.DeleteMemory(ctx, ids)
+}
+
+// Iterate walks the primary with decrypted records. A record that fails to
+// decrypt stops the walk and is returned as the error.
+func (s *EncryptedStore) Iterate(ctx context.Context, fn func(model.MemoryRecord) bool) error {
+	var openErr error
+	err := s.primary.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec, openErr = s.open(ctx, rec); openErr != nil {
+			return false
+		}
+		return fn(rec)
+	})
+	if err != nil {
+		return err
+	}
+	return openErr
+}
+
+// Count forwards to the primary.
+func (s *EncryptedStore) Count(ctx context.Context) (int, error) {
+	return s.primary.Count(ctx)
+}
+
+// CreateSchema forwards to the primary when it supports it.
+func (s *EncryptedStore) CreateSchema(ctx context.Context, schemaPath string) error {
+	if initializer, ok := s.primary.(SchemaInitializer); ok {
+		return initializer.CreateSchema(ctx, schemaPath)
+	}
+	return nil
+}
+
+// UpsertGraph forwards to the primary when it is a GraphStore. Graph
+// backends keep the record's content on the node, so it is sealed too.
+func (s *EncryptedStore) UpsertGraph(ctx context.Context, record model.MemoryRecord, edges []model.GraphEdge) error {
+	graph, ok := s.primary.(GraphStore)
+	if !ok {
+		return nil
+	}
+	content, meta, err := s.seal(ctx, record.SessionID, record.Content, model.DecodeMetadata(record.Metadata))
+	if err != nil {
+		return err
+	}
+	record.Content = content
+	record.Metadata = model.StringFromAny(meta)
+	if record.Summary != "" {
+		if record.Summary, err = s.enc.Encrypt(ctx, record.SessionID, record.Summary); err != nil {
+			return err
+		}
+	}
+	return graph.UpsertGraph(ctx, record, edges)
+}
+
+// Neighborhood forwards to the primary when it is a GraphStore and decrypts
+// the results.
+func (s *EncryptedStore) Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
+	graph, ok := s.primary.(GraphStore)
+	if !ok {
+		return nil, nil
+	}
+	records, err := graph.Neighborhood(ctx, sessionID, seedIDs, hops, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.openAll(ctx, records)
+}
+
+// QueryGraph answers q from the primary and decrypts the memories on each
+// path.
+func (s *EncryptedStore) QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	paths, err := QueryGraph(ctx, s.primary, q)
+	if err != nil {
+		return nil, err
+	}
+	for i := range paths {
+		if paths[i].Nodes, err = s.openAll(ctx, paths[i].Nodes); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// seal encrypts content and the protected values of a copy of metadata.
+func (s *EncryptedStore) seal(ctx context.Context, sessionID, content string, metadata map[string]any) (string, map[string]any, error) {
+	sealed, err := s.enc.Encrypt(ctx, sessionID, content)
+	if err != nil {
+		return "", nil, fmt.Errorf("encrypt content: %w", err)
+	}
+	meta, err := s.sealMetadata(ctx, sessionID, metadata)
+	if err != nil {
+		return "", nil, err
+	}
+	return sealed, meta, nil
+}
+
+// sealMetadata encrypts the protected values of a copy of metadata.
+func (s *EncryptedStore) sealMetadata(ctx context.Context, sessionID string, metadata map[string]any) (map[string]any, error) {
+	meta := model.CloneMetadata(metadata)
+	for _, key := range encryptedMetadataKeys {
+		value, ok := meta[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		var err error
+		if meta[key], err = s.enc.Encrypt(ctx, sessionID, value); err != nil {
+			return nil, fmt.Errorf("encrypt %s: %w", key, err)
+		}
+	}
+	return meta, nil
+}
+
+func (s *EncryptedStore) openAll(ctx context.Context, records []model.MemoryRecord) ([]model.MemoryRecord, error) {
+	for i := range records {
+		rec, err := s.open(ctx, records[i])
+		if err != nil {
+			return nil, err
+		}
+		records[i] = rec
+	}
+	return records, nil
+}
+
+// open decrypts the content, summary and protected metadata of rec.
+func (s *EncryptedStore) open(ctx context.Context, rec model.MemoryRecord) (model.MemoryRecord, error) {
+	var err error
+	if rec.Content, err = s.enc.Decrypt(ctx, rec.SessionID, rec.Content); err != nil {
+		return rec, fmt.Errorf("memory %d: %w", rec.ID, err)
+	}
+	if rec.Summary, err = s.enc.Decrypt(ctx, rec.SessionID, rec.Summary); err != nil {
+		return rec, fmt.Errorf("memory %d summary: %w", rec.ID, err)
+	}
+	if rec.Metadata == "" {
+		return rec, nil
+	}
+	var meta map[string]any
+	if json.Unmarshal([]byte(rec.Metadata), &meta) != nil {
+		return rec, nil
+	}
+	changed := false
+	for _, key := range encryptedMetadataKeys {
+		value, ok := meta[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		opened, err := s.enc.Decrypt(ctx, rec.SessionID, value)
+		if err != nil {
+			return rec, fmt.Errorf("memory %d %s: %w", rec.ID, key, err)
+		}
+		if opened != value {
+			meta[key] = opened
+			changed = true
+		}
+	}
+	if changed {
+		rec.Metadata = model.StringFromAny(meta)
+	}
+	return rec, nil
+}
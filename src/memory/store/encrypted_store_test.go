@@ -0,0 +1,115 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func testKeys(current string) StaticKeys {
+	return StaticKeys{Current: current, Keys: map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	}}
+}
+
+func TestEncryptedStoreSealsContentAtRest(t *testing.T) {
+	ctx := context.Background()
+	inner := NewInMemoryStore()
+	// A record written before encryption was enabled.
+	_ = inner.StoreMemory(ctx, "s", "legacy note", nil, []float32{0, 1})
+
+	s, err := NewEncryptedStore(inner, NewAESGCMEncryptor(testKeys("k1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := map[string]any{"summary": "account details", "data_base64": "aGVsbG8=", "source": "file_upload"}
+	if err := s.StoreMemory(ctx, "s", "the account number is 1234", meta, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if meta["summary"] != "account details" {
+		t.Fatal("caller metadata was modified")
+	}
+
+	_ = inner.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.Content == "legacy note" {
+			return true
+		}
+		if strings.Contains(rec.Content, "1234") || strings.Contains(rec.Metadata, "account details") || strings.Contains(rec.Metadata, "aGVsbG8=") {
+			t.Fatalf("plaintext reached the store: %+v", rec)
+		}
+		if !strings.Contains(rec.Metadata, `"source":"file_upload"`) {
+			t.Fatalf("unprotected metadata should stay readable: %s", rec.Metadata)
+		}
+		return true
+	})
+
+	// Rotating the key keeps older values readable.
+	rotated, _ := NewEncryptedStore(inner, NewAESGCMEncryptor(testKeys("k2")))
+	results, err := rotated.SearchMemory(ctx, "s", []float32{1, 0}, 5)
+	if err != nil {
+		t.Fatalf("SearchMemory: %v", err)
+	}
+	var got model.MemoryRecord
+	for _, rec := range results {
+		if rec.Embedding[0] == 1 {
+			got = rec
+		}
+	}
+	meta = model.DecodeMetadata(got.Metadata)
+	if got.Content != "the account number is 1234" || got.Summary != "account details" || meta["data_base64"] != "aGVsbG8=" {
+		t.Fatalf("record not decrypted: %+v", got)
+	}
+	seen := 0
+	if err := rotated.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		seen++
+		return true
+	}); err != nil || seen != 2 {
+		t.Fatalf("Iterate: %d records, %v", seen, err)
+	}
+}
+
+func TestAESGCMEncryptorBindsScope(t *testing.T) {
+	ctx := context.Background()
+	enc := NewAESGCMEncryptor(testKeys("k1"))
+	sealed, err := enc.Encrypt(ctx, "alice", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.Decrypt(ctx, "bob", sealed); err == nil {
+		t.Fatal("value sealed for one session decrypted in another")
+	}
+	if _, err := NewAESGCMEncryptor(StaticKeys{Current: "k1"}).Decrypt(ctx, "alice", sealed); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}
+
+func TestEncryptedStoreUpdateMetadataSealsSummary(t *testing.T) {
+	ctx := context.Background()
+	inner := NewInMemoryStore()
+	s, _ := NewEncryptedStore(inner, NewAESGCMEncryptor(testKeys("k1")))
+	_ = s.StoreMemory(ctx, "s", "note", nil, []float32{1, 0})
+	if err := s.UpdateMetadata(ctx, model.MemoryRecord{ID: 1, SessionID: "s", Metadata: `{"summary":"private summary","tombstoned_at":"2026-01-01T00:00:00Z"}`}); err != nil {
+		t.Fatalf("UpdateMetadata: %v", err)
+	}
+	_ = inner.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if strings.Contains(rec.Metadata, "private summary") || !strings.Contains(rec.Metadata, "tombstoned_at") {
+			t.Fatalf("unexpected stored metadata: %s", rec.Metadata)
+		}
+		return true
+	})
+	_ = s.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.Summary != "private summary" {
+			t.Fatalf("summary not decrypted: %+v", rec)
+		}
+		return true
+	})
+
+	bare, _ := NewEncryptedStore(struct{ VectorStore }{inner}, NewAESGCMEncryptor(testKeys("k1")))
+	if err := bare.UpdateMetadata(ctx, model.MemoryRecord{ID: 1, SessionID: "s"}); err != ErrMetadataUpdatesUnsupported {
+		t.Fatalf("expected ErrMetadataUpdatesUnsupported, got %v", err)
+	}
+}
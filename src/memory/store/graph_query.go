@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// GraphQuerier is implemented by graph stores that can answer a
+// model.GraphQuery themselves.
+type GraphQuerier interface {
+	QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error)
+}
+
+// QueryGraph answers q with the store's GraphQuerier. Other stores are
+// walked in memory over the GraphEdges of their records, which costs one
+// scan of the store.
+func QueryGraph(ctx context.Context, vs VectorStore, q model.GraphQuery) ([]model.GraphPath, error) {
+	if gq, ok := vs.(GraphQuerier); ok {
+		return gq.QueryGraph(ctx, q)
+	}
+	var index *recordGraph
+	links := func(ctx context.Context, ids []int64, dir model.GraphDirection) ([]model.GraphHop, error) {
+		if index == nil {
+			var err error
+			if index, err = scanRecordGraph(ctx, vs); err != nil {
+				return nil, err
+			}
+		}
+		return index.links(ids, dir), nil
+	}
+	return walkGraph(ctx, q, links, func(ctx context.Context, ids []int64) ([]model.MemoryRecord, error) {
+		return GetMemories(ctx, vs, ids)
+	})
+}
+
+// graphLinker returns the stored links touching ids: the outgoing ones, the
+// incoming ones, or both.
+type graphLinker func(ctx context.Context, ids []int64, dir model.GraphDirection) ([]model.GraphHop, error)
+
+// walkGraph runs q breadth first, one linker call per hop, so each memory
+// is reached by one of its shortest paths. Among links of the same hop the
+// heavier are followed first. Memories that load fails to return, or that
+// belong to another session, end the walk there.
+func walkGraph(ctx context.Context, q model.GraphQuery, linker graphLinker, load func(context.Context, []int64) ([]model.MemoryRecord, error)) ([]model.GraphPath, error) {
+	q = q.Normalized()
+	if len(q.Seeds) == 0 {
+		return nil, nil
+	}
+	targets := make(map[int64]bool, len(q.Targets))
+	for _, id := range q.Targets {
+		targets[id] = true
+	}
+	inScope := func(rec model.MemoryRecord) bool {
+		return q.SessionID == "" || rec.SessionID == q.SessionID
+	}
+
+	seeds, err := load(ctx, q.Seeds)
+	if err != nil {
+		return nil, err
+	}
+	reached := make(map[int64]model.GraphPath, len(seeds))
+	var frontier []int64
+	for _, rec := range seeds {
+		if !inScope(rec) {
+			continue
+		}
+		reached[rec.ID] = model.GraphPath{Nodes: []model.MemoryRecord{rec}}
+		frontier = append(frontier, rec.ID)
+	}
+
+	var out []model.GraphPath
+	for hop := 0; hop < q.MaxHops && len(frontier) > 0 && len(out) < q.Limit; hop++ {
+		links, err := linker(ctx, frontier, q.Direction)
+		if err != nil {
+			return nil, err
+		}
+		type step struct {
+			from, to int64
+			link     model.GraphHop
+		}
+		var steps []step
+		for _, link := range links {
+			if !q.Allows(link) {
+				continue
+			}
+			if _, ok := reached[link.From]; ok && q.Direction != model.GraphIncoming {
+				steps = append(steps, step{link.From, link.To, link})
+			}
+			if _, ok := reached[link.To]; ok && q.Direction != model.GraphOutgoing {
+				steps = append(steps, step{link.To, link.From, link})
+			}
+		}
+		sort.SliceStable(steps, func(i, j int) bool {
+			return steps[i].link.Strength() > steps[j].link.Strength()
+		})
+		next := make(map[int64]step)
+		var order []int64
+		for _, s := range steps {
+			if _, seen := reached[s.to]; seen {
+				continue
+			}
+			if _, seen := next[s.to]; seen {
+				continue
+			}
+			next[s.to] = s
+			order = append(order, s.to)
+		}
+		if len(order) == 0 {
+			break
+		}
+		records, err := load(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, rec := range records {
+			if !inScope(rec) {
+				continue
+			}
+			s := next[rec.ID]
+			parent := reached[s.from]
+			path := model.GraphPath{
+				Nodes: append(append([]model.MemoryRecord(nil), parent.Nodes...), rec),
+				Hops:  append(append([]model.GraphHop(nil), parent.Hops...), s.link),
+			}
+			reached[rec.ID] = path
+			frontier = append(frontier, rec.ID)
+			if len(targets) == 0 || targets[rec.ID] {
+				out = append(out, path)
+				if len(out) == q.Limit {
+					break
+				}
+			}
+		}
+	}
+	return out, ctx.Err()
+}
+
+// recordGraph indexes the edges stored on records, for stores without a
+// graph of their own.
+type recordGraph struct {
+	out map[int64][]model.GraphHop
+	in  map[int64][]model.GraphHop
+}
+
+func scanRecordGraph(ctx context.Context, vs VectorStore) (*recordGraph, error) {
+	g := &recordGraph{out: map[int64][]model.GraphHop{}, in: map[int64][]model.GraphHop{}}
+	tenant := model.TenantFromContext(ctx)
+	err := vs.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if rec.ID == 0 || model.RecordTenant(rec) != tenant {
+			return ctx.Err() == nil
+		}
+		edges := rec.GraphEdges
+		if len(edges) == 0 {
+			edges = model.ValidGraphEdges(model.DecodeMetadata(rec.Metadata))
+		}
+		for _, edge := range edges {
+			link := model.GraphHop{From: rec.ID, To: edge.Target, Type: edge.Type, Weight: edge.Weight}
+			g.out[link.From] = append(g.out[link.From], link)
+			g.in[link.To] = append(g.in[link.To], link)
+		}
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, ctx.Err()
+}
+
+func (g *recordGraph) links(ids []int64, dir model.GraphDirection) []model.GraphHop {
+	var out []model.GraphHop
+	for _, id := range ids {
+		if dir != model.GraphIncoming {
+			out = append(out, g.out[id]...)
+		}
+		if dir != model.GraphOutgoing {
+			out = append(out, g.in[id]...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func graphFixture(t *testing.T) *InMemoryStore {
+	t.Helper()
+	ctx := context.Background()
+	s := NewInMemoryStore()
+	_ = s.StoreMemory(ctx, "s", "outage", nil, []float32{1})
+	_ = s.StoreMemory(ctx, "s", "root cause", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 1, Type: model.EdgeExplains, Weight: 0.9}},
+	}, []float32{1})
+	_ = s.StoreMemory(ctx, "s", "postmortem", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 2, Type: model.EdgeFollows, Weight: 0.2}},
+	}, []float32{1})
+	_ = s.StoreMemory(ctx, "other", "gossip", map[string]any{
+		"graph_edges": []model.GraphEdge{{Target: 1, Type: model.EdgeRelatesTo}},
+	}, []float32{1})
+	return s
+}
+
+func pathIDs(p model.GraphPath) []int64 {
+	ids := make([]int64, len(p.Nodes))
+	for i, n := range p.Nodes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func TestQueryGraphReturnsPathsWithinSession(t *testing.T) {
+	s := graphFixture(t)
+	paths, err := QueryGraph(context.Background(), s, model.GraphQuery{SessionID: "s", Seeds: []int64{1}, MaxHops: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want root cause and postmortem: %+v", len(paths), paths)
+	}
+	if got := pathIDs(paths[1]); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("second path = %v, want 1 -> 2 -> 3", got)
+	}
+	if hops := paths[1].Hops; len(hops) != 2 || hops[0].From != 2 || hops[0].Type != model.EdgeExplains || hops[1].Weight != 0.2 {
+		t.Fatalf("hops = %+v", hops)
+	}
+}
+
+func TestQueryGraphFilters(t *testing.T) {
+	ctx := context.Background()
+	s := graphFixture(t)
+	cases := []struct {
+		name string
+		q    model.GraphQuery
+		want int
+	}{
+		{"outgoing edges only", model.GraphQuery{Seeds: []int64{1}, Direction: model.GraphOutgoing, MaxHops: 3}, 0},
+		{"incoming edges across sessions", model.GraphQuery{Seeds: []int64{1}, Direction: model.GraphIncoming}, 2},
+		{"edge type", model.GraphQuery{Seeds: []int64{1}, EdgeTypes: []model.EdgeType{model.EdgeExplains}, MaxHops: 3}, 1},
+		{"weight threshold", model.GraphQuery{Seeds: []int64{1}, SessionID: "s", MinWeight: 0.5, MaxHops: 3}, 1},
+		{"path to a target", model.GraphQuery{Seeds: []int64{3}, Targets: []int64{1}, MaxHops: 2}, 1},
+		{"target out of reach", model.GraphQuery{Seeds: []int64{3}, Targets: []int64{1}, MaxHops: 1}, 0},
+	}
+	for _, tc := range cases {
+		paths, err := QueryGraph(ctx, s, tc.q)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(paths) != tc.want {
+			t.Fatalf("%s: got %d paths, want %d: %+v", tc.name, len(paths), tc.want, paths)
+		}
+	}
+}
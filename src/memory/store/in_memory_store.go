@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"os"
 	"sort"
 	"sync"
 	"time"
@@ -12,9 +13,12 @@ import (
 
 // InMemoryStore implements VectorStore for tests and lightweight deployments.
 type InMemoryStore struct {
+	dimensionGuard
+
 	mu      sync.RWMutex
 	nextID  int64
 	records map[int64]*inMemoryRecord
+	wal     *os.File
 }
 
 // inMemoryRecord keeps search-only derived data beside the record so a scan
@@ -84,15 +88,45 @@ func NewInMemoryStore() *InMemoryStore {
 }
 
 func (s *InMemoryStore) StoreMemory(_ context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
+	if err := s.checkWrite("store", embedding); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records == nil {
+		s.records = make(map[int64]*inMemoryRecord)
+	}
+	return s.insertLocked(sessionID, content, metadata, embedding, time.Now().UTC())
+}
+
+// StoreMemories stores writes under a single lock acquisition.
+func (s *InMemoryStore) StoreMemories(_ context.Context, sessionID string, writes []MemoryWrite) error {
+	for _, w := range writes {
+		if err := s.checkWrite("store", w.Embedding); err != nil {
+			return err
+		}
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.records == nil {
 		s.records = make(map[int64]*inMemoryRecord)
 	}
 	now := time.Now().UTC()
+	for _, w := range writes {
+		if err := s.insertLocked(sessionID, w.Content, w.Metadata, w.Embedding, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) insertLocked(sessionID, content string, metadata map[string]any, embedding []float32, now time.Time) error {
 	record := prepareMemoryRecord(sessionID, content, metadata, embedding, now, false)
-	s.nextID++
-	record.ID = s.nextID
+	record.ID = s.nextID + 1
+	if err := s.journal(walEntry{Op: walPut, Record: &record}); err != nil {
+		return err
+	}
+	s.nextID = record.ID
 	s.records[record.ID] = &inMemoryRecord{
 		record:     record,
 		magnitudes: calculateRecordMagnitudes(record),
@@ -100,17 +134,48 @@ func (s *InMemoryStore) StoreMemory(_ context.Context, sessionID, content string
 	return nil
 }
 
-func (s *InMemoryStore) SearchMemory(_ context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+// replaceLocked journals and installs the new state of a stored record.
+func (s *InMemoryStore) replaceLocked(stored *inMemoryRecord, record model.MemoryRecord) error {
+	if err := s.journal(walEntry{Op: walPut, Record: &record}); err != nil {
+		return err
+	}
+	stored.record = record
+	stored.magnitudes = calculateRecordMagnitudes(record)
+	return nil
+}
+
+// SearchMemory scans the records of sessionID that belong to the tenant on
+// ctx.
+func (s *InMemoryStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	return s.search(ctx, queryEmbedding, limit, func(rec *model.MemoryRecord) bool {
+		return sessionID == "" || rec.SessionID == sessionID
+	})
+}
+
+// SearchMemoryFiltered implements FilteredSearcher.
+func (s *InMemoryStore) SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	return s.search(ctx, queryEmbedding, limit, func(rec *model.MemoryRecord) bool {
+		return filter.Matches(*rec)
+	})
+}
+
+// search returns the limit records of the tenant on ctx accepted by keep
+// that are most similar to queryEmbedding.
+func (s *InMemoryStore) search(ctx context.Context, queryEmbedding []float32, limit int, keep func(*model.MemoryRecord) bool) ([]model.MemoryRecord, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if limit <= 0 {
 		return nil, nil
 	}
+	if err := s.check("search", queryEmbedding); err != nil {
+		return nil, err
+	}
 	query := model.NewCosineQuery(queryEmbedding)
+	tenant := model.TenantFromContext(ctx)
 	scoredRecords := make(topMemoryRecords, 0, min(limit, len(s.records)))
 	for _, stored := range s.records {
 		rec := &stored.record
-		if sessionID != "" && rec.SessionID != sessionID {
+		if rec.TenantID != tenant || !keep(rec) {
 			continue
 		}
 		score := maxSimilarityWithMagnitudes(query, rec, stored.magnitudes)
@@ -137,21 +202,61 @@ func (s *InMemoryStore) SearchMemory(_ context.Context, sessionID string, queryE
 }
 
 func (s *InMemoryStore) UpdateEmbedding(_ context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
+	if err := s.checkWrite("update", embedding); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	stored, ok := s.records[id]
 	if !ok {
 		return errors.New("memory not found")
 	}
-	stored.record.Embedding = append([]float32(nil), embedding...)
-	stored.record.LastEmbedded = lastEmbedded
-	stored.magnitudes = calculateRecordMagnitudes(stored.record)
-	return nil
+	record := stored.record
+	record.Embedding = append([]float32(nil), embedding...)
+	record.LastEmbedded = lastEmbedded
+	return s.replaceLocked(stored, record)
+}
+
+func (s *InMemoryStore) UpdateImportance(_ context.Context, id int64, importance float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.records[id]
+	if !ok {
+		return errors.New("memory not found")
+	}
+	record := stored.record
+	record.Importance = importance
+	return s.replaceLocked(stored, record)
+}
+
+func (s *InMemoryStore) UpdateMetadata(_ context.Context, record model.MemoryRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.records[record.ID]
+	if !ok {
+		return errors.New("memory not found")
+	}
+	meta := model.DecodeMetadata(record.Metadata)
+	updated := stored.record
+	updated.Metadata = model.StringFromAny(meta)
+	updated.Source = model.StringFromAny(meta["source"])
+	updated.Summary = model.StringFromAny(meta["summary"])
+	if space := model.StringFromAny(meta["space"]); space != "" {
+		updated.Space = space
+	}
+	updated.GraphEdges = model.ValidGraphEdges(meta)
+	return s.replaceLocked(stored, updated)
 }
 
 func (s *InMemoryStore) DeleteMemory(_ context.Context, ids []int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.journal(walEntry{Op: walDelete, IDs: ids}); err != nil {
+		return err
+	}
 	for _, id := range ids {
 		delete(s.records, id)
 	}
@@ -219,6 +324,20 @@ func (s *InMemoryStore) Iterate(_ context.Context, fn func(model.MemoryRecord) b
 	return nil
 }
 
+// GetMemories implements RecordGetter.
+func (s *InMemoryStore) GetMemories(ctx context.Context, ids []int64) ([]model.MemoryRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tenant := model.TenantFromContext(ctx)
+	out := make([]model.MemoryRecord, 0, len(ids))
+	for _, id := range ids {
+		if rec, ok := s.records[id]; ok && model.RecordTenant(rec.record) == tenant {
+			out = append(out, rec.record)
+		}
+	}
+	return out, nil
+}
+
 func (s *InMemoryStore) Count(_ context.Context) (int, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"math"
 	"sort"
 	"strconv"
@@ -212,3 +213,130 @@ func fullSortSearchMemory(store *InMemoryStore, sessionID string, query []float3
 	}
 	return results
 }
+
+func TestInMemoryStoreRejectsDimensionChanges(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	if err := store.StoreMemory(ctx, "s", "first", nil, []float32{1, 0, 0}); err != nil {
+		t.Fatalf("StoreMemory returned error: %v", err)
+	}
+	if store.Dimensions() != 3 {
+		t.Fatalf("expected first write to pin 3 dimensions, got %d", store.Dimensions())
+	}
+
+	err := store.StoreMemory(ctx, "s", "second", nil, []float32{1, 0})
+	var mismatch *DimensionMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Expected != 3 || mismatch.Got != 2 || !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch on store, got %v", err)
+	}
+	if _, err := store.SearchMemory(ctx, "s", []float32{1, 0, 0, 0}, 1); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch on search, got %v", err)
+	}
+	if err := store.UpdateEmbedding(ctx, 1, []float32{1}, time.Now()); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch on update, got %v", err)
+	}
+	if got, err := store.SearchMemory(ctx, "s", []float32{1, 0, 0}, 1); err != nil || len(got) != 1 {
+		t.Fatalf("matching search = %v, %v", got, err)
+	}
+}
+
+func TestStoreMemoriesUsesBulkPathOrFallsBack(t *testing.T) {
+	ctx := context.Background()
+	writes := []MemoryWrite{
+		{Content: "one", Embedding: []float32{1, 0}},
+		{Content: "two", Metadata: map[string]any{"space": "team"}, Embedding: []float32{0, 1}},
+	}
+	bulk := NewInMemoryStore()
+	if err := StoreMemories(ctx, bulk, "s", writes); err != nil {
+		t.Fatalf("StoreMemories: %v", err)
+	}
+	// Wrapping the store hides its bulk method, forcing per-record writes.
+	single := NewInMemoryStore()
+	if err := StoreMemories(ctx, struct{ VectorStore }{single}, "s", writes); err != nil {
+		t.Fatalf("StoreMemories fallback: %v", err)
+	}
+	for name, st := range map[string]*InMemoryStore{"bulk": bulk, "single": single} {
+		var got []string
+		_ = st.Iterate(ctx, func(rec model.MemoryRecord) bool {
+			got = append(got, rec.Space+"/"+rec.Content)
+			return true
+		})
+		sort.Strings(got)
+		if len(got) != 2 || got[0] != "s/one" || got[1] != "team/two" {
+			t.Fatalf("%s: unexpected records %v", name, got)
+		}
+	}
+	if err := bulk.StoreMemories(ctx, "s", []MemoryWrite{{Content: "bad", Embedding: []float32{1, 2, 3}}}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch, got %v", err)
+	}
+}
+
+func TestInMemoryStoreSearchMemoryIsScopedToTenant(t *testing.T) {
+	s := NewInMemoryStore()
+	ctx := context.Background()
+	acme := model.ContextWithTenant(ctx, "acme")
+	_ = s.StoreMemory(ctx, "s", "shared default", nil, []float32{1, 0})
+	_ = s.StoreMemory(ctx, "s", "acme secret", map[string]any{model.MetaTenant: "acme"}, []float32{1, 0})
+
+	for _, tc := range []struct {
+		ctx  context.Context
+		want string
+	}{{ctx, "shared default"}, {acme, "acme secret"}, {model.ContextWithTenant(ctx, "globex"), ""}} {
+		got, err := s.SearchMemory(tc.ctx, "s", []float32{1, 0}, 5)
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		if tc.want == "" {
+			if len(got) != 0 {
+				t.Fatalf("tenant %q sees %+v", model.TenantFromContext(tc.ctx), got)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0].Content != tc.want {
+			t.Fatalf("tenant %q sees %+v, want %q", model.TenantFromContext(tc.ctx), got, tc.want)
+		}
+	}
+}
+
+func TestSearchFilteredInStoreOrFallsBack(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryStore()
+	_ = s.StoreMemory(ctx, "alice", "slack deploy note", map[string]any{"source": "slack", "tags": []string{"deploy"}}, []float32{1, 0})
+	_ = s.StoreMemory(ctx, "alice", "notion deploy doc", map[string]any{"source": "notion", "tags": []string{"deploy"}}, []float32{1, 0.1})
+	_ = s.StoreMemory(ctx, "bob", "bob's slack note", map[string]any{"source": "slack", "tags": "deploy"}, []float32{1, 0})
+	filter := model.Filter{Sessions: []string{"alice"}, Sources: []string{"slack"}, Tags: []string{"deploy"}}
+
+	// Wrapping the store hides SearchMemoryFiltered, forcing the client-side path.
+	for name, vs := range map[string]VectorStore{"store": s, "fallback": struct{ VectorStore }{s}} {
+		got, err := SearchFiltered(ctx, vs, []float32{1, 0}, filter, 5)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 1 || got[0].Content != "slack deploy note" {
+			t.Fatalf("%s: got %+v, want only alice's slack note", name, got)
+		}
+	}
+}
+
+func TestGetMemoriesKeepsOrderAndTenant(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryStore()
+	for _, content := range []string{"one", "two", "three"} {
+		if err := s.StoreMemory(ctx, "s", content, nil, []float32{1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.StoreMemory(model.ContextWithTenant(ctx, "acme"), "s", "theirs", map[string]any{model.MetaTenant: "acme"}, []float32{1}); err != nil {
+		t.Fatal(err)
+	}
+	type iterOnly struct{ VectorStore }
+	for name, vs := range map[string]VectorStore{"getter": s, "scan": iterOnly{s}} {
+		got, err := GetMemories(ctx, vs, []int64{3, 99, 1, 4})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 2 || got[0].Content != "three" || got[1].Content != "one" {
+			t.Fatalf("%s: got %+v, want three then one", name, got)
+		}
+	}
+}
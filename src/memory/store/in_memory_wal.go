@@ -0,0 +1,184 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+const (
+	walPut    = "put"
+	walDelete = "delete"
+	// walSeq records the last ID handed out, so IDs of deleted records are
+	// not reused after compaction.
+	walSeq = "seq"
+)
+
+// walEntry is one line of an InMemoryStore journal. A put carries the full
+// state of a record, so replaying the last put of each ID restores it.
+type walEntry struct {
+	Op     string              `json:"op"`
+	Record *model.MemoryRecord `json:"record,omitempty"`
+	IDs    []int64             `json:"ids,omitempty"`
+	LastID int64               `json:"last_id,omitempty"`
+}
+
+// NewInMemoryStoreFromWAL returns an InMemoryStore journaled to the
+// append-only file at path, so a deployment without a database keeps its
+// memories across restarts. The journal is replayed first, creating it
+// when missing, then compacted to one entry per live record. Every later
+// write, update and delete is appended before it is applied. An entry cut
+// short by a crash is dropped on replay. Close the store to release the
+// file.
+func NewInMemoryStoreFromWAL(path string) (*InMemoryStore, error) {
+	s := NewInMemoryStore()
+	if err := s.replayWAL(path); err != nil {
+		return nil, err
+	}
+	if err := s.CompactWAL(path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *InMemoryStore) replayWAL(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		raw, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// A final line without its newline is a write the crash cut off.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		var entry walEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return fmt.Errorf("journal %s line %d: %w", path, line, err)
+		}
+		s.applyWAL(entry)
+	}
+}
+
+func (s *InMemoryStore) applyWAL(entry walEntry) {
+	switch entry.Op {
+	case walPut:
+		if entry.Record == nil || entry.Record.ID == 0 {
+			return
+		}
+		rec := *entry.Record
+		_ = s.checkWrite("store", rec.Embedding)
+		s.records[rec.ID] = &inMemoryRecord{record: rec, magnitudes: calculateRecordMagnitudes(rec)}
+		s.nextID = max(s.nextID, rec.ID)
+	case walDelete:
+		for _, id := range entry.IDs {
+			delete(s.records, id)
+		}
+	case walSeq:
+		s.nextID = max(s.nextID, entry.LastID)
+	}
+}
+
+// CompactWAL rewrites the journal at path as one put per live record,
+// replacing the file atomically, and journals later writes to it. Call it
+// to bound the journal of a long-running store, or to start journaling a
+// store created with NewInMemoryStore.
+func (s *InMemoryStore) CompactWAL(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	ids := make([]int64, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(walEntry{Op: walSeq, LastID: s.nextID}); err != nil {
+		tmp.Close()
+		return err
+	}
+	for _, id := range ids {
+		rec := s.records[id].record
+		if err := enc.Encode(walEntry{Op: walPut, Record: &rec}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if s.wal != nil {
+		_ = s.wal.Close()
+	}
+	s.wal = f
+	return nil
+}
+
+// journal appends entry to the store's journal, if it has one. Callers
+// hold s.mu and apply the change only once it is journaled.
+func (s *InMemoryStore) journal(entry walEntry) error {
+	if s.wal == nil {
+		return nil
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := s.wal.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("journal %s: %w", entry.Op, err)
+	}
+	return nil
+}
+
+// Close syncs and closes the journal of a store opened with
+// NewInMemoryStoreFromWAL. The records stay readable, but later writes
+// are no longer journaled.
+func (s *InMemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return nil
+	}
+	err := errors.Join(s.wal.Sync(), s.wal.Close())
+	s.wal = nil
+	return err
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func TestInMemoryStoreWALSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "memories.wal")
+	s, err := NewInMemoryStoreFromWAL(path)
+	if err != nil {
+		t.Fatalf("NewInMemoryStoreFromWAL: %v", err)
+	}
+	for _, content := range []string{"keep", "drop", "rescore"} {
+		if err := s.StoreMemory(ctx, "s", content, map[string]any{"source": "chat"}, []float32{1, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.StoreMemory(ctx, "s", "last", nil, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteMemory(ctx, []int64{2, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateImportance(ctx, 3, 0.9); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateEmbedding(ctx, 1, []float32{0, 1}, time.Unix(100, 0).UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// A write cut short by a crash leaves a partial last line.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	_, _ = f.WriteString(`{"op":"put","record":{"id":9,"content":"tor`)
+	f.Close()
+
+	reopened, err := NewInMemoryStoreFromWAL(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	got, _ := GetMemories(ctx, reopened, []int64{1, 2, 3, 9})
+	if len(got) != 2 || got[0].Content != "keep" || got[1].Content != "rescore" {
+		t.Fatalf("replayed records = %+v", got)
+	}
+	if got[0].Embedding[1] != 1 || !got[0].LastEmbedded.Equal(time.Unix(100, 0)) || got[1].Importance != 0.9 {
+		t.Fatalf("updates not replayed: %+v", got)
+	}
+	if reopened.Dimensions() != 2 {
+		t.Fatalf("dimensions = %d, want 2 from the journal", reopened.Dimensions())
+	}
+
+	// Replay compacted the journal to one line per live record, and new IDs
+	// continue after the highest one handed out.
+	raw, _ := os.ReadFile(path)
+	if lines := strings.Count(string(raw), "\n"); lines != 3 {
+		t.Fatalf("compacted journal has %d lines, want the ID sequence and 2 records", lines)
+	}
+	if err := reopened.StoreMemory(ctx, "s", "after restart", nil, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	_ = reopened.Iterate(ctx, func(rec model.MemoryRecord) bool { ids = append(ids, rec.ID); return true })
+	if ids[len(ids)-1] != 5 {
+		t.Fatalf("ids = %v, want the new record to get id 5, not the deleted 4", ids)
+	}
+}
@@ -0,0 +1,467 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// ErrNotQuarantined is returned when a quarantine ID is unknown.
+var ErrNotQuarantined = errors.New("memory is not quarantined")
+
+// ErrQuarantined is returned by StoreMemory for a write held for review.
+var ErrQuarantined = errors.New("memory write quarantined")
+
+// Integrity rules reported in IntegrityFinding.Rule.
+const (
+	RuleBurst         = "burst"
+	RuleInstruction   = "instruction"
+	RuleContradiction = "contradiction"
+)
+
+// IntegrityStore screens writes to a VectorStore for signs of memory
+// poisoning and holds suspicious ones in quarantine instead of storing them.
+// It flags a source writing in a sudden burst, instruction-like content
+// written to a shared space, and content contradicting a pinned fact.
+//
+// StoreMemory reports a quarantined write with an error wrapping
+// ErrQuarantined, so a legitimate writer caught by a rule finds out, and
+// the write waits for Approve or Reject. At most MaxQuarantined writes are
+// held; the oldest are discarded first.
+type IntegrityStore struct {
+	primary VectorStore
+	opts    IntegrityOptions
+
+	mu         sync.Mutex
+	writes     map[string][]time.Time // burst key -> recent write times
+	pinned     []string
+	quarantine map[int64]QuarantinedMemory
+	nextID     int64
+}
+
+// IntegrityOptions configures an IntegrityStore.
+type IntegrityOptions struct {
+	// BurstLimit is how many writes one source may make to one session of
+	// one tenant within BurstWindow before further writes are quarantined.
+	// Defaults to 30 per minute.
+	BurstLimit  int
+	BurstWindow time.Duration
+	// MaxQuarantined caps the writes held for review; 1000 when zero.
+	MaxQuarantined int
+	// Source names the writer of a record. Defaults to the metadata
+	// "author", "agent" or "source" value, then the session ID.
+	Source func(sessionID string, metadata map[string]any) string
+	// Shared reports whether sessionID is a shared space, where
+	// instruction-like content is quarantined. Defaults to IDs containing
+	// a colon, such as "team:alpha".
+	Shared func(sessionID string) bool
+	// Contradicts reports whether content contradicts a pinned fact.
+	// Defaults to a word-overlap heuristic that looks for the same claim
+	// with the opposite polarity.
+	Contradicts func(fact, content string) bool
+	// OnQuarantine is called for every quarantined write.
+	OnQuarantine func(QuarantinedMemory)
+	// Logger receives one line per quarantined write. Defaults to stderr
+	// with a "memory-integrity: " prefix.
+	Logger *log.Logger
+	now    func() time.Time
+}
+
+// IntegrityFinding is one reason a write was quarantined.
+type IntegrityFinding struct {
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"`
+}
+
+// QuarantinedMemory is a write held for review.
+type QuarantinedMemory struct {
+	ID        int64              `json:"id"`
+	SessionID string             `json:"session_id"`
+	Source    string             `json:"source"`
+	Content   string             `json:"content"`
+	Metadata  map[string]any     `json:"metadata,omitempty"`
+	Embedding []float32          `json:"-"`
+	Findings  []IntegrityFinding `json:"findings"`
+	At        time.Time          `json:"at"`
+}
+
+var (
+	_ VectorStore       = (*IntegrityStore)(nil)
+	_ GraphStore        = (*IntegrityStore)(nil)
+	_ SchemaInitializer = (*IntegrityStore)(nil)
+)
+
+// NewIntegrityStore screens writes to primary.
+func NewIntegrityStore(primary VectorStore, opts IntegrityOptions) (*IntegrityStore, error) {
+	if primary == nil {
+		return nil, errors.New("primary vector store is nil")
+	}
+	if opts.BurstLimit <= 0 {
+		opts.BurstLimit = 30
+	}
+	if opts.BurstWindow <= 0 {
+		opts.BurstWindow = time.Minute
+	}
+	if opts.MaxQuarantined <= 0 {
+		opts.MaxQuarantined = defaultMaxQuarantined
+	}
+	if opts.Source == nil {
+		opts.Source = defaultIntegritySource
+	}
+	if opts.Shared == nil {
+		opts.Shared = func(sessionID string) bool { return strings.Contains(sessionID, ":") }
+	}
+	if opts.Contradicts == nil {
+		opts.Contradicts = Contradicts
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "memory-integrity: ", log.LstdFlags)
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	return &IntegrityStore{
+		primary:    primary,
+		opts:       opts,
+		writes:     map[string][]time.Time{},
+		quarantine: map[int64]QuarantinedMemory{},
+	}, nil
+}
+
+const defaultMaxQuarantined = 1000
+
+// Primary returns the store approved writes go to.
+func (s *IntegrityStore) Primary() VectorStore { return s.primary }
+
+// Pin records facts that later writes must not contradict.
+func (s *IntegrityStore) Pin(facts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range facts {
+		if f = strings.TrimSpace(f); f != "" {
+			s.pinned = append(s.pinned, f)
+		}
+	}
+}
+
+// Pinned returns the pinned facts.
+func (s *IntegrityStore) Pinned() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.pinned...)
+}
+
+// StoreMemory stores the record, or quarantines it if it trips a rule.
+func (s *IntegrityStore) StoreMemory(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
+	source := s.opts.Source(sessionID, metadata)
+	findings := s.check(burstKey(ctx, sessionID, source), sessionID, source, content)
+	if len(findings) == 0 {
+		return s.primary.StoreMemory(ctx, sessionID, content, metadata, embedding)
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	q := QuarantinedMemory{
+		ID:        s.nextID,
+		SessionID: sessionID,
+		Source:    source,
+		Content:   content,
+		Metadata:  metadata,
+		Embedding: embedding,
+		Findings:  findings,
+		At:        s.opts.now().UTC(),
+	}
+	s.quarantine[q.ID] = q
+	dropped := s.trimQuarantineLocked()
+	s.mu.Unlock()
+
+	rules := make([]string, len(findings))
+	for i, f := range findings {
+		rules[i] = f.Rule
+	}
+	s.opts.Logger.Printf("quarantined #%d session=%q source=%q rules=%s", q.ID, sessionID, source, strings.Join(rules, ","))
+	if dropped > 0 {
+		s.opts.Logger.Printf("quarantine full: discarded %d oldest held writes", dropped)
+	}
+	if s.opts.OnQuarantine != nil {
+		s.opts.OnQuarantine(q)
+	}
+	return fmt.Errorf("%w as #%d: %s", ErrQuarantined, q.ID, strings.Join(rules, ","))
+}
+
+// trimQuarantineLocked discards the oldest held writes past
+// MaxQuarantined and reports how many went. The caller holds s.mu.
+func (s *IntegrityStore) trimQuarantineLocked() int {
+	excess := len(s.quarantine) - s.opts.MaxQuarantined
+	if excess <= 0 {
+		return 0
+	}
+	ids := make([]int64, 0, len(s.quarantine))
+	for id := range s.quarantine {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids[:excess] {
+		delete(s.quarantine, id)
+	}
+	return excess
+}
+
+// burstKey separates the burst windows of writers that share a source
+// name, such as every engine write tagged "default", by tenant and session.
+func burstKey(ctx context.Context, sessionID, source string) string {
+	return model.TenantFromContext(ctx) + "\x1f" + sessionID + "\x1f" + source
+}
+
+// check applies the rules to a write and counts it toward the burst
+// window of key.
+func (s *IntegrityStore) check(key, sessionID, source, content string) []IntegrityFinding {
+	var findings []IntegrityFinding
+
+	s.mu.Lock()
+	now := s.opts.now()
+	recent := s.writes[key][:0]
+	for _, at := range s.writes[key] {
+		if now.Sub(at) < s.opts.BurstWindow {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	s.writes[key] = recent
+	if len(s.writes) > maxBurstKeys {
+		s.sweepWritesLocked(now)
+	}
+	pinned := append([]string(nil), s.pinned...)
+	s.mu.Unlock()
+
+	if len(recent) > s.opts.BurstLimit {
+		findings = append(findings, IntegrityFinding{
+			Rule:   RuleBurst,
+			Detail: fmt.Sprintf("%d writes from %s within %s", len(recent), source, s.opts.BurstWindow),
+		})
+	}
+	if s.opts.Shared(sessionID) {
+		if phrase := instructionPhrase(content); phrase != "" {
+			findings = append(findings, IntegrityFinding{
+				Rule:   RuleInstruction,
+				Detail: fmt.Sprintf("instruction-like %q in shared space %s", phrase, sessionID),
+			})
+		}
+	}
+	for _, fact := range pinned {
+		if s.opts.Contradicts(fact, content) {
+			findings = append(findings, IntegrityFinding{Rule: RuleContradiction, Detail: "contradicts pinned fact: " + fact})
+		}
+	}
+	return findings
+}
+
+// maxBurstKeys is how many burst windows are kept before idle ones are
+// swept.
+const maxBurstKeys = 4096
+
+// sweepWritesLocked forgets the burst windows with no write inside
+// BurstWindow. The caller holds s.mu.
+func (s *IntegrityStore) sweepWritesLocked(now time.Time) {
+	for key, times := range s.writes {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= s.opts.BurstWindow {
+			delete(s.writes, key)
+		}
+	}
+}
+
+// Quarantined returns the writes awaiting review, oldest first.
+func (s *IntegrityStore) Quarantined() []QuarantinedMemory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]QuarantinedMemory, 0, len(s.quarantine))
+	for _, q := range s.quarantine {
+		out = append(out, q)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Approve releases a quarantined write to the primary store.
+func (s *IntegrityStore) Approve(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	q, ok := s.quarantine[id]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrNotQuarantined, id)
+	}
+	if err := s.primary.StoreMemory(ctx, q.SessionID, q.Content, q.Metadata, q.Embedding); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.quarantine, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// Reject discards a quarantined write.
+func (s *IntegrityStore) Reject(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.quarantine[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrNotQuarantined, id)
+	}
+	delete(s.quarantine, id)
+	return nil
+}
+
+// SearchMemory searches the primary; quarantined writes are never returned.
+func (s *IntegrityStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	return s.primary.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+}
+
+// SearchMemoryFiltered searches the primary with filter.
+func (s *IntegrityStore) SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	return SearchFiltered(ctx, s.primary, queryEmbedding, filter, limit)
+}
+
+// UpdateEmbedding forwards to the primary.
+func (s *IntegrityStore) UpdateEmbedding(ctx context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
+	return s.primary.UpdateEmbedding(ctx, id, embedding, lastEmbedded)
+}
+
+// UpdateImportance forwards to the primary when it supports it.
+func (s *IntegrityStore) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if u, ok := s.primary.(ImportanceUpdater); ok {
+		return u.UpdateImportance(ctx, id, importance)
+	}
+	return nil
+}
+
+// UpdateMetadata forwards to the primary. Metadata changes are not checked
+// against the integrity rules, which only look at content.
+func (s *IntegrityStore) UpdateMetadata(ctx context.Context, record model.MemoryRecord) error {
+	if u, ok := s.primary.(MetadataUpdater); ok {
+		return u.UpdateMetadata(ctx, record)
+	}
+	return ErrMetadataUpdatesUnsupported
+}
+
+// DeleteMemory forwards to the primary.
+func (s *IntegrityStore) DeleteMemory(ctx context.Context, ids []int64) error {
+	return s.primary.DeleteMemory(ctx, ids)
+}
+
+// Iterate walks the primary.
+func (s *IntegrityStore) Iterate(ctx context.Context, fn func(model.MemoryRecord) bool) error {
+	return s.primary.Iterate(ctx, fn)
+}
+
+// Count reports the primary's record count.
+func (s *IntegrityStore) Count(ctx context.Context) (int, error) {
+	return s.primary.Count(ctx)
+}
+
+// CreateSchema initialises the primary.
+func (s *IntegrityStore) CreateSchema(ctx context.Context, schemaPath string) error {
+	if initializer, ok := s.primary.(SchemaInitializer); ok {
+		return initializer.CreateSchema(ctx, schemaPath)
+	}
+	return nil
+}
+
+// UpsertGraph forwards to the primary when it is a GraphStore.
+func (s *IntegrityStore) UpsertGraph(ctx context.Context, record model.MemoryRecord, edges []model.GraphEdge) error {
+	if graph, ok := s.primary.(GraphStore); ok {
+		return graph.UpsertGraph(ctx, record, edges)
+	}
+	return nil
+}
+
+// Neighborhood forwards to the primary when it is a GraphStore.
+func (s *IntegrityStore) Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
+	if graph, ok := s.primary.(GraphStore); ok {
+		return graph.Neighborhood(ctx, sessionID, seedIDs, hops, limit)
+	}
+	return nil, nil
+}
+
+// QueryGraph answers q from the primary.
+func (s *IntegrityStore) QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	return QueryGraph(ctx, s.primary, q)
+}
+
+func defaultIntegritySource(sessionID string, metadata map[string]any) string {
+	for _, key := range []string{"author", "agent", "source"} {
+		if v, ok := metadata[key].(string); ok && strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return sessionID
+}
+
+// instructionPatterns match text addressed to an agent rather than facts
+// about the world: prompt-injection phrasing planted for other readers.
+var instructionPatterns = regexp.MustCompile(`(?i)\b(ignore|disregard|forget) (all |any |the )?(previous|prior|above|earlier|your) (instructions|rules|prompts?|messages)\b` +
+	`|\b(you must|you should|you are) now\b` +
+	`|\bfrom now on\b` +
+	`|\bnew (system )?instructions?\b` +
+	`|\b(reveal|print|output) (your|the) (system prompt|instructions|secrets?|api keys?)\b` +
+	`|\bact as (an? )?(admin|administrator|root|system|developer)\b` +
+	`|<\s*/?\s*system\s*>` +
+	`|\bsystem prompt\b`)
+
+func instructionPhrase(content string) string {
+	return instructionPatterns.FindString(content)
+}
+
+var negationWords = map[string]bool{
+	"not": true, "no": true, "never": true, "none": true, "cannot": true, "nobody": true, "nothing": true,
+	"isn't": true, "aren't": true, "wasn't": true, "weren't": true, "don't": true, "doesn't": true,
+	"didn't": true, "won't": true, "can't": true, "shouldn't": true, "mustn't": true, "false": true,
+}
+
+// Contradicts reports whether content makes the claim of fact with the
+// opposite polarity: most of the fact's words appear in content, and
+// exactly one of them is negated.
+func Contradicts(fact, content string) bool {
+	factWords, factNeg := claimWords(fact)
+	contentWords, contentNeg := claimWords(content)
+	if len(factWords) == 0 || factNeg == contentNeg {
+		return false
+	}
+	shared := 0
+	for w := range factWords {
+		if contentWords[w] {
+			shared++
+		}
+	}
+	return float64(shared)/float64(len(factWords)) >= 0.6
+}
+
+func claimWords(s string) (map[string]bool, bool) {
+	words := map[string]bool{}
+	negated := false
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r == '\'' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if negationWords[w] || strings.HasSuffix(w, "n't") {
+			negated = !negated
+			continue
+		}
+		if len(w) > 2 && !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words, negated
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "are": true, "was": true, "were": true, "has": true, "have": true,
+	"for": true, "that": true, "this": true, "with": true, "its": true, "our": true, "your": true,
+	"any": true, "all": true, "only": true, "also": true, "does": true, "did": true, "will": true,
+}
@@ -16,10 +16,15 @@ type MongoStore struct {
 	client            *mongo.Client
 	collection        *mongo.Collection
 	counterCollection *mongo.Collection
+
+	dimensionGuard
 }
 
 const mongoCloseTimeout = 5 * time.Second
 
+// defaultMongoVectorDimensions matches DummyEmbedding and the Postgres schema.
+const defaultMongoVectorDimensions = 768
+
 func NewMongoStore(ctx context.Context, uri, database, collection string) (*MongoStore, error) {
 	if uri == "" {
 		return nil, errors.New("mongo uri is required")
@@ -50,18 +55,49 @@ func (ms *MongoStore) StoreMemory(ctx context.Context, sessionID, content string
 	if ms == nil || ms.collection == nil {
 		return nil
 	}
-	now := time.Now().UTC()
-	record := prepareMemoryRecord(sessionID, content, metadata, embedding, now, true)
-
+	if err := ms.checkWrite("store", embedding); err != nil {
+		return err
+	}
 	id, err := ms.nextID(ctx)
 	if err != nil {
 		return err
 	}
+	_, err = ms.collection.InsertOne(ctx, mongoDocument(id, sessionID, content, metadata, embedding, time.Now().UTC()))
+	return err
+}
+
+// StoreMemories reserves IDs for writes with one counter update and inserts
+// them with InsertMany.
+func (ms *MongoStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	if ms == nil || ms.collection == nil || len(writes) == 0 {
+		return nil
+	}
+	for _, w := range writes {
+		if err := ms.checkWrite("store", w.Embedding); err != nil {
+			return err
+		}
+	}
+	last, err := ms.reserveIDs(ctx, len(writes))
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	first := last - int64(len(writes)) + 1
+	docs := make([]any, len(writes))
+	for i, w := range writes {
+		docs[i] = mongoDocument(first+int64(i), sessionID, w.Content, w.Metadata, w.Embedding, now)
+	}
+	_, err = ms.collection.InsertMany(ctx, docs)
+	return err
+}
 
+func mongoDocument(id int64, sessionID, content string, metadata map[string]any, embedding []float32, now time.Time) bson.M {
+	record := prepareMemoryRecord(sessionID, content, metadata, embedding, now, true)
 	doc := bson.M{
 		"_id":           id,
 		"session_id":    sessionID,
 		"space":         record.Space,
+		"tenant_id":     record.TenantID,
 		"content":       content,
 		"metadata":      record.Metadata,
 		"embedding":     float64Embedding(record.Embedding),
@@ -77,16 +113,18 @@ func (ms *MongoStore) StoreMemory(ctx context.Context, sessionID, content string
 	if len(record.GraphEdges) > 0 {
 		doc["graph_edges"] = record.GraphEdges
 	}
-	_, err = ms.collection.InsertOne(ctx, doc)
-	return err
+	return doc
 }
 
 func (ms *MongoStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
 	if ms == nil || ms.collection == nil || limit <= 0 {
 		return nil, nil
 	}
+	if err := ms.check("search", queryEmbedding); err != nil {
+		return nil, err
+	}
 
-	pipeline := mongoVectorSearchPipeline(sessionID, queryEmbedding, limit)
+	pipeline := mongoVectorSearchPipeline(sessionID, model.TenantFromContext(ctx), queryEmbedding, limit)
 
 	cursor, err := ms.collection.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -119,7 +157,10 @@ func mongoCosineSimilarity(vectorSearchScore float64) float64 {
 	return vectorSearchScore*2 - 1
 }
 
-func mongoVectorSearchPipeline(sessionID string, queryEmbedding []float32, limit int) mongo.Pipeline {
+// mongoVectorSearchPipeline searches the documents of sessionID, when set,
+// that belong to tenant. Documents written before tenants existed have no
+// tenant_id field, so the default tenant matches a missing field too.
+func mongoVectorSearchPipeline(sessionID, tenant string, queryEmbedding []float32, limit int) mongo.Pipeline {
 	vectorSearch := bson.D{
 		{Key: "index", Value: "vector_index"},
 		{Key: "path", Value: "embedding"},
@@ -127,14 +168,16 @@ func mongoVectorSearchPipeline(sessionID string, queryEmbedding []float32, limit
 		{Key: "numCandidates", Value: int64(limit) * 10}, // Oversample for better accuracy
 		{Key: "limit", Value: int64(limit)},
 	}
+	// Filtering inside $vectorSearch avoids retrieving global candidates
+	// that a later $match would discard and potentially under-fill.
+	filter := bson.D{{Key: "tenant_id", Value: tenant}}
+	if tenant == "" {
+		filter = bson.D{{Key: "tenant_id", Value: bson.D{{Key: "$in", Value: bson.A{"", nil}}}}}
+	}
 	if sessionID != "" {
-		// Filtering inside $vectorSearch avoids retrieving global candidates
-		// that a later $match would discard and potentially under-fill.
-		vectorSearch = append(vectorSearch, bson.E{
-			Key:   "filter",
-			Value: bson.D{{Key: "session_id", Value: sessionID}},
-		})
+		filter = append(filter, bson.E{Key: "session_id", Value: sessionID})
 	}
+	vectorSearch = append(vectorSearch, bson.E{Key: "filter", Value: filter})
 
 	// Use $vectorSearch for efficient similarity search in MongoDB Atlas.
 	pipeline := mongo.Pipeline{
@@ -155,6 +198,9 @@ func (ms *MongoStore) UpdateEmbedding(ctx context.Context, id int64, embedding [
 	if ms == nil || ms.collection == nil {
 		return nil
 	}
+	if err := ms.checkWrite("update", embedding); err != nil {
+		return err
+	}
 	_, err := ms.collection.UpdateByID(ctx, id, bson.M{
 		"$set": bson.M{
 			"embedding":     float64Embedding(embedding),
@@ -164,6 +210,28 @@ func (ms *MongoStore) UpdateEmbedding(ctx context.Context, id int64, embedding [
 	return err
 }
 
+func (ms *MongoStore) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if ms == nil || ms.collection == nil {
+		return nil
+	}
+	_, err := ms.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"importance": importance}})
+	return err
+}
+
+func (ms *MongoStore) UpdateMetadata(ctx context.Context, record model.MemoryRecord) error {
+	if ms == nil || ms.collection == nil {
+		return nil
+	}
+	meta := model.DecodeMetadata(record.Metadata)
+	_, err := ms.collection.UpdateByID(ctx, record.ID, bson.M{"$set": bson.M{
+		"metadata":    model.StringFromAny(meta),
+		"source":      model.StringFromAny(meta["source"]),
+		"summary":     model.StringFromAny(meta["summary"]),
+		"graph_edges": model.ValidGraphEdges(meta),
+	}})
+	return err
+}
+
 func (ms *MongoStore) DeleteMemory(ctx context.Context, ids []int64) error {
 	if ms == nil || ms.collection == nil || len(ids) == 0 {
 		return nil
@@ -203,10 +271,15 @@ func (ms *MongoStore) Count(ctx context.Context) (int, error) {
 }
 
 // CreateSchema ensures the primary collection has useful indexes and initializes the counter collection.
+// The vector index uses Dimensions when set (see SetDimensions), else 768.
 func (ms *MongoStore) CreateSchema(ctx context.Context, _ string) error {
 	if ms == nil || ms.collection == nil {
 		return nil
 	}
+	dims := ms.Dimensions()
+	if dims == 0 {
+		dims = defaultMongoVectorDimensions
+	}
 
 	indexes := []mongo.IndexModel{
 		{
@@ -217,6 +290,10 @@ func (ms *MongoStore) CreateSchema(ctx context.Context, _ string) error {
 			Keys:    bson.D{{Key: "space", Value: 1}},
 			Options: options.Index().SetName("space"),
 		},
+		{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "session_id", Value: 1}},
+			Options: options.Index().SetName("tenant_session"),
+		},
 		// Vector search index for Atlas
 		{
 			Keys: bson.D{
@@ -225,7 +302,7 @@ func (ms *MongoStore) CreateSchema(ctx context.Context, _ string) error {
 			Options: options.Index().
 				SetName("vector_index").
 				SetWeights(bson.D{
-					{Key: "numDimensions", Value: 768}, // Assuming 768, adjust as needed
+					{Key: "numDimensions", Value: dims},
 					{Key: "similarity", Value: "cosine"},
 					{Key: "type", Value: "ivf"},
 				}),
@@ -234,6 +311,7 @@ func (ms *MongoStore) CreateSchema(ctx context.Context, _ string) error {
 	if _, err := ms.collection.Indexes().CreateMany(ctx, indexes); err != nil {
 		return err
 	}
+	ms.SetDimensions(dims)
 
 	if ms.counterCollection != nil {
 		_, err := ms.counterCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
@@ -249,11 +327,16 @@ func (ms *MongoStore) CreateSchema(ctx context.Context, _ string) error {
 }
 
 func (ms *MongoStore) nextID(ctx context.Context) (int64, error) {
+	return ms.reserveIDs(ctx, 1)
+}
+
+// reserveIDs advances the ID counter by n and returns the last ID reserved.
+func (ms *MongoStore) reserveIDs(ctx context.Context, n int) (int64, error) {
 	if ms.counterCollection == nil {
 		return 0, errors.New("mongo counter collection is not configured")
 	}
 	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
-	res := ms.counterCollection.FindOneAndUpdate(ctx, bson.M{"_id": ms.collection.Name()}, bson.M{"$inc": bson.M{"seq": 1}}, opts)
+	res := ms.counterCollection.FindOneAndUpdate(ctx, bson.M{"_id": ms.collection.Name()}, bson.M{"$inc": bson.M{"seq": n}}, opts)
 	if res.Err() != nil {
 		return 0, res.Err()
 	}
@@ -270,6 +353,7 @@ type mongoMemoryDocument struct {
 	ID           int64             `bson:"_id"`
 	SessionID    string            `bson:"session_id"`
 	Space        string            `bson:"space"`
+	TenantID     string            `bson:"tenant_id,omitempty"`
 	Content      string            `bson:"content"`
 	Metadata     string            `bson:"metadata"`
 	Embedding    []float64         `bson:"embedding"`
@@ -287,6 +371,7 @@ func (doc mongoMemoryDocument) toRecord() model.MemoryRecord {
 		ID:              doc.ID,
 		SessionID:       doc.SessionID,
 		Space:           doc.Space,
+		TenantID:        doc.TenantID,
 		Content:         doc.Content,
 		Metadata:        doc.Metadata,
 		Embedding:       float32Embedding(doc.Embedding),
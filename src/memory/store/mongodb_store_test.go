@@ -104,7 +104,7 @@ func TestMongoStoreCreateSchemaOnNilStore(t *testing.T) {
 }
 
 func TestMongoVectorSearchPipelinePushesDownSessionFilter(t *testing.T) {
-	pipeline := mongoVectorSearchPipeline("session-1", []float32{1, 0}, 8)
+	pipeline := mongoVectorSearchPipeline("session-1", "acme", []float32{1, 0}, 8)
 	if len(pipeline) != 2 {
 		t.Fatalf("pipeline has %d stages, want 2", len(pipeline))
 	}
@@ -128,6 +128,9 @@ func TestMongoVectorSearchPipelinePushesDownSessionFilter(t *testing.T) {
 	if got, ok := documentValue(filterDoc, "session_id"); !ok || got != "session-1" {
 		t.Fatalf("session filter = %#v, want session-1", got)
 	}
+	if got, ok := documentValue(filterDoc, "tenant_id"); !ok || got != "acme" {
+		t.Fatalf("tenant filter = %#v, want acme", got)
+	}
 	for _, stage := range pipeline {
 		if _, ok := documentValue(stage, "$match"); ok {
 			t.Fatalf("session filter must not use a post-search $match: %#v", pipeline)
@@ -206,6 +206,7 @@ func (s *Neo4jStore) UpsertGraph(ctx context.Context, record model.MemoryRecord,
 		"id":            record.ID,
 		"session_id":    record.SessionID,
 		"space":         space,
+		"tenant_id":     model.RecordTenant(record),
 		"content":       record.Content,
 		"metadata":      sanitizeMetadata(record.Metadata),
 		"importance":    record.Importance,
@@ -239,6 +240,7 @@ func (s *Neo4jStore) UpsertGraph(ctx context.Context, record model.MemoryRecord,
 		validEdges = append(validEdges, map[string]any{
 			"target":    edge.Target,
 			"edge_type": string(edge.Type),
+			"weight":    edge.Strength(),
 		})
 	}
 	if len(validEdges) > 0 {
@@ -263,7 +265,9 @@ func (s *Neo4jStore) UpsertGraph(ctx context.Context, record model.MemoryRecord,
 	return nil
 }
 
-// Neighborhood returns the nodes within the requested number of hops from the provided seeds.
+// Neighborhood returns the nodes of the tenant on ctx within the requested
+// number of hops from the provided seeds, nearest first and then by the
+// heaviest path to them.
 func (s *Neo4jStore) Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
 	if s.driver == nil {
 		return nil, ErrNeo4jUnavailable
@@ -280,6 +284,7 @@ func (s *Neo4jStore) Neighborhood(ctx context.Context, sessionID string, seedIDs
 		"seed_ids":   seedIDs,
 		"hops":       hops,
 		"session_id": sessionID, // Pass sessionID to the query
+		"tenant_id":  model.TenantFromContext(ctx),
 		"limit":      limit,
 	}
 	result, err := session.Run(ctx, neo4jNeighborhoodQuery, params)
@@ -306,6 +311,49 @@ func (s *Neo4jStore) Neighborhood(ctx context.Context, sessionID string, seedIDs
 	return records, nil
 }
 
+// QueryGraph implements GraphQuerier. Each hop's relationships come from
+// Neo4j; the memories they reach are read from the base store.
+func (s *Neo4jStore) QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	if s.driver == nil {
+		return nil, ErrNeo4jUnavailable
+	}
+	return walkGraph(ctx, q, s.graphLinks, func(ctx context.Context, ids []int64) ([]model.MemoryRecord, error) {
+		return GetMemories(ctx, s.base, ids)
+	})
+}
+
+func (s *Neo4jStore) graphLinks(ctx context.Context, ids []int64, dir model.GraphDirection) ([]model.GraphHop, error) {
+	session, err := s.driver.NewSession(ctx, Neo4jSessionConfig{AccessMode: AccessModeRead, DatabaseName: s.database})
+	if err != nil {
+		return nil, fmt.Errorf("neo4j new session: %w", err)
+	}
+	defer session.Close(ctx)
+	result, err := session.Run(ctx, neo4jGraphLinksQuery, map[string]any{"ids": ids, "direction": string(dir)})
+	if err != nil {
+		return nil, fmt.Errorf("neo4j graph links: %w", err)
+	}
+	defer result.Close(ctx)
+	var links []model.GraphHop
+	for result.Next(ctx) {
+		rec := result.Record()
+		var link model.GraphHop
+		if v, ok := rec.Get("from_id"); ok {
+			link.From = toInt64(v)
+		}
+		if v, ok := rec.Get("to_id"); ok {
+			link.To = toInt64(v)
+		}
+		if v, ok := rec.Get("edge_type"); ok {
+			link.Type = model.EdgeType(toString(v))
+		}
+		if v, ok := rec.Get("weight"); ok {
+			link.Weight = toFloat64(v)
+		}
+		links = append(links, link)
+	}
+	return links, result.Err()
+}
+
 func (s *Neo4jStore) now() time.Time {
 	if s == nil || s.nowFn == nil {
 		return time.Now().UTC()
@@ -336,6 +384,7 @@ MERGE (m:Memory {id: $id})
 ON CREATE SET m.created_at = $created_at
 SET m.session_id = $session_id,
     m.space = $space,
+    m.tenant_id = $tenant_id,
     m.content = $content,
     m.metadata = $metadata,
     m.importance = $importance,
@@ -351,7 +400,13 @@ MERGE (target:Memory {id: edge.target})
 ON CREATE SET target.space = COALESCE(target.space, $space)
 MERGE (m)-[r:RELATED_TO {target_id: edge.target}]->(target)
 SET r.edge_type = edge.edge_type,
+    r.weight = edge.weight,
     r.updated_at = $updated_at
+`
+	neo4jGraphLinksQuery = `
+MATCH (a:Memory)-[r:RELATED_TO]->(b:Memory)
+WHERE ($direction <> 'in' AND a.id IN $ids) OR ($direction <> 'out' AND b.id IN $ids)
+RETURN a.id AS from_id, b.id AS to_id, r.edge_type AS edge_type, coalesce(r.weight, 1.0) AS weight
 `
 	neo4jNeighborhoodQuery = `
 UNWIND $seed_ids AS seed
@@ -359,7 +414,9 @@ MATCH (start:Memory {id: seed})
 MATCH path=(start)-[:RELATED_TO*1..$hops]-(neighbor:Memory)
 WHERE NOT neighbor.id IN $seed_ids
   AND ($session_id = '' OR neighbor.session_id = $session_id) // Filter by session_id
-WITH neighbor, MIN(length(path)) AS depth
+  AND coalesce(neighbor.tenant_id, '') = $tenant_id
+WITH neighbor, path, reduce(w = 1.0, r IN relationships(path) | w * coalesce(r.weight, 1.0)) AS weight
+WITH neighbor, MIN(length(path)) AS depth, MAX(weight) AS weight
 RETURN neighbor.id AS id,
        neighbor.session_id AS session_id,
        neighbor.space AS space,
@@ -370,7 +427,7 @@ RETURN neighbor.id AS id,
        neighbor.summary AS summary,
        neighbor.created_at AS created_at,
        neighbor.last_embedded AS last_embedded
-ORDER BY depth ASC, neighbor.updated_at DESC
+ORDER BY depth ASC, weight DESC, neighbor.updated_at DESC
 LIMIT $limit
 `
 )
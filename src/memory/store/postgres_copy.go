@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// DefaultPostgresCopyThreshold is the batch size from which StoreMemories
+// switches from batched INSERTs to COPY.
+const DefaultPostgresCopyThreshold = 64
+
+// postgresCopyColumns are the columns of the staging table COPY fills. The
+// vector and JSON columns travel as text, which pgx encodes without knowing
+// the pgvector type, and are cast when moved into memory_bank.
+var postgresCopyColumns = []string{"id", "session_id", "content", "metadata", "embedding", "importance", "source", "summary", "last_embedded", "embedding_matrix", "tenant_id"}
+
+const postgresCopyStaging = `
+CREATE TEMP TABLE memory_bank_copy (
+    id BIGINT, session_id TEXT, content TEXT, metadata TEXT, embedding TEXT,
+    importance DOUBLE PRECISION, source TEXT, summary TEXT, last_embedded TIMESTAMPTZ,
+    embedding_matrix TEXT, tenant_id TEXT
+) ON COMMIT DROP`
+
+const postgresCopyInsert = `
+INSERT INTO memory_bank (id, session_id, content, metadata, embedding, importance, source, summary, last_embedded, embedding_matrix, tenant_id)
+SELECT id, session_id, content, metadata::jsonb, embedding::vector, importance, source, summary, last_embedded, embedding_matrix::jsonb, tenant_id
+FROM memory_bank_copy`
+
+// postgresReserveIDs draws ids from the memory_bank sequence up front, since
+// COPY cannot return the ids it assigns.
+const postgresReserveIDs = `SELECT nextval(pg_get_serial_sequence('memory_bank', 'id')) FROM generate_series(1, $1)`
+
+func (ps *PostgresStore) copyThreshold() int {
+	if ps.CopyThreshold > 0 {
+		return ps.CopyThreshold
+	}
+	return DefaultPostgresCopyThreshold
+}
+
+// copyMemories writes records in one transaction through COPY into a
+// staging table, filling in their ids.
+func (ps *PostgresStore) copyMemories(ctx context.Context, records []model.MemoryRecord) error {
+	tx, err := ps.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(ctx, postgresReserveIDs, len(records))
+	if err != nil {
+		return fmt.Errorf("reserve ids: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("reserve ids: %w", err)
+	}
+	if len(ids) != len(records) {
+		return fmt.Errorf("reserve ids: got %d, want %d", len(ids), len(records))
+	}
+	for i := range records {
+		records[i].ID = ids[i]
+	}
+
+	if _, err := tx.Exec(ctx, postgresCopyStaging); err != nil {
+		return fmt.Errorf("create copy staging table: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"memory_bank_copy"}, postgresCopyColumns, pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+		return postgresCopyRow(records[i]), nil
+	})); err != nil {
+		return fmt.Errorf("copy memories: %w", err)
+	}
+	if _, err := tx.Exec(ctx, postgresCopyInsert); err != nil {
+		return fmt.Errorf("insert copied memories: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// postgresCopyRow is one staging row, in postgresCopyColumns order, holding
+// the same values postgresInsertArgs binds.
+func postgresCopyRow(record model.MemoryRecord) []any {
+	args := postgresInsertArgs(record)
+	var matrix *string
+	if raw, _ := args[8].([]byte); len(raw) > 0 {
+		s := string(raw)
+		matrix = &s
+	}
+	return []any{record.ID, args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], matrix, args[9]}
+}
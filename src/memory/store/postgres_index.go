@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Vector index strategies for PostgresIndex.Kind.
+const (
+	PostgresIndexIVFFlat = "ivfflat"
+	PostgresIndexHNSW    = "hnsw"
+	PostgresIndexNone    = "none"
+)
+
+const (
+	defaultIVFFlatLists    = 100
+	defaultHNSWM           = 16
+	defaultHNSWEfConstruct = 64
+	postgresEmbeddingIndex = "memory_embedding_idx"
+)
+
+const postgresEmbeddingIndexQuery = `SELECT indexdef FROM pg_indexes WHERE indexname = '` + postgresEmbeddingIndex + `'`
+
+// postgresIndexOptionRe matches one WITH parameter of an index definition;
+// pg_indexes quotes the values, as in (m='16').
+var postgresIndexOptionRe = regexp.MustCompile(`(\w+)\s*=\s*'?(\d+)'?`)
+
+// PostgresIndex selects the pgvector index CreateSchema builds on
+// memory_bank.embedding. Without one, searches on a large table fall back to
+// a sequential scan. The zero value is the IVFFlat index with 100 lists that
+// the built-in schema has always created.
+type PostgresIndex struct {
+	// Kind is PostgresIndexIVFFlat, PostgresIndexHNSW or PostgresIndexNone.
+	Kind string
+	// Lists is the number of IVFFlat lists; zero means 100.
+	Lists int
+	// M is the number of HNSW links per node; zero means 16.
+	M int
+	// EfConstruction is the HNSW candidate list size during the build; zero
+	// means 64.
+	EfConstruction int
+	// Concurrently builds the index without locking out writes. It is slower
+	// and cannot run inside a transaction.
+	Concurrently bool
+}
+
+// withDefaults fills the zero fields with pgvector's defaults.
+func (idx PostgresIndex) withDefaults() PostgresIndex {
+	idx.Kind = strings.ToLower(strings.TrimSpace(idx.Kind))
+	if idx.Kind == "" {
+		idx.Kind = PostgresIndexIVFFlat
+	}
+	if idx.Lists <= 0 {
+		idx.Lists = defaultIVFFlatLists
+	}
+	if idx.M <= 0 {
+		idx.M = defaultHNSWM
+	}
+	if idx.EfConstruction <= 0 {
+		idx.EfConstruction = defaultHNSWEfConstruct
+	}
+	return idx
+}
+
+// options returns the WITH parameters of the index, in the order pgvector
+// reports them.
+func (idx PostgresIndex) options() [][2]string {
+	switch idx.Kind {
+	case PostgresIndexIVFFlat:
+		return [][2]string{{"lists", strconv.Itoa(idx.Lists)}}
+	case PostgresIndexHNSW:
+		return [][2]string{{"m", strconv.Itoa(idx.M)}, {"ef_construction", strconv.Itoa(idx.EfConstruction)}}
+	}
+	return nil
+}
+
+// postgresIndexSQL returns the CREATE INDEX statement for idx, or "" for
+// PostgresIndexNone. The index uses vector_cosine_ops to match the <=>
+// operator SearchMemory orders by.
+func postgresIndexSQL(idx PostgresIndex) (string, error) {
+	idx = idx.withDefaults()
+	switch idx.Kind {
+	case PostgresIndexNone:
+		return "", nil
+	case PostgresIndexIVFFlat, PostgresIndexHNSW:
+	default:
+		return "", fmt.Errorf("unknown postgres index kind %q", idx.Kind)
+	}
+	params := make([]string, 0, 2)
+	for _, opt := range idx.options() {
+		params = append(params, opt[0]+" = "+opt[1])
+	}
+	concurrently := ""
+	if idx.Concurrently {
+		concurrently = "CONCURRENTLY "
+	}
+	return fmt.Sprintf("CREATE INDEX %sIF NOT EXISTS %s ON memory_bank USING %s (embedding vector_cosine_ops) WITH (%s)",
+		concurrently, postgresEmbeddingIndex, idx.Kind, strings.Join(params, ", ")), nil
+}
+
+// postgresIndexMatches reports whether indexdef, as listed in pg_indexes,
+// is the index idx describes.
+func postgresIndexMatches(indexdef string, idx PostgresIndex) bool {
+	idx = idx.withDefaults()
+	def := strings.ToLower(indexdef)
+	if !strings.Contains(def, "using "+idx.Kind+" ") || !strings.Contains(def, "vector_cosine_ops") {
+		return false
+	}
+	got := map[string]string{}
+	if i := strings.Index(def, " with ("); i >= 0 {
+		for _, m := range postgresIndexOptionRe.FindAllStringSubmatch(def[i:], -1) {
+			got[m[1]] = m[2]
+		}
+	}
+	want := idx.options()
+	if len(got) > len(want) {
+		return false
+	}
+	defaults := PostgresIndex{Kind: idx.Kind}.withDefaults().options()
+	for i, opt := range want {
+		v, ok := got[opt[0]]
+		if !ok {
+			// pgvector omits parameters left at their defaults.
+			v = defaults[i][1]
+		}
+		if v != opt[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// EnsureIndex makes memory_embedding_idx the index idx describes. An index
+// of another kind or with other parameters is dropped and rebuilt; one that
+// already matches is left alone, so the call is cheap to repeat at startup.
+// PostgresIndexNone drops the index.
+func (ps *PostgresStore) EnsureIndex(ctx context.Context, idx PostgresIndex) error {
+	if ps == nil || ps.DB == nil {
+		return nil
+	}
+	idx = idx.withDefaults()
+	create, err := postgresIndexSQL(idx)
+	if err != nil {
+		return err
+	}
+	var existing string
+	if err := ps.DB.QueryRow(ctx, postgresEmbeddingIndexQuery).Scan(&existing); err == nil {
+		if create != "" && postgresIndexMatches(existing, idx) {
+			return nil
+		}
+		drop := "DROP INDEX IF EXISTS " + postgresEmbeddingIndex
+		if idx.Concurrently {
+			drop = "DROP INDEX CONCURRENTLY IF EXISTS " + postgresEmbeddingIndex
+		}
+		if _, err := ps.DB.Exec(ctx, drop); err != nil {
+			return fmt.Errorf("drop vector index: %w", err)
+		}
+	}
+	if create == "" {
+		return nil
+	}
+	if _, err := ps.DB.Exec(ctx, create); err != nil {
+		return fmt.Errorf("create %s index: %w", idx.Kind, err)
+	}
+	return nil
+}
+
+// MaintainIndex refreshes the planner statistics of memory_bank with
+// ANALYZE and, when reindex is set, rebuilds the vector index without
+// blocking writes. IVFFlat lists are fixed when the index is built, so
+// rebuild after the table has grown well past its size at build time;
+// HNSW indexes rarely need it.
+func (ps *PostgresStore) MaintainIndex(ctx context.Context, reindex bool) error {
+	if ps == nil || ps.DB == nil {
+		return nil
+	}
+	if _, err := ps.DB.Exec(ctx, "ANALYZE memory_bank"); err != nil {
+		return fmt.Errorf("analyze memory_bank: %w", err)
+	}
+	if !reindex {
+		return nil
+	}
+	if _, err := ps.DB.Exec(ctx, "REINDEX INDEX CONCURRENTLY "+postgresEmbeddingIndex); err != nil {
+		return fmt.Errorf("reindex %s: %w", postgresEmbeddingIndex, err)
+	}
+	return nil
+}
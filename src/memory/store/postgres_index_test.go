@@ -0,0 +1,43 @@
+package store
+
+import "testing"
+
+func TestPostgresIndexSQL(t *testing.T) {
+	cases := []struct {
+		idx  PostgresIndex
+		want string
+	}{
+		{PostgresIndex{}, "CREATE INDEX IF NOT EXISTS memory_embedding_idx ON memory_bank USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100)"},
+		{PostgresIndex{Kind: "HNSW", M: 32, Concurrently: true}, "CREATE INDEX CONCURRENTLY IF NOT EXISTS memory_embedding_idx ON memory_bank USING hnsw (embedding vector_cosine_ops) WITH (m = 32, ef_construction = 64)"},
+		{PostgresIndex{Kind: PostgresIndexNone}, ""},
+	}
+	for _, tc := range cases {
+		got, err := postgresIndexSQL(tc.idx)
+		if err != nil || got != tc.want {
+			t.Fatalf("postgresIndexSQL(%+v) = %q, %v; want %q", tc.idx, got, err, tc.want)
+		}
+	}
+	if _, err := postgresIndexSQL(PostgresIndex{Kind: "diskann"}); err == nil {
+		t.Fatal("unknown index kind should be rejected")
+	}
+}
+
+func TestPostgresIndexMatchesExistingDefinition(t *testing.T) {
+	const hnsw = "CREATE INDEX memory_embedding_idx ON public.memory_bank USING hnsw (embedding vector_cosine_ops) WITH (m='16', ef_construction='64')"
+	const bareIVF = "CREATE INDEX memory_embedding_idx ON public.memory_bank USING ivfflat (embedding vector_cosine_ops)"
+	if !postgresIndexMatches(hnsw, PostgresIndex{Kind: PostgresIndexHNSW}) {
+		t.Fatal("default HNSW index should match")
+	}
+	if postgresIndexMatches(hnsw, PostgresIndex{Kind: PostgresIndexHNSW, EfConstruction: 128}) {
+		t.Fatal("different ef_construction should not match")
+	}
+	if postgresIndexMatches(hnsw, PostgresIndex{}) {
+		t.Fatal("HNSW index should not match the IVFFlat strategy")
+	}
+	if !postgresIndexMatches(bareIVF, PostgresIndex{Lists: 100}) || postgresIndexMatches(bareIVF, PostgresIndex{Lists: 400}) {
+		t.Fatal("omitted parameters should compare as pgvector defaults")
+	}
+	if postgresIndexMatches("CREATE INDEX memory_embedding_idx ON public.memory_bank USING hnsw (embedding vector_l2_ops)", PostgresIndex{Kind: PostgresIndexHNSW}) {
+		t.Fatal("an L2 index cannot serve cosine searches")
+	}
+}
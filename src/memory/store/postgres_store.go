@@ -19,51 +19,175 @@ import (
 // PostgresStore implements VectorStore using Postgres + pgvector.
 type PostgresStore struct {
 	DB *pgxpool.Pool
+	// Index is the vector index CreateSchema builds; see PostgresIndex.
+	Index PostgresIndex
+	// CopyThreshold is the StoreMemories batch size from which rows are
+	// written with COPY instead of batched INSERTs; zero means
+	// DefaultPostgresCopyThreshold.
+	CopyThreshold int
+
+	dimensionGuard
 }
 
 const postgresCosineDistanceOperator = "<=>"
 const postgresCosineScoreExpression = "1 - (embedding " + postgresCosineDistanceOperator + " $1::vector)"
 
+// PostgresOptions sizes the connection pool of a PostgresStore. Zero fields
+// keep what connStr sets, through pool_max_conns and the other pgxpool DSN
+// parameters, or else the pgxpool defaults.
+type PostgresOptions struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// StatementCacheCapacity is the number of prepared statements each
+	// connection keeps. In pgx's default exec mode every query the store
+	// runs is prepared once per connection and reused; the DSN's
+	// default_query_exec_mode can turn that off, e.g. behind PgBouncer.
+	StatementCacheCapacity int
+}
+
 // NewPostgresStore connects to Postgres and returns a Postgres-backed VectorStore implementation.
 func NewPostgresStore(ctx context.Context, connStr string) (*PostgresStore, error) {
-	db, err := pgxpool.New(ctx, connStr)
+	return NewPostgresStoreWithOptions(ctx, connStr, PostgresOptions{})
+}
+
+// NewPostgresStoreWithOptions is NewPostgresStore with the pool sized by opts.
+func NewPostgresStoreWithOptions(ctx context.Context, connStr string, opts PostgresOptions) (*PostgresStore, error) {
+	cfg, err := postgresPoolConfig(connStr, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Postgres connection string: %w", err)
+	}
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
 	return &PostgresStore{DB: db}, nil
 }
 
+func postgresPoolConfig(connStr string, opts PostgresOptions) (*pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxConns > 0 {
+		cfg.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > 0 {
+		cfg.MinConns = opts.MinConns
+	}
+	if cfg.MinConns > cfg.MaxConns {
+		cfg.MinConns = cfg.MaxConns
+	}
+	if opts.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+	if opts.StatementCacheCapacity > 0 {
+		cfg.ConnConfig.StatementCacheCapacity = opts.StatementCacheCapacity
+	}
+	return cfg, nil
+}
+
 // StoreMemory inserts a long-term record into Postgres.
 func (ps *PostgresStore) StoreMemory(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
 	if ps == nil || ps.DB == nil {
 		return nil
 	}
+	if err := ps.checkWrite("store", embedding); err != nil {
+		return err
+	}
 	record := prepareMemoryRecord(sessionID, content, metadata, embedding, time.Now().UTC(), true)
-	query := `
-                INSERT INTO memory_bank (session_id, content, metadata, embedding, importance, source, summary, last_embedded, embedding_matrix)
-                VALUES ($1, $2, $3::jsonb, $4::vector, $5, $6, $7, $8, $9::jsonb)
+	if err := ps.DB.QueryRow(ctx, postgresInsertMemory, postgresInsertArgs(record)...).Scan(&record.ID); err != nil {
+		return err
+	}
+	if err := ps.UpsertGraph(ctx, record, record.GraphEdges); err != nil {
+		return err
+	}
+	return nil
+}
+
+const postgresInsertMemory = `
+                INSERT INTO memory_bank (session_id, content, metadata, embedding, importance, source, summary, last_embedded, embedding_matrix, tenant_id)
+                VALUES ($1, $2, $3::jsonb, $4::vector, $5, $6, $7, $8, $9::jsonb, $10)
                 RETURNING id;
         `
+
+func postgresInsertArgs(record model.MemoryRecord) []any {
 	var matrixJSON []byte
 	if len(record.EmbeddingMatrix) > 0 {
 		matrixJSON, _ = json.Marshal(record.EmbeddingMatrix)
 	}
-	if err := ps.DB.QueryRow(ctx, query, sessionID, content, record.Metadata, formatVector(record.Embedding), record.Importance, record.Source, record.Summary, record.LastEmbedded, matrixJSON).Scan(&record.ID); err != nil {
+	return []any{record.SessionID, record.Content, record.Metadata, formatVector(record.Embedding), record.Importance, record.Source, record.Summary, record.LastEmbedded, matrixJSON, record.TenantID}
+}
+
+// StoreMemories inserts writes in one transaction, sending the inserts as a
+// single pgx batch, or through COPY once there are CopyThreshold of them.
+func (ps *PostgresStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	if ps == nil || ps.DB == nil || len(writes) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	records := make([]model.MemoryRecord, len(writes))
+	for i, w := range writes {
+		if err := ps.checkWrite("store", w.Embedding); err != nil {
+			return err
+		}
+		records[i] = prepareMemoryRecord(sessionID, w.Content, w.Metadata, w.Embedding, now, true)
+	}
+	if len(records) >= ps.copyThreshold() {
+		if err := ps.copyMemories(ctx, records); err != nil {
+			return err
+		}
+		return ps.upsertGraphs(ctx, records)
+	}
+	batch := &pgx.Batch{}
+	for _, record := range records {
+		batch.Queue(postgresInsertMemory, postgresInsertArgs(record)...)
+	}
+	tx, err := ps.DB.Begin(ctx)
+	if err != nil {
 		return err
 	}
-	if err := ps.UpsertGraph(ctx, record, record.GraphEdges); err != nil {
+	defer func() { _ = tx.Rollback(ctx) }()
+	results := tx.SendBatch(ctx, batch)
+	for i := range records {
+		if err := results.QueryRow().Scan(&records[i].ID); err != nil {
+			_ = results.Close()
+			return err
+		}
+	}
+	if err := results.Close(); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
+	return ps.upsertGraphs(ctx, records)
+}
+
+func (ps *PostgresStore) upsertGraphs(ctx context.Context, records []model.MemoryRecord) error {
+	for _, record := range records {
+		if err := ps.UpsertGraph(ctx, record, record.GraphEdges); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// SearchMemory returns top-k similar memories from Postgres.
+// SearchMemory returns top-k similar memories from Postgres, limited to the
+// tenant on ctx.
 func (ps *PostgresStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
 	if ps == nil || ps.DB == nil || limit <= 0 {
 		return nil, nil
 	}
+	if err := ps.check("search", queryEmbedding); err != nil {
+		return nil, err
+	}
 	var queryBuilder strings.Builder
-	// The ivfflat index in defaultPostgresSchema uses vector_cosine_ops, so
+	// The vector index CreateSchema builds uses vector_cosine_ops, so
 	// retrieval must use pgvector's cosine-distance operator (<=>). Using the
 	// L2 operator (<->) prevents that index from serving the ORDER BY query.
 	queryBuilder.WriteString(`
@@ -71,9 +195,10 @@ func (ps *PostgresStore) SearchMemory(ctx context.Context, sessionID string, que
         FROM memory_bank
         `)
 
-	args := []any{formatVector(queryEmbedding)}
+	args := []any{formatVector(queryEmbedding), model.TenantFromContext(ctx)}
+	queryBuilder.WriteString(" WHERE tenant_id = $2")
 	if sessionID != "" {
-		queryBuilder.WriteString(" WHERE session_id = $" + strconv.Itoa(len(args)+1))
+		queryBuilder.WriteString(" AND session_id = $" + strconv.Itoa(len(args)+1))
 		args = append(args, sessionID)
 	}
 	queryBuilder.WriteString(" ORDER BY embedding " + postgresCosineDistanceOperator + " $1::vector LIMIT $" + strconv.Itoa(len(args)+1))
@@ -114,6 +239,9 @@ func (ps *PostgresStore) UpdateEmbedding(ctx context.Context, id int64, embeddin
 	if ps == nil || ps.DB == nil {
 		return nil
 	}
+	if err := ps.checkWrite("update", embedding); err != nil {
+		return err
+	}
 	_, err := ps.DB.Exec(ctx, `
                 UPDATE memory_bank
                 SET embedding = $2::vector, last_embedded = $3
@@ -122,6 +250,26 @@ func (ps *PostgresStore) UpdateEmbedding(ctx context.Context, id int64, embeddin
 	return err
 }
 
+func (ps *PostgresStore) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if ps == nil || ps.DB == nil {
+		return nil
+	}
+	_, err := ps.DB.Exec(ctx, `UPDATE memory_bank SET importance = $2 WHERE id = $1`, id, importance)
+	return err
+}
+
+// UpdateMetadata rewrites the metadata column and the source and summary
+// columns derived from it.
+func (ps *PostgresStore) UpdateMetadata(ctx context.Context, record model.MemoryRecord) error {
+	if ps == nil || ps.DB == nil {
+		return nil
+	}
+	meta := model.DecodeMetadata(record.Metadata)
+	_, err := ps.DB.Exec(ctx, `UPDATE memory_bank SET metadata = $2::jsonb, source = $3, summary = $4 WHERE id = $1`,
+		record.ID, model.StringFromAny(meta), model.StringFromAny(meta["source"]), model.StringFromAny(meta["summary"]))
+	return err
+}
+
 func (ps *PostgresStore) DeleteMemory(ctx context.Context, ids []int64) error {
 	if ps == nil || ps.DB == nil || len(ids) == 0 {
 		return nil
@@ -162,6 +310,37 @@ func (ps *PostgresStore) Iterate(ctx context.Context, fn func(model.MemoryRecord
 	return rows.Err()
 }
 
+// GetMemories implements RecordGetter with one indexed lookup.
+func (ps *PostgresStore) GetMemories(ctx context.Context, ids []int64) ([]model.MemoryRecord, error) {
+	if ps == nil || ps.DB == nil || len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := ps.DB.Query(ctx, `
+        SELECT id, session_id, content, metadata::text, importance, source, summary, created_at, last_embedded, embedding::text
+        FROM memory_bank
+        WHERE id = ANY($1) AND tenant_id = $2
+        `, ids, model.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := make([]model.MemoryRecord, 0, len(ids))
+	for rows.Next() {
+		var rec model.MemoryRecord
+		var embeddingText string
+		if err := rows.Scan(&rec.ID, &rec.SessionID, &rec.Content, &rec.Metadata, &rec.Importance, &rec.Source, &rec.Summary, &rec.CreatedAt, &rec.LastEmbedded, &embeddingText); err != nil {
+			return nil, err
+		}
+		rec.Embedding = parseVector(embeddingText)
+		model.HydrateRecordFromMetadata(&rec, model.DecodeMetadata(rec.Metadata))
+		if rec.Space == "" {
+			rec.Space = rec.SessionID
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
 func (ps *PostgresStore) Count(ctx context.Context) (int, error) {
 	if ps == nil || ps.DB == nil {
 		return 0, nil
@@ -197,15 +376,17 @@ func (ps *PostgresStore) UpsertGraph(ctx context.Context, record model.MemoryRec
 	}
 	targets := make([]int64, 0, len(edges))
 	edgeTypes := make([]string, 0, len(edges))
+	weights := make([]float64, 0, len(edges))
 	for _, edge := range edges {
 		if err := edge.Validate(); err != nil {
 			continue
 		}
 		targets = append(targets, edge.Target)
 		edgeTypes = append(edgeTypes, string(edge.Type))
+		weights = append(weights, edge.Strength())
 	}
 	if len(targets) > 0 {
-		if _, err = tx.Exec(ctx, postgresUpsertEdgesQuery, targets, edgeTypes, record.ID); err != nil {
+		if _, err = tx.Exec(ctx, postgresUpsertEdgesQuery, targets, edgeTypes, record.ID, weights); err != nil {
 			return err
 		}
 	}
@@ -217,7 +398,7 @@ func (ps *PostgresStore) UpsertGraph(ctx context.Context, record model.MemoryRec
 
 const postgresUpsertEdgesQuery = `
 WITH edge_data AS (
-        SELECT * FROM UNNEST($1::bigint[], $2::text[]) AS edge(target, edge_type)
+        SELECT * FROM UNNEST($1::bigint[], $2::text[], $4::double precision[]) AS edge(target, edge_type, weight)
 ),
 upsert_nodes AS (
         INSERT INTO memory_nodes (memory_id, space, updated_at)
@@ -232,13 +413,53 @@ upsert_nodes AS (
             updated_at = NOW()
         RETURNING memory_id
 )
-INSERT INTO memory_edges (from_memory, to_memory, edge_type)
-SELECT $3, edge.target, edge.edge_type
+INSERT INTO memory_edges (from_memory, to_memory, edge_type, weight)
+SELECT $3, edge.target, edge.edge_type, edge.weight
 FROM edge_data edge
-ON CONFLICT (from_memory, to_memory, edge_type) DO NOTHING
+ON CONFLICT (from_memory, to_memory, edge_type) DO UPDATE SET weight = EXCLUDED.weight
 `
 
-// Neighborhood returns memories connected within the configured hop distance.
+// QueryGraph implements GraphQuerier, reading each hop's edges from
+// memory_edges and the memories they reach with GetMemories.
+func (ps *PostgresStore) QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	if ps == nil || ps.DB == nil {
+		return nil, nil
+	}
+	return walkGraph(ctx, q, ps.graphLinks, ps.GetMemories)
+}
+
+func (ps *PostgresStore) graphLinks(ctx context.Context, ids []int64, dir model.GraphDirection) ([]model.GraphHop, error) {
+	var where string
+	switch dir {
+	case model.GraphOutgoing:
+		where = "from_memory = ANY($1)"
+	case model.GraphIncoming:
+		where = "to_memory = ANY($1)"
+	default:
+		where = "from_memory = ANY($1) OR to_memory = ANY($1)"
+	}
+	rows, err := ps.DB.Query(ctx, `SELECT from_memory, to_memory, edge_type, weight FROM memory_edges WHERE `+where, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var links []model.GraphHop
+	for rows.Next() {
+		var link model.GraphHop
+		var edgeType string
+		if err := rows.Scan(&link.From, &link.To, &edgeType, &link.Weight); err != nil {
+			return nil, err
+		}
+		link.Type = model.EdgeType(edgeType)
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// Neighborhood returns memories of the tenant on ctx connected within the
+// configured hop distance, nearest first. Among memories at the same
+// distance, those whose path has the higher product of edge weights come
+// first.
 func (ps *PostgresStore) Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
 	if ps == nil || ps.DB == nil || len(seedIDs) == 0 || hops <= 0 || limit <= 0 {
 		return nil, nil
@@ -247,30 +468,34 @@ func (ps *PostgresStore) Neighborhood(ctx context.Context, sessionID string, see
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(`
 WITH RECURSIVE walk AS (
-        SELECT UNNEST($1::bigint[]) AS id, 0 AS depth
+        SELECT UNNEST($1::bigint[]) AS id, 0 AS depth, 1::double precision AS weight
         UNION ALL
         SELECT CASE WHEN me.from_memory = walk.id THEN me.to_memory ELSE me.from_memory END AS id,
-               walk.depth + 1 AS depth
+               walk.depth + 1 AS depth,
+               walk.weight * me.weight AS weight
         FROM memory_edges me
         JOIN walk ON me.from_memory = walk.id OR me.to_memory = walk.id
         WHERE walk.depth < $2
 )
+SELECT * FROM (
 SELECT DISTINCT ON (mb.id)
         mb.id, mb.session_id, mb.content, mb.metadata::text, mb.importance, mb.source,
         mb.summary, mb.created_at, mb.last_embedded, mb.embedding::text,
         COALESCE(mn.space, mb.session_id) AS space,
-        walk.depth
+        walk.depth, walk.weight
 FROM walk
 JOIN memory_bank mb ON mb.id = walk.id
 LEFT JOIN memory_nodes mn ON mn.memory_id = mb.id
 WHERE walk.depth > 0 `)
 
-	args := []any{seedIDs, hops, limit}
+	args := []any{seedIDs, hops, limit, model.TenantFromContext(ctx)}
+	queryBuilder.WriteString(" AND mb.tenant_id = $4")
 	if sessionID != "" {
 		queryBuilder.WriteString(" AND mb.session_id = $" + strconv.Itoa(len(args)+1))
 		args = append(args, sessionID)
 	}
-	queryBuilder.WriteString(` ORDER BY mb.id, walk.depth ASC, mb.created_at DESC LIMIT $3;`)
+	queryBuilder.WriteString(` ORDER BY mb.id, walk.depth ASC, walk.weight DESC
+) nearest ORDER BY depth ASC, weight DESC, created_at DESC LIMIT $3;`)
 
 	rows, err := ps.DB.Query(ctx, queryBuilder.String(), args...)
 	if err != nil {
@@ -282,7 +507,8 @@ WHERE walk.depth > 0 `)
 		var rec model.MemoryRecord
 		var embeddingText string
 		var depth int
-		if err := rows.Scan(&rec.ID, &rec.SessionID, &rec.Content, &rec.Metadata, &rec.Importance, &rec.Source, &rec.Summary, &rec.CreatedAt, &rec.LastEmbedded, &embeddingText, &rec.Space, &depth); err != nil {
+		var weight float64
+		if err := rows.Scan(&rec.ID, &rec.SessionID, &rec.Content, &rec.Metadata, &rec.Importance, &rec.Source, &rec.Summary, &rec.CreatedAt, &rec.LastEmbedded, &embeddingText, &rec.Space, &depth, &weight); err != nil {
 			return nil, err
 		}
 		rec.Embedding = parseVector(embeddingText)
@@ -299,7 +525,11 @@ WHERE walk.depth > 0 `)
 	return results, rows.Err()
 }
 
-// CreateSchema ensures pgvector extension and memory table are available.
+// CreateSchema ensures pgvector extension and memory table are available,
+// builds the vector index ps.Index selects, and records the declared
+// vector(N) size of memory_bank.embedding for dimension checks. A custom
+// schema file is expected to create its own index unless ps.Index.Kind is
+// set.
 func (ps *PostgresStore) CreateSchema(ctx context.Context, schemaPath string) error {
 	if ps == nil || ps.DB == nil {
 		return nil
@@ -317,9 +547,24 @@ func (ps *PostgresStore) CreateSchema(ctx context.Context, schemaPath string) er
 	if err != nil {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
+	if schemaPath == "" || ps.Index.Kind != "" {
+		if err := ps.EnsureIndex(ctx, ps.Index); err != nil {
+			return err
+		}
+	}
+	// pgvector stores the declared dimension as the column typmod; -1 means
+	// the column is unconstrained and sizes are learnt from the first write.
+	var dims int
+	if err := ps.DB.QueryRow(ctx, postgresEmbeddingDimsQuery).Scan(&dims); err == nil && dims > 0 {
+		ps.SetDimensions(dims)
+	}
 	return nil
 }
 
+const postgresEmbeddingDimsQuery = `
+SELECT atttypmod FROM pg_attribute
+WHERE attrelid = to_regclass('memory_bank') AND attname = 'embedding' AND NOT attisdropped`
+
 // Close releases the underlying Postgres connection pool.
 func (ps *PostgresStore) Close() error {
 	if ps == nil || ps.DB == nil {
@@ -376,13 +621,14 @@ CREATE TABLE IF NOT EXISTS memory_bank (
 );
 
 CREATE INDEX IF NOT EXISTS memory_session_idx ON memory_bank (session_id);
-CREATE INDEX IF NOT EXISTS memory_embedding_idx ON memory_bank USING ivfflat (embedding vector_cosine_ops) WITH (lists = 100);
 
 ALTER TABLE memory_bank ADD COLUMN IF NOT EXISTS importance DOUBLE PRECISION DEFAULT 0;
 ALTER TABLE memory_bank ADD COLUMN IF NOT EXISTS source TEXT DEFAULT '';
 ALTER TABLE memory_bank ADD COLUMN IF NOT EXISTS summary TEXT DEFAULT '';
 ALTER TABLE memory_bank ADD COLUMN IF NOT EXISTS last_embedded TIMESTAMPTZ DEFAULT NOW();
 ALTER TABLE memory_bank ADD COLUMN IF NOT EXISTS embedding_matrix JSONB;
+ALTER TABLE memory_bank ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS memory_tenant_session_idx ON memory_bank (tenant_id, session_id);
 
 CREATE TABLE IF NOT EXISTS memory_nodes (
     memory_id BIGINT PRIMARY KEY REFERENCES memory_bank(id) ON DELETE CASCADE,
@@ -400,6 +646,7 @@ CREATE TABLE IF NOT EXISTS memory_edges (
 );
 
 CREATE INDEX IF NOT EXISTS memory_edges_to_idx ON memory_edges (to_memory);
+ALTER TABLE memory_edges ADD COLUMN IF NOT EXISTS weight DOUBLE PRECISION NOT NULL DEFAULT 1;
 `
 
 func vectorFromJSON(jsonEmbed []byte) string {
@@ -5,6 +5,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 func TestTrimJSON(t *testing.T) {
@@ -76,10 +79,51 @@ func TestPostgresSimilarityOperatorMatchesCosineIndex(t *testing.T) {
 	if postgresCosineDistanceOperator != "<=>" {
 		t.Fatalf("postgres similarity operator = %q, want cosine distance operator <=>", postgresCosineDistanceOperator)
 	}
-	if !strings.Contains(defaultPostgresSchema, "vector_cosine_ops") {
-		t.Fatal("default schema must keep the cosine vector index")
+	for _, kind := range []string{"", PostgresIndexIVFFlat, PostgresIndexHNSW} {
+		create, err := postgresIndexSQL(PostgresIndex{Kind: kind})
+		if err != nil || !strings.Contains(create, "vector_cosine_ops") {
+			t.Fatalf("%q index = %q, %v; must keep the cosine vector index", kind, create, err)
+		}
 	}
 	if postgresCosineScoreExpression != "1 - (embedding <=> $1::vector)" {
 		t.Fatalf("postgres score expression = %q, want cosine similarity", postgresCosineScoreExpression)
 	}
 }
+
+func TestPostgresPoolConfigAppliesOptions(t *testing.T) {
+	cfg, err := postgresPoolConfig("postgres://u:p@localhost:5432/db?pool_max_conns=4&default_query_exec_mode=simple_protocol", PostgresOptions{MinConns: 8, StatementCacheCapacity: 32})
+	if err != nil {
+		t.Fatalf("postgresPoolConfig: %v", err)
+	}
+	if cfg.MaxConns != 4 || cfg.MinConns != 4 {
+		t.Fatalf("conns = %d..%d, want the DSN maximum to cap the minimum", cfg.MinConns, cfg.MaxConns)
+	}
+	if cfg.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeSimpleProtocol || cfg.ConnConfig.StatementCacheCapacity != 32 {
+		t.Fatalf("exec mode = %v, cache = %d; want the DSN's exec mode kept", cfg.ConnConfig.DefaultQueryExecMode, cfg.ConnConfig.StatementCacheCapacity)
+	}
+	cfg, _ = postgresPoolConfig("postgres://localhost/db", PostgresOptions{MaxConns: 20, MaxConnIdleTime: time.Minute})
+	if cfg.MaxConns != 20 || cfg.MaxConnIdleTime != time.Minute {
+		t.Fatalf("pool = %d conns, idle %v", cfg.MaxConns, cfg.MaxConnIdleTime)
+	}
+	if cfg.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeCacheStatement {
+		t.Fatalf("exec mode = %v, want cached prepared statements by default", cfg.ConnConfig.DefaultQueryExecMode)
+	}
+}
+
+func TestPostgresCopyRowMatchesInsertArgs(t *testing.T) {
+	rec := prepareMemoryRecord("alice", "deployed", map[string]any{"source": "slack", "embedding_matrix": [][]float32{{1, 0}}}, []float32{1, 0}, time.Unix(0, 0).UTC(), true)
+	rec.ID = 7
+	row := postgresCopyRow(rec)
+	if len(row) != len(postgresCopyColumns) || row[0] != int64(7) || row[4] != "[1,0]" || row[6] != "slack" {
+		t.Fatalf("copy row = %v", row)
+	}
+	if matrix, ok := row[9].(*string); !ok || matrix == nil || *matrix != "[[1,0]]" {
+		t.Fatalf("embedding_matrix = %v", row[9])
+	}
+	if row := postgresCopyRow(prepareMemoryRecord("alice", "plain", nil, []float32{1}, time.Unix(0, 0), true)); row[9].(*string) != nil {
+		t.Fatal("a record without a matrix should copy NULL")
+	}
+	if (&PostgresStore{}).copyThreshold() != DefaultPostgresCopyThreshold {
+		t.Fatal("zero CopyThreshold should use the default")
+	}
+}
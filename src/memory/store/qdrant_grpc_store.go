@@ -0,0 +1,584 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultQdrantUpsertBatch is the number of points the gRPC store sends per
+// upsert request.
+const DefaultQdrantUpsertBatch = 256
+
+// qdrantScrollPage is the number of points fetched per scroll request.
+const qdrantScrollPage = 512
+
+// QdrantGRPCStore is a Qdrant-backed VectorStore that talks to the gRPC API
+// (port 6334 by default) instead of REST. The points it writes are the same
+// as QdrantStore's, so either store can read a collection written by the
+// other. Protobuf encoding is far cheaper than JSON for large vectors, which
+// makes this the better choice for bulk ingestion and full scans.
+type QdrantGRPCStore struct {
+	dimensionGuard
+
+	client     *qdrant.Client
+	collection string
+	// BatchSize caps the points per upsert request; zero means
+	// DefaultQdrantUpsertBatch.
+	BatchSize int
+}
+
+// NewQdrantStoreGRPC connects to the Qdrant gRPC API at host:port. An empty
+// host means localhost and a zero port 6334. Close releases the connection.
+func NewQdrantStoreGRPC(host string, port int, collection, apiKey string, useTLS bool) (*QdrantGRPCStore, error) {
+	if collection == "" {
+		return nil, errors.New("qdrant collection is empty")
+	}
+	client, err := qdrant.NewClient(&qdrant.Config{
+		Host:                   host,
+		Port:                   port,
+		APIKey:                 apiKey,
+		UseTLS:                 useTLS,
+		SkipCompatibilityCheck: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("qdrant grpc: %w", err)
+	}
+	return &QdrantGRPCStore{client: client, collection: collection}, nil
+}
+
+// Close closes the gRPC connection.
+func (qs *QdrantGRPCStore) Close() error {
+	if qs == nil || qs.client == nil {
+		return nil
+	}
+	return qs.client.Close()
+}
+
+// CreateSchema implements SchemaInitializer with the schema file read by
+// QdrantStore.CreateSchema. base_url and api_key are ignored because the
+// store's own connection is used, and only unnamed vectors are supported.
+// An existing collection is left as it is.
+func (qs *QdrantGRPCStore) CreateSchema(ctx context.Context, schemaPath string) error {
+	if schemaPath == "" {
+		return errors.New("schemaPath is empty")
+	}
+	f, err := os.Open(schemaPath)
+	if err != nil {
+		return fmt.Errorf("open schema file: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read schema file: %w", err)
+	}
+	var cfg qdrantSchemaFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("unmarshal schema file (JSON): %w", err)
+	}
+	if cfg.Collection == "" {
+		cfg.Collection = qs.collection
+	}
+	req, err := qdrantCreateCollection(cfg.Collection, cfg.Request)
+	if err != nil {
+		return err
+	}
+	exists, err := qs.client.CollectionExists(ctx, cfg.Collection)
+	if err != nil {
+		return fmt.Errorf("qdrant grpc: %w", err)
+	}
+	if !exists {
+		if err := qs.client.CreateCollection(ctx, req); err != nil {
+			return fmt.Errorf("qdrant grpc: create collection: %w", err)
+		}
+	}
+	if cfg.Collection == qs.collection {
+		qs.SetDimensions(cfg.Request.VectorSize())
+	}
+	return nil
+}
+
+func qdrantCreateCollection(collection string, req CreateCollectionRequest) (*qdrant.CreateCollection, error) {
+	var vectors struct {
+		Size     uint64   `json:"size"`
+		Distance Distance `json:"distance"`
+	}
+	if err := json.Unmarshal(req.Vectors, &vectors); err != nil || vectors.Size == 0 {
+		return nil, errors.New("schema file 'request.vectors' must be an unnamed {size, distance} vector")
+	}
+	distance := qdrant.Distance_Cosine
+	switch vectors.Distance {
+	case DistanceDot:
+		distance = qdrant.Distance_Dot
+	case DistanceEuclid:
+		distance = qdrant.Distance_Euclid
+	}
+	out := &qdrant.CreateCollection{
+		CollectionName: collection,
+		VectorsConfig:  qdrant.NewVectorsConfig(&qdrant.VectorParams{Size: vectors.Size, Distance: distance}),
+		OnDiskPayload:  req.OnDiskPayload,
+	}
+	if req.ShardNumber != nil {
+		out.ShardNumber = qdrant.PtrOf(uint32(*req.ShardNumber))
+	}
+	if req.ReplicationFactor != nil {
+		out.ReplicationFactor = qdrant.PtrOf(uint32(*req.ReplicationFactor))
+	}
+	if req.WriteConsistencyFactor != nil {
+		out.WriteConsistencyFactor = qdrant.PtrOf(uint32(*req.WriteConsistencyFactor))
+	}
+	return out, nil
+}
+
+// StoreMemory upserts a memory point.
+func (qs *QdrantGRPCStore) StoreMemory(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
+	return qs.StoreMemories(ctx, sessionID, []MemoryWrite{{Content: content, Metadata: metadata, Embedding: embedding}})
+}
+
+// StoreMemories upserts writes in requests of at most BatchSize points,
+// waiting for each batch to be applied before sending the next.
+func (qs *QdrantGRPCStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	if qs == nil {
+		return errors.New("nil qdrant store")
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	points := make([]*qdrant.PointStruct, 0, len(writes))
+	for _, w := range writes {
+		if err := qs.checkWrite("store", w.Embedding); err != nil {
+			return err
+		}
+		payload, vector := qdrantPayload(sessionID, w.Content, w.Metadata, w.Embedding, now)
+		values, err := qdrantValues(payload)
+		if err != nil {
+			return fmt.Errorf("qdrant grpc: payload: %w", err)
+		}
+		points = append(points, &qdrant.PointStruct{
+			Id:      qdrant.NewIDNum(uint64(newQdrantID())),
+			Payload: values,
+			Vectors: qdrant.NewVectorsDense(vector),
+		})
+	}
+	batch := qs.BatchSize
+	if batch <= 0 {
+		batch = DefaultQdrantUpsertBatch
+	}
+	for start := 0; start < len(points); start += batch {
+		end := min(start+batch, len(points))
+		if _, err := qs.client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: qs.collection,
+			Wait:           qdrant.PtrOf(true),
+			Points:         points[start:end],
+		}); err != nil {
+			return fmt.Errorf("qdrant grpc: upsert: %w", err)
+		}
+	}
+	return nil
+}
+
+// SearchMemory performs a similarity search over the points of the tenant
+// on ctx.
+func (qs *QdrantGRPCStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	return qs.search(ctx, queryEmbedding, limit, qdrantGRPCFilter(model.Filter{Sessions: nonEmpty(sessionID)}, model.TenantFromContext(ctx)))
+}
+
+// SearchMemoryFiltered implements FilteredSearcher with the same payload
+// filter QdrantStore sends over REST.
+func (qs *QdrantGRPCStore) SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	return qs.search(ctx, queryEmbedding, limit, qdrantGRPCFilter(filter, model.TenantFromContext(ctx)))
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func (qs *QdrantGRPCStore) search(ctx context.Context, queryEmbedding []float32, limit int, filter *qdrant.Filter) ([]model.MemoryRecord, error) {
+	if qs == nil {
+		return nil, errors.New("nil qdrant store")
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	if err := qs.check("search", queryEmbedding); err != nil {
+		return nil, err
+	}
+	points, err := qs.client.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: qs.collection,
+		Query:          qdrant.NewQueryDense(queryEmbedding),
+		Filter:         filter,
+		Limit:          qdrant.PtrOf(uint64(limit)),
+		WithPayload:    qdrant.NewWithPayload(true),
+		WithVectors:    qdrant.NewWithVectors(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("qdrant grpc: query: %w", err)
+	}
+	results := make([]model.MemoryRecord, 0, len(points))
+	for _, point := range points {
+		results = append(results, qdrantRecord(qdrantPointID(point.GetId()), qdrantPayloadMap(point.GetPayload()), qdrantVector(point.GetVectors())))
+	}
+	// As with REST, rescore by cosine whatever metric the collection uses.
+	return rescoreMemoryRecords(results, queryEmbedding, limit), nil
+}
+
+// qdrantGRPCFilter is the protobuf form of qdrantFilter.
+func qdrantGRPCFilter(filter model.Filter, tenant string) *qdrant.Filter {
+	tenantCond := qdrant.NewIsEmpty(model.MetaTenant)
+	if tenant != "" {
+		tenantCond = qdrant.NewMatchKeyword(model.MetaTenant, tenant)
+	}
+	must := []*qdrant.Condition{tenantCond}
+	var scopes []*qdrant.Condition
+	for _, s := range filter.Sessions {
+		scopes = append(scopes, qdrant.NewMatchKeyword("session_id", s))
+	}
+	for _, s := range filter.Spaces {
+		scopes = append(scopes, qdrant.NewMatchKeyword("space", s))
+	}
+	if len(scopes) > 0 {
+		must = append(must, qdrant.NewFilterAsCondition(&qdrant.Filter{Should: scopes}))
+	}
+	if len(filter.Sources) > 0 {
+		must = append(must, qdrant.NewMatchKeywords("source", filter.Sources...))
+	}
+	if len(filter.Tags) > 0 {
+		must = append(must, qdrant.NewMatchKeywords("metadata."+model.MetaTags, filter.Tags...))
+	}
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		bounds := &qdrant.DatetimeRange{}
+		if !filter.Since.IsZero() {
+			bounds.Gte = timestamppb.New(filter.Since)
+		}
+		if !filter.Until.IsZero() {
+			bounds.Lte = timestamppb.New(filter.Until)
+		}
+		must = append(must, qdrant.NewDatetimeRange("created_at", bounds))
+	}
+	return &qdrant.Filter{Must: must}
+}
+
+// UpdateEmbedding replaces a point's vector and its last embedded time.
+func (qs *QdrantGRPCStore) UpdateEmbedding(ctx context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
+	if qs == nil {
+		return errors.New("nil qdrant store")
+	}
+	if err := qs.checkWrite("update", embedding); err != nil {
+		return err
+	}
+	pid := qdrant.NewIDNum(uint64(id))
+	if _, err := qs.client.UpdateVectors(ctx, &qdrant.UpdatePointVectors{
+		CollectionName: qs.collection,
+		Wait:           qdrant.PtrOf(true),
+		Points:         []*qdrant.PointVectors{{Id: pid, Vectors: qdrant.NewVectorsDense(embedding)}},
+	}); err != nil {
+		return fmt.Errorf("qdrant grpc: update vectors: %w", err)
+	}
+	if _, err := qs.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: qs.collection,
+		Wait:           qdrant.PtrOf(true),
+		Payload:        map[string]*qdrant.Value{"last_embedded": qdrant.NewValueString(lastEmbedded.Format(time.RFC3339Nano))},
+		PointsSelector: qdrant.NewPointsSelector(pid),
+	}); err != nil {
+		return fmt.Errorf("qdrant grpc: set payload: %w", err)
+	}
+	return nil
+}
+
+// DeleteMemory removes points by id.
+func (qs *QdrantGRPCStore) DeleteMemory(ctx context.Context, ids []int64) error {
+	if qs == nil || len(ids) == 0 {
+		return nil
+	}
+	if _, err := qs.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: qs.collection,
+		Wait:           qdrant.PtrOf(true),
+		Points:         qdrant.NewPointsSelectorIDs(qdrantPointIDs(ids)),
+	}); err != nil {
+		return fmt.Errorf("qdrant grpc: delete: %w", err)
+	}
+	return nil
+}
+
+// Iterate scrolls through every point, a page at a time, until fn returns
+// false or the collection is exhausted.
+func (qs *QdrantGRPCStore) Iterate(ctx context.Context, fn func(model.MemoryRecord) bool) error {
+	if qs == nil {
+		return nil
+	}
+	var offset *qdrant.PointId
+	for {
+		points, next, err := qs.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: qs.collection,
+			Offset:         offset,
+			Limit:          qdrant.PtrOf(uint32(qdrantScrollPage)),
+			WithPayload:    qdrant.NewWithPayload(true),
+			WithVectors:    qdrant.NewWithVectors(true),
+		})
+		if err != nil {
+			return fmt.Errorf("qdrant grpc: scroll: %w", err)
+		}
+		for _, point := range points {
+			if !fn(qdrantRecord(qdrantPointID(point.GetId()), qdrantPayloadMap(point.GetPayload()), qdrantVector(point.GetVectors()))) {
+				return nil
+			}
+		}
+		if next == nil || len(points) == 0 {
+			return nil
+		}
+		offset = next
+	}
+}
+
+// Count returns the exact number of points in the collection.
+func (qs *QdrantGRPCStore) Count(ctx context.Context) (int, error) {
+	if qs == nil {
+		return 0, nil
+	}
+	n, err := qs.client.Count(ctx, &qdrant.CountPoints{CollectionName: qs.collection, Exact: qdrant.PtrOf(true)})
+	if err != nil {
+		return 0, fmt.Errorf("qdrant grpc: count: %w", err)
+	}
+	return int(n), nil
+}
+
+// UpsertGraph rewrites the point's space and graph edges, at the top level
+// and inside its metadata, as QdrantStore does.
+func (qs *QdrantGRPCStore) UpsertGraph(ctx context.Context, record model.MemoryRecord, edges []model.GraphEdge) error {
+	if qs == nil || record.ID == 0 {
+		return nil
+	}
+	points, err := qs.getPoints(ctx, []int64{record.ID}, false)
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("point %d not found", record.ID)
+	}
+	payload := qdrantPayloadMap(points[0].GetPayload())
+	meta, _ := payload["metadata"].(map[string]any)
+	if record.Space != "" {
+		payload["space"] = record.Space
+		if meta != nil {
+			meta["space"] = record.Space
+		}
+	}
+	if len(edges) > 0 {
+		payload["graph_edges"] = edges
+		if meta != nil {
+			meta["graph_edges"] = edges
+		}
+	} else {
+		delete(payload, "graph_edges")
+		delete(meta, "graph_edges")
+	}
+	values, err := qdrantValues(payload)
+	if err != nil {
+		return fmt.Errorf("qdrant grpc: payload: %w", err)
+	}
+	if _, err := qs.client.OverwritePayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: qs.collection,
+		Wait:           qdrant.PtrOf(true),
+		Payload:        values,
+		PointsSelector: qdrant.NewPointsSelector(qdrant.NewIDNum(uint64(record.ID))),
+	}); err != nil {
+		return fmt.Errorf("qdrant grpc: overwrite payload: %w", err)
+	}
+	return nil
+}
+
+// Neighborhood walks the edge payloads breadth first from seedIDs, fetching
+// each level in one request, and returns up to limit neighbours.
+func (qs *QdrantGRPCStore) Neighborhood(ctx context.Context, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
+	if qs == nil || len(seedIDs) == 0 || hops <= 0 || limit <= 0 {
+		return nil, nil
+	}
+	seen := make(map[int64]struct{}, len(seedIDs))
+	frontier := make([]int64, 0, len(seedIDs))
+	for _, id := range seedIDs {
+		if id != 0 {
+			seen[id] = struct{}{}
+			frontier = append(frontier, id)
+		}
+	}
+	var neighbors []int64
+	for depth := 0; depth < hops && len(frontier) > 0; depth++ {
+		points, err := qs.getPoints(ctx, frontier, false)
+		if err != nil {
+			return nil, err
+		}
+		var next []int64
+		for _, point := range points {
+			for _, edge := range extractEdgesFromPayload(qdrantPayloadMap(point.GetPayload())) {
+				if edge.Validate() != nil {
+					continue
+				}
+				if _, ok := seen[edge.Target]; ok {
+					continue
+				}
+				seen[edge.Target] = struct{}{}
+				neighbors = append(neighbors, edge.Target)
+				next = append(next, edge.Target)
+			}
+		}
+		frontier = next
+	}
+	if len(neighbors) == 0 {
+		return nil, nil
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i] < neighbors[j] })
+	if len(neighbors) > limit {
+		neighbors = neighbors[:limit]
+	}
+	points, err := qs.getPoints(ctx, neighbors, true)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]model.MemoryRecord, 0, len(points))
+	for _, point := range points {
+		results = append(results, qdrantRecord(qdrantPointID(point.GetId()), qdrantPayloadMap(point.GetPayload()), qdrantVector(point.GetVectors())))
+	}
+	return results, nil
+}
+
+func (qs *QdrantGRPCStore) getPoints(ctx context.Context, ids []int64, withVectors bool) ([]*qdrant.RetrievedPoint, error) {
+	points, err := qs.client.Get(ctx, &qdrant.GetPoints{
+		CollectionName: qs.collection,
+		Ids:            qdrantPointIDs(ids),
+		WithPayload:    qdrant.NewWithPayload(true),
+		WithVectors:    qdrant.NewWithVectors(withVectors),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("qdrant grpc: get: %w", err)
+	}
+	return points, nil
+}
+
+// newQdrantID returns a random positive point ID.
+func newQdrantID() int64 {
+	v := time.Now().UnixNano() ^ rand.Int63()
+	if v < 0 {
+		v = -v
+	}
+	return v
+}
+
+func qdrantPointIDs(ids []int64) []*qdrant.PointId {
+	out := make([]*qdrant.PointId, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, qdrant.NewIDNum(uint64(id)))
+	}
+	return out
+}
+
+func qdrantPointID(id *qdrant.PointId) int64 {
+	if id == nil {
+		return 0
+	}
+	if uuid := id.GetUuid(); uuid != "" {
+		id, _ := parseQdrantID(json.RawMessage(`"` + uuid + `"`))
+		return id
+	}
+	return int64(id.GetNum())
+}
+
+func qdrantVector(v *qdrant.VectorsOutput) []float32 {
+	vec := v.GetVector()
+	if dense := vec.GetDense(); dense != nil {
+		return dense.GetData()
+	}
+	return vec.GetData()
+}
+
+// qdrantValues converts a payload to protobuf values through JSON, so
+// structs such as model.GraphEdge encode as they do over REST. Integers stay
+// integers rather than passing through float64, which would corrupt large
+// edge targets.
+func qdrantValues(payload map[string]any) (map[string]*qdrant.Value, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return qdrant.TryValueMap(unwrapNumbers(doc).(map[string]any))
+}
+
+func unwrapNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if !strings.ContainsAny(t.String(), ".eE") {
+			if i, err := t.Int64(); err == nil {
+				return i
+			}
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, item := range t {
+			t[k] = unwrapNumbers(item)
+		}
+		return t
+	case []any:
+		for i, item := range t {
+			t[i] = unwrapNumbers(item)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// qdrantPayloadMap converts protobuf payload values back to the plain Go
+// values REST responses decode to.
+func qdrantPayloadMap(values map[string]*qdrant.Value) map[string]any {
+	out := make(map[string]any, len(values))
+	for k, v := range values {
+		out[k] = qdrantAny(v)
+	}
+	return out
+}
+
+func qdrantAny(v *qdrant.Value) any {
+	switch kind := v.GetKind().(type) {
+	case *qdrant.Value_BoolValue:
+		return kind.BoolValue
+	case *qdrant.Value_IntegerValue:
+		return kind.IntegerValue
+	case *qdrant.Value_DoubleValue:
+		return kind.DoubleValue
+	case *qdrant.Value_StringValue:
+		return kind.StringValue
+	case *qdrant.Value_StructValue:
+		return qdrantPayloadMap(kind.StructValue.GetFields())
+	case *qdrant.Value_ListValue:
+		items := kind.ListValue.GetValues()
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = qdrantAny(item)
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/qdrant/go-client/qdrant"
+)
+
+func TestQdrantGRPCFilterMatchesRESTFilter(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := qdrantGRPCFilter(model.Filter{
+		Sessions: []string{"alice"},
+		Spaces:   []string{"team:eng"},
+		Sources:  []string{"slack", "notion"},
+		Tags:     []string{"deploy"},
+		Since:    since,
+	}, "acme")
+	must := f.GetMust()
+	if len(must) != 5 {
+		t.Fatalf("must = %v, want tenant, scopes, source, tags and time conditions", must)
+	}
+	if m := must[0].GetField(); m.GetKey() != model.MetaTenant || m.GetMatch().GetKeyword() != "acme" {
+		t.Fatalf("tenant condition = %v", must[0])
+	}
+	if scopes := must[1].GetFilter().GetShould(); len(scopes) != 2 || scopes[1].GetField().GetKey() != "space" {
+		t.Fatalf("scope condition = %v", must[1])
+	}
+	if m := must[3].GetField(); m.GetKey() != "metadata.tags" || len(m.GetMatch().GetKeywords().GetStrings()) != 1 {
+		t.Fatalf("tag condition = %v", must[3])
+	}
+	if r := must[4].GetField().GetDatetimeRange(); !r.GetGte().AsTime().Equal(since) || r.Lte != nil {
+		t.Fatalf("time condition = %v", must[4])
+	}
+
+	if must := qdrantGRPCFilter(model.Filter{}, "").GetMust(); len(must) != 1 || must[0].GetIsEmpty().GetKey() != model.MetaTenant {
+		t.Fatalf("zero filter = %v", must)
+	}
+}
+
+func TestQdrantGRPCPayloadRoundTrip(t *testing.T) {
+	const target = int64(1<<62 + 1) // not representable as float64
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	payload, vector := qdrantPayload("alice", "deployed v2", map[string]any{
+		"tags":        []string{"deploy"},
+		"importance":  0.75,
+		"graph_edges": []model.GraphEdge{{Target: target, Type: model.EdgeFollows}},
+	}, []float32{1, 0.5}, now)
+	values, err := qdrantValues(payload)
+	if err != nil {
+		t.Fatalf("qdrantValues: %v", err)
+	}
+	point := &qdrant.RetrievedPoint{
+		Id:      qdrant.NewIDNum(42),
+		Payload: values,
+		Vectors: &qdrant.VectorsOutput{VectorsOptions: &qdrant.VectorsOutput_Vector{Vector: &qdrant.VectorOutput{Data: vector}}},
+	}
+
+	rec := qdrantRecord(qdrantPointID(point.GetId()), qdrantPayloadMap(point.GetPayload()), qdrantVector(point.GetVectors()))
+	if rec.ID != 42 || rec.SessionID != "alice" || rec.Content != "deployed v2" || rec.Space != "alice" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if rec.Importance != 0.75 || !rec.CreatedAt.Equal(now) || len(rec.Embedding) != 2 {
+		t.Fatalf("record fields = %+v", rec)
+	}
+	if edges := extractEdgesFromPayload(qdrantPayloadMap(point.GetPayload())); len(edges) != 1 || edges[0].Target != target {
+		t.Fatalf("graph edges = %+v, want target %d intact", edges, target)
+	}
+	if tags := model.RecordTags(rec); len(tags) != 1 || tags[0] != "deploy" {
+		t.Fatalf("tags = %v", tags)
+	}
+}
+
+func TestQdrantCreateCollectionRequiresUnnamedVectors(t *testing.T) {
+	shards := 2
+	req, err := qdrantCreateCollection("memories", CreateCollectionRequest{
+		Vectors:     []byte(`{"size":768,"distance":"Dot"}`),
+		ShardNumber: &shards,
+	})
+	if err != nil {
+		t.Fatalf("qdrantCreateCollection: %v", err)
+	}
+	params := req.GetVectorsConfig().GetParams()
+	if params.GetSize() != 768 || params.GetDistance() != qdrant.Distance_Dot || req.GetShardNumber() != 2 {
+		t.Fatalf("request = %v", req)
+	}
+	if _, err := qdrantCreateCollection("memories", CreateCollectionRequest{Vectors: []byte(`{"text":{"size":768}}`)}); err == nil {
+		t.Fatal("named vectors should be rejected")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,6 +17,7 @@ import (
 	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 )
 
 // --- Qdrant types ---
@@ -103,6 +103,8 @@ type qdrantSchemaFile struct {
 
 // Your store type.
 type QdrantStore struct {
+	dimensionGuard
+
 	baseURL    string
 	apiKey     string
 	collection string
@@ -152,7 +154,7 @@ func (qs *QdrantStore) CreateSchema(ctx context.Context, schemaPath string) erro
 		cfg.BaseURL = "http://localhost:6333"
 	}
 	if cfg.APIKey == "" {
-		cfg.APIKey = os.Getenv("QDRANT_API_KEY")
+		cfg.APIKey = secrets.Lookup("QDRANT_API_KEY")
 	}
 	if cfg.Collection == "" {
 		return errors.New("schema file missing 'collection'")
@@ -161,7 +163,27 @@ func (qs *QdrantStore) CreateSchema(ctx context.Context, schemaPath string) erro
 		return errors.New("schema file 'request.vectors' is required")
 	}
 
-	return qs.createCollection(ctx, cfg.BaseURL, cfg.APIKey, cfg.Collection, cfg.Request)
+	if err := qs.createCollection(ctx, cfg.BaseURL, cfg.APIKey, cfg.Collection, cfg.Request); err != nil {
+		return err
+	}
+	if cfg.Collection == qs.collection {
+		if dims := cfg.Request.VectorSize(); dims > 0 {
+			qs.SetDimensions(dims)
+		}
+	}
+	return nil
+}
+
+// VectorSize returns the size of an unnamed vector configuration, or 0 for
+// named vectors and malformed input.
+func (r CreateCollectionRequest) VectorSize() int {
+	var single struct {
+		Size int `json:"size"`
+	}
+	if err := json.Unmarshal(r.Vectors, &single); err != nil {
+		return 0
+	}
+	return single.Size
 }
 
 // --- Internal HTTP call with robust handling (idempotent, dual-status parsing) ---
@@ -233,7 +255,46 @@ func (qs *QdrantStore) StoreMemory(ctx context.Context, sessionID, content strin
 	if qs.collection == "" {
 		return errors.New("qdrant collection is empty")
 	}
+	if err := qs.checkWrite("store", embedding); err != nil {
+		return err
+	}
+	return qs.upsertPoints(ctx, []map[string]any{qs.point(sessionID, content, metadata, embedding, time.Now().UTC())})
+}
+
+// StoreMemories upserts writes as the points of a single request.
+func (qs *QdrantStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	if qs == nil {
+		return errors.New("nil qdrant store")
+	}
+	if qs.collection == "" {
+		return errors.New("qdrant collection is empty")
+	}
+	if len(writes) == 0 {
+		return nil
+	}
 	now := time.Now().UTC()
+	points := make([]map[string]any, 0, len(writes))
+	for _, w := range writes {
+		if err := qs.checkWrite("store", w.Embedding); err != nil {
+			return err
+		}
+		points = append(points, qs.point(sessionID, w.Content, w.Metadata, w.Embedding, now))
+	}
+	return qs.upsertPoints(ctx, points)
+}
+
+func (qs *QdrantStore) point(sessionID, content string, metadata map[string]any, embedding []float32, now time.Time) map[string]any {
+	payload, vector := qdrantPayload(sessionID, content, metadata, embedding, now)
+	return map[string]any{
+		"id":      qs.generateID(),
+		"vector":  vector,
+		"payload": payload,
+	}
+}
+
+// qdrantPayload builds the payload and vector of a new point; the REST and
+// gRPC stores share it so their points are interchangeable.
+func qdrantPayload(sessionID, content string, metadata map[string]any, embedding []float32, now time.Time) (map[string]any, []float32) {
 	// Qdrant historically serializes sanitized edges directly from the input,
 	// before JSON normalization can coerce large integer targets through float64.
 	graphEdges := model.SanitizeGraphEdges(metadata)
@@ -252,20 +313,20 @@ func (qs *QdrantStore) StoreMemory(ctx context.Context, sessionID, content strin
 		"last_embedded": record.LastEmbedded.Format(time.RFC3339Nano),
 		"space":         record.Space,
 	}
+	if record.TenantID != "" {
+		payload[model.MetaTenant] = record.TenantID
+	}
 	if len(record.GraphEdges) > 0 {
 		payload["graph_edges"] = record.GraphEdges
 	}
 	if len(record.EmbeddingMatrix) > 0 {
 		payload[model.EmbeddingMatrixKey] = record.EmbeddingMatrix
 	}
-	pointID := qs.generateID()
-	req := map[string]any{
-		"points": []map[string]any{{
-			"id":      pointID,
-			"vector":  record.Embedding,
-			"payload": payload,
-		}},
-	}
+	return payload, record.Embedding
+}
+
+func (qs *QdrantStore) upsertPoints(ctx context.Context, points []map[string]any) error {
+	req := map[string]any{"points": points}
 	var resp qdrantEnvelope[json.RawMessage]
 	if err := qs.do(ctx, http.MethodPut, fmt.Sprintf("/collections/%s/points", url.PathEscape(qs.collection)), req, &resp); err != nil {
 		return err
@@ -276,25 +337,35 @@ func (qs *QdrantStore) StoreMemory(ctx context.Context, sessionID, content strin
 	return nil
 }
 
-// SearchMemory performs a similarity search.
+// SearchMemory performs a similarity search over the points of the tenant
+// on ctx.
 func (qs *QdrantStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	return qs.search(ctx, queryEmbedding, limit, qdrantSearchFilter(sessionID, model.TenantFromContext(ctx)))
+}
+
+// SearchMemoryFiltered implements FilteredSearcher by translating filter
+// into a Qdrant payload filter, so only matching points are scored and
+// returned.
+func (qs *QdrantStore) SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	return qs.search(ctx, queryEmbedding, limit, qdrantFilter(filter, model.TenantFromContext(ctx)))
+}
+
+func (qs *QdrantStore) search(ctx context.Context, queryEmbedding []float32, limit int, filter map[string]any) ([]model.MemoryRecord, error) {
 	if qs == nil {
 		return nil, errors.New("nil qdrant store")
 	}
 	if limit <= 0 {
 		return nil, nil
 	}
+	if err := qs.check("search", queryEmbedding); err != nil {
+		return nil, err
+	}
 	reqBody := map[string]any{
 		"vector":       queryEmbedding,
 		"limit":        limit,
 		"with_vector":  true,
 		"with_payload": true,
-	}
-	if sessionID != "" {
-		// Add session ID filter to the request body
-		reqBody["filter"] = map[string]any{
-			"must": []map[string]any{{"key": "session_id", "match": map[string]any{"value": sessionID}}},
-		}
+		"filter":       filter,
 	}
 	var resp qdrantEnvelope[[]qdrantPointResult]
 	if err := qs.do(ctx, http.MethodPost, fmt.Sprintf("/collections/%s/points/search", url.PathEscape(qs.collection)), reqBody, &resp); err != nil {
@@ -303,41 +374,7 @@ func (qs *QdrantStore) SearchMemory(ctx context.Context, sessionID string, query
 	results := make([]model.MemoryRecord, 0, len(resp.Result))
 	for _, point := range resp.Result {
 		id, _ := parseQdrantID(point.ID)
-		meta := mapFromPayload(point.Payload)
-		metaMap, _ := meta["metadata"].(map[string]any)
-		if metaMap == nil {
-			metaMap = model.DecodeMetadata(encodeMetadata(meta["metadata"]))
-		}
-		record := model.MemoryRecord{
-			ID:           id,
-			SessionID:    model.StringFromAny(meta["session_id"]),
-			Content:      model.StringFromAny(meta["content"]),
-			Metadata:     encodeMetadata(meta["metadata"]),
-			Embedding:    point.Vector,
-			Importance:   model.FloatFromAny(meta["importance"]),
-			Source:       model.StringFromAny(meta["source"]),
-			Summary:      model.StringFromAny(meta["summary"]),
-			CreatedAt:    model.TimeFromAny(meta["created_at"]),
-			LastEmbedded: model.TimeFromAny(meta["last_embedded"]),
-		}
-		model.HydrateRecordFromMetadata(&record, metaMap)
-		if len(record.EmbeddingMatrix) == 0 {
-			if matrix := model.DecodeEmbeddingMatrix(meta[model.EmbeddingMatrixKey]); len(matrix) > 0 {
-				record.EmbeddingMatrix = matrix
-			} else if matrix := model.DecodeEmbeddingMatrix(point.Payload[model.EmbeddingMatrixKey]); len(matrix) > 0 {
-				record.EmbeddingMatrix = matrix
-			}
-		}
-		if record.Space == "" {
-			record.Space = model.StringFromAny(meta["space"])
-			if record.Space == "" {
-				record.Space = record.SessionID
-			}
-		}
-		if len(record.GraphEdges) == 0 {
-			record.GraphEdges = model.ValidGraphEdges(metaMap)
-		}
-		results = append(results, record)
+		results = append(results, qdrantRecord(id, point.Payload, point.Vector))
 	}
 	// Qdrant collections can use cosine, dot-product, or Euclidean distance.
 	// Normalize the returned vectors to the VectorStore cosine contract rather
@@ -345,11 +382,101 @@ func (qs *QdrantStore) SearchMemory(ctx context.Context, sessionID string, query
 	return rescoreMemoryRecords(results, queryEmbedding, limit), nil
 }
 
+// qdrantRecord rebuilds a memory from a point's payload and vector.
+func qdrantRecord(id int64, payload map[string]any, vector []float32) model.MemoryRecord {
+	meta := mapFromPayload(payload)
+	metaMap, _ := meta["metadata"].(map[string]any)
+	if metaMap == nil {
+		metaMap = model.DecodeMetadata(encodeMetadata(meta["metadata"]))
+	}
+	record := model.MemoryRecord{
+		ID:           id,
+		SessionID:    model.StringFromAny(meta["session_id"]),
+		Content:      model.StringFromAny(meta["content"]),
+		Metadata:     encodeMetadata(meta["metadata"]),
+		Embedding:    vector,
+		Importance:   model.FloatFromAny(meta["importance"]),
+		Source:       model.StringFromAny(meta["source"]),
+		Summary:      model.StringFromAny(meta["summary"]),
+		CreatedAt:    model.TimeFromAny(meta["created_at"]),
+		LastEmbedded: model.TimeFromAny(meta["last_embedded"]),
+	}
+	model.HydrateRecordFromMetadata(&record, metaMap)
+	if len(record.EmbeddingMatrix) == 0 {
+		if matrix := model.DecodeEmbeddingMatrix(meta[model.EmbeddingMatrixKey]); len(matrix) > 0 {
+			record.EmbeddingMatrix = matrix
+		}
+	}
+	if record.Space == "" {
+		record.Space = model.StringFromAny(meta["space"])
+		if record.Space == "" {
+			record.Space = record.SessionID
+		}
+	}
+	if len(record.GraphEdges) == 0 {
+		record.GraphEdges = model.ValidGraphEdges(metaMap)
+	}
+	return record
+}
+
+// qdrantSearchFilter matches the points of sessionID, when set, that belong
+// to tenant. Untenanted points carry no tenant_id payload.
+func qdrantSearchFilter(sessionID, tenant string) map[string]any {
+	must := []map[string]any{qdrantTenantCondition(tenant)}
+	if sessionID != "" {
+		must = append(must, map[string]any{"key": "session_id", "match": map[string]any{"value": sessionID}})
+	}
+	return map[string]any{"must": must}
+}
+
+func qdrantTenantCondition(tenant string) map[string]any {
+	if tenant == "" {
+		return map[string]any{"is_empty": map[string]any{"key": model.MetaTenant}}
+	}
+	return map[string]any{"key": model.MetaTenant, "match": map[string]any{"value": tenant}}
+}
+
+// qdrantFilter translates filter for the points of tenant. Sessions and
+// spaces form one "should" group, so a point in any of them matches.
+func qdrantFilter(filter model.Filter, tenant string) map[string]any {
+	must := []map[string]any{qdrantTenantCondition(tenant)}
+	var scopes []map[string]any
+	for _, s := range filter.Sessions {
+		scopes = append(scopes, map[string]any{"key": "session_id", "match": map[string]any{"value": s}})
+	}
+	for _, s := range filter.Spaces {
+		scopes = append(scopes, map[string]any{"key": "space", "match": map[string]any{"value": s}})
+	}
+	if len(scopes) > 0 {
+		must = append(must, map[string]any{"should": scopes})
+	}
+	if len(filter.Sources) > 0 {
+		must = append(must, map[string]any{"key": "source", "match": map[string]any{"any": filter.Sources}})
+	}
+	if len(filter.Tags) > 0 {
+		must = append(must, map[string]any{"key": "metadata." + model.MetaTags, "match": map[string]any{"any": filter.Tags}})
+	}
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		bounds := map[string]any{}
+		if !filter.Since.IsZero() {
+			bounds["gte"] = filter.Since.UTC().Format(time.RFC3339Nano)
+		}
+		if !filter.Until.IsZero() {
+			bounds["lte"] = filter.Until.UTC().Format(time.RFC3339Nano)
+		}
+		must = append(must, map[string]any{"key": "created_at", "range": bounds})
+	}
+	return map[string]any{"must": must}
+}
+
 // UpdateEmbedding updates the vector and last embedded timestamp.
 func (qs *QdrantStore) UpdateEmbedding(ctx context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
 	if qs == nil {
 		return errors.New("nil qdrant store")
 	}
+	if err := qs.checkWrite("update", embedding); err != nil {
+		return err
+	}
 	point, err := qs.getPoint(ctx, id)
 	if err != nil {
 		return err
@@ -746,11 +873,7 @@ func (qs *QdrantStore) getPoints(ctx context.Context, ids []int64) ([]qdrantPoin
 func (qs *QdrantStore) generateID() int64 {
 	qs.mu.Lock()
 	defer qs.mu.Unlock()
-	v := time.Now().UnixNano() ^ rand.Int63()
-	if v < 0 {
-		v = -v
-	}
-	return v
+	return newQdrantID()
 }
 
 func parseQdrantID(raw json.RawMessage) (int64, error) {
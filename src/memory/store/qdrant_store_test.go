@@ -0,0 +1,38 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func TestQdrantFilterTranslatesEveryField(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	got := qdrantFilter(model.Filter{
+		Sessions: []string{"alice"},
+		Spaces:   []string{"team:eng"},
+		Sources:  []string{"slack", "notion"},
+		Tags:     []string{"deploy"},
+		Since:    since,
+	}, "acme")
+	raw, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"must":[` +
+		`{"key":"tenant_id","match":{"value":"acme"}},` +
+		`{"should":[{"key":"session_id","match":{"value":"alice"}},{"key":"space","match":{"value":"team:eng"}}]},` +
+		`{"key":"source","match":{"any":["slack","notion"]}},` +
+		`{"key":"metadata.tags","match":{"any":["deploy"]}},` +
+		`{"key":"created_at","range":{"gte":"2026-01-01T00:00:00Z"}}]}`
+	if string(raw) != want {
+		t.Fatalf("filter =\n%s\nwant\n%s", raw, want)
+	}
+
+	raw, _ = json.Marshal(qdrantFilter(model.Filter{}, ""))
+	if string(raw) != `{"must":[{"is_empty":{"key":"tenant_id"}}]}` {
+		t.Fatalf("zero filter = %s", raw)
+	}
+}
@@ -96,6 +96,7 @@ func prepareMemoryRecord(
 	return model.MemoryRecord{
 		SessionID:       sessionID,
 		Space:           space,
+		TenantID:        model.StringFromAny(normalizedMetadata[model.MetaTenant]),
 		Content:         content,
 		Metadata:        metadataJSON,
 		Embedding:       storedEmbedding,
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// ShadowStore dual-writes to a primary and a shadow VectorStore while a
+// migration is in progress. Reads are always served by the primary; every
+// search is replayed against the shadow and the two result lists are compared
+// so divergence is visible before reads are cut over.
+//
+// Shadow failures never fail the caller: they are logged and reported
+// through ShadowOptions.OnDiff.
+type ShadowStore struct {
+	primary VectorStore
+	shadow  VectorStore
+	opts    ShadowOptions
+}
+
+// ShadowOptions configures a ShadowStore.
+type ShadowOptions struct {
+	// Logger receives one line per divergent search and per shadow error.
+	// Defaults to stderr with a "memory-shadow: " prefix.
+	Logger *log.Logger
+	// OnDiff, when set, receives every comparison, including matching ones,
+	// so callers can export agreement metrics.
+	OnDiff func(ShadowDiff)
+}
+
+// ShadowDiff describes one primary/shadow comparison. Records are matched
+// by session and content because the two stores assign IDs independently.
+type ShadowDiff struct {
+	Op        string // "store", "search", "update" or "delete"
+	SessionID string
+	Limit     int
+
+	PrimaryCount int
+	ShadowCount  int
+	// PrimaryOnly and ShadowOnly hold the content of unmatched results.
+	PrimaryOnly []string
+	ShadowOnly  []string
+	// Reordered counts matched results whose rank differs.
+	Reordered int
+
+	PrimaryLatency time.Duration
+	ShadowLatency  time.Duration
+	ShadowErr      error
+}
+
+// Divergent reports whether the shadow disagreed with the primary.
+func (d ShadowDiff) Divergent() bool {
+	return d.ShadowErr != nil || len(d.PrimaryOnly) > 0 || len(d.ShadowOnly) > 0 || d.Reordered > 0
+}
+
+// Overlap is the fraction of primary results also returned by the shadow.
+func (d ShadowDiff) Overlap() float64 {
+	if d.PrimaryCount == 0 {
+		if d.ShadowCount == 0 {
+			return 1
+		}
+		return 0
+	}
+	return float64(d.PrimaryCount-len(d.PrimaryOnly)) / float64(d.PrimaryCount)
+}
+
+var (
+	_ VectorStore       = (*ShadowStore)(nil)
+	_ GraphStore        = (*ShadowStore)(nil)
+	_ SchemaInitializer = (*ShadowStore)(nil)
+)
+
+// NewShadowStore wraps primary (the current store) and shadow (the migration
+// target).
+func NewShadowStore(primary, shadow VectorStore, opts ShadowOptions) (*ShadowStore, error) {
+	if primary == nil {
+		return nil, errors.New("primary vector store is nil")
+	}
+	if shadow == nil {
+		return nil, errors.New("shadow vector store is nil")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "memory-shadow: ", log.LstdFlags)
+	}
+	return &ShadowStore{primary: primary, shadow: shadow, opts: opts}, nil
+}
+
+// Primary returns the store serving reads.
+func (s *ShadowStore) Primary() VectorStore { return s.primary }
+
+// Shadow returns the migration target.
+func (s *ShadowStore) Shadow() VectorStore { return s.shadow }
+
+// StoreMemory writes to the primary and, if that succeeds, to the shadow.
+func (s *ShadowStore) StoreMemory(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
+	// Stores may mutate metadata while preparing the record; give the shadow
+	// its own copy.
+	shadowMeta := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		shadowMeta[k] = v
+	}
+	if err := s.primary.StoreMemory(ctx, sessionID, content, metadata, embedding); err != nil {
+		return err
+	}
+	if err := s.shadow.StoreMemory(ctx, sessionID, content, shadowMeta, embedding); err != nil {
+		s.report(ShadowDiff{Op: "store", SessionID: sessionID, ShadowErr: err})
+	}
+	return nil
+}
+
+// SearchMemory returns the primary's results after comparing them with the
+// shadow's answer to the same query.
+func (s *ShadowStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	start := time.Now()
+	primary, err := s.primary.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+	primaryLatency := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	shadow, shadowErr := s.shadow.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+	diff := compareSearchResults(primary, shadow)
+	diff.Op, diff.SessionID, diff.Limit = "search", sessionID, limit
+	diff.PrimaryLatency, diff.ShadowLatency = primaryLatency, time.Since(start)
+	diff.ShadowErr = shadowErr
+	s.report(diff)
+	return primary, nil
+}
+
+// UpdateEmbedding updates the primary record and its shadow counterpart.
+func (s *ShadowStore) UpdateEmbedding(ctx context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
+	if err := s.primary.UpdateEmbedding(ctx, id, embedding, lastEmbedded); err != nil {
+		return err
+	}
+	shadowIDs, err := s.shadowIDs(ctx, []int64{id})
+	for _, shadowID := range shadowIDs {
+		if updateErr := s.shadow.UpdateEmbedding(ctx, shadowID, embedding, lastEmbedded); updateErr != nil {
+			err = errors.Join(err, updateErr)
+		}
+	}
+	if err != nil {
+		s.report(ShadowDiff{Op: "update", ShadowErr: err})
+	}
+	return nil
+}
+
+// UpdateImportance rescores the primary record when the primary supports
+// it; shadow records keep the importance they were written with.
+func (s *ShadowStore) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if u, ok := s.primary.(ImportanceUpdater); ok {
+		return u.UpdateImportance(ctx, id, importance)
+	}
+	return nil
+}
+
+// UpdateMetadata rewrites the primary record; like importance, shadow
+// records keep the metadata they were written with.
+func (s *ShadowStore) UpdateMetadata(ctx context.Context, record model.MemoryRecord) error {
+	if u, ok := s.primary.(MetadataUpdater); ok {
+		return u.UpdateMetadata(ctx, record)
+	}
+	return ErrMetadataUpdatesUnsupported
+}
+
+// DeleteMemory deletes from the primary and removes matching shadow records.
+func (s *ShadowStore) DeleteMemory(ctx context.Context, ids []int64) error {
+	// Resolve shadow IDs first: the primary records are needed to match them.
+	shadowIDs, resolveErr := s.shadowIDs(ctx, ids)
+	if err := s.primary.DeleteMemory(ctx, ids); err != nil {
+		return err
+	}
+	err := resolveErr
+	if len(shadowIDs) > 0 {
+		err = errors.Join(err, s.shadow.DeleteMemory(ctx, shadowIDs))
+	}
+	if err != nil {
+		s.report(ShadowDiff{Op: "delete", ShadowErr: err})
+	}
+	return nil
+}
+
+// Iterate walks the primary.
+func (s *ShadowStore) Iterate(ctx context.Context, fn func(model.MemoryRecord) bool) error {
+	return s.primary.Iterate(ctx, fn)
+}
+
+// Count reports the primary's record count.
+func (s *ShadowStore) Count(ctx context.Context) (int, error) {
+	return s.primary.Count(ctx)
+}
+
+// CreateSchema initialises both stores. A shadow failure is returned because
+// a migration target without a schema would make every comparison noise.
+func (s *ShadowStore) CreateSchema(ctx context.Context, schemaPath string) error {
+	if initializer, ok := s.primary.(SchemaInitializer); ok {
+		if err := initializer.CreateSchema(ctx, schemaPath); err != nil {
+			return err
+		}
+	}
+	if initializer, ok := s.shadow.(SchemaInitializer); ok {
+		if err := initializer.CreateSchema(ctx, schemaPath); err != nil {
+			return fmt.Errorf("shadow schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpsertGraph forwards to the primary when it is a GraphStore. Graph edges
+// reference primary IDs, so they are not mirrored.
+func (s *ShadowStore) UpsertGraph(ctx context.Context, record model.MemoryRecord, edges []model.GraphEdge) error {
+	if graph, ok := s.primary.(GraphStore); ok {
+		return graph.UpsertGraph(ctx, record, edges)
+	}
+	return nil
+}
+
+// Neighborhood forwards to the primary when it is a GraphStore.
+func (s *ShadowStore) Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
+	if graph, ok := s.primary.(GraphStore); ok {
+		return graph.Neighborhood(ctx, sessionID, seedIDs, hops, limit)
+	}
+	return nil, nil
+}
+
+// QueryGraph answers q from the primary.
+func (s *ShadowStore) QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	return QueryGraph(ctx, s.primary, q)
+}
+
+// shadowIDs maps primary IDs to shadow IDs by session and content. It scans
+// both stores, which is acceptable for the update and delete paths (drift
+// re-embedding and pruning) during a migration window.
+func (s *ShadowStore) shadowIDs(ctx context.Context, primaryIDs []int64) ([]int64, error) {
+	if len(primaryIDs) == 0 {
+		return nil, nil
+	}
+	wanted := make(map[int64]struct{}, len(primaryIDs))
+	for _, id := range primaryIDs {
+		wanted[id] = struct{}{}
+	}
+	keys := make(map[string]struct{}, len(primaryIDs))
+	if err := s.primary.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if _, ok := wanted[rec.ID]; ok {
+			keys[shadowKey(rec)] = struct{}{}
+		}
+		return len(keys) < len(wanted)
+	}); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	var ids []int64
+	err := s.shadow.Iterate(ctx, func(rec model.MemoryRecord) bool {
+		if _, ok := keys[shadowKey(rec)]; ok {
+			ids = append(ids, rec.ID)
+		}
+		return true
+	})
+	return ids, err
+}
+
+func (s *ShadowStore) report(diff ShadowDiff) {
+	if s.opts.OnDiff != nil {
+		s.opts.OnDiff(diff)
+	}
+	if !diff.Divergent() {
+		return
+	}
+	if diff.ShadowErr != nil {
+		s.opts.Logger.Printf("%s session=%q: shadow error: %v", diff.Op, diff.SessionID, diff.ShadowErr)
+		return
+	}
+	s.opts.Logger.Printf("%s session=%q limit=%d: overlap=%.2f primary=%d shadow=%d primary_only=%d shadow_only=%d reordered=%d latency=%s/%s",
+		diff.Op, diff.SessionID, diff.Limit, diff.Overlap(), diff.PrimaryCount, diff.ShadowCount,
+		len(diff.PrimaryOnly), len(diff.ShadowOnly), diff.Reordered, diff.PrimaryLatency, diff.ShadowLatency)
+}
+
+func compareSearchResults(primary, shadow []model.MemoryRecord) ShadowDiff {
+	diff := ShadowDiff{PrimaryCount: len(primary), ShadowCount: len(shadow)}
+	shadowRank := make(map[string]int, len(shadow))
+	for i, rec := range shadow {
+		if _, dup := shadowRank[shadowKey(rec)]; !dup {
+			shadowRank[shadowKey(rec)] = i
+		}
+	}
+	matched := make(map[string]struct{}, len(primary))
+	for i, rec := range primary {
+		key := shadowKey(rec)
+		rank, ok := shadowRank[key]
+		if !ok {
+			diff.PrimaryOnly = append(diff.PrimaryOnly, rec.Content)
+			continue
+		}
+		matched[key] = struct{}{}
+		if rank != i {
+			diff.Reordered++
+		}
+	}
+	for _, rec := range shadow {
+		if _, ok := matched[shadowKey(rec)]; !ok {
+			diff.ShadowOnly = append(diff.ShadowOnly, rec.Content)
+		}
+	}
+	return diff
+}
+
+func shadowKey(rec model.MemoryRecord) string {
+	return rec.SessionID + "\x00" + rec.Content
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+func TestShadowStoreDualWritesAndComparesSearches(t *testing.T) {
+	ctx := context.Background()
+	primary, shadow := NewInMemoryStore(), NewInMemoryStore()
+	var logs bytes.Buffer
+	var diffs []ShadowDiff
+	s, err := NewShadowStore(primary, shadow, ShadowOptions{
+		Logger: log.New(&logs, "", 0),
+		OnDiff: func(d ShadowDiff) { diffs = append(diffs, d) },
+	})
+	if err != nil {
+		t.Fatalf("NewShadowStore: %v", err)
+	}
+
+	if err := s.StoreMemory(ctx, "s", "alpha", map[string]any{"source": "test"}, []float32{1, 0}); err != nil {
+		t.Fatalf("StoreMemory: %v", err)
+	}
+	if n, _ := shadow.Count(ctx); n != 1 {
+		t.Fatalf("expected shadow to receive the write, count=%d", n)
+	}
+	if _, err := s.SearchMemory(ctx, "s", []float32{1, 0}, 5); err != nil {
+		t.Fatalf("SearchMemory: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].Divergent() || logs.Len() != 0 {
+		t.Fatalf("expected a matching comparison, got %+v log=%q", diffs, logs.String())
+	}
+
+	// A record only the shadow has must surface as divergence.
+	_ = shadow.StoreMemory(ctx, "s", "beta", nil, []float32{0, 1})
+	results, err := s.SearchMemory(ctx, "s", []float32{1, 0}, 5)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("expected primary results only, got %d, %v", len(results), err)
+	}
+	last := diffs[len(diffs)-1]
+	if !last.Divergent() || len(last.ShadowOnly) != 1 || last.ShadowOnly[0] != "beta" || last.Overlap() != 1 {
+		t.Fatalf("unexpected diff %+v", last)
+	}
+	if !strings.Contains(logs.String(), "shadow_only=1") {
+		t.Fatalf("expected divergence to be logged, got %q", logs.String())
+	}
+
+	// Deletes translate primary IDs to the shadow's records.
+	var id int64
+	_ = primary.Iterate(ctx, func(rec model.MemoryRecord) bool { id = rec.ID; return false })
+	if err := s.DeleteMemory(ctx, []int64{id}); err != nil {
+		t.Fatalf("DeleteMemory: %v", err)
+	}
+	if n, _ := shadow.Count(ctx); n != 1 {
+		t.Fatalf("expected only the shadow-only record to remain, count=%d", n)
+	}
+}
@@ -0,0 +1,583 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+const (
+	defaultTieredHotRecords    = 10000
+	defaultTieredQueueSize     = 1024
+	defaultTieredHitScore      = 0.75
+	defaultTieredPinImportance = 0.8
+	defaultTieredWriteAttempts = 5
+	defaultTieredRetryBackoff  = 100 * time.Millisecond
+	// tieredResolveLimit is how many near neighbours are searched to find
+	// the ID the backing store gave a queued write.
+	tieredResolveLimit = 5
+)
+
+// TieredStore keeps the records of active sessions in RAM in front of a
+// slower backing store such as Qdrant or Postgres. Writes land in RAM at
+// once and reach the backing store from a background queue. A session
+// search is answered from RAM when it finds limit records scoring at least
+// TieredOptions.HitScore; otherwise it goes to the backing store, whose
+// results are kept in RAM for the next search. When RAM is full the least
+// recently used records go first, except that records of high importance
+// outlast the others.
+//
+// A record still queued for the backing store is returned with ID zero,
+// since only the backing store assigns IDs. A write the backing store
+// rejects stays queued and is retried with backoff; one that still fails
+// after TieredOptions.WriteAttempts is dropped, passed to OnError and
+// returned by the next Flush or Close. Call Flush to wait for the queue,
+// and Close before exiting so no write is lost.
+type TieredStore struct {
+	cold VectorStore
+	opts TieredOptions
+
+	queue chan tieredWrite
+	done  chan struct{}
+	// sendMu keeps Close from closing the queue during a send.
+	sendMu sync.RWMutex
+	closed bool
+
+	mu       sync.Mutex
+	hot      map[int64]*tieredRecord
+	pending  map[uint64]*tieredRecord
+	seq      uint64
+	inflight int
+	idle     chan struct{}
+	failed   []error // writes given up on since the last Flush or Close
+}
+
+// TieredOptions configures a TieredStore.
+type TieredOptions struct {
+	// HotRecords caps the records kept in RAM; zero means 10000. Queued
+	// writes are kept on top of the cap until they are stored.
+	HotRecords int
+	// HitScore is the similarity the limit-th record in RAM must reach for
+	// a search to skip the backing store; zero means 0.75.
+	HitScore float64
+	// PinImportance is the importance from which records are evicted only
+	// after every less important one; zero means 0.8.
+	PinImportance float64
+	// QueueSize bounds the writes waiting for the backing store. StoreMemory
+	// blocks while the queue is full; zero means 1024.
+	QueueSize int
+	// WriteAttempts is how often a queued write is tried against the
+	// backing store before it is given up on; zero means 5. RetryBackoff is
+	// the wait before the first retry, doubled for each later one; zero
+	// means 100ms.
+	WriteAttempts int
+	RetryBackoff  time.Duration
+	// OnError, when set, is called from the queue with every write given up
+	// on.
+	OnError func(sessionID string, writes []MemoryWrite, err error)
+	// Logger receives backing-store writes that failed in the background.
+	// Defaults to stderr with a "memory-tiered: " prefix.
+	Logger *log.Logger
+}
+
+type tieredRecord struct {
+	record     model.MemoryRecord
+	magnitudes recordVectorMagnitudes
+	used       time.Time
+}
+
+type tieredWrite struct {
+	ctx       context.Context
+	sessionID string
+	writes    []MemoryWrite
+	keys      []uint64
+}
+
+var (
+	_ VectorStore       = (*TieredStore)(nil)
+	_ BatchStore        = (*TieredStore)(nil)
+	_ RecordGetter      = (*TieredStore)(nil)
+	_ GraphStore        = (*TieredStore)(nil)
+	_ SchemaInitializer = (*TieredStore)(nil)
+)
+
+// NewTieredStore wraps cold, the store that keeps every record, with an
+// in-memory tier and starts its write-through queue.
+func NewTieredStore(cold VectorStore, opts TieredOptions) (*TieredStore, error) {
+	if cold == nil {
+		return nil, errors.New("backing vector store is nil")
+	}
+	if opts.HotRecords <= 0 {
+		opts.HotRecords = defaultTieredHotRecords
+	}
+	if opts.HitScore <= 0 {
+		opts.HitScore = defaultTieredHitScore
+	}
+	if opts.PinImportance <= 0 {
+		opts.PinImportance = defaultTieredPinImportance
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultTieredQueueSize
+	}
+	if opts.WriteAttempts <= 0 {
+		opts.WriteAttempts = defaultTieredWriteAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultTieredRetryBackoff
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.New(os.Stderr, "memory-tiered: ", log.LstdFlags)
+	}
+	s := &TieredStore{
+		cold:    cold,
+		opts:    opts,
+		queue:   make(chan tieredWrite, opts.QueueSize),
+		done:    make(chan struct{}),
+		hot:     make(map[int64]*tieredRecord),
+		pending: make(map[uint64]*tieredRecord),
+	}
+	go s.writeBehind()
+	return s, nil
+}
+
+// Backing returns the store that keeps every record.
+func (s *TieredStore) Backing() VectorStore { return s.cold }
+
+// StoreMemory makes the memory searchable at once and queues it for the
+// backing store.
+func (s *TieredStore) StoreMemory(ctx context.Context, sessionID, content string, metadata map[string]any, embedding []float32) error {
+	return s.StoreMemories(ctx, sessionID, []MemoryWrite{{Content: content, Metadata: metadata, Embedding: embedding}})
+}
+
+// StoreMemories implements BatchStore; the batch reaches the backing store
+// through its own bulk path.
+func (s *TieredStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	job := tieredWrite{ctx: context.WithoutCancel(ctx), sessionID: sessionID}
+	s.sendMu.RLock()
+	defer s.sendMu.RUnlock()
+	if s.closed {
+		return errors.New("tiered store is closed")
+	}
+	s.mu.Lock()
+	for _, w := range writes {
+		// The backing store prepares its own copy of the metadata.
+		meta := make(map[string]any, len(w.Metadata))
+		for k, v := range w.Metadata {
+			meta[k] = v
+		}
+		rec := prepareMemoryRecord(sessionID, w.Content, meta, w.Embedding, now, false)
+		s.seq++
+		s.pending[s.seq] = &tieredRecord{record: rec, magnitudes: calculateRecordMagnitudes(rec), used: now}
+		job.writes = append(job.writes, w)
+		job.keys = append(job.keys, s.seq)
+	}
+	if s.inflight == 0 {
+		s.idle = make(chan struct{})
+	}
+	s.inflight++
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- job:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		for _, key := range job.keys {
+			delete(s.pending, key)
+		}
+		s.finishLocked()
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// SearchMemory answers from RAM when it holds limit good matches in the
+// session and from the backing store otherwise. Queued writes of the
+// session are ranked alongside the backing store's results.
+func (s *TieredStore) SearchMemory(ctx context.Context, sessionID string, queryEmbedding []float32, limit int) ([]model.MemoryRecord, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	query := model.NewCosineQuery(queryEmbedding)
+	tenant := model.TenantFromContext(ctx)
+	keep := func(rec *model.MemoryRecord) bool {
+		return rec.TenantID == tenant && (sessionID == "" || rec.SessionID == sessionID)
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	hot := scoreTiered(query, s.hot, keep)
+	queued := scoreTiered(query, s.pending, keep)
+	all := append(append([]*scoredTiered(nil), hot...), queued...)
+	sortScoredTiered(all)
+	if len(all) >= limit && all[limit-1].score >= s.opts.HitScore {
+		out := make([]model.MemoryRecord, limit)
+		for i, sc := range all[:limit] {
+			sc.entry.used = now
+			out[i] = sc.result()
+		}
+		s.mu.Unlock()
+		return out, nil
+	}
+	s.mu.Unlock()
+
+	records, err := s.cold.SearchMemory(ctx, sessionID, queryEmbedding, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	seen := make(map[string]struct{}, len(records))
+	for _, rec := range records {
+		s.admitLocked(rec, now)
+		seen[shadowKey(rec)] = struct{}{}
+	}
+	s.evictLocked()
+	s.mu.Unlock()
+	for _, sc := range queued {
+		if _, dup := seen[shadowKey(sc.entry.record)]; !dup {
+			records = append(records, sc.result())
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Score > records[j].Score })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// SearchMemoryFiltered implements FilteredSearcher by searching the backing
+// store, which holds every record the filter may select.
+func (s *TieredStore) SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return SearchFiltered(ctx, s.cold, queryEmbedding, filter, limit)
+}
+
+// UpdateEmbedding updates the backing store and drops the copy in RAM, so
+// the next search reads the new version.
+func (s *TieredStore) UpdateEmbedding(ctx context.Context, id int64, embedding []float32, lastEmbedded time.Time) error {
+	if err := s.cold.UpdateEmbedding(ctx, id, embedding, lastEmbedded); err != nil {
+		return err
+	}
+	s.forget(id)
+	return nil
+}
+
+// UpdateImportance rescores the record in the backing store when it
+// supports it.
+func (s *TieredStore) UpdateImportance(ctx context.Context, id int64, importance float64) error {
+	if u, ok := s.cold.(ImportanceUpdater); ok {
+		if err := u.UpdateImportance(ctx, id, importance); err != nil {
+			return err
+		}
+		s.forget(id)
+	}
+	return nil
+}
+
+// UpdateMetadata rewrites the record in the backing store.
+func (s *TieredStore) UpdateMetadata(ctx context.Context, record model.MemoryRecord) error {
+	u, ok := s.cold.(MetadataUpdater)
+	if !ok {
+		return ErrMetadataUpdatesUnsupported
+	}
+	if err := u.UpdateMetadata(ctx, record); err != nil {
+		return err
+	}
+	s.forget(record.ID)
+	return nil
+}
+
+// DeleteMemory deletes from the backing store and from RAM.
+func (s *TieredStore) DeleteMemory(ctx context.Context, ids []int64) error {
+	if err := s.cold.DeleteMemory(ctx, ids); err != nil {
+		return err
+	}
+	s.forget(ids...)
+	return nil
+}
+
+// Iterate waits for queued writes and walks the backing store.
+func (s *TieredStore) Iterate(ctx context.Context, fn func(model.MemoryRecord) bool) error {
+	if err := s.wait(ctx); err != nil {
+		return err
+	}
+	return s.cold.Iterate(ctx, fn)
+}
+
+// Count waits for queued writes and counts the backing store.
+func (s *TieredStore) Count(ctx context.Context) (int, error) {
+	if err := s.wait(ctx); err != nil {
+		return 0, err
+	}
+	return s.cold.Count(ctx)
+}
+
+// GetMemories implements RecordGetter, reading what RAM lacks from the
+// backing store.
+func (s *TieredStore) GetMemories(ctx context.Context, ids []int64) ([]model.MemoryRecord, error) {
+	tenant := model.TenantFromContext(ctx)
+	var out []model.MemoryRecord
+	var missing []int64
+	s.mu.Lock()
+	for _, id := range ids {
+		if entry, ok := s.hot[id]; ok {
+			if entry.record.TenantID == tenant {
+				out = append(out, entry.record)
+			}
+			continue
+		}
+		missing = append(missing, id)
+	}
+	s.mu.Unlock()
+	cold, err := GetMemories(ctx, s.cold, missing)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, cold...), nil
+}
+
+// CreateSchema initialises the backing store.
+func (s *TieredStore) CreateSchema(ctx context.Context, schemaPath string) error {
+	if initializer, ok := s.cold.(SchemaInitializer); ok {
+		return initializer.CreateSchema(ctx, schemaPath)
+	}
+	return nil
+}
+
+// UpsertGraph forwards to the backing store when it is a GraphStore.
+func (s *TieredStore) UpsertGraph(ctx context.Context, record model.MemoryRecord, edges []model.GraphEdge) error {
+	if graph, ok := s.cold.(GraphStore); ok {
+		return graph.UpsertGraph(ctx, record, edges)
+	}
+	return nil
+}
+
+// Neighborhood forwards to the backing store when it is a GraphStore.
+func (s *TieredStore) Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error) {
+	if graph, ok := s.cold.(GraphStore); ok {
+		return graph.Neighborhood(ctx, sessionID, seedIDs, hops, limit)
+	}
+	return nil, nil
+}
+
+// QueryGraph answers q from the backing store, so edges of writes still
+// queued are not followed yet.
+func (s *TieredStore) QueryGraph(ctx context.Context, q model.GraphQuery) ([]model.GraphPath, error) {
+	return QueryGraph(ctx, s.cold, q)
+}
+
+// Flush waits until every queued write has reached the backing store or
+// been given up on, and returns the errors of the writes given up on since
+// the last Flush or Close.
+func (s *TieredStore) Flush(ctx context.Context) error {
+	if err := s.wait(ctx); err != nil {
+		return err
+	}
+	return s.takeErrors()
+}
+
+// wait blocks until the queue is empty.
+func (s *TieredStore) wait(ctx context.Context) error {
+	s.mu.Lock()
+	if s.inflight == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	idle := s.idle
+	s.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stores the queued writes and stops the queue, returning the errors
+// of the writes given up on since the last Flush. The backing store is left
+// open. Later writes fail.
+func (s *TieredStore) Close() error {
+	s.sendMu.Lock()
+	if s.closed {
+		s.sendMu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.sendMu.Unlock()
+	<-s.done
+	return s.takeErrors()
+}
+
+func (s *TieredStore) takeErrors() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.failed...)
+	s.failed = nil
+	return err
+}
+
+// writeBehind stores queued writes in order and swaps each pending record
+// for the backing store's copy, which carries its ID. A job stays pending
+// while it is retried.
+func (s *TieredStore) writeBehind() {
+	defer close(s.done)
+	for job := range s.queue {
+		err := s.store(job)
+		if err != nil {
+			s.opts.Logger.Printf("give up storing %d memories of session %q: %v", len(job.writes), job.sessionID, err)
+			if s.opts.OnError != nil {
+				s.opts.OnError(job.sessionID, job.writes, err)
+			}
+		}
+		stored := make([]*model.MemoryRecord, len(job.writes))
+		if err == nil {
+			for i, w := range job.writes {
+				stored[i] = s.resolve(job.ctx, job.sessionID, w)
+			}
+		}
+		s.mu.Lock()
+		if err != nil {
+			s.failed = append(s.failed, fmt.Errorf("store %d memories of session %q: %w", len(job.writes), job.sessionID, err))
+		}
+		for i, key := range job.keys {
+			entry, ok := s.pending[key]
+			delete(s.pending, key)
+			if ok && stored[i] != nil {
+				s.admitLocked(*stored[i], entry.used)
+			}
+		}
+		s.evictLocked()
+		s.finishLocked()
+		s.mu.Unlock()
+	}
+}
+
+// store writes job to the backing store, retrying with doubling backoff up
+// to WriteAttempts times. Without a BatchStore, writes already stored are
+// not repeated on retry.
+func (s *TieredStore) store(job tieredWrite) error {
+	backoff := s.opts.RetryBackoff
+	bs, batch := s.cold.(BatchStore)
+	done := 0
+	for attempt := 1; ; attempt++ {
+		var err error
+		if batch {
+			err = bs.StoreMemories(job.ctx, job.sessionID, job.writes)
+		} else {
+			for ; done < len(job.writes); done++ {
+				w := job.writes[done]
+				if err = s.cold.StoreMemory(job.ctx, job.sessionID, w.Content, w.Metadata, w.Embedding); err != nil {
+					break
+				}
+			}
+		}
+		if err == nil || attempt >= s.opts.WriteAttempts {
+			return err
+		}
+		s.opts.Logger.Printf("store %d memories of session %q (attempt %d): %v; retrying in %s", len(job.writes), job.sessionID, attempt, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// resolve finds the backing store's copy of a write it has just stored.
+func (s *TieredStore) resolve(ctx context.Context, sessionID string, w MemoryWrite) *model.MemoryRecord {
+	records, err := s.cold.SearchMemory(ctx, sessionID, w.Embedding, tieredResolveLimit)
+	if err != nil {
+		return nil
+	}
+	var found *model.MemoryRecord
+	for i := range records {
+		if records[i].ID != 0 && records[i].Content == w.Content && (found == nil || records[i].CreatedAt.After(found.CreatedAt)) {
+			found = &records[i]
+		}
+	}
+	return found
+}
+
+func (s *TieredStore) finishLocked() {
+	s.inflight--
+	if s.inflight == 0 {
+		close(s.idle)
+	}
+}
+
+func (s *TieredStore) admitLocked(rec model.MemoryRecord, used time.Time) {
+	if rec.ID == 0 {
+		return
+	}
+	rec.Score = 0
+	s.hot[rec.ID] = &tieredRecord{record: rec, magnitudes: calculateRecordMagnitudes(rec), used: used}
+}
+
+// evictLocked trims RAM to a tenth below HotRecords, so eviction runs once
+// per many admissions. Records under PinImportance go first, oldest use
+// first within each group.
+func (s *TieredStore) evictLocked() {
+	if len(s.hot) <= s.opts.HotRecords {
+		return
+	}
+	entries := make([]*tieredRecord, 0, len(s.hot))
+	for _, entry := range s.hot {
+		entries = append(entries, entry)
+	}
+	pin := s.opts.PinImportance
+	sort.Slice(entries, func(i, j int) bool {
+		pi, pj := entries[i].record.Importance >= pin, entries[j].record.Importance >= pin
+		if pi != pj {
+			return !pi
+		}
+		return entries[i].used.Before(entries[j].used)
+	})
+	target := s.opts.HotRecords - s.opts.HotRecords/10
+	for _, entry := range entries[:len(entries)-target] {
+		delete(s.hot, entry.record.ID)
+	}
+}
+
+func (s *TieredStore) forget(ids ...int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.hot, id)
+	}
+}
+
+type scoredTiered struct {
+	entry *tieredRecord
+	score float64
+}
+
+func (sc *scoredTiered) result() model.MemoryRecord {
+	rec := sc.entry.record
+	rec.Score = sc.score
+	return rec
+}
+
+func scoreTiered[K comparable](query model.CosineQuery, entries map[K]*tieredRecord, keep func(*model.MemoryRecord) bool) []*scoredTiered {
+	var out []*scoredTiered
+	for _, entry := range entries {
+		if keep(&entry.record) {
+			out = append(out, &scoredTiered{entry: entry, score: maxSimilarityWithMagnitudes(query, &entry.record, entry.magnitudes)})
+		}
+	}
+	return out
+}
+
+func sortScoredTiered(scored []*scoredTiered) {
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+}
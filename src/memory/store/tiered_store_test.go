@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+)
+
+// countingStore counts the searches that reach the backing store.
+type countingStore struct {
+	*InMemoryStore
+	searches atomic.Int32
+}
+
+func (c *countingStore) SearchMemory(ctx context.Context, sessionID string, q []float32, limit int) ([]model.MemoryRecord, error) {
+	c.searches.Add(1)
+	return c.InMemoryStore.SearchMemory(ctx, sessionID, q, limit)
+}
+
+func TestTieredStoreServesActiveSessionsFromRAM(t *testing.T) {
+	ctx := context.Background()
+	cold := &countingStore{InMemoryStore: NewInMemoryStore()}
+	s, err := NewTieredStore(cold, TieredOptions{HitScore: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.StoreMemory(ctx, "s", "alpha", nil, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	// The write is searchable before the backing store has it.
+	got, err := s.SearchMemory(ctx, "s", []float32{1, 0}, 1)
+	if err != nil || len(got) != 1 || got[0].Content != "alpha" {
+		t.Fatalf("search before flush = %+v, %v", got, err)
+	}
+	if err := s.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cold.Count(ctx); n != 1 {
+		t.Fatalf("backing store has %d records, want 1", n)
+	}
+
+	before := cold.searches.Load()
+	got, err = s.SearchMemory(ctx, "s", []float32{1, 0}, 1)
+	if err != nil || len(got) != 1 || got[0].ID == 0 {
+		t.Fatalf("hot search = %+v, %v", got, err)
+	}
+	if cold.searches.Load() != before {
+		t.Fatal("a good match in RAM should not reach the backing store")
+	}
+
+	// A record only the backing store has is found on a miss and kept.
+	_ = cold.InMemoryStore.StoreMemory(ctx, "s", "beta", nil, []float32{0, 1})
+	got, err = s.SearchMemory(ctx, "s", []float32{0, 1}, 1)
+	if err != nil || len(got) != 1 || got[0].Content != "beta" {
+		t.Fatalf("miss = %+v, %v", got, err)
+	}
+	before = cold.searches.Load()
+	if got, _ = s.SearchMemory(ctx, "s", []float32{0, 1}, 1); len(got) != 1 || cold.searches.Load() != before {
+		t.Fatalf("second search should be served from RAM, got %+v", got)
+	}
+
+	if err := s.DeleteMemory(ctx, []int64{got[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = s.SearchMemory(ctx, "s", []float32{0, 1}, 1); len(got) == 1 && got[0].Content == "beta" {
+		t.Fatal("deleted record still served")
+	}
+}
+
+func TestTieredStoreEvictsUnimportantRecordsFirst(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewTieredStore(NewInMemoryStore(), TieredOptions{HotRecords: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_ = s.StoreMemory(ctx, "s", "keep", map[string]any{"importance": 0.9}, []float32{1, 0})
+	_ = s.StoreMemory(ctx, "s", "old", nil, []float32{0, 1})
+	_ = s.StoreMemory(ctx, "s", "new", nil, []float32{1, 1})
+	if err := s.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contents := map[string]bool{}
+	for _, entry := range s.hot {
+		contents[entry.record.Content] = true
+	}
+	if len(contents) != 2 || !contents["keep"] || !contents["new"] {
+		t.Fatalf("hot tier holds %v, want the important and the newest record", contents)
+	}
+}
+
+func TestTieredStoreCloseStoresQueuedWrites(t *testing.T) {
+	ctx := context.Background()
+	cold := NewInMemoryStore()
+	s, _ := NewTieredStore(cold, TieredOptions{})
+	if err := s.StoreMemories(ctx, "s", []MemoryWrite{{Content: "a", Embedding: []float32{1}}, {Content: "b", Embedding: []float32{1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cold.Count(ctx); n != 2 {
+		t.Fatalf("backing store has %d records after Close, want 2", n)
+	}
+	if err := s.StoreMemory(ctx, "s", "late", nil, []float32{1}); err == nil {
+		t.Fatal("writes after Close should fail")
+	}
+}
+
+// flakyStore rejects the first failures batch writes.
+type flakyStore struct {
+	*InMemoryStore
+	failures atomic.Int32
+}
+
+func (f *flakyStore) StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error {
+	if f.failures.Add(-1) >= 0 {
+		return errors.New("backend unavailable")
+	}
+	return f.InMemoryStore.StoreMemories(ctx, sessionID, writes)
+}
+
+func TestTieredStoreRetriesRejectedWrites(t *testing.T) {
+	ctx := context.Background()
+	cold := &flakyStore{InMemoryStore: NewInMemoryStore()}
+	cold.failures.Store(2)
+	s, _ := NewTieredStore(cold, TieredOptions{RetryBackoff: time.Millisecond, Logger: log.New(io.Discard, "", 0)})
+	if err := s.StoreMemory(ctx, "s", "a", nil, []float32{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush after transient failures = %v", err)
+	}
+	if n, _ := cold.Count(ctx); n != 1 {
+		t.Fatalf("backing store has %d records, want 1", n)
+	}
+
+	cold.failures.Store(100)
+	var given atomic.Int32
+	s, _ = NewTieredStore(cold, TieredOptions{
+		WriteAttempts: 3,
+		RetryBackoff:  time.Millisecond,
+		Logger:        log.New(io.Discard, "", 0),
+		OnError:       func(string, []MemoryWrite, error) { given.Add(1) },
+	})
+	if err := s.StoreMemory(ctx, "s", "b", nil, []float32{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(ctx); err == nil {
+		t.Fatal("Flush should report the write given up on")
+	}
+	if given.Load() != 1 {
+		t.Fatalf("OnError called %d times, want 1", given.Load())
+	}
+	if got := 100 - cold.failures.Load(); got != 3 {
+		t.Fatalf("backing store tried %d times, want 3", got)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close after Flush reported the failure again: %v", err)
+	}
+}
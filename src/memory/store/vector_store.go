@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory/model"
@@ -17,6 +19,24 @@ type VectorStore interface {
 	Count(ctx context.Context) (int, error)
 }
 
+// ImportanceUpdater is implemented by stores that can rescore a memory's
+// importance in place, for example after user feedback.
+type ImportanceUpdater interface {
+	UpdateImportance(ctx context.Context, id int64, importance float64) error
+}
+
+// MetadataUpdater is implemented by stores that can rewrite a memory's
+// metadata in place. UpdateMetadata replaces the metadata of record.ID with
+// record.Metadata; SessionID identifies the record's session and the other
+// fields are ignored.
+type MetadataUpdater interface {
+	UpdateMetadata(ctx context.Context, record model.MemoryRecord) error
+}
+
+// ErrMetadataUpdatesUnsupported is returned by store wrappers whose primary
+// store does not implement MetadataUpdater.
+var ErrMetadataUpdatesUnsupported = errors.New("store does not support metadata updates")
+
 // SchemaInitializer allows stores to expose optional schema/bootstrap routines.
 type SchemaInitializer interface {
 	CreateSchema(ctx context.Context, schemaPath string) error
@@ -27,3 +47,135 @@ type GraphStore interface {
 	UpsertGraph(ctx context.Context, record model.MemoryRecord, edges []model.GraphEdge) error
 	Neighborhood(ctx context.Context, sessionID string, seedIDs []int64, hops, limit int) ([]model.MemoryRecord, error)
 }
+
+// FilteredSearcher is implemented by stores that can apply a model.Filter
+// while searching, so filtered-out records never leave the store. The
+// filter's Sessions and Spaces take the place of a session ID; with
+// neither, every session of the tenant on ctx is searched.
+type FilteredSearcher interface {
+	SearchMemoryFiltered(ctx context.Context, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error)
+}
+
+// filterOverfetch widens each session's search when SearchFiltered has to
+// filter the results itself.
+const filterOverfetch = 4
+
+// SearchFiltered searches vs with filter applied, inside the store when it
+// is a FilteredSearcher. Otherwise each of the filter's sessions and spaces
+// is searched more widely and the results are filtered here; a filter
+// naming neither searches session "", which not every store treats as
+// "all sessions".
+func SearchFiltered(ctx context.Context, vs VectorStore, queryEmbedding []float32, filter model.Filter, limit int) ([]model.MemoryRecord, error) {
+	if fs, ok := vs.(FilteredSearcher); ok {
+		return fs.SearchMemoryFiltered(ctx, queryEmbedding, filter, limit)
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	scopes := filter.Scopes()
+	if len(scopes) == 0 {
+		scopes = []string{""}
+	}
+	var out []model.MemoryRecord
+	seen := map[int64]struct{}{}
+	for _, scope := range scopes {
+		records, err := vs.SearchMemory(ctx, scope, queryEmbedding, limit*filterOverfetch)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			if !filter.Matches(rec) {
+				continue
+			}
+			if rec.ID != 0 {
+				if _, dup := seen[rec.ID]; dup {
+					continue
+				}
+				seen[rec.ID] = struct{}{}
+			}
+			out = append(out, rec)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// MemoryWrite is one memory of a batch write.
+type MemoryWrite struct {
+	Content   string
+	Metadata  map[string]any
+	Embedding []float32
+}
+
+// BatchStore is implemented by stores that can persist many memories of a
+// session in one round trip.
+type BatchStore interface {
+	StoreMemories(ctx context.Context, sessionID string, writes []MemoryWrite) error
+}
+
+// StoreMemories writes writes through the store's bulk path when it has one
+// and one StoreMemory call at a time otherwise.
+func StoreMemories(ctx context.Context, vs VectorStore, sessionID string, writes []MemoryWrite) error {
+	if bs, ok := vs.(BatchStore); ok {
+		return bs.StoreMemories(ctx, sessionID, writes)
+	}
+	for _, w := range writes {
+		if err := vs.StoreMemory(ctx, sessionID, w.Content, w.Metadata, w.Embedding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordGetter is implemented by stores that can fetch memories by ID.
+// GetMemories returns the records of the tenant on ctx among ids; missing
+// IDs are skipped.
+type RecordGetter interface {
+	GetMemories(ctx context.Context, ids []int64) ([]model.MemoryRecord, error)
+}
+
+// GetMemories fetches the records with the given ids through the store's
+// RecordGetter, or with one scan of the store otherwise. Only records of
+// the tenant on ctx are returned, in the order of ids.
+func GetMemories(ctx context.Context, vs VectorStore, ids []int64) ([]model.MemoryRecord, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []model.MemoryRecord
+	if rg, ok := vs.(RecordGetter); ok {
+		recs, err := rg.GetMemories(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		found = recs
+	} else {
+		want := make(map[int64]struct{}, len(ids))
+		for _, id := range ids {
+			want[id] = struct{}{}
+		}
+		tenant := model.TenantFromContext(ctx)
+		if err := vs.Iterate(ctx, func(rec model.MemoryRecord) bool {
+			if _, ok := want[rec.ID]; ok && model.RecordTenant(rec) == tenant {
+				found = append(found, rec)
+			}
+			return len(found) < len(want) && ctx.Err() == nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[int64]model.MemoryRecord, len(found))
+	for _, rec := range found {
+		byID[rec.ID] = rec
+	}
+	out := make([]model.MemoryRecord, 0, len(byID))
+	for _, id := range ids {
+		if rec, ok := byID[id]; ok {
+			out = append(out, rec)
+			delete(byID, id)
+		}
+	}
+	return out, ctx.Err()
+}
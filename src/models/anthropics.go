@@ -2,10 +2,11 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 	anthropic "github.com/anthropics/anthropic-sdk-go"
 	anthropicopt "github.com/anthropics/anthropic-sdk-go/option"
 )
@@ -20,7 +21,10 @@ type AnthropicLLM struct {
 
 // NewAnthropicLLM constructs a client. It reads ANTHROPIC_API_KEY from the env.
 func NewAnthropicLLM(model, promptPrefix string) *AnthropicLLM {
-	key := os.Getenv("ANTHROPIC_API_KEY")
+	return newAnthropicLLMWithKey(secrets.Lookup("ANTHROPIC_API_KEY"), model, promptPrefix)
+}
+
+func newAnthropicLLMWithKey(key, model, promptPrefix string) *AnthropicLLM {
 	cl := anthropic.NewClient(
 		anthropicopt.WithAPIKey(key),
 	)
@@ -60,6 +64,35 @@ func (a *AnthropicLLM) Generate(ctx context.Context, prompt string) (any, error)
 }
 
 func (a *AnthropicLLM) GenerateWithFiles(ctx context.Context, prompt string, files []File) (any, error) {
+	// Add system prefix if present
+	fullPrompt := prompt
+	if a.PromptPrefix != "" {
+		fullPrompt = fmt.Sprintf("%s\n\n%s", a.PromptPrefix, prompt)
+	}
+
+	msg, err := a.Client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.Model),
+		MaxTokens: int64(a.MaxTokens),
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropicUserBlocks(fullPrompt, files)...),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anthropic generateWithFiles: %w", err)
+	}
+
+	var b strings.Builder
+	for _, cb := range msg.Content {
+		if tb, ok := cb.AsAny().(anthropic.TextBlock); ok {
+			b.WriteString(tb.Text)
+		}
+	}
+	return b.String(), nil
+}
+
+// anthropicUserBlocks builds the content of a user message: the text with
+// inline text files, then the images Anthropic accepts.
+func anthropicUserBlocks(text string, files []File) []anthropic.ContentBlockParamUnion {
 	// Normalize all file MIME types
 	norm := make([]File, 0, len(files))
 	for _, f := range files {
@@ -70,18 +103,10 @@ func (a *AnthropicLLM) GenerateWithFiles(ctx context.Context, prompt string, fil
 		})
 	}
 
-	// Build message content with text and attachments
-	var contentBlocks []anthropic.ContentBlockParamUnion
-
-	// Add system prefix if present
-	fullPrompt := prompt
-	if a.PromptPrefix != "" {
-		fullPrompt = fmt.Sprintf("%s\n\n%s", a.PromptPrefix, prompt)
-	}
-
 	// Add text prompt with inline text files
-	textContent := combinePromptWithFiles(fullPrompt, norm)
-	contentBlocks = append(contentBlocks, anthropic.NewTextBlock(textContent))
+	contentBlocks := []anthropic.ContentBlockParamUnion{
+		anthropic.NewTextBlock(combinePromptWithFiles(text, norm)),
+	}
 
 	// Attach images and videos as proper content blocks
 	for _, f := range norm {
@@ -101,25 +126,70 @@ func (a *AnthropicLLM) GenerateWithFiles(ctx context.Context, prompt string, fil
 		// Note: Anthropic doesn't support video in Messages API yet
 		// Videos will be referenced in the text context only
 	}
+	return contentBlocks
+}
 
-	msg, err := a.Client.Messages.New(ctx, anthropic.MessageNewParams{
+// Chat sends the system messages as the system parameter, tool calls as
+// tool_use blocks and tool messages as tool_result blocks. Consecutive
+// messages of the same role are merged, as the Messages API requires.
+func (a *AnthropicLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	system, rest := splitSystem(withPromptPrefix(a.PromptPrefix, messages))
+	var params []anthropic.MessageParam
+	for _, m := range rest {
+		var param anthropic.MessageParam
+		switch m.Role {
+		case RoleAssistant:
+			var blocks []anthropic.ContentBlockParamUnion
+			if m.Content != "" {
+				blocks = append(blocks, anthropic.NewTextBlock(m.Content))
+			}
+			for _, call := range m.ToolCalls {
+				blocks = append(blocks, anthropic.NewToolUseBlock(call.ID, call.Arguments, call.Name))
+			}
+			param = anthropic.NewAssistantMessage(blocks...)
+		case RoleTool:
+			param = anthropic.NewUserMessage(anthropic.NewToolResultBlock(m.ToolCallID, m.Content, false))
+		default:
+			param = anthropic.NewUserMessage(anthropicUserBlocks(m.Content, m.Files)...)
+		}
+		if n := len(params); n > 0 && params[n-1].Role == param.Role {
+			params[n-1].Content = append(params[n-1].Content, param.Content...)
+			continue
+		}
+		params = append(params, param)
+	}
+
+	req := anthropic.MessageNewParams{
 		Model:     anthropic.Model(a.Model),
 		MaxTokens: int64(a.MaxTokens),
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(contentBlocks...),
-		},
-	})
+		Messages:  params,
+	}
+	if system != "" {
+		req.System = []anthropic.TextBlockParam{{Text: system}}
+	}
+	msg, err := a.Client.Messages.New(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("anthropic generateWithFiles: %w", err)
+		return Message{}, fmt.Errorf("anthropic chat: %w", err)
 	}
 
+	reply := Message{Role: RoleAssistant}
 	var b strings.Builder
 	for _, cb := range msg.Content {
-		if tb, ok := cb.AsAny().(anthropic.TextBlock); ok {
-			b.WriteString(tb.Text)
+		switch block := cb.AsAny().(type) {
+		case anthropic.TextBlock:
+			b.WriteString(block.Text)
+		case anthropic.ToolUseBlock:
+			arguments := map[string]any{}
+			if len(block.Input) > 0 {
+				if err := json.Unmarshal(block.Input, &arguments); err != nil {
+					return Message{}, fmt.Errorf("anthropic tool %s arguments: %w", block.Name, err)
+				}
+			}
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: arguments})
 		}
 	}
-	return b.String(), nil
+	reply.Content = b.String()
+	return reply, nil
 }
 
 // GenerateStream uses Anthropic's streaming messages API.
@@ -135,6 +205,7 @@ func (a *AnthropicLLM) GenerateStream(ctx context.Context, prompt string) (<-cha
 		Messages: []anthropic.MessageParam{
 			anthropic.NewUserMessage(anthropic.NewTextBlock(fullPrompt)),
 		},
+		StopSequences: StopSequences(ctx),
 	})
 
 	ch := make(chan StreamChunk, 16)
@@ -159,7 +230,7 @@ func (a *AnthropicLLM) GenerateStream(ctx context.Context, prompt string) (<-cha
 		ch <- StreamChunk{Done: true, FullText: sb.String()}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
 // sanitizeForAnthropic filters MIME types to what Anthropic supports
@@ -8,12 +8,16 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/cache"
 )
 
-// CachedLLM wraps an Agent and caches Generate calls.
+// CachedLLM wraps an Agent and caches Generate calls. When the context
+// carries a fingerprint (see WithContextFingerprint), it is part of the cache
+// key, so a cached answer is only reused while the knowledge it was based on
+// is unchanged.
 type CachedLLM struct {
 	Agent    Agent
 	Cache    *cache.LRUCache
@@ -33,6 +37,30 @@ func NewCachedLLM(agent Agent, size int, ttl time.Duration, filePath string) *Ca
 	return c
 }
 
+type contextFingerprintKey struct{}
+
+// WithContextFingerprint tags ctx with a fingerprint of the knowledge behind
+// the prompts sent under it, typically the retrieved memory records.
+func WithContextFingerprint(ctx context.Context, fingerprint string) context.Context {
+	return context.WithValue(ctx, contextFingerprintKey{}, fingerprint)
+}
+
+// ContextFingerprint returns the fingerprint set by WithContextFingerprint.
+func ContextFingerprint(ctx context.Context) string {
+	fp, _ := ctx.Value(contextFingerprintKey{}).(string)
+	return fp
+}
+
+func cacheKey(ctx context.Context, prompt string) string {
+	if stops := StopSequences(ctx); len(stops) > 0 {
+		prompt = strings.Join(stops, "\x00") + "\x00stop\x00" + prompt
+	}
+	if fp := ContextFingerprint(ctx); fp != "" {
+		return cache.HashKey(fp + "\x00" + prompt)
+	}
+	return cache.HashKey(prompt)
+}
+
 func (c *CachedLLM) load() {
 	f, err := os.Open(c.FilePath)
 	if err != nil {
@@ -70,7 +98,7 @@ func (c *CachedLLM) save() {
 
 // Generate checks the cache before calling the underlying agent.
 func (c *CachedLLM) Generate(ctx context.Context, prompt string) (any, error) {
-	key := cache.HashKey(prompt)
+	key := cacheKey(ctx, prompt)
 	if val, ok := c.Cache.Get(key); ok {
 		return val, nil
 	}
@@ -100,6 +128,7 @@ func (c *CachedLLM) GenerateWithTools(ctx context.Context, prompt string, tools
 func (c *CachedLLM) GenerateWithFiles(ctx context.Context, prompt string, files []File) (any, error) {
 	// Create a cache key that includes the prompt and all file contents
 	h := sha256.New()
+	h.Write([]byte(ContextFingerprint(ctx)))
 	h.Write([]byte(prompt))
 	for _, f := range files {
 		h.Write([]byte(f.Name))
@@ -122,11 +151,46 @@ func (c *CachedLLM) GenerateWithFiles(ctx context.Context, prompt string, files
 	return res, nil
 }
 
+// Chat checks the cache, keyed by every message and file, before calling
+// the wrapped model through models.Chat. Replies that call tools are not
+// cached, for the same reason GenerateWithTools results are not.
+func (c *CachedLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	h := sha256.New()
+	h.Write([]byte("chat\x00" + ContextFingerprint(ctx)))
+	enc := json.NewEncoder(h)
+	for _, m := range messages {
+		_ = enc.Encode(m)
+		for _, f := range m.Files {
+			h.Write([]byte(f.Name))
+			h.Write([]byte(f.MIME))
+			h.Write(f.Data)
+		}
+	}
+	key := hex.EncodeToString(h.Sum(nil))
+
+	if val, ok := c.Cache.Get(key); ok {
+		return Message{Role: RoleAssistant, Content: fmt.Sprint(val)}, nil
+	}
+
+	reply, err := Chat(ctx, c.Agent, messages)
+	if err != nil {
+		return Message{}, err
+	}
+	if len(reply.ToolCalls) == 0 {
+		c.Cache.Set(key, reply.Content)
+		c.save()
+	}
+	return reply, nil
+}
+
+// PromptAdapter implements PromptAdapterProvider for the wrapped model.
+func (c *CachedLLM) PromptAdapter() PromptAdapter { return PromptAdapterFor(c.Agent) }
+
 // GenerateStream passes through to the underlying agent's streaming.
 // If the prompt is already cached, it returns a single-chunk stream from cache.
 // Otherwise, it streams from the underlying agent and caches the full result when done.
 func (c *CachedLLM) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
-	key := cache.HashKey(prompt)
+	key := cacheKey(ctx, prompt)
 	if val, ok := c.Cache.Get(key); ok {
 		ch := make(chan StreamChunk, 1)
 		go func() {
@@ -148,7 +212,7 @@ func (c *CachedLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 		for chunk := range innerCh {
 			ch <- chunk
 			if chunk.Done {
-				if chunk.FullText != "" && chunk.Err == nil {
+				if chunk.FullText != "" && chunk.Err == nil && !chunk.Interrupted {
 					c.Cache.Set(key, chunk.FullText)
 					c.save()
 				}
@@ -110,3 +110,19 @@ func TestCachedLLM_GenerateWithFiles(t *testing.T) {
 		t.Errorf("expected 2 calls, got %d", count)
 	}
 }
+
+func TestCachedLLMKeysByContextFingerprint(t *testing.T) {
+	mock := &MockAgent{}
+	cached := NewCachedLLM(mock, 10, time.Minute, "")
+	before := WithContextFingerprint(context.Background(), "kb-v1")
+	after := WithContextFingerprint(context.Background(), "kb-v2")
+
+	for _, ctx := range []context.Context{before, before, after} {
+		if _, err := cached.Generate(ctx, "what is the refund window?"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count := atomic.LoadInt32(&mock.CallCount); count != 2 {
+		t.Fatalf("expected a miss after the knowledge changed, got %d calls", count)
+	}
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is one turn of a conversation. An assistant message may carry
+// the tool calls the model made; a RoleTool message answers the call with
+// ID ToolCallID, and its Name is the tool's.
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content,omitempty"`
+	Files      []File     `json:"-"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+// ChatAgent is implemented by models that take a conversation as
+// structured messages, so system instructions, earlier turns and tool
+// results reach the provider in its own message format instead of one
+// concatenated prompt. Chat returns the assistant's reply.
+type ChatAgent interface {
+	Chat(ctx context.Context, messages []Message) (Message, error)
+}
+
+// MessageFormatter is implemented by prompt adapters that render a whole
+// conversation as one prompt, such as a chat template.
+type MessageFormatter interface {
+	FormatMessages([]Message) string
+}
+
+// Chat sends messages to m, natively when m is a ChatAgent. Other models
+// get the messages flattened by FlattenMessages, with every file attached.
+func Chat(ctx context.Context, m Agent, messages []Message) (Message, error) {
+	if chat, ok := m.(ChatAgent); ok {
+		return chat.Chat(ctx, messages)
+	}
+	prompt, files := FlattenMessages(messages)
+	var (
+		out any
+		err error
+	)
+	if len(files) > 0 {
+		out, err = m.GenerateWithFiles(ctx, prompt, files)
+	} else {
+		out, err = m.Generate(ctx, prompt)
+	}
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Role: RoleAssistant, Content: responseText(out)}, nil
+}
+
+// FlattenMessages renders messages as the single prompt Agent.Generate
+// takes and collects their files. System messages come first and the first
+// user message is written as is, so a system and a user message flatten to
+// the prompt agents have always sent. Later turns are labelled by role.
+func FlattenMessages(messages []Message) (string, []File) {
+	var sb strings.Builder
+	var files []File
+	for _, m := range messages {
+		if m.Role == RoleSystem {
+			if system := strings.TrimSpace(m.Content); system != "" {
+				sb.WriteString(system)
+				sb.WriteString("\n\n")
+			}
+		}
+	}
+	first := true
+	for _, m := range messages {
+		files = append(files, m.Files...)
+		switch m.Role {
+		case RoleSystem:
+			continue
+		case RoleUser:
+			if first {
+				sb.WriteString(m.Content)
+			} else {
+				sb.WriteString("User: " + m.Content + "\n")
+			}
+		case RoleAssistant:
+			if m.Content != "" {
+				sb.WriteString("Assistant: " + m.Content + "\n")
+			}
+			for _, call := range m.ToolCalls {
+				args, _ := json.Marshal(call.Arguments)
+				fmt.Fprintf(&sb, "Assistant called %s with %s\n", call.Name, args)
+			}
+		case RoleTool:
+			fmt.Fprintf(&sb, "Tool %s returned: %s\n", m.Name, m.Content)
+		default:
+			sb.WriteString(m.Content + "\n")
+		}
+		first = false
+	}
+	return sb.String(), files
+}
+
+// splitSystem returns the joined system messages and the other messages.
+func splitSystem(messages []Message) (string, []Message) {
+	var system []string
+	rest := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Role == RoleSystem {
+			if s := strings.TrimSpace(m.Content); s != "" {
+				system = append(system, s)
+			}
+			continue
+		}
+		rest = append(rest, m)
+	}
+	return strings.Join(system, "\n\n"), rest
+}
+
+// withPromptPrefix puts a model's PromptPrefix ahead of the system
+// messages.
+func withPromptPrefix(prefix string, messages []Message) []Message {
+	if strings.TrimSpace(prefix) == "" {
+		return messages
+	}
+	return append([]Message{{Role: RoleSystem, Content: prefix}}, messages...)
+}
+
+// toolResultPayload wraps a tool message for providers that expect a JSON
+// object as the result.
+func toolResultPayload(content string) map[string]any {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return obj
+	}
+	return map[string]any{"result": content}
+}
+
+func responseText(out any) string {
+	if v, ok := out.(struct {
+		Text       string
+		Done       bool
+		DoneReason string
+	}); ok {
+		return v.Text
+	}
+	return fmt.Sprint(out)
+}
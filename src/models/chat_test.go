@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// promptRecorder is a model without a Chat method.
+type promptRecorder struct {
+	prompt string
+	files  []File
+}
+
+func (p *promptRecorder) Generate(_ context.Context, prompt string) (any, error) {
+	p.prompt = prompt
+	return "ok", nil
+}
+
+func (p *promptRecorder) GenerateWithFiles(_ context.Context, prompt string, files []File) (any, error) {
+	p.prompt, p.files = prompt, files
+	return "ok with files", nil
+}
+
+func (p *promptRecorder) GenerateStream(context.Context, string) (<-chan StreamChunk, error) {
+	return nil, nil
+}
+
+func TestChatFlattensMessagesForModelsWithoutChat(t *testing.T) {
+	model := &promptRecorder{}
+	file := File{Name: "notes.txt", MIME: "text/plain", Data: []byte("hi")}
+	reply, err := Chat(context.Background(), model, []Message{
+		{Role: RoleSystem, Content: " Be brief. "},
+		{Role: RoleUser, Content: "User: what is in the file?\n", Files: []File{file}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Role != RoleAssistant || reply.Content != "ok with files" {
+		t.Fatalf("reply = %+v", reply)
+	}
+	if want := "Be brief.\n\nUser: what is in the file?\n"; model.prompt != want {
+		t.Fatalf("prompt = %q, want %q", model.prompt, want)
+	}
+	if len(model.files) != 1 || model.files[0].Name != "notes.txt" {
+		t.Fatalf("files = %+v", model.files)
+	}
+}
+
+func TestFlattenMessagesLabelsLaterTurns(t *testing.T) {
+	prompt, _ := FlattenMessages([]Message{
+		{Role: RoleUser, Content: "weather?\n"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "1", Name: "weather", Arguments: map[string]any{"city": "Oslo"}}}},
+		{Role: RoleTool, Name: "weather", ToolCallID: "1", Content: "rain"},
+		{Role: RoleUser, Content: "thanks"},
+	})
+	for _, want := range []string{
+		"weather?\n",
+		`Assistant called weather with {"city":"Oslo"}`,
+		"Tool weather returned: rain",
+		"User: thanks",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt %q lacks %q", prompt, want)
+		}
+	}
+}
+
+func TestLlamaFormatMessagesRendersEveryTurn(t *testing.T) {
+	got := LlamaPromptAdapter{}.FormatMessages([]Message{
+		{Role: RoleSystem, Content: "Be brief."},
+		{Role: RoleUser, Content: "hi"},
+		{Role: RoleAssistant, Content: "hello"},
+		{Role: RoleTool, Name: "clock", Content: "12:00"},
+	})
+	want := "<|begin_of_text|>" +
+		"<|start_header_id|>system<|end_header_id|>\n\nBe brief.<|eot_id|>" +
+		"<|start_header_id|>user<|end_header_id|>\n\nhi<|eot_id|>" +
+		"<|start_header_id|>assistant<|end_header_id|>\n\nhello<|eot_id|>" +
+		"<|start_header_id|>ipython<|end_header_id|>\n\n12:00<|eot_id|>" +
+		"<|start_header_id|>assistant<|end_header_id|>\n\n"
+	if got != want {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestVertexChatRequestSeparatesSystemAndToolTurns(t *testing.T) {
+	config, contents := vertexChatRequest([]Message{
+		{Role: RoleSystem, Content: "Be brief."},
+		{Role: RoleUser, Content: "weather?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{Name: "weather", Arguments: map[string]any{"city": "Oslo"}}}},
+		{Role: RoleTool, Name: "weather", Content: "rain"},
+	})
+	if config == nil || config.SystemInstruction == nil || config.SystemInstruction.Parts[0].Text != "Be brief." {
+		t.Fatalf("system instruction = %+v", config)
+	}
+	if len(contents) != 3 {
+		t.Fatalf("got %d contents, want user, model and function response", len(contents))
+	}
+	if contents[1].Role != genai.RoleModel || contents[1].Parts[0].FunctionCall == nil {
+		t.Fatalf("tool call content = %+v", contents[1])
+	}
+	response := contents[2].Parts[0].FunctionResponse
+	if response == nil || response.Name != "weather" || response.Response["result"] != "rain" {
+		t.Fatalf("tool response = %+v", response)
+	}
+}
+
+func TestCachedLLMChatCachesByMessages(t *testing.T) {
+	mock := &MockAgent{}
+	cached := NewCachedLLM(mock, 10, time.Minute, "")
+	ctx := context.Background()
+	messages := []Message{{Role: RoleSystem, Content: "Be brief."}, {Role: RoleUser, Content: "hello"}}
+
+	for i := 0; i < 2; i++ {
+		reply, err := cached.Chat(ctx, messages)
+		if err != nil || reply.Content != "mock response" {
+			t.Fatalf("chat %d = %+v, %v", i, reply, err)
+		}
+	}
+	if count := atomic.LoadInt32(&mock.CallCount); count != 1 {
+		t.Fatalf("expected 1 call (cached), got %d", count)
+	}
+
+	other := []Message{{Role: RoleSystem, Content: "Be verbose."}, {Role: RoleUser, Content: "hello"}}
+	if _, err := cached.Chat(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if count := atomic.LoadInt32(&mock.CallCount); count != 2 {
+		t.Fatalf("a different system message should miss the cache, got %d calls", count)
+	}
+}
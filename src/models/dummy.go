@@ -41,8 +41,20 @@ func (d *DummyLLM) GenerateWithFiles(ctx context.Context, prompt string, files [
 	return fmt.Sprintf("%s %s", d.Prefix, combined), nil
 }
 
+// Chat answers the flattened conversation the way Generate answers a prompt.
+func (d *DummyLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	prompt, files := FlattenMessages(messages)
+	var out any
+	if len(files) > 0 {
+		out, _ = d.GenerateWithFiles(ctx, prompt, files)
+	} else {
+		out, _ = d.Generate(ctx, prompt)
+	}
+	return Message{Role: RoleAssistant, Content: fmt.Sprint(out)}, nil
+}
+
 // GenerateStream simulates streaming by splitting the response into word-level chunks.
-func (d *DummyLLM) GenerateStream(_ context.Context, prompt string) (<-chan StreamChunk, error) {
+func (d *DummyLLM) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
 	result, _ := d.Generate(context.Background(), prompt)
 	text := fmt.Sprint(result)
 
@@ -61,7 +73,10 @@ func (d *DummyLLM) GenerateStream(_ context.Context, prompt string) (<-chan Stre
 		ch <- StreamChunk{Done: true, FullText: sb.String()}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
-var _ Agent = (*DummyLLM)(nil)
+var (
+	_ Agent     = (*DummyLLM)(nil)
+	_ ChatAgent = (*DummyLLM)(nil)
+)
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 	genai "github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
@@ -18,13 +18,14 @@ type GeminiLLM struct {
 }
 
 func NewGeminiLLM(ctx context.Context, model string, promptPrefix string) (Agent, error) {
-	key := os.Getenv("GOOGLE_API_KEY")
-	if key == "" {
-		key = os.Getenv("GEMINI_API_KEY")
-	}
+	key := secrets.Lookup("GOOGLE_API_KEY", "GEMINI_API_KEY")
 	if key == "" {
 		return nil, errors.New("gemini: missing GOOGLE_API_KEY/GEMINI_API_KEY")
 	}
+	return newGeminiLLMWithKey(ctx, key, model, promptPrefix)
+}
+
+func newGeminiLLMWithKey(ctx context.Context, key, model, promptPrefix string) (Agent, error) {
 	cl, err := genai.NewClient(ctx, option.WithAPIKey(key))
 	if err != nil {
 		return nil, err
@@ -51,6 +52,7 @@ func (g *GeminiLLM) Generate(ctx context.Context, prompt string) (any, error) {
 // GenerateStream uses Gemini's streaming API to yield tokens incrementally.
 func (g *GeminiLLM) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
 	model := g.Client.GenerativeModel(g.Model)
+	model.StopSequences = StopSequences(ctx)
 	full := prompt
 	if g.PromptPrefix != "" {
 		full = g.PromptPrefix + "\n\n" + prompt
@@ -87,7 +89,7 @@ func (g *GeminiLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 		}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
 // NEW: pass images/videos as parts so Gemini can read them.
@@ -97,6 +99,25 @@ func (g *GeminiLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 func (g *GeminiLLM) GenerateWithFiles(ctx context.Context, prompt string, files []File) (any, error) {
 	model := g.Client.GenerativeModel(g.Model)
 
+	var parts []genai.Part
+	if p := strings.TrimSpace(g.PromptPrefix); p != "" {
+		parts = append(parts, genai.Text(p))
+	}
+	parts = append(parts, geminiUserParts(prompt, files)...)
+
+	resp, err := model.GenerateContent(ctx, parts...)
+	if err != nil {
+		return nil, fmt.Errorf("gemini generateWithFiles: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return nil, errors.New("gemini: empty response")
+	}
+	return resp.Candidates[0].Content.Parts[0], nil
+}
+
+// geminiUserParts returns the text, with every file described inline, and
+// the images and videos Gemini accepts as parts of their own.
+func geminiUserParts(text string, files []File) []genai.Part {
 	// Build normalized copies (never pass raw f.MIME to Gemini)
 	norm := make([]File, 0, len(files))
 	for _, f := range files {
@@ -109,13 +130,7 @@ func (g *GeminiLLM) GenerateWithFiles(ctx context.Context, prompt string, files
 	}
 
 	// Text context always present
-	text := combinePromptWithFiles(prompt, norm)
-
-	var parts []genai.Part
-	if p := strings.TrimSpace(g.PromptPrefix); p != "" {
-		parts = append(parts, genai.Text(p))
-	}
-	parts = append(parts, genai.Text(text))
+	parts := []genai.Part{genai.Text(combinePromptWithFiles(text, norm))}
 
 	// Attach only if MIME is sanitized for Gemini
 	for _, f := range norm {
@@ -137,13 +152,66 @@ func (g *GeminiLLM) GenerateWithFiles(ctx context.Context, prompt string, files
 			parts = append(parts, genai.Blob{MIMEType: sanitized, Data: f.Data})
 		}
 	}
+	return parts
+}
 
-	resp, err := model.GenerateContent(ctx, parts...)
+// Chat sends the system messages as the model's system instruction and the
+// earlier messages as chat history. Tool calls and tool messages become
+// function call and function response parts.
+func (g *GeminiLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	model := g.Client.GenerativeModel(g.Model)
+	system, rest := splitSystem(withPromptPrefix(g.PromptPrefix, messages))
+	if system != "" {
+		model.SystemInstruction = genai.NewUserContent(genai.Text(system))
+	}
+
+	var history []*genai.Content
+	for _, m := range rest {
+		content := &genai.Content{Role: "user"}
+		switch m.Role {
+		case RoleAssistant:
+			content.Role = "model"
+			if m.Content != "" {
+				content.Parts = append(content.Parts, genai.Text(m.Content))
+			}
+			for _, call := range m.ToolCalls {
+				content.Parts = append(content.Parts, genai.FunctionCall{Name: call.Name, Args: call.Arguments})
+			}
+		case RoleTool:
+			content.Parts = []genai.Part{genai.FunctionResponse{Name: m.Name, Response: toolResultPayload(m.Content)}}
+		default:
+			content.Parts = geminiUserParts(m.Content, m.Files)
+		}
+		if n := len(history); n > 0 && history[n-1].Role == content.Role {
+			history[n-1].Parts = append(history[n-1].Parts, content.Parts...)
+			continue
+		}
+		history = append(history, content)
+	}
+	if len(history) == 0 || history[len(history)-1].Role != "user" {
+		return Message{}, errors.New("gemini chat: the last message must be from the user or a tool")
+	}
+
+	chat := model.StartChat()
+	chat.History = history[:len(history)-1]
+	resp, err := chat.SendMessage(ctx, history[len(history)-1].Parts...)
 	if err != nil {
-		return nil, fmt.Errorf("gemini generateWithFiles: %w", err)
+		return Message{}, fmt.Errorf("gemini chat: %w", err)
 	}
-	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, errors.New("gemini: empty response")
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return Message{}, errors.New("gemini: empty response")
 	}
-	return resp.Candidates[0].Content.Parts[0], nil
+
+	reply := Message{Role: RoleAssistant}
+	var b strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		switch part := part.(type) {
+		case genai.Text:
+			b.WriteString(string(part))
+		case genai.FunctionCall:
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: part.Name, Name: part.Name, Arguments: part.Args})
+		}
+	}
+	reply.Content = b.String()
+	return reply, nil
 }
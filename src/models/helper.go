@@ -64,6 +64,18 @@ func newLLMProvider(
 	var agent Agent
 	var err error
 
+	// Multiple keys (<PREFIX>_API_KEYS or <PREFIX>_API_KEYS_FILE) opt the
+	// provider into rotation on auth failures and on a schedule.
+	if source := providerKeySource(provider); source != nil {
+		agent, err = NewKeyRotatingLLM(source, providerKeyedFactory(provider, model, promptPrefix), KeyRotationOptions{
+			RotateEvery: keyRotationInterval(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s key rotation: %w", provider, err)
+		}
+		return TryCreateCachedLLM(agent), nil
+	}
+
 	switch provider {
 	case "openai":
 		agent = NewOpenAILLM(model, promptPrefix)
@@ -25,6 +25,11 @@ type StreamChunk struct {
 	Done     bool   // true on the final chunk
 	FullText string // aggregated text (populated only on the final chunk)
 	Err      error  // non-nil if the stream encountered a fatal error
+	// Stop is the stop sequence that ended the output, on the final chunk.
+	Stop string
+	// Interrupted is set on the final chunk when the stream was canceled
+	// before the model finished; FullText holds the output so far.
+	Interrupted bool
 }
 
 type Agent interface {
@@ -0,0 +1,416 @@
+package models
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrNoAPIKeys is returned when a key-rotating model has no usable keys.
+var ErrNoAPIKeys = errors.New("no api keys configured")
+
+// KeySource returns the current ordered list of API keys for a provider. It is
+// consulted again on every rotation so keys can be added or revoked without a
+// restart.
+type KeySource func() ([]string, error)
+
+// StaticKeys returns a KeySource that always yields keys.
+func StaticKeys(keys ...string) KeySource {
+	cleaned := cleanKeys(keys)
+	return func() ([]string, error) { return cleaned, nil }
+}
+
+// EnvKeys reads a comma- or newline-separated list of keys from the variable name.
+func EnvKeys(name string) KeySource {
+	return func() ([]string, error) {
+		return splitKeys(os.Getenv(name)), nil
+	}
+}
+
+// SecretKeys reads a comma- or newline-separated list of keys through the
+// default secrets provider, so Vault or file-backed keys rotate the same way.
+func SecretKeys(name string) KeySource {
+	return func() ([]string, error) {
+		return splitKeys(secrets.Lookup(name)), nil
+	}
+}
+
+// FileKeys reads one key per line from path. Blank lines and lines starting with
+// '#' are ignored.
+func FileKeys(path string) KeySource {
+	return func() ([]string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("read api keys file: %w", err)
+		}
+		defer f.Close()
+
+		var keys []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			keys = append(keys, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read api keys file: %w", err)
+		}
+		return keys, nil
+	}
+}
+
+// KeyedFactory builds a provider client bound to a single API key.
+type KeyedFactory func(ctx context.Context, key string) (Agent, error)
+
+// KeyRotationOptions configures a KeyRotatingLLM.
+type KeyRotationOptions struct {
+	// RotateEvery advances to the next key on a fixed schedule. Zero disables
+	// scheduled rotation; auth failures still rotate.
+	RotateEvery time.Duration
+	// IsAuthError decides whether an error should trigger rotation. Defaults to
+	// IsAuthError.
+	IsAuthError func(error) bool
+	// Clock overrides time.Now, primarily for tests.
+	Clock func() time.Time
+}
+
+// KeyRotatingLLM spreads calls over several API keys for the same provider.
+// When a call fails with an authentication error the key is skipped and the
+// call is retried with the next key, so revoked keys cause no downtime.
+type KeyRotatingLLM struct {
+	source  KeySource
+	factory KeyedFactory
+	opts    KeyRotationOptions
+
+	mu        sync.Mutex
+	keys      []string
+	current   int
+	rotatedAt time.Time
+	clients   map[string]Agent
+}
+
+// NewKeyRotatingLLM loads the initial keys from source and returns a rotating
+// wrapper that creates per-key clients on demand via factory.
+func NewKeyRotatingLLM(source KeySource, factory KeyedFactory, opts KeyRotationOptions) (*KeyRotatingLLM, error) {
+	if source == nil {
+		return nil, errors.New("key rotation requires a key source")
+	}
+	if factory == nil {
+		return nil, errors.New("key rotation requires a client factory")
+	}
+	if opts.IsAuthError == nil {
+		opts.IsAuthError = IsAuthError
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	keys, err := source()
+	if err != nil {
+		return nil, err
+	}
+	keys = cleanKeys(keys)
+	if len(keys) == 0 {
+		return nil, ErrNoAPIKeys
+	}
+	return &KeyRotatingLLM{
+		source:    source,
+		factory:   factory,
+		opts:      opts,
+		keys:      keys,
+		rotatedAt: opts.Clock(),
+		clients:   make(map[string]Agent),
+	}, nil
+}
+
+// Keys returns the number of keys currently loaded.
+func (k *KeyRotatingLLM) Keys() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.keys)
+}
+
+// Rotate advances to the next key immediately and reloads the key source.
+func (k *KeyRotatingLLM) Rotate() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.rotateLocked(k.currentKeyLocked())
+}
+
+func (k *KeyRotatingLLM) Generate(ctx context.Context, prompt string) (any, error) {
+	var out any
+	err := k.do(ctx, func(agent Agent) error {
+		var err error
+		out, err = agent.Generate(ctx, prompt)
+		return err
+	})
+	return out, err
+}
+
+func (k *KeyRotatingLLM) GenerateWithFiles(ctx context.Context, prompt string, files []File) (any, error) {
+	var out any
+	err := k.do(ctx, func(agent Agent) error {
+		var err error
+		out, err = agent.GenerateWithFiles(ctx, prompt, files)
+		return err
+	})
+	return out, err
+}
+
+func (k *KeyRotatingLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	var out Message
+	err := k.do(ctx, func(agent Agent) error {
+		var err error
+		out, err = Chat(ctx, agent, messages)
+		return err
+	})
+	return out, err
+}
+
+// GenerateStream rotates only when opening the stream fails; errors reported
+// mid-stream are passed through to the caller unchanged.
+func (k *KeyRotatingLLM) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
+	var out <-chan StreamChunk
+	err := k.do(ctx, func(agent Agent) error {
+		var err error
+		out, err = agent.GenerateStream(ctx, prompt)
+		return err
+	})
+	return out, err
+}
+
+// GenerateWithTools forwards native tool calling when the per-key client
+// supports it.
+func (k *KeyRotatingLLM) GenerateWithTools(ctx context.Context, prompt string, tools []ToolDefinition) (ToolCallResponse, error) {
+	var out ToolCallResponse
+	err := k.do(ctx, func(agent Agent) error {
+		native, ok := agent.(ToolCallingAgent)
+		if !ok {
+			return fmt.Errorf("%w: wrapped model", ErrToolCallingUnsupported)
+		}
+		var err error
+		out, err = native.GenerateWithTools(ctx, prompt, tools)
+		return err
+	})
+	return out, err
+}
+
+// PromptAdapter implements PromptAdapterProvider. Every key reaches the
+// same provider, so the client of the current key decides.
+func (k *KeyRotatingLLM) PromptAdapter() PromptAdapter {
+	_, agent, err := k.client(context.Background())
+	if err != nil {
+		return nil
+	}
+	return PromptAdapterFor(agent)
+}
+
+// do runs call with the active key, moving on to the next key after each
+// authentication failure until every loaded key has been tried once.
+func (k *KeyRotatingLLM) do(ctx context.Context, call func(Agent) error) error {
+	k.mu.Lock()
+	if k.opts.RotateEvery > 0 && k.opts.Clock().Sub(k.rotatedAt) >= k.opts.RotateEvery {
+		k.rotateLocked(k.currentKeyLocked())
+	}
+	attempts := len(k.keys)
+	k.mu.Unlock()
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		key, agent, err := k.client(ctx)
+		if err != nil {
+			return err
+		}
+		err = call(agent)
+		if err == nil || !k.opts.IsAuthError(err) {
+			return err
+		}
+		lastErr = err
+
+		k.mu.Lock()
+		k.rotateLocked(key)
+		k.mu.Unlock()
+	}
+	if lastErr == nil {
+		lastErr = ErrNoAPIKeys
+	}
+	return fmt.Errorf("all api keys rejected: %w", lastErr)
+}
+
+func (k *KeyRotatingLLM) client(ctx context.Context) (string, Agent, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key := k.currentKeyLocked()
+	if key == "" {
+		return "", nil, ErrNoAPIKeys
+	}
+	if agent, ok := k.clients[key]; ok {
+		return key, agent, nil
+	}
+	agent, err := k.factory(ctx, key)
+	if err != nil {
+		return "", nil, fmt.Errorf("create client for rotated key: %w", err)
+	}
+	k.clients[key] = agent
+	return key, agent, nil
+}
+
+func (k *KeyRotatingLLM) currentKeyLocked() string {
+	if len(k.keys) == 0 {
+		return ""
+	}
+	return k.keys[k.current%len(k.keys)]
+}
+
+// rotateLocked moves past from, refreshing keys from the source. A failing or
+// empty source keeps the previous key list so a bad deploy of the key file
+// does not take the model offline.
+func (k *KeyRotatingLLM) rotateLocked(from string) {
+	k.rotatedAt = k.opts.Clock()
+	if from != k.currentKeyLocked() {
+		// Another caller already rotated away from this key.
+		return
+	}
+	if keys, err := k.source(); err == nil {
+		if keys = cleanKeys(keys); len(keys) > 0 {
+			k.keys = keys
+			live := make(map[string]bool, len(keys))
+			for _, key := range keys {
+				live[key] = true
+			}
+			for key := range k.clients {
+				if !live[key] {
+					delete(k.clients, key)
+				}
+			}
+		}
+	}
+	next := 0
+	for i, key := range k.keys {
+		if key == from {
+			next = i + 1
+			break
+		}
+	}
+	k.current = next % len(k.keys)
+}
+
+// IsAuthError reports whether err looks like a rejected or revoked credential.
+func IsAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == 401 || apiErr.HTTPStatusCode == 403
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode == 401 || reqErr.HTTPStatusCode == 403
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"status 401", "status 403", "status code: 401", "status code: 403",
+		"unauthorized", "unauthenticated", "forbidden",
+		"invalid api key", "invalid_api_key", "invalid x-api-key",
+		"api key not valid", "authentication_error", "permission_denied",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// providerKeySource returns the multi-key source configured for provider via
+// <PREFIX>_API_KEYS_FILE or <PREFIX>_API_KEYS, or nil if neither is set.
+func providerKeySource(provider string) KeySource {
+	var prefix string
+	switch provider {
+	case "openai":
+		prefix = "OPENAI"
+	case "gemini", "google":
+		prefix = "GEMINI"
+	case "anthropic", "claude":
+		prefix = "ANTHROPIC"
+	case "openrouter":
+		prefix = "OPENROUTER"
+	default:
+		return nil
+	}
+	if path := strings.TrimSpace(os.Getenv(prefix + "_API_KEYS_FILE")); path != "" {
+		return FileKeys(path)
+	}
+	if secrets.Lookup(prefix+"_API_KEYS") != "" {
+		return SecretKeys(prefix + "_API_KEYS")
+	}
+	return nil
+}
+
+// providerKeyedFactory binds a provider constructor to an explicit key.
+func providerKeyedFactory(provider, model, promptPrefix string) KeyedFactory {
+	switch provider {
+	case "openai":
+		return func(_ context.Context, key string) (Agent, error) {
+			return newOpenAILLMWithKey(key, model, promptPrefix), nil
+		}
+	case "gemini", "google":
+		return func(ctx context.Context, key string) (Agent, error) {
+			return newGeminiLLMWithKey(ctx, key, model, promptPrefix)
+		}
+	case "anthropic", "claude":
+		return func(_ context.Context, key string) (Agent, error) {
+			return newAnthropicLLMWithKey(key, model, promptPrefix), nil
+		}
+	case "openrouter":
+		return func(_ context.Context, key string) (Agent, error) {
+			return newOpenRouterLLMWithKey(key, model, promptPrefix), nil
+		}
+	}
+	return nil
+}
+
+// keyRotationInterval reads AGENT_KEY_ROTATION_INTERVAL as a Go duration.
+func keyRotationInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("AGENT_KEY_ROTATION_INTERVAL"))
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+func splitKeys(raw string) []string {
+	return cleanKeys(strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ';'
+	}))
+}
+
+func cleanKeys(keys []string) []string {
+	out := make([]string, 0, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, key)
+	}
+	return out
+}
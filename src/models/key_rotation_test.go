@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type keyedStubAgent struct {
+	key     string
+	revoked map[string]bool
+}
+
+func (s *keyedStubAgent) Generate(context.Context, string) (any, error) {
+	if s.revoked[s.key] {
+		return nil, errors.New("status 401: invalid api key")
+	}
+	return "ok:" + s.key, nil
+}
+
+func (s *keyedStubAgent) GenerateWithFiles(ctx context.Context, prompt string, _ []File) (any, error) {
+	return s.Generate(ctx, prompt)
+}
+
+func (s *keyedStubAgent) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
+	out, err := s.Generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan StreamChunk, 1)
+	ch <- StreamChunk{Delta: out.(string), FullText: out.(string), Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func stubKeyedFactory(revoked map[string]bool, created *int) KeyedFactory {
+	return func(_ context.Context, key string) (Agent, error) {
+		*created++
+		return &keyedStubAgent{key: key, revoked: revoked}, nil
+	}
+}
+
+func TestKeyRotatingLLMRotatesOnAuthError(t *testing.T) {
+	revoked := map[string]bool{"a": true}
+	var created int
+	llm, err := NewKeyRotatingLLM(StaticKeys("a", "b"), stubKeyedFactory(revoked, &created), KeyRotationOptions{})
+	if err != nil {
+		t.Fatalf("NewKeyRotatingLLM: %v", err)
+	}
+
+	out, err := llm.Generate(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if out != "ok:b" {
+		t.Fatalf("expected fallback to key b, got %v", out)
+	}
+
+	// The healthy key stays active and its client is reused.
+	if _, err := llm.Generate(context.Background(), "again"); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if created != 2 {
+		t.Fatalf("expected two clients to be created, got %d", created)
+	}
+}
+
+func TestKeyRotatingLLMAllKeysRejected(t *testing.T) {
+	revoked := map[string]bool{"a": true, "b": true}
+	var created int
+	llm, err := NewKeyRotatingLLM(StaticKeys("a", "b"), stubKeyedFactory(revoked, &created), KeyRotationOptions{})
+	if err != nil {
+		t.Fatalf("NewKeyRotatingLLM: %v", err)
+	}
+	if _, err := llm.Generate(context.Background(), "hi"); err == nil {
+		t.Fatal("expected error when every key is rejected")
+	}
+}
+
+func TestKeyRotatingLLMScheduledRotationReloadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte("# primary\nold\n"), 0o600); err != nil {
+		t.Fatalf("write keys: %v", err)
+	}
+
+	now := time.Unix(0, 0)
+	var created int
+	llm, err := NewKeyRotatingLLM(FileKeys(path), stubKeyedFactory(nil, &created), KeyRotationOptions{
+		RotateEvery: time.Hour,
+		Clock:       func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("NewKeyRotatingLLM: %v", err)
+	}
+	if out, _ := llm.Generate(context.Background(), "hi"); out != "ok:old" {
+		t.Fatalf("expected old key, got %v", out)
+	}
+
+	if err := os.WriteFile(path, []byte("new\n"), 0o600); err != nil {
+		t.Fatalf("rewrite keys: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+
+	if out, _ := llm.Generate(context.Background(), "hi"); out != "ok:new" {
+		t.Fatalf("expected rotated key, got %v", out)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	if !IsAuthError(errors.New("POST https://api: 401 Unauthorized")) {
+		t.Fatal("expected 401 to be an auth error")
+	}
+	if IsAuthError(errors.New("context deadline exceeded")) {
+		t.Fatal("timeouts must not trigger rotation")
+	}
+}
+
+func TestProviderKeySourceFromEnv(t *testing.T) {
+	t.Setenv("OPENAI_API_KEYS", "k1, k2,k1")
+	source := providerKeySource("openai")
+	if source == nil {
+		t.Fatal("expected key source")
+	}
+	keys, err := source()
+	if err != nil {
+		t.Fatalf("source: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "k1" || keys[1] != "k2" {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if providerKeySource("ollama") != nil {
+		t.Fatal("ollama has no key rotation")
+	}
+}
@@ -69,6 +69,8 @@ type rateLimitAgent struct {
 
 func (a *rateLimitAgent) wrappedModel() models.Agent { return a.next }
 
+func (a *rateLimitAgent) PromptAdapter() models.PromptAdapter { return models.PromptAdapterFor(a.next) }
+
 func (a *rateLimitAgent) Generate(ctx context.Context, prompt string) (any, error) {
 	if err := a.acquire(ctx); err != nil {
 		return nil, err
@@ -101,6 +103,13 @@ func (a *rateLimitAgent) GenerateWithTools(ctx context.Context, prompt string, t
 	return native.GenerateWithTools(ctx, prompt, tools)
 }
 
+func (a *rateLimitAgent) Chat(ctx context.Context, messages []models.Message) (models.Message, error) {
+	if err := a.acquire(ctx); err != nil {
+		return models.Message{}, err
+	}
+	return models.Chat(ctx, a.next, messages)
+}
+
 func (a *rateLimitAgent) acquire(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
@@ -110,6 +110,8 @@ type retryAgent struct {
 
 func (a *retryAgent) wrappedModel() models.Agent { return a.next }
 
+func (a *retryAgent) PromptAdapter() models.PromptAdapter { return models.PromptAdapterFor(a.next) }
+
 func (a *retryAgent) Generate(ctx context.Context, prompt string) (any, error) {
 	return retryCall(ctx, a.config, func(callCtx context.Context) (any, error) {
 		return a.next.Generate(callCtx, prompt)
@@ -128,6 +130,12 @@ func (a *retryAgent) GenerateStream(ctx context.Context, prompt string) (<-chan
 	})
 }
 
+func (a *retryAgent) Chat(ctx context.Context, messages []models.Message) (models.Message, error) {
+	return retryCall(ctx, a.config, func(callCtx context.Context) (models.Message, error) {
+		return models.Chat(callCtx, a.next, messages)
+	})
+}
+
 func (a *retryAgent) GenerateWithTools(ctx context.Context, prompt string, tools []models.ToolDefinition) (models.ToolCallResponse, error) {
 	native, err := nativeModel(a.next)
 	if err != nil {
@@ -35,6 +35,8 @@ type timeoutAgent struct {
 
 func (a *timeoutAgent) wrappedModel() models.Agent { return a.next }
 
+func (a *timeoutAgent) PromptAdapter() models.PromptAdapter { return models.PromptAdapterFor(a.next) }
+
 func (a *timeoutAgent) Generate(ctx context.Context, prompt string) (any, error) {
 	return timeoutCall(ctx, a.duration, func(callCtx context.Context) (any, error) {
 		return a.next.Generate(callCtx, prompt)
@@ -47,6 +49,12 @@ func (a *timeoutAgent) GenerateWithFiles(ctx context.Context, prompt string, fil
 	})
 }
 
+func (a *timeoutAgent) Chat(ctx context.Context, messages []models.Message) (models.Message, error) {
+	return timeoutCall(ctx, a.duration, func(callCtx context.Context) (models.Message, error) {
+		return models.Chat(callCtx, a.next, messages)
+	})
+}
+
 func (a *timeoutAgent) GenerateWithTools(ctx context.Context, prompt string, tools []models.ToolDefinition) (models.ToolCallResponse, error) {
 	native, err := nativeModel(a.next)
 	if err != nil {
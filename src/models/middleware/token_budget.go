@@ -158,6 +158,10 @@ type tokenBudgetAgent struct {
 
 func (a *tokenBudgetAgent) wrappedModel() models.Agent { return a.next }
 
+func (a *tokenBudgetAgent) PromptAdapter() models.PromptAdapter {
+	return models.PromptAdapterFor(a.next)
+}
+
 func (a *tokenBudgetAgent) Generate(ctx context.Context, prompt string) (any, error) {
 	budget := a.budget(ctx)
 	if budget == nil {
@@ -195,6 +199,29 @@ func (a *tokenBudgetAgent) GenerateWithFiles(ctx context.Context, prompt string,
 	return result, nil
 }
 
+func (a *tokenBudgetAgent) Chat(ctx context.Context, messages []models.Message) (models.Message, error) {
+	budget := a.budget(ctx)
+	if budget == nil {
+		return models.Chat(ctx, a.next, messages)
+	}
+	prompt, files := models.FlattenMessages(messages)
+	if err := budget.chargeInput(budget.estimate(prompt) + estimateFileTokens(budget, files)); err != nil {
+		return models.Message{}, err
+	}
+	reply, err := models.Chat(ctx, a.next, messages)
+	if err != nil {
+		return models.Message{}, err
+	}
+	encoded, err := json.Marshal(reply)
+	if err != nil {
+		encoded = []byte(reply.Content)
+	}
+	if err := budget.chargeOutput(budget.estimate(string(encoded))); err != nil {
+		return models.Message{}, err
+	}
+	return reply, nil
+}
+
 func (a *tokenBudgetAgent) GenerateWithTools(ctx context.Context, prompt string, tools []models.ToolDefinition) (models.ToolCallResponse, error) {
 	native, err := nativeModel(a.next)
 	if err != nil {
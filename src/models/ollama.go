@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 	ollama "github.com/ollama/ollama/api" // <- correct import
 )
 
@@ -21,8 +22,11 @@ type OllamaLLM struct {
 	Client       *ollama.Client
 	Model        string
 	PromptPrefix string
-	httpClient   *http.Client
-	host         string
+	// Raw sends prompts without the model's chat template. Agents then lay
+	// them out with LlamaPromptAdapter, so set it only for Llama 3 models.
+	Raw        bool
+	httpClient *http.Client
+	host       string
 }
 
 func NewOllamaLLM(model string, promptPrefix string) (*OllamaLLM, error) {
@@ -64,6 +68,7 @@ func (o *OllamaLLM) Generate(ctx context.Context, prompt string) (any, error) {
 	req := &ollama.GenerateRequest{
 		Model:  o.Model,
 		Prompt: fullPrompt,
+		Raw:    o.Raw,
 	}
 
 	if err := o.Client.Generate(ctx, req, func(gr ollama.GenerateResponse) error {
@@ -93,26 +98,7 @@ func (o *OllamaLLM) GenerateWithFiles(ctx context.Context, prompt string, files
 		fullPrompt = fmt.Sprintf("%s\n\n%s", o.PromptPrefix, prompt)
 	}
 
-	// Separate text files from images/videos
-	var textFiles []File
-	var imageData []ollama.ImageData
-
-	for _, f := range files {
-		mt := normalizeMIME(f.Name, f.MIME)
-
-		if isImageOrVideoMIME(mt) {
-			// Encode image/video as base64 for Ollama API
-			encoded := base64.StdEncoding.EncodeToString(f.Data)
-			imageData = append(imageData, ollama.ImageData(encoded))
-		} else if isTextMIME(mt) {
-			textFiles = append(textFiles, f)
-		}
-	}
-
-	// Combine text files into the prompt
-	if len(textFiles) > 0 {
-		fullPrompt = combinePromptWithFiles(fullPrompt, textFiles)
-	}
+	fullPrompt, imageData := ollamaUserInput(fullPrompt, files)
 
 	var (
 		text strings.Builder
@@ -122,6 +108,7 @@ func (o *OllamaLLM) GenerateWithFiles(ctx context.Context, prompt string, files
 	req := &ollama.GenerateRequest{
 		Model:  o.Model,
 		Prompt: fullPrompt,
+		Raw:    o.Raw,
 		Images: imageData, // Send images/videos to Ollama
 	}
 
@@ -146,6 +133,106 @@ func (o *OllamaLLM) GenerateWithFiles(ctx context.Context, prompt string, files
 	}, nil
 }
 
+// ollamaUserInput inlines the text files into text and returns the images
+// and videos separately, as Ollama takes them.
+func ollamaUserInput(text string, files []File) (string, []ollama.ImageData) {
+	// Separate text files from images/videos
+	var textFiles []File
+	var imageData []ollama.ImageData
+
+	for _, f := range files {
+		mt := normalizeMIME(f.Name, f.MIME)
+
+		if isImageOrVideoMIME(mt) {
+			// Encode image/video as base64 for Ollama API
+			encoded := base64.StdEncoding.EncodeToString(f.Data)
+			imageData = append(imageData, ollama.ImageData(encoded))
+		} else if isTextMIME(mt) {
+			textFiles = append(textFiles, f)
+		}
+	}
+
+	// Combine text files into the prompt
+	if len(textFiles) > 0 {
+		text = combinePromptWithFiles(text, textFiles)
+	}
+	return text, imageData
+}
+
+// Chat uses Ollama's /api/chat endpoint, which applies the model's own chat
+// template. With Raw set, the messages are rendered with
+// LlamaPromptAdapter.FormatMessages and sent as one raw prompt instead.
+func (o *OllamaLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	messages = withPromptPrefix(o.PromptPrefix, messages)
+	if o.Raw {
+		return o.chatRaw(ctx, messages)
+	}
+
+	req := &ollama.ChatRequest{Model: o.Model}
+	for _, m := range messages {
+		message := ollama.Message{Role: m.Role, Content: m.Content}
+		switch m.Role {
+		case RoleAssistant:
+			for _, call := range m.ToolCalls {
+				message.ToolCalls = append(message.ToolCalls, ollama.ToolCall{
+					Function: ollama.ToolCallFunction{Name: call.Name, Arguments: call.Arguments},
+				})
+			}
+		case RoleTool:
+			message.ToolName = m.Name
+		case RoleUser:
+			message.Content, message.Images = ollamaUserInput(m.Content, m.Files)
+		}
+		req.Messages = append(req.Messages, message)
+	}
+
+	reply := Message{Role: RoleAssistant}
+	var text strings.Builder
+	if err := o.Client.Chat(ctx, req, func(cr ollama.ChatResponse) error {
+		text.WriteString(cr.Message.Content)
+		for _, call := range cr.Message.ToolCalls {
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{
+				ID:        call.Function.Name,
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			})
+		}
+		return nil
+	}); err != nil {
+		return Message{}, err
+	}
+	reply.Content = text.String()
+	return reply, nil
+}
+
+func (o *OllamaLLM) chatRaw(ctx context.Context, messages []Message) (Message, error) {
+	messages = append([]Message(nil), messages...)
+	var images []ollama.ImageData
+	for i, m := range messages {
+		if m.Role != RoleUser || len(m.Files) == 0 {
+			continue
+		}
+		var data []ollama.ImageData
+		messages[i].Content, data = ollamaUserInput(m.Content, m.Files)
+		images = append(images, data...)
+	}
+
+	req := &ollama.GenerateRequest{
+		Model:  o.Model,
+		Prompt: LlamaPromptAdapter{}.FormatMessages(messages),
+		Raw:    true,
+		Images: images,
+	}
+	var text strings.Builder
+	if err := o.Client.Generate(ctx, req, func(gr ollama.GenerateResponse) error {
+		text.WriteString(gr.Response)
+		return nil
+	}); err != nil {
+		return Message{}, err
+	}
+	return Message{Role: RoleAssistant, Content: text.String()}, nil
+}
+
 // GenerateStream leverages Ollama's native callback-based streaming.
 func (o *OllamaLLM) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
 	fullPrompt := prompt
@@ -156,6 +243,10 @@ func (o *OllamaLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 	req := &ollama.GenerateRequest{
 		Model:  o.Model,
 		Prompt: fullPrompt,
+		Raw:    o.Raw,
+	}
+	if stops := StopSequences(ctx); len(stops) > 0 {
+		req.Options = map[string]any{"stop": stops}
 	}
 
 	ch := make(chan StreamChunk, 16)
@@ -176,7 +267,7 @@ func (o *OllamaLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 		ch <- StreamChunk{Done: true, FullText: sb.String()}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
 // WebSearch queries the Ollama Web Search API and returns top results.
@@ -197,7 +288,7 @@ func (o *OllamaLLM) WebSearch(ctx context.Context, query string, limit int) ([]m
 		return nil, fmt.Errorf("new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if key := os.Getenv("OLLAMA_API_KEY"); key != "" {
+	if key := secrets.Lookup("OLLAMA_API_KEY"); key != "" {
 		req.Header.Set("Authorization", "Bearer "+key)
 	}
 
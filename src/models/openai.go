@@ -7,9 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -20,10 +20,11 @@ type OpenAILLM struct {
 }
 
 func NewOpenAILLM(model string, promptPrefix string) *OpenAILLM {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENAI_KEY") // fallback
-	}
+	apiKey := secrets.Lookup("OPENAI_API_KEY", "OPENAI_KEY")
+	return newOpenAILLMWithKey(apiKey, model, promptPrefix)
+}
+
+func newOpenAILLMWithKey(apiKey, model, promptPrefix string) *OpenAILLM {
 	client := openai.NewClient(apiKey)
 	return &OpenAILLM{Client: client, Model: model, PromptPrefix: promptPrefix}
 }
@@ -125,6 +126,7 @@ func (o *OpenAILLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 			Content: fullPrompt,
 		}},
 		Stream: true,
+		Stop:   StopSequences(ctx),
 	})
 	if err != nil {
 		return nil, err
@@ -155,7 +157,7 @@ func (o *OpenAILLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 		}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
 // getOpenAIMimeType converts normalized MIME types to OpenAI's expected format
@@ -191,6 +193,28 @@ func (o *OpenAILLM) GenerateWithFiles(ctx context.Context, prompt string, files
 		fullPrompt = o.PromptPrefix + "\n" + prompt
 	}
 
+	message := openAIUserMessage(fullPrompt, files)
+	// If no media files, fall back to text-only approach
+	if message.MultiContent == nil {
+		return o.Generate(ctx, message.Content)
+	}
+
+	resp, err := o.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    o.Model,
+		Messages: []openai.ChatCompletionMessage{message},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// openAIUserMessage builds a user message from text and files: text files
+// are inlined, and images and videos become content parts.
+func openAIUserMessage(text string, files []File) openai.ChatCompletionMessage {
 	// Separate files by type
 	var textFiles []File
 	var mediaFiles []File
@@ -205,25 +229,20 @@ func (o *OpenAILLM) GenerateWithFiles(ctx context.Context, prompt string, files
 		}
 	}
 
-	// If no media files, fall back to text-only approach
-	if len(mediaFiles) == 0 {
-		combined := combinePromptWithFiles(fullPrompt, textFiles)
-		return o.Generate(ctx, combined)
-	}
-
-	// Build MultiContent message with text and media
-	var contentParts []openai.ChatMessagePart
-
 	// Add the text prompt (including inline text files)
-	textPrompt := fullPrompt
+	textPrompt := text
 	if len(textFiles) > 0 {
-		textPrompt = combinePromptWithFiles(fullPrompt, textFiles)
+		textPrompt = combinePromptWithFiles(text, textFiles)
+	}
+	if len(mediaFiles) == 0 {
+		return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: textPrompt}
 	}
 
-	contentParts = append(contentParts, openai.ChatMessagePart{
+	// Build MultiContent message with text and media
+	contentParts := []openai.ChatMessagePart{{
 		Type: openai.ChatMessagePartTypeText,
 		Text: textPrompt,
-	})
+	}}
 
 	// Add media files
 	for _, f := range mediaFiles {
@@ -255,19 +274,64 @@ func (o *OpenAILLM) GenerateWithFiles(ctx context.Context, prompt string, files
 			})
 		}
 	}
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, MultiContent: contentParts}
+}
 
-	resp, err := o.Client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: o.Model,
-		Messages: []openai.ChatCompletionMessage{{
-			Role:         openai.ChatMessageRoleUser,
-			MultiContent: contentParts,
-		}},
-	})
+// Chat maps messages onto the Chat Completions API, including the tool
+// calls of assistant messages and the tool messages that answer them.
+func (o *OpenAILLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	request := openai.ChatCompletionRequest{Model: o.Model}
+	for _, m := range withPromptPrefix(o.PromptPrefix, messages) {
+		switch m.Role {
+		case RoleSystem:
+			request.Messages = append(request.Messages, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: m.Content,
+			})
+		case RoleAssistant:
+			message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: m.Content}
+			for _, call := range m.ToolCalls {
+				arguments, err := json.Marshal(call.Arguments)
+				if err != nil {
+					return Message{}, fmt.Errorf("openai tool %s arguments: %w", call.Name, err)
+				}
+				message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+					ID:       call.ID,
+					Type:     openai.ToolTypeFunction,
+					Function: openai.FunctionCall{Name: call.Name, Arguments: string(arguments)},
+				})
+			}
+			request.Messages = append(request.Messages, message)
+		case RoleTool:
+			request.Messages = append(request.Messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    m.Content,
+				Name:       m.Name,
+				ToolCallID: m.ToolCallID,
+			})
+		default:
+			request.Messages = append(request.Messages, openAIUserMessage(m.Content, m.Files))
+		}
+	}
+
+	resp, err := o.Client.CreateChatCompletion(ctx, request)
 	if err != nil {
-		return nil, err
+		return Message{}, err
 	}
 	if len(resp.Choices) == 0 {
-		return nil, errors.New("no response from OpenAI")
+		return Message{}, errors.New("no response from OpenAI")
 	}
-	return resp.Choices[0].Message.Content, nil
+
+	choice := resp.Choices[0].Message
+	reply := Message{Role: RoleAssistant, Content: choice.Content}
+	for _, call := range choice.ToolCalls {
+		arguments := map[string]any{}
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &arguments); err != nil {
+				return Message{}, fmt.Errorf("openai tool %s arguments: %w", call.Function.Name, err)
+			}
+		}
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: arguments})
+	}
+	return reply, nil
 }
@@ -3,13 +3,15 @@ package models
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	openrouter "github.com/OpenRouterTeam/go-sdk"
 	"github.com/OpenRouterTeam/go-sdk/models/components"
+	"github.com/OpenRouterTeam/go-sdk/optionalnullable"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
 )
 
 type OpenRouterLLM struct {
@@ -19,11 +21,11 @@ type OpenRouterLLM struct {
 }
 
 func NewOpenRouterLLM(model string, promptPrefix string) *OpenRouterLLM {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENROUTER_KEY") // fallback
-	}
+	apiKey := secrets.Lookup("OPENROUTER_API_KEY", "OPENROUTER_KEY")
+	return newOpenRouterLLMWithKey(apiKey, model, promptPrefix)
+}
 
+func newOpenRouterLLMWithKey(apiKey, model, promptPrefix string) *OpenRouterLLM {
 	client := openrouter.New(
 		openrouter.WithSecurity(apiKey),
 	)
@@ -97,7 +99,7 @@ func (o *OpenRouterLLM) Generate(ctx context.Context, prompt string) (any, error
 func (o *OpenRouterLLM) GenerateStream(ctx context.Context, prompt string) (<-chan StreamChunk, error) {
 	fullPrompt := o.buildPrompt(prompt)
 
-	res, err := o.Client.Chat.Send(ctx, components.ChatRequest{
+	req := components.ChatRequest{
 		Model:  openrouter.String(o.Model),
 		Stream: openrouter.Bool(true),
 		Messages: []components.ChatMessages{
@@ -105,7 +107,12 @@ func (o *OpenRouterLLM) GenerateStream(ctx context.Context, prompt string) (<-ch
 				Content: components.CreateChatUserMessageContentStr(fullPrompt),
 			}),
 		},
-	}, nil)
+	}
+	if stops := StopSequences(ctx); len(stops) > 0 {
+		stop := components.CreateStopArrayOfStr(stops)
+		req.Stop = optionalnullable.From(&stop)
+	}
+	res, err := o.Client.Chat.Send(ctx, req, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +159,7 @@ func (o *OpenRouterLLM) GenerateStream(ctx context.Context, prompt string) (<-ch
 		ch <- StreamChunk{Done: true, FullText: sb.String()}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
 // getOpenRouterMimeType converts normalized MIME types to OpenRouter's expected format.
@@ -184,6 +191,33 @@ func getOpenRouterMimeType(mt string) string {
 func (o *OpenRouterLLM) GenerateWithFiles(ctx context.Context, prompt string, files []File) (any, error) {
 	fullPrompt := o.buildPrompt(prompt)
 
+	content := openRouterUserContent(fullPrompt, files)
+	// If no media files, fall back to text-only approach
+	if content.Str != nil {
+		return o.Generate(ctx, *content.Str)
+	}
+
+	res, err := o.Client.Chat.Send(ctx, components.ChatRequest{
+		Model: openrouter.String(o.Model),
+		Messages: []components.ChatMessages{
+			components.CreateChatMessagesUser(components.ChatUserMessage{
+				Content: content,
+			}),
+		},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || res.ChatResult == nil {
+		return nil, errors.New("no response from OpenRouter")
+	}
+
+	return firstChoiceText(res.ChatResult)
+}
+
+// openRouterUserContent builds the content of a user message: the text with
+// inline text files, plus the first image or, failing that, the first PDF.
+func openRouterUserContent(text string, files []File) components.ChatUserMessageContent {
 	// Separate files by type
 	var textFiles []File
 	var imageFiles []File
@@ -201,20 +235,12 @@ func (o *OpenRouterLLM) GenerateWithFiles(ctx context.Context, prompt string, fi
 		}
 	}
 
-	// If no media files, fall back to text-only approach
-	if len(imageFiles) == 0 && len(pdfFiles) == 0 {
-		combined := combinePromptWithFiles(fullPrompt, textFiles)
-		return o.Generate(ctx, combined)
-	}
-
 	// Build the text prompt with inline text files
-	textPrompt := fullPrompt
+	textPrompt := text
 	if len(textFiles) > 0 {
-		textPrompt = combinePromptWithFiles(fullPrompt, textFiles)
+		textPrompt = combinePromptWithFiles(text, textFiles)
 	}
 
-	var content components.ChatUserMessageContent
-
 	switch {
 	case len(imageFiles) > 0:
 		// Only the first image is attached, matching the original function's behavior.
@@ -225,7 +251,7 @@ func (o *OpenRouterLLM) GenerateWithFiles(ctx context.Context, prompt string, fi
 			getOpenRouterMimeType(normalizeMIME(firstImage.Name, firstImage.MIME)),
 			encoded,
 		)
-		content = components.CreateChatUserMessageContentArrayOfChatContentItems([]components.ChatContentItems{
+		return components.CreateChatUserMessageContentArrayOfChatContentItems([]components.ChatContentItems{
 			components.CreateChatContentItemsText(components.ChatContentText{Text: textPrompt}),
 			components.CreateChatContentItemsImageURL(components.ChatContentImage{
 				ImageURL: components.ChatContentImageImageURL{URL: dataURL},
@@ -237,7 +263,7 @@ func (o *OpenRouterLLM) GenerateWithFiles(ctx context.Context, prompt string, fi
 		encoded := base64.StdEncoding.EncodeToString(firstPDF.Data)
 		dataURL := fmt.Sprintf("data:application/pdf;base64,%s", encoded)
 		filename := firstPDF.Name
-		content = components.CreateChatUserMessageContentArrayOfChatContentItems([]components.ChatContentItems{
+		return components.CreateChatUserMessageContentArrayOfChatContentItems([]components.ChatContentItems{
 			components.CreateChatContentItemsText(components.ChatContentText{Text: textPrompt}),
 			components.CreateChatContentItemsFile(components.ChatContentFile{
 				File: components.File{
@@ -248,23 +274,64 @@ func (o *OpenRouterLLM) GenerateWithFiles(ctx context.Context, prompt string, fi
 		})
 
 	default:
-		content = components.CreateChatUserMessageContentStr(textPrompt)
+		return components.CreateChatUserMessageContentStr(textPrompt)
+	}
+}
+
+// Chat sends messages in OpenRouter's chat format. Tool calls are passed
+// through so a conversation can continue after a tool ran, but the reply
+// carries only the assistant's text.
+func (o *OpenRouterLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	var chat []components.ChatMessages
+	for _, m := range withPromptPrefix(o.PromptPrefix, messages) {
+		switch m.Role {
+		case RoleSystem:
+			chat = append(chat, components.CreateChatMessagesSystem(components.ChatSystemMessage{
+				Content: components.CreateChatSystemMessageContentStr(m.Content),
+			}))
+		case RoleAssistant:
+			message := components.ChatAssistantMessage{}
+			if m.Content != "" {
+				content := components.CreateChatAssistantMessageContentStr(m.Content)
+				message.Content = optionalnullable.From(&content)
+			}
+			for _, call := range m.ToolCalls {
+				arguments, err := json.Marshal(call.Arguments)
+				if err != nil {
+					return Message{}, fmt.Errorf("openrouter tool %s arguments: %w", call.Name, err)
+				}
+				message.ToolCalls = append(message.ToolCalls, components.ChatToolCall{
+					ID:       call.ID,
+					Type:     components.ChatToolCallTypeFunction,
+					Function: components.ChatToolCallFunction{Name: call.Name, Arguments: string(arguments)},
+				})
+			}
+			chat = append(chat, components.CreateChatMessagesAssistant(message))
+		case RoleTool:
+			chat = append(chat, components.CreateChatMessagesTool(components.ChatToolMessage{
+				Content:    components.CreateChatToolMessageContentStr(m.Content),
+				ToolCallID: m.ToolCallID,
+			}))
+		default:
+			chat = append(chat, components.CreateChatMessagesUser(components.ChatUserMessage{
+				Content: openRouterUserContent(m.Content, m.Files),
+			}))
+		}
 	}
 
 	res, err := o.Client.Chat.Send(ctx, components.ChatRequest{
-		Model: openrouter.String(o.Model),
-		Messages: []components.ChatMessages{
-			components.CreateChatMessagesUser(components.ChatUserMessage{
-				Content: content,
-			}),
-		},
+		Model:    openrouter.String(o.Model),
+		Messages: chat,
 	}, nil)
 	if err != nil {
-		return nil, err
+		return Message{}, err
 	}
 	if res == nil || res.ChatResult == nil {
-		return nil, errors.New("no response from OpenRouter")
+		return Message{}, errors.New("no response from OpenRouter")
 	}
-
-	return firstChoiceText(res.ChatResult)
+	text, err := firstChoiceText(res.ChatResult)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Role: RoleAssistant, Content: text}, nil
 }
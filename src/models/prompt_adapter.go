@@ -0,0 +1,224 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PromptSection is one titled part of a prompt, such as the retrieved
+// memory or the tools a model may call.
+type PromptSection struct {
+	Title string
+	Body  string
+}
+
+// Prompt is a provider-neutral prompt: the instructions in System, the
+// material the model works from in Sections and the user's message in
+// Input. A PromptAdapter lays it out for one family of models.
+type Prompt struct {
+	System   string
+	Sections []PromptSection
+	Input    string
+}
+
+// PromptAdapter renders a Prompt in the scaffolding a model follows best,
+// for example XML tags for Claude or a chat template for a raw Llama
+// endpoint. Sections with an empty body are left out.
+type PromptAdapter interface {
+	FormatPrompt(Prompt) string
+}
+
+// PromptAdapterProvider is implemented by models, and by wrappers around
+// them, that pick their own PromptAdapter.
+type PromptAdapterProvider interface {
+	PromptAdapter() PromptAdapter
+}
+
+// PromptAdapterFor returns the adapter that suits m, or nil when m has no
+// preference and the plain layout applies.
+func PromptAdapterFor(m Agent) PromptAdapter {
+	switch m := m.(type) {
+	case PromptAdapterProvider:
+		return m.PromptAdapter()
+	case *AnthropicLLM:
+		return XMLPromptAdapter{}
+	case *GeminiLLM, *VertexLLM:
+		return MarkdownPromptAdapter{}
+	case *OllamaLLM:
+		if m.Raw {
+			return LlamaPromptAdapter{}
+		}
+	}
+	return nil
+}
+
+// FormatPrompt renders p with adapter, or with PlainPromptAdapter when
+// adapter is nil.
+func FormatPrompt(adapter PromptAdapter, p Prompt) string {
+	if adapter == nil {
+		adapter = PlainPromptAdapter{}
+	}
+	return adapter.FormatPrompt(p)
+}
+
+// PlainPromptAdapter writes each section as "Title:" followed by its body
+// and the input as "User: ...". It suits models that take a prompt without
+// a preferred structure.
+type PlainPromptAdapter struct{}
+
+func (PlainPromptAdapter) FormatPrompt(p Prompt) string {
+	var sb strings.Builder
+	if system := strings.TrimSpace(p.System); system != "" {
+		sb.WriteString(system)
+		sb.WriteString("\n\n")
+	}
+	writePlainSections(&sb, p.Sections)
+	if input := strings.TrimSpace(p.Input); input != "" {
+		sb.WriteString("User: ")
+		sb.WriteString(input)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func writePlainSections(sb *strings.Builder, sections []PromptSection) {
+	for _, s := range sections {
+		body := strings.TrimSpace(s.Body)
+		if body == "" {
+			continue
+		}
+		sb.WriteString(s.Title)
+		sb.WriteString(":\n")
+		sb.WriteString(body)
+		sb.WriteString("\n\n")
+	}
+}
+
+// XMLPromptAdapter wraps each section in a tag named after its title, as in
+// <conversation_memory>...</conversation_memory>, and the input in
+// <user_input>. Claude models keep instructions and data apart best this
+// way. A closing tag inside a body is escaped so it cannot end the section
+// early.
+type XMLPromptAdapter struct{}
+
+func (XMLPromptAdapter) FormatPrompt(p Prompt) string {
+	var sb strings.Builder
+	if system := strings.TrimSpace(p.System); system != "" {
+		sb.WriteString(system)
+		sb.WriteString("\n\n")
+	}
+	for _, s := range p.Sections {
+		writeXMLSection(&sb, promptTag(s.Title), s.Body)
+	}
+	writeXMLSection(&sb, "user_input", p.Input)
+	return sb.String()
+}
+
+func writeXMLSection(sb *strings.Builder, tag, body string) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return
+	}
+	closing := "</" + tag + ">"
+	sb.WriteString("<" + tag + ">\n")
+	sb.WriteString(strings.ReplaceAll(body, closing, `<\/`+tag+">"))
+	sb.WriteString("\n" + closing + "\n\n")
+}
+
+var promptTagRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// promptTag turns a section title such as "AVAILABLE UTCP TOOLS" into the
+// tag name available_utcp_tools.
+func promptTag(title string) string {
+	tag := strings.Trim(promptTagRe.ReplaceAllString(strings.ToLower(title), "_"), "_")
+	if tag == "" {
+		return "section"
+	}
+	return tag
+}
+
+// MarkdownPromptAdapter puts the instructions under a "System instruction"
+// heading and each section under a heading of its own. Gemini models read
+// a system instruction apart from the content that follows it.
+type MarkdownPromptAdapter struct{}
+
+func (MarkdownPromptAdapter) FormatPrompt(p Prompt) string {
+	var sb strings.Builder
+	if system := strings.TrimSpace(p.System); system != "" {
+		sb.WriteString("# System instruction\n\n")
+		sb.WriteString(system)
+		sb.WriteString("\n\n")
+	}
+	for _, s := range p.Sections {
+		body := strings.TrimSpace(s.Body)
+		if body == "" {
+			continue
+		}
+		sb.WriteString("## ")
+		sb.WriteString(s.Title)
+		sb.WriteString("\n\n")
+		sb.WriteString(body)
+		sb.WriteString("\n\n")
+	}
+	if input := strings.TrimSpace(p.Input); input != "" {
+		sb.WriteString("## User\n\n")
+		sb.WriteString(input)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// LlamaPromptAdapter renders the Llama 3 chat template: a system turn with
+// the instructions, a user turn with the sections and input, and an open
+// assistant turn. Use it with endpoints that take a raw prompt, such as an
+// OllamaLLM with Raw set; others apply the template themselves. Special
+// tokens inside the text are broken up so they cannot open a turn.
+type LlamaPromptAdapter struct{}
+
+func (a LlamaPromptAdapter) FormatPrompt(p Prompt) string {
+	var user strings.Builder
+	writePlainSections(&user, p.Sections)
+	user.WriteString(strings.TrimSpace(p.Input))
+	return a.FormatMessages([]Message{
+		{Role: RoleSystem, Content: p.System},
+		{Role: RoleUser, Content: user.String()},
+	})
+}
+
+// FormatMessages renders a conversation in the same template, one turn per
+// message, with tool messages in the ipython role Llama 3 uses for tool
+// output.
+func (LlamaPromptAdapter) FormatMessages(messages []Message) string {
+	var sb strings.Builder
+	sb.WriteString("<|begin_of_text|>")
+	for _, m := range messages {
+		text := strings.TrimSpace(m.Content)
+		role := m.Role
+		switch m.Role {
+		case RoleSystem:
+			if text == "" {
+				continue
+			}
+		case RoleAssistant:
+			for _, call := range m.ToolCalls {
+				args, _ := json.Marshal(call.Arguments)
+				text = strings.TrimSpace(text + "\n" + fmt.Sprintf(`{"name": %q, "parameters": %s}`, call.Name, args))
+			}
+		case RoleTool:
+			role = "ipython"
+		default:
+			role = RoleUser
+		}
+		writeLlamaTurn(&sb, role, text)
+	}
+	sb.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+	return sb.String()
+}
+
+func writeLlamaTurn(sb *strings.Builder, role, text string) {
+	sb.WriteString("<|start_header_id|>" + role + "<|end_header_id|>\n\n")
+	sb.WriteString(strings.ReplaceAll(text, "<|", "< |"))
+	sb.WriteString("<|eot_id|>")
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"strings"
+)
+
+type stopSequencesKey struct{}
+
+// WithStopSequences asks the models streaming under ctx to end their
+// output before the first of stops. Providers that support stop sequences
+// receive them with the request, and every provider stream is also cut
+// client side, so the stop text never reaches the caller.
+func WithStopSequences(ctx context.Context, stops ...string) context.Context {
+	var kept []string
+	for _, s := range stops {
+		if s != "" {
+			kept = append(kept, s)
+		}
+	}
+	return context.WithValue(ctx, stopSequencesKey{}, kept)
+}
+
+// StopSequences returns the stop sequences set by WithStopSequences.
+func StopSequences(ctx context.Context) []string {
+	stops, _ := ctx.Value(stopSequencesKey{}).([]string)
+	return stops
+}
+
+// stopStream ends in at the first of stops, holding back text that may be
+// the start of one. The final chunk names the stop sequence that matched;
+// the rest of in is drained so the provider goroutine can finish.
+func stopStream(stops []string, in <-chan StreamChunk) <-chan StreamChunk {
+	if len(stops) == 0 {
+		return in
+	}
+	out := make(chan StreamChunk, 16)
+	go func() {
+		defer close(out)
+		var full strings.Builder
+		pending := ""
+		emit := func(text string) {
+			if text != "" {
+				full.WriteString(text)
+				out <- StreamChunk{Delta: text}
+			}
+		}
+		for chunk := range in {
+			pending += chunk.Delta
+			if at, stop := firstStop(pending, stops); stop != "" {
+				emit(pending[:at])
+				out <- StreamChunk{Done: true, FullText: full.String(), Stop: stop}
+				go func() {
+					for range in {
+					}
+				}()
+				return
+			}
+			if chunk.Done || chunk.Err != nil {
+				emit(pending)
+				out <- StreamChunk{Done: true, FullText: full.String(), Err: chunk.Err, Interrupted: chunk.Interrupted}
+				return
+			}
+			held := partialStop(pending, stops)
+			emit(pending[:held])
+			pending = pending[held:]
+		}
+		emit(pending)
+		out <- StreamChunk{Done: true, FullText: full.String()}
+	}()
+	return out
+}
+
+// firstStop returns the position and text of the earliest stop sequence in
+// text.
+func firstStop(text string, stops []string) (int, string) {
+	at, found := -1, ""
+	for _, s := range stops {
+		if i := strings.Index(text, s); i >= 0 && (at < 0 || i < at) {
+			at, found = i, s
+		}
+	}
+	return at, found
+}
+
+// partialStop returns where the longest suffix of text that begins a stop
+// sequence starts, or len(text) when none does.
+func partialStop(text string, stops []string) int {
+	for i := range len(text) {
+		for _, s := range stops {
+			if strings.HasPrefix(s, text[i:]) {
+				return i
+			}
+		}
+	}
+	return len(text)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStopStreamCutsAcrossChunks(t *testing.T) {
+	in := make(chan StreamChunk, 8)
+	for _, delta := range []string{"Answer: 42", "\nUs", "er: next question"} {
+		in <- StreamChunk{Delta: delta}
+	}
+	in <- StreamChunk{Done: true, FullText: "Answer: 42\nUser: next question"}
+	close(in)
+
+	var text string
+	var last StreamChunk
+	for chunk := range stopStream([]string{"\nUser:", "###"}, in) {
+		text += chunk.Delta
+		last = chunk
+	}
+	if text != "Answer: 42" || last.FullText != "Answer: 42" || last.Stop != "\nUser:" || !last.Done {
+		t.Fatalf("text %q, final chunk %+v", text, last)
+	}
+}
+
+func TestDummyStreamHonorsStopSequences(t *testing.T) {
+	ctx := WithStopSequences(context.Background(), "world")
+	stream, err := NewDummyLLM("").GenerateStream(ctx, "hello world again")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last StreamChunk
+	for chunk := range stream {
+		last = chunk
+	}
+	if last.Stop != "world" || last.FullText == "" {
+		t.Fatalf("final chunk = %+v", last)
+	}
+}
@@ -83,13 +83,17 @@ func (v *VertexLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 	contents := []*genai.Content{
 		genai.NewContentFromText(v.fullPrompt(prompt), genai.RoleUser),
 	}
+	var config *genai.GenerateContentConfig
+	if stops := StopSequences(ctx); len(stops) > 0 {
+		config = &genai.GenerateContentConfig{StopSequences: stops}
+	}
 
 	ch := make(chan StreamChunk, 16)
 	go func() {
 		defer close(ch)
 
 		var full strings.Builder
-		for resp, err := range v.Client.Models.GenerateContentStream(ctx, v.Model, contents, nil) {
+		for resp, err := range v.Client.Models.GenerateContentStream(ctx, v.Model, contents, config) {
 			if err != nil {
 				ch <- StreamChunk{Done: true, FullText: full.String(), Err: err}
 				return
@@ -109,7 +113,7 @@ func (v *VertexLLM) GenerateStream(ctx context.Context, prompt string) (<-chan S
 		ch <- StreamChunk{Done: true, FullText: full.String()}
 	}()
 
-	return ch, nil
+	return stopStream(StopSequences(ctx), ch), nil
 }
 
 func (v *VertexLLM) GenerateWithFiles(ctx context.Context, prompt string, files []File) (any, error) {
@@ -134,6 +138,14 @@ func (v *VertexLLM) fullPrompt(prompt string) string {
 }
 
 func (v *VertexLLM) contentParts(prompt string, files []File) []*genai.Part {
+	var parts []*genai.Part
+	if prefix := strings.TrimSpace(v.PromptPrefix); prefix != "" {
+		parts = append(parts, genai.NewPartFromText(prefix))
+	}
+	return append(parts, vertexUserParts(prompt, files)...)
+}
+
+func vertexUserParts(text string, files []File) []*genai.Part {
 	normalized := make([]File, 0, len(files))
 	for _, file := range files {
 		normalized = append(normalized, File{
@@ -143,11 +155,8 @@ func (v *VertexLLM) contentParts(prompt string, files []File) []*genai.Part {
 		})
 	}
 
-	parts := make([]*genai.Part, 0, len(normalized)+2)
-	if prefix := strings.TrimSpace(v.PromptPrefix); prefix != "" {
-		parts = append(parts, genai.NewPartFromText(prefix))
-	}
-	parts = append(parts, genai.NewPartFromText(combinePromptWithFiles(prompt, normalized)))
+	parts := make([]*genai.Part, 0, len(normalized)+1)
+	parts = append(parts, genai.NewPartFromText(combinePromptWithFiles(text, normalized)))
 
 	for _, file := range normalized {
 		if len(file.Data) == 0 {
@@ -163,6 +172,69 @@ func (v *VertexLLM) contentParts(prompt string, files []File) []*genai.Part {
 	return parts
 }
 
+// Chat sends the system messages as the system instruction and the others
+// as contents, with tool calls and tool messages as function call and
+// function response parts.
+func (v *VertexLLM) Chat(ctx context.Context, messages []Message) (Message, error) {
+	config, contents := vertexChatRequest(withPromptPrefix(v.PromptPrefix, messages))
+	resp, err := v.Client.Models.GenerateContent(ctx, v.Model, contents, config)
+	if err != nil {
+		return Message{}, fmt.Errorf("vertex chat: %w", err)
+	}
+	if resp == nil {
+		return Message{}, errors.New("vertex: empty response")
+	}
+	reply := Message{Role: RoleAssistant, Content: resp.Text()}
+	for _, call := range resp.FunctionCalls() {
+		id := call.ID
+		if id == "" {
+			id = call.Name
+		}
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: id, Name: call.Name, Arguments: call.Args})
+	}
+	if reply.Content == "" && len(reply.ToolCalls) == 0 {
+		return Message{}, errors.New("vertex: empty response")
+	}
+	return reply, nil
+}
+
+func vertexChatRequest(messages []Message) (*genai.GenerateContentConfig, []*genai.Content) {
+	system, rest := splitSystem(messages)
+	var config *genai.GenerateContentConfig
+	if system != "" {
+		config = &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText(system, genai.RoleUser),
+		}
+	}
+
+	var contents []*genai.Content
+	for _, m := range rest {
+		var content *genai.Content
+		switch m.Role {
+		case RoleAssistant:
+			var parts []*genai.Part
+			if m.Content != "" {
+				parts = append(parts, genai.NewPartFromText(m.Content))
+			}
+			for _, call := range m.ToolCalls {
+				parts = append(parts, genai.NewPartFromFunctionCall(call.Name, call.Arguments))
+			}
+			content = genai.NewContentFromParts(parts, genai.RoleModel)
+		case RoleTool:
+			part := genai.NewPartFromFunctionResponse(m.Name, toolResultPayload(m.Content))
+			content = genai.NewContentFromParts([]*genai.Part{part}, genai.RoleUser)
+		default:
+			content = genai.NewContentFromParts(vertexUserParts(m.Content, m.Files), genai.RoleUser)
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == content.Role {
+			contents[n-1].Parts = append(contents[n-1].Parts, content.Parts...)
+			continue
+		}
+		contents = append(contents, content)
+	}
+	return config, contents
+}
+
 func vertexResponseText(resp *genai.GenerateContentResponse) (string, error) {
 	if resp == nil {
 		return "", errors.New("vertex: empty response")
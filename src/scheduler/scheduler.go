@@ -0,0 +1,352 @@
+// Package scheduler runs persisted one-shot jobs, such as reminders, that
+// nudge an agent session at a given time. Jobs survive restarts through a
+// Store; jobs that came due while the process was down fire on start.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// Job is one scheduled nudge.
+type Job struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+	Created   time.Time `json:"created"`
+	// Kind labels the job's origin, e.g. "reminder".
+	Kind     string `json:"kind,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+	// Tenant and Identity are those of the context the job was scheduled
+	// on; the handler runs under them so the turn sees the same memories.
+	Tenant   string          `json:"tenant,omitempty"`
+	Identity memory.Identity `json:"identity,omitzero"`
+}
+
+// Store persists pending jobs.
+type Store interface {
+	Load(ctx context.Context) ([]Job, error)
+	Save(ctx context.Context, jobs []Job) error
+}
+
+// MemoryStore keeps jobs in process; they are lost on restart.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// Load returns a copy of the stored jobs.
+func (m *MemoryStore) Load(context.Context) ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Job(nil), m.jobs...), nil
+}
+
+// Save replaces the stored jobs.
+func (m *MemoryStore) Save(_ context.Context, jobs []Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs = append([]Job(nil), jobs...)
+	return nil
+}
+
+// FileStore keeps jobs as JSON at Path. A missing file holds no jobs.
+type FileStore struct {
+	Path string
+}
+
+// Load reads the job file.
+func (f FileStore) Load(context.Context) ([]Job, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("parse jobs %s: %w", f.Path, err)
+	}
+	return jobs, nil
+}
+
+// Save writes the job file atomically.
+func (f FileStore) Save(_ context.Context, jobs []Job) error {
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), ".jobs-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// Handler delivers a due job. A returned error schedules a retry.
+type Handler func(ctx context.Context, job Job) error
+
+// Runner is the part of *agent.Agent NudgeSession needs.
+type Runner interface {
+	Generate(ctx context.Context, sessionID, userInput string) (any, error)
+}
+
+// NudgeSession returns a Handler that sends each job's message to its
+// session as a "[REMINDER]" turn, so the agent can follow up there.
+func NudgeSession(r Runner) Handler {
+	return func(ctx context.Context, job Job) error {
+		_, err := r.Generate(ctx, job.SessionID, "[REMINDER] "+job.Message)
+		return err
+	}
+}
+
+const (
+	// DefaultMaxAttempts bounds deliveries of a failing job.
+	DefaultMaxAttempts = 5
+	// DefaultRetryDelay is the back-off unit between failed deliveries.
+	DefaultRetryDelay = time.Minute
+)
+
+// Scheduler fires jobs at their due time. Create it with New and drive it
+// with Run.
+type Scheduler struct {
+	// MaxAttempts and RetryDelay control retries of failing jobs; the delay
+	// grows linearly with each attempt.
+	MaxAttempts int
+	RetryDelay  time.Duration
+
+	store   Store
+	handler Handler
+	now     func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]Job
+	wake chan struct{}
+}
+
+// New loads pending jobs from store (a MemoryStore when nil).
+func New(ctx context.Context, store Store, handler Handler) (*Scheduler, error) {
+	if handler == nil {
+		return nil, errors.New("scheduler: handler is required")
+	}
+	if store == nil {
+		store = &MemoryStore{}
+	}
+	jobs, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: load jobs: %w", err)
+	}
+	s := &Scheduler{
+		store:   store,
+		handler: handler,
+		now:     time.Now,
+		jobs:    make(map[string]Job, len(jobs)),
+		wake:    make(chan struct{}, 1),
+	}
+	for _, j := range jobs {
+		s.jobs[j.ID] = j
+	}
+	return s, nil
+}
+
+// Schedule adds a job, assigning its ID and creation time. A job without a
+// tenant or identity takes those on ctx.
+func (s *Scheduler) Schedule(ctx context.Context, job Job) (Job, error) {
+	if strings.TrimSpace(job.SessionID) == "" {
+		return Job{}, errors.New("scheduler: job needs a session")
+	}
+	if job.At.IsZero() {
+		return Job{}, errors.New("scheduler: job needs a due time")
+	}
+	if job.Tenant == "" {
+		job.Tenant = memory.TenantFromContext(ctx)
+	}
+	if job.Identity.Principal == "" {
+		job.Identity, _ = memory.IdentityFromContext(ctx)
+	}
+	job.ID = newID()
+	job.Created = s.now()
+	job.Attempts = 0
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	err := s.persistLocked(ctx)
+	if err != nil {
+		delete(s.jobs, job.ID)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return Job{}, err
+	}
+	s.poke()
+	return job, nil
+}
+
+// Cancel removes a pending job. When sessionID is non-empty the job must
+// belong to it. It reports whether a job was removed.
+func (s *Scheduler) Cancel(ctx context.Context, sessionID, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || (sessionID != "" && job.SessionID != sessionID) {
+		return false, nil
+	}
+	delete(s.jobs, id)
+	if err := s.persistLocked(ctx); err != nil {
+		s.jobs[id] = job
+		return false, err
+	}
+	return true, nil
+}
+
+// Jobs lists pending jobs for sessionID (all sessions when empty), soonest
+// first.
+func (s *Scheduler) Jobs(sessionID string) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		if sessionID == "" || j.SessionID == sessionID {
+			out = append(out, j)
+		}
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].At.Before(out[k].At) })
+	return out
+}
+
+// Run fires due jobs until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		s.fireDue(ctx)
+		var (
+			timer *time.Timer
+			wait  <-chan time.Time
+		)
+		if next, ok := s.nextDue(); ok {
+			timer = time.NewTimer(max(next.Sub(s.now()), 0))
+			wait = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Scheduler) nextDue() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, j := range s.jobs {
+		if next.IsZero() || j.At.Before(next) {
+			next = j.At
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// fireDue delivers every job that is due, one at a time.
+func (s *Scheduler) fireDue(ctx context.Context) {
+	for _, job := range s.Jobs("") {
+		if ctx.Err() != nil || job.At.After(s.now()) {
+			return
+		}
+		err := s.handler(jobContext(ctx, job), job)
+		s.mu.Lock()
+		if _, still := s.jobs[job.ID]; !still {
+			// Cancelled while being delivered.
+			s.mu.Unlock()
+			continue
+		}
+		if err == nil {
+			delete(s.jobs, job.ID)
+		} else {
+			job.Attempts++
+			maxAttempts := s.MaxAttempts
+			if maxAttempts <= 0 {
+				maxAttempts = DefaultMaxAttempts
+			}
+			delay := s.RetryDelay
+			if delay <= 0 {
+				delay = DefaultRetryDelay
+			}
+			if job.Attempts >= maxAttempts {
+				log.Printf("scheduler: dropping job %s for %s after %d attempts: %v", job.ID, job.SessionID, job.Attempts, err)
+				delete(s.jobs, job.ID)
+			} else {
+				job.At = s.now().Add(delay * time.Duration(job.Attempts))
+				s.jobs[job.ID] = job
+			}
+		}
+		if perr := s.persistLocked(ctx); perr != nil {
+			log.Printf("scheduler: persist jobs: %v", perr)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// jobContext restores the tenant and identity job was scheduled under.
+func jobContext(ctx context.Context, job Job) context.Context {
+	if job.Tenant != "" {
+		ctx = memory.ContextWithTenant(ctx, job.Tenant)
+	}
+	if job.Identity.Principal != "" {
+		ctx = memory.ContextWithIdentity(ctx, job.Identity)
+	}
+	return ctx
+}
+
+func (s *Scheduler) persistLocked(ctx context.Context) error {
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].At.Before(jobs[k].At) })
+	if err := s.store.Save(ctx, jobs); err != nil {
+		return fmt.Errorf("scheduler: save jobs: %w", err)
+	}
+	return nil
+}
+
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
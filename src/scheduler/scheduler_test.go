@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestParseWhen(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2024, 6, 5, 10, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"in 2 hours":           now.Add(2 * time.Hour),
+		"90m":                  now.Add(90 * time.Minute),
+		"tomorrow":             time.Date(2024, 6, 6, 9, 0, 0, 0, time.UTC),
+		"tonight":              time.Date(2024, 6, 5, 20, 0, 0, 0, time.UTC),
+		"Friday":               time.Date(2024, 6, 7, 9, 0, 0, 0, time.UTC),
+		"fri 3pm":              time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC),
+		"next monday at 14:30": time.Date(2024, 6, 10, 14, 30, 0, 0, time.UTC),
+		"wednesday":            time.Date(2024, 6, 12, 9, 0, 0, 0, time.UTC),
+		"9:30am":               time.Date(2024, 6, 6, 9, 30, 0, 0, time.UTC),
+		"at noon":              time.Date(2024, 6, 5, 12, 0, 0, 0, time.UTC),
+		"2024-07-01":           time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+		"2024-07-01 16:45":     time.Date(2024, 7, 1, 16, 45, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		got, err := ParseWhen(in, now)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%q = %s, want %s", in, got, want)
+		}
+	}
+	for _, bad := range []string{"", "someday", "friday 25:00"} {
+		if _, err := ParseWhen(bad, now); err == nil {
+			t.Errorf("%q should not parse", bad)
+		}
+	}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	turns []string
+	fired chan struct{}
+}
+
+func (r *recorder) Generate(_ context.Context, session, input string) (any, error) {
+	r.mu.Lock()
+	r.turns = append(r.turns, session+": "+input)
+	r.mu.Unlock()
+	r.fired <- struct{}{}
+	return "ok", nil
+}
+
+func TestJobsSurviveRestartAndFireWhenOverdue(t *testing.T) {
+	ctx := context.Background()
+	store := FileStore{Path: filepath.Join(t.TempDir(), "jobs.json")}
+	idle := func(context.Context, Job) error { return errors.New("not running") }
+
+	first, err := New(ctx, store, idle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	due, err := first.Schedule(ctx, Job{SessionID: "s1", Message: "file the report", At: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Schedule(ctx, Job{SessionID: "s1", Message: "later", At: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recorder{fired: make(chan struct{}, 4)}
+	second, err := New(ctx, store, NudgeSession(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(second.Jobs("s1")); n != 2 {
+		t.Fatalf("restart should reload 2 jobs, got %d", n)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- second.Run(runCtx) }()
+	select {
+	case <-rec.fired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("overdue job did not fire")
+	}
+	cancel()
+	<-done
+
+	if len(rec.turns) != 1 || rec.turns[0] != "s1: [REMINDER] file the report" {
+		t.Fatalf("turns = %v", rec.turns)
+	}
+	left, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].ID == due.ID {
+		t.Fatalf("delivered job should be removed from the store, left %+v", left)
+	}
+}
+
+func TestFailingJobRetriesThenDrops(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 5, 10, 0, 0, 0, time.UTC)
+	calls := 0
+	s, err := New(ctx, nil, func(context.Context, Job) error { calls++; return errors.New("boom") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return now }
+	s.MaxAttempts = 2
+	s.RetryDelay = time.Minute
+	if _, err := s.Schedule(ctx, Job{SessionID: "s", Message: "m", At: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.fireDue(ctx)
+	jobs := s.Jobs("")
+	if calls != 1 || len(jobs) != 1 || !jobs[0].At.Equal(now.Add(time.Minute)) {
+		t.Fatalf("first failure should reschedule: calls=%d jobs=%+v", calls, jobs)
+	}
+	now = now.Add(time.Minute)
+	s.fireDue(ctx)
+	if calls != 2 || len(s.Jobs("")) != 0 {
+		t.Fatalf("job should be dropped after MaxAttempts: calls=%d jobs=%+v", calls, s.Jobs(""))
+	}
+}
+
+func TestJobsRunAsTheTenantAndIdentityThatScheduledThem(t *testing.T) {
+	ctx := context.Background()
+	store := FileStore{Path: filepath.Join(t.TempDir(), "jobs.json")}
+	now := time.Date(2024, 6, 5, 10, 0, 0, 0, time.UTC)
+	first, err := New(ctx, store, func(context.Context, Job) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduled := memory.ContextWithIdentity(memory.ContextWithTenant(ctx, "acme"), memory.Identity{Principal: "alice", Groups: []string{"eng"}})
+	if _, err := first.Schedule(scheduled, Job{SessionID: "s1", Message: "m", At: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	var tenant string
+	var id memory.Identity
+	second, err := New(ctx, store, func(ctx context.Context, _ Job) error {
+		tenant = memory.TenantFromContext(ctx)
+		id, _ = memory.IdentityFromContext(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.now = func() time.Time { return now }
+	second.fireDue(ctx)
+	if tenant != "acme" || id.Principal != "alice" || len(id.Groups) != 1 {
+		t.Fatalf("handler ran as tenant %q, identity %+v; want the scheduler's", tenant, id)
+	}
+}
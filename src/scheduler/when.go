@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHour is the time of day used when a phrase names only a day.
+const DefaultHour = 9
+
+var (
+	inPattern  = regexp.MustCompile(`^in\s+(\d+)\s*(m|min|mins|minutes?|h|hrs?|hours?|d|days?|w|weeks?)$`)
+	clockAMPM  = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)$`)
+	clock24    = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+	dateLayout = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+)
+
+// ParseWhen resolves a due time relative to now: RFC 3339 timestamps,
+// dates ("2024-06-07 15:00"), Go durations or "in 2 hours", and day phrases
+// such as "tomorrow", "friday 3pm" or "next monday at 14:30". Day phrases
+// without a time resolve to DefaultHour in now's location.
+func ParseWhen(text string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+	for _, layout := range dateLayout {
+		if t, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			if layout == "2006-01-02" {
+				t = t.Add(DefaultHour * time.Hour)
+			}
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(s, "in ")); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	if m := inPattern.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2][0] {
+		case 'm':
+			return now.Add(time.Duration(n) * time.Minute), nil
+		case 'h':
+			return now.Add(time.Duration(n) * time.Hour), nil
+		case 'd':
+			return now.AddDate(0, 0, n), nil
+		case 'w':
+			return now.AddDate(0, 0, 7*n), nil
+		}
+	}
+
+	if h, m, err := parseClock(strings.TrimPrefix(s, "at ")); err == nil {
+		// A bare clock time means its next occurrence.
+		t := time.Date(now.Year(), now.Month(), now.Day(), h, m, 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	day, clock, _ := strings.Cut(strings.Replace(s, " at ", " ", 1), " ")
+	if strings.HasPrefix(s, "next ") {
+		day, clock, _ = strings.Cut(strings.TrimPrefix(s, "next "), " ")
+		clock = strings.TrimPrefix(clock, "at ")
+	}
+	hour, minute := DefaultHour, 0
+	if clock != "" {
+		var err error
+		if hour, minute, err = parseClock(clock); err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse time %q: %w", text, err)
+		}
+	}
+	at := func(d time.Time) time.Time {
+		return time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, now.Location())
+	}
+
+	switch day {
+	case "today", "tonight":
+		if day == "tonight" && clock == "" {
+			hour = 20
+		}
+		return at(now), nil
+	case "tomorrow":
+		return at(now.AddDate(0, 0, 1)), nil
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if day != name && day != name[:3] {
+			continue
+		}
+		ahead := (int(wd) - int(now.Weekday()) + 7) % 7
+		t := at(now.AddDate(0, 0, ahead))
+		if !t.After(now) || strings.HasPrefix(s, "next ") && ahead == 0 {
+			t = t.AddDate(0, 0, 7)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q", text)
+}
+
+func parseClock(s string) (hour, minute int, err error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "noon":
+		return 12, 0, nil
+	case "midnight":
+		return 0, 0, nil
+	case "morning":
+		return DefaultHour, 0, nil
+	case "afternoon":
+		return 14, 0, nil
+	case "evening":
+		return 18, 0, nil
+	}
+	if m := clockAMPM.FindStringSubmatch(s); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			minute, _ = strconv.Atoi(m[2])
+		}
+		if hour < 1 || hour > 12 || minute > 59 {
+			return 0, 0, fmt.Errorf("bad clock time %q", s)
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+		return hour, minute, nil
+	}
+	if m := clock24.FindStringSubmatch(s); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+		if hour > 23 || minute > 59 {
+			return 0, 0, fmt.Errorf("bad clock time %q", s)
+		}
+		return hour, minute, nil
+	}
+	return 0, 0, fmt.Errorf("bad clock time %q", s)
+}
@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from a HashiCorp Vault KV v2 engine. Every
+// secret name is a field of the single secret stored at Path.
+type VaultProvider struct {
+	Address string
+	Token   string
+	Mount   string // defaults to "secret"
+	Path    string
+	Client  *http.Client
+}
+
+// NewVaultProviderFromEnv configures Vault from VAULT_ADDR and VAULT_TOKEN.
+func NewVaultProviderFromEnv(path string) (*VaultProvider, error) {
+	addr := strings.TrimSpace(os.Getenv("VAULT_ADDR"))
+	token := strings.TrimSpace(os.Getenv("VAULT_TOKEN"))
+	if addr == "" || token == "" {
+		return nil, errors.New("vault: VAULT_ADDR and VAULT_TOKEN are required")
+	}
+	return &VaultProvider{Address: addr, Token: token, Path: path}, nil
+}
+
+// Get implements Provider.
+func (v *VaultProvider) Get(ctx context.Context, name string) (string, error) {
+	data, err := v.read(ctx)
+	if err != nil {
+		return "", err
+	}
+	raw, ok := data[name]
+	if !ok || raw == nil {
+		return "", ErrNotFound
+	}
+	return fmt.Sprint(raw), nil
+}
+
+func (v *VaultProvider) read(ctx context.Context) (map[string]any, error) {
+	mount := strings.Trim(v.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	endpoint, err := url.JoinPath(strings.TrimRight(v.Address, "/"), "v1", mount, "data", strings.Trim(v.Path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("vault: build url: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault: new request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, fmt.Errorf("vault: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("vault: decode response: %w", err)
+	}
+	return payload.Data.Data, nil
+}
+
+// SecretsManagerAPI is the subset of the AWS Secrets Manager client used by
+// AWSSecretsManagerProvider. It is satisfied by a thin adapter over the AWS
+// SDK's GetSecretValue, which keeps the SDK out of this module's dependencies.
+type SecretsManagerAPI interface {
+	GetSecretString(ctx context.Context, secretID string) (string, error)
+}
+
+// AWSSecretsManagerProvider resolves secrets from AWS Secrets Manager. When
+// SecretID is set, that secret is expected to hold a JSON object and names are
+// looked up as its keys; otherwise each name is used as its own secret ID.
+type AWSSecretsManagerProvider struct {
+	Client   SecretsManagerAPI
+	SecretID string
+}
+
+// Get implements Provider.
+func (p AWSSecretsManagerProvider) Get(ctx context.Context, name string) (string, error) {
+	if p.Client == nil {
+		return "", errors.New("aws secrets manager: client is nil")
+	}
+	if p.SecretID == "" {
+		return p.Client.GetSecretString(ctx, name)
+	}
+	raw, err := p.Client.GetSecretString(ctx, p.SecretID)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return "", fmt.Errorf("aws secrets manager: decode %s: %w", p.SecretID, err)
+	}
+	value, ok := fields[name]
+	if !ok || value == nil {
+		return "", ErrNotFound
+	}
+	return fmt.Sprint(value), nil
+}
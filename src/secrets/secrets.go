@@ -0,0 +1,212 @@
+// Package secrets centralises how credentials are resolved so models, stores
+// and integrations do not read os.Getenv directly. The default provider reads
+// the environment; deployments can swap in files, Vault or AWS Secrets Manager
+// and wrap any provider with caching (for rotation) and auditing.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a provider has no value for a secret name.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider resolves secret values by name.
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Get calls f.
+func (f ProviderFunc) Get(ctx context.Context, name string) (string, error) { return f(ctx, name) }
+
+var (
+	defaultMu       sync.RWMutex
+	defaultProvider Provider = EnvProvider{}
+)
+
+// SetDefault replaces the process-wide provider used by Lookup. Passing nil
+// restores the environment provider.
+func SetDefault(p Provider) {
+	if p == nil {
+		p = EnvProvider{}
+	}
+	defaultMu.Lock()
+	defaultProvider = p
+	defaultMu.Unlock()
+}
+
+// Default returns the process-wide provider.
+func Default() Provider {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultProvider
+}
+
+// Lookup returns the first non-empty value for names from the default
+// provider, or "" if none is set. Errors other than ErrNotFound are treated as
+// missing so callers keep their existing "key not configured" handling.
+func Lookup(names ...string) string {
+	return LookupContext(context.Background(), names...)
+}
+
+// LookupContext is Lookup with an explicit context.
+func LookupContext(ctx context.Context, names ...string) string {
+	p := Default()
+	for _, name := range names {
+		value, err := p.Get(ctx, name)
+		if err == nil && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// EnvProvider reads secrets from environment variables. NAME_FILE is honoured
+// as an indirection to a file holding the value, matching the Docker and
+// Kubernetes secrets convention.
+type EnvProvider struct{}
+
+// Get implements Provider.
+func (EnvProvider) Get(_ context.Context, name string) (string, error) {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value, nil
+	}
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s_FILE: %w", name, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", ErrNotFound
+}
+
+// FileProvider reads each secret from a file named after it inside Dir, for
+// example /run/secrets/OPENAI_API_KEY.
+type FileProvider struct {
+	Dir string
+}
+
+// Get implements Provider.
+func (p FileProvider) Get(_ context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Chain tries providers in order and returns the first value found. Errors
+// other than ErrNotFound stop the search so misconfigured backends are not
+// silently skipped.
+func Chain(providers ...Provider) Provider {
+	return ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		for _, p := range providers {
+			if p == nil {
+				continue
+			}
+			value, err := p.Get(ctx, name)
+			if err == nil {
+				return value, nil
+			}
+			if !errors.Is(err, ErrNotFound) {
+				return "", err
+			}
+		}
+		return "", ErrNotFound
+	})
+}
+
+// Cache memoises values from a slower provider for TTL. Invalidate forces the
+// next Get to refetch, which is how rotated credentials are picked up early.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+// NewCache wraps provider with a TTL cache. A non-positive ttl caches until
+// Invalidate is called.
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	return &Cache{provider: provider, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// Get implements Provider.
+func (c *Cache) Get(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && (c.ttl <= 0 || c.now().Before(entry.expires)) {
+		return entry.value, nil
+	}
+
+	value, err := c.provider.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[name] = cacheEntry{value: value, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops cached values for names, or every value when none are given.
+func (c *Cache) Invalidate(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(names) == 0 {
+		c.entries = make(map[string]cacheEntry)
+		return
+	}
+	for _, name := range names {
+		delete(c.entries, name)
+	}
+}
+
+// AuditEvent records a secret access. Values are never included.
+type AuditEvent struct {
+	Name  string
+	Found bool
+	Err   error
+	Time  time.Time
+}
+
+// Audited reports every Get on provider to fn.
+func Audited(provider Provider, fn func(context.Context, AuditEvent)) Provider {
+	if fn == nil {
+		return provider
+	}
+	return ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		value, err := provider.Get(ctx, name)
+		event := AuditEvent{Name: name, Found: err == nil, Time: time.Now()}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			event.Err = err
+		}
+		fn(ctx, event)
+		return value, err
+	})
+}
@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvProviderHonoursFileIndirection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Setenv("SECRETS_TEST_DIRECT", "direct")
+	t.Setenv("SECRETS_TEST_INDIRECT_FILE", path)
+
+	ctx := context.Background()
+	if got, err := (EnvProvider{}).Get(ctx, "SECRETS_TEST_DIRECT"); err != nil || got != "direct" {
+		t.Fatalf("direct: got %q, %v", got, err)
+	}
+	if got, err := (EnvProvider{}).Get(ctx, "SECRETS_TEST_INDIRECT"); err != nil || got != "from-file" {
+		t.Fatalf("indirect: got %q, %v", got, err)
+	}
+	if _, err := (EnvProvider{}).Get(ctx, "SECRETS_TEST_MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestChainAndLookup(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "API_KEY"), []byte("file-key"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var audited []AuditEvent
+	SetDefault(Audited(Chain(EnvProvider{}, FileProvider{Dir: dir}), func(_ context.Context, ev AuditEvent) {
+		audited = append(audited, ev)
+	}))
+	t.Cleanup(func() { SetDefault(nil) })
+
+	if got := Lookup("PRIMARY_KEY_UNSET", "API_KEY"); got != "file-key" {
+		t.Fatalf("Lookup = %q", got)
+	}
+	if len(audited) != 2 || audited[0].Found || !audited[1].Found {
+		t.Fatalf("unexpected audit trail: %+v", audited)
+	}
+	if _, err := (FileProvider{Dir: dir}).Get(context.Background(), "../etc/passwd"); err == nil {
+		t.Fatal("expected path traversal to be rejected")
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	calls := 0
+	value := "v1"
+	cache := NewCache(ProviderFunc(func(context.Context, string) (string, error) {
+		calls++
+		return value, nil
+	}), time.Hour)
+
+	ctx := context.Background()
+	_, _ = cache.Get(ctx, "k")
+	_, _ = cache.Get(ctx, "k")
+	if calls != 1 {
+		t.Fatalf("expected cached value, got %d calls", calls)
+	}
+
+	value = "v2"
+	cache.Invalidate("k")
+	if got, _ := cache.Get(ctx, "k"); got != "v2" {
+		t.Fatalf("expected refreshed value, got %q", got)
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/agent" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"OPENAI_API_KEY":"sk-vault"}}}`))
+	}))
+	defer srv.Close()
+
+	v := &VaultProvider{Address: srv.URL, Token: "root", Path: "agent"}
+	got, err := v.Get(context.Background(), "OPENAI_API_KEY")
+	if err != nil || got != "sk-vault" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	if _, err := v.Get(context.Background(), "MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+type fakeSecretsManager map[string]string
+
+func (f fakeSecretsManager) GetSecretString(_ context.Context, id string) (string, error) {
+	if v, ok := f[id]; ok {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+
+func TestAWSSecretsManagerProviderJSONSecret(t *testing.T) {
+	p := AWSSecretsManagerProvider{
+		Client:   fakeSecretsManager{"prod/agent": `{"ANTHROPIC_API_KEY":"sk-ant"}`},
+		SecretID: "prod/agent",
+	}
+	got, err := p.Get(context.Background(), "ANTHROPIC_API_KEY")
+	if err != nil || got != "sk-ant" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+}
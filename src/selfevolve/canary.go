@@ -0,0 +1,225 @@
+package selfevolve
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/selfevolve/eval"
+)
+
+const (
+	// DefaultCanaryMinSamples is how many scored turns each arm needs
+	// before a canary can be rolled back.
+	DefaultCanaryMinSamples = 20
+	// DefaultCanaryThreshold is the drop in mean score, candidate against
+	// stable, that triggers a rollback.
+	DefaultCanaryThreshold = 0.05
+)
+
+// Canary rolls a candidate prompt out to a share of sessions. It is an
+// agent.PromptSelector, so it plugs in as agent.Options.Prompts: each
+// session is assigned to the candidate or stable cohort by a hash of its
+// ID, and keeps that assignment for its lifetime.
+//
+// Completed turns are scored with Evaluators, and user feedback arriving
+// through RecordFeedback counts too. Once both cohorts have MinSamples
+// scores and the candidate's mean trails the stable mean by more than
+// Threshold, the canary rolls back and every session gets Stable.
+type Canary struct {
+	Stable    string
+	Candidate string
+	// Percent of sessions, 0–100, that get the candidate.
+	Percent    float64
+	Evaluators []eval.Evaluator
+	MinSamples int
+	Threshold  float64
+	// OnRollback is called once, outside the canary's lock, when an
+	// automatic rollback happens.
+	OnRollback func(CanaryStatus)
+
+	mu         sync.Mutex
+	stable     armStats
+	candidate  armStats
+	rolledBack bool
+	reason     string
+}
+
+type armStats struct {
+	n   int
+	sum float64
+}
+
+func (a armStats) mean() float64 {
+	if a.n == 0 {
+		return 0
+	}
+	return a.sum / float64(a.n)
+}
+
+// ArmStatus summarises one cohort's live scores.
+type ArmStatus struct {
+	Samples int     `json:"samples"`
+	Mean    float64 `json:"mean"`
+}
+
+// CanaryStatus is a snapshot of a canary.
+type CanaryStatus struct {
+	Percent    float64   `json:"percent"`
+	Stable     ArmStatus `json:"stable"`
+	Candidate  ArmStatus `json:"candidate"`
+	RolledBack bool      `json:"rolled_back"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// InCandidate reports whether sessionID belongs to the candidate cohort.
+// After a rollback no session does.
+func (c *Canary) InCandidate(sessionID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inCandidateLocked(sessionID)
+}
+
+func (c *Canary) inCandidateLocked(sessionID string) bool {
+	if c.rolledBack || c.Candidate == "" || c.Percent <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(sessionID))
+	return float64(h.Sum32()%10000) < c.Percent*100
+}
+
+// SystemPrompt returns the prompt for sessionID's cohort.
+func (c *Canary) SystemPrompt(sessionID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inCandidateLocked(sessionID) {
+		return c.Candidate
+	}
+	return c.Stable
+}
+
+// ObserveTurn scores a completed turn with Evaluators and attributes the
+// mean to the turn's cohort. Evaluator errors drop the turn.
+func (c *Canary) ObserveTurn(ctx context.Context, turn agent.Turn) {
+	if len(c.Evaluators) == 0 {
+		return
+	}
+	cs := eval.Case{Input: turn.Input, Output: turn.Output, Latency: turn.Latency}
+	for _, rec := range turn.Context {
+		cs.Context = append(cs.Context, rec.Content)
+	}
+	var sum float64
+	for _, ev := range c.Evaluators {
+		res, err := ev.Evaluate(ctx, cs)
+		if err != nil {
+			log.Printf("selfevolve: canary evaluator %s: %v", ev.Name(), err)
+			return
+		}
+		sum += res.Score
+	}
+	c.Observe(turn.SessionID, sum/float64(len(c.Evaluators)))
+}
+
+// RecordFeedback counts a user rating, mapped from [-1, 1] to [0, 1], as a
+// live score. It lets a Canary serve as agent.Options.FeedbackSink.
+func (c *Canary) RecordFeedback(_ context.Context, fb agent.Feedback) error {
+	c.Observe(fb.Turn.SessionID, (fb.Rating+1)/2)
+	return nil
+}
+
+// Observe records a score in [0, 1] for sessionID's cohort and rolls the
+// canary back when the candidate has degraded.
+func (c *Canary) Observe(sessionID string, score float64) {
+	c.mu.Lock()
+	if c.rolledBack {
+		c.mu.Unlock()
+		return
+	}
+	if c.inCandidateLocked(sessionID) {
+		c.candidate.n++
+		c.candidate.sum += score
+	} else {
+		c.stable.n++
+		c.stable.sum += score
+	}
+
+	minSamples := c.MinSamples
+	if minSamples <= 0 {
+		minSamples = DefaultCanaryMinSamples
+	}
+	threshold := c.Threshold
+	if threshold <= 0 {
+		threshold = DefaultCanaryThreshold
+	}
+	var status CanaryStatus
+	drop := c.stable.mean() - c.candidate.mean()
+	regressed := c.stable.n >= minSamples && c.candidate.n >= minSamples && drop > threshold
+	if regressed {
+		c.rolledBack = true
+		c.reason = fmt.Sprintf("candidate mean %.3f trails stable %.3f by %.3f (threshold %.3f)",
+			c.candidate.mean(), c.stable.mean(), drop, threshold)
+		status = c.statusLocked()
+	}
+	onRollback := c.OnRollback
+	c.mu.Unlock()
+
+	if regressed {
+		log.Printf("selfevolve: canary rolled back: %s", status.Reason)
+		if onRollback != nil {
+			onRollback(status)
+		}
+	}
+}
+
+// Rollback sends every session to the stable prompt.
+func (c *Canary) Rollback(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rolledBack = true
+	c.reason = reason
+}
+
+// Promote makes the candidate the stable prompt for every session and
+// resets the live scores. A rolled-back canary cannot be promoted.
+func (c *Canary) Promote() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rolledBack {
+		return fmt.Errorf("selfevolve: canary was rolled back: %s", c.reason)
+	}
+	if c.Candidate == "" {
+		return fmt.Errorf("selfevolve: canary has no candidate")
+	}
+	c.Stable = c.Candidate
+	c.Candidate = ""
+	c.Percent = 0
+	c.stable, c.candidate = armStats{}, armStats{}
+	return nil
+}
+
+// Status returns the canary's live scores and state.
+func (c *Canary) Status() CanaryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.statusLocked()
+}
+
+func (c *Canary) statusLocked() CanaryStatus {
+	return CanaryStatus{
+		Percent:    c.Percent,
+		Stable:     ArmStatus{Samples: c.stable.n, Mean: c.stable.mean()},
+		Candidate:  ArmStatus{Samples: c.candidate.n, Mean: c.candidate.mean()},
+		RolledBack: c.rolledBack,
+		Reason:     c.reason,
+	}
+}
+
+var (
+	_ agent.PromptSelector = (*Canary)(nil)
+	_ agent.TurnObserver   = (*Canary)(nil)
+	_ agent.FeedbackSink   = (*Canary)(nil)
+)
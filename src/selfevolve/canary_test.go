@@ -0,0 +1,87 @@
+package selfevolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+	"github.com/Protocol-Lattice/go-agent/src/selfevolve/eval"
+)
+
+// echoModel answers with the prompt it was given, so the system prompt in
+// use shows up in the response.
+type echoModel struct{}
+
+func (echoModel) Generate(_ context.Context, prompt string) (any, error) { return prompt, nil }
+func (echoModel) GenerateWithFiles(_ context.Context, prompt string, _ []models.File) (any, error) {
+	return prompt, nil
+}
+func (echoModel) GenerateStream(context.Context, string) (<-chan models.StreamChunk, error) {
+	return nil, errors.New("not streaming")
+}
+
+func TestCanaryRollsBackDegradedCandidate(t *testing.T) {
+	ctx := context.Background()
+	var rolledBack []CanaryStatus
+	canary := &Canary{
+		Stable:     "STABLE prompt",
+		Candidate:  "CANDIDATE prompt",
+		Percent:    50,
+		Evaluators: []eval.Evaluator{&eval.RegexFormat{Pattern: regexp.MustCompile(`STABLE`)}},
+		MinSamples: 3,
+		OnRollback: func(s CanaryStatus) { rolledBack = append(rolledBack, s) },
+	}
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 4).WithEmbedder(memory.DummyEmbedder{})
+	a, err := agent.New(agent.Options{Model: echoModel{}, Memory: mem, Prompts: canary})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cohorts := map[bool]int{}
+	for i := 0; i < 40 && !canary.Status().RolledBack; i++ {
+		session := fmt.Sprintf("session-%d", i)
+		inCandidate := canary.InCandidate(session)
+		cohorts[inCandidate]++
+		out, err := a.Generate(ctx, session, "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(fmt.Sprint(out), "CANDIDATE"); got != inCandidate {
+			t.Fatalf("%s: candidate prompt used = %v, cohort = %v", session, got, inCandidate)
+		}
+	}
+
+	status := canary.Status()
+	if !status.RolledBack || len(rolledBack) != 1 {
+		t.Fatalf("candidate scoring 0 against 1 should roll back: %+v (cohorts %v)", status, cohorts)
+	}
+	if status.Candidate.Mean != 0 || status.Stable.Mean != 1 || status.Candidate.Samples < 3 {
+		t.Fatalf("status = %+v", status)
+	}
+	for i := 0; i < 10; i++ {
+		if p := canary.SystemPrompt(fmt.Sprintf("session-%d", i)); p != "STABLE prompt" {
+			t.Fatalf("after rollback session-%d got %q", i, p)
+		}
+	}
+
+	if err := canary.Promote(); err == nil || canary.SystemPrompt("any") != "STABLE prompt" {
+		t.Fatal("a rolled-back candidate should not be promotable")
+	}
+}
+
+func TestCanaryCountsFeedback(t *testing.T) {
+	c := &Canary{Stable: "s", Candidate: "c", Percent: 100, MinSamples: 1}
+	_ = c.RecordFeedback(context.Background(), agent.Feedback{Turn: agent.Turn{SessionID: "x"}, Rating: 0})
+	if st := c.Status(); st.Candidate.Samples != 1 || st.Candidate.Mean != 0.5 {
+		t.Fatalf("status = %+v", st)
+	}
+	if err := c.Promote(); err != nil || c.SystemPrompt("x") != "c" || c.Status().Candidate.Samples != 0 {
+		t.Fatalf("promote: %v, prompt %q", err, c.SystemPrompt("x"))
+	}
+}
@@ -0,0 +1,58 @@
+package selfevolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Protocol-Lattice/go-agent/src/selfevolve/eval"
+)
+
+// EvolutionConfig describes how candidate prompts are judged.
+type EvolutionConfig struct {
+	// Evaluators score every case; an eval.LLMJudge typically sits
+	// alongside the rule-based ones.
+	Evaluators []eval.Evaluator
+	// Weights scales each evaluator's mean score in Overall; evaluators
+	// not listed weigh 1.
+	Weights map[string]float64
+}
+
+// OverallScore is the Scores key Score uses for the weighted mean.
+const OverallScore = "overall"
+
+// Score runs every evaluator over cases and returns each evaluator's mean
+// score plus their weighted mean under OverallScore, ready to record as
+// Iteration.Scores.
+func (c EvolutionConfig) Score(ctx context.Context, cases []eval.Case) (map[string]float64, error) {
+	if len(c.Evaluators) == 0 {
+		return nil, errors.New("selfevolve: no evaluators configured")
+	}
+	if len(cases) == 0 {
+		return nil, errors.New("selfevolve: no cases to evaluate")
+	}
+	scores := make(map[string]float64, len(c.Evaluators)+1)
+	var weighted, totalWeight float64
+	for _, ev := range c.Evaluators {
+		var sum float64
+		for i, cs := range cases {
+			res, err := ev.Evaluate(ctx, cs)
+			if err != nil {
+				return nil, fmt.Errorf("selfevolve: %s on case %d: %w", ev.Name(), i, err)
+			}
+			sum += res.Score
+		}
+		mean := sum / float64(len(cases))
+		scores[ev.Name()] = mean
+		w, ok := c.Weights[ev.Name()]
+		if !ok {
+			w = 1
+		}
+		weighted += w * mean
+		totalWeight += w
+	}
+	if totalWeight > 0 {
+		scores[OverallScore] = weighted / totalWeight
+	}
+	return scores, nil
+}
@@ -0,0 +1,159 @@
+package selfevolve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/selfevolve/eval"
+)
+
+// Example is a production turn rated by a user, kept for evaluating
+// candidate prompts.
+type Example struct {
+	SessionID string        `json:"session_id"`
+	TurnID    string        `json:"turn_id"`
+	Prompt    string        `json:"prompt"`
+	Input     string        `json:"input"`
+	Output    string        `json:"output"`
+	Context   []string      `json:"context,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	Rating    float64       `json:"rating"`
+	Comment   string        `json:"comment,omitempty"`
+	At        time.Time     `json:"at"`
+}
+
+// Case converts the example for evaluators. Positively rated outputs become
+// the expected answer; others are left for evaluators to judge afresh.
+func (e Example) Case() eval.Case {
+	c := eval.Case{Input: e.Input, Output: e.Output, Context: e.Context, Latency: e.Latency}
+	if e.Rating > 0 {
+		c.Expected = e.Output
+	}
+	return c
+}
+
+// Dataset collects rated turns. It implements agent.FeedbackSink, so
+// passing it as agent.Options.FeedbackSink fills it from Agent.Feedback.
+// With Path set, examples are appended to that JSON Lines file and
+// reloaded by NewDataset.
+type Dataset struct {
+	Path string
+
+	mu       sync.Mutex
+	examples []Example
+	byTurn   map[string]int
+}
+
+// NewDataset loads examples from path when it exists. An empty path keeps
+// the dataset in memory.
+func NewDataset(path string) (*Dataset, error) {
+	d := &Dataset{Path: path, byTurn: map[string]int{}}
+	if path == "" {
+		return d, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var ex Example
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			return nil, fmt.Errorf("parse dataset %s line %d: %w", path, line, err)
+		}
+		d.add(ex)
+	}
+	return d, scanner.Err()
+}
+
+// RecordFeedback adds the rated turn. Rating the same turn again replaces
+// the earlier verdict.
+func (d *Dataset) RecordFeedback(_ context.Context, fb agent.Feedback) error {
+	ex := Example{
+		SessionID: fb.Turn.SessionID,
+		TurnID:    fb.Turn.ID,
+		Prompt:    fb.Turn.SystemPrompt,
+		Input:     fb.Turn.Input,
+		Output:    fb.Turn.Output,
+		Latency:   fb.Turn.Latency,
+		Rating:    fb.Rating,
+		Comment:   fb.Comment,
+		At:        fb.At,
+	}
+	for _, rec := range fb.Turn.Context {
+		ex.Context = append(ex.Context, rec.Content)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.Path != "" {
+		if err := appendJSONLine(d.Path, ex); err != nil {
+			return err
+		}
+	}
+	d.add(ex)
+	return nil
+}
+
+func (d *Dataset) add(ex Example) {
+	if d.byTurn == nil {
+		d.byTurn = map[string]int{}
+	}
+	if i, ok := d.byTurn[ex.TurnID]; ok && ex.TurnID != "" {
+		d.examples[i] = ex
+		return
+	}
+	d.byTurn[ex.TurnID] = len(d.examples)
+	d.examples = append(d.examples, ex)
+}
+
+// Examples returns a copy of the collected examples in arrival order.
+func (d *Dataset) Examples() []Example {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Example(nil), d.examples...)
+}
+
+// Cases returns evaluation cases for examples rated at least minRating.
+func (d *Dataset) Cases(minRating float64) []eval.Case {
+	var cases []eval.Case
+	for _, ex := range d.Examples() {
+		if ex.Rating >= minRating {
+			cases = append(cases, ex.Case())
+		}
+	}
+	return cases
+}
+
+func appendJSONLine(path string, v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var _ agent.FeedbackSink = (*Dataset)(nil)
@@ -0,0 +1,98 @@
+package selfevolve
+
+import "strings"
+
+// RowKind classifies one row of a side-by-side diff.
+type RowKind string
+
+const (
+	RowEqual   RowKind = "equal"
+	RowChanged RowKind = "changed"
+	RowAdded   RowKind = "added"
+	RowRemoved RowKind = "removed"
+)
+
+// DiffRow is one line of a side-by-side diff. Left is empty for added rows
+// and Right for removed ones.
+type DiffRow struct {
+	Kind  RowKind `json:"kind"`
+	Left  string  `json:"left,omitempty"`
+	Right string  `json:"right,omitempty"`
+}
+
+// DiffLines compares before and after line by line. Runs of removed lines
+// directly followed by added lines are paired up as changed rows so edits
+// line up side by side.
+func DiffLines(before, after string) []DiffRow {
+	a, b := splitLines(before), splitLines(after)
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var (
+		rows    []DiffRow
+		removed []string
+		added   []string
+	)
+	flush := func() {
+		n := min(len(removed), len(added))
+		for k := 0; k < n; k++ {
+			rows = append(rows, DiffRow{Kind: RowChanged, Left: removed[k], Right: added[k]})
+		}
+		for _, l := range removed[n:] {
+			rows = append(rows, DiffRow{Kind: RowRemoved, Left: l})
+		}
+		for _, r := range added[n:] {
+			rows = append(rows, DiffRow{Kind: RowAdded, Right: r})
+		}
+		removed, added = removed[:0], added[:0]
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			rows = append(rows, DiffRow{Kind: RowEqual, Left: a[i], Right: b[j]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, b[j])
+			j++
+		default:
+			removed = append(removed, a[i])
+			i++
+		}
+	}
+	flush()
+	return rows
+}
+
+// Changed reports whether any row differs.
+func Changed(rows []DiffRow) bool {
+	for _, r := range rows {
+		if r.Kind != RowEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,104 @@
+// Package eval scores agent responses for prompt optimisation. Evaluators
+// return a score in [0, 1], higher is better, so they can be averaged and
+// compared across iterations.
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// Case is one response under evaluation together with what is known about
+// how it was produced.
+type Case struct {
+	Input    string
+	Output   string
+	Expected string
+	// Context holds the retrieved passages the response should rely on.
+	Context []string
+	Latency time.Duration
+	// Cost is the spend of producing the response, in the caller's unit.
+	Cost float64
+}
+
+// Result is an evaluator's verdict on one case.
+type Result struct {
+	Score  float64
+	Reason string
+}
+
+// Evaluator scores a case.
+type Evaluator interface {
+	Name() string
+	Evaluate(ctx context.Context, c Case) (Result, error)
+}
+
+// LLMJudge asks a model to grade the response against Criteria on a 0–10
+// scale.
+type LLMJudge struct {
+	Model    models.Agent
+	Criteria string
+}
+
+const defaultJudgeCriteria = "Is the response correct, helpful and responsive to the input?"
+
+const judgePrompt = `You are grading an assistant's response.
+Criteria: %s
+
+Input:
+<input>
+%s
+</input>
+%s
+Response:
+<response>
+%s
+</response>
+
+Reply with a single integer from 0 (fails the criteria) to 10 (fully meets them), then a short reason.`
+
+func (j *LLMJudge) Name() string { return "llm_judge" }
+
+func (j *LLMJudge) Evaluate(ctx context.Context, c Case) (Result, error) {
+	if j.Model == nil {
+		return Result{}, fmt.Errorf("llm judge has no model")
+	}
+	criteria := j.Criteria
+	if criteria == "" {
+		criteria = defaultJudgeCriteria
+	}
+	expected := ""
+	if c.Expected != "" {
+		expected = "\nReference answer:\n<reference>\n" + fence(c.Expected) + "\n</reference>\n"
+	}
+	raw, err := j.Model.Generate(ctx, fmt.Sprintf(judgePrompt, criteria, fence(c.Input), expected, fence(c.Output)))
+	if err != nil {
+		return Result{}, fmt.Errorf("llm judge: %w", err)
+	}
+	verdict := strings.TrimSpace(fmt.Sprint(raw))
+	m := leadingNumber.FindStringSubmatch(verdict)
+	if m == nil {
+		return Result{}, fmt.Errorf("llm judge: no grade in %q", verdict)
+	}
+	grade, _ := strconv.ParseFloat(m[1], 64)
+	return Result{Score: clamp(grade / 10), Reason: strings.TrimSpace(verdict[len(m[0]):])}, nil
+}
+
+var leadingNumber = regexp.MustCompile(`^\D*?(\d+(?:\.\d+)?)`)
+
+// fence keeps graded text from closing the tags that delimit it.
+func fence(s string) string {
+	return strings.NewReplacer("</", "< /").Replace(s)
+}
+
+func clamp(v float64) float64 {
+	return min(max(v, 0), 1)
+}
+
+var _ Evaluator = (*LLMJudge)(nil)
@@ -0,0 +1,74 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+type fixedModel string
+
+func (m fixedModel) Generate(context.Context, string) (any, error) { return string(m), nil }
+func (m fixedModel) GenerateWithFiles(context.Context, string, []models.File) (any, error) {
+	return string(m), nil
+}
+func (m fixedModel) GenerateStream(context.Context, string) (<-chan models.StreamChunk, error) {
+	return nil, errors.New("not streaming")
+}
+
+type rejectAll struct{}
+
+func (rejectAll) Validate(context.Context, string) error { return errors.New("flagged") }
+
+func TestEvaluators(t *testing.T) {
+	ctx := context.Background()
+	grounded := Case{
+		Output:  "Refunds are processed within five business days. Our office is on the moon.",
+		Context: []string{"All refunds are processed within five business days of approval."},
+	}
+	cases := []struct {
+		name string
+		ev   Evaluator
+		c    Case
+		want float64
+	}{
+		{"grounded half", &Groundedness{}, grounded, 0.5},
+		{"no claims", &Groundedness{}, Case{Output: "Ok."}, 1},
+		{"toxic term", &Toxicity{}, Case{Output: "That was a STUPID question."}, 0},
+		{"clean", &Toxicity{}, Case{Output: "Happy to help."}, 1},
+		{"policy", &Toxicity{Terms: []string{}, Policies: []agent.SafetyPolicy{rejectAll{}}}, Case{Output: "hi"}, 0},
+		{"json keys", &JSONFormat{Required: []string{"answer", "sources"}}, Case{Output: `{"answer":"yes"}`}, 0.5},
+		{"json fenced", &JSONFormat{AllowFences: true}, Case{Output: "```json\n{\"a\":1}\n```"}, 1},
+		{"json invalid", &JSONFormat{}, Case{Output: "yes"}, 0},
+		{"regex", &RegexFormat{Pattern: regexp.MustCompile(`^TICKET-\d+$`)}, Case{Output: "TICKET-42"}, 1},
+		{"latency ok", &Latency{Target: time.Second, Max: 3 * time.Second}, Case{Latency: 800 * time.Millisecond}, 1},
+		{"latency slow", &Latency{Target: time.Second, Max: 3 * time.Second}, Case{Latency: 2 * time.Second}, 0.5},
+		{"cost over", &Cost{Target: 0.01}, Case{Cost: 0.02}, 0},
+		{"judge", &LLMJudge{Model: fixedModel("8 - cites the policy")}, Case{Input: "q", Output: "a"}, 0.8},
+	}
+	for _, tc := range cases {
+		res, err := tc.ev.Evaluate(ctx, tc.c)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if math.Abs(res.Score-tc.want) > 1e-9 {
+			t.Errorf("%s: score %v, want %v (%s)", tc.name, res.Score, tc.want, res.Reason)
+		}
+	}
+
+	res, _ := (&Groundedness{}).Evaluate(ctx, grounded)
+	if !strings.Contains(res.Reason, "moon") {
+		t.Errorf("groundedness should name the unsupported sentence: %q", res.Reason)
+	}
+	if _, err := (&LLMJudge{Model: fixedModel("looks fine")}).Evaluate(ctx, Case{}); err == nil {
+		t.Error("judge without a grade should fail")
+	}
+}
@@ -0,0 +1,97 @@
+// Package selfevolve records prompt optimisation runs and renders them for
+// review. Each iteration of a run keeps the prompt it tried, the evaluator
+// scores it earned and sample responses, so a human can see what the
+// optimizer changed before a candidate is promoted.
+package selfevolve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Sample is one evaluation input and the response the iteration's prompt
+// produced for it.
+type Sample struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// Iteration is one prompt version tried by the optimizer.
+type Iteration struct {
+	Number int    `json:"number"`
+	Prompt string `json:"prompt"`
+	// Scores maps evaluator name to score; higher is better.
+	Scores   map[string]float64 `json:"scores,omitempty"`
+	Samples  []Sample           `json:"samples,omitempty"`
+	Note     string             `json:"note,omitempty"`
+	Promoted bool               `json:"promoted,omitempty"`
+	Created  time.Time          `json:"created"`
+}
+
+// History is the ordered record of one optimisation run.
+type History struct {
+	Name       string      `json:"name"`
+	Iterations []Iteration `json:"iterations"`
+}
+
+// Append adds it, numbering it after the last iteration when its Number is
+// unset.
+func (h *History) Append(it Iteration) Iteration {
+	if it.Number == 0 {
+		it.Number = len(h.Iterations)
+		if n := len(h.Iterations); n > 0 {
+			it.Number = h.Iterations[n-1].Number + 1
+		}
+	}
+	if it.Created.IsZero() {
+		it.Created = time.Now().UTC()
+	}
+	h.Iterations = append(h.Iterations, it)
+	return it
+}
+
+// Iteration returns the iteration numbered n.
+func (h *History) Iteration(n int) (Iteration, bool) {
+	for _, it := range h.Iterations {
+		if it.Number == n {
+			return it, true
+		}
+	}
+	return Iteration{}, false
+}
+
+// LoadHistory reads a history written by Save.
+func LoadHistory(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("parse history %s: %w", path, err)
+	}
+	return &h, nil
+}
+
+// Save writes the history as JSON, replacing path atomically.
+func (h *History) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*")
+	if err != nil {
+		return err
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if err := errors.Join(werr, cerr); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,238 @@
+package selfevolve
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ScoreDelta is one evaluator's score before and after an iteration. Before
+// or After is NaN when the evaluator did not score that side.
+type ScoreDelta struct {
+	Evaluator string  `json:"evaluator"`
+	Before    float64 `json:"before"`
+	After     float64 `json:"after"`
+	Delta     float64 `json:"delta"`
+}
+
+// Regressed reports whether the score went down.
+func (d ScoreDelta) Regressed() bool { return d.Delta < 0 }
+
+// ResponseDiff compares the responses to one sample input.
+type ResponseDiff struct {
+	Input string    `json:"input"`
+	Rows  []DiffRow `json:"rows"`
+}
+
+// Comparison is the review of one iteration against an earlier one.
+type Comparison struct {
+	From      Iteration      `json:"from"`
+	To        Iteration      `json:"to"`
+	Prompt    []DiffRow      `json:"prompt"`
+	Scores    []ScoreDelta   `json:"scores"`
+	Responses []ResponseDiff `json:"responses,omitempty"`
+}
+
+// Compare diffs the prompts of from and to, the scores of every evaluator
+// either recorded, and the responses to inputs both sampled whose output
+// changed.
+func Compare(from, to Iteration) Comparison {
+	c := Comparison{From: from, To: to, Prompt: DiffLines(from.Prompt, to.Prompt)}
+
+	names := map[string]bool{}
+	for name := range from.Scores {
+		names[name] = true
+	}
+	for name := range to.Scores {
+		names[name] = true
+	}
+	for name := range names {
+		d := ScoreDelta{Evaluator: name, Before: math.NaN(), After: math.NaN(), Delta: math.NaN()}
+		if v, ok := from.Scores[name]; ok {
+			d.Before = v
+		}
+		if v, ok := to.Scores[name]; ok {
+			d.After = v
+		}
+		if !math.IsNaN(d.Before) && !math.IsNaN(d.After) {
+			d.Delta = d.After - d.Before
+		}
+		c.Scores = append(c.Scores, d)
+	}
+	sort.Slice(c.Scores, func(i, j int) bool { return c.Scores[i].Evaluator < c.Scores[j].Evaluator })
+
+	before := make(map[string]string, len(from.Samples))
+	for _, s := range from.Samples {
+		before[s.Input] = s.Output
+	}
+	for _, s := range to.Samples {
+		old, ok := before[s.Input]
+		if !ok || old == s.Output {
+			continue
+		}
+		c.Responses = append(c.Responses, ResponseDiff{Input: s.Input, Rows: DiffLines(old, s.Output)})
+	}
+	return c
+}
+
+// Report reviews a stretch of a history: each iteration against the one
+// before it and, when the stretch spans several steps, the last against the
+// first.
+type Report struct {
+	Name    string       `json:"name"`
+	Steps   []Comparison `json:"steps"`
+	Overall *Comparison  `json:"overall,omitempty"`
+}
+
+// Report compares iterations numbered from through to. A negative to means
+// the last iteration.
+func (h *History) Report(from, to int) (*Report, error) {
+	if to < 0 && len(h.Iterations) > 0 {
+		to = h.Iterations[len(h.Iterations)-1].Number
+	}
+	var span []Iteration
+	for _, it := range h.Iterations {
+		if it.Number >= from && it.Number <= to {
+			span = append(span, it)
+		}
+	}
+	if len(span) < 2 {
+		return nil, fmt.Errorf("selfevolve: need at least two iterations between %d and %d, found %d", from, to, len(span))
+	}
+	r := &Report{Name: h.Name}
+	for i := 1; i < len(span); i++ {
+		r.Steps = append(r.Steps, Compare(span[i-1], span[i]))
+	}
+	if len(span) > 2 {
+		overall := Compare(span[0], span[len(span)-1])
+		r.Overall = &overall
+	}
+	return r, nil
+}
+
+// WriteMarkdown renders the report as GitHub-flavoured Markdown tables.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	title := "Prompt evolution report"
+	if r.Name != "" {
+		title += ": " + r.Name
+	}
+	fmt.Fprintf(&sb, "# %s\n", title)
+	if r.Overall != nil {
+		sb.WriteString("\n## Overall\n")
+		writeComparisonMarkdown(&sb, *r.Overall)
+	}
+	for _, c := range r.Steps {
+		fmt.Fprintf(&sb, "\n## Iteration %d → %d\n", c.From.Number, c.To.Number)
+		writeComparisonMarkdown(&sb, c)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeComparisonMarkdown(sb *strings.Builder, c Comparison) {
+	from, to := fmt.Sprintf("#%d", c.From.Number), fmt.Sprintf("#%d", c.To.Number)
+	if c.To.Note != "" {
+		fmt.Fprintf(sb, "\n%s\n", c.To.Note)
+	}
+	if len(c.Scores) > 0 {
+		fmt.Fprintf(sb, "\n| Evaluator | %s | %s | Δ |\n|---|---:|---:|---:|\n", from, to)
+		for _, d := range c.Scores {
+			fmt.Fprintf(sb, "| %s | %s | %s | %s |\n", mdCell(d.Evaluator), formatScore(d.Before), formatScore(d.After), formatDelta(d.Delta))
+		}
+	}
+	sb.WriteString("\n**Prompt**\n")
+	writeRowsMarkdown(sb, c.Prompt, from, to)
+	for _, resp := range c.Responses {
+		fmt.Fprintf(sb, "\n**Response to** %s\n", mdCell(resp.Input))
+		writeRowsMarkdown(sb, resp.Rows, from, to)
+	}
+}
+
+func writeRowsMarkdown(sb *strings.Builder, rows []DiffRow, from, to string) {
+	if !Changed(rows) {
+		sb.WriteString("\n_unchanged_\n")
+		return
+	}
+	fmt.Fprintf(sb, "\n|   | %s | %s |\n|---|---|---|\n", from, to)
+	for _, row := range rows {
+		fmt.Fprintf(sb, "| %s | %s | %s |\n", rowMarker[row.Kind], mdCell(row.Left), mdCell(row.Right))
+	}
+}
+
+var rowMarker = map[RowKind]string{RowEqual: " ", RowChanged: "~", RowAdded: "+", RowRemoved: "-"}
+
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", " ")
+	if strings.TrimSpace(s) == "" {
+		return " "
+	}
+	return s
+}
+
+func formatScore(v float64) string {
+	if math.IsNaN(v) {
+		return "—"
+	}
+	return fmt.Sprintf("%.3f", v)
+}
+
+func formatDelta(v float64) string {
+	if math.IsNaN(v) {
+		return "—"
+	}
+	return fmt.Sprintf("%+.3f", v)
+}
+
+// WriteHTML renders the report as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return reportHTML.Execute(w, r)
+}
+
+var reportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"score":   formatScore,
+	"delta":   formatDelta,
+	"changed": Changed,
+	"deltaClass": func(d ScoreDelta) string {
+		switch {
+		case d.Delta > 0:
+			return "up"
+		case d.Delta < 0:
+			return "down"
+		}
+		return ""
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8">
+<title>Prompt evolution report{{if .Name}}: {{.Name}}{{end}}</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:2rem;color:#222}
+table{border-collapse:collapse;margin:.5rem 0 1.5rem;width:100%}
+td,th{border:1px solid #ddd;padding:.25rem .5rem;vertical-align:top}
+td.line{font-family:ui-monospace,monospace;white-space:pre-wrap;width:50%}
+tr.added td.right,tr.changed td.right{background:#e6ffec}
+tr.removed td.left,tr.changed td.left{background:#ffebe9}
+td.up{color:#1a7f37}td.down{color:#cf222e;font-weight:bold}
+.scores td:not(:first-child){text-align:right}
+</style></head><body>
+<h1>Prompt evolution report{{if .Name}}: {{.Name}}{{end}}</h1>
+{{if .Overall}}<h2>Overall</h2>{{template "comparison" .Overall}}{{end}}
+{{range .Steps}}<h2>Iteration {{.From.Number}} → {{.To.Number}}</h2>{{template "comparison" .}}{{end}}
+</body></html>
+{{define "comparison"}}
+{{if .To.Note}}<p>{{.To.Note}}</p>{{end}}
+{{if .Scores}}<table class="scores"><tr><th>Evaluator</th><th>#{{.From.Number}}</th><th>#{{.To.Number}}</th><th>Δ</th></tr>
+{{range .Scores}}<tr><td>{{.Evaluator}}</td><td>{{score .Before}}</td><td>{{score .After}}</td><td class="{{deltaClass .}}">{{delta .Delta}}</td></tr>
+{{end}}</table>{{end}}
+<h3>Prompt</h3>{{template "rows" .Prompt}}
+{{range .Responses}}<h3>Response to “{{.Input}}”</h3>{{template "rows" .Rows}}{{end}}
+{{end}}
+{{define "rows"}}{{if changed .}}<table>
+{{range .}}<tr class="{{.Kind}}"><td class="line left">{{.Left}}</td><td class="line right">{{.Right}}</td></tr>
+{{end}}</table>{{else}}<p><em>unchanged</em></p>{{end}}{{end}}
+`))
@@ -0,0 +1,123 @@
+package selfevolve
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/selfevolve/eval"
+)
+
+func TestDiffLinesPairsEdits(t *testing.T) {
+	rows := DiffLines("You are helpful.\nBe brief.\nCite sources.", "You are helpful.\nBe thorough.\nCite sources.\nUse bullet points.")
+	kinds := make([]string, len(rows))
+	for i, r := range rows {
+		kinds[i] = string(r.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "equal,changed,equal,added" {
+		t.Fatalf("kinds = %s", got)
+	}
+	if rows[1].Left != "Be brief." || rows[1].Right != "Be thorough." {
+		t.Fatalf("changed row = %+v", rows[1])
+	}
+}
+
+func TestHistoryReport(t *testing.T) {
+	h := &History{Name: "support"}
+	h.Append(Iteration{Prompt: "Answer questions.", Scores: map[string]float64{"judge": 0.6, "latency": 0.9},
+		Samples: []Sample{{Input: "refund?", Output: "No."}}})
+	h.Append(Iteration{Prompt: "Answer questions.\nBe polite.", Scores: map[string]float64{"judge": 0.8, "latency": 0.7},
+		Samples: []Sample{{Input: "refund?", Output: "Sorry, refunds | returns take 5 days."}}, Note: "added tone"})
+	h.Append(Iteration{Prompt: "Answer questions politely.", Scores: map[string]float64{"judge": 0.85, "groundedness": 1}})
+
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := h.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := loaded.Report(0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Steps) != 2 || r.Overall == nil || r.Overall.To.Number != 2 {
+		t.Fatalf("report = %+v", r)
+	}
+	first := r.Steps[0]
+	if len(first.Scores) != 2 || first.Scores[0].Evaluator != "judge" || !first.Scores[1].Regressed() {
+		t.Fatalf("scores = %+v", first.Scores)
+	}
+	if len(first.Responses) != 1 {
+		t.Fatalf("responses = %+v", first.Responses)
+	}
+
+	var md strings.Builder
+	if err := r.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Prompt evolution report: support", "## Iteration 0 → 1", "| judge | 0.600 | 0.800 | +0.200 |",
+		"| + |   | Be polite. |", `refunds \| returns`, "| groundedness | — | 1.000 | — |"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var html strings.Builder
+	if err := r.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<td class="down">-0.200</td>`) || !strings.Contains(html.String(), `<tr class="added">`) {
+		t.Fatalf("html missing score delta or diff rows:\n%s", html.String())
+	}
+
+	if _, err := loaded.Report(5, 9); err == nil {
+		t.Fatal("a range with fewer than two iterations should fail")
+	}
+}
+
+func TestEvolutionConfigScore(t *testing.T) {
+	cfg := EvolutionConfig{
+		Evaluators: []eval.Evaluator{&eval.JSONFormat{}, &eval.Latency{Target: time.Second}},
+		Weights:    map[string]float64{"latency": 3},
+	}
+	scores, err := cfg.Score(context.Background(), []eval.Case{
+		{Output: `{"ok":true}`, Latency: 500 * time.Millisecond},
+		{Output: "nope", Latency: 2 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scores["format_json"] != 0.5 || scores["latency"] != 0.5 || scores[OverallScore] != 0.5 {
+		t.Fatalf("scores = %v", scores)
+	}
+}
+
+func TestDatasetCollectsFeedback(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "feedback.jsonl")
+	d, err := NewDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := agent.Turn{ID: "t1", SessionID: "s", Input: "q", Output: "a", SystemPrompt: "be nice"}
+	_ = d.RecordFeedback(ctx, agent.Feedback{Turn: turn, Rating: -1})
+	_ = d.RecordFeedback(ctx, agent.Feedback{Turn: turn, Rating: 1, Comment: "changed my mind"})
+	_ = d.RecordFeedback(ctx, agent.Feedback{Turn: agent.Turn{ID: "t2", Input: "q2", Output: "bad"}, Rating: -0.5})
+
+	reloaded, err := NewDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(reloaded.Examples()); n != 2 {
+		t.Fatalf("re-rating a turn should replace it, got %d examples", n)
+	}
+	cases := reloaded.Cases(0)
+	if len(cases) != 1 || cases[0].Expected != "a" {
+		t.Fatalf("cases = %+v", cases)
+	}
+}
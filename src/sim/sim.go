@@ -0,0 +1,336 @@
+// Package sim load-tests agents and swarms with synthetic users. A Runner
+// plays many episodes of a Scenario — each a conversation between a scripted
+// or model-played user and the agents under test — and reports task
+// completion, tool usage and memory growth, so memory and routing policies
+// can be compared before they meet real traffic.
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/swarm"
+)
+
+// DefaultMaxTurns bounds an episode whose scenario sets no limit.
+const DefaultMaxTurns = 10
+
+// Responder is an agent under test.
+type Responder interface {
+	Respond(ctx context.Context, sessionID, input string) (string, error)
+}
+
+// ResponderFunc adapts a function to Responder.
+type ResponderFunc func(ctx context.Context, sessionID, input string) (string, error)
+
+func (f ResponderFunc) Respond(ctx context.Context, sessionID, input string) (string, error) {
+	return f(ctx, sessionID, input)
+}
+
+// FromAgent makes an agent a Responder.
+func FromAgent(a *agent.Agent) Responder {
+	return ResponderFunc(func(ctx context.Context, sessionID, input string) (string, error) {
+		out, err := a.Generate(ctx, sessionID, input)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(out), nil
+	})
+}
+
+// FromSwarm makes each swarm participant a Responder, keyed by participant
+// ID. Episodes run in their own sessions, derived from the participant's.
+func FromSwarm(s *swarm.Swarm) map[string]Responder {
+	out := map[string]Responder{}
+	if s == nil || s.Participants == nil {
+		return out
+	}
+	for id, p := range *s.Participants {
+		if p == nil || p.Agent == nil {
+			continue
+		}
+		conv := p.Agent
+		out[id] = ResponderFunc(func(ctx context.Context, sessionID, input string) (string, error) {
+			return conv.Generate(ctx, sessionID, input)
+		})
+	}
+	return out
+}
+
+// Message is one line of an episode's conversation.
+type Message struct {
+	// Role is "user" or the ID of the agent that answered.
+	Role    string        `json:"role"`
+	Content string        `json:"content"`
+	Latency time.Duration `json:"latency_ns,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Router picks the agent that answers the next user message. The default
+// sends everything to the first agent by ID.
+type Router func(episode int, history []Message, agents []string) string
+
+// RoundRobin rotates through the agents turn by turn.
+func RoundRobin(_ int, history []Message, agents []string) string {
+	turn := 0
+	for _, m := range history {
+		if m.Role == "user" {
+			turn++
+		}
+	}
+	return agents[(turn-1+len(agents))%len(agents)]
+}
+
+// Scenario describes the conversations to simulate.
+type Scenario struct {
+	Name string
+	// NewUser returns a fresh synthetic user for an episode.
+	NewUser func(episode int) User
+	// MaxTurns bounds the user messages per episode; DefaultMaxTurns when 0.
+	MaxTurns int
+	// Completed decides whether an episode achieved its task. Without it an
+	// episode counts as completed when the user ended it before MaxTurns and
+	// no turn failed.
+	Completed func(Episode) bool
+}
+
+// Episode is the outcome of one simulated conversation.
+type Episode struct {
+	Index     int            `json:"index"`
+	Session   string         `json:"session"`
+	Messages  []Message      `json:"messages"`
+	Turns     int            `json:"turns"`
+	Completed bool           `json:"completed"`
+	ToolCalls map[string]int `json:"tool_calls,omitempty"`
+	Errors    int            `json:"errors"`
+	Duration  time.Duration  `json:"duration_ns"`
+	// Err is set when the synthetic user itself failed.
+	Err string `json:"err,omitempty"`
+}
+
+// Transcript renders the episode as plain text.
+func (e Episode) Transcript() string {
+	var sb strings.Builder
+	for _, m := range e.Messages {
+		fmt.Fprintf(&sb, "%s: %s\n", m.Role, m.Content)
+	}
+	return sb.String()
+}
+
+// Report aggregates a run.
+type Report struct {
+	Scenario       string         `json:"scenario"`
+	Episodes       []Episode      `json:"episodes"`
+	Completed      int            `json:"completed"`
+	CompletionRate float64        `json:"completion_rate"`
+	AvgTurns       float64        `json:"avg_turns"`
+	AvgLatency     time.Duration  `json:"avg_latency_ns"`
+	ToolCalls      map[string]int `json:"tool_calls"`
+	Errors         int            `json:"errors"`
+	// MemoryBefore and MemoryAfter count the records in Runner.Memory.
+	MemoryBefore int `json:"memory_before"`
+	MemoryAfter  int `json:"memory_after"`
+}
+
+// MemoryGrowth is the number of records the run added.
+func (r Report) MemoryGrowth() int { return r.MemoryAfter - r.MemoryBefore }
+
+// String summarises the report on one line.
+func (r Report) String() string {
+	return fmt.Sprintf("%s: %d episodes, %.0f%% completed, %.1f turns avg, %s avg latency, %d tool calls, %d errors, +%d memories",
+		r.Scenario, len(r.Episodes), r.CompletionRate*100, r.AvgTurns, r.AvgLatency.Round(time.Millisecond),
+		totalCalls(r.ToolCalls), r.Errors, r.MemoryGrowth())
+}
+
+// Iterator is the part of a memory store the runner counts.
+type Iterator interface {
+	Iterate(ctx context.Context, fn func(memory.MemoryRecord) bool) error
+}
+
+// Runner plays episodes of a scenario against agents.
+type Runner struct {
+	Agents map[string]Responder
+	Router Router
+	// Memory, when set, is counted before and after the run.
+	Memory Iterator
+	// Concurrency is how many episodes run at once; 1 when 0.
+	Concurrency int
+	// SessionPrefix names episode sessions "<prefix>-<n>"; "sim" when empty.
+	SessionPrefix string
+}
+
+// Run plays episodes episodes of sc. Errors from the agents are counted in
+// the report rather than stopping the run.
+func (r *Runner) Run(ctx context.Context, sc Scenario, episodes int) (Report, error) {
+	if len(r.Agents) == 0 {
+		return Report{}, errors.New("sim: no agents to test")
+	}
+	if sc.NewUser == nil {
+		return Report{}, errors.New("sim: scenario has no user")
+	}
+	report := Report{Scenario: sc.Name, ToolCalls: map[string]int{}}
+	if r.Memory != nil {
+		n, err := countMemory(ctx, r.Memory)
+		if err != nil {
+			return Report{}, err
+		}
+		report.MemoryBefore = n
+	}
+
+	workers := r.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	report.Episodes = make([]Episode, episodes)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Episodes[i] = r.episode(ctx, sc, i)
+			}
+		}()
+	}
+	for i := 0; i < episodes && ctx.Err() == nil; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return Report{}, err
+	}
+
+	var turns int
+	var latency time.Duration
+	var answered int
+	for _, ep := range report.Episodes {
+		turns += ep.Turns
+		report.Errors += ep.Errors
+		if ep.Completed {
+			report.Completed++
+		}
+		for tool, n := range ep.ToolCalls {
+			report.ToolCalls[tool] += n
+		}
+		for _, m := range ep.Messages {
+			if m.Role != "user" {
+				latency += m.Latency
+				answered++
+			}
+		}
+	}
+	if episodes > 0 {
+		report.CompletionRate = float64(report.Completed) / float64(episodes)
+		report.AvgTurns = float64(turns) / float64(episodes)
+	}
+	if answered > 0 {
+		report.AvgLatency = latency / time.Duration(answered)
+	}
+	if r.Memory != nil {
+		n, err := countMemory(ctx, r.Memory)
+		if err != nil {
+			return report, err
+		}
+		report.MemoryAfter = n
+	}
+	return report, nil
+}
+
+func (r *Runner) episode(ctx context.Context, sc Scenario, index int) Episode {
+	prefix := r.SessionPrefix
+	if prefix == "" {
+		prefix = "sim"
+	}
+	ep := Episode{Index: index, Session: fmt.Sprintf("%s-%d", prefix, index), ToolCalls: map[string]int{}}
+	maxTurns := sc.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = DefaultMaxTurns
+	}
+	ids := make([]string, 0, len(r.Agents))
+	for id := range r.Agents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	route := r.Router
+	if route == nil {
+		route = func(int, []Message, []string) string { return ids[0] }
+	}
+
+	var mu sync.Mutex
+	ctx = agent.ContextWithToolEvents(ctx, func(ev agent.ToolEvent) {
+		if ev.Type == agent.ToolEventStart {
+			mu.Lock()
+			ep.ToolCalls[ev.Tool]++
+			mu.Unlock()
+		}
+	})
+
+	started := time.Now()
+	user := sc.NewUser(index)
+	ended := false
+	for ep.Turns < maxTurns {
+		msg, done, err := user.Next(ctx, ep.Messages)
+		if err != nil {
+			ep.Err = err.Error()
+			break
+		}
+		if done {
+			ended = true
+			break
+		}
+		ep.Messages = append(ep.Messages, Message{Role: "user", Content: msg})
+		ep.Turns++
+
+		id := route(index, ep.Messages, ids)
+		responder, ok := r.Agents[id]
+		if !ok {
+			ep.Errors++
+			ep.Messages = append(ep.Messages, Message{Role: id, Error: "unknown agent"})
+			continue
+		}
+		turnStart := time.Now()
+		reply, err := responder.Respond(ctx, ep.Session, msg)
+		answer := Message{Role: id, Content: reply, Latency: time.Since(turnStart)}
+		if err != nil {
+			ep.Errors++
+			answer.Error = err.Error()
+		}
+		ep.Messages = append(ep.Messages, answer)
+	}
+	ep.Duration = time.Since(started)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sc.Completed != nil {
+		ep.Completed = sc.Completed(ep)
+	} else {
+		ep.Completed = ended && ep.Errors == 0 && ep.Err == ""
+	}
+	return ep
+}
+
+func countMemory(ctx context.Context, it Iterator) (int, error) {
+	n := 0
+	err := it.Iterate(ctx, func(memory.MemoryRecord) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+func totalCalls(calls map[string]int) int {
+	n := 0
+	for _, c := range calls {
+		n += c
+	}
+	return n
+}
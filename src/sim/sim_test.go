@@ -0,0 +1,134 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+type countingMemory struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (m *countingMemory) add() {
+	m.mu.Lock()
+	m.n++
+	m.mu.Unlock()
+}
+
+func (m *countingMemory) Iterate(_ context.Context, fn func(memory.MemoryRecord) bool) error {
+	m.mu.Lock()
+	n := m.n
+	m.mu.Unlock()
+	for i := 0; i < n; i++ {
+		if !fn(memory.MemoryRecord{}) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestRunnerCollectsMetrics(t *testing.T) {
+	mem := &countingMemory{}
+	support := ResponderFunc(func(ctx context.Context, sessionID, input string) (string, error) {
+		mem.add()
+		if strings.Contains(input, "order") {
+			if emit, ok := agent.ToolEventsFromContext(ctx); ok {
+				emit(agent.ToolEvent{Type: agent.ToolEventStart, SessionID: sessionID, Tool: "orders.lookup"})
+			}
+			return "your order ships tomorrow", nil
+		}
+		if input == "break" {
+			return "", errors.New("boom")
+		}
+		return "hello", nil
+	})
+	r := &Runner{Agents: map[string]Responder{"support": support}, Memory: mem, Concurrency: 3}
+	sc := Scenario{
+		Name:    "orders",
+		NewUser: Scripts(Script{"hi", "where is my order"}, Script{"break"}),
+	}
+
+	report, err := r.Run(context.Background(), sc, 4)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Episodes) != 4 || report.Completed != 2 || report.CompletionRate != 0.5 {
+		t.Fatalf("unexpected completion: %+v", report)
+	}
+	if report.ToolCalls["orders.lookup"] != 2 || report.Errors != 2 {
+		t.Fatalf("unexpected tool calls or errors: %v, %d", report.ToolCalls, report.Errors)
+	}
+	if report.AvgTurns != 1.5 || report.MemoryGrowth() != 6 {
+		t.Fatalf("unexpected turns or memory growth: %v, %d", report.AvgTurns, report.MemoryGrowth())
+	}
+	if ep := report.Episodes[0]; ep.Session != "sim-0" || !strings.Contains(ep.Transcript(), "support: your order ships tomorrow") {
+		t.Fatalf("unexpected episode %+v", ep)
+	}
+}
+
+func TestRoundRobinRouting(t *testing.T) {
+	seen := map[string]int{}
+	var mu sync.Mutex
+	reply := func(id string) Responder {
+		return ResponderFunc(func(context.Context, string, string) (string, error) {
+			mu.Lock()
+			seen[id]++
+			mu.Unlock()
+			return id, nil
+		})
+	}
+	r := &Runner{Agents: map[string]Responder{"a": reply("a"), "b": reply("b")}, Router: RoundRobin}
+	report, err := r.Run(context.Background(), Scenario{NewUser: Scripts(Script{"1", "2", "3"})}, 1)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if seen["a"] != 2 || seen["b"] != 1 {
+		t.Fatalf("unexpected routing %v", seen)
+	}
+	if got := report.Episodes[0].Messages[3].Role; got != "b" {
+		t.Fatalf("second answer should come from b, got %s", got)
+	}
+}
+
+type scriptedModel struct{ replies []string }
+
+func (m *scriptedModel) Generate(context.Context, string) (any, error) {
+	if len(m.replies) == 0 {
+		return DoneToken, nil
+	}
+	r := m.replies[0]
+	m.replies = m.replies[1:]
+	return r, nil
+}
+
+func (m *scriptedModel) GenerateWithFiles(ctx context.Context, p string, _ []models.File) (any, error) {
+	return m.Generate(ctx, p)
+}
+
+func (m *scriptedModel) GenerateStream(context.Context, string) (<-chan models.StreamChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestLLMUserEndsOnDoneToken(t *testing.T) {
+	model := &scriptedModel{replies: []string{"I need a refund"}}
+	r := &Runner{Agents: map[string]Responder{"support": ResponderFunc(func(context.Context, string, string) (string, error) {
+		return "refund issued", nil
+	})}}
+	report, err := r.Run(context.Background(), Scenario{
+		NewUser: func(int) User { return &LLMUser{Model: model, Persona: "impatient customer", Goal: "get a refund"} },
+	}, 1)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if ep := report.Episodes[0]; ep.Turns != 1 || !ep.Completed {
+		t.Fatalf("unexpected episode %+v", ep)
+	}
+}
@@ -0,0 +1,85 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// User is a synthetic user. Next returns the user's next message given the
+// conversation so far, or done when the user would end the conversation.
+type User interface {
+	Next(ctx context.Context, history []Message) (msg string, done bool, err error)
+}
+
+// Script is a user that sends fixed messages in order and then stops.
+type Script []string
+
+func (s Script) Next(_ context.Context, history []Message) (string, bool, error) {
+	sent := 0
+	for _, m := range history {
+		if m.Role == "user" {
+			sent++
+		}
+	}
+	if sent >= len(s) {
+		return "", true, nil
+	}
+	return s[sent], false, nil
+}
+
+// Scripts returns a NewUser that plays the scripts in turn, one per episode.
+func Scripts(scripts ...Script) func(int) User {
+	return func(episode int) User {
+		if len(scripts) == 0 {
+			return Script(nil)
+		}
+		return scripts[episode%len(scripts)]
+	}
+}
+
+// DoneToken is what an LLMUser replies with once its goal is met.
+const DoneToken = "[DONE]"
+
+// LLMUser has a model play a user with a persona and a goal.
+type LLMUser struct {
+	Model   models.Agent
+	Persona string
+	Goal    string
+}
+
+const llmUserPrompt = `You are role-playing a user talking to an AI assistant, to test it.
+Persona: %s
+Your goal: %s
+
+Conversation so far:
+%s
+Write only your next message to the assistant, in character. If your goal has been met, or the assistant clearly cannot help, reply with exactly %s.`
+
+func (u *LLMUser) Next(ctx context.Context, history []Message) (string, bool, error) {
+	if u.Model == nil {
+		return "", false, fmt.Errorf("llm user has no model")
+	}
+	var sb strings.Builder
+	for _, m := range history {
+		role := "Assistant"
+		if m.Role == "user" {
+			role = "You"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", role, m.Content)
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("(nothing yet — open the conversation)\n")
+	}
+	raw, err := u.Model.Generate(ctx, fmt.Sprintf(llmUserPrompt, u.Persona, u.Goal, sb.String(), DoneToken))
+	if err != nil {
+		return "", false, err
+	}
+	msg := strings.TrimSpace(fmt.Sprint(raw))
+	if msg == "" || strings.Contains(msg, DoneToken) {
+		return "", true, nil
+	}
+	return msg, false, nil
+}
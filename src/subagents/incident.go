@@ -0,0 +1,81 @@
+package subagents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/incident"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+var incidentRef = regexp.MustCompile(regexp.QuoteMeta(incident.SpacePrefix) + `([A-Za-z0-9]+)`)
+
+// IncidentResponder is a sub-agent that triages incidents. When the task
+// names an incident as incident:<id> and a PagerDuty client is configured,
+// the incident and its timeline are pulled into the prompt.
+type IncidentResponder struct {
+	model     models.Agent
+	pagerDuty *incident.PagerDuty
+	persona   string
+}
+
+// NewIncidentResponder builds the sub-agent; pagerDuty may be nil.
+func NewIncidentResponder(model models.Agent, pagerDuty *incident.PagerDuty) *IncidentResponder {
+	return &IncidentResponder{
+		model:     model,
+		pagerDuty: pagerDuty,
+		persona:   "You are an incident commander. Stay factual, separate what is known from what is assumed, and prioritise mitigation over root cause.",
+	}
+}
+
+func (r *IncidentResponder) Name() string { return "incident_responder" }
+func (r *IncidentResponder) Description() string {
+	return "Triages incidents: assesses impact, proposes mitigations and drafts status updates."
+}
+
+func (r *IncidentResponder) Run(ctx context.Context, input string) (string, error) {
+	if r.model == nil {
+		return "", fmt.Errorf("incident responder subagent missing model")
+	}
+
+	prompt := strings.Builder{}
+	prompt.WriteString(r.persona)
+	prompt.WriteString("\n\nTask:\n")
+	prompt.WriteString(strings.TrimSpace(input))
+	if m := incidentRef.FindStringSubmatch(input); m != nil && r.pagerDuty != nil {
+		prompt.WriteString("\n\nIncident data:\n")
+		prompt.WriteString(r.incidentContext(ctx, m[1]))
+	}
+	prompt.WriteString("\n\nDeliverable: Impact, current status, next mitigation steps with owners, and a short status update for stakeholders.\n")
+
+	resp, err := r.model.Generate(ctx, prompt.String())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(resp), nil
+}
+
+// incidentContext renders the incident and its timeline. Lookup failures
+// are reported inline so triage can continue without PagerDuty.
+func (r *IncidentResponder) incidentContext(ctx context.Context, id string) string {
+	var sb strings.Builder
+	inc, err := r.pagerDuty.Incident(ctx, id)
+	if err != nil {
+		return fmt.Sprintf("(could not fetch incident %s: %v)", id, err)
+	}
+	fmt.Fprintf(&sb, "#%d %s — %s (status %s, urgency %s)\n", inc.Number, inc.ID, inc.Title, inc.Status, inc.Urgency)
+	entries, err := r.pagerDuty.Timeline(ctx, id)
+	if err != nil {
+		fmt.Fprintf(&sb, "(could not fetch timeline: %v)\n", err)
+		return sb.String()
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "- %s %s\n", e.CreatedAt.UTC().Format("15:04:05Z"), e.Summary)
+	}
+	return sb.String()
+}
+
+var _ agent.SubAgent = (*IncidentResponder)(nil)
@@ -0,0 +1,38 @@
+package subagents
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/incident"
+)
+
+func TestIncidentResponderPullsReferencedIncident(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/incidents/P7":
+			fmt.Fprint(w, `{"incident":{"id":"P7","incident_number":7,"title":"Checkout errors","status":"triggered","urgency":"high"}}`)
+		case "/incidents/P7/log_entries":
+			fmt.Fprint(w, `{"log_entries":[{"id":"L1","summary":"Triggered by Datadog","created_at":"2024-05-01T10:00:00Z"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fm := &fakeModel{response: "plan"}
+	responder := NewIncidentResponder(fm, &incident.PagerDuty{Token: "tok", BaseURL: srv.URL})
+	if _, err := responder.Run(context.Background(), "Triage incident:P7"); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	prompt := fm.prompts[0]
+	for _, want := range []string{responder.persona, "Checkout errors", "Triggered by Datadog"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q: %q", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package subagents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+const defaultSynthesisPersona = "You are the coordinator of a team of specialists who each worked on the same task. Combine their answers into one response: keep what they agree on, resolve or flag disagreements, and drop repetition. Do not mention the specialists unless their disagreement matters to the reader."
+
+// ParallelSubAgent runs several sub-agents concurrently on the same
+// instruction, e.g. a researcher, a critic and a planner, and synthesizes
+// their answers with a model into one. It is a SubAgent itself, so it can
+// be listed in agent.Options.SubAgents like any other.
+//
+// A member that fails or runs past MemberTimeout is left out of the
+// synthesis, which is told about the gap; Run fails only when every member
+// does. Without a model the answers are returned one after another under
+// each member's name.
+type ParallelSubAgent struct {
+	name    string
+	model   models.Agent
+	members []agent.SubAgent
+
+	// Desc is returned by Description; by default it names the members.
+	Desc string
+	// Persona opens the synthesis prompt.
+	Persona string
+	// MemberTimeout bounds each member's run; zero leaves it to ctx.
+	MemberTimeout time.Duration
+}
+
+// Parallel builds a ParallelSubAgent called name whose answers are combined
+// by model, which may be nil.
+func Parallel(name string, model models.Agent, members ...agent.SubAgent) *ParallelSubAgent {
+	p := &ParallelSubAgent{name: name, model: model, Persona: defaultSynthesisPersona}
+	for _, m := range members {
+		if m != nil {
+			p.members = append(p.members, m)
+		}
+	}
+	return p
+}
+
+func (p *ParallelSubAgent) Name() string { return p.name }
+func (p *ParallelSubAgent) Description() string {
+	if p.Desc != "" {
+		return p.Desc
+	}
+	names := make([]string, len(p.members))
+	for i, m := range p.members {
+		names[i] = m.Name()
+	}
+	return "Runs " + strings.Join(names, ", ") + " on the same task in parallel and combines their answers."
+}
+
+// MemberResult is one member's part of a parallel run.
+type MemberResult struct {
+	Name   string
+	Output string
+	Err    error
+}
+
+// RunAll runs every member on input concurrently and returns their results
+// in member order.
+func (p *ParallelSubAgent) RunAll(ctx context.Context, input string) []MemberResult {
+	results := make([]MemberResult, len(p.members))
+	var wg sync.WaitGroup
+	for i, m := range p.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runCtx := ctx
+			if p.MemberTimeout > 0 {
+				var cancel context.CancelFunc
+				runCtx, cancel = context.WithTimeout(ctx, p.MemberTimeout)
+				defer cancel()
+			}
+			out, err := m.Run(runCtx, input)
+			results[i] = MemberResult{Name: m.Name(), Output: strings.TrimSpace(out), Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (p *ParallelSubAgent) Run(ctx context.Context, input string) (string, error) {
+	if len(p.members) == 0 {
+		return "", fmt.Errorf("parallel subagent %s has no members", p.name)
+	}
+	results := p.RunAll(ctx, input)
+	var ok []MemberResult
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name, r.Err))
+			continue
+		}
+		ok = append(ok, r)
+	}
+	if len(ok) == 0 {
+		return "", fmt.Errorf("parallel subagent %s: every member failed: %w", p.name, errors.Join(errs...))
+	}
+	if len(results) == 1 {
+		return ok[0].Output, nil
+	}
+	if p.model == nil {
+		return joinMemberOutputs(ok), nil
+	}
+
+	prompt := strings.Builder{}
+	prompt.WriteString(p.Persona)
+	prompt.WriteString("\n\nTask:\n")
+	prompt.WriteString(strings.TrimSpace(input))
+	prompt.WriteString("\n\nAnswers:\n\n")
+	prompt.WriteString(joinMemberOutputs(ok))
+	for _, err := range errs {
+		fmt.Fprintf(&prompt, "\n\nUnavailable: %v", err)
+	}
+	prompt.WriteString("\n\nDeliverable: One combined answer to the task.\n")
+
+	resp, err := p.model.Generate(ctx, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("parallel subagent %s synthesis: %w", p.name, err)
+	}
+	return fmt.Sprint(resp), nil
+}
+
+func joinMemberOutputs(results []MemberResult) string {
+	parts := make([]string, len(results))
+	for i, r := range results {
+		parts[i] = "### " + r.Name + "\n" + r.Output
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+var _ agent.SubAgent = (*ParallelSubAgent)(nil)
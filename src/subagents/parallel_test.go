@@ -0,0 +1,101 @@
+package subagents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type staticSubAgent struct {
+	name   string
+	output string
+	err    error
+	delay  time.Duration
+	wait   *sync.WaitGroup // released once every member has started
+}
+
+func (s *staticSubAgent) Name() string        { return s.name }
+func (s *staticSubAgent) Description() string { return s.name }
+
+func (s *staticSubAgent) Run(ctx context.Context, input string) (string, error) {
+	if s.wait != nil {
+		s.wait.Done()
+		s.wait.Wait()
+	}
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.output + " on " + input, nil
+}
+
+func TestParallelRunsMembersConcurrentlyAndSynthesizes(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(3)
+	fm := &fakeModel{response: "combined"}
+	p := Parallel("panel", fm,
+		&staticSubAgent{name: "researcher", output: "facts", wait: &started},
+		&staticSubAgent{name: "critic", output: "risks", wait: &started},
+		&staticSubAgent{name: "planner", output: "steps", wait: &started},
+	)
+
+	out, err := p.Run(context.Background(), "launch plan")
+	if err != nil || out != "combined" {
+		t.Fatalf("Run = %q, %v", out, err)
+	}
+	if len(fm.prompts) != 1 {
+		t.Fatalf("synthesis prompts = %d, want 1", len(fm.prompts))
+	}
+	prompt := fm.prompts[0]
+	for _, want := range []string{"Task:\nlaunch plan", "### researcher\nfacts on launch plan", "### critic\nrisks on launch plan", "### planner\nsteps on launch plan"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("synthesis prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if !strings.Contains(p.Description(), "researcher, critic, planner") {
+		t.Fatalf("description = %q", p.Description())
+	}
+}
+
+func TestParallelToleratesFailedMembers(t *testing.T) {
+	fm := &fakeModel{response: "combined"}
+	p := Parallel("panel", fm,
+		&staticSubAgent{name: "researcher", output: "facts"},
+		&staticSubAgent{name: "critic", err: errors.New("quota exceeded")},
+		&staticSubAgent{name: "planner", delay: time.Minute},
+	)
+	p.MemberTimeout = 20 * time.Millisecond
+
+	if _, err := p.Run(context.Background(), "task"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	prompt := fm.prompts[0]
+	if !strings.Contains(prompt, "Unavailable: critic: quota exceeded") || !strings.Contains(prompt, "Unavailable: planner: context deadline exceeded") {
+		t.Fatalf("synthesis prompt does not report the failed members:\n%s", prompt)
+	}
+
+	all := Parallel("panel", fm, &staticSubAgent{name: "critic", err: errors.New("down")})
+	if _, err := all.Run(context.Background(), "task"); err == nil || !strings.Contains(err.Error(), "critic: down") {
+		t.Fatalf("err = %v, want the members' errors", err)
+	}
+}
+
+func TestParallelWithoutModelJoinsAnswers(t *testing.T) {
+	p := Parallel("panel", nil,
+		&staticSubAgent{name: "a", output: "one"},
+		&staticSubAgent{name: "b", output: "two"},
+	)
+	out, err := p.Run(context.Background(), "x")
+	if err != nil || out != "### a\none on x\n\n### b\ntwo on x" {
+		t.Fatalf("Run = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,502 @@
+// Package tools holds general-purpose agent tools that talk to personal
+// productivity services, such as calendars and reminders.
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/scheduler"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+	calendar "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// CalendarEvent is the provider-neutral view of a calendar entry.
+type CalendarEvent struct {
+	ID          string
+	Title       string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Attendees   []string // email addresses
+	URL         string
+}
+
+// Calendar is a calendar backend the CalendarTool can read and write.
+type Calendar interface {
+	// Events lists events overlapping [from, to), soonest first.
+	Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error)
+	// CreateEvent adds ev and returns it as stored by the provider.
+	CreateEvent(ctx context.Context, ev CalendarEvent) (CalendarEvent, error)
+}
+
+// GoogleCalendar is a Calendar backed by the Google Calendar API.
+type GoogleCalendar struct {
+	CalendarID string
+
+	service *calendar.Service
+}
+
+// NewGoogleCalendar connects with Application Default Credentials unless
+// opts supply others. An empty calendarID selects "primary".
+func NewGoogleCalendar(ctx context.Context, calendarID string, opts ...option.ClientOption) (*GoogleCalendar, error) {
+	if calendarID == "" {
+		calendarID = "primary"
+	}
+	if opts == nil {
+		opts = []option.ClientOption{option.WithScopes(calendar.CalendarEventsScope)}
+	}
+	svc, err := calendar.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("google calendar: %w", err)
+	}
+	return &GoogleCalendar{CalendarID: calendarID, service: svc}, nil
+}
+
+func (g *GoogleCalendar) Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	var out []CalendarEvent
+	call := g.service.Events.List(g.CalendarID).Context(ctx).
+		TimeMin(from.Format(time.RFC3339)).
+		TimeMax(to.Format(time.RFC3339)).
+		SingleEvents(true).
+		OrderBy("startTime")
+	err := call.Pages(ctx, func(page *calendar.Events) error {
+		for _, e := range page.Items {
+			out = append(out, fromGoogle(e))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("google calendar: list events: %w", err)
+	}
+	return out, nil
+}
+
+func (g *GoogleCalendar) CreateEvent(ctx context.Context, ev CalendarEvent) (CalendarEvent, error) {
+	in := &calendar.Event{
+		Summary:     ev.Title,
+		Description: ev.Description,
+		Location:    ev.Location,
+		Start:       &calendar.EventDateTime{DateTime: ev.Start.Format(time.RFC3339)},
+		End:         &calendar.EventDateTime{DateTime: ev.End.Format(time.RFC3339)},
+	}
+	for _, a := range ev.Attendees {
+		in.Attendees = append(in.Attendees, &calendar.EventAttendee{Email: a})
+	}
+	created, err := g.service.Events.Insert(g.CalendarID, in).Context(ctx).Do()
+	if err != nil {
+		return CalendarEvent{}, fmt.Errorf("google calendar: create event: %w", err)
+	}
+	return fromGoogle(created), nil
+}
+
+func fromGoogle(e *calendar.Event) CalendarEvent {
+	ev := CalendarEvent{
+		ID:          e.Id,
+		Title:       e.Summary,
+		Description: e.Description,
+		Location:    e.Location,
+		Start:       googleTime(e.Start),
+		End:         googleTime(e.End),
+		URL:         e.HtmlLink,
+	}
+	for _, a := range e.Attendees {
+		ev.Attendees = append(ev.Attendees, a.Email)
+	}
+	return ev
+}
+
+// googleTime reads a timed or all-day event boundary.
+func googleTime(t *calendar.EventDateTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	if t.DateTime != "" {
+		parsed, _ := time.Parse(time.RFC3339, t.DateTime)
+		return parsed
+	}
+	parsed, _ := time.Parse("2006-01-02", t.Date)
+	return parsed
+}
+
+const graphAPIBase = "https://graph.microsoft.com/v1.0"
+
+// OutlookCalendar is a Calendar backed by Microsoft Graph, using the
+// signed-in user's default calendar.
+type OutlookCalendar struct {
+	// Token is an OAuth access token with Calendars.ReadWrite.
+	Token string
+	// BaseURL overrides the Graph endpoint; used by tests.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewOutlookCalendarFromEnv reads MS_GRAPH_TOKEN or OUTLOOK_ACCESS_TOKEN.
+func NewOutlookCalendarFromEnv() *OutlookCalendar {
+	return &OutlookCalendar{Token: secrets.Lookup("MS_GRAPH_TOKEN", "OUTLOOK_ACCESS_TOKEN")}
+}
+
+// graphTimeLayout is how Graph renders dateTime values.
+const graphTimeLayout = "2006-01-02T15:04:05.9999999"
+
+type graphTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type graphEvent struct {
+	ID      string `json:"id,omitempty"`
+	Subject string `json:"subject"`
+	Body    *struct {
+		ContentType string `json:"contentType"`
+		Content     string `json:"content"`
+	} `json:"body,omitempty"`
+	BodyPreview string `json:"bodyPreview,omitempty"`
+	Location    *struct {
+		DisplayName string `json:"displayName"`
+	} `json:"location,omitempty"`
+	Start     graphTime `json:"start"`
+	End       graphTime `json:"end"`
+	Attendees []struct {
+		EmailAddress struct {
+			Address string `json:"address"`
+		} `json:"emailAddress"`
+		Type string `json:"type,omitempty"`
+	} `json:"attendees,omitempty"`
+	WebLink string `json:"webLink,omitempty"`
+}
+
+func (o *OutlookCalendar) Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	query := url.Values{
+		"startDateTime": {from.UTC().Format(time.RFC3339)},
+		"endDateTime":   {to.UTC().Format(time.RFC3339)},
+		"$orderby":      {"start/dateTime"},
+		"$top":          {"100"},
+	}
+	next := o.base() + "/me/calendarView?" + query.Encode()
+	var out []CalendarEvent
+	for next != "" {
+		var page struct {
+			Value    []graphEvent `json:"value"`
+			NextLink string       `json:"@odata.nextLink"`
+		}
+		if err := o.call(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, e := range page.Value {
+			out = append(out, e.event())
+		}
+		next = page.NextLink
+	}
+	return out, nil
+}
+
+func (o *OutlookCalendar) CreateEvent(ctx context.Context, ev CalendarEvent) (CalendarEvent, error) {
+	in := graphEvent{
+		Subject: ev.Title,
+		Start:   graphTime{DateTime: ev.Start.UTC().Format(graphTimeLayout), TimeZone: "UTC"},
+		End:     graphTime{DateTime: ev.End.UTC().Format(graphTimeLayout), TimeZone: "UTC"},
+	}
+	if ev.Description != "" {
+		in.Body = &struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		}{"text", ev.Description}
+	}
+	if ev.Location != "" {
+		in.Location = &struct {
+			DisplayName string `json:"displayName"`
+		}{ev.Location}
+	}
+	for _, a := range ev.Attendees {
+		att := struct {
+			EmailAddress struct {
+				Address string `json:"address"`
+			} `json:"emailAddress"`
+			Type string `json:"type,omitempty"`
+		}{Type: "required"}
+		att.EmailAddress.Address = a
+		in.Attendees = append(in.Attendees, att)
+	}
+	var created graphEvent
+	if err := o.call(ctx, http.MethodPost, o.base()+"/me/events", in, &created); err != nil {
+		return CalendarEvent{}, err
+	}
+	return created.event(), nil
+}
+
+func (e graphEvent) event() CalendarEvent {
+	ev := CalendarEvent{
+		ID:          e.ID,
+		Title:       e.Subject,
+		Description: e.BodyPreview,
+		Start:       e.Start.time(),
+		End:         e.End.time(),
+		URL:         e.WebLink,
+	}
+	if e.Body != nil && ev.Description == "" {
+		ev.Description = e.Body.Content
+	}
+	if e.Location != nil {
+		ev.Location = e.Location.DisplayName
+	}
+	for _, a := range e.Attendees {
+		ev.Attendees = append(ev.Attendees, a.EmailAddress.Address)
+	}
+	return ev
+}
+
+// time reads a Graph dateTime. Requests ask for UTC, so a zone other than
+// UTC only appears when the server ignores the preference.
+func (t graphTime) time() time.Time {
+	loc := time.UTC
+	if t.TimeZone != "" && t.TimeZone != "UTC" {
+		if l, err := time.LoadLocation(t.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	parsed, _ := time.ParseInLocation(graphTimeLayout, t.DateTime, loc)
+	return parsed
+}
+
+func (o *OutlookCalendar) base() string {
+	if o.BaseURL != "" {
+		return strings.TrimRight(o.BaseURL, "/")
+	}
+	return graphAPIBase
+}
+
+func (o *OutlookCalendar) call(ctx context.Context, method, u string, body, out any) error {
+	if o.Token == "" {
+		return fmt.Errorf("outlook calendar: access token is empty")
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+o.Token)
+	req.Header.Set("Prefer", `outlook.timezone="UTC"`)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := o.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("outlook calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("outlook calendar: %s: http %d: %s", method, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("outlook calendar: decode: %w", err)
+	}
+	return nil
+}
+
+// DefaultEventDuration is used when a created event gives no end.
+const DefaultEventDuration = 30 * time.Minute
+
+// CalendarTool exposes a Calendar to an agent as the "calendar" tool with
+// the actions list and create. Times accept anything scheduler.ParseWhen
+// does, so the model can pass "tomorrow 3pm" directly.
+type CalendarTool struct {
+	Calendar Calendar
+	// Now overrides the clock used to resolve relative times.
+	Now func() time.Time
+}
+
+// NewCalendarTool wraps cal.
+func NewCalendarTool(cal Calendar) *CalendarTool {
+	return &CalendarTool{Calendar: cal}
+}
+
+func (t *CalendarTool) Spec() agent.ToolSpec {
+	return agent.ToolSpec{
+		Name:        "calendar",
+		Description: "Reads and creates calendar events. Times may be absolute (2024-06-07 15:00) or relative (tomorrow 3pm, friday, in 2 hours).",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"list", "create"},
+					"description": "List events in a window or create one.",
+				},
+				"from":             map[string]any{"type": "string", "description": "Start of the listing window; defaults to now."},
+				"to":               map[string]any{"type": "string", "description": "End of the listing window; defaults to a week after from."},
+				"title":            map[string]any{"type": "string", "description": "Event title when creating."},
+				"start":            map[string]any{"type": "string", "description": "Event start when creating."},
+				"end":              map[string]any{"type": "string", "description": "Event end when creating; defaults to 30 minutes after start."},
+				"duration_minutes": map[string]any{"type": "number", "description": "Event length when end is not given."},
+				"description":      map[string]any{"type": "string"},
+				"location":         map[string]any{"type": "string"},
+				"attendees": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Attendee email addresses.",
+				},
+			},
+			"required": []string{"action"},
+		},
+	}
+}
+
+func (t *CalendarTool) Invoke(ctx context.Context, req agent.ToolRequest) (agent.ToolResponse, error) {
+	if t.Calendar == nil {
+		return agent.ToolResponse{}, fmt.Errorf("calendar tool has no calendar")
+	}
+	now := time.Now()
+	if t.Now != nil {
+		now = t.Now()
+	}
+	action, _ := req.Arguments["action"].(string)
+
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "list":
+		from, err := timeArg(req.Arguments, "from", now, now)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		to, err := timeArg(req.Arguments, "to", now, from.AddDate(0, 0, 7))
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if !to.After(from) {
+			return agent.ToolResponse{}, fmt.Errorf("calendar: to must be after from")
+		}
+		events, err := t.Calendar.Events(ctx, from, to)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		var sb strings.Builder
+		for _, ev := range events {
+			sb.WriteString(formatEvent(ev, now.Location()))
+			sb.WriteString("\n")
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("No events.")
+		}
+		return agent.ToolResponse{
+			Content:  strings.TrimSpace(sb.String()),
+			Metadata: map[string]string{"events": fmt.Sprint(len(events))},
+		}, nil
+	case "create":
+		title, _ := req.Arguments["title"].(string)
+		if strings.TrimSpace(title) == "" {
+			return agent.ToolResponse{}, fmt.Errorf("calendar: title is required")
+		}
+		if s, _ := req.Arguments["start"].(string); strings.TrimSpace(s) == "" {
+			return agent.ToolResponse{}, fmt.Errorf("calendar: start is required")
+		}
+		start, err := timeArg(req.Arguments, "start", now, time.Time{})
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		length := DefaultEventDuration
+		if mins, ok := req.Arguments["duration_minutes"].(float64); ok && mins > 0 {
+			length = time.Duration(mins * float64(time.Minute))
+		}
+		end, err := timeArg(req.Arguments, "end", start, start.Add(length))
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if !end.After(start) {
+			return agent.ToolResponse{}, fmt.Errorf("calendar: end must be after start")
+		}
+		ev := CalendarEvent{Title: strings.TrimSpace(title), Start: start, End: end}
+		ev.Description, _ = req.Arguments["description"].(string)
+		ev.Location, _ = req.Arguments["location"].(string)
+		ev.Attendees = stringList(req.Arguments["attendees"])
+		created, err := t.Calendar.CreateEvent(ctx, ev)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		return agent.ToolResponse{
+			Content:  "Created " + formatEvent(created, now.Location()),
+			Metadata: map[string]string{"event_id": created.ID},
+		}, nil
+	}
+	return agent.ToolResponse{}, fmt.Errorf("unknown calendar action %q", action)
+}
+
+// timeArg parses the named argument relative to base, returning fallback
+// when it is absent.
+func timeArg(args map[string]any, name string, base, fallback time.Time) (time.Time, error) {
+	s, _ := args[name].(string)
+	if strings.TrimSpace(s) == "" {
+		return fallback, nil
+	}
+	t, err := scheduler.ParseWhen(s, base)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("calendar: %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+		return out
+	case string:
+		var out []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func formatEvent(ev CalendarEvent, loc *time.Location) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s–%s  %s", ev.Start.In(loc).Format("Mon Jan 2 15:04"), ev.End.In(loc).Format("15:04"), ev.Title)
+	if ev.Location != "" {
+		fmt.Fprintf(&sb, " @ %s", ev.Location)
+	}
+	if len(ev.Attendees) > 0 {
+		fmt.Fprintf(&sb, " with %s", strings.Join(ev.Attendees, ", "))
+	}
+	if ev.ID != "" {
+		fmt.Fprintf(&sb, " [%s]", ev.ID)
+	}
+	return sb.String()
+}
+
+var (
+	_ Calendar   = (*GoogleCalendar)(nil)
+	_ Calendar   = (*OutlookCalendar)(nil)
+	_ agent.Tool = (*CalendarTool)(nil)
+)
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/scheduler"
+)
+
+// ReminderKind labels scheduler jobs created by the RemindersTool.
+const ReminderKind = "reminder"
+
+// RemindersTool lets an agent nudge its own session later ("remind me
+// Friday"): reminders become scheduler jobs on the calling session, so they
+// persist with the scheduler's Store and arrive as a "[REMINDER]" turn when
+// the scheduler runs with scheduler.NudgeSession.
+type RemindersTool struct {
+	Scheduler *scheduler.Scheduler
+	// Now overrides the clock used to resolve relative times.
+	Now func() time.Time
+}
+
+// NewRemindersTool wraps s.
+func NewRemindersTool(s *scheduler.Scheduler) *RemindersTool {
+	return &RemindersTool{Scheduler: s}
+}
+
+func (t *RemindersTool) Spec() agent.ToolSpec {
+	return agent.ToolSpec{
+		Name:        "reminders",
+		Description: "Schedules a reminder that is delivered back to this conversation later, lists pending reminders, or cancels one.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"action": map[string]any{
+					"type": "string",
+					"enum": []string{"create", "list", "cancel"},
+				},
+				"when": map[string]any{
+					"type":        "string",
+					"description": "When to remind, e.g. friday, tomorrow 9am, in 2 hours, 2024-06-07 15:00.",
+				},
+				"message": map[string]any{"type": "string", "description": "What to be reminded about."},
+				"id":      map[string]any{"type": "string", "description": "Reminder ID to cancel."},
+			},
+			"required": []string{"action"},
+		},
+	}
+}
+
+func (t *RemindersTool) Invoke(ctx context.Context, req agent.ToolRequest) (agent.ToolResponse, error) {
+	if t.Scheduler == nil {
+		return agent.ToolResponse{}, fmt.Errorf("reminders tool has no scheduler")
+	}
+	if strings.TrimSpace(req.SessionID) == "" {
+		return agent.ToolResponse{}, fmt.Errorf("reminders need a session to deliver to")
+	}
+	now := time.Now()
+	if t.Now != nil {
+		now = t.Now()
+	}
+	action, _ := req.Arguments["action"].(string)
+
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "create", "add":
+		when, _ := req.Arguments["when"].(string)
+		message, _ := req.Arguments["message"].(string)
+		if strings.TrimSpace(message) == "" {
+			return agent.ToolResponse{}, fmt.Errorf("reminder message is required")
+		}
+		at, err := scheduler.ParseWhen(when, now)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if !at.After(now) {
+			return agent.ToolResponse{}, fmt.Errorf("reminder time %s is in the past", at.Format(time.RFC3339))
+		}
+		job, err := t.Scheduler.Schedule(ctx, scheduler.Job{
+			SessionID: req.SessionID,
+			Message:   strings.TrimSpace(message),
+			At:        at,
+			Kind:      ReminderKind,
+		})
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		return agent.ToolResponse{
+			Content:  fmt.Sprintf("Reminder %s set for %s: %s", job.ID, at.In(now.Location()).Format("Mon Jan 2 15:04"), job.Message),
+			Metadata: map[string]string{"reminder_id": job.ID, "at": at.Format(time.RFC3339)},
+		}, nil
+	case "list":
+		var sb strings.Builder
+		n := 0
+		for _, job := range t.Scheduler.Jobs(req.SessionID) {
+			if job.Kind != ReminderKind {
+				continue
+			}
+			fmt.Fprintf(&sb, "%s  %s  %s\n", job.ID, job.At.In(now.Location()).Format("Mon Jan 2 15:04"), job.Message)
+			n++
+		}
+		if n == 0 {
+			sb.WriteString("No pending reminders.")
+		}
+		return agent.ToolResponse{
+			Content:  strings.TrimSpace(sb.String()),
+			Metadata: map[string]string{"reminders": fmt.Sprint(n)},
+		}, nil
+	case "cancel", "delete":
+		id, _ := req.Arguments["id"].(string)
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return agent.ToolResponse{}, fmt.Errorf("reminder id is required")
+		}
+		ok, err := t.Scheduler.Cancel(ctx, req.SessionID, id)
+		if err != nil {
+			return agent.ToolResponse{}, err
+		}
+		if !ok {
+			return agent.ToolResponse{}, fmt.Errorf("no pending reminder %q", id)
+		}
+		return agent.ToolResponse{Content: "Cancelled reminder " + id, Metadata: map[string]string{"reminder_id": id}}, nil
+	}
+	return agent.ToolResponse{}, fmt.Errorf("unknown reminders action %q", action)
+}
+
+var _ agent.Tool = (*RemindersTool)(nil)
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/scheduler"
+)
+
+// Wednesday 10:00 UTC.
+var testNow = time.Date(2024, 6, 5, 10, 0, 0, 0, time.UTC)
+
+func TestCalendarToolAgainstOutlook(t *testing.T) {
+	var created map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/me/calendarView":
+			if got := r.URL.Query().Get("endDateTime"); got != "2024-06-12T10:00:00Z" {
+				t.Errorf("window end = %q, want a week out", got)
+			}
+			fmt.Fprint(w, `{"value":[{"id":"E1","subject":"Standup","start":{"dateTime":"2024-06-06T09:00:00.0000000","timeZone":"UTC"},
+				"end":{"dateTime":"2024-06-06T09:15:00.0000000","timeZone":"UTC"},"location":{"displayName":"Room 1"}}]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/me/events":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"id":"E2","subject":"Review","start":{"dateTime":"2024-06-07T15:00:00.0000000","timeZone":"UTC"},
+				"end":{"dateTime":"2024-06-07T15:45:00.0000000","timeZone":"UTC"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tool := &CalendarTool{
+		Calendar: &OutlookCalendar{Token: "tok", BaseURL: srv.URL},
+		Now:      func() time.Time { return testNow },
+	}
+	ctx := context.Background()
+	resp, err := tool.Invoke(ctx, agent.ToolRequest{Arguments: map[string]any{"action": "list"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Content, "Thu Jun 6 09:00–09:15  Standup @ Room 1") {
+		t.Fatalf("list = %q", resp.Content)
+	}
+
+	resp, err = tool.Invoke(ctx, agent.ToolRequest{Arguments: map[string]any{
+		"action": "create", "title": "Review", "start": "friday 3pm", "duration_minutes": 45.0,
+		"attendees": []any{"ada@example.com"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Metadata["event_id"] != "E2" {
+		t.Fatalf("create = %+v", resp)
+	}
+	start := created["start"].(map[string]any)
+	end := created["end"].(map[string]any)
+	if start["dateTime"] != "2024-06-07T15:00:00" || end["dateTime"] != "2024-06-07T15:45:00" {
+		t.Fatalf("event sent with start %v end %v", start, end)
+	}
+	if !strings.Contains(fmt.Sprint(created["attendees"]), "ada@example.com") {
+		t.Fatalf("attendees not sent: %v", created["attendees"])
+	}
+
+	if _, err := tool.Invoke(ctx, agent.ToolRequest{Arguments: map[string]any{"action": "create", "title": "x"}}); err == nil {
+		t.Fatal("create without start should fail")
+	}
+}
+
+func TestRemindersToolSchedulesOnCallingSession(t *testing.T) {
+	ctx := context.Background()
+	s, err := scheduler.New(ctx, nil, func(context.Context, scheduler.Job) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &RemindersTool{Scheduler: s, Now: func() time.Time { return testNow }}
+	invoke := func(session string, args map[string]any) (agent.ToolResponse, error) {
+		return tool.Invoke(ctx, agent.ToolRequest{SessionID: session, Arguments: args})
+	}
+
+	resp, err := invoke("alice", map[string]any{"action": "create", "when": "friday", "message": "send the invoice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := resp.Metadata["reminder_id"]
+	jobs := s.Jobs("alice")
+	if len(jobs) != 1 || jobs[0].ID != id || jobs[0].Kind != ReminderKind ||
+		!jobs[0].At.Equal(time.Date(2024, 6, 7, scheduler.DefaultHour, 0, 0, 0, time.UTC)) {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+
+	if resp, _ := invoke("bob", map[string]any{"action": "list"}); resp.Content != "No pending reminders." {
+		t.Fatalf("other sessions should not see the reminder: %q", resp.Content)
+	}
+	if _, err := invoke("bob", map[string]any{"action": "cancel", "id": id}); err == nil {
+		t.Fatal("another session should not cancel the reminder")
+	}
+	if resp, _ := invoke("alice", map[string]any{"action": "list"}); !strings.Contains(resp.Content, "send the invoice") {
+		t.Fatalf("list = %q", resp.Content)
+	}
+	if _, err := invoke("alice", map[string]any{"action": "cancel", "id": id}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Jobs("")) != 0 {
+		t.Fatal("cancel should remove the job")
+	}
+}
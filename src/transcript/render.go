@@ -0,0 +1,226 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// Write renders t in format: "markdown" (or "md"), "html" or "json".
+func Write(w io.Writer, t Transcript, format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "markdown", "md":
+		return t.WriteMarkdown(w)
+	case "html":
+		return t.WriteHTML(w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t)
+	default:
+		return fmt.Errorf("transcript: unknown format %q", format)
+	}
+}
+
+func (t Transcript) title() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	if len(t.Sessions) == 1 {
+		return "Session " + t.Sessions[0]
+	}
+	return "Conversation"
+}
+
+// summary is the one-line header under the title.
+func (t Transcript) summary() string {
+	parts := []string{}
+	if len(t.Sessions) > 0 {
+		parts = append(parts, "Sessions: "+strings.Join(t.Sessions, ", "))
+	}
+	if start, end := t.Span(); !start.IsZero() {
+		parts = append(parts, fmt.Sprintf("%s → %s (%s)", stamp(start), stamp(end), duration(end.Sub(start))))
+	}
+	parts = append(parts, fmt.Sprintf("%d messages", len(t.Entries)))
+	if len(t.ToolCalls) > 0 {
+		parts = append(parts, fmt.Sprintf("%d tool calls", len(t.ToolCalls)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// speaker names the author of an entry; with several sessions the session
+// is part of the name.
+func (t Transcript) speaker(e Entry) string {
+	role := e.Role
+	if role != "" {
+		role = strings.ToUpper(role[:1]) + role[1:]
+	}
+	if len(t.Sessions) > 1 && e.Session != "" {
+		return fmt.Sprintf("%s (%s)", role, e.Session)
+	}
+	return role
+}
+
+// WriteMarkdown renders the transcript as Markdown.
+func (t Transcript) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n_%s_\n", t.title(), t.summary())
+	for _, e := range t.Entries {
+		fmt.Fprintf(&sb, "\n### %s · %s", t.speaker(e), stamp(e.At))
+		if e.Latency > 0 {
+			fmt.Fprintf(&sb, " · %s", duration(e.Latency))
+		}
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.TrimSpace(e.Content))
+		sb.WriteString("\n")
+		if len(e.ToolCalls) > 0 {
+			refs := make([]string, len(e.ToolCalls))
+			for i, ref := range e.ToolCalls {
+				refs[i] = fmt.Sprintf("[%s](#%s)", ref, strings.ToLower(ref))
+				if c, ok := t.Call(ref); ok {
+					refs[i] += " " + c.Tool
+				}
+			}
+			fmt.Fprintf(&sb, "\nTools: %s\n", strings.Join(refs, ", "))
+		}
+		if len(e.Citations) > 0 {
+			sb.WriteString("\nSources:\n")
+			for i, c := range e.Citations {
+				fmt.Fprintf(&sb, "%d. %s%s\n", i+1, citationLabel(c), c.Snippet)
+			}
+		}
+	}
+	if len(t.ToolCalls) > 0 {
+		sb.WriteString("\n## Appendix: tool calls\n")
+		for _, c := range t.ToolCalls {
+			fmt.Fprintf(&sb, "\n<a id=\"%s\"></a>\n### %s · %s · %s", strings.ToLower(c.Ref), c.Ref, c.Tool, stamp(c.Started))
+			if c.Duration > 0 {
+				fmt.Fprintf(&sb, " · %s", duration(c.Duration))
+			}
+			sb.WriteString("\n")
+			if len(c.Arguments) > 0 {
+				fmt.Fprintf(&sb, "\nArguments:\n\n```json\n%s\n```\n", prettyJSON(c.Arguments))
+			}
+			if c.Result != "" {
+				fmt.Fprintf(&sb, "\nResult:\n\n%s\n", fence(c.Result))
+			}
+			if c.Error != "" {
+				fmt.Fprintf(&sb, "\nError: %s\n", c.Error)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteHTML renders the transcript as a standalone HTML page.
+func (t Transcript) WriteHTML(w io.Writer) error {
+	tmpl, err := transcriptHTML.Clone()
+	if err != nil {
+		return err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"speaker": t.speaker,
+		"tool": func(ref string) string {
+			c, _ := t.Call(ref)
+			return c.Tool
+		},
+	})
+	return tmpl.Execute(w, struct {
+		Transcript
+		Heading, Summary string
+	}{t, t.title(), t.summary()})
+}
+
+func citationLabel(c Citation) string {
+	label := c.Source
+	if c.Space != "" {
+		if label != "" {
+			label += ", "
+		}
+		label += "space " + c.Space
+	}
+	if label == "" {
+		return ""
+	}
+	return "**" + label + "** — "
+}
+
+func stamp(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.UTC().Format("2006-01-02 15:04:05Z")
+}
+
+func duration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(100 * time.Millisecond).String()
+	}
+}
+
+func prettyJSON(v any) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// fence wraps s in a code fence longer than any backtick run inside it.
+func fence(s string) string {
+	ticks := "```"
+	for strings.Contains(s, ticks) {
+		ticks += "`"
+	}
+	return ticks + "\n" + strings.TrimRight(s, "\n") + "\n" + ticks
+}
+
+var transcriptHTML = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"stamp":    stamp,
+	"duration": duration,
+	"json":     prettyJSON,
+	"lower":    strings.ToLower,
+	// Bound to the transcript being rendered by WriteHTML.
+	"speaker": func(Entry) string { return "" },
+	"tool":    func(string) string { return "" },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8">
+<title>{{.Heading}}</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:2rem auto;max-width:60rem;color:#222}
+.meta{color:#666}
+.entry{border-left:4px solid #ddd;margin:1rem 0;padding:.25rem 1rem}
+.entry.user{border-color:#0969da}.entry.assistant{border-color:#1a7f37}
+.entry.subagent,.entry.tool{border-color:#9a6700}
+.entry h3{font-size:.95rem;margin:.25rem 0}
+.content{white-space:pre-wrap}
+pre{background:#f6f8fa;padding:.5rem;overflow:auto;white-space:pre-wrap}
+.error{color:#cf222e}
+ol.sources{font-size:.9rem;color:#444}
+</style></head><body>
+<h1>{{.Heading}}</h1>
+<p class="meta">{{.Summary}}</p>
+{{range .Entries}}<div class="entry {{.Role}}">
+<h3>{{speaker .}} · <span class="meta">{{stamp .At}}{{with duration .Latency}} · {{.}}{{end}}</span></h3>
+<div class="content">{{.Content}}</div>
+{{if .ToolCalls}}<p class="meta">Tools: {{range $i, $ref := .ToolCalls}}{{if $i}}, {{end}}<a href="#{{lower $ref}}">{{$ref}}</a>{{with tool $ref}} {{.}}{{end}}{{end}}</p>{{end}}
+{{if .Citations}}<ol class="sources">{{range .Citations}}<li>{{if .Source}}<strong>{{.Source}}</strong>{{if .Space}}, space {{.Space}}{{end}} — {{else if .Space}}<strong>space {{.Space}}</strong> — {{end}}{{.Snippet}}</li>{{end}}</ol>{{end}}
+</div>
+{{end}}
+{{if .ToolCalls}}<h2>Appendix: tool calls</h2>
+{{range .ToolCalls}}<h3 id="{{lower .Ref}}">{{.Ref}} · {{.Tool}} · <span class="meta">{{stamp .Started}}{{with duration .Duration}} · {{.}}{{end}}</span></h3>
+{{if .Arguments}}<p>Arguments:</p><pre>{{json .Arguments}}</pre>{{end}}
+{{if .Result}}<p>Result:</p><pre>{{.Result}}</pre>{{end}}
+{{if .Error}}<p class="error">Error: {{.Error}}</p>{{end}}
+{{end}}{{end}}
+</body></html>
+`))
@@ -0,0 +1,295 @@
+// Package transcript turns agent sessions into shareable reports: the
+// conversation in order, the memories each answer cited, timings, and an
+// appendix with every tool call's arguments and result.
+//
+// A Recorder captures a live session, including tool calls reported through
+// agent tool events. FromMemory rebuilds a transcript after the fact from a
+// memory store, for one session or the several sessions of a swarm.
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// maxResultBytes bounds each tool result kept in the appendix.
+const maxResultBytes = 16 << 10
+
+// Transcript is a session, or a swarm conversation across sessions, ready
+// to render.
+type Transcript struct {
+	Title     string     `json:"title,omitempty"`
+	Sessions  []string   `json:"sessions"`
+	Entries   []Entry    `json:"entries"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// Entry is one message in the conversation.
+type Entry struct {
+	Session string `json:"session"`
+	// Role is "user", "assistant", "subagent", "tool" or whatever role the
+	// memory record carried.
+	Role    string    `json:"role"`
+	Content string    `json:"content"`
+	At      time.Time `json:"at"`
+	// Latency is how long the agent took to produce an answer.
+	Latency time.Duration `json:"latency_ns,omitempty"`
+	// ToolCalls references ToolCall.Ref values made while answering.
+	ToolCalls []string   `json:"tool_calls,omitempty"`
+	Citations []Citation `json:"citations,omitempty"`
+}
+
+// Citation is a memory retrieved to answer a turn.
+type Citation struct {
+	ID      int64   `json:"id,omitempty"`
+	Source  string  `json:"source,omitempty"`
+	Space   string  `json:"space,omitempty"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score,omitempty"`
+}
+
+// ToolCall is one tool or sub-agent invocation.
+type ToolCall struct {
+	Ref       string         `json:"ref"`
+	Session   string         `json:"session"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Result    string         `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Started   time.Time      `json:"started"`
+	Duration  time.Duration  `json:"duration_ns,omitempty"`
+}
+
+// Span returns the times of the first and last entries.
+func (t Transcript) Span() (start, end time.Time) {
+	for _, e := range t.Entries {
+		if start.IsZero() || e.At.Before(start) {
+			start = e.At
+		}
+		if e.At.After(end) {
+			end = e.At
+		}
+	}
+	return start, end
+}
+
+// Call returns the tool call with ref.
+func (t Transcript) Call(ref string) (ToolCall, bool) {
+	for _, c := range t.ToolCalls {
+		if c.Ref == ref {
+			return c, true
+		}
+	}
+	return ToolCall{}, false
+}
+
+// TurnSource reports the agent's last completed turn; *agent.Agent
+// implements it.
+type TurnSource interface {
+	LastTurn(sessionID string) (agent.Turn, bool)
+}
+
+// Recorder builds a transcript while a session runs. Wrap each request's
+// context with Context so tool calls are captured, then call Record once the
+// agent has answered.
+type Recorder struct {
+	mu       sync.Mutex
+	t        Transcript
+	open     map[string][]int // session+tool -> indexes of unfinished calls
+	unbound  map[string][]string
+	sessions map[string]bool
+}
+
+// NewRecorder starts an empty transcript titled title.
+func NewRecorder(title string) *Recorder {
+	return &Recorder{
+		t:        Transcript{Title: title},
+		open:     map[string][]int{},
+		unbound:  map[string][]string{},
+		sessions: map[string]bool{},
+	}
+}
+
+// Context reports the agent's tool events on ctx to the recorder, and to
+// any handler already registered on ctx.
+func (r *Recorder) Context(ctx context.Context) context.Context {
+	prev, _ := agent.ToolEventsFromContext(ctx)
+	return agent.ContextWithToolEvents(ctx, func(ev agent.ToolEvent) {
+		r.toolEvent(ev)
+		if prev != nil {
+			prev(ev)
+		}
+	})
+}
+
+func (r *Recorder) toolEvent(ev agent.ToolEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := ev.SessionID + "\x00" + ev.Tool
+	switch ev.Type {
+	case agent.ToolEventStart:
+		call := ToolCall{
+			Ref:       fmt.Sprintf("T%d", len(r.t.ToolCalls)+1),
+			Session:   ev.SessionID,
+			Tool:      ev.Tool,
+			Arguments: ev.Arguments,
+			Started:   ev.Time,
+		}
+		r.open[key] = append(r.open[key], len(r.t.ToolCalls))
+		r.t.ToolCalls = append(r.t.ToolCalls, call)
+		r.unbound[ev.SessionID] = append(r.unbound[ev.SessionID], call.Ref)
+	case agent.ToolEventChunk:
+		if idx, ok := r.current(key); ok {
+			r.t.ToolCalls[idx].Result = clip(r.t.ToolCalls[idx].Result + fmt.Sprint(ev.Result))
+		}
+	case agent.ToolEventResult, agent.ToolEventError:
+		idx, ok := r.current(key)
+		if !ok {
+			// Results without a start, such as cached sub-agent answers,
+			// still belong in the appendix.
+			idx = len(r.t.ToolCalls)
+			ref := fmt.Sprintf("T%d", idx+1)
+			r.t.ToolCalls = append(r.t.ToolCalls, ToolCall{Ref: ref, Session: ev.SessionID, Tool: ev.Tool, Started: ev.Time})
+			r.unbound[ev.SessionID] = append(r.unbound[ev.SessionID], ref)
+		} else {
+			stack := r.open[key]
+			r.open[key] = stack[:len(stack)-1]
+		}
+		call := &r.t.ToolCalls[idx]
+		call.Duration = ev.Duration
+		if ev.Type == agent.ToolEventError {
+			call.Error = ev.Error
+		}
+		if ev.Result != nil {
+			call.Result = clip(fmt.Sprint(ev.Result))
+		}
+	}
+}
+
+func (r *Recorder) current(key string) (int, bool) {
+	stack := r.open[key]
+	if len(stack) == 0 {
+		return 0, false
+	}
+	return stack[len(stack)-1], true
+}
+
+// Record adds an exchange that started at started. When turns reports a
+// turn for the same input, its retrieved memories become the answer's
+// citations and its latency the answer's timing. Tool calls captured since
+// the previous exchange in the session are attached to the answer.
+func (r *Recorder) Record(sessionID, input, output string, started time.Time, turns TurnSource) {
+	answer := Entry{Session: sessionID, Role: "assistant", Content: output, At: time.Now().UTC(), Latency: time.Since(started)}
+	if turns != nil {
+		if turn, ok := turns.LastTurn(sessionID); ok && turn.Input == input && !turn.At.Before(started.UTC()) {
+			answer.At, answer.Latency = turn.At, turn.Latency
+			answer.Citations = Citations(turn.Context)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.sessions[sessionID] {
+		r.sessions[sessionID] = true
+		r.t.Sessions = append(r.t.Sessions, sessionID)
+	}
+	answer.ToolCalls = r.unbound[sessionID]
+	delete(r.unbound, sessionID)
+	r.t.Entries = append(r.t.Entries,
+		Entry{Session: sessionID, Role: "user", Content: input, At: started.UTC()},
+		answer,
+	)
+}
+
+// Transcript returns a copy of what has been recorded so far.
+func (r *Recorder) Transcript() Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.t
+	t.Sessions = append([]string(nil), t.Sessions...)
+	t.Entries = append([]Entry(nil), t.Entries...)
+	t.ToolCalls = append([]ToolCall(nil), t.ToolCalls...)
+	return t
+}
+
+// Citations converts retrieved memories to citations.
+func Citations(records []memory.MemoryRecord) []Citation {
+	var out []Citation
+	for _, rec := range records {
+		out = append(out, Citation{
+			ID:      rec.ID,
+			Source:  rec.Source,
+			Space:   rec.Space,
+			Snippet: snippet(rec.Content, 240),
+			Score:   rec.Score,
+		})
+	}
+	return out
+}
+
+// Iterator is the part of a memory store FromMemory reads.
+type Iterator interface {
+	Iterate(ctx context.Context, fn func(memory.MemoryRecord) bool) error
+}
+
+// FromMemory rebuilds the conversation of the given sessions from store,
+// ordered by time. Several sessions make a swarm transcript with one
+// speaker per session. Timings and tool arguments are not kept in memory,
+// so tool records appear inline rather than in the appendix.
+func FromMemory(ctx context.Context, store Iterator, title string, sessionIDs ...string) (Transcript, error) {
+	wanted := map[string]bool{}
+	for _, id := range sessionIDs {
+		wanted[id] = true
+	}
+	t := Transcript{Title: title, Sessions: append([]string(nil), sessionIDs...)}
+	err := store.Iterate(ctx, func(rec memory.MemoryRecord) bool {
+		if !wanted[rec.SessionID] {
+			return true
+		}
+		role := "unknown"
+		var meta map[string]any
+		if json.Unmarshal([]byte(rec.Metadata), &meta) == nil {
+			if r, ok := meta["role"].(string); ok && r != "" {
+				role = r
+			}
+			if _, ok := meta["tool"]; ok && role == "assistant" {
+				role = "tool"
+			}
+		}
+		t.Entries = append(t.Entries, Entry{Session: rec.SessionID, Role: role, Content: rec.Content, At: rec.CreatedAt})
+		return true
+	})
+	if err != nil {
+		return Transcript{}, err
+	}
+	sort.SliceStable(t.Entries, func(i, j int) bool { return t.Entries[i].At.Before(t.Entries[j].At) })
+	return t, nil
+}
+
+func clip(s string) string {
+	if len(s) <= maxResultBytes {
+		return s
+	}
+	cut := maxResultBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+func snippet(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
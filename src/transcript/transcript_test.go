@@ -0,0 +1,124 @@
+package transcript
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	agent "github.com/Protocol-Lattice/go-agent"
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+type fixedTurns map[string]agent.Turn
+
+func (f fixedTurns) LastTurn(sessionID string) (agent.Turn, bool) {
+	t, ok := f[sessionID]
+	return t, ok
+}
+
+func recordSample(t *testing.T) Transcript {
+	t.Helper()
+	rec := NewRecorder("Q3 review")
+	started := time.Now().Add(-2 * time.Second)
+	ctx := rec.Context(context.Background())
+	emit, ok := agent.ToolEventsFromContext(ctx)
+	if !ok {
+		t.Fatal("recorder context has no tool event handler")
+	}
+	emit(agent.ToolEvent{Type: agent.ToolEventStart, SessionID: "s1", Tool: "search", Arguments: map[string]any{"q": "q3 revenue"}, Time: started})
+	emit(agent.ToolEvent{Type: agent.ToolEventChunk, SessionID: "s1", Tool: "search", Result: "partial "})
+	emit(agent.ToolEvent{Type: agent.ToolEventResult, SessionID: "s1", Tool: "search", Result: "revenue: $4M <b>", Duration: 150 * time.Millisecond})
+
+	turns := fixedTurns{"s1": {
+		SessionID: "s1",
+		Input:     "What was Q3 revenue?",
+		At:        time.Now().UTC(),
+		Latency:   1200 * time.Millisecond,
+		Context:   []memory.MemoryRecord{{ID: 7, Source: "finance.pdf", Content: "Q3 revenue   was $4M"}},
+	}}
+	rec.Record("s1", "What was Q3 revenue?", "It was $4M.", started, turns)
+	return rec.Transcript()
+}
+
+func TestRecorderCapturesToolCallsCitationsAndTimings(t *testing.T) {
+	tr := recordSample(t)
+	if len(tr.Entries) != 2 || tr.Entries[0].Role != "user" || tr.Entries[1].Role != "assistant" {
+		t.Fatalf("entries = %+v", tr.Entries)
+	}
+	answer := tr.Entries[1]
+	if answer.Latency != 1200*time.Millisecond || len(answer.ToolCalls) != 1 || answer.ToolCalls[0] != "T1" {
+		t.Fatalf("answer = %+v", answer)
+	}
+	if len(answer.Citations) != 1 || answer.Citations[0].Source != "finance.pdf" || answer.Citations[0].Snippet != "Q3 revenue was $4M" {
+		t.Fatalf("citations = %+v", answer.Citations)
+	}
+	call := tr.ToolCalls[0]
+	if call.Tool != "search" || call.Result != "revenue: $4M <b>" || call.Duration != 150*time.Millisecond {
+		t.Fatalf("tool call = %+v", call)
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	tr := recordSample(t)
+
+	var md bytes.Buffer
+	if err := Write(&md, tr, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Q3 review", "### Assistant", "1.2s", "Tools: [T1](#t1) search", "**finance.pdf** — Q3 revenue was $4M", "## Appendix: tool calls", `"q": "q3 revenue"`} {
+		if !strings.Contains(md.String(), want) {
+			t.Fatalf("markdown lacks %q:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := Write(&html, tr, "html"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<h3 id="t1">T1 · search`) || !strings.Contains(html.String(), "revenue: $4M &lt;b&gt;") {
+		t.Fatalf("html:\n%s", html.String())
+	}
+
+	var raw bytes.Buffer
+	if err := Write(&raw, tr, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var back Transcript
+	if err := json.Unmarshal(raw.Bytes(), &back); err != nil || len(back.ToolCalls) != 1 {
+		t.Fatalf("json round trip: %v %+v", err, back)
+	}
+	if err := Write(&raw, tr, "pdf"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestFromMemoryMergesSwarmSessions(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	for _, m := range []struct{ session, role, content string }{
+		{"alice", "user", "kick off"},
+		{"bob", "assistant", "on it"},
+		{"carol", "user", "not part of this swarm"},
+	} {
+		if err := store.StoreMemory(ctx, m.session, m.content, map[string]any{"role": m.role}, []float32{1}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	tr, err := FromMemory(ctx, store, "", "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Entries) != 2 || tr.Entries[0].Content != "kick off" || tr.Entries[1].Session != "bob" {
+		t.Fatalf("entries = %+v", tr.Entries)
+	}
+	var md bytes.Buffer
+	tr.WriteMarkdown(&md)
+	if !strings.Contains(md.String(), "### Assistant (bob)") || !strings.Contains(md.String(), "# Conversation") {
+		t.Fatalf("markdown:\n%s", md.String())
+	}
+}
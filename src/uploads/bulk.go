@@ -0,0 +1,325 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// DefaultBulkWorkers is the number of files a BulkJob ingests at once when
+// Workers is zero.
+const DefaultBulkWorkers = 4
+
+// ExpandPaths turns command-line inputs into the files to ingest. A
+// directory contributes every regular file below it, skipping hidden files
+// and directories; other inputs are file paths or filepath.Match globs.
+// When include is set (e.g. "*.md"), files found in directories must match
+// it by base name. The result is sorted and free of duplicates.
+func ExpandPaths(inputs []string, include string) ([]string, error) {
+	if include != "" {
+		if _, err := filepath.Match(include, ""); err != nil {
+			return nil, fmt.Errorf("include pattern %q: %w", include, err)
+		}
+	}
+	seen := map[string]bool{}
+	var out []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			out = append(out, path)
+		}
+	}
+	for _, input := range inputs {
+		matches := []string{input}
+		if strings.ContainsAny(input, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(input); err != nil {
+				return nil, fmt.Errorf("glob %q: %w", input, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("glob %q matches no files", input)
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if path != match && strings.HasPrefix(d.Name(), ".") {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				if include != "" {
+					if ok, _ := filepath.Match(include, d.Name()); !ok {
+						return nil
+					}
+				}
+				add(path)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// BulkJob ingests many local files with a pool of workers. Progress is
+// recorded per file in Progress, keyed by absolute path with the file's
+// size and modification time, so a rerun after a crash or interrupt skips
+// what was already ingested and picks up files that changed. A changed
+// file is ingested again next to its earlier chunks; use a Syncer to
+// replace documents in place.
+//
+// Status may be polled from another goroutine while Run is in progress.
+type BulkJob struct {
+	Pipeline  *Pipeline
+	SessionID string
+	// Workers defaults to DefaultBulkWorkers.
+	Workers int
+	// Progress defaults to an in-process MemorySyncState, which makes the
+	// job restart from scratch.
+	Progress SyncState
+	// Metadata, when set, supplies per-file metadata such as GitMetadata.
+	// Its errors are ignored.
+	Metadata func(ctx context.Context, path string) (DocumentMetadata, error)
+	// FileTimeout bounds each file's ingestion; zero leaves it to ctx.
+	FileTimeout time.Duration
+
+	started  atomic.Int64 // UnixNano, zero before Run
+	total    atomic.Int64
+	done     atomic.Int64
+	skipped  atomic.Int64
+	failed   atomic.Int64
+	chunks   atomic.Int64
+	bytes    atomic.Int64
+	finished atomic.Int64 // UnixNano, zero while running
+}
+
+// BulkStatus is a snapshot of a BulkJob's progress.
+type BulkStatus struct {
+	Total   int `json:"total"`
+	Done    int `json:"done"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+	Chunks  int `json:"chunks"`
+	// Bytes is the size of the files ingested so far.
+	Bytes   int64         `json:"bytes"`
+	Elapsed time.Duration `json:"elapsed"`
+	// FilesPerSecond and BytesPerSecond count ingested files only, so
+	// skipped ones do not inflate the rate of a resumed job.
+	FilesPerSecond float64 `json:"files_per_second"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	Finished       bool    `json:"finished"`
+}
+
+// Remaining is the number of files not yet done, skipped or failed.
+func (s BulkStatus) Remaining() int {
+	return max(s.Total-s.Done-s.Skipped-s.Failed, 0)
+}
+
+// BulkReport summarises a BulkJob run.
+type BulkReport struct {
+	BulkStatus
+	Ingested []Result          `json:"ingested,omitempty"`
+	Failures map[string]string `json:"failures,omitempty"` // path -> error
+}
+
+// Status reports the job's progress.
+func (j *BulkJob) Status() BulkStatus {
+	s := BulkStatus{
+		Total:   int(j.total.Load()),
+		Done:    int(j.done.Load()),
+		Skipped: int(j.skipped.Load()),
+		Failed:  int(j.failed.Load()),
+		Chunks:  int(j.chunks.Load()),
+		Bytes:   j.bytes.Load(),
+	}
+	started := j.started.Load()
+	if started == 0 {
+		return s
+	}
+	end := time.Now()
+	if at := j.finished.Load(); at != 0 {
+		end = time.Unix(0, at)
+		s.Finished = true
+	}
+	s.Elapsed = end.Sub(time.Unix(0, started))
+	if secs := s.Elapsed.Seconds(); secs > 0 {
+		s.FilesPerSecond = float64(s.Done) / secs
+		s.BytesPerSecond = float64(s.Bytes) / secs
+	}
+	return s
+}
+
+// Run ingests paths. A file that fails is recorded in BulkReport.Failures
+// and retried on the next run; the returned error is reserved for
+// failures that stop the job (progress state, cancellation). Results are
+// in path order.
+func (j *BulkJob) Run(ctx context.Context, paths []string) (BulkReport, error) {
+	if j.Pipeline == nil {
+		return BulkReport{}, errors.New("uploads: bulk job needs a pipeline")
+	}
+	if j.Progress == nil {
+		j.Progress = &MemorySyncState{}
+	}
+	workers := j.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+	progress, err := j.Progress.Load(ctx)
+	if err != nil {
+		return BulkReport{}, fmt.Errorf("load progress: %w", err)
+	}
+	for _, n := range []*atomic.Int64{&j.finished, &j.done, &j.skipped, &j.failed, &j.chunks, &j.bytes} {
+		n.Store(0)
+	}
+	j.total.Store(int64(len(paths)))
+	j.started.Store(time.Now().UnixNano())
+
+	type item struct {
+		index int
+		path  string
+		key   string
+		stamp string
+		size  int64
+	}
+	var (
+		mu       sync.Mutex
+		saveErr  error
+		results  = make([]*Result, len(paths))
+		failures = map[string]string{}
+	)
+	fail := func(path string, err error) {
+		j.failed.Add(1)
+		mu.Lock()
+		failures[path] = err.Error()
+		mu.Unlock()
+	}
+
+	var pending []item
+	for i, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			fail(path, err)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			fail(path, err)
+			continue
+		}
+		stamp := fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano())
+		if progress[abs] == stamp {
+			j.skipped.Add(1)
+			continue
+		}
+		pending = append(pending, item{index: i, path: path, key: abs, stamp: stamp, size: info.Size()})
+	}
+
+	queue := make(chan item)
+	var wg sync.WaitGroup
+	for range min(workers, max(len(pending), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range queue {
+				res, err := j.ingestFile(ctx, it.path)
+				if err != nil {
+					if ctx.Err() == nil {
+						fail(it.path, err)
+					}
+					continue
+				}
+				j.done.Add(1)
+				j.chunks.Add(int64(res.Chunks))
+				j.bytes.Add(it.size)
+				mu.Lock()
+				results[it.index] = &res
+				progress[it.key] = it.stamp
+				if err := j.Progress.Save(ctx, progress); err != nil && saveErr == nil {
+					saveErr = fmt.Errorf("save progress: %w", err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, it := range pending {
+		mu.Lock()
+		stop := saveErr != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+		select {
+		case queue <- it:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	report := BulkReport{}
+	for _, res := range results {
+		if res != nil {
+			report.Ingested = append(report.Ingested, *res)
+		}
+	}
+	if len(failures) > 0 {
+		report.Failures = failures
+	}
+	j.finished.Store(time.Now().UnixNano())
+	report.BulkStatus = j.Status()
+	if saveErr != nil {
+		return report, saveErr
+	}
+	return report, ctx.Err()
+}
+
+func (j *BulkJob) ingestFile(ctx context.Context, path string) (Result, error) {
+	if j.FileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.FileTimeout)
+		defer cancel()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	doc := Document{File: models.File{Name: filepath.Base(path), Data: data}}
+	if j.Metadata != nil {
+		doc.Metadata, _ = j.Metadata(ctx, path)
+	}
+	results, err := j.Pipeline.IngestDocuments(ctx, j.SessionID, doc)
+	if err != nil {
+		return Result{}, err
+	}
+	return results[0], nil
+}
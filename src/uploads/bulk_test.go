@@ -0,0 +1,82 @@
+package uploads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.txt", "sub/c.md", ".git/d.md", "top.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := ExpandPaths([]string{dir, filepath.Join(dir, "*.txt")}, "*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.md"), filepath.Join(dir, "b.txt"), filepath.Join(dir, "sub/c.md"), filepath.Join(dir, "top.md")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExpandPaths = %v, want %v", got, want)
+	}
+	if _, err := ExpandPaths([]string{filepath.Join(dir, "*.pdf")}, ""); err == nil {
+		t.Fatal("a glob without matches should fail")
+	}
+}
+
+func TestBulkJobResumesFromProgress(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"one.md", "two.md", "three.md", "broken.pdf"} {
+		path := filepath.Join(dir, name)
+		content := "# " + name + "\n\nNotes about " + name + "."
+		if name == "broken.pdf" {
+			content = "not a pdf"
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	progress := FileSyncState{Path: filepath.Join(dir, "progress.json")}
+	job := &BulkJob{Pipeline: NewPipeline(mem), SessionID: "docs", Workers: 3, Progress: progress}
+
+	rep, err := job.Run(ctx, paths)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if rep.Done != 3 || rep.Failed != 1 || rep.Skipped != 0 || !rep.Finished || rep.Remaining() != 0 {
+		t.Fatalf("first run status = %+v", rep.BulkStatus)
+	}
+	if _, ok := rep.Failures[paths[3]]; !ok || len(rep.Ingested) != 3 || rep.Ingested[0].Name != "one.md" {
+		t.Fatalf("first run report = %+v", rep)
+	}
+	if rep.Chunks == 0 || rep.Bytes == 0 {
+		t.Fatalf("throughput not counted: %+v", rep.BulkStatus)
+	}
+
+	if err := os.WriteFile(paths[1], []byte("# two.md\n\nRevised notes."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	job = &BulkJob{Pipeline: NewPipeline(mem), SessionID: "docs", Progress: progress}
+	rep, err = job.Run(ctx, paths)
+	if err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if rep.Skipped != 2 || rep.Done != 1 || rep.Failed != 1 || len(rep.Ingested) != 1 || rep.Ingested[0].Name != "two.md" {
+		t.Fatalf("resumed run should only redo the changed and failed files: %+v", rep)
+	}
+}
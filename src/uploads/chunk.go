@@ -0,0 +1,178 @@
+package uploads
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Boundary selects where the chunker is allowed to cut a document.
+type Boundary string
+
+const (
+	// BoundaryLine packs whole lines, matching memory.ChunkText.
+	BoundaryLine Boundary = "line"
+	// BoundarySentence packs whole sentences.
+	BoundarySentence Boundary = "sentence"
+	// BoundaryParagraph packs whole paragraphs separated by blank lines.
+	BoundaryParagraph Boundary = "paragraph"
+	// BoundaryToken packs whitespace-delimited tokens; Size and Overlap are
+	// then measured in tokens rather than characters.
+	BoundaryToken Boundary = "token"
+)
+
+// ChunkOptions controls how extracted text is split before it is embedded.
+type ChunkOptions struct {
+	// Size is the target chunk length in characters (tokens for BoundaryToken).
+	Size int
+	// Overlap repeats up to this many trailing characters (tokens) of the
+	// previous chunk at the start of the next, rounded to whole units.
+	Overlap int
+	// Boundary defaults to BoundaryLine.
+	Boundary Boundary
+}
+
+// DefaultChunkOptions returns the historical line-based 2000 character chunks.
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{Size: defaultChunkSize, Boundary: BoundaryLine}
+}
+
+func (o ChunkOptions) withDefaults() ChunkOptions {
+	if o.Size <= 0 {
+		o.Size = defaultChunkSize
+	}
+	if o.Boundary == "" {
+		o.Boundary = BoundaryLine
+	}
+	if o.Overlap < 0 {
+		o.Overlap = 0
+	}
+	if o.Overlap >= o.Size {
+		o.Overlap = o.Size / 2
+	}
+	return o
+}
+
+// ParseBoundary maps a user-supplied name to a Boundary. Unknown names fall
+// back to BoundaryLine and report false.
+func ParseBoundary(name string) (Boundary, bool) {
+	switch b := Boundary(strings.ToLower(strings.TrimSpace(name))); b {
+	case BoundaryLine, BoundarySentence, BoundaryParagraph, BoundaryToken:
+		return b, true
+	case "":
+		return BoundaryLine, true
+	}
+	return BoundaryLine, false
+}
+
+var (
+	paragraphSplit = regexp.MustCompile(`\n[ \t]*\n\s*`)
+	sentenceEnd    = regexp.MustCompile(`[.!?]["')\]]*\s+`)
+)
+
+// Chunk splits text according to opts. Units longer than Size are split
+// further on rune boundaries so no chunk grossly exceeds the target.
+func Chunk(text string, opts ChunkOptions) []string {
+	opts = opts.withDefaults()
+	units, sep := splitUnits(text, opts.Boundary)
+	measure := func(s string) int { return utf8.RuneCountInString(s) }
+	if opts.Boundary == BoundaryToken {
+		measure = func(string) int { return 1 }
+	} else {
+		units = splitOversized(units, opts.Size)
+	}
+
+	var (
+		chunks  []string
+		current []string
+		size    int
+		fresh   bool // current holds units not yet emitted
+	)
+	flush := func() {
+		chunks = append(chunks, strings.Join(current, sep))
+
+		// Carry whole trailing units forward as overlap.
+		var carried []string
+		carriedSize := 0
+		for i := len(current) - 1; i >= 0 && opts.Overlap > 0; i-- {
+			n := measure(current[i])
+			if carriedSize+n > opts.Overlap {
+				break
+			}
+			carried = append([]string{current[i]}, carried...)
+			carriedSize += n
+		}
+		current, size, fresh = carried, carriedSize, false
+	}
+
+	for _, unit := range units {
+		n := measure(unit)
+		if fresh && size+n > opts.Size {
+			flush()
+			// If the carried overlap leaves no room for the unit, drop it.
+			if size+n > opts.Size {
+				current, size = nil, 0
+			}
+		}
+		current = append(current, unit)
+		size += n
+		fresh = true
+	}
+	if fresh {
+		chunks = append(chunks, strings.Join(current, sep))
+	}
+	return chunks
+}
+
+func splitUnits(text string, boundary Boundary) ([]string, string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var units []string
+	sep := "\n"
+	switch boundary {
+	case BoundaryParagraph:
+		units, sep = paragraphSplit.Split(text, -1), "\n\n"
+	case BoundarySentence:
+		sep = " "
+		for _, para := range paragraphSplit.Split(text, -1) {
+			last := 0
+			for _, loc := range sentenceEnd.FindAllStringIndex(para, -1) {
+				units = append(units, para[last:loc[1]])
+				last = loc[1]
+			}
+			units = append(units, para[last:])
+		}
+	case BoundaryToken:
+		units, sep = strings.Fields(text), " "
+	default:
+		units = strings.Split(text, "\n")
+	}
+
+	out := units[:0]
+	for _, u := range units {
+		if boundary != BoundaryLine {
+			u = strings.TrimSpace(u)
+		}
+		if strings.TrimSpace(u) == "" {
+			continue
+		}
+		out = append(out, u)
+	}
+	return out, sep
+}
+
+func splitOversized(units []string, size int) []string {
+	out := make([]string, 0, len(units))
+	for _, u := range units {
+		count := 0
+		start := 0
+		for i := range u {
+			if count == size {
+				out = append(out, u[start:i])
+				start, count = i, 0
+			}
+			count++
+		}
+		out = append(out, u[start:])
+	}
+	return out
+}
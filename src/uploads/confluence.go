@@ -0,0 +1,172 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+)
+
+// ConfluenceSource pulls the pages of one Confluence space through the REST
+// API. Page versions act as ETags, and each page's closest ancestor becomes
+// its Parent so the page tree is kept as graph edges.
+type ConfluenceSource struct {
+	// BaseURL is the site root, e.g. "https://acme.atlassian.net/wiki".
+	BaseURL  string
+	SpaceKey string
+	// Email and APIToken authenticate Confluence Cloud with basic auth. When
+	// Email is empty APIToken is sent as a bearer personal access token
+	// (Confluence Data Center).
+	Email    string
+	APIToken string
+
+	Client *http.Client
+}
+
+// NewConfluenceSourceFromEnv configures a ConfluenceSource from
+// CONFLUENCE_BASE_URL, CONFLUENCE_EMAIL and CONFLUENCE_API_TOKEN.
+func NewConfluenceSourceFromEnv(spaceKey string) *ConfluenceSource {
+	return &ConfluenceSource{
+		BaseURL:  secrets.Lookup("CONFLUENCE_BASE_URL"),
+		SpaceKey: spaceKey,
+		Email:    secrets.Lookup("CONFLUENCE_EMAIL"),
+		APIToken: secrets.Lookup("CONFLUENCE_API_TOKEN"),
+	}
+}
+
+type confluencePage struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int       `json:"number"`
+		When   time.Time `json:"when"`
+	} `json:"version"`
+	Ancestors []struct {
+		ID string `json:"id"`
+	} `json:"ancestors"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+// List returns every current page in the space.
+func (c *ConfluenceSource) List(ctx context.Context) ([]SourceObject, error) {
+	if c.BaseURL == "" || c.SpaceKey == "" {
+		return nil, fmt.Errorf("confluence: base URL and space key are required")
+	}
+	var objects []SourceObject
+	const pageSize = 50
+	for start := 0; ; start += pageSize {
+		query := url.Values{
+			"spaceKey": {c.SpaceKey},
+			"type":     {"page"},
+			"status":   {"current"},
+			"expand":   {"version,ancestors"},
+			"limit":    {strconv.Itoa(pageSize)},
+			"start":    {strconv.Itoa(start)},
+		}
+		var page struct {
+			Results []confluencePage `json:"results"`
+			Links   struct {
+				Next string `json:"next"`
+			} `json:"_links"`
+		}
+		if err := c.get(ctx, "/rest/api/content", query, &page); err != nil {
+			return nil, err
+		}
+		for _, p := range page.Results {
+			obj := SourceObject{
+				Key:         pageFileName(p.Title, p.ID, ".html"),
+				URI:         c.pageURI(p.ID),
+				ETag:        strconv.Itoa(p.Version.Number),
+				Modified:    p.Version.When,
+				ContentType: "text/html",
+				Title:       p.Title,
+			}
+			if n := len(p.Ancestors); n > 0 {
+				obj.Parent = c.pageURI(p.Ancestors[n-1].ID)
+			}
+			objects = append(objects, obj)
+		}
+		if page.Links.Next == "" || len(page.Results) == 0 {
+			return objects, nil
+		}
+	}
+}
+
+// Open fetches the page body in storage format (XHTML).
+func (c *ConfluenceSource) Open(ctx context.Context, obj SourceObject) (io.ReadCloser, error) {
+	id := obj.URI[strings.LastIndexByte(obj.URI, '/')+1:]
+	var page confluencePage
+	if err := c.get(ctx, "/rest/api/content/"+url.PathEscape(id), url.Values{"expand": {"body.storage"}}, &page); err != nil {
+		return nil, err
+	}
+	doc := "<html><head><title>" + html.EscapeString(page.Title) + "</title></head><body>" + page.Body.Storage.Value + "</body></html>"
+	return io.NopCloser(strings.NewReader(doc)), nil
+}
+
+func (c *ConfluenceSource) pageURI(id string) string {
+	return "confluence://" + c.SpaceKey + "/" + id
+}
+
+func (c *ConfluenceSource) get(ctx context.Context, path string, query url.Values, out any) error {
+	u := strings.TrimRight(c.BaseURL, "/") + path + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.Email != "":
+		req.SetBasicAuth(c.Email, c.APIToken)
+	case c.APIToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	}
+	return doJSON(c.Client, req, "confluence", out)
+}
+
+// pageFileName builds a file name for a wiki page; the extension drives MIME
+// detection and the ID keeps colliding titles distinct.
+func pageFileName(title, id, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = "page"
+	}
+	return name + " (" + id + ")" + ext
+}
+
+// doJSON performs req and decodes a JSON response, reporting non-2xx
+// statuses with a snippet of the body.
+func doJSON(client *http.Client, req *http.Request, service string, out any) error {
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s: %s %s: http %d: %s", service, req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: decode %s: %w", service, req.URL.Path, err)
+	}
+	return nil
+}
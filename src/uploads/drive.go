@@ -0,0 +1,383 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const (
+	driveFolderMIME = "application/vnd.google-apps.folder"
+	driveFileFields = "id, name, mimeType, md5Checksum, version, modifiedTime, size, parents, trashed, driveId, permissions(type, role, emailAddress, domain)"
+)
+
+// driveExports maps Google-native formats to the format they are exported
+// in; other native types (forms, drawings, shortcuts) are skipped.
+var driveExports = map[string]struct{ mime, ext string }{
+	"application/vnd.google-apps.document":     {"text/html", ".html"},
+	"application/vnd.google-apps.spreadsheet":  {"text/csv", ".csv"},
+	"application/vnd.google-apps.presentation": {"text/plain", ".txt"},
+}
+
+// DriveSource syncs files from Google Drive. It implements ChangeSource, so
+// after the first listing a Syncer only fetches what the changes API
+// reports. Docs, Sheets and Slides are exported as HTML, CSV and plain text.
+//
+// Each file is stored in the space of its nearest folder in FolderSpaces or,
+// failing that and with SpacePrefix set, in SpacePrefix plus the name of its
+// top-level folder. File permissions become SourceObject.Grants: readers
+// and commenters map to SpaceRoleReader, writers and owners to
+// SpaceRoleWriter. Users and groups are named by email address, domains as
+// "domain:example.com" and link-shared files as "anyone".
+type DriveSource struct {
+	// FolderID limits the sync to one folder and its subfolders; empty
+	// syncs every file the credentials can see.
+	FolderID string
+	// DriveID selects a shared drive instead of My Drive.
+	DriveID      string
+	FolderSpaces map[string]string
+	SpacePrefix  string
+
+	service *drive.Service
+
+	mu      sync.Mutex
+	folders map[string]*drive.File // folder ID -> folder
+	exports map[string]string      // file ID -> export MIME type
+}
+
+// NewDriveSource connects with Application Default Credentials unless opts
+// supply others (for example option.WithCredentialsFile).
+func NewDriveSource(ctx context.Context, folderID string, opts ...option.ClientOption) (*DriveSource, error) {
+	if opts == nil {
+		opts = []option.ClientOption{option.WithScopes(drive.DriveReadonlyScope)}
+	}
+	svc, err := drive.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("drive: %w", err)
+	}
+	return &DriveSource{FolderID: folderID, service: svc}, nil
+}
+
+// List returns every supported file under FolderID and refreshes the folder
+// tree used to assign spaces.
+func (d *DriveSource) List(ctx context.Context) ([]SourceObject, error) {
+	if err := d.loadFolders(ctx); err != nil {
+		return nil, err
+	}
+	var objects []SourceObject
+	call := d.service.Files.List().Context(ctx).
+		Q("trashed = false and mimeType != '" + driveFolderMIME + "'").
+		Fields(googleapi.Field("nextPageToken, files(" + driveFileFields + ")")).
+		PageSize(1000)
+	if d.DriveID != "" {
+		call = call.Corpora("drive").DriveId(d.DriveID).IncludeItemsFromAllDrives(true).SupportsAllDrives(true)
+	}
+	err := call.Pages(ctx, func(page *drive.FileList) error {
+		for _, f := range page.Files {
+			obj, ok, err := d.object(ctx, f)
+			if err != nil {
+				return err
+			}
+			if ok {
+				objects = append(objects, obj)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("drive: list files: %w", err)
+	}
+	return objects, nil
+}
+
+// Cursor returns the changes API start page token.
+func (d *DriveSource) Cursor(ctx context.Context) (string, error) {
+	call := d.service.Changes.GetStartPageToken().Context(ctx)
+	if d.DriveID != "" {
+		call = call.DriveId(d.DriveID).SupportsAllDrives(true)
+	}
+	tok, err := call.Do()
+	if err != nil {
+		return "", fmt.Errorf("drive: start page token: %w", err)
+	}
+	return tok.StartPageToken, nil
+}
+
+// Changes reads the changes API from cursor. Folder changes can move whole
+// subtrees between spaces, so they ask for a resync instead, as does an
+// expired page token.
+func (d *DriveSource) Changes(ctx context.Context, cursor string) (changed []SourceObject, removed []string, next string, err error) {
+	d.mu.Lock()
+	loaded := d.folders != nil
+	d.mu.Unlock()
+	if !loaded {
+		if err := d.loadFolders(ctx); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	for token := cursor; token != ""; {
+		call := d.service.Changes.List(token).Context(ctx).
+			Fields(googleapi.Field("nextPageToken, newStartPageToken, changes(changeType, fileId, removed, file(" + driveFileFields + "))")).
+			IncludeRemoved(true).
+			PageSize(1000)
+		if d.DriveID != "" {
+			call = call.DriveId(d.DriveID).IncludeItemsFromAllDrives(true).SupportsAllDrives(true)
+		}
+		list, err := call.Do()
+		if err != nil {
+			var gerr *googleapi.Error
+			if errors.As(err, &gerr) && (gerr.Code == http.StatusNotFound || gerr.Code == http.StatusGone) {
+				return nil, nil, "", fmt.Errorf("drive: page token expired: %w", ErrResync)
+			}
+			return nil, nil, "", fmt.Errorf("drive: list changes: %w", err)
+		}
+		for _, c := range list.Changes {
+			if c.ChangeType == "drive" {
+				continue
+			}
+			if d.isFolder(c.FileId) || (c.File != nil && c.File.MimeType == driveFolderMIME) {
+				return nil, nil, "", fmt.Errorf("drive: folder %s changed: %w", c.FileId, ErrResync)
+			}
+			uri := driveURI(c.FileId)
+			if c.Removed || c.File == nil || c.File.Trashed {
+				removed = append(removed, uri)
+				continue
+			}
+			obj, ok, err := d.object(ctx, c.File)
+			if err != nil {
+				return nil, nil, "", err
+			}
+			if !ok {
+				// Moved out of FolderID or converted to an unsupported type.
+				removed = append(removed, uri)
+				continue
+			}
+			changed = append(changed, obj)
+		}
+		if list.NewStartPageToken != "" {
+			return changed, removed, list.NewStartPageToken, nil
+		}
+		token = list.NextPageToken
+	}
+	return changed, removed, cursor, nil
+}
+
+// Open downloads the file, exporting Google-native formats.
+func (d *DriveSource) Open(ctx context.Context, obj SourceObject) (io.ReadCloser, error) {
+	id := strings.TrimPrefix(obj.URI, "gdrive://")
+	d.mu.Lock()
+	export, native := d.exports[id]
+	d.mu.Unlock()
+	var (
+		resp *http.Response
+		err  error
+	)
+	if native {
+		resp, err = d.service.Files.Export(id, export).Context(ctx).Download()
+	} else {
+		resp, err = d.service.Files.Get(id).Context(ctx).SupportsAllDrives(true).Download()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("drive: get %s: %w", obj.URI, err)
+	}
+	return resp.Body, nil
+}
+
+func (d *DriveSource) loadFolders(ctx context.Context) error {
+	folders := map[string]*drive.File{}
+	call := d.service.Files.List().Context(ctx).
+		Q("trashed = false and mimeType = '" + driveFolderMIME + "'").
+		Fields("nextPageToken, files(id, name, parents)").
+		PageSize(1000)
+	if d.DriveID != "" {
+		call = call.Corpora("drive").DriveId(d.DriveID).IncludeItemsFromAllDrives(true).SupportsAllDrives(true)
+	}
+	err := call.Pages(ctx, func(page *drive.FileList) error {
+		for _, f := range page.Files {
+			folders[f.Id] = f
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("drive: list folders: %w", err)
+	}
+	d.mu.Lock()
+	d.folders = folders
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *DriveSource) isFolder(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.folders[id]
+	return ok
+}
+
+// object converts a Drive file, reporting false for files outside FolderID
+// and for native types that cannot be exported.
+func (d *DriveSource) object(ctx context.Context, f *drive.File) (SourceObject, bool, error) {
+	name, contentType, etag := f.Name, f.MimeType, f.Md5Checksum
+	export, native := driveExports[f.MimeType]
+	switch {
+	case native:
+		contentType = export.mime
+		if path.Ext(name) != export.ext {
+			name += export.ext
+		}
+	case strings.HasPrefix(f.MimeType, "application/vnd.google-apps."):
+		return SourceObject{}, false, nil
+	}
+	if etag == "" {
+		etag = strconv.FormatInt(f.Version, 10)
+	}
+
+	ancestors := d.ancestors(f)
+	if d.FolderID != "" {
+		i := indexOf(ancestors, d.FolderID)
+		if i < 0 {
+			return SourceObject{}, false, nil
+		}
+		ancestors = ancestors[:i]
+	}
+
+	perms := f.Permissions
+	if len(perms) == 0 && f.DriveId != "" {
+		// Shared drives leave permissions out of file listings.
+		var err error
+		if perms, err = d.permissions(ctx, f.Id); err != nil {
+			return SourceObject{}, false, err
+		}
+	}
+
+	modified, _ := time.Parse(time.RFC3339, f.ModifiedTime)
+	d.mu.Lock()
+	if d.exports == nil {
+		d.exports = map[string]string{}
+	}
+	if native {
+		d.exports[f.Id] = export.mime
+	} else {
+		delete(d.exports, f.Id)
+	}
+	d.mu.Unlock()
+	return SourceObject{
+		Key:         name,
+		URI:         driveURI(f.Id),
+		ETag:        etag,
+		Size:        f.Size,
+		Modified:    modified,
+		ContentType: contentType,
+		Title:       f.Name,
+		Space:       d.space(ancestors),
+		Grants:      driveGrants(perms),
+	}, true, nil
+}
+
+// ancestors returns the folder IDs above f, nearest first.
+func (d *DriveSource) ancestors(f *drive.File) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []string
+	seen := map[string]bool{}
+	for parents := f.Parents; len(parents) > 0 && !seen[parents[0]]; {
+		id := parents[0]
+		seen[id] = true
+		out = append(out, id)
+		folder := d.folders[id]
+		if folder == nil {
+			break
+		}
+		parents = folder.Parents
+	}
+	return out
+}
+
+// space picks the space for a file given its ancestors below FolderID.
+func (d *DriveSource) space(ancestors []string) string {
+	for _, id := range ancestors {
+		if space := d.FolderSpaces[id]; space != "" {
+			return space
+		}
+	}
+	if d.SpacePrefix == "" || len(ancestors) == 0 {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The root of My Drive (or of a shared drive) is not listed as a
+	// folder, so the top-level folder is the last listed ancestor.
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if folder := d.folders[ancestors[i]]; folder != nil {
+			return d.SpacePrefix + folder.Name
+		}
+	}
+	return ""
+}
+
+func (d *DriveSource) permissions(ctx context.Context, fileID string) ([]*drive.Permission, error) {
+	var perms []*drive.Permission
+	err := d.service.Permissions.List(fileID).Context(ctx).SupportsAllDrives(true).
+		Fields("nextPageToken, permissions(type, role, emailAddress, domain)").
+		Pages(ctx, func(page *drive.PermissionList) error {
+			perms = append(perms, page.Permissions...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("drive: permissions of %s: %w", fileID, err)
+	}
+	return perms, nil
+}
+
+func driveGrants(perms []*drive.Permission) map[string]memory.SpaceRole {
+	grants := map[string]memory.SpaceRole{}
+	for _, p := range perms {
+		var principal string
+		switch p.Type {
+		case "user", "group":
+			principal = strings.ToLower(p.EmailAddress)
+		case "domain":
+			principal = "domain:" + strings.ToLower(p.Domain)
+		case "anyone":
+			principal = "anyone"
+		}
+		if principal == "" || principal == "domain:" {
+			continue
+		}
+		role := memory.SpaceRoleReader
+		switch p.Role {
+		case "owner", "organizer", "fileOrganizer", "writer":
+			role = memory.SpaceRoleWriter
+		}
+		if grants[principal] != memory.SpaceRoleWriter {
+			grants[principal] = role
+		}
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	return grants
+}
+
+func driveURI(id string) string { return "gdrive://" + id }
+
+func indexOf(list []string, v string) int {
+	for i, s := range list {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
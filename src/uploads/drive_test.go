@@ -0,0 +1,105 @@
+package uploads
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"google.golang.org/api/option"
+)
+
+func TestDriveSyncFollowsChangesAndGrants(t *testing.T) {
+	const (
+		fileA = `{"id":"a","name":"a.txt","mimeType":"text/plain","md5Checksum":"m1","parents":["eng"],
+			"permissions":[{"type":"user","role":"reader","emailAddress":"Alice@example.com"}]}`
+		form = `{"id":"f","name":"Survey","mimeType":"application/vnd.google-apps.form","parents":["eng"]}`
+		doc  = `{"id":"doc","name":"Spec","mimeType":"application/vnd.google-apps.document","version":"%d","parents":["design"],
+			"permissions":[{"type":"user","role":"writer","emailAddress":"bob@example.com"}%s]}`
+	)
+	var listed int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case r.URL.Path == "/files" && strings.Contains(q.Get("q"), "mimeType = "):
+			fmt.Fprint(w, `{"files":[{"id":"eng","name":"Eng","parents":["root"]},{"id":"design","name":"Design","parents":["eng"]}]}`)
+		case r.URL.Path == "/files":
+			listed++
+			if listed == 1 {
+				fmt.Fprintf(w, `{"files":[%s,%s,%s]}`, fileA, form, fmt.Sprintf(doc, 3, `,{"type":"user","role":"commenter","emailAddress":"alice@example.com"}`))
+			} else {
+				fmt.Fprintf(w, `{"files":[%s]}`, fmt.Sprintf(doc, 4, ""))
+			}
+		case r.URL.Path == "/changes/startPageToken":
+			fmt.Fprint(w, `{"startPageToken":"t1"}`)
+		case r.URL.Path == "/changes" && q.Get("pageToken") == "t1":
+			fmt.Fprintf(w, `{"newStartPageToken":"t2","changes":[{"fileId":"a","removed":true},{"fileId":"doc","file":%s}]}`, fmt.Sprintf(doc, 4, ""))
+		case r.URL.Path == "/changes" && q.Get("pageToken") == "t2":
+			fmt.Fprint(w, `{"newStartPageToken":"t3","changes":[{"fileId":"eng","file":{"id":"eng","name":"Engineering","mimeType":"application/vnd.google-apps.folder"}}]}`)
+		case r.URL.Path == "/files/a" && q.Get("alt") == "media":
+			fmt.Fprint(w, "alpha notes")
+		case r.URL.Path == "/files/doc/export" && q.Get("mimeType") == "text/html":
+			fmt.Fprint(w, "<html><body><h1>Spec</h1><p>design spec</p></body></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	src, err := NewDriveSource(ctx, "", option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("NewDriveSource: %v", err)
+	}
+	src.FolderSpaces = map[string]string{"design": "team:design"}
+	src.SpacePrefix = "drive:"
+
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	spaces := memory.NewSpaceRegistry(0)
+	syncer := &Syncer{Source: src, Pipeline: NewPipeline(mem), SessionID: "kb", Spaces: spaces}
+
+	report, err := syncer.Sync(ctx)
+	if err != nil || len(report.Ingested) != 2 || len(report.Skipped) != 0 {
+		t.Fatalf("first sync = %+v, %v", report, err)
+	}
+	sessions := map[string]string{}
+	_ = store.Iterate(ctx, func(rec memory.MemoryRecord) bool {
+		sessions[rec.Content] = rec.SessionID
+		return true
+	})
+	for content, session := range sessions {
+		if strings.Contains(content, "alpha") && session != "drive:Eng" || strings.Contains(content, "design spec") && session != "team:design" {
+			t.Fatalf("record %q stored in %q", content, session)
+		}
+	}
+	if !spaces.CanRead("drive:Eng", "alice@example.com") || !spaces.CanWrite("team:design", "bob@example.com") || !spaces.CanRead("team:design", "alice@example.com") {
+		t.Fatal("file permissions were not mirrored as space grants")
+	}
+
+	report, err = syncer.Sync(ctx)
+	if err != nil || report.Listed != 1 || len(report.Ingested) != 1 || len(report.Removed) != 1 || report.Removed[0] != "gdrive://a" {
+		t.Fatalf("change sync = %+v, %v", report, err)
+	}
+	if listed != 1 {
+		t.Fatalf("change sync listed the drive again")
+	}
+	if spaces.CanRead("drive:Eng", "alice@example.com") || spaces.CanRead("team:design", "alice@example.com") || !spaces.CanWrite("team:design", "bob@example.com") {
+		t.Fatal("grants were not updated from the new permissions")
+	}
+	_ = store.Iterate(ctx, func(rec memory.MemoryRecord) bool {
+		if strings.Contains(rec.Content, "alpha") {
+			t.Fatalf("removed file still stored: %q", rec.Content)
+		}
+		return true
+	})
+
+	// A folder change invalidates space assignments and forces a listing.
+	report, err = syncer.Sync(ctx)
+	if err != nil || listed != 2 || report.Unchanged != 1 || len(report.Ingested) != 0 {
+		t.Fatalf("resync = %+v, %v (listed %d)", report, err, listed)
+	}
+}
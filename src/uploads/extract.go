@@ -0,0 +1,158 @@
+// Package uploads turns user-supplied documents into long-term memories:
+// files are converted to text by a MIME-specific Extractor, chunked, and
+// stored through a SessionMemory.
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoExtractor is returned when no extractor is registered for a MIME type.
+var ErrNoExtractor = errors.New("no extractor for MIME")
+
+// Extractor converts a document to plain text.
+type Extractor interface {
+	Extract(ctx context.Context, name string, data []byte) (string, error)
+}
+
+// ExtractorFunc adapts a function to Extractor.
+type ExtractorFunc func(ctx context.Context, name string, data []byte) (string, error)
+
+// Extract calls f.
+func (f ExtractorFunc) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	return f(ctx, name, data)
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   = map[string]Extractor{
+		"text/plain":       PlainTextExtractor{},
+		"text/markdown":    PlainTextExtractor{},
+		"text/csv":         PlainTextExtractor{},
+		"application/json": PlainTextExtractor{},
+		"application/xml":  PlainTextExtractor{},
+		"text/xml":         PlainTextExtractor{},
+		"application/yaml": PlainTextExtractor{},
+		"text/html":        HTMLExtractor{},
+		"application/pdf":  PDFTextExtractor{},
+	}
+)
+
+// RegisterExtractor installs e for mimeType, replacing any existing entry.
+func RegisterExtractor(mimeType string, e Extractor) {
+	mimeType = normalizeMIME(mimeType)
+	if mimeType == "" || e == nil {
+		return
+	}
+	extractorsMu.Lock()
+	extractors[mimeType] = e
+	extractorsMu.Unlock()
+}
+
+// ExtractorFor returns the extractor registered for mimeType. Any text/*
+// type without a dedicated extractor is handled as plain text.
+func ExtractorFor(mimeType string) (Extractor, bool) {
+	mimeType = normalizeMIME(mimeType)
+	extractorsMu.RLock()
+	e, ok := extractors[mimeType]
+	extractorsMu.RUnlock()
+	if ok {
+		return e, true
+	}
+	if strings.HasPrefix(mimeType, "text/") || strings.HasSuffix(mimeType, "+json") || strings.HasSuffix(mimeType, "+xml") {
+		return PlainTextExtractor{}, true
+	}
+	return nil, false
+}
+
+// Extract detects the document type and extracts its text.
+func Extract(ctx context.Context, name, mimeType string, data []byte) (string, error) {
+	mimeType = DetectMIME(name, mimeType, data)
+	e, ok := ExtractorFor(mimeType)
+	if !ok {
+		return "", fmt.Errorf("%w %q (%s)", ErrNoExtractor, mimeType, name)
+	}
+	return e.Extract(ctx, name, data)
+}
+
+// DetectMIME returns declared when it is specific, otherwise guesses from the
+// file extension and content.
+func DetectMIME(name, declared string, data []byte) string {
+	declared = normalizeMIME(declared)
+	if declared != "" && declared != "application/octet-stream" {
+		return declared
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown":
+		return "text/markdown"
+	case ".yaml", ".yml":
+		return "application/yaml"
+	}
+	if byExt := normalizeMIME(mime.TypeByExtension(filepath.Ext(name))); byExt != "" {
+		return byExt
+	}
+	if strings.HasPrefix(string(data), "%PDF-") {
+		return "application/pdf"
+	}
+	if utf8.Valid(data) {
+		return "text/plain"
+	}
+	return "application/octet-stream"
+}
+
+func normalizeMIME(m string) string {
+	m = strings.ToLower(strings.TrimSpace(m))
+	if semi := strings.IndexByte(m, ';'); semi >= 0 {
+		m = strings.TrimSpace(m[:semi])
+	}
+	switch m {
+	case "application/x-yaml", "text/yaml", "text/x-yaml":
+		return "application/yaml"
+	case "text/x-markdown":
+		return "text/markdown"
+	}
+	return m
+}
+
+// PlainTextExtractor returns UTF-8 documents unchanged.
+type PlainTextExtractor struct{}
+
+// Extract implements Extractor.
+func (PlainTextExtractor) Extract(_ context.Context, name string, data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%s is not valid UTF-8 text", name)
+	}
+	return string(data), nil
+}
+
+var (
+	htmlDropBlocks = regexp.MustCompile(`(?is)<(script|style|noscript)[^>]*>.*?</(script|style|noscript)>`)
+	htmlBreaks     = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr)[^>]*>`)
+	htmlTags       = regexp.MustCompile(`(?s)<[^>]+>`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLExtractor strips markup and scripts, keeping block boundaries as newlines.
+type HTMLExtractor struct{}
+
+// Extract implements Extractor.
+func (HTMLExtractor) Extract(_ context.Context, _ string, data []byte) (string, error) {
+	s := htmlDropBlocks.ReplaceAllString(string(data), "")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")), nil
+}
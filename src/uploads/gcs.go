@@ -0,0 +1,72 @@
+package uploads
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// GCSSource lists and fetches objects from a Google Cloud Storage bucket
+// through the JSON API.
+type GCSSource struct {
+	Bucket  string
+	Prefix  string
+	service *storage.Service
+}
+
+// NewGCSSource connects with Application Default Credentials unless opts
+// supply others (for example option.WithCredentialsFile).
+func NewGCSSource(ctx context.Context, bucket, prefix string, opts ...option.ClientOption) (*GCSSource, error) {
+	if opts == nil {
+		opts = []option.ClientOption{option.WithScopes(storage.DevstorageReadOnlyScope)}
+	}
+	svc, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("gcs: %w", err)
+	}
+	return &GCSSource{Bucket: bucket, Prefix: prefix, service: svc}, nil
+}
+
+// List returns every object under Prefix, skipping "directory" placeholders.
+func (g *GCSSource) List(ctx context.Context) ([]SourceObject, error) {
+	var objects []SourceObject
+	call := g.service.Objects.List(g.Bucket).Context(ctx)
+	if g.Prefix != "" {
+		call = call.Prefix(g.Prefix)
+	}
+	err := call.Pages(ctx, func(page *storage.Objects) error {
+		for _, o := range page.Items {
+			if strings.HasSuffix(o.Name, "/") {
+				continue
+			}
+			modified, _ := time.Parse(time.RFC3339, o.Updated)
+			objects = append(objects, SourceObject{
+				Key:         o.Name,
+				URI:         "gs://" + g.Bucket + "/" + o.Name,
+				ETag:        o.Etag,
+				Size:        int64(o.Size),
+				Modified:    modified,
+				ContentType: o.ContentType,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcs: list gs://%s/%s: %w", g.Bucket, g.Prefix, err)
+	}
+	return objects, nil
+}
+
+// Open streams the object body.
+func (g *GCSSource) Open(ctx context.Context, obj SourceObject) (io.ReadCloser, error) {
+	resp, err := g.service.Objects.Get(g.Bucket, obj.Key).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("gcs: get %s: %w", obj.URI, err)
+	}
+	return resp.Body, nil
+}
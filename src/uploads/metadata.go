@@ -0,0 +1,254 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+// DocumentMetadata is document-level attribution attached to every chunk.
+type DocumentMetadata struct {
+	Title   string            `json:"title,omitempty"`
+	Author  string            `json:"author,omitempty"`
+	Created time.Time         `json:"created,omitzero"`
+	Extra   map[string]string `json:"extra,omitempty"`
+}
+
+// IsZero reports whether no metadata was found.
+func (m DocumentMetadata) IsZero() bool {
+	return m.Title == "" && m.Author == "" && m.Created.IsZero() && len(m.Extra) == 0
+}
+
+// Merge fills empty fields of m from other; values already present win.
+func (m DocumentMetadata) Merge(other DocumentMetadata) DocumentMetadata {
+	if m.Title == "" {
+		m.Title = other.Title
+	}
+	if m.Author == "" {
+		m.Author = other.Author
+	}
+	if m.Created.IsZero() {
+		m.Created = other.Created
+	}
+	for k, v := range other.Extra {
+		if m.Extra == nil {
+			m.Extra = map[string]string{}
+		}
+		if _, ok := m.Extra[k]; !ok {
+			m.Extra[k] = v
+		}
+	}
+	return m
+}
+
+// reservedFields are record metadata keys that a document's own metadata
+// may not set: the tenant is taken from the context and the ACL from
+// Document.ACL, so front-matter cannot move a document into another tenant
+// or widen who may read it.
+var reservedFields = map[string]bool{
+	memory.MetaTenant:        true,
+	memory.MetaACLPrincipals: true,
+	memory.MetaACLGroups:     true,
+}
+
+// fields flattens the metadata into memory record metadata keys.
+func (m DocumentMetadata) fields() map[string]any {
+	out := map[string]any{}
+	if m.Title != "" {
+		out["title"] = m.Title
+	}
+	if m.Author != "" {
+		out["author"] = m.Author
+	}
+	if !m.Created.IsZero() {
+		out["created"] = m.Created.UTC().Format(time.RFC3339)
+	}
+	for k, v := range m.Extra {
+		if _, taken := out[k]; !taken && v != "" && !reservedFields[k] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// ExtractMetadata reads embedded document metadata: the PDF Info dictionary,
+// Markdown front-matter, or HTML <title> and author meta tags.
+func ExtractMetadata(name, mimeType string, data []byte) DocumentMetadata {
+	switch DetectMIME(name, mimeType, data) {
+	case "application/pdf":
+		return pdfMetadata(data)
+	case "text/markdown":
+		meta, _ := splitFrontMatter(string(data))
+		return meta
+	case "text/html":
+		return htmlMetadata(data)
+	}
+	return DocumentMetadata{}
+}
+
+var pdfInfoKey = regexp.MustCompile(`/(Title|Author|Subject|Keywords|CreationDate)\s*[(<]`)
+
+func pdfMetadata(data []byte) DocumentMetadata {
+	var meta DocumentMetadata
+	for _, loc := range pdfInfoKey.FindAllSubmatchIndex(data, -1) {
+		key := string(data[loc[2]:loc[3]])
+		lex := pdfLexer{data: data, pos: loc[1] - 1}
+		tok, ok := lex.next()
+		if !ok || tok.kind != 's' {
+			continue
+		}
+		value := strings.TrimSpace(tok.str)
+		if value == "" {
+			continue
+		}
+		switch key {
+		case "Title":
+			meta.Title = value
+		case "Author":
+			meta.Author = value
+		case "CreationDate":
+			meta.Created = parsePDFDate(value)
+		default:
+			if meta.Extra == nil {
+				meta.Extra = map[string]string{}
+			}
+			meta.Extra[strings.ToLower(key)] = value
+		}
+	}
+	return meta
+}
+
+// parsePDFDate parses D:YYYYMMDDHHmmSSOHH'mm' dates, tolerating truncation.
+func parsePDFDate(s string) time.Time {
+	s = strings.TrimPrefix(s, "D:")
+	s = strings.ReplaceAll(s, "'", "")
+	layouts := []string{"20060102150405-0700", "20060102150405Z", "20060102150405", "200601021504", "20060102", "2006"}
+	if strings.HasSuffix(s, "Z") && len(s) > 15 {
+		s = s[:15] + "Z"
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// splitFrontMatter separates a leading "---" delimited front-matter block
+// from Markdown text. Only flat "key: value" pairs are interpreted.
+func splitFrontMatter(text string) (DocumentMetadata, string) {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return DocumentMetadata{}, text
+	}
+	// The block ends at the first line that is exactly "---"; only that
+	// line and its newline are cut, so a body opening with "- item" or a
+	// rule keeps its dashes.
+	rest := "\n" + normalized[4:]
+	var block, body string
+	for off := 0; ; {
+		end := strings.Index(rest[off:], "\n---")
+		if end < 0 {
+			return DocumentMetadata{}, text
+		}
+		end += off
+		after := rest[end+len("\n---"):]
+		if after == "" || after[0] == '\n' {
+			block = strings.TrimPrefix(rest[:end], "\n")
+			body, _ = strings.CutPrefix(after, "\n")
+			break
+		}
+		off = end + 1
+	}
+
+	var meta DocumentMetadata
+	for _, line := range strings.Split(block, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if key == "" || value == "" {
+			continue
+		}
+		switch key {
+		case "title":
+			meta.Title = value
+		case "author", "authors":
+			meta.Author = value
+		case "date", "created":
+			for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+				if t, err := time.Parse(layout, value); err == nil {
+					meta.Created = t
+					break
+				}
+			}
+		default:
+			if meta.Extra == nil {
+				meta.Extra = map[string]string{}
+			}
+			meta.Extra[key] = value
+		}
+	}
+	return meta, body
+}
+
+var (
+	htmlTitle  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlAuthor = regexp.MustCompile(`(?is)<meta\s+[^>]*name=["']author["'][^>]*content=["']([^"']*)["']`)
+)
+
+func htmlMetadata(data []byte) DocumentMetadata {
+	var meta DocumentMetadata
+	if m := htmlTitle.FindSubmatch(data); m != nil {
+		meta.Title = strings.Join(strings.Fields(string(m[1])), " ")
+	}
+	if m := htmlAuthor.FindSubmatch(data); m != nil {
+		meta.Author = strings.TrimSpace(string(m[1]))
+	}
+	return meta
+}
+
+// GitMetadata describes the last commit touching path when it lives in a git
+// work tree. It returns zero metadata and no error outside a repository or
+// when git is not installed.
+func GitMetadata(ctx context.Context, path string) (DocumentMetadata, error) {
+	bin, err := exec.LookPath("git")
+	if err != nil {
+		return DocumentMetadata{}, nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return DocumentMetadata{}, err
+	}
+	cmd := exec.CommandContext(ctx, bin, "log", "-1", "--format=%an%x00%aI%x00%H", "--", filepath.Base(abs))
+	cmd.Dir = filepath.Dir(abs)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		// Not a repository (or untracked): nothing to attribute.
+		return DocumentMetadata{}, nil
+	}
+	parts := strings.Split(strings.TrimSpace(stdout.String()), "\x00")
+	if len(parts) != 3 {
+		return DocumentMetadata{}, nil
+	}
+	meta := DocumentMetadata{
+		Author: parts[0],
+		Extra:  map[string]string{"git_commit": parts[2]},
+	}
+	if t, err := time.Parse(time.RFC3339, parts[1]); err == nil {
+		meta.Extra["git_modified"] = t.UTC().Format(time.RFC3339)
+	} else {
+		return meta, fmt.Errorf("parse git date %q: %w", parts[1], err)
+	}
+	return meta, nil
+}
@@ -0,0 +1,250 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+)
+
+const (
+	notionAPIBase = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// notionMaxDepth bounds recursion into nested blocks (toggles, lists).
+	notionMaxDepth = 4
+)
+
+// NotionSource pulls every page shared with a Notion integration. Page
+// content is rendered to Markdown, last_edited_time acts as the ETag and
+// parent pages become Parent links.
+type NotionSource struct {
+	Token string
+	// BaseURL overrides the API endpoint; used by tests.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewNotionSourceFromEnv reads the integration token from NOTION_API_KEY
+// (or NOTION_TOKEN).
+func NewNotionSourceFromEnv() *NotionSource {
+	return &NotionSource{Token: secrets.Lookup("NOTION_API_KEY", "NOTION_TOKEN")}
+}
+
+type notionRichText []struct {
+	PlainText string `json:"plain_text"`
+}
+
+func (r notionRichText) String() string {
+	var sb strings.Builder
+	for _, t := range r {
+		sb.WriteString(t.PlainText)
+	}
+	return sb.String()
+}
+
+type notionPage struct {
+	ID             string    `json:"id"`
+	Object         string    `json:"object"`
+	LastEditedTime time.Time `json:"last_edited_time"`
+	Archived       bool      `json:"archived"`
+	Parent         struct {
+		Type   string `json:"type"`
+		PageID string `json:"page_id"`
+	} `json:"parent"`
+	Properties map[string]struct {
+		Type  string         `json:"type"`
+		Title notionRichText `json:"title"`
+	} `json:"properties"`
+}
+
+func (p notionPage) title() string {
+	for _, prop := range p.Properties {
+		if prop.Type == "title" {
+			return strings.TrimSpace(prop.Title.String())
+		}
+	}
+	return ""
+}
+
+// List returns every non-archived page visible to the integration.
+func (n *NotionSource) List(ctx context.Context) ([]SourceObject, error) {
+	var (
+		objects []SourceObject
+		cursor  string
+	)
+	for {
+		body := map[string]any{
+			"filter":    map[string]string{"property": "object", "value": "page"},
+			"page_size": 100,
+		}
+		if cursor != "" {
+			body["start_cursor"] = cursor
+		}
+		var resp struct {
+			Results    []notionPage `json:"results"`
+			HasMore    bool         `json:"has_more"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		if err := n.call(ctx, http.MethodPost, "/search", nil, body, &resp); err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Results {
+			if p.Archived || p.Object != "page" {
+				continue
+			}
+			title := p.title()
+			obj := SourceObject{
+				Key:         pageFileName(title, p.ID, ".md"),
+				URI:         "notion://" + p.ID,
+				ETag:        p.LastEditedTime.UTC().Format(time.RFC3339Nano),
+				Modified:    p.LastEditedTime,
+				ContentType: "text/markdown",
+				Title:       title,
+			}
+			if p.Parent.Type == "page_id" && p.Parent.PageID != "" {
+				obj.Parent = "notion://" + p.Parent.PageID
+			}
+			objects = append(objects, obj)
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			return objects, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// Open renders the page's blocks as Markdown.
+func (n *NotionSource) Open(ctx context.Context, obj SourceObject) (io.ReadCloser, error) {
+	var sb strings.Builder
+	if obj.Title != "" {
+		sb.WriteString("# " + obj.Title + "\n\n")
+	}
+	if err := n.renderBlocks(ctx, strings.TrimPrefix(obj.URI, "notion://"), 0, &sb); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(sb.String())), nil
+}
+
+type notionBlock struct {
+	ID          string                     `json:"id"`
+	Type        string                     `json:"type"`
+	HasChildren bool                       `json:"has_children"`
+	Content     map[string]json.RawMessage `json:"-"`
+}
+
+func (b *notionBlock) UnmarshalJSON(data []byte) error {
+	type plain notionBlock
+	if err := json.Unmarshal(data, (*plain)(b)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &b.Content)
+}
+
+// text returns the block's plain text and, for to-do items, its state.
+func (b notionBlock) text() (string, bool) {
+	var body struct {
+		RichText notionRichText `json:"rich_text"`
+		Checked  bool           `json:"checked"`
+	}
+	_ = json.Unmarshal(b.Content[b.Type], &body)
+	return body.RichText.String(), body.Checked
+}
+
+func (n *NotionSource) renderBlocks(ctx context.Context, blockID string, depth int, sb *strings.Builder) error {
+	indent := strings.Repeat("  ", depth)
+	cursor := ""
+	for {
+		query := url.Values{"page_size": {"100"}}
+		if cursor != "" {
+			query.Set("start_cursor", cursor)
+		}
+		var resp struct {
+			Results    []notionBlock `json:"results"`
+			HasMore    bool          `json:"has_more"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := n.call(ctx, http.MethodGet, "/blocks/"+url.PathEscape(blockID)+"/children", query, nil, &resp); err != nil {
+			return err
+		}
+		for _, b := range resp.Results {
+			text, checked := b.text()
+			switch b.Type {
+			case "heading_1":
+				sb.WriteString("# " + text + "\n\n")
+			case "heading_2":
+				sb.WriteString("## " + text + "\n\n")
+			case "heading_3":
+				sb.WriteString("### " + text + "\n\n")
+			case "bulleted_list_item", "toggle":
+				sb.WriteString(indent + "- " + text + "\n")
+			case "numbered_list_item":
+				sb.WriteString(indent + "1. " + text + "\n")
+			case "to_do":
+				box := "[ ]"
+				if checked {
+					box = "[x]"
+				}
+				sb.WriteString(indent + "- " + box + " " + text + "\n")
+			case "quote", "callout":
+				sb.WriteString("> " + text + "\n\n")
+			case "code":
+				sb.WriteString("```\n" + text + "\n```\n\n")
+			case "child_page", "child_database":
+				// Synced as pages of their own.
+				continue
+			default:
+				if text != "" {
+					sb.WriteString(indent + text + "\n\n")
+				}
+			}
+			if b.HasChildren && depth < notionMaxDepth {
+				if err := n.renderBlocks(ctx, b.ID, depth+1, sb); err != nil {
+					return err
+				}
+			}
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			return nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+func (n *NotionSource) call(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	if n.Token == "" {
+		return fmt.Errorf("notion: integration token is empty")
+	}
+	base := n.BaseURL
+	if base == "" {
+		base = notionAPIBase
+	}
+	u := strings.TrimRight(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.Token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return doJSON(n.Client, req, "notion", out)
+}
@@ -0,0 +1,426 @@
+package uploads
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrEncryptedPDF is returned for password-protected PDFs, which the pure-Go
+// extractor cannot decrypt.
+var ErrEncryptedPDF = errors.New("encrypted PDF not supported")
+
+// maxPDFStreamSize caps the decompressed size of a single content stream.
+const maxPDFStreamSize = 64 << 20
+
+// PDFTextExtractor is a dependency-free PDF text extractor. It decodes
+// Flate-compressed content streams and collects the strings drawn by the text
+// operators (Tj, TJ, ', "). Layout, custom font encodings and scanned pages are
+// out of scope; builds with -tags=pdf prefer a higher-fidelity extractor when
+// one is available at runtime.
+type PDFTextExtractor struct{}
+
+var pdfStreamStart = regexp.MustCompile(`>>\s*stream\r?\n`)
+
+// Extract implements Extractor.
+func (PDFTextExtractor) Extract(ctx context.Context, _ string, data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return "", errors.New("pdf: missing %PDF header")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", ErrEncryptedPDF
+	}
+
+	var out strings.Builder
+	for _, loc := range pdfStreamStart.FindAllIndex(data, -1) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		// The stream dictionary runs from the enclosing "N G obj" header.
+		dictStart := bytes.LastIndex(data[:loc[0]], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := data[dictStart:loc[0]]
+		if skipPDFStream(dict) {
+			continue
+		}
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[start : start+end]
+		content, ok := decodePDFStream(dict, raw)
+		if !ok || !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		extractPDFText(content, &out)
+	}
+
+	text := normalizePDFText(out.String())
+	if text == "" {
+		return "", errors.New("pdf: no extractable text (scanned or image-only document?)")
+	}
+	return text, nil
+}
+
+func skipPDFStream(dict []byte) bool {
+	for _, marker := range []string{"/Image", "/XRef", "/ObjStm", "/Metadata", "/EmbeddedFile", "/FontFile", "/Length1"} {
+		if bytes.Contains(dict, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+func decodePDFStream(dict, raw []byte) ([]byte, bool) {
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return raw, true
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) {
+		// DCT, JBIG2, LZW and friends carry no extractable text for us.
+		return nil, false
+	}
+	raw = bytes.TrimRight(raw, "\r\n")
+	if zr, err := zlib.NewReader(bytes.NewReader(raw)); err == nil {
+		decoded, err := io.ReadAll(io.LimitReader(zr, maxPDFStreamSize))
+		zr.Close()
+		if err == nil || len(decoded) > 0 {
+			return decoded, true
+		}
+	}
+	fr := flate.NewReader(bytes.NewReader(raw))
+	defer fr.Close()
+	decoded, err := io.ReadAll(io.LimitReader(fr, maxPDFStreamSize))
+	if err != nil && len(decoded) == 0 {
+		return nil, false
+	}
+	return decoded, true
+}
+
+type pdfToken struct {
+	kind byte // 'n' number, 's' string, 'a' array, 'o' operator, '/' name, '[' array start
+	num  float64
+	str  string
+	arr  []pdfToken
+}
+
+// extractPDFText interprets the text-showing operators of a content stream.
+func extractPDFText(content []byte, out *strings.Builder) {
+	lex := pdfLexer{data: content}
+	var stack []pdfToken
+	lineY := -1.0
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			return
+		}
+		switch tok.kind {
+		case '[':
+			stack = append(stack, tok)
+			continue
+		case ']':
+			i := len(stack) - 1
+			for i >= 0 && stack[i].kind != '[' {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			arr := append([]pdfToken(nil), stack[i+1:]...)
+			stack = append(stack[:i], pdfToken{kind: 'a', arr: arr})
+			continue
+		case 'o':
+		default:
+			stack = append(stack, tok)
+			continue
+		}
+
+		switch tok.str {
+		case "Tj":
+			if s, ok := lastString(stack); ok {
+				out.WriteString(s)
+			}
+		case "'", "\"":
+			newline(out)
+			if s, ok := lastString(stack); ok {
+				out.WriteString(s)
+			}
+		case "TJ":
+			if len(stack) > 0 && stack[len(stack)-1].kind == 'a' {
+				for _, el := range stack[len(stack)-1].arr {
+					switch el.kind {
+					case 's':
+						out.WriteString(el.str)
+					case 'n':
+						// Large negative kerning is how most generators encode a word gap.
+						if el.num < -200 {
+							space(out)
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(stack) >= 2 && stack[len(stack)-1].kind == 'n' && stack[len(stack)-1].num != 0 {
+				newline(out)
+			} else {
+				space(out)
+			}
+		case "Tm":
+			// Generators often position every word with Tm; only a change of
+			// baseline starts a new line.
+			if len(stack) >= 6 && stack[len(stack)-1].kind == 'n' {
+				if y := stack[len(stack)-1].num; y != lineY {
+					lineY = y
+					newline(out)
+				} else {
+					space(out)
+				}
+			} else {
+				newline(out)
+			}
+		case "T*":
+			newline(out)
+		case "ET":
+			space(out)
+		case "ID":
+			lex.skipInlineImage()
+		}
+		stack = stack[:0]
+	}
+}
+
+func lastString(stack []pdfToken) (string, bool) {
+	if len(stack) == 0 || stack[len(stack)-1].kind != 's' {
+		return "", false
+	}
+	return stack[len(stack)-1].str, true
+}
+
+func newline(out *strings.Builder) {
+	s := out.String()
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		out.WriteByte('\n')
+	}
+}
+
+func space(out *strings.Builder) {
+	s := out.String()
+	if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+		out.WriteByte(' ')
+	}
+}
+
+func normalizePDFText(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: 's', str: decodePDFString(l.literal())}, true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+			l.pos += 2
+			return pdfToken{kind: 'd'}, true
+		case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+			return pdfToken{kind: 'd'}, true
+		case c == '<':
+			return pdfToken{kind: 's', str: decodePDFString(l.hex())}, true
+		case c == '[' || c == ']':
+			l.pos++
+			return pdfToken{kind: c}, true
+		case c == '/':
+			l.pos++
+			return pdfToken{kind: '/', str: l.regular()}, true
+		case c == '{' || c == '}' || c == ')' || c == '>':
+			l.pos++
+		default:
+			word := l.regular()
+			if n, err := strconv.ParseFloat(word, 64); err == nil {
+				return pdfToken{kind: 'n', num: n}, true
+			}
+			return pdfToken{kind: 'o', str: word}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+func (l *pdfLexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func (l *pdfLexer) literal() []byte {
+	l.pos++ // (
+	var buf []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '\\':
+			if l.pos >= len(l.data) {
+				return buf
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'b':
+				buf = append(buf, '\b')
+			case 'f':
+				buf = append(buf, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					buf = append(buf, byte(v))
+				} else {
+					buf = append(buf, e)
+				}
+			}
+		case '(':
+			depth++
+			buf = append(buf, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf
+			}
+			buf = append(buf, c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+func (l *pdfLexer) hex() []byte {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		c := l.data[l.pos]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// skipInlineImage advances past the binary payload of a BI … ID … EI block.
+func (l *pdfLexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' && isPDFSpace(l.data[l.pos-1]) &&
+			(l.pos+2 == len(l.data) || isPDFSpace(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+// decodePDFString maps PDF string bytes to UTF-8. UTF-16BE (with or without a
+// BOM, as emitted for Identity-H fonts with Unicode glyph IDs) is detected;
+// everything else is treated as Latin-1, which matches PDFDocEncoding and
+// WinAnsi for the printable ASCII range.
+func decodePDFString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		return decodeUTF16BE(b[2:])
+	}
+	if len(b) >= 2 && len(b)%2 == 0 {
+		zeroHigh := 0
+		for i := 0; i < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] != 0 {
+				zeroHigh++
+			}
+		}
+		if zeroHigh == len(b)/2 {
+			return decodeUTF16BE(b)
+		}
+	}
+	runes := make([]rune, 0, len(b))
+	for _, c := range b {
+		if c < 0x20 && c != '\n' && c != '\t' {
+			continue
+		}
+		runes = append(runes, rune(c))
+	}
+	return string(runes)
+}
+
+func decodeUTF16BE(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
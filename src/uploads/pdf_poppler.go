@@ -0,0 +1,42 @@
+//go:build pdf
+
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// With -tags=pdf the PDF extractor shells out to poppler's pdftotext, which
+// handles font encodings and layout far better than the pure-Go fallback. The
+// binary is resolved at call time so the same build works on hosts without
+// poppler installed.
+func init() {
+	RegisterExtractor("application/pdf", popplerExtractor{fallback: PDFTextExtractor{}})
+}
+
+type popplerExtractor struct {
+	fallback Extractor
+}
+
+func (p popplerExtractor) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	bin, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return p.fallback.Extract(ctx, name, data)
+	}
+	cmd := exec.CommandContext(ctx, bin, "-enc", "UTF-8", "-layout", "-", "-")
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if text, fbErr := p.fallback.Extract(ctx, name, data); fbErr == nil {
+			return text, nil
+		}
+		return "", fmt.Errorf("pdftotext %s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,348 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+	"github.com/Protocol-Lattice/go-agent/src/models/middleware"
+)
+
+const defaultChunkSize = 2000
+
+// Result summarises a single ingested document.
+type Result struct {
+	Name   string `json:"name"`
+	MIME   string `json:"mime"`
+	Chunks int    `json:"chunks"`
+	Chars  int    `json:"chars"`
+
+	Metadata DocumentMetadata `json:"metadata"`
+	// DocumentID is the long-term record ID of the document-level record,
+	// zero when the store could not report it.
+	DocumentID int64 `json:"document_id,omitempty"`
+
+	// EmbeddingTokens estimates the tokens sent to the embedder for the
+	// chunks and the document record, and EstimatedCost prices them at
+	// IngestOptions.EmbeddingPricePerMillion.
+	EmbeddingTokens int64         `json:"embedding_tokens"`
+	EstimatedCost   float64       `json:"estimated_cost,omitempty"`
+	Timings         IngestTimings `json:"timings"`
+	// DuplicateChunks counts chunks the engine's duplicate check folded
+	// into memories already stored.
+	DuplicateChunks int      `json:"duplicate_chunks,omitempty"`
+	Warnings        []string `json:"warnings,omitempty"`
+}
+
+// IngestTimings breaks down where a document's ingestion time went.
+type IngestTimings struct {
+	Extract time.Duration `json:"extract"`
+	Chunk   time.Duration `json:"chunk"`
+	Embed   time.Duration `json:"embed"`
+	Write   time.Duration `json:"write"`
+}
+
+// Total is the sum of the stages.
+func (t IngestTimings) Total() time.Duration {
+	return t.Extract + t.Chunk + t.Embed + t.Write
+}
+
+// IngestOptions configures how documents are prepared for storage.
+// PerMIME overrides Chunking for specific types, either exact ("text/html")
+// or by major type ("text/*").
+type IngestOptions struct {
+	Chunking ChunkOptions
+	PerMIME  map[string]ChunkOptions
+	// TokenEstimator counts embedding tokens for Result.EmbeddingTokens;
+	// middleware.ApproximateTokenCount when nil.
+	TokenEstimator middleware.TokenEstimator
+	// EmbeddingPricePerMillion is the embedder's price per million input
+	// tokens, in the caller's currency. Zero leaves EstimatedCost empty.
+	EmbeddingPricePerMillion float64
+}
+
+// ChunkingFor resolves the chunk options used for mimeType.
+func (o IngestOptions) ChunkingFor(mimeType string) ChunkOptions {
+	mimeType = normalizeMIME(mimeType)
+	if opts, ok := o.PerMIME[mimeType]; ok {
+		return opts
+	}
+	if slash := strings.IndexByte(mimeType, '/'); slash > 0 {
+		if opts, ok := o.PerMIME[mimeType[:slash]+"/*"]; ok {
+			return opts
+		}
+	}
+	return o.Chunking
+}
+
+// Pipeline extracts, chunks and stores documents as long-term memories.
+type Pipeline struct {
+	Memory  *memory.SessionMemory
+	Options IngestOptions
+}
+
+// NewPipeline returns a pipeline that writes into mem using the default
+// line-based chunking.
+func NewPipeline(mem *memory.SessionMemory) *Pipeline {
+	return &Pipeline{Memory: mem, Options: IngestOptions{Chunking: DefaultChunkOptions()}}
+}
+
+// Document is a file plus caller-supplied metadata (for example from
+// GitMetadata). Supplied fields take precedence over embedded metadata.
+// Edges are attached to every stored record, linking the document into the
+// memory graph (for example to its parent page). ACL, when set, is stamped
+// on every chunk so retrieval only surfaces them to the listed principals
+// and groups.
+type Document struct {
+	File     models.File
+	Metadata DocumentMetadata
+	Edges    []memory.GraphEdge
+	ACL      memory.ACL
+}
+
+// Ingest stores every file under sessionID and returns per-file results in
+// input order. Ingestion stops at the first failing file.
+func (p *Pipeline) Ingest(ctx context.Context, sessionID string, files ...models.File) ([]Result, error) {
+	docs := make([]Document, len(files))
+	for i, file := range files {
+		docs[i] = Document{File: file}
+	}
+	return p.IngestDocuments(ctx, sessionID, docs...)
+}
+
+// IngestAttachment ingests a file an agent received into space. It lets
+// a Pipeline serve as an agent.AttachmentIngestor.
+func (p *Pipeline) IngestAttachment(ctx context.Context, space string, file models.File) error {
+	_, err := p.Ingest(ctx, space, file)
+	return err
+}
+
+// IngestDocuments is Ingest with per-document metadata.
+func (p *Pipeline) IngestDocuments(ctx context.Context, sessionID string, docs ...Document) ([]Result, error) {
+	if p == nil || p.Memory == nil {
+		return nil, errors.New("uploads: pipeline has no memory")
+	}
+	if strings.TrimSpace(sessionID) == "" {
+		return nil, errors.New("uploads: session id is required")
+	}
+
+	results := make([]Result, 0, len(docs))
+	for _, doc := range docs {
+		res, err := p.ingestDocument(ctx, sessionID, doc)
+		if err != nil {
+			return results, fmt.Errorf("ingest %s: %w", doc.File.Name, err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func (p *Pipeline) ingestDocument(ctx context.Context, sessionID string, doc Document) (Result, error) {
+	file := doc.File
+	var rep ingestReport
+	started := time.Now()
+	mimeType := DetectMIME(file.Name, file.MIME, file.Data)
+	text, err := Extract(ctx, file.Name, mimeType, file.Data)
+	if err != nil {
+		return Result{}, err
+	}
+	docMeta := doc.Metadata.Merge(ExtractMetadata(file.Name, mimeType, file.Data))
+	if mimeType == "text/markdown" {
+		_, text = splitFrontMatter(text)
+	}
+	rep.Timings.Extract = time.Since(started)
+
+	started = time.Now()
+	chunks := Chunk(text, p.Options.ChunkingFor(mimeType))
+	rep.Timings.Chunk = time.Since(started)
+
+	base := docMeta.fields()
+	base["source"] = "upload"
+	base["document"] = file.Name
+	base["mime"] = mimeType
+	base["chunk_count"] = len(chunks)
+	if len(doc.Edges) > 0 {
+		base["graph_edges"] = doc.Edges
+	}
+	doc.ACL.Apply(base)
+
+	chunkIDs := make([]int64, 0, len(chunks))
+	for i, chunk := range chunks {
+		meta := make(map[string]any, len(base)+1)
+		for k, v := range base {
+			meta[k] = v
+		}
+		meta["chunk_index"] = i
+		rec, err := p.store(ctx, &rep, sessionID, chunk, meta)
+		if err != nil {
+			return Result{}, err
+		}
+		if rec.ID != 0 {
+			chunkIDs = append(chunkIDs, rec.ID)
+		}
+	}
+	chunkDuplicates := rep.duplicates
+
+	// A document-level record lets retrieval answer "which files do I have"
+	// and gives citations a single place to resolve attribution. Its chunk
+	// IDs let engine.RetrieveHierarchical expand it into its chunks.
+	base["record"] = "document"
+	if len(chunkIDs) > 0 {
+		base[memory.MetaChunkIDs] = chunkIDs
+	}
+	record, err := p.store(ctx, &rep, sessionID, describeDocument(file.Name, mimeType, docMeta, len(chunks)), base)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Name:            file.Name,
+		MIME:            mimeType,
+		Chunks:          len(chunks),
+		Chars:           len(text),
+		Metadata:        docMeta,
+		DocumentID:      p.recordID(ctx, record),
+		EmbeddingTokens: rep.tokens,
+		EstimatedCost:   float64(rep.tokens) * p.Options.EmbeddingPricePerMillion / 1e6,
+		Timings:         rep.Timings,
+		DuplicateChunks: chunkDuplicates,
+	}
+	res.Warnings = ingestWarnings(text, chunks, chunkDuplicates, rep.duplicateOf)
+	return res, nil
+}
+
+// ingestReport accumulates the cost of one document's writes.
+type ingestReport struct {
+	Timings    IngestTimings
+	tokens     int64
+	duplicates int
+	// duplicateOf counts the stored documents that duplicated chunks
+	// belong to.
+	duplicateOf map[string]int
+}
+
+// ingestWarnings flags documents worth a second look: ones without any
+// text, with blank chunks, or whose chunks were mostly already stored.
+func ingestWarnings(text string, chunks []string, duplicates int, duplicateOf map[string]int) []string {
+	var warnings []string
+	if strings.TrimSpace(text) == "" {
+		warnings = append(warnings, "no text was extracted")
+	}
+	blank := 0
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk) == "" {
+			blank++
+		}
+	}
+	if blank > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d of %d chunks are empty", blank, len(chunks)))
+	}
+	if duplicates > 0 && 2*duplicates >= len(chunks) {
+		names := make([]string, 0, len(duplicateOf))
+		for name := range duplicateOf {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if duplicateOf[names[i]] != duplicateOf[names[j]] {
+				return duplicateOf[names[i]] > duplicateOf[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		warning := fmt.Sprintf("near-duplicate document: %d of %d chunks are already stored", duplicates, len(chunks))
+		if len(names) > 0 {
+			warning += " (" + strings.Join(names, ", ") + ")"
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+func describeDocument(name, mimeType string, meta DocumentMetadata, chunks int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Document: %s (%s, %d chunks)", name, mimeType, chunks)
+	if meta.Title != "" {
+		fmt.Fprintf(&sb, "\nTitle: %s", meta.Title)
+	}
+	if meta.Author != "" {
+		fmt.Fprintf(&sb, "\nAuthor: %s", meta.Author)
+	}
+	if !meta.Created.IsZero() {
+		fmt.Fprintf(&sb, "\nCreated: %s", meta.Created.UTC().Format("2006-01-02"))
+	}
+	return sb.String()
+}
+
+// store persists one record and adds its cost to rep. Through the engine
+// the returned record carries its ID; on the bank path only the content
+// and embedding are known.
+func (p *Pipeline) store(ctx context.Context, rep *ingestReport, sessionID, content string, meta map[string]any) (memory.MemoryRecord, error) {
+	estimate := p.Options.TokenEstimator
+	if estimate == nil {
+		estimate = middleware.ApproximateTokenCount
+	}
+	rep.tokens += estimate(content)
+
+	started := time.Now()
+	var embedding []float32
+	var err error
+	if p.Memory.Engine != nil {
+		embedding, err = p.Memory.Engine.Embed(ctx, content)
+	} else {
+		embedding, err = p.Memory.Embed(ctx, content)
+	}
+	rep.Timings.Embed += time.Since(started)
+	if err != nil {
+		return memory.MemoryRecord{}, err
+	}
+
+	started = time.Now()
+	defer func() { rep.Timings.Write += time.Since(started) }()
+	if p.Memory.Engine != nil {
+		rec, dup, err := p.Memory.Engine.StoreEmbedded(ctx, sessionID, content, meta, embedding)
+		if dup {
+			rep.duplicates++
+			var stored struct {
+				Document string `json:"document"`
+			}
+			if json.Unmarshal([]byte(rec.Metadata), &stored) == nil && stored.Document != "" {
+				if rep.duplicateOf == nil {
+					rep.duplicateOf = map[string]int{}
+				}
+				rep.duplicateOf[stored.Document]++
+			}
+		}
+		return rec, err
+	}
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return memory.MemoryRecord{}, err
+	}
+	if err := p.Memory.Bank.StoreMemory(ctx, sessionID, content, string(raw), embedding); err != nil {
+		return memory.MemoryRecord{}, err
+	}
+	return memory.MemoryRecord{SessionID: sessionID, Content: content, Embedding: embedding}, nil
+}
+
+// recordID resolves the ID of a just-stored record, searching for it by its
+// own embedding when the store did not report one.
+func (p *Pipeline) recordID(ctx context.Context, rec memory.MemoryRecord) int64 {
+	if rec.ID != 0 || len(rec.Embedding) == 0 || p.Memory.Bank == nil || p.Memory.Bank.Store == nil {
+		return rec.ID
+	}
+	hits, err := p.Memory.Bank.Store.SearchMemory(ctx, rec.SessionID, rec.Embedding, 3)
+	if err != nil {
+		return 0
+	}
+	for _, hit := range hits {
+		if hit.Content == rec.Content {
+			return hit.ID
+		}
+	}
+	return 0
+}
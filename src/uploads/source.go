@@ -0,0 +1,677 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// DefaultMaxObjectBytes caps how much of a single source object is fetched.
+const DefaultMaxObjectBytes = 64 << 20
+
+// SourceObject describes one document in an external source.
+type SourceObject struct {
+	// Key is the object's name within the source (an S3/GCS object key).
+	Key string
+	// URI is a stable, fully qualified identifier such as s3://bucket/key.
+	URI string
+	// ETag changes whenever the content changes; incremental sync compares it.
+	ETag        string
+	Size        int64
+	Modified    time.Time
+	ContentType string
+	// Title is a human-readable name (a page title) when the source has one.
+	Title string
+	// Parent is the URI of the enclosing object in sources with a hierarchy,
+	// such as a Confluence or Notion parent page.
+	Parent string
+	// Space, when set, names the session or shared space the object is
+	// stored in instead of Syncer.SessionID.
+	Space string
+	// Grants lists who may access the object in the source. A Syncer with a
+	// space registry or ChunkACLs stamps them on the object's chunks as an
+	// ACL, and a registry also gets them as grants on the object's space.
+	Grants map[string]memory.SpaceRole
+}
+
+// Source lists and streams documents from an external system.
+type Source interface {
+	List(ctx context.Context) ([]SourceObject, error)
+	Open(ctx context.Context, obj SourceObject) (io.ReadCloser, error)
+}
+
+// ErrResync is returned by ChangeSource.Changes when the cursor can no longer
+// be used and the source has to be listed in full.
+var ErrResync = errors.New("uploads: source needs a full resync")
+
+// ChangeSource is a Source that can report what changed since a cursor, so a
+// Syncer only lists it in full on the first pass (or after ErrResync).
+type ChangeSource interface {
+	Source
+	// Cursor returns a cursor for the source's current state.
+	Cursor(ctx context.Context) (string, error)
+	// Changes returns the objects changed since cursor, the URIs of removed
+	// objects and the cursor to resume from.
+	Changes(ctx context.Context, cursor string) (changed []SourceObject, removed []string, next string, err error)
+}
+
+// Sync state keys starting with '#' hold syncer bookkeeping next to the
+// per-URI ETags; URIs always carry a scheme, so they cannot collide.
+const (
+	stateCursorKey   = "#cursor"
+	stateSpacePrefix = "#space "
+	stateGrantPrefix = "#grants "
+)
+
+// SyncState persists the ETag last ingested for each object URI.
+type SyncState interface {
+	Load(ctx context.Context) (map[string]string, error)
+	Save(ctx context.Context, etags map[string]string) error
+}
+
+// MemorySyncState keeps sync state in process; it is lost on restart.
+type MemorySyncState struct {
+	mu    sync.Mutex
+	etags map[string]string
+}
+
+// Load returns a copy of the stored ETags.
+func (s *MemorySyncState) Load(context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]string, len(s.etags))
+	for k, v := range s.etags {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// Save replaces the stored ETags.
+func (s *MemorySyncState) Save(_ context.Context, etags map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.etags = make(map[string]string, len(etags))
+	for k, v := range etags {
+		s.etags[k] = v
+	}
+	return nil
+}
+
+// FileSyncState stores sync state as JSON at Path. A missing file is an
+// empty state.
+type FileSyncState struct {
+	Path string
+}
+
+// Load reads the state file.
+func (s FileSyncState) Load(context.Context) (map[string]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	etags := map[string]string{}
+	if err := json.Unmarshal(data, &etags); err != nil {
+		return nil, fmt.Errorf("parse sync state %s: %w", s.Path, err)
+	}
+	return etags, nil
+}
+
+// Save writes the state file atomically.
+func (s FileSyncState) Save(_ context.Context, etags map[string]string) error {
+	data, err := json.MarshalIndent(etags, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".sync-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// Syncer ingests a Source into a Pipeline, skipping objects whose ETag has
+// not changed since the last sync. Changed objects have their previous
+// chunks removed before being ingested again.
+//
+// Objects with a Parent are linked to the parent's document record with an
+// EdgeDerivedFrom graph edge. Parents are ingested first, and the children of
+// a re-ingested parent are re-ingested too so their edges stay valid.
+//
+// SessionID may name a shared space so every agent in that space can
+// retrieve the synced documents; objects that carry a Space override it.
+//
+// Sources implementing ChangeSource are listed in full once and then only
+// asked for changes since the cursor saved in State.
+type Syncer struct {
+	Source    Source
+	Pipeline  *Pipeline
+	SessionID string
+	// State defaults to an in-process MemorySyncState.
+	State SyncState
+	// Include, when set, filters listed objects (for example by extension).
+	Include func(SourceObject) bool
+	// MaxObjectBytes caps the size of fetched objects; larger objects are
+	// skipped. Defaults to DefaultMaxObjectBytes.
+	MaxObjectBytes int64
+	// Spaces, when set, mirrors object Grants onto their spaces: each
+	// principal gets the highest role it holds on any object in the space,
+	// and principals this syncer granted are revoked once no object grants
+	// them anymore. A space grant only admits a principal to the space;
+	// each document's chunks still carry that document's own ACL, as with
+	// ChunkACLs, so being granted one file does not reveal its neighbours.
+	Spaces *memory.SpaceRegistry
+	// ChunkACLs stamps each object's grants on its chunks as an ACL, so
+	// retrieval filters per document. "domain:" grants become groups;
+	// objects granted to "anyone" stay unrestricted. It is implied by
+	// Spaces.
+	ChunkACLs bool
+}
+
+// SyncReport summarises one Sync run.
+type SyncReport struct {
+	Listed    int               `json:"listed"`
+	Unchanged int               `json:"unchanged"`
+	Ingested  []Result          `json:"ingested,omitempty"`
+	Removed   []string          `json:"removed,omitempty"`
+	Skipped   map[string]string `json:"skipped,omitempty"` // URI -> reason
+}
+
+// Sync performs one incremental pass. Per-object failures are recorded in
+// SyncReport.Skipped and retried next time; the returned error is reserved
+// for failures that abort the pass (listing, state persistence).
+func (s *Syncer) Sync(ctx context.Context) (SyncReport, error) {
+	var report SyncReport
+	if s.Source == nil || s.Pipeline == nil {
+		return report, errors.New("uploads: syncer needs a source and a pipeline")
+	}
+	if s.State == nil {
+		s.State = &MemorySyncState{}
+	}
+	maxBytes := s.MaxObjectBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxObjectBytes
+	}
+
+	previous, err := s.State.Load(ctx)
+	if err != nil {
+		return report, fmt.Errorf("load sync state: %w", err)
+	}
+	objects, removed, cursor, full, err := s.collect(ctx, previous)
+	if err != nil {
+		return report, err
+	}
+
+	if s.Include != nil {
+		kept := objects[:0]
+		for _, obj := range objects {
+			if s.Include(obj) {
+				kept = append(kept, obj)
+			}
+		}
+		objects = kept
+	}
+	objects = parentsFirst(objects)
+
+	var docIDs map[string]int64
+	for _, obj := range objects {
+		if obj.Parent != "" {
+			if docIDs, err = s.Pipeline.documentIDs(ctx, s.SessionID); err != nil {
+				return report, fmt.Errorf("index documents: %w", err)
+			}
+			break
+		}
+	}
+
+	// A change feed only mentions what changed, so everything else carries
+	// over; a full listing starts from scratch.
+	next := make(map[string]string, len(previous))
+	if !full {
+		for k, v := range previous {
+			next[k] = v
+		}
+	}
+	reingested := map[string]bool{}
+	skip := func(uri, reason string) {
+		if report.Skipped == nil {
+			report.Skipped = map[string]string{}
+		}
+		report.Skipped[uri] = reason
+	}
+	for _, obj := range objects {
+		report.Listed++
+		space := s.spaceOf(obj)
+		prevSpace, seen := s.storedSpace(previous, obj.URI)
+		if seen && previous[obj.URI] == obj.ETag && obj.ETag != "" && prevSpace == space && !reingested[obj.Parent] && !s.aclChanged(previous, obj) {
+			s.remember(next, obj, space)
+			report.Unchanged++
+			continue
+		}
+		if obj.Size > maxBytes {
+			skip(obj.URI, fmt.Sprintf("object is %d bytes, limit %d", obj.Size, maxBytes))
+			continue
+		}
+		var edges []memory.GraphEdge
+		if id := docIDs[obj.Parent]; id != 0 {
+			edges = []memory.GraphEdge{{Target: id, Type: memory.EdgeDerivedFrom}}
+		}
+		var replace string
+		if seen {
+			replace = prevSpace
+		}
+		res, err := s.ingest(ctx, obj, space, replace, maxBytes, edges)
+		if err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			// Keep the old state so the next pass retries this object.
+			if seen {
+				carryState(next, previous, obj.URI)
+			}
+			skip(obj.URI, err.Error())
+			continue
+		}
+		s.remember(next, obj, space)
+		reingested[obj.URI] = true
+		if docIDs != nil {
+			docIDs[obj.URI] = res.DocumentID
+		}
+		report.Ingested = append(report.Ingested, res)
+	}
+
+	var gone []string
+	if full {
+		for uri := range previous {
+			if _, still := next[uri]; still || isBookkeepingKey(uri) {
+				continue
+			}
+			if _, failed := report.Skipped[uri]; failed {
+				continue
+			}
+			gone = append(gone, uri)
+		}
+	} else {
+		for _, uri := range removed {
+			if _, known := previous[uri]; known && !isBookkeepingKey(uri) {
+				gone = append(gone, uri)
+			}
+		}
+	}
+	for _, uri := range gone {
+		space, _ := s.storedSpace(previous, uri)
+		if err := s.Pipeline.Forget(ctx, space, uri); err != nil {
+			carryState(next, previous, uri)
+			skip(uri, "remove: "+err.Error())
+			continue
+		}
+		dropState(next, uri)
+		report.Removed = append(report.Removed, uri)
+	}
+
+	// Failed objects have to come back on the next pass, so the cursor only
+	// advances on a clean pass: a change feed then replays from the old
+	// cursor and a full listing is simply repeated.
+	if len(report.Skipped) == 0 && cursor != "" {
+		next[stateCursorKey] = cursor
+	}
+
+	if s.Spaces != nil {
+		s.mirrorGrants(previous, next, skip)
+	}
+	if err := s.State.Save(ctx, next); err != nil {
+		return report, fmt.Errorf("save sync state: %w", err)
+	}
+	return report, nil
+}
+
+// collect returns the objects to consider in this pass. full reports a
+// complete listing, where anything missing has been removed, as opposed to a
+// change feed that names removals explicitly.
+func (s *Syncer) collect(ctx context.Context, previous map[string]string) (objects []SourceObject, removed []string, cursor string, full bool, err error) {
+	cs, ok := s.Source.(ChangeSource)
+	if ok {
+		if prev := previous[stateCursorKey]; prev != "" {
+			objects, removed, cursor, err = cs.Changes(ctx, prev)
+			if err == nil {
+				return objects, removed, cursor, false, nil
+			}
+			if !errors.Is(err, ErrResync) {
+				return nil, nil, "", false, fmt.Errorf("list changes: %w", err)
+			}
+		}
+		// Take the cursor before listing so changes made during the listing
+		// are replayed on the next pass.
+		if cursor, err = cs.Cursor(ctx); err != nil {
+			return nil, nil, "", false, fmt.Errorf("read change cursor: %w", err)
+		}
+	}
+	if objects, err = s.Source.List(ctx); err != nil {
+		return nil, nil, "", false, fmt.Errorf("list source: %w", err)
+	}
+	return objects, nil, cursor, true, nil
+}
+
+func (s *Syncer) spaceOf(obj SourceObject) string {
+	if obj.Space != "" {
+		return obj.Space
+	}
+	return s.SessionID
+}
+
+// storedSpace reports where uri was stored on a previous pass and whether
+// it was stored at all.
+func (s *Syncer) storedSpace(previous map[string]string, uri string) (string, bool) {
+	_, seen := previous[uri]
+	if space, ok := previous[stateSpacePrefix+uri]; ok {
+		return space, seen
+	}
+	return s.SessionID, seen
+}
+
+func (s *Syncer) remember(next map[string]string, obj SourceObject, space string) {
+	dropState(next, obj.URI)
+	next[obj.URI] = obj.ETag
+	if space != s.SessionID {
+		next[stateSpacePrefix+obj.URI] = space
+	}
+	if len(obj.Grants) > 0 {
+		next[stateGrantPrefix+obj.URI] = encodeGrants(obj.Grants)
+	}
+}
+
+// documentACLs reports whether chunks carry their object's grants.
+func (s *Syncer) documentACLs() bool { return s.ChunkACLs || s.Spaces != nil }
+
+// aclChanged reports whether obj's grants differ from the ones its chunks
+// were stamped with, which calls for ingesting it again.
+func (s *Syncer) aclChanged(previous map[string]string, obj SourceObject) bool {
+	return s.documentACLs() && previous[stateGrantPrefix+obj.URI] != encodeGrants(obj.Grants)
+}
+
+func carryState(next, previous map[string]string, uri string) {
+	for _, key := range []string{uri, stateSpacePrefix + uri, stateGrantPrefix + uri} {
+		if v, ok := previous[key]; ok {
+			next[key] = v
+		}
+	}
+}
+
+func dropState(next map[string]string, uri string) {
+	delete(next, uri)
+	delete(next, stateSpacePrefix+uri)
+	delete(next, stateGrantPrefix+uri)
+}
+
+func isBookkeepingKey(key string) bool { return strings.HasPrefix(key, "#") }
+
+// mirrorGrants applies the grants recorded in next to the space registry and
+// revokes those only recorded in previous.
+func (s *Syncer) mirrorGrants(previous, next map[string]string, skip func(uri, reason string)) {
+	before, after := s.spaceGrants(previous), s.spaceGrants(next)
+	for space, grants := range after {
+		for principal, role := range grants {
+			if err := s.Spaces.Grant(space, principal, role, 0); err != nil {
+				skip(space, "grant "+principal+": "+err.Error())
+			}
+		}
+	}
+	for space, grants := range before {
+		for principal := range grants {
+			if _, kept := after[space][principal]; !kept {
+				s.Spaces.Revoke(space, principal)
+			}
+		}
+	}
+}
+
+// spaceGrants folds per-object grants into per-space grants, keeping the
+// highest role for each principal.
+func (s *Syncer) spaceGrants(state map[string]string) map[string]map[string]memory.SpaceRole {
+	rank := map[memory.SpaceRole]int{memory.SpaceRoleReader: 1, memory.SpaceRoleWriter: 2, memory.SpaceRoleAdmin: 3}
+	out := map[string]map[string]memory.SpaceRole{}
+	for key, encoded := range state {
+		uri, ok := strings.CutPrefix(key, stateGrantPrefix)
+		if !ok {
+			continue
+		}
+		space, _ := s.storedSpace(state, uri)
+		if out[space] == nil {
+			out[space] = map[string]memory.SpaceRole{}
+		}
+		for principal, role := range decodeGrants(encoded) {
+			if rank[role] > rank[out[space][principal]] {
+				out[space][principal] = role
+			}
+		}
+	}
+	return out
+}
+
+// grantsACL turns source grants into a chunk ACL.
+func grantsACL(grants map[string]memory.SpaceRole) memory.ACL {
+	var acl memory.ACL
+	if _, public := grants["anyone"]; public {
+		return acl
+	}
+	for principal := range grants {
+		if strings.HasPrefix(principal, "domain:") {
+			acl.Groups = append(acl.Groups, principal)
+		} else {
+			acl.Principals = append(acl.Principals, principal)
+		}
+	}
+	sort.Strings(acl.Principals)
+	sort.Strings(acl.Groups)
+	return acl
+}
+
+func encodeGrants(grants map[string]memory.SpaceRole) string {
+	parts := make([]string, 0, len(grants))
+	for principal, role := range grants {
+		parts = append(parts, principal+"="+string(role))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func decodeGrants(encoded string) map[string]memory.SpaceRole {
+	grants := map[string]memory.SpaceRole{}
+	for _, part := range strings.Split(encoded, ",") {
+		principal, role, ok := strings.Cut(part, "=")
+		if !ok || principal == "" {
+			continue
+		}
+		switch r := memory.SpaceRole(role); r {
+		case memory.SpaceRoleReader, memory.SpaceRoleWriter, memory.SpaceRoleAdmin:
+			grants[principal] = r
+		}
+	}
+	return grants
+}
+
+// ingest fetches and stores obj in space. A non-empty replace names the
+// space holding the previous version, which is removed first.
+func (s *Syncer) ingest(ctx context.Context, obj SourceObject, space, replace string, maxBytes int64, edges []memory.GraphEdge) (Result, error) {
+	rc, err := s.Source.Open(ctx, obj)
+	if err != nil {
+		return Result{}, err
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, maxBytes+1))
+	rc.Close()
+	if err != nil {
+		return Result{}, err
+	}
+	if int64(len(data)) > maxBytes {
+		return Result{}, fmt.Errorf("object exceeds %d bytes", maxBytes)
+	}
+	if replace != "" {
+		if err := s.Pipeline.Forget(ctx, replace, obj.URI); err != nil {
+			return Result{}, fmt.Errorf("remove previous version: %w", err)
+		}
+	}
+	doc := Document{
+		File: models.File{Name: path.Base(obj.Key), MIME: obj.ContentType, Data: data},
+		Metadata: DocumentMetadata{
+			Title:   obj.Title,
+			Created: obj.Modified,
+			Extra:   map[string]string{"source_uri": obj.URI, "etag": obj.ETag},
+		},
+		Edges: edges,
+	}
+	if s.documentACLs() {
+		doc.ACL = grantsACL(obj.Grants)
+	}
+	if obj.Parent != "" {
+		doc.Metadata.Extra["parent_uri"] = obj.Parent
+	}
+	results, err := s.Pipeline.IngestDocuments(ctx, space, doc)
+	if err != nil {
+		return Result{}, err
+	}
+	return results[0], nil
+}
+
+// Run calls Sync immediately and then every interval until ctx is done.
+// Each report (or error) is passed to onSync when it is non-nil.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration, onSync func(SyncReport, error)) error {
+	if interval <= 0 {
+		return errors.New("uploads: sync interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := s.Sync(ctx)
+		if onSync != nil {
+			onSync(report, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// parentsFirst orders objects so every parent listed in the same pass comes
+// before its children. Unknown parents and cycles are treated as roots.
+func parentsFirst(objects []SourceObject) []SourceObject {
+	parents := make(map[string]string, len(objects))
+	for _, obj := range objects {
+		parents[obj.URI] = obj.Parent
+	}
+	depth := func(uri string) int {
+		d := 0
+		seen := map[string]bool{uri: true}
+		for p := parents[uri]; p != "" && !seen[p]; p = parents[p] {
+			if _, listed := parents[p]; !listed {
+				break
+			}
+			seen[p] = true
+			d++
+		}
+		return d
+	}
+	depths := make(map[string]int, len(objects))
+	for _, obj := range objects {
+		depths[obj.URI] = depth(obj.URI)
+	}
+	sort.SliceStable(objects, func(i, j int) bool { return depths[objects[i].URI] < depths[objects[j].URI] })
+	return objects
+}
+
+// documentIDs maps source URIs to the IDs of their document-level records.
+func (p *Pipeline) documentIDs(ctx context.Context, sessionID string) (map[string]int64, error) {
+	ids := map[string]int64{}
+	err := p.eachSourceRecord(ctx, sessionID, func(rec memory.MemoryRecord, meta map[string]any) {
+		if uri, _ := meta["source_uri"].(string); uri != "" && meta["record"] == "document" {
+			ids[uri] = rec.ID
+		}
+	})
+	return ids, err
+}
+
+func (p *Pipeline) eachSourceRecord(ctx context.Context, sessionID string, fn func(memory.MemoryRecord, map[string]any)) error {
+	if p == nil || p.Memory == nil || p.Memory.Bank == nil || p.Memory.Bank.Store == nil {
+		return errors.New("uploads: pipeline has no memory store")
+	}
+	return p.Memory.Bank.Store.Iterate(ctx, func(rec memory.MemoryRecord) bool {
+		if rec.SessionID != sessionID || !strings.Contains(rec.Metadata, "source_uri") {
+			return true
+		}
+		meta := map[string]any{}
+		if json.Unmarshal([]byte(rec.Metadata), &meta) == nil {
+			fn(rec, meta)
+		}
+		return true
+	})
+}
+
+// Forget deletes every long-term record ingested from sourceURI for
+// sessionID. It scans the bank's store, so it is meant for sync-time
+// replacement rather than hot paths.
+func (p *Pipeline) Forget(ctx context.Context, sessionID, sourceURI string) error {
+	var ids []int64
+	err := p.eachSourceRecord(ctx, sessionID, func(rec memory.MemoryRecord, meta map[string]any) {
+		if meta["source_uri"] == sourceURI {
+			ids = append(ids, rec.ID)
+		}
+	})
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	return p.Memory.Bank.Store.DeleteMemory(ctx, ids)
+}
+
+// OpenSource resolves a source URI using credentials from the environment:
+// s3://bucket/prefix, gs://bucket/prefix, confluence://SPACEKEY, notion://
+// (every page shared with the integration) or gdrive://FOLDERID (gdrive://
+// alone syncs everything the credentials can see).
+func OpenSource(ctx context.Context, uri string) (Source, error) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return nil, fmt.Errorf("uploads: source %q is not a URI", uri)
+	}
+	scheme = strings.ToLower(scheme)
+	switch scheme {
+	case "notion":
+		return NewNotionSourceFromEnv(), nil
+	case "gdrive":
+		return NewDriveSource(ctx, strings.Trim(rest, "/"))
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("uploads: source %q has no bucket or space", uri)
+	}
+	switch scheme {
+	case "s3":
+		return NewS3SourceFromEnv(bucket, prefix), nil
+	case "gs", "gcs":
+		return NewGCSSource(ctx, bucket, prefix)
+	case "confluence":
+		return NewConfluenceSourceFromEnv(bucket), nil
+	}
+	return nil, fmt.Errorf("uploads: unsupported source scheme %q", scheme)
+}
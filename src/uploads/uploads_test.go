@@ -0,0 +1,306 @@
+package uploads
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+	"github.com/Protocol-Lattice/go-agent/src/models"
+)
+
+// buildPDF assembles a single-page PDF whose page content is stream.
+func buildPDF(t *testing.T, stream string, compress bool) []byte {
+	t.Helper()
+	body := []byte(stream)
+	filter := ""
+	if compress {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			t.Fatalf("compress: %v", err)
+		}
+		zw.Close()
+		body = buf.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj << /Length %d%s >>\nstream\n", len(body), filter)
+	pdf.Write(body)
+	pdf.WriteString("\nendstream\nendobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func TestPDFTextExtractor(t *testing.T) {
+	content := "BT /F1 12 Tf 72 720 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Sec) 20 (ond) -300 (line)] TJ ET"
+	for _, compress := range []bool{false, true} {
+		text, err := PDFTextExtractor{}.Extract(context.Background(), "doc.pdf", buildPDF(t, content, compress))
+		if err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if text != "Hello (PDF)\nSecond line" {
+			t.Fatalf("compress=%v: unexpected text %q", compress, text)
+		}
+	}
+}
+
+func TestPDFTextExtractorRejectsEncrypted(t *testing.T) {
+	data := append(buildPDF(t, "BT (x) Tj ET", false), []byte("trailer << /Encrypt 9 0 R >>")...)
+	if _, err := (PDFTextExtractor{}).Extract(context.Background(), "x.pdf", data); !errors.Is(err, ErrEncryptedPDF) {
+		t.Fatalf("expected ErrEncryptedPDF, got %v", err)
+	}
+}
+
+func TestExtractDetectsTypes(t *testing.T) {
+	ctx := context.Background()
+	text, err := Extract(ctx, "page.html", "", []byte("<html><script>x()</script><p>Hi &amp; bye</p></html>"))
+	if err != nil || text != "Hi & bye" {
+		t.Fatalf("html: %q, %v", text, err)
+	}
+	if _, err := Extract(ctx, "blob.bin", "application/zip", []byte{0x50, 0x4b}); !errors.Is(err, ErrNoExtractor) {
+		t.Fatalf("expected ErrNoExtractor, got %v", err)
+	}
+	pdf := buildPDF(t, "BT (detected) Tj ET", false)
+	if text, err := Extract(ctx, "upload", "application/octet-stream", pdf); err != nil || text != "detected" {
+		t.Fatalf("pdf sniffing: %q, %v", text, err)
+	}
+}
+
+func TestPipelineIngestStoresChunks(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	p := NewPipeline(mem)
+	p.Options.Chunking.Size = 20
+
+	doc := strings.Repeat("a line of notes\n", 4)
+	results, err := p.Ingest(context.Background(), "s1", models.File{Name: "notes.md", Data: []byte(doc)})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if len(results) != 1 || results[0].MIME != "text/markdown" || results[0].Chunks < 2 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	// One record per chunk plus the document-level record.
+	count, _ := store.Count(context.Background())
+	if count != results[0].Chunks+1 {
+		t.Fatalf("expected %d stored records, got %d", results[0].Chunks+1, count)
+	}
+}
+
+func TestChunkBoundariesAndOverlap(t *testing.T) {
+	text := "One two. Three four. Five six.\n\nSeven eight."
+
+	sentences := Chunk(text, ChunkOptions{Size: 20, Boundary: BoundarySentence})
+	want := []string{"One two. Three four.", "Five six.", "Seven eight."}
+	if strings.Join(sentences, "|") != strings.Join(want, "|") {
+		t.Fatalf("sentence chunks = %q", sentences)
+	}
+
+	paragraphs := Chunk(text, ChunkOptions{Size: 1000, Boundary: BoundaryParagraph})
+	if len(paragraphs) != 1 || !strings.Contains(paragraphs[0], "\n\nSeven") {
+		t.Fatalf("paragraph chunks = %q", paragraphs)
+	}
+
+	tokens := Chunk("a b c d e f", ChunkOptions{Size: 3, Overlap: 1, Boundary: BoundaryToken})
+	want = []string{"a b c", "c d e", "e f"}
+	if strings.Join(tokens, "|") != strings.Join(want, "|") {
+		t.Fatalf("token chunks = %q", tokens)
+	}
+}
+
+func TestIngestOptionsPerMIME(t *testing.T) {
+	opts := IngestOptions{
+		Chunking: ChunkOptions{Size: 100},
+		PerMIME: map[string]ChunkOptions{
+			"application/pdf": {Size: 10},
+			"text/*":          {Size: 50},
+		},
+	}
+	if got := opts.ChunkingFor("application/pdf").Size; got != 10 {
+		t.Fatalf("pdf size = %d", got)
+	}
+	if got := opts.ChunkingFor("text/markdown; charset=utf-8").Size; got != 50 {
+		t.Fatalf("markdown size = %d", got)
+	}
+	if got := opts.ChunkingFor("application/json").Size; got != 100 {
+		t.Fatalf("json size = %d", got)
+	}
+}
+
+func TestExtractMetadata(t *testing.T) {
+	pdf := buildPDF(t, "BT (body) Tj ET", false)
+	pdf = append(pdf, []byte("5 0 obj << /Title (Quarterly Report) /Author <FEFF0041006E006E> /CreationDate (D:20240131120000Z) >> endobj\n")...)
+	meta := ExtractMetadata("r.pdf", "application/pdf", pdf)
+	if meta.Title != "Quarterly Report" || meta.Author != "Ann" || meta.Created.Year() != 2024 {
+		t.Fatalf("pdf metadata = %+v", meta)
+	}
+
+	md := "---\ntitle: \"Runbook\"\nauthor: Ops\ndate: 2023-05-01\ntags: oncall\n---\n# Body\n"
+	meta, body := splitFrontMatter(md)
+	if meta.Title != "Runbook" || meta.Author != "Ops" || meta.Extra["tags"] != "oncall" || body != "# Body\n" {
+		t.Fatalf("front matter = %+v body=%q", meta, body)
+	}
+	for md, want := range map[string]string{
+		"---\ntitle: List\n---\n- item\n- other\n":         "- item\n- other\n",
+		"---\ntitle: Rule\n---\n---\nafter the rule\n":     "---\nafter the rule\n",
+		"---\ntitle: Dashes\n----\nnot a delimiter\n---\n": "",
+	} {
+		if _, body := splitFrontMatter(md); body != want {
+			t.Errorf("splitFrontMatter(%q) body = %q, want %q", md, body, want)
+		}
+	}
+
+	html := []byte(`<html><head><title> Home </title><meta name="author" content="Web Team"></head></html>`)
+	if meta := ExtractMetadata("i.html", "", html); meta.Title != "Home" || meta.Author != "Web Team" {
+		t.Fatalf("html metadata = %+v", meta)
+	}
+}
+
+func TestPipelineStampsACLOnChunks(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	p := NewPipeline(mem)
+
+	docs := []Document{
+		{File: models.File{Name: "handbook.md", Data: []byte("Holiday policy.\n")}},
+		{
+			File: models.File{Name: "salaries.md", Data: []byte("Salary bands.\n")},
+			ACL:  memory.ACL{Principals: []string{"alice"}, Groups: []string{"hr"}},
+		},
+	}
+	if _, err := p.IngestDocuments(context.Background(), "s1", docs...); err != nil {
+		t.Fatalf("IngestDocuments: %v", err)
+	}
+
+	retrieve := func(ctx context.Context) (public, private bool) {
+		recs, err := mem.RetrieveContext(ctx, "s1", "policy", 10)
+		if err != nil {
+			t.Fatalf("RetrieveContext: %v", err)
+		}
+		for _, rec := range recs {
+			if strings.Contains(rec.Content, "salaries.md") || strings.Contains(rec.Content, "Salary") {
+				private = true
+			} else {
+				public = true
+			}
+		}
+		return public, private
+	}
+	if public, private := retrieve(context.Background()); !public || private {
+		t.Fatalf("anonymous retrieval: public=%v private=%v", public, private)
+	}
+	bob := memory.ContextWithIdentity(context.Background(), memory.Identity{Principal: "bob", Groups: []string{"eng"}})
+	if _, private := retrieve(bob); private {
+		t.Fatal("bob should not see the restricted document")
+	}
+	hr := memory.ContextWithIdentity(context.Background(), memory.Identity{Principal: "carol", Groups: []string{"hr"}})
+	if _, private := retrieve(hr); !private {
+		t.Fatal("hr should see the restricted document")
+	}
+}
+
+func TestFrontMatterCannotSetTenantOrACL(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	p := NewPipeline(mem)
+
+	doc := Document{
+		File: models.File{Name: "salaries.md", Data: []byte("---\ntenant_id: acme\nacl_groups: eng\n---\nSalary bands.\n")},
+		ACL:  memory.ACL{Groups: []string{"hr"}},
+	}
+	if _, err := p.IngestDocuments(context.Background(), "s1", doc); err != nil {
+		t.Fatalf("IngestDocuments: %v", err)
+	}
+	eng := memory.ContextWithIdentity(context.Background(), memory.Identity{Principal: "bob", Groups: []string{"eng"}})
+	recs, err := mem.RetrieveContext(eng, "s1", "salary", 10)
+	if err != nil {
+		t.Fatalf("RetrieveContext: %v", err)
+	}
+	for _, rec := range recs {
+		if strings.Contains(rec.Content, "Salary") || strings.Contains(rec.Content, "salaries.md") {
+			t.Fatalf("front-matter widened the ACL: %+v", rec)
+		}
+	}
+	hr := memory.ContextWithIdentity(context.Background(), memory.Identity{Principal: "carol", Groups: []string{"hr"}})
+	recs, err = mem.RetrieveContext(hr, "s1", "salary", 10)
+	if err != nil || len(recs) == 0 {
+		t.Fatalf("hr retrieval = %v, %v; front-matter must not move the document to another tenant", recs, err)
+	}
+}
+
+func TestPipelineAttachesDocumentMetadata(t *testing.T) {
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	p := NewPipeline(mem)
+
+	doc := Document{
+		File:     models.File{Name: "guide.md", Data: []byte("---\ntitle: Guide\n---\nStep one.\n")},
+		Metadata: DocumentMetadata{Author: "git-author"},
+	}
+	if _, err := p.IngestDocuments(context.Background(), "s1", doc); err != nil {
+		t.Fatalf("IngestDocuments: %v", err)
+	}
+
+	var sawDocument bool
+	_ = store.Iterate(context.Background(), func(rec memory.MemoryRecord) bool {
+		meta := map[string]any{}
+		_ = json.Unmarshal([]byte(rec.Metadata), &meta)
+		if meta["title"] != "Guide" || meta["author"] != "git-author" {
+			t.Errorf("record %q missing attribution: %v", rec.Content, meta)
+		}
+		if strings.Contains(rec.Content, "title:") {
+			t.Errorf("front matter leaked into chunk: %q", rec.Content)
+		}
+		if meta["record"] == "document" {
+			sawDocument = true
+		}
+		return true
+	})
+	if !sawDocument {
+		t.Fatal("expected a document-level record")
+	}
+}
+
+func TestIngestReportsCostAndDuplicates(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewInMemoryStore()
+	engine := memory.NewEngine(store, memory.Options{}).WithEmbedder(memory.DummyEmbedder{})
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{}).WithEngine(engine)
+	pipeline := NewPipeline(mem)
+	pipeline.Options.Chunking = ChunkOptions{Size: 40, Boundary: BoundaryLine}
+	pipeline.Options.EmbeddingPricePerMillion = 0.02
+
+	text := "The deploy window is Friday at five.\nRollbacks need two approvals.\nOn-call rotates every Monday."
+	first, err := pipeline.Ingest(ctx, "docs", models.File{Name: "runbook.md", Data: []byte(text)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := first[0]
+	if res.EmbeddingTokens <= 0 || res.EstimatedCost != float64(res.EmbeddingTokens)*0.02/1e6 {
+		t.Fatalf("tokens %d, cost %g", res.EmbeddingTokens, res.EstimatedCost)
+	}
+	if res.Timings.Total() <= 0 {
+		t.Fatalf("timings not recorded: %+v", res.Timings)
+	}
+	if res.DuplicateChunks != 0 || len(res.Warnings) != 0 {
+		t.Fatalf("a fresh document should not warn: %+v", res)
+	}
+
+	second, err := pipeline.Ingest(ctx, "docs", models.File{Name: "runbook-copy.md", Data: []byte(text)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := second[0]
+	if copied.DuplicateChunks != copied.Chunks || len(copied.Warnings) != 1 || !strings.Contains(copied.Warnings[0], "runbook.md") {
+		t.Fatalf("the copy should be flagged as a near-duplicate of runbook.md: %+v", copied)
+	}
+}
@@ -0,0 +1,123 @@
+package uploads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestConfluenceSyncKeepsHierarchyAsEdges(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me@example.com" || pass != "tok" {
+			http.Error(w, "auth", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/wiki/rest/api/content":
+			// Child listed before its parent to exercise ordering.
+			fmt.Fprint(w, `{"results":[
+{"id":"2","title":"Deploys","version":{"number":3},"ancestors":[{"id":"1"}]},
+{"id":"1","title":"Runbooks","version":{"number":1},"ancestors":[]}],"_links":{}}`)
+		case "/wiki/rest/api/content/1":
+			fmt.Fprint(w, `{"id":"1","title":"Runbooks","body":{"storage":{"value":"<p>All runbooks.</p>"}}}`)
+		case "/wiki/rest/api/content/2":
+			fmt.Fprint(w, `{"id":"2","title":"Deploys","body":{"storage":{"value":"<p>Roll back with make rollback.</p>"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	store := memory.NewInMemoryStore()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(store), 0).WithEmbedder(memory.DummyEmbedder{})
+	syncer := &Syncer{
+		Source:    &ConfluenceSource{BaseURL: srv.URL + "/wiki", SpaceKey: "OPS", Email: "me@example.com", APIToken: "tok"},
+		Pipeline:  NewPipeline(mem),
+		SessionID: "team:ops",
+	}
+	report, err := syncer.Sync(context.Background())
+	if err != nil || len(report.Ingested) != 2 {
+		t.Fatalf("Sync = %+v, %v", report, err)
+	}
+	parentID := report.Ingested[0].DocumentID
+	if report.Ingested[0].Metadata.Title != "Runbooks" || parentID == 0 {
+		t.Fatalf("expected parent page first with a document id, got %+v", report.Ingested[0])
+	}
+
+	var linked bool
+	_ = store.Iterate(context.Background(), func(rec memory.MemoryRecord) bool {
+		if strings.Contains(rec.Content, "rollback") {
+			for _, edge := range rec.GraphEdges {
+				if edge.Target == parentID && edge.Type == memory.EdgeDerivedFrom {
+					linked = true
+				}
+			}
+			if !strings.Contains(rec.Metadata, `"parent_uri":"confluence://OPS/1"`) {
+				t.Errorf("child chunk missing parent_uri: %s", rec.Metadata)
+			}
+		}
+		return true
+	})
+	if !linked {
+		t.Fatal("expected child page chunks to link to the parent document record")
+	}
+}
+
+func TestNotionSourceRendersMarkdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") == "" {
+			http.Error(w, "auth", http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/search" && r.Method == http.MethodPost:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["start_cursor"] == nil {
+				fmt.Fprint(w, `{"results":[{"object":"page","id":"p1","last_edited_time":"2024-03-01T10:00:00.000Z",
+"parent":{"type":"workspace"},"properties":{"Name":{"type":"title","title":[{"plain_text":"Onboarding"}]}}}],
+"has_more":true,"next_cursor":"c2"}`)
+				return
+			}
+			fmt.Fprint(w, `{"results":[{"object":"page","id":"p2","last_edited_time":"2024-03-02T10:00:00.000Z",
+"parent":{"type":"page_id","page_id":"p1"},"properties":{"title":{"type":"title","title":[{"plain_text":"Laptop"}]}}},
+{"object":"page","id":"old","archived":true}],"has_more":false}`)
+		case r.URL.Path == "/blocks/p2/children":
+			fmt.Fprint(w, `{"results":[
+{"id":"b1","type":"heading_2","heading_2":{"rich_text":[{"plain_text":"Setup"}]}},
+{"id":"b2","type":"to_do","has_children":true,"to_do":{"rich_text":[{"plain_text":"Enable disk encryption"}],"checked":true}},
+{"id":"b3","type":"child_page","child_page":{"title":"Skip me"}}],"has_more":false}`)
+		case r.URL.Path == "/blocks/b2/children":
+			fmt.Fprint(w, `{"results":[{"id":"b4","type":"paragraph","paragraph":{"rich_text":[{"plain_text":"Use FileVault."}]}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	n := &NotionSource{Token: "secret", BaseURL: srv.URL}
+	objects, err := n.List(context.Background())
+	if err != nil || len(objects) != 2 {
+		t.Fatalf("List = %+v, %v", objects, err)
+	}
+	child := objects[1]
+	if child.Title != "Laptop" || child.Parent != "notion://p1" || child.URI != "notion://p2" || child.ETag == "" {
+		t.Fatalf("unexpected child %+v", child)
+	}
+	rc, err := n.Open(context.Background(), child)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	want := "# Laptop\n\n## Setup\n\n- [x] Enable disk encryption\n  Use FileVault.\n\n"
+	if string(body) != want {
+		t.Fatalf("markdown = %q, want %q", body, want)
+	}
+}
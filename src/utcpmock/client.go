@@ -0,0 +1,94 @@
+package utcpmock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+
+	utcp "github.com/universal-tool-calling-protocol/go-utcp"
+	"github.com/universal-tool-calling-protocol/go-utcp/src/providers/base"
+	grpcprov "github.com/universal-tool-calling-protocol/go-utcp/src/providers/grpc"
+	httpprov "github.com/universal-tool-calling-protocol/go-utcp/src/providers/http"
+)
+
+// HTTPProvider returns the UTCP provider of the HTTP transport, or nil
+// before ListenHTTP.
+func (s *Server) HTTPProvider() base.Provider {
+	url := s.HTTPURL()
+	if url == "" {
+		return nil
+	}
+	return &httpprov.HttpProvider{
+		BaseProvider: base.BaseProvider{Name: s.name, ProviderType: base.ProviderHTTP},
+		HTTPMethod:   http.MethodPost,
+		URL:          url,
+		ContentType:  "application/json",
+	}
+}
+
+// GRPCProvider returns the UTCP provider of the gRPC transport, or nil
+// before ListenGRPC.
+func (s *Server) GRPCProvider() base.Provider {
+	s.mu.Lock()
+	addr := s.grpcAddr
+	s.mu.Unlock()
+	if addr == "" {
+		return nil
+	}
+	host, portStr, err := net.SplitHostPort(loopback(addr))
+	if err != nil {
+		return nil
+	}
+	port, _ := strconv.Atoi(portStr)
+	return &grpcprov.GRPCProvider{
+		BaseProvider: base.BaseProvider{Name: s.name, ProviderType: base.ProviderGRPC},
+		Host:         host,
+		Port:         port,
+		ServiceName:  "grpcpb.UTCPService",
+		MethodName:   "CallTool",
+	}
+}
+
+// Client returns a UTCP client with the server registered as a provider,
+// over gRPC when it listens there and over HTTP otherwise.
+func (s *Server) Client(ctx context.Context) (utcp.UtcpClientInterface, error) {
+	prov := s.GRPCProvider()
+	if prov == nil {
+		prov = s.HTTPProvider()
+	}
+	if prov == nil {
+		return nil, errors.New("utcpmock: server is not listening")
+	}
+	client, err := utcp.NewUTCPClient(ctx, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.RegisterToolProvider(ctx, prov); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Start serves defs on loopback ports over both transports for the length
+// of the test and returns the server with a UTCP client connected over
+// gRPC.
+func Start(tb testing.TB, name string, defs ...Tool) (*Server, utcp.UtcpClientInterface) {
+	tb.Helper()
+	s := New(name, defs...)
+	if _, err := s.ListenHTTP("127.0.0.1:0"); err != nil {
+		tb.Fatalf("utcpmock: listen http: %v", err)
+	}
+	if _, err := s.ListenGRPC("127.0.0.1:0"); err != nil {
+		_ = s.Close()
+		tb.Fatalf("utcpmock: listen grpc: %v", err)
+	}
+	tb.Cleanup(func() { _ = s.Close() })
+	client, err := s.Client(context.Background())
+	if err != nil {
+		tb.Fatalf("utcpmock: client: %v", err)
+	}
+	return s, client
+}
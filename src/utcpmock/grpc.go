@@ -0,0 +1,87 @@
+package utcpmock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/grpcpb"
+)
+
+// grpcService implements the UTCPService the UTCP gRPC transport calls.
+type grpcService struct {
+	grpcpb.UnimplementedUTCPServiceServer
+	s *Server
+}
+
+func registerGRPC(srv *grpc.Server, s *Server) {
+	grpcpb.RegisterUTCPServiceServer(srv, &grpcService{s: s})
+}
+
+func (g *grpcService) GetManual(context.Context, *grpcpb.Empty) (*grpcpb.Manual, error) {
+	m := &grpcpb.Manual{Version: "1.0"}
+	for _, def := range g.s.Tools() {
+		m.Tools = append(m.Tools, &grpcpb.Tool{Name: g.s.qualified(def.Name), Description: def.Description})
+	}
+	return m, nil
+}
+
+func (g *grpcService) CallTool(ctx context.Context, req *grpcpb.ToolCallRequest) (*grpcpb.ToolCallResponse, error) {
+	result, err := g.invoke(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return encodeResult(result)
+}
+
+// CallToolStream sends a slice result one element per message and any
+// other result as a single message.
+func (g *grpcService) CallToolStream(req *grpcpb.ToolCallRequest, stream grpc.ServerStreamingServer[grpcpb.ToolCallResponse]) error {
+	result, err := g.invoke(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	items, ok := result.([]any)
+	if !ok {
+		items = []any{result}
+	}
+	for _, item := range items {
+		resp, err := encodeResult(item)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *grpcService) invoke(ctx context.Context, req *grpcpb.ToolCallRequest) (any, error) {
+	var args map[string]any
+	if req.GetArgsJson() != "" {
+		if err := json.Unmarshal([]byte(req.GetArgsJson()), &args); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid arguments: %v", err)
+		}
+	}
+	result, err := g.s.call(ctx, "grpc", req.GetTool(), args)
+	if err != nil {
+		if errors.Is(err, ErrUnknownTool) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return result, nil
+}
+
+func encodeResult(v any) (*grpcpb.ToolCallResponse, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encode result: %v", err)
+	}
+	return &grpcpb.ToolCallResponse{ResultJson: string(raw)}, nil
+}
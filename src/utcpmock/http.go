@@ -0,0 +1,130 @@
+package utcpmock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// manual is the UTCP manual served on discovery.
+type manual struct {
+	Version string       `json:"version"`
+	Tools   []manualTool `json:"tools"`
+}
+
+type manualTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Inputs      any    `json:"inputs"`
+}
+
+func (s *Server) manual() manual {
+	m := manual{Version: "1.0"}
+	for _, def := range s.Tools() {
+		m.Tools = append(m.Tools, manualTool{Name: s.qualified(def.Name), Description: def.Description, Inputs: def.Inputs})
+	}
+	return m
+}
+
+// ServeHTTP implements the HTTP transport. A request without body or query
+// is discovery and gets the manual. A body of the form {"tool": ..., "args":
+// ...} calls the named tool. The UTCP HTTP transport sends only the
+// arguments, so any other request goes to the one tool whose input schema
+// accepts them; a tool without arguments is therefore reachable only over
+// gRPC or with an explicit body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(raw) == 0 && r.URL.RawQuery == "" {
+		writeJSON(w, http.StatusOK, s.manual())
+		return
+	}
+
+	args := map[string]any{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+	} else {
+		for key, values := range r.URL.Query() {
+			args[key] = values[0]
+		}
+	}
+
+	name, explicit := args["tool"].(string)
+	if explicit {
+		args, _ = args["args"].(map[string]any)
+	} else if name, err = s.toolFor(args); err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, ErrAmbiguousCall) {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+
+	result, err := s.call(r.Context(), "http", name, args)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrUnknownTool):
+			status = http.StatusNotFound
+		case errors.Is(err, context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// toolFor picks the tool whose input schema accepts args: every argument is
+// a declared property (when the schema declares any) and every required
+// property is present.
+func (s *Server) toolFor(args map[string]any) (string, error) {
+	var matches []string
+	for _, def := range s.Tools() {
+		if accepts(def, args) {
+			matches = append(matches, def.Name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.New("no tool accepts these arguments")
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%w: %v", ErrAmbiguousCall, matches)
+}
+
+func accepts(def Tool, args map[string]any) bool {
+	if len(def.Inputs.Properties) == 0 && len(def.Inputs.Required) == 0 {
+		return false
+	}
+	if len(def.Inputs.Properties) > 0 {
+		for key := range args {
+			if _, ok := def.Inputs.Properties[key]; !ok {
+				return false
+			}
+		}
+	}
+	for _, key := range def.Inputs.Required {
+		if _, ok := args[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
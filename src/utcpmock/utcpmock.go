@@ -0,0 +1,301 @@
+// Package utcpmock serves declaratively defined UTCP tools over HTTP and
+// gRPC for tests and demos. It logs every request and can inject latency
+// and errors per tool.
+package utcpmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+var (
+	// ErrUnknownTool is returned for calls of a tool the server does not
+	// define.
+	ErrUnknownTool = errors.New("unknown tool")
+	// ErrAmbiguousCall is returned over HTTP when bare arguments match
+	// more than one tool.
+	ErrAmbiguousCall = errors.New("arguments match more than one tool")
+)
+
+// Tool is one tool the server exposes. Handler computes the result; when it
+// is nil the call returns Result.
+type Tool struct {
+	// Name is the tool name without the provider prefix, e.g. "echo" or
+	// "math.add".
+	Name        string
+	Description string
+	Inputs      tools.ToolInputOutputSchema
+	Handler     func(ctx context.Context, args map[string]any) (any, error)
+	Result      any
+}
+
+// Fault is a failure injected into calls of a tool.
+type Fault struct {
+	// Latency delays the call before it runs.
+	Latency time.Duration
+	// Err, when set, fails the call instead of running the tool.
+	Err error
+	// Times limits the fault to the next Times calls; zero applies it to
+	// every call until ClearFaults.
+	Times int
+}
+
+// Request is one logged tool call.
+type Request struct {
+	Transport string // "http" or "grpc"
+	Tool      string
+	Args      map[string]any
+	Result    any
+	Err       error
+	At        time.Time
+}
+
+// Server is a mock UTCP provider. Its zero value is not usable; create one
+// with New.
+type Server struct {
+	name string
+
+	mu       sync.Mutex
+	tools    map[string]Tool
+	faults   map[string]*Fault
+	requests []Request
+
+	httpServer *http.Server
+	httpAddr   string
+	grpcServer *grpc.Server
+	grpcAddr   string
+}
+
+// New returns a server for provider name exposing tools. Clients see the
+// tools qualified by name, e.g. "mock.echo".
+func New(name string, defs ...Tool) *Server {
+	s := &Server{
+		name:   name,
+		tools:  make(map[string]Tool),
+		faults: make(map[string]*Fault),
+	}
+	for _, def := range defs {
+		s.Handle(def)
+	}
+	return s
+}
+
+// Name returns the provider name.
+func (s *Server) Name() string { return s.name }
+
+// Handle adds def, replacing any tool of the same name.
+func (s *Server) Handle(def Tool) {
+	if def.Inputs.Type == "" {
+		def.Inputs.Type = "object"
+	}
+	s.mu.Lock()
+	s.tools[def.Name] = def
+	s.mu.Unlock()
+}
+
+// Inject makes calls of the named tool fail or slow down as f describes.
+// The name "*" applies f to every tool without a fault of its own.
+func (s *Server) Inject(name string, f Fault) {
+	s.mu.Lock()
+	s.faults[s.baseName(name)] = &f
+	s.mu.Unlock()
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	s.faults = make(map[string]*Fault)
+	s.mu.Unlock()
+}
+
+// Requests returns the calls served so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CallsTo returns the logged calls of the named tool.
+func (s *Server) CallsTo(name string) []Request {
+	name = s.baseName(name)
+	var out []Request
+	for _, req := range s.Requests() {
+		if req.Tool == name {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+// Reset clears the request log.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.requests = nil
+	s.mu.Unlock()
+}
+
+// Tools returns the tool definitions sorted by name.
+func (s *Server) Tools() []Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Tool, 0, len(s.tools))
+	for _, def := range s.tools {
+		out = append(out, def)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ListenHTTP serves the HTTP transport on addr (":0" picks a port) and
+// returns the provider URL.
+func (s *Server) ListenHTTP(addr string) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	srv := &http.Server{Handler: s}
+	s.mu.Lock()
+	s.httpServer = srv
+	s.httpAddr = lis.Addr().String()
+	s.mu.Unlock()
+	go func() { _ = srv.Serve(lis) }()
+	return s.HTTPURL(), nil
+}
+
+// HTTPURL returns the provider URL of the HTTP transport, or "" before
+// ListenHTTP.
+func (s *Server) HTTPURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.httpAddr == "" {
+		return ""
+	}
+	return "http://" + loopback(s.httpAddr) + "/tools"
+}
+
+// ListenGRPC serves the gRPC transport on addr and returns the address it
+// listens on.
+func (s *Server) ListenGRPC(addr string) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	srv := grpc.NewServer()
+	registerGRPC(srv, s)
+	s.mu.Lock()
+	s.grpcServer = srv
+	s.grpcAddr = lis.Addr().String()
+	s.mu.Unlock()
+	go func() { _ = srv.Serve(lis) }()
+	return lis.Addr().String(), nil
+}
+
+// Close stops both transports.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	httpServer, grpcServer := s.httpServer, s.grpcServer
+	s.httpServer, s.grpcServer = nil, nil
+	s.mu.Unlock()
+	var err error
+	if httpServer != nil {
+		err = httpServer.Close()
+	}
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+	return err
+}
+
+// call runs a tool with its fault and logs the request.
+func (s *Server) call(ctx context.Context, transport, name string, args map[string]any) (any, error) {
+	name = s.baseName(name)
+	s.mu.Lock()
+	def, ok := s.tools[name]
+	fault := s.takeFault(name)
+	s.mu.Unlock()
+
+	var result any
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	default:
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		if err == nil && fault.Err != nil {
+			err = fault.Err
+		}
+		if err == nil {
+			if def.Handler != nil {
+				result, err = def.Handler(ctx, args)
+			} else {
+				result = def.Result
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Transport: transport, Tool: name, Args: args, Result: result, Err: err, At: time.Now()})
+	s.mu.Unlock()
+	return result, err
+}
+
+// takeFault returns the fault for name and uses up one of its calls. The
+// caller holds s.mu.
+func (s *Server) takeFault(name string) Fault {
+	key := name
+	f, ok := s.faults[key]
+	if !ok {
+		key = "*"
+		if f, ok = s.faults[key]; !ok {
+			return Fault{}
+		}
+	}
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			delete(s.faults, key)
+		}
+	}
+	return *f
+}
+
+// baseName strips the provider prefix clients add to tool names.
+func (s *Server) baseName(name string) string {
+	return strings.TrimPrefix(name, s.name+".")
+}
+
+// qualified prefixes a tool name with the provider name, so that clients
+// keep dotted names such as "math.add" intact.
+func (s *Server) qualified(name string) string {
+	return s.name + "." + name
+}
+
+// loopback rewrites a wildcard listen address to 127.0.0.1, which the UTCP
+// HTTP transport accepts without TLS.
+func loopback(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
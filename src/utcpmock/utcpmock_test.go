@@ -0,0 +1,124 @@
+package utcpmock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/universal-tool-calling-protocol/go-utcp/src/tools"
+)
+
+func mockTools() []Tool {
+	return []Tool{
+		{
+			Name:   "echo",
+			Inputs: tools.ToolInputOutputSchema{Properties: map[string]any{"message": map[string]any{"type": "string"}}, Required: []string{"message"}},
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				return map[string]any{"result": args["message"]}, nil
+			},
+		},
+		{
+			Name:   "math.add",
+			Inputs: tools.ToolInputOutputSchema{Properties: map[string]any{"a": map[string]any{}, "b": map[string]any{}}, Required: []string{"a", "b"}},
+			Handler: func(_ context.Context, args map[string]any) (any, error) {
+				return args["a"].(float64) + args["b"].(float64), nil
+			},
+		},
+		{Name: "timestamp", Result: "2026-01-02T03:04:05Z"},
+	}
+}
+
+func TestGRPCCallsAreRoutedAndLogged(t *testing.T) {
+	s, client := Start(t, "mock", mockTools()...)
+	ctx := context.Background()
+
+	got, err := client.CallTool(ctx, "mock.math.add", map[string]any{"a": 2, "b": 3})
+	if err != nil || got != 5.0 {
+		t.Fatalf("math.add = %v, %v", got, err)
+	}
+	if got, err := client.CallTool(ctx, "mock.timestamp", nil); err != nil || got != "2026-01-02T03:04:05Z" {
+		t.Fatalf("timestamp = %v, %v", got, err)
+	}
+	reqs := s.CallsTo("mock.math.add")
+	if len(reqs) != 1 || reqs[0].Transport != "grpc" || reqs[0].Args["a"] != 2.0 {
+		t.Fatalf("unexpected log: %+v", reqs)
+	}
+	if len(s.Requests()) != 2 {
+		t.Fatalf("expected 2 logged requests, got %d", len(s.Requests()))
+	}
+}
+
+func TestHTTPDiscoveryAndArgumentRouting(t *testing.T) {
+	s := New("mock", mockTools()...)
+	if _, err := s.ListenHTTP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	client, err := s.Client(ctx)
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	got, err := client.CallTool(ctx, "mock.echo", map[string]any{"message": "hi"})
+	if err != nil || got.(map[string]any)["result"] != "hi" {
+		t.Fatalf("echo = %v, %v", got, err)
+	}
+
+	body, _ := json.Marshal(map[string]any{"tool": "timestamp"})
+	resp, err := http.Post(s.HTTPURL(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(s.CallsTo("timestamp")) != 1 {
+		t.Fatalf("explicit call: status %d, log %+v", resp.StatusCode, s.Requests())
+	}
+
+	resp, err = http.Post(s.HTTPURL(), "application/json", strings.NewReader(`{"unknown":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unmatched arguments: status %d", resp.StatusCode)
+	}
+}
+
+func TestInjectedFaults(t *testing.T) {
+	s, client := Start(t, "mock", mockTools()...)
+	ctx := context.Background()
+
+	boom := errors.New("backend down")
+	s.Inject("mock.echo", Fault{Err: boom, Times: 1})
+	if _, err := client.CallTool(ctx, "mock.echo", map[string]any{"message": "x"}); err == nil || !strings.Contains(err.Error(), "backend down") {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+	if _, err := client.CallTool(ctx, "mock.echo", map[string]any{"message": "x"}); err != nil {
+		t.Fatalf("fault should apply once: %v", err)
+	}
+	if reqs := s.CallsTo("echo"); len(reqs) != 2 || !errors.Is(reqs[0].Err, boom) {
+		t.Fatalf("unexpected log: %+v", reqs)
+	}
+
+	s.Inject("*", Fault{Latency: 50 * time.Millisecond})
+	start := time.Now()
+	if _, err := client.CallTool(ctx, "mock.timestamp", nil); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("latency not injected")
+	}
+	s.ClearFaults()
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	s.Inject("timestamp", Fault{Latency: time.Second})
+	if _, err := client.CallTool(timeout, "mock.timestamp", nil); err == nil {
+		t.Fatal("expected the caller's deadline to cut the injected latency short")
+	}
+}
@@ -0,0 +1,381 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/memory/session"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of an
+	// outgoing space delivery. Receivers check it with
+	// HMACSHA256(SignatureHeader, "sha256=", secret).
+	SignatureHeader = "X-Lattice-Signature-256"
+	// DefaultBatchSize caps the events in one space delivery.
+	DefaultBatchSize = 50
+	// DefaultFlushInterval is how long an event waits for its batch to fill.
+	DefaultFlushInterval = 2 * time.Second
+	// DefaultDeliveryTimeout bounds one delivery attempt.
+	DefaultDeliveryTimeout = 10 * time.Second
+	// AllTenants as SpaceHook.Tenant fires a hook for every tenant's writes.
+	AllTenants = "*"
+	// deliveryAttempts is how often a batch is sent before it is dropped.
+	deliveryAttempts = 3
+)
+
+// SpaceHook posts the records written to matching shared spaces to URL.
+type SpaceHook struct {
+	Name string
+	URL  string
+	// Spaces lists the spaces that fire the hook. A trailing "*" matches a
+	// prefix, e.g. "team:*"; an empty list matches every shared space.
+	Spaces []string
+	// Tenant selects whose writes fire the hook: only untenanted records
+	// when empty, one tenant's records when set, and every tenant's with
+	// AllTenants.
+	Tenant string
+	// Secret, when set, signs every delivery under SignatureHeader.
+	Secret string
+	// BatchSize defaults to DefaultBatchSize; FlushInterval to
+	// DefaultFlushInterval.
+	BatchSize     int
+	FlushInterval time.Duration
+	// Timeout bounds one delivery attempt; DefaultDeliveryTimeout when zero.
+	Timeout time.Duration
+}
+
+// SpaceEvent is one record in a space delivery.
+type SpaceEvent struct {
+	Space     string         `json:"space"`
+	Tenant    string         `json:"tenant,omitempty"`
+	ID        int64          `json:"id,omitempty"`
+	Seq       uint64         `json:"seq,omitempty"`
+	Writer    string         `json:"writer,omitempty"`
+	Content   string         `json:"content"`
+	Source    string         `json:"source,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// SpaceDelivery is the JSON body POSTed to a SpaceHook. ID is also sent as
+// X-Webhook-Id, so a Router on the receiving side drops retried deliveries.
+type SpaceDelivery struct {
+	ID     string       `json:"id"`
+	Hook   string       `json:"hook"`
+	SentAt time.Time    `json:"sent_at"`
+	Events []SpaceEvent `json:"events"`
+}
+
+// SpaceNotifier batches shared-space writes and delivers them to the
+// configured hooks. Install Observe as SessionMemory.OnSpaceWrite:
+//
+//	notifier, _ := webhooks.NewSpaceNotifier(webhooks.SpaceHook{URL: url, Spaces: []string{"team:design"}})
+//	mem.OnSpaceWrite = notifier.Observe
+//	defer notifier.Close(ctx)
+type SpaceNotifier struct {
+	// Client sends deliveries; http.DefaultClient when nil.
+	Client *http.Client
+	// OnError, when set, is called for every batch that could not be
+	// delivered; errors are logged otherwise.
+	OnError func(hook string, err error)
+
+	hooks  []*spaceHook
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+}
+
+type spaceHook struct {
+	SpaceHook
+	mu      sync.Mutex
+	pending []SpaceEvent
+	timer   *time.Timer
+}
+
+// NewSpaceNotifier validates hooks and returns a notifier for them.
+func NewSpaceNotifier(hooks ...SpaceHook) (*SpaceNotifier, error) {
+	n := &SpaceNotifier{}
+	for _, h := range hooks {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhooks: space hook %q: invalid URL %q", h.Name, h.URL)
+		}
+		if h.Name == "" {
+			h.Name = h.URL
+		}
+		if h.BatchSize <= 0 {
+			h.BatchSize = DefaultBatchSize
+		}
+		if h.FlushInterval <= 0 {
+			h.FlushInterval = DefaultFlushInterval
+		}
+		if h.Timeout <= 0 {
+			h.Timeout = DefaultDeliveryTimeout
+		}
+		n.hooks = append(n.hooks, &spaceHook{SpaceHook: h})
+	}
+	return n, nil
+}
+
+// Observe queues rec for every hook watching its space and tenant. A
+// record without a tenant of its own belongs to the tenant on ctx. A full
+// batch is
+// delivered in the background right away; others are delivered once
+// their FlushInterval passes. Observe never blocks on the network.
+func (n *SpaceNotifier) Observe(ctx context.Context, rec model.MemoryRecord) {
+	n.mu.Lock()
+	closed := n.closed
+	n.mu.Unlock()
+	if closed {
+		return
+	}
+	space := rec.Space
+	if space == "" {
+		space = rec.SessionID
+	}
+	ev := newSpaceEvent(space, rec)
+	if ev.Tenant == "" {
+		ev.Tenant = model.TenantFromContext(ctx)
+	}
+	for _, h := range n.hooks {
+		if !h.matches(space) || (h.Tenant != AllTenants && h.Tenant != ev.Tenant) {
+			continue
+		}
+		h.mu.Lock()
+		h.pending = append(h.pending, ev)
+		switch {
+		case len(h.pending) >= h.BatchSize:
+			batch := h.take()
+			n.send(h, batch)
+		case h.timer == nil:
+			h.timer = time.AfterFunc(h.FlushInterval, func() {
+				h.mu.Lock()
+				batch := h.take()
+				h.mu.Unlock()
+				n.send(h, batch)
+			})
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Flush delivers every pending batch and waits for all deliveries,
+// including those already in flight, to finish.
+func (n *SpaceNotifier) Flush(ctx context.Context) error {
+	var errs []error
+	for _, h := range n.hooks {
+		h.mu.Lock()
+		batch := h.take()
+		h.mu.Unlock()
+		if len(batch) > 0 {
+			if err := n.deliver(ctx, h, batch); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", h.Name, err))
+			}
+		}
+	}
+	n.wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close stops accepting events and flushes what is pending.
+func (n *SpaceNotifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+	return n.Flush(ctx)
+}
+
+// take empties the pending batch. The caller holds h.mu.
+func (h *spaceHook) take() []SpaceEvent {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	batch := h.pending
+	h.pending = nil
+	return batch
+}
+
+func (h *spaceHook) matches(space string) bool {
+	if len(h.Spaces) == 0 {
+		return true
+	}
+	for _, pattern := range h.Spaces {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(space, prefix) {
+				return true
+			}
+		} else if pattern == space {
+			return true
+		}
+	}
+	return false
+}
+
+// send delivers batch in the background.
+func (n *SpaceNotifier) send(h *spaceHook, batch []SpaceEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.deliver(context.Background(), h, batch); err != nil {
+			n.fail(h.Name, err)
+		}
+	}()
+}
+
+// deliver POSTs one batch, retrying network errors, 429s and 5xx responses.
+func (n *SpaceNotifier) deliver(ctx context.Context, h *spaceHook, batch []SpaceEvent) error {
+	delivery := SpaceDelivery{ID: deliveryID(), Hook: h.Name, SentAt: time.Now().UTC(), Events: batch}
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err = n.post(ctx, client, h, delivery.ID, body)
+		var status statusError
+		retryable := err != nil && (!errors.As(err, &status) || status.code == http.StatusTooManyRequests || status.code >= 500)
+		if !retryable || attempt == deliveryAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (n *SpaceNotifier) post(ctx context.Context, client *http.Client, h *spaceHook, id string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", id)
+	if h.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return statusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+func (n *SpaceNotifier) fail(hook string, err error) {
+	if n.OnError != nil {
+		n.OnError(hook, err)
+		return
+	}
+	log.Printf("webhooks: space hook %s: delivery failed: %v", hook, err)
+}
+
+type statusError struct{ code int }
+
+func (e statusError) Error() string { return fmt.Sprintf("receiver answered %d", e.code) }
+
+func newSpaceEvent(space string, rec model.MemoryRecord) SpaceEvent {
+	meta := model.DecodeMetadata(rec.Metadata)
+	created := rec.CreatedAt
+	if created.IsZero() {
+		created = time.Now().UTC()
+	}
+	return SpaceEvent{
+		Space:     space,
+		Tenant:    model.RecordTenant(rec),
+		ID:        rec.ID,
+		Seq:       session.SeqOf(rec),
+		Writer:    model.StringFromAny(meta[session.MetaWriter]),
+		Content:   rec.Content,
+		Source:    rec.Source,
+		Metadata:  meta,
+		CreatedAt: created,
+	}
+}
+
+func deliveryID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// SpaceHookConfig is the JSON form of a SpaceHook.
+type SpaceHookConfig struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Spaces []string `json:"spaces,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+	// SecretEnv names the secret that signs deliveries.
+	SecretEnv     string `json:"secret_env,omitempty"`
+	BatchSize     int    `json:"batch_size,omitempty"`
+	FlushInterval string `json:"flush_interval,omitempty"`
+	Timeout       string `json:"timeout,omitempty"`
+}
+
+// LoadSpaceHooks reads a JSON array of SpaceHookConfig from path,
+// resolving secrets through the secrets package.
+func LoadSpaceHooks(path string) ([]SpaceHook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var configs []SpaceHookConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("webhooks: parse %s: %w", path, err)
+	}
+	hooks := make([]SpaceHook, 0, len(configs))
+	for _, c := range configs {
+		h := SpaceHook{Name: c.Name, URL: c.URL, Spaces: c.Spaces, Tenant: c.Tenant, BatchSize: c.BatchSize}
+		if c.FlushInterval != "" {
+			if h.FlushInterval, err = time.ParseDuration(c.FlushInterval); err != nil {
+				return nil, fmt.Errorf("webhooks: space hook %q: flush_interval: %w", c.Name, err)
+			}
+		}
+		if c.Timeout != "" {
+			if h.Timeout, err = time.ParseDuration(c.Timeout); err != nil {
+				return nil, fmt.Errorf("webhooks: space hook %q: timeout: %w", c.Name, err)
+			}
+		}
+		if c.SecretEnv != "" {
+			if h.Secret = secrets.Lookup(c.SecretEnv); h.Secret == "" {
+				return nil, fmt.Errorf("webhooks: space hook %q: secret %s is not set", c.Name, c.SecretEnv)
+			}
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, nil
+}
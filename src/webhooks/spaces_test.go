@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Protocol-Lattice/go-agent/src/memory"
+)
+
+func TestSpaceNotifierBatchesAndSignsSpaceWrites(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		deliveries []SpaceDelivery
+		verify     = HMACSHA256(SignatureHeader, "sha256=", "s3cret")
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := verify(r, body); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var d SpaceDelivery
+		if err := json.Unmarshal(body, &d); err != nil || r.Header.Get("X-Webhook-Id") != d.ID {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		deliveries = append(deliveries, d)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	notifier, err := NewSpaceNotifier(SpaceHook{
+		Name:          "design",
+		URL:           receiver.URL,
+		Spaces:        []string{"team:*"},
+		Secret:        "s3cret",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier.OnError = func(hook string, err error) { t.Errorf("%s: %v", hook, err) }
+
+	ctx := context.Background()
+	mem := memory.NewSessionMemory(memory.NewMemoryBankWithStore(memory.NewInMemoryStore()), 8)
+	mem.OnSpaceWrite = notifier.Observe
+	for _, space := range []string{"team:design", "ops"} {
+		if err := mem.Spaces.Grant(space, "agent-a", memory.SpaceRoleWriter, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shared := memory.NewSharedSession(mem, "agent-a", "team:design", "ops")
+	for _, write := range []struct{ space, content string }{
+		{"team:design", "Use Postgres for the ledger"},
+		{"agent-a", "private scratch note"},
+		{"ops", "pager rotation updated"},
+		{"team:design", "Ship the API behind a flag"},
+		{"team:design", "Drop the legacy importer"},
+	} {
+		if _, err := shared.StoreLongTo(ctx, write.space, write.content, nil); err != nil {
+			t.Fatalf("store %q: %v", write.content, err)
+		}
+	}
+	if err := notifier.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveries) != 2 {
+		t.Fatalf("expected a full batch and a flushed remainder, got %d deliveries", len(deliveries))
+	}
+	var contents []string
+	for _, d := range deliveries {
+		for _, ev := range d.Events {
+			if ev.Space != "team:design" || ev.Writer != "agent-a" || ev.Seq == 0 {
+				t.Fatalf("unexpected event: %+v", ev)
+			}
+			contents = append(contents, ev.Content)
+		}
+	}
+	// The full batch is sent in the background, so it may arrive second.
+	if len(contents) != 3 || len(deliveries[0].Events)+len(deliveries[1].Events) != 3 || len(deliveries[0].Events) == len(deliveries[1].Events) {
+		t.Fatalf("unexpected batches: %+v", deliveries)
+	}
+}
+
+func TestSpaceNotifierSkipsOtherTenants(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events = map[string][]SpaceEvent{}
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d SpaceDelivery
+		_ = json.NewDecoder(r.Body).Decode(&d)
+		mu.Lock()
+		events[d.Hook] = append(events[d.Hook], d.Events...)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	notifier, err := NewSpaceNotifier(
+		SpaceHook{Name: "acme", URL: receiver.URL, Spaces: []string{"team:*"}, Tenant: "acme"},
+		SpaceHook{Name: "untenanted", URL: receiver.URL, Spaces: []string{"team:*"}},
+		SpaceHook{Name: "all", URL: receiver.URL, Spaces: []string{"team:*"}, Tenant: AllTenants},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	notifier.Observe(ctx, memory.MemoryRecord{Space: "team:x", Content: "acme note", TenantID: "acme"})
+	notifier.Observe(memory.ContextWithTenant(ctx, "globex"), memory.MemoryRecord{Space: "team:x", Content: "globex note"})
+	notifier.Observe(ctx, memory.MemoryRecord{Space: "team:x", Content: "shared note"})
+	if err := notifier.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := events["acme"]; len(got) != 1 || got[0].Content != "acme note" || got[0].Tenant != "acme" {
+		t.Fatalf("acme hook got %+v", got)
+	}
+	if got := events["untenanted"]; len(got) != 1 || got[0].Content != "shared note" {
+		t.Fatalf("untenanted hook got %+v", got)
+	}
+	if got := events["all"]; len(got) != 3 || got[1].Tenant != "globex" {
+		t.Fatalf("all-tenants hook got %+v", got)
+	}
+}
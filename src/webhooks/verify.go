@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBadSignature is returned by verifiers when a delivery is not signed
+// with the route's secret.
+var ErrBadSignature = errors.New("webhooks: bad signature")
+
+// Verifier authenticates a delivery before its body is trusted.
+type Verifier func(r *http.Request, body []byte) error
+
+// HMACSHA256 checks a hex HMAC-SHA256 of the body sent in header, after an
+// optional prefix such as "sha256=".
+func HMACSHA256(header, prefix, secret string) Verifier {
+	return func(r *http.Request, body []byte) error {
+		sig, ok := strings.CutPrefix(r.Header.Get(header), prefix)
+		if !ok || !validMAC(secret, body, sig) {
+			return ErrBadSignature
+		}
+		return nil
+	}
+}
+
+// GitHub verifies the X-Hub-Signature-256 header.
+func GitHub(secret string) Verifier {
+	return HMACSHA256("X-Hub-Signature-256", "sha256=", secret)
+}
+
+// PagerDuty verifies the X-PagerDuty-Signature header, which lists one
+// "v1=" signature per active secret while secrets rotate.
+func PagerDuty(secret string) Verifier {
+	return func(r *http.Request, body []byte) error {
+		for _, part := range strings.Split(r.Header.Get("X-PagerDuty-Signature"), ",") {
+			if sig, ok := strings.CutPrefix(strings.TrimSpace(part), "v1="); ok && validMAC(secret, body, sig) {
+				return nil
+			}
+		}
+		return ErrBadSignature
+	}
+}
+
+// DefaultStripeTolerance bounds the age of a Stripe signature timestamp.
+const DefaultStripeTolerance = 5 * time.Minute
+
+// Stripe verifies the Stripe-Signature header ("t=<unix>,v1=<hex>") and
+// rejects timestamps older than tolerance to stop replays.
+func Stripe(secret string, tolerance time.Duration) Verifier {
+	if tolerance <= 0 {
+		tolerance = DefaultStripeTolerance
+	}
+	return func(r *http.Request, body []byte) error {
+		var (
+			ts   string
+			sigs []string
+		)
+		for _, part := range strings.Split(r.Header.Get("Stripe-Signature"), ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "t":
+				ts = v
+			case "v1":
+				sigs = append(sigs, v)
+			}
+		}
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return ErrBadSignature
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside %s tolerance", ErrBadSignature, tolerance)
+		}
+		signed := append([]byte(ts+"."), body...)
+		for _, sig := range sigs {
+			if validMAC(secret, signed, sig) {
+				return nil
+			}
+		}
+		return ErrBadSignature
+	}
+}
+
+// BearerToken accepts deliveries carrying "Authorization: Bearer <token>",
+// for senders that cannot sign payloads.
+func BearerToken(token string) Verifier {
+	return func(r *http.Request, _ []byte) error {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return ErrBadSignature
+		}
+		return nil
+	}
+}
+
+// VerifierFor builds the verifier for a provider name: github, stripe,
+// pagerduty or bearer. An empty or "none" provider accepts unsigned
+// deliveries, so hosts with authenticated callers should refuse such routes
+// unless they set AllowUnsigned.
+func VerifierFor(provider, secret string) (Verifier, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" || provider == "none" {
+		return nil, nil
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("webhooks: %s verification needs a secret", provider)
+	}
+	switch provider {
+	case "github":
+		return GitHub(secret), nil
+	case "stripe":
+		return Stripe(secret, 0), nil
+	case "pagerduty":
+		return PagerDuty(secret), nil
+	case "bearer":
+		return BearerToken(secret), nil
+	}
+	return nil, fmt.Errorf("webhooks: unknown provider %q", provider)
+}
+
+func validMAC(secret string, body []byte, sig string) bool {
+	want, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
@@ -0,0 +1,444 @@
+// Package webhooks routes inbound webhook deliveries (GitHub, Stripe,
+// PagerDuty, ...) to agent runs. Each Route renders a session ID and a prompt
+// from the event, so the payload reaches the agent as context.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Protocol-Lattice/go-agent/src/cache"
+	"github.com/Protocol-Lattice/go-agent/src/secrets"
+)
+
+const (
+	// DefaultAgent is the agent name used by routes that do not set one.
+	DefaultAgent = "default"
+	// DefaultPrompt hands the agent the event kind and the raw payload.
+	DefaultPrompt = "A {{.Kind}} webhook event was delivered to {{.Route}}.\n\nPayload:\n{{trunc 16000 .Raw}}"
+	// DefaultTimeout bounds a triggered run.
+	DefaultTimeout = 5 * time.Minute
+	// DefaultMaxBodyBytes caps accepted payloads.
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// Runner is the part of *agent.Agent a route needs.
+type Runner interface {
+	Generate(ctx context.Context, sessionID, userInput string) (any, error)
+}
+
+// Event is the data session and prompt templates are rendered with.
+type Event struct {
+	Route string
+	// Kind is the provider's event type, e.g. "push", "invoice.paid" or
+	// "incident.triggered".
+	Kind string
+	// ID is the delivery or event ID when the provider sends one; repeated
+	// IDs are acknowledged without running the agent again.
+	ID string
+	// Payload is the decoded JSON body, nil when the body is not JSON.
+	Payload  any
+	Raw      string
+	Headers  http.Header
+	Query    url.Values
+	Received time.Time
+}
+
+// Route maps one endpoint to an agent run.
+type Route struct {
+	Name string
+	// Path is matched exactly, e.g. "/hooks/github".
+	Path string
+	// Agent names the runner; empty means DefaultAgent.
+	Agent string
+	// Session and Prompt are text/template sources rendered with an Event,
+	// e.g. "gh:{{.Payload.repository.full_name}}". Prompt defaults to
+	// DefaultPrompt.
+	Session string
+	Prompt  string
+	// Verify authenticates deliveries; nil accepts everything.
+	Verify Verifier
+	// AllowUnsigned marks a route without Verify as open on purpose, for
+	// hosts that refuse unsigned routes by default.
+	AllowUnsigned bool
+	// Events, when set, lists the event kinds that trigger a run. Other
+	// deliveries are acknowledged and ignored.
+	Events []string
+	// Wait holds the request open until the run finishes and returns its
+	// output. By default deliveries get 202 Accepted and run in the
+	// background, since most providers time out after a few seconds.
+	Wait    bool
+	Timeout time.Duration
+	// Before runs ahead of the agent, for side effects such as opening a
+	// space for the event; an error fails the delivery so it is retried.
+	Before func(ctx context.Context, ev Event) error
+}
+
+// Run describes a finished triggered run.
+type Run struct {
+	Route   string
+	Event   Event
+	Session string
+	Output  any
+	Err     error
+}
+
+// Router is an http.Handler serving every configured route.
+type Router struct {
+	// MaxBodyBytes caps payload size; defaults to DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// OnRun, when set, is called after every run; errors are logged
+	// otherwise.
+	OnRun func(Run)
+	// Claim, when set, is called with each rendered session before the
+	// run; an error answers the delivery with 409 Conflict. Hosts use it to
+	// keep webhook sessions away from other callers.
+	Claim func(ctx context.Context, route, session string) error
+
+	agents map[string]Runner
+	routes map[string]*route
+	seen   *cache.LRUCache
+	wg     sync.WaitGroup
+}
+
+type route struct {
+	Route
+	session *template.Template
+	prompt  *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"trunc": func(n int, s string) string {
+		if len(s) <= n {
+			return s
+		}
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return s[:n] + "…"
+	},
+}
+
+// NewRouter compiles routes against the named agents.
+func NewRouter(agents map[string]Runner, routes ...Route) (*Router, error) {
+	r := &Router{
+		agents: agents,
+		routes: make(map[string]*route, len(routes)),
+		seen:   cache.NewLRUCache(4096, 24*time.Hour),
+	}
+	for _, rt := range routes {
+		if !strings.HasPrefix(rt.Path, "/") {
+			return nil, fmt.Errorf("webhooks: route %q: path must start with /", rt.Name)
+		}
+		if _, dup := r.routes[rt.Path]; dup {
+			return nil, fmt.Errorf("webhooks: duplicate route path %s", rt.Path)
+		}
+		if rt.Name == "" {
+			rt.Name = rt.Path
+		}
+		if rt.Agent == "" {
+			rt.Agent = DefaultAgent
+		}
+		if agents[rt.Agent] == nil {
+			return nil, fmt.Errorf("webhooks: route %q: unknown agent %q", rt.Name, rt.Agent)
+		}
+		if strings.TrimSpace(rt.Session) == "" {
+			return nil, fmt.Errorf("webhooks: route %q: session template is required", rt.Name)
+		}
+		if rt.Prompt == "" {
+			rt.Prompt = DefaultPrompt
+		}
+		if rt.Timeout <= 0 {
+			rt.Timeout = DefaultTimeout
+		}
+		compiled := &route{Route: rt}
+		var err error
+		if compiled.session, err = template.New("session").Funcs(templateFuncs).Parse(rt.Session); err != nil {
+			return nil, fmt.Errorf("webhooks: route %q: %w", rt.Name, err)
+		}
+		if compiled.prompt, err = template.New("prompt").Funcs(templateFuncs).Parse(rt.Prompt); err != nil {
+			return nil, fmt.Errorf("webhooks: route %q: %w", rt.Name, err)
+		}
+		r.routes[rt.Path] = compiled
+	}
+	return r, nil
+}
+
+// Paths lists the configured route paths, for mounting on a mux.
+func (r *Router) Paths() []string {
+	paths := make([]string, 0, len(r.routes))
+	for p := range r.routes {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// Reserved reports whether session could be rendered by a route: it starts
+// with the literal text ahead of the first action of a session template, or
+// equals a template without actions.
+func (r *Router) Reserved(session string) bool {
+	for _, rt := range r.routes {
+		// Rendered sessions are trimmed, so leading space never matches.
+		prefix, _, templated := strings.Cut(strings.TrimLeftFunc(rt.Session, unicode.IsSpace), "{{")
+		if !templated && session == strings.TrimSpace(prefix) ||
+			templated && prefix != "" && strings.HasPrefix(session, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait blocks until background runs have finished.
+func (r *Router) Wait() { r.wg.Wait() }
+
+// ServeHTTP handles one delivery.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt := r.routes[req.URL.Path]
+	if rt == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no webhook route for " + req.URL.Path})
+		return
+	}
+	if req.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "webhooks accept POST only"})
+		return
+	}
+	limit := r.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if int64(len(body)) > limit {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": fmt.Sprintf("payload exceeds %d bytes", limit)})
+		return
+	}
+	if rt.Verify != nil {
+		if err := rt.Verify(req, body); err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	ev := newEvent(rt.Name, req, body)
+	if len(rt.Events) > 0 && !slices.Contains(rt.Events, ev.Kind) {
+		writeJSON(w, http.StatusOK, map[string]any{"ignored": true, "kind": ev.Kind})
+		return
+	}
+	var seenKey string
+	if ev.ID != "" {
+		seenKey = rt.Path + "\x00" + ev.ID
+		if _, dup := r.seen.Get(seenKey); dup {
+			writeJSON(w, http.StatusOK, map[string]any{"duplicate": true, "id": ev.ID})
+			return
+		}
+	}
+
+	session, err := render(rt.session, ev)
+	if err == nil && (session == "" || strings.Contains(session, "<no value>")) {
+		err = errors.New("session template rendered empty or missing fields")
+	}
+	var prompt string
+	if err == nil {
+		prompt, err = render(rt.prompt, ev)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if r.Claim != nil {
+		if err := r.Claim(req.Context(), rt.Name, session); err != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error(), "session": session})
+			return
+		}
+	}
+
+	if rt.Before != nil {
+		if err := rt.Before(req.Context(), ev); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	runner := r.agents[rt.Agent]
+	if rt.Wait {
+		ctx, cancel := context.WithTimeout(req.Context(), rt.Timeout)
+		defer cancel()
+		out, err := runner.Generate(ctx, session, prompt)
+		r.finish(Run{Route: rt.Name, Event: ev, Session: session, Output: out, Err: err})
+		if err != nil {
+			// Not marked as seen, so the provider's retry runs again.
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error(), "session": session})
+			return
+		}
+		if seenKey != "" {
+			r.seen.Set(seenKey, true)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"session": session, "response": fmt.Sprint(out)})
+		return
+	}
+
+	if seenKey != "" {
+		r.seen.Set(seenKey, true)
+	}
+	// The run outlives the request, so detach it from the request's
+	// cancellation but keep its values.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), rt.Timeout)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		out, err := runner.Generate(ctx, session, prompt)
+		r.finish(Run{Route: rt.Name, Event: ev, Session: session, Output: out, Err: err})
+	}()
+	writeJSON(w, http.StatusAccepted, map[string]any{"accepted": true, "session": session, "id": ev.ID})
+}
+
+func (r *Router) finish(run Run) {
+	if r.OnRun != nil {
+		r.OnRun(run)
+		return
+	}
+	if run.Err != nil {
+		log.Printf("webhooks: %s run for session %s failed: %v", run.Route, run.Session, run.Err)
+	}
+}
+
+func newEvent(name string, req *http.Request, body []byte) Event {
+	ev := Event{
+		Route:    name,
+		Raw:      string(body),
+		Headers:  req.Header.Clone(),
+		Query:    req.URL.Query(),
+		Received: time.Now(),
+	}
+	var payload any
+	if json.Unmarshal(body, &payload) == nil {
+		ev.Payload = payload
+	}
+	fields, _ := payload.(map[string]any)
+	str := func(v any) string { s, _ := v.(string); return s }
+
+	// GitHub names the event in headers; Stripe and PagerDuty v3 put it in
+	// the payload.
+	ev.Kind = req.Header.Get("X-GitHub-Event")
+	ev.ID = req.Header.Get("X-GitHub-Delivery")
+	if pd, ok := fields["event"].(map[string]any); ok {
+		if ev.Kind == "" {
+			ev.Kind = str(pd["event_type"])
+		}
+		if ev.ID == "" {
+			ev.ID = str(pd["id"])
+		}
+	}
+	if ev.Kind == "" {
+		ev.Kind = str(fields["type"])
+	}
+	if ev.ID == "" {
+		ev.ID = req.Header.Get("X-Webhook-Id")
+	}
+	if ev.ID == "" && fields["object"] == "event" {
+		ev.ID = str(fields["id"])
+	}
+	if ev.Kind == "" {
+		ev.Kind = "webhook"
+	}
+	return ev
+}
+
+func render(t *template.Template, ev Event) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, ev); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// RouteConfig is the JSON form of a Route.
+type RouteConfig struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Agent   string `json:"agent,omitempty"`
+	Session string `json:"session"`
+	Prompt  string `json:"prompt,omitempty"`
+	// Provider selects signature verification (github, stripe, pagerduty,
+	// bearer); the secret is looked up under SecretEnv.
+	Provider  string   `json:"provider,omitempty"`
+	SecretEnv string   `json:"secret_env,omitempty"`
+	Events    []string `json:"events,omitempty"`
+	Wait      bool     `json:"wait,omitempty"`
+	Timeout   string   `json:"timeout,omitempty"`
+	// AllowUnsigned opts a route without a provider in where unsigned
+	// routes are refused.
+	AllowUnsigned bool `json:"allow_unsigned,omitempty"`
+}
+
+// LoadRoutes reads a JSON array of RouteConfig from path, resolving
+// secrets through the secrets package.
+func LoadRoutes(path string) ([]Route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var configs []RouteConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("webhooks: parse %s: %w", path, err)
+	}
+	routes := make([]Route, 0, len(configs))
+	for _, c := range configs {
+		rt := Route{
+			Name:    c.Name,
+			Path:    c.Path,
+			Agent:   c.Agent,
+			Session: c.Session,
+			Prompt:  c.Prompt,
+			Events:  c.Events,
+			Wait:    c.Wait,
+
+			AllowUnsigned: c.AllowUnsigned,
+		}
+		if c.Timeout != "" {
+			if rt.Timeout, err = time.ParseDuration(c.Timeout); err != nil {
+				return nil, fmt.Errorf("webhooks: route %q: timeout: %w", c.Name, err)
+			}
+		}
+		var secret string
+		if c.SecretEnv != "" {
+			secret = secrets.Lookup(c.SecretEnv)
+		}
+		if rt.Verify, err = VerifierFor(c.Provider, secret); err != nil {
+			return nil, fmt.Errorf("webhooks: route %q: %w", c.Name, err)
+		}
+		routes = append(routes, rt)
+	}
+	return routes, nil
+}
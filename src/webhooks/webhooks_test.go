@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingRunner struct {
+	mu    sync.Mutex
+	calls [][2]string
+	err   error
+}
+
+func (r *recordingRunner) Generate(_ context.Context, session, prompt string) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, [2]string{session, prompt})
+	return "handled " + session, r.err
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestRouterRendersSessionAndPromptFromGitHubEvent(t *testing.T) {
+	runner := &recordingRunner{}
+	router, err := NewRouter(map[string]Runner{DefaultAgent: runner}, Route{
+		Path:    "/hooks/github",
+		Session: "gh:{{.Payload.repository.full_name}}",
+		Prompt:  "{{.Kind}} on {{.Payload.repository.full_name}}: {{json .Payload.pull_request}}",
+		Verify:  GitHub("s3cret"),
+		Events:  []string{"pull_request"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	body := `{"repository":{"full_name":"acme/api"},"pull_request":{"number":7}}`
+	deliver := func(event, delivery, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/hooks/github", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-GitHub-Delivery", delivery)
+		req.Header.Set("X-Hub-Signature-256", signature)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		router.Wait()
+		return rec
+	}
+
+	if rec := deliver("pull_request", "d1", "sha256=deadbeef"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status %d", rec.Code)
+	}
+	good := "sha256=" + sign("s3cret", body)
+	if rec := deliver("push", "d0", good); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "ignored") {
+		t.Fatalf("filtered event: %d %s", rec.Code, rec.Body)
+	}
+	if rec := deliver("pull_request", "d1", good); rec.Code != http.StatusAccepted {
+		t.Fatalf("delivery: status %d %s", rec.Code, rec.Body)
+	}
+	if rec := deliver("pull_request", "d1", good); !strings.Contains(rec.Body.String(), "duplicate") {
+		t.Fatalf("redelivery should be deduplicated: %s", rec.Body)
+	}
+
+	if len(runner.calls) != 1 {
+		t.Fatalf("expected one run, got %v", runner.calls)
+	}
+	if got := runner.calls[0]; got[0] != "gh:acme/api" || got[1] != `pull_request on acme/api: {"number":7}` {
+		t.Fatalf("run = %q", got)
+	}
+}
+
+func TestRouterWaitModeReturnsOutputAndRetriesFailures(t *testing.T) {
+	runner := &recordingRunner{err: errors.New("model down")}
+	router, err := NewRouter(map[string]Runner{"oncall": runner}, Route{
+		Path:    "/hooks/pd",
+		Agent:   "oncall",
+		Session: "incident:{{.Payload.event.data.id}}",
+		Wait:    true,
+	})
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	body := `{"event":{"id":"ev1","event_type":"incident.triggered","data":{"id":"P123"}}}`
+	post := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hooks/pd", strings.NewReader(body)))
+		return rec
+	}
+	if rec := post(); rec.Code != http.StatusBadGateway {
+		t.Fatalf("failed run: status %d", rec.Code)
+	}
+	runner.err = nil
+	rec := post()
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "handled incident:P123") {
+		t.Fatalf("retried run: %d %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(runner.calls[1][1], "incident.triggered") || !strings.Contains(runner.calls[1][1], `"P123"`) {
+		t.Fatalf("default prompt should carry kind and payload: %q", runner.calls[1][1])
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hooks/pd", strings.NewReader(`{"event":{"id":"ev2"}}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing session field: status %d", rec.Code)
+	}
+}
+
+func TestStripeVerifier(t *testing.T) {
+	body := `{"id":"evt_1","object":"event","type":"invoice.paid"}`
+	ts := fmt.Sprint(time.Now().Unix())
+	verify := Stripe("whsec", 0)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Stripe-Signature", "t="+ts+",v1="+sign("whsec", ts+"."+body))
+	if err := verify(req, []byte(body)); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	old := fmt.Sprint(time.Now().Add(-time.Hour).Unix())
+	req.Header.Set("Stripe-Signature", "t="+old+",v1="+sign("whsec", old+"."+body))
+	if err := verify(req, []byte(body)); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("stale signature accepted: %v", err)
+	}
+}
+
+func TestRouterClaimsAndReservesSessions(t *testing.T) {
+	runner := &recordingRunner{}
+	router, err := NewRouter(map[string]Runner{DefaultAgent: runner},
+		Route{Name: "github", Path: "/hooks/github", Session: " gh:{{.Payload.repo}}", Wait: true},
+		Route{Name: "ops", Path: "/hooks/ops", Session: "ops", Wait: true},
+	)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	claimed := map[string]string{"gh:taken": "alice"}
+	router.Claim = func(_ context.Context, route, session string) error {
+		if owner, ok := claimed[session]; ok && owner != route {
+			return errors.New("session belongs to another caller")
+		}
+		claimed[session] = route
+		return nil
+	}
+	deliver := func(repo string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hooks/github", strings.NewReader(`{"repo":"`+repo+`"}`))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := deliver("acme"); code != http.StatusOK || claimed["gh:acme"] != "github" {
+		t.Fatalf("delivery = %d, claims %v", code, claimed)
+	}
+	if code := deliver("taken"); code != http.StatusConflict {
+		t.Fatalf("delivery to another caller's session = %d, want 409", code)
+	}
+	if len(runner.calls) != 1 {
+		t.Fatalf("runner called %d times, want 1", len(runner.calls))
+	}
+
+	for session, want := range map[string]bool{"gh:acme": true, "gh:": true, "ops": true, "ops2": false, "alice": false} {
+		if got := router.Reserved(session); got != want {
+			t.Errorf("Reserved(%q) = %v, want %v", session, got, want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Protocol-Lattice/go-agent/src/memory/model"
+	"github.com/Protocol-Lattice/go-agent/src/models"
 )
 
 // ToolSpec describes how the agent should present a tool to the model.
@@ -13,6 +14,29 @@ type ToolSpec struct {
 	Description string           `json:"description"`
 	InputSchema map[string]any   `json:"input_schema"`
 	Examples    []map[string]any `json:"examples,omitempty"`
+	// Policy controls how executeTool runs the tool: timeout, retries and
+	// whether repeating a call is safe.
+	Policy ToolPolicy `json:"policy,omitzero"`
+}
+
+// ToolPolicy configures how a tool is invoked. The zero value runs the tool
+// once with the caller's deadline.
+type ToolPolicy struct {
+	// Timeout bounds each attempt.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Idempotent marks tools that may safely be called again with the same
+	// arguments. Only idempotent tools are retried.
+	Idempotent bool `json:"idempotent,omitempty"`
+	// MaxAttempts caps attempts for idempotent tools, counting the first;
+	// zero means DefaultToolAttempts.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// Backoff is the wait before the first retry, doubling after that up to
+	// MaxBackoff; zeros mean DefaultToolBackoff and DefaultToolMaxBackoff.
+	Backoff    time.Duration `json:"backoff,omitempty"`
+	MaxBackoff time.Duration `json:"max_backoff,omitempty"`
+	// ReadOnly marks tools that never change state. They are the only tools
+	// an agent in read-only mode will run.
+	ReadOnly bool `json:"read_only,omitempty"`
 }
 
 // ToolRequest captures an invocation request for a tool.
@@ -33,6 +57,15 @@ type Tool interface {
 	Invoke(ctx context.Context, req ToolRequest) (ToolResponse, error)
 }
 
+// StreamingTool is a Tool that can report output as it is produced. The
+// agent streams it when the caller asks for "stream": true or is listening
+// for tool events, reporting each delta as a ToolEventChunk. InvokeStream
+// must stop and close its channel when ctx is done.
+type StreamingTool interface {
+	Tool
+	InvokeStream(ctx context.Context, req ToolRequest) (<-chan models.StreamChunk, error)
+}
+
 // ToolCatalog maintains an ordered set of tools and provides lookup by name.
 type ToolCatalog interface {
 	Register(tool Tool) error
@@ -48,6 +81,14 @@ type SubAgent interface {
 	Run(ctx context.Context, input string) (string, error)
 }
 
+// StreamingSubAgent is a SubAgent that reports output as it is produced, so
+// the coordinator can surface progress from slow delegations. RunStream
+// must stop and close its channel when ctx is done.
+type StreamingSubAgent interface {
+	SubAgent
+	RunStream(ctx context.Context, input string) (<-chan models.StreamChunk, error)
+}
+
 // SubAgentDirectory stores sub-agents by name while preserving insertion order.
 type SubAgentDirectory interface {
 	Register(subAgent SubAgent) error
@@ -57,10 +98,11 @@ type SubAgentDirectory interface {
 
 // AgentState represents the serializable state of an agent for checkpointing.
 type AgentState struct {
-	SystemPrompt string                          `json:"system_prompt"`
-	ShortTerm    map[string][]model.MemoryRecord `json:"short_term"`
-	JoinedSpaces []string                        `json:"joined_spaces,omitempty"`
-	Timestamp    time.Time                       `json:"timestamp"`
+	SystemPrompt  string                          `json:"system_prompt"`
+	ShortTerm     map[string][]model.MemoryRecord `json:"short_term"`
+	JoinedSpaces  []string                        `json:"joined_spaces,omitempty"`
+	ToolProviders map[string][]string             `json:"tool_providers,omitempty"`
+	Timestamp     time.Time                       `json:"timestamp"`
 }
 
 // SafetyPolicy defines an interface for validating LLM responses.